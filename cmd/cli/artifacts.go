@@ -0,0 +1,110 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(checkArtifactsCommand())
+}
+
+func checkArtifactsCommand() *cli.Command {
+	var programPath string
+	var programAllowlist string
+	return &cli.Command{
+		Name:      "check-artifacts",
+		Usage:     "checks that artifacts were produced by the same VM version, program and layout, from their metadata",
+		ArgsUsage: "<artifact>...",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "program",
+				Usage:       "compiled cairo zero program the artifacts must have been produced by, with this VM version",
+				Required:    false,
+				Destination: &programPath,
+			},
+			&cli.StringFlag{
+				Name:        "program_allowlist",
+				Usage:       "file of the program hashes the artifacts may have been produced by, one per line",
+				Required:    false,
+				Destination: &programAllowlist,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			paths := ctx.Args().Slice()
+			if len(paths) == 0 {
+				return fmt.Errorf("no artifact given")
+			}
+			metadata := make([]runner.ArtifactMetadata, len(paths))
+			for i, path := range paths {
+				var ok bool
+				var err error
+				metadata[i], ok, err = runner.ReadArtifactMetadata(path)
+				if err != nil {
+					return err
+				}
+				if !ok {
+					return fmt.Errorf("%s has no metadata, expected at %s", path, runner.ArtifactMetadataPath(path))
+				}
+			}
+			for i := 1; i < len(metadata); i++ {
+				if err := metadata[0].CheckCompatible(&metadata[i]); err != nil {
+					return err
+				}
+			}
+			if programPath != "" {
+				program, err := loadZeroProgram(programPath)
+				if err != nil {
+					return err
+				}
+				if err := metadata[0].CheckProgram(program); err != nil {
+					return err
+				}
+			}
+			if programAllowlist != "" {
+				allowlist, err := runner.ReadProgramAllowlist(programAllowlist)
+				if err != nil {
+					return err
+				}
+				if err := allowlist.CheckArtifact(&metadata[0]); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("%d artifacts produced by VM version %s for program %s with layout %s\n", len(metadata), metadata[0].VMVersion, metadata[0].ProgramHash, metadata[0].Layout)
+			return nil
+		},
+	}
+}
+
+// checkTraceMetadata verifies that a trace to be loaded was produced by this VM
+// version for the program, unless it has no metadata. The metadata of the trace is
+// returned so that derived traces can be stamped with it
+func checkTraceMetadata(tracePath string, program *runner.Program) (runner.ArtifactMetadata, bool, error) {
+	metadata, ok, err := runner.ReadArtifactMetadata(tracePath)
+	if err != nil || !ok {
+		return metadata, ok, err
+	}
+	if err := metadata.CheckProgram(program); err != nil {
+		return metadata, ok, fmt.Errorf("incompatible trace: %w", err)
+	}
+	return metadata, ok, nil
+}
+
+func loadZeroProgram(programPath string) (*runner.Program, error) {
+	content, err := readProgram(programPath, 0, "")
+	if err != nil {
+		return nil, fmt.Errorf("cannot load program: %w", err)
+	}
+	zeroProgram, err := zero.ZeroProgramFromJSON(content)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load program: %w", err)
+	}
+	program, err := runner.LoadCairoZeroProgram(zeroProgram)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load program: %w", err)
+	}
+	return program, nil
+}
@@ -0,0 +1,220 @@
+//go:build !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+
+	hintrunner "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(runClassCommand(), convertClassCommand())
+}
+
+func runClassCommand() *cli.Command {
+	var selector string
+	var calldata string
+	var layoutName string
+	var maxsteps uint64
+	var rpcFlags rpcClassFlags
+	return &cli.Command{
+		Name:      "run-class",
+		Usage:     "runs an entry point of a cairo zero contract class",
+		ArgsUsage: "<class.json>",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:        "selector",
+				Usage:       "selector of the entry point, of any type",
+				Required:    true,
+				Destination: &selector,
+			},
+			&cli.StringFlag{
+				Name:        "calldata",
+				Usage:       "felts passed as calldata, separated by spaces or commas",
+				Required:    false,
+				Destination: &calldata,
+			},
+			&cli.StringFlag{
+				Name:        "layout",
+				Usage:       "specifies the set of builtins to be used",
+				Required:    false,
+				Destination: &layoutName,
+			},
+			&cli.Uint64Flag{
+				Name:        "maxsteps",
+				Usage:       "limits the execution steps to 'maxsteps'",
+				DefaultText: "2**64 - 1",
+				Value:       math.MaxUint64,
+				Destination: &maxsteps,
+			},
+		}, rpcFlags.cliFlags()...),
+		Action: func(ctx *cli.Context) error {
+			content, err := rpcFlags.loadClass(ctx)
+			if err != nil {
+				return fmt.Errorf("cannot load class: %w", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(content, &fields); err != nil {
+				return fmt.Errorf("cannot load class: %w", err)
+			}
+			if fields["sierra_program"] != nil {
+				return fmt.Errorf("run-class only runs cairo zero classes, sierra classes can be converted for cairo-run with convert-class")
+			}
+			class, err := zero.DeprecatedContractClassFromJSON(content)
+			if err != nil {
+				return fmt.Errorf("cannot load class: %w", err)
+			}
+
+			selectorFelt, err := new(fp.Element).SetString(selector)
+			if err != nil {
+				return fmt.Errorf("invalid selector %s: %w", selector, err)
+			}
+			entryPoint, err := class.EntryPointOffset(selectorFelt)
+			if err != nil {
+				return err
+			}
+			calldataFelts, err := parseCalldata(calldata)
+			if err != nil {
+				return err
+			}
+
+			hints, err := hintrunner.GetZeroHints(&class.Program)
+			if err != nil {
+				return fmt.Errorf("cannot create hints: %w", err)
+			}
+			program, err := runner.LoadCairoZeroProgram(&class.Program)
+			if err != nil {
+				return fmt.Errorf("cannot load program: %w", err)
+			}
+			classRunner, err := runner.NewRunner(program, hints, runner.ExecutionModeZero, false, maxsteps, layoutName, nil, 0)
+			if err != nil {
+				return fmt.Errorf("cannot create runner: %w", err)
+			}
+
+			retdata, err := classRunner.RunDeprecatedEntryPoint(entryPoint, selectorFelt, calldataFelts)
+			if err != nil {
+				return fmt.Errorf("runtime error (entrypoint=%d): %w", entryPoint, err)
+			}
+			fmt.Println("Success!")
+			fmt.Println("Retdata:")
+			for _, felt := range retdata {
+				fmt.Printf("  %s\n", utils.FeltString(felt))
+			}
+			return nil
+		},
+	}
+}
+
+func convertClassCommand() *cli.Command {
+	var compiledClassPath string
+	var mainName string
+	var outputPath string
+	var rpcFlags rpcClassFlags
+	return &cli.Command{
+		Name:      "convert-class",
+		Usage:     "converts a contract class into a program run by run or cairo-run",
+		ArgsUsage: "<class.json>",
+		Flags: append([]cli.Flag{
+			&cli.StringFlag{
+				Name:        "compiled_class",
+				Usage:       "compiled CASM class of a sierra class, whose ABI names its entry points, fetched from the node with --class_hash",
+				Required:    false,
+				Destination: &compiledClassPath,
+			},
+			&cli.StringFlag{
+				Name:        "main",
+				Usage:       "entry point of a sierra or CASM class also converted as main, which cairo-run runs",
+				Required:    false,
+				Destination: &mainName,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Usage:       "path of the converted program",
+				Required:    true,
+				Destination: &outputPath,
+			},
+		}, rpcFlags.cliFlags()...),
+		Action: func(ctx *cli.Context) error {
+			content, err := rpcFlags.loadClass(ctx)
+			if err != nil {
+				return fmt.Errorf("cannot load class: %w", err)
+			}
+			var fields map[string]json.RawMessage
+			if err := json.Unmarshal(content, &fields); err != nil {
+				return fmt.Errorf("cannot load class: %w", err)
+			}
+
+			var program []byte
+			switch {
+			case fields["program"] != nil:
+				class, err := zero.DeprecatedContractClassFromJSON(content)
+				if err != nil {
+					return fmt.Errorf("cannot load class: %w", err)
+				}
+				program, err = json.MarshalIndent(class.ToProgram(), "", "    ")
+				if err != nil {
+					return fmt.Errorf("cannot convert class: %w", err)
+				}
+			case fields["sierra_program"] != nil:
+				class, err := starknet.SierraContractClassFromJSON(content)
+				if err != nil {
+					return fmt.Errorf("cannot load class: %w", err)
+				}
+				var compiledClass []byte
+				switch {
+				case compiledClassPath != "":
+					compiledClass, err = os.ReadFile(compiledClassPath)
+				case rpcFlags.classHash != "":
+					compiledClass, err = rpcFlags.fetchCompiledClass()
+				default:
+					return fmt.Errorf("sierra classes can't be run as is, --compiled_class must give the class compiled by starknet-sierra-compile")
+				}
+				if err != nil {
+					return fmt.Errorf("cannot load compiled class: %w", err)
+				}
+				program, err = starknet.CompiledClassToProgram(compiledClass, class.Abi, mainName)
+				if err != nil {
+					return fmt.Errorf("cannot convert class: %w", err)
+				}
+			case fields["bytecode"] != nil:
+				program, err = starknet.CompiledClassToProgram(content, nil, mainName)
+				if err != nil {
+					return fmt.Errorf("cannot convert class: %w", err)
+				}
+			default:
+				return fmt.Errorf("the class is neither a cairo zero, a sierra nor a CASM contract class")
+			}
+
+			if err := os.WriteFile(outputPath, program, 0644); err != nil {
+				return fmt.Errorf("cannot write program: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+func parseCalldata(calldata string) ([]*fp.Element, error) {
+	fields := strings.FieldsFunc(calldata, func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	felts := make([]*fp.Element, len(fields))
+	for i, field := range fields {
+		felt, err := new(fp.Element).SetString(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid calldata %s: %w", field, err)
+		}
+		felts[i] = felt
+	}
+	return felts, nil
+}
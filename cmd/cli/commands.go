@@ -0,0 +1,15 @@
+package main
+
+import "github.com/urfave/cli/v2"
+
+// Commands besides run and cairo-run register themselves from the init function of
+// their own file. Optional subsystems are left out of the binary by guarding their
+// file with a build constraint, as done by the `minimal` tag for the inspection
+// commands:
+//
+//	go build -tags minimal ./cmd/cli
+var registeredCommands []*cli.Command
+
+func registerCommands(commands ...*cli.Command) {
+	registeredCommands = append(registeredCommands, commands...)
+}
@@ -0,0 +1,94 @@
+//go:build !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+
+	hintrunner "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(estimateCommand())
+}
+
+func estimateCommand() *cli.Command {
+	var layoutName string
+	var budget uint64
+	var extrapolateSteps uint64
+	return &cli.Command{
+		Name:      "estimate",
+		Usage:     "quickly estimates the steps and builtin instances of a cairo zero compiled file, without the checks of a run",
+		ArgsUsage: "<program>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "layout",
+				Usage:       "specifies the set of builtins to be used",
+				Required:    false,
+				Destination: &layoutName,
+			},
+			&cli.Uint64Flag{
+				Name:        "budget",
+				Usage:       "steps after which the estimation stops, the resources being a lower bound",
+				Value:       10_000_000,
+				Required:    false,
+				Destination: &budget,
+			},
+			&cli.Uint64Flag{
+				Name:        "extrapolate_steps",
+				Usage:       "scales the builtin instances of an estimation stopped by --budget to a run of that many steps",
+				Required:    false,
+				Destination: &extrapolateSteps,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			pathToFile := ctx.Args().Get(0)
+			if pathToFile == "" {
+				return fmt.Errorf("path to cairo file not set")
+			}
+			content, err := readProgram(pathToFile, defaultMaxProgramSize, "")
+			if err != nil {
+				return fmt.Errorf("cannot load program: %w", err)
+			}
+			zeroProgram, err := zero.ZeroProgramFromJSON(content)
+			if err != nil {
+				return fmt.Errorf("cannot load program: %w", err)
+			}
+			hints, err := hintrunner.GetZeroHints(zeroProgram)
+			if err != nil {
+				return fmt.Errorf("cannot create hints: %w", err)
+			}
+			program, err := runner.LoadCairoZeroProgram(zeroProgram)
+			if err != nil {
+				return fmt.Errorf("cannot load program: %w", err)
+			}
+			cairoRunner, err := runner.NewRunner(program, hints, runner.ExecutionModeZero, false, budget, layoutName, nil, 0)
+			if err != nil {
+				return fmt.Errorf("cannot create runner: %w", err)
+			}
+			estimate, err := cairoRunner.Estimate(budget)
+			if err != nil {
+				return fmt.Errorf("cannot estimate resources: %w", err)
+			}
+
+			resources := estimate.Resources
+			if extrapolateSteps != 0 {
+				resources = estimate.Extrapolate(extrapolateSteps)
+			}
+			content, err = json.MarshalIndent(resources, "", "  ")
+			if err != nil {
+				return err
+			}
+			fmt.Println("Approximate resources, the writes to the builtins were not checked:")
+			fmt.Println(string(content))
+			if !estimate.Complete {
+				fmt.Printf("The estimation stopped after %d steps, before the end of the program\n", budget)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,99 @@
+//go:build !minimal
+
+package main
+
+import (
+	"embed"
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/urfave/cli/v2"
+)
+
+// Default parameter files for the stone prover. The fri_step_list of cpu_air_params
+// has to be adapted to the trace length of the proven program
+//
+//go:embed templates/*.json
+var proverTemplates embed.FS
+
+func init() {
+	registerCommands(layoutsCommand(), templatesCommand())
+}
+
+func layoutsCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "layouts",
+		Usage: "inspects the layouts supported by the vm",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the supported layouts",
+				Action: func(ctx *cli.Context) error {
+					for _, name := range builtins.LayoutNames() {
+						fmt.Println(name)
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "prints the rc units and builtin ratios of a layout",
+				ArgsUsage: "<layout>",
+				Action: func(ctx *cli.Context) error {
+					name := ctx.Args().Get(0)
+					if name == "" {
+						return fmt.Errorf("layout name not set")
+					}
+					content, err := builtins.LayoutDefinitionJSON(name)
+					if err != nil {
+						return err
+					}
+					fmt.Print(string(content))
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func templatesCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "templates",
+		Usage: "prints default prover parameter templates",
+		Subcommands: []*cli.Command{
+			{
+				Name:  "list",
+				Usage: "lists the available templates",
+				Action: func(ctx *cli.Context) error {
+					entries, err := proverTemplates.ReadDir("templates")
+					if err != nil {
+						return err
+					}
+					for _, entry := range entries {
+						fmt.Println(strings.TrimSuffix(entry.Name(), ".json"))
+					}
+					return nil
+				},
+			},
+			{
+				Name:      "show",
+				Usage:     "prints a template",
+				ArgsUsage: "<template>",
+				Action: func(ctx *cli.Context) error {
+					name := ctx.Args().Get(0)
+					if name == "" {
+						return fmt.Errorf("template name not set")
+					}
+					content, err := proverTemplates.ReadFile(path.Join("templates", name+".json"))
+					if err != nil {
+						return fmt.Errorf("template %s not found", name)
+					}
+					fmt.Print(string(content))
+					return nil
+				},
+			},
+		},
+	}
+}
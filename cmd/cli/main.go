@@ -3,32 +3,35 @@ package main
 import (
 	"encoding/json"
 	"fmt"
-	"math"
+	"net/http"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	hintrunner "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/oracle"
 	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
 	zero "github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/plugin"
 	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 	"github.com/urfave/cli/v2"
 )
 
 func main() {
-	var proofmode bool
-	var buildMemory bool
-	var collectTrace bool
-	var maxsteps uint64
-	var entrypointOffset uint64
-	var traceLocation string
-	var memoryLocation string
-	var layoutName string
-	var airPublicInputLocation string
-	var airPrivateInputLocation string
+	var options runOptions
+	var plugins cli.StringSlice
+	var loadablePrograms cli.StringSlice
+	var oracleConfig string
 	var args string
+	var blobs cli.StringSlice
+	var blobFormat string
+	var blobBytesPerFelt uint64
 	var availableGas uint64
 	app := &cli.App{
 		Name:                 "cairo-vm",
@@ -40,80 +43,72 @@ func main() {
 			{
 				Name:  "run",
 				Usage: "runs a cairo zero compiled file",
-				Flags: []cli.Flag{
-					&cli.BoolFlag{
-						Name:        "proofmode",
-						Usage:       "runs the cairo vm in proof mode",
+				Flags: append(options.flags(),
+					&cli.StringSliceFlag{
+						Name:        "plugin",
+						Usage:       "executable providing hints unknown to the vm, can be repeated",
 						Required:    false,
-						Destination: &proofmode,
-					},
-					&cli.Uint64Flag{
-						Name:        "maxsteps",
-						Usage:       "limits the execution steps to 'maxsteps'",
-						DefaultText: "2**64 - 1",
-						Value:       math.MaxUint64,
-						Required:    false,
-						Destination: &maxsteps,
-					},
-					&cli.Uint64Flag{
-						Name:        "entrypoint",
-						Usage:       "a PC offset that will be used as an entry point (by default it executes a main function)",
-						Value:       0,
-						Destination: &entrypointOffset,
-					},
-					&cli.BoolFlag{
-						Name:        "collect_trace",
-						Usage:       "collects the trace and builds the relocated trace after execution",
-						Required:    false,
-						Destination: &collectTrace,
+						Destination: &plugins,
 					},
 					&cli.StringFlag{
-						Name:        "tracefile",
-						Usage:       "location to store the relocated trace",
-						Required:    false,
-						Destination: &traceLocation,
-					},
-					&cli.BoolFlag{
-						Name:        "build_memory",
-						Usage:       "builds the relocated memory after execution",
+						Name:        "oracle",
+						Usage:       "json file declaring hints answered by json-rpc calls, not available in proof mode",
 						Required:    false,
-						Destination: &buildMemory,
+						Destination: &oracleConfig,
 					},
-					&cli.StringFlag{
-						Name:        "memoryfile",
-						Usage:       "location to store the relocated memory",
+					&cli.StringSliceFlag{
+						Name:        "loadable_program",
+						Usage:       "compiled cairo zero program the load_program hint can load by its hash, can be repeated",
 						Required:    false,
-						Destination: &memoryLocation,
+						Destination: &loadablePrograms,
 					},
-					&cli.StringFlag{
-						Name:        "layout",
-						Usage:       "specifies the set of builtins to be used",
-						Required:    false,
-						Destination: &layoutName,
-					},
-					&cli.StringFlag{
-						Name:        "air_public_input",
-						Usage:       "location to store the air_public_input",
-						Required:    false,
-						Destination: &airPublicInputLocation,
-					},
-					&cli.StringFlag{
-						Name:        "air_private_input",
-						Usage:       "location to store the air_private_input",
-						Required:    false,
-						Destination: &airPrivateInputLocation,
-					},
-				},
+				),
 				Action: func(ctx *cli.Context) error {
 					pathToFile := ctx.Args().Get(0)
 					if pathToFile == "" {
 						return fmt.Errorf("path to cairo file not set")
 					}
+					expectations, err := resourceExpectations(ctx, options.expectSteps, options.expectBuiltins.Value())
+					if err != nil {
+						return err
+					}
+					telemetry, closeTelemetry, err := newTelemetry(options.statsdAddress, options.otlpEndpoint)
+					if err != nil {
+						return err
+					}
+					defer closeTelemetry()
+					loadStart := time.Now()
 					fmt.Printf("Loading program at %s\n", pathToFile)
-					zeroProgram, err := zero.ZeroProgramFromFile(pathToFile)
+					content, err := readProgram(pathToFile, options.maxProgramSize, options.programChecksum)
+					if err != nil {
+						return fmt.Errorf("cannot load program: %w", err)
+					}
+					zeroProgram, err := zero.ZeroProgramFromJSON(content)
 					if err != nil {
 						return fmt.Errorf("cannot load program: %w", err)
 					}
+					for _, path := range plugins.Value() {
+						hintPlugin, err := plugin.Start(path)
+						if err != nil {
+							return fmt.Errorf("cannot start plugin: %w", err)
+						}
+						defer hintPlugin.Close()
+						hintrunner.RegisterHintProvider(hintPlugin)
+					}
+					if oracleConfig != "" {
+						if options.proofmode {
+							return fmt.Errorf("oracle hints are not available in proof mode")
+						}
+						config, err := oracle.ConfigFromFile(oracleConfig)
+						if err != nil {
+							return fmt.Errorf("cannot load oracle: %w", err)
+						}
+						hintOracle, err := oracle.New(config)
+						if err != nil {
+							return fmt.Errorf("cannot load oracle: %w", err)
+						}
+						hintrunner.RegisterHintProvider(hintOracle)
+					}
 					hints, err := hintrunner.GetZeroHints(zeroProgram)
 					if err != nil {
 						return fmt.Errorf("cannot create hints: %w", err)
@@ -122,84 +117,65 @@ func main() {
 					if err != nil {
 						return fmt.Errorf("cannot load program: %w", err)
 					}
+					loadable := make([]*runner.Program, 0, len(loadablePrograms.Value()))
+					for _, path := range loadablePrograms.Value() {
+						content, err := readProgram(path, options.maxProgramSize, "")
+						if err != nil {
+							return fmt.Errorf("cannot load loadable program: %w", err)
+						}
+						loadableZeroProgram, err := zero.ZeroProgramFromJSON(content)
+						if err != nil {
+							return fmt.Errorf("cannot load loadable program: %w", err)
+						}
+						loadableProgram, err := runner.LoadCairoZeroProgram(loadableZeroProgram)
+						if err != nil {
+							return fmt.Errorf("cannot load loadable program: %w", err)
+						}
+						hash := loadableProgram.Hash()
+						fmt.Printf("Loadable program %s has hash %s\n", path, utils.FeltString(&hash))
+						loadable = append(loadable, loadableProgram)
+					}
+					runner.RecordLoad(telemetry, loadStart, nil)
 					runnerMode := runner.ExecutionModeZero
-					if proofmode {
+					if options.proofmode {
 						runnerMode = runner.ProofModeZero
 					}
-					return runVM(*program, proofmode, maxsteps, entrypointOffset, collectTrace, traceLocation, buildMemory, memoryLocation, layoutName, airPublicInputLocation, airPrivateInputLocation, hints, runnerMode, nil, 0)
+					return runVM(*program, hints, runnerMode, &options, &runInputs{
+						loadablePrograms: loadable,
+						expectations:     expectations,
+						telemetry:        telemetry,
+					})
 				},
 			},
 			{
 				Name:  "cairo-run",
 				Usage: "runs a cairo zero compiled file",
-				Flags: []cli.Flag{
-					&cli.BoolFlag{
-						Name:        "proofmode",
-						Usage:       "runs the cairo vm in proof mode",
-						Required:    false,
-						Destination: &proofmode,
-					},
-					&cli.Uint64Flag{
-						Name:        "maxsteps",
-						Usage:       "limits the execution steps to 'maxsteps'",
-						DefaultText: "2**64 - 1",
-						Value:       math.MaxUint64,
-						Required:    false,
-						Destination: &maxsteps,
-					},
-					&cli.Uint64Flag{
-						Name:        "entrypoint",
-						Usage:       "a PC offset that will be used as an entry point (by default it executes a main function)",
-						Value:       0,
-						Destination: &entrypointOffset,
-					},
-					&cli.BoolFlag{
-						Name:        "collect_trace",
-						Usage:       "collects the trace and builds the relocated trace after execution",
-						Required:    false,
-						Destination: &collectTrace,
-					},
-					&cli.StringFlag{
-						Name:        "tracefile",
-						Usage:       "location to store the relocated trace",
-						Required:    false,
-						Destination: &traceLocation,
-					},
-					&cli.BoolFlag{
-						Name:        "build_memory",
-						Usage:       "builds the relocated memory after execution",
-						Required:    false,
-						Destination: &buildMemory,
-					},
-					&cli.StringFlag{
-						Name:        "memoryfile",
-						Usage:       "location to store the relocated memory",
-						Required:    false,
-						Destination: &memoryLocation,
-					},
+				Flags: append(options.flags(),
 					&cli.StringFlag{
-						Name:        "layout",
-						Usage:       "specifies the set of builtins to be used",
+						Name:        "args",
+						Usage:       "input arguments for the `main` function in the cairo progran",
 						Required:    false,
-						Destination: &layoutName,
+						Destination: &args,
 					},
-					&cli.StringFlag{
-						Name:        "air_public_input",
-						Usage:       "location to store the air_public_input",
+					&cli.StringSliceFlag{
+						Name:        "blob",
+						Usage:       "file loaded as an array argument passed after --args, can be repeated",
 						Required:    false,
-						Destination: &airPublicInputLocation,
+						Destination: &blobs,
 					},
 					&cli.StringFlag{
-						Name:        "air_private_input",
-						Usage:       "location to store the air_private_input",
+						Name:        "blob_format",
+						Usage:       "format of the --blob files: bin, or hex for the hex encoding of the bytes",
 						Required:    false,
-						Destination: &airPrivateInputLocation,
+						Value:       starknet.BlobFormatBinary,
+						Destination: &blobFormat,
 					},
-					&cli.StringFlag{
-						Name:        "args",
-						Usage:       "input arguments for the `main` function in the cairo progran",
+					&cli.Uint64Flag{
+						Name:        "blob_bytes_per_felt",
+						Usage:       "number of bytes of the --blob files packed in each felt, from 1 to 31",
 						Required:    false,
-						Destination: &args,
+						Value:       31,
+						Destination: &blobBytesPerFelt,
 					},
 					&cli.Uint64Flag{
 						Name:        "available_gas",
@@ -207,14 +183,28 @@ func main() {
 						Required:    false,
 						Destination: &availableGas,
 					},
-				},
+				),
 				Action: func(ctx *cli.Context) error {
 					pathToFile := ctx.Args().Get(0)
 					if pathToFile == "" {
 						return fmt.Errorf("path to cairo file not set")
 					}
+					expectations, err := resourceExpectations(ctx, options.expectSteps, options.expectBuiltins.Value())
+					if err != nil {
+						return err
+					}
 
-					cairoProgram, err := starknet.StarknetProgramFromFile(pathToFile)
+					telemetry, closeTelemetry, err := newTelemetry(options.statsdAddress, options.otlpEndpoint)
+					if err != nil {
+						return err
+					}
+					defer closeTelemetry()
+					loadStart := time.Now()
+					content, err := readProgram(pathToFile, options.maxProgramSize, options.programChecksum)
+					if err != nil {
+						return fmt.Errorf("cannot load program: %w", err)
+					}
+					cairoProgram, err := starknet.StarknetProgramFromJSON(content)
 					if err != nil {
 						return fmt.Errorf("cannot load program: %w", err)
 					}
@@ -222,60 +212,256 @@ func main() {
 					if err != nil {
 						return fmt.Errorf("cannot parse args: %w", err)
 					}
-					program, hints, userArgs, err := runner.AssembleProgram(cairoProgram, userArgs, availableGas, proofmode)
+					for _, path := range blobs.Value() {
+						blob, err := starknet.BlobArgFromFile(path, blobFormat, int(blobBytesPerFelt))
+						if err != nil {
+							return fmt.Errorf("cannot load blob: %w", err)
+						}
+						userArgs = append(userArgs, blob)
+					}
+					program, hints, userArgs, err := runner.AssembleProgram(cairoProgram, userArgs, availableGas, options.proofmode)
 					if err != nil {
 						return fmt.Errorf("cannot assemble program: %w", err)
 					}
+					runner.RecordLoad(telemetry, loadStart, nil)
 					runnerMode := runner.ExecutionModeCairo
-					if proofmode {
+					if options.proofmode {
 						runnerMode = runner.ProofModeCairo
 					}
-					return runVM(program, proofmode, maxsteps, entrypointOffset, collectTrace, traceLocation, buildMemory, memoryLocation, layoutName, airPublicInputLocation, airPrivateInputLocation, hints, runnerMode, userArgs, availableGas)
+					return runVM(program, hints, runnerMode, &options, &runInputs{
+						userArgs:     userArgs,
+						availableGas: availableGas,
+						expectations: expectations,
+						telemetry:    telemetry,
+					})
 				},
 			},
 		},
 	}
+	app.Commands = append(app.Commands, registeredCommands...)
 
 	if err := app.Run(os.Args); err != nil {
 		fmt.Println(err)
+		if explanation := utils.ExplainError(err); explanation != "" {
+			fmt.Println(explanation)
+		}
 		os.Exit(1)
 	}
 }
 
+// Reads the --expect_steps and --expect_builtin flags
+func resourceExpectations(ctx *cli.Context, steps uint64, builtins []string) (runner.ResourceExpectations, error) {
+	var expectations runner.ResourceExpectations
+	if ctx.IsSet("expect_steps") {
+		expectations.Steps = &steps
+	}
+	for _, builtin := range builtins {
+		if err := expectations.AddBuiltin(builtin); err != nil {
+			return runner.ResourceExpectations{}, err
+		}
+	}
+	return expectations, nil
+}
+
+// Fails with the report of the builtin segments whose stop pointer doesn't match the
+// cells written to them
+func checkBuiltinConsistency(cairoRunner *runner.Runner) error {
+	report, err := cairoRunner.CheckBuiltinConsistency()
+	if err != nil {
+		return err
+	}
+	if err := report.Err(); err != nil {
+		return fmt.Errorf("inconsistent builtin segments:\n%w", err)
+	}
+	return nil
+}
+
+// Writes the metadata of an artifact next to it when --artifact_metadata is given
+func writeArtifactMetadata(options *runOptions, cairoRunner *runner.Runner, location string, artifact string) error {
+	if !options.artifactMetadata {
+		return nil
+	}
+	return runner.WriteArtifactMetadata(location, cairoRunner.ArtifactMetadata(artifact))
+}
+
 func runVM(
 	program runner.Program,
-	proofmode bool,
-	maxsteps uint64,
-	entrypointOffset uint64,
-	collectTrace bool,
-	traceLocation string,
-	buildMemory bool,
-	memoryLocation string,
-	layoutName string,
-	airPublicInputLocation string,
-	airPrivateInputLocation string,
 	hints map[uint64][]hinter.Hinter,
 	runnerMode runner.RunnerMode,
-	userArgs []starknet.CairoFuncArgs,
-	availableGas uint64,
+	options *runOptions,
+	inputs *runInputs,
 ) error {
+	format, err := utils.ParseFeltFormat(options.feltFormat)
+	if err != nil {
+		return err
+	}
+	utils.SetFeltFormat(format)
+	if options.outputFileFormat != outputFileText && options.outputFileFormat != outputFileBinary {
+		return fmt.Errorf("unknown output file format %s, expected text or binary", options.outputFileFormat)
+	}
+
+	if options.programAllowlist != "" {
+		allowlist, err := runner.ReadProgramAllowlist(options.programAllowlist)
+		if err != nil {
+			return err
+		}
+		if err := allowlist.CheckProgram(&program); err != nil {
+			return fmt.Errorf("refusing to run: %w", err)
+		}
+		for _, loadableProgram := range inputs.loadablePrograms {
+			if err := allowlist.CheckProgram(loadableProgram); err != nil {
+				return fmt.Errorf("refusing to run: loadable %w", err)
+			}
+		}
+	}
+
 	fmt.Println("Running....")
-	cairoRunner, err := runner.NewRunner(&program, hints, runnerMode, collectTrace, maxsteps, layoutName, userArgs, availableGas)
+	if (options.layoutName == builtins.DynamicLayoutName) != (options.layoutParamsFile != "") {
+		return fmt.Errorf("--cairo_layout_params_file is required by --layout dynamic, and only by it")
+	}
+	// the dynamic layout is set once its parameters are read
+	runnerLayoutName := options.layoutName
+	if options.layoutName == builtins.DynamicLayoutName {
+		runnerLayoutName = ""
+	}
+	cairoRunner, err := runner.NewRunner(&program, hints, runnerMode, options.collectTrace, options.maxsteps, runnerLayoutName, inputs.userArgs, inputs.availableGas)
 	if err != nil {
 		return fmt.Errorf("cannot create runner: %w", err)
 	}
+	if options.layoutParamsFile != "" {
+		params, err := builtins.DynamicLayoutParamsFromFile(options.layoutParamsFile)
+		if err != nil {
+			return fmt.Errorf("cannot load layout params file: %w", err)
+		}
+		layout, err := params.Layout()
+		if err != nil {
+			return fmt.Errorf("invalid layout params file: %w", err)
+		}
+		if err := cairoRunner.SetLayout(layout); err != nil {
+			return fmt.Errorf("cannot set layout: %w", err)
+		}
+	}
+	if options.layoutFile != "" {
+		if options.layoutName != "" {
+			return fmt.Errorf("--layout and --layout_file cannot be used together")
+		}
+		definition, err := builtins.LayoutDefinitionFromFile(options.layoutFile)
+		if err != nil {
+			return fmt.Errorf("cannot load layout file: %w", err)
+		}
+		layout, err := definition.Build()
+		if err != nil {
+			return fmt.Errorf("invalid layout file: %w", err)
+		}
+		if err := cairoRunner.SetLayout(layout); err != nil {
+			return fmt.Errorf("cannot set layout: %w", err)
+		}
+	}
+	if options.profileLocation != "" && options.sampleInterval == 0 {
+		return fmt.Errorf("--profile_location requires --sample_interval")
+	}
+	if err := cairoRunner.SetSampleInterval(options.sampleInterval); err != nil {
+		return fmt.Errorf("cannot set sample interval: %w", err)
+	}
+	if options.accelerateKeccak {
+		if err := cairoRunner.EnableKeccakAcceleration(); err != nil {
+			return fmt.Errorf("cannot accelerate keccak: %w", err)
+		}
+	}
+	for _, loadableProgram := range inputs.loadablePrograms {
+		if _, err := cairoRunner.AddLoadableProgram(loadableProgram); err != nil {
+			return fmt.Errorf("cannot add loadable program: %w", err)
+		}
+	}
+	if options.stackGuard {
+		if err := cairoRunner.EnableStackGuard(); err != nil {
+			return fmt.Errorf("cannot enable the stack guard: %w", err)
+		}
+	}
+	if builtinReturnChecks := options.builtinReturnChecks.Value(); len(builtinReturnChecks) > 0 {
+		// * checks every function
+		if slices.Contains(builtinReturnChecks, "*") {
+			builtinReturnChecks = nil
+		}
+		if err := cairoRunner.CheckBuiltinReturns(builtinReturnChecks...); err != nil {
+			return err
+		}
+	}
+	if options.ecdsaWorkers != 0 {
+		if err := cairoRunner.EnableParallelECDSA(options.ecdsaWorkers); err != nil {
+			return fmt.Errorf("cannot enable parallel ECDSA verification: %w", err)
+		}
+	}
+	if options.deferECDSA {
+		if err := cairoRunner.EnableDeferredECDSA(); err != nil {
+			return fmt.Errorf("cannot defer ECDSA verification: %w", err)
+		}
+	}
+	schedule, err := newSchedule(options)
+	if err != nil {
+		return err
+	}
+	if schedule != nil {
+		if err := cairoRunner.ScheduleConcurrency(schedule); err != nil {
+			return fmt.Errorf("cannot schedule the concurrent tasks: %w", err)
+		}
+	}
+	if options.inputCommitment {
+		if err := cairoRunner.EnableInputCommitment(); err != nil {
+			return fmt.Errorf("cannot enable input commitment: %w", err)
+		}
+	}
+	// the inspect UI answers what a cell held at any step from the recorded writes
+	if options.inspectAddress != "" {
+		if err := cairoRunner.RecordWrites(); err != nil {
+			return fmt.Errorf("cannot record memory writes: %w", err)
+		}
+	}
+	if options.padBuiltins {
+		if err := cairoRunner.PadBuiltins(); err != nil {
+			return fmt.Errorf("cannot pad the builtins: %w", err)
+		}
+	}
+	if options.pedersenTables {
+		if err := cairoRunner.EnablePedersenTables(); err != nil {
+			return fmt.Errorf("cannot enable the pedersen tables: %w", err)
+		}
+	}
+	if options.deduceRatios {
+		if err := cairoRunner.DeduceDynamicRatios(); err != nil {
+			return fmt.Errorf("cannot deduce the dynamic ratios: %w", err)
+		}
+	}
+	if options.relocateEagerly && (options.proofmode || options.buildMemory) {
+		if err := cairoRunner.RelocateEagerly(); err != nil {
+			return fmt.Errorf("cannot relocate eagerly: %w", err)
+		}
+	}
+	if inputs.telemetry != nil {
+		if err := cairoRunner.SetTelemetry(inputs.telemetry); err != nil {
+			return fmt.Errorf("cannot set the telemetry: %w", err)
+		}
+	}
+	progress := &progressLine{file: os.Stderr}
+	if !options.quiet && isTerminal(os.Stderr) {
+		if err := cairoRunner.SetProgressReporter(progressInterval, options.expectedSteps, progress.report); err != nil {
+			return fmt.Errorf("cannot report progress: %w", err)
+		}
+	}
+	// on errors, the line is cleared before the error is printed
+	defer progress.clear()
 
 	// Run executes main(), RunEntryPoint is used to test contract_class-style entry points.
 	// In theory, calling RunEntryPoint with main's offset should behave identically,
 	// but these functions are implemented differently in both this and cairo-rs VMs
 	// and the difference is quite subtle.
-	if entrypointOffset == 0 {
+	if options.entrypointOffset == 0 {
 		if err := cairoRunner.Run(); err != nil {
 			return fmt.Errorf("runtime error: %w", err)
 		}
 	} else {
-		if err := cairoRunner.RunEntryPoint(entrypointOffset); err != nil {
-			return fmt.Errorf("runtime error (entrypoint=%d): %w", entrypointOffset, err)
+		if err := cairoRunner.RunEntryPoint(options.entrypointOffset); err != nil {
+			return fmt.Errorf("runtime error (entrypoint=%d): %w", options.entrypointOffset, err)
 		}
 	}
 
@@ -284,6 +470,12 @@ func runVM(
 		if err := cairoRunner.EndRun(); err != nil {
 			return fmt.Errorf("cannot end run: %w", err)
 		}
+		if err := cairoRunner.FinalizeBuiltins(); err != nil {
+			return fmt.Errorf("cannot finalize builtins: %w", err)
+		}
+		if err := checkBuiltinConsistency(&cairoRunner); err != nil {
+			return err
+		}
 		if err := cairoRunner.FinalizeSegments(); err != nil {
 			return fmt.Errorf("cannot finalize segments: %w", err)
 		}
@@ -291,47 +483,67 @@ func runVM(
 		if err := cairoRunner.EndRun(); err != nil {
 			return fmt.Errorf("cannot end run: %w", err)
 		}
-		if airPublicInputLocation != "" {
-			if err := cairoRunner.FinalizeBuiltins(); err != nil {
-				return fmt.Errorf("cannot finalize builtins: %w", err)
-			}
-
+		if err := cairoRunner.FinalizeBuiltins(); err != nil {
+			return fmt.Errorf("cannot finalize builtins: %w", err)
+		}
+		if err := checkBuiltinConsistency(&cairoRunner); err != nil {
+			return err
+		}
+		if options.airPublicInputLocation != "" {
 			if err := cairoRunner.FinalizeSegments(); err != nil {
 				return fmt.Errorf("cannot finalize segments: %w", err)
 			}
 		}
 	}
 
-	if proofmode || collectTrace {
+	if options.secureRun() {
+		if err := cairoRunner.RunSecurityChecks(); err != nil {
+			return fmt.Errorf("security checks: %w", err)
+		}
+	}
+	resources := cairoRunner.ExecutionResources()
+	if err := inputs.expectations.Check(&resources); err != nil {
+		return fmt.Errorf("unexpected resource usage: %w", err)
+	}
+
+	progress.clear()
+
+	if options.proofmode || options.collectTrace {
 		trace, err := cairoRunner.BuildTrace()
 		if err != nil {
 			return fmt.Errorf("cannot build trace: %w", err)
 		}
 
-		if traceLocation != "" {
-			if err := os.WriteFile(traceLocation, trace, 0644); err != nil {
+		if options.traceLocation != "" {
+			if err := os.WriteFile(options.traceLocation, trace, 0644); err != nil {
 				return fmt.Errorf("cannot write relocated trace: %w", err)
 			}
+			if err := writeArtifactMetadata(options, &cairoRunner, options.traceLocation, runner.TraceArtifact); err != nil {
+				return err
+			}
 		}
 	}
 
 	var segmentsOffsets []uint64
 	var relocatedMemory []*fp.Element
-	if proofmode || buildMemory {
+	if options.proofmode || options.buildMemory {
 		relocatedMemory, segmentsOffsets = cairoRunner.BuildMemory()
 		if err != nil {
 			return fmt.Errorf("cannot build memory: %w", err)
 		}
 
-		if memoryLocation != "" {
-			if err := os.WriteFile(memoryLocation, vm.EncodeMemory(relocatedMemory), 0644); err != nil {
+		if options.memoryLocation != "" {
+			if err := os.WriteFile(options.memoryLocation, vm.EncodeMemory(relocatedMemory), 0644); err != nil {
 				return fmt.Errorf("cannot write relocated memory: %w", err)
 			}
+			if err := writeArtifactMetadata(options, &cairoRunner, options.memoryLocation, runner.MemoryArtifact); err != nil {
+				return err
+			}
 		}
 	}
 
-	if proofmode {
-		if airPublicInputLocation != "" {
+	if options.proofmode {
+		if options.airPublicInputLocation != "" {
 			publicMemoryAddresses := cairoRunner.GetPublicMemoryAddresses(segmentsOffsets)
 			airPublicInput, err := cairoRunner.GetAirPublicInput(relocatedMemory, publicMemoryAddresses)
 			if err != nil {
@@ -341,44 +553,84 @@ func runVM(
 			if err != nil {
 				return err
 			}
-			err = os.WriteFile(airPublicInputLocation, airPublicInputJson, 0644)
+			err = os.WriteFile(options.airPublicInputLocation, airPublicInputJson, 0644)
 			if err != nil {
 				return fmt.Errorf("cannot write air_public_input: %w", err)
 			}
+			if err := writeArtifactMetadata(options, &cairoRunner, options.airPublicInputLocation, runner.AirPublicInputArtifact); err != nil {
+				return err
+			}
 		}
 
-		if airPrivateInputLocation != "" {
-			tracePath, err := filepath.Abs(traceLocation)
+		if options.airPrivateInputLocation != "" {
+			tracePath, err := filepath.Abs(options.traceLocation)
 			if err != nil {
 				return err
 			}
-			memoryPath, err := filepath.Abs(memoryLocation)
+			memoryPath, err := filepath.Abs(options.memoryLocation)
 			if err != nil {
 				return err
 			}
-			airPrivateInput, err := cairoRunner.GetAirPrivateInput(tracePath, memoryPath)
-			if err != nil {
+			if err := cairoRunner.WriteAirPrivateInput(options.airPrivateInputLocation, tracePath, memoryPath); err != nil {
 				return err
 			}
-			airPrivateInputJson, err := json.MarshalIndent(airPrivateInput, "", "  ")
-			if err != nil {
+			if err := writeArtifactMetadata(options, &cairoRunner, options.airPrivateInputLocation, runner.AirPrivateInputArtifact); err != nil {
 				return err
 			}
-			err = os.WriteFile(airPrivateInputLocation, airPrivateInputJson, 0644)
-			if err != nil {
-				return fmt.Errorf("cannot write air_private_input: %w", err)
-			}
 		}
 	}
 
+	if options.segmentMapLocation != "" {
+		segmentMap := cairoRunner.BuildSegmentMap()
+		file, err := os.Create(options.segmentMapLocation)
+		if err != nil {
+			return fmt.Errorf("cannot create segment map: %w", err)
+		}
+		defer file.Close()
+		if err := segmentMap.WriteHTML(file); err != nil {
+			return fmt.Errorf("cannot write segment map: %w", err)
+		}
+	}
+
+	if options.profileLocation != "" {
+		profile := cairoRunner.BuildProfile()
+		file, err := os.Create(options.profileLocation)
+		if err != nil {
+			return fmt.Errorf("cannot create profile: %w", err)
+		}
+		defer file.Close()
+		if err := profile.WriteText(file); err != nil {
+			return fmt.Errorf("cannot write profile: %w", err)
+		}
+	}
+
+	// the verifications are joined when ending the run and the relocations when
+	// building the memory, so no task is left
+	if err := finishSchedule(schedule, options); err != nil {
+		return err
+	}
+
 	fmt.Println("Success!")
 	output := cairoRunner.Output()
 	if len(output) > 0 {
 		fmt.Println("Program output:")
 		for _, val := range output {
 			// cairo-run v0.11-0.13 pad the output lines with two spaces.
-			fmt.Printf("  %s\n", val)
+			fmt.Printf("  %s\n", utils.FeltString(val))
 		}
 	}
+	if options.outputFile != "" {
+		if err := writeOutputFile(options.outputFile, options.outputFileFormat, output); err != nil {
+			return fmt.Errorf("cannot write output file: %w", err)
+		}
+	}
+	if commitment, ok := cairoRunner.InputCommitment(); ok {
+		fmt.Printf("Input commitment: 0x%s\n", commitment.Text(16))
+	}
+
+	if options.inspectAddress != "" {
+		fmt.Printf("Inspecting the run at http://%s\n", options.inspectAddress)
+		return http.ListenAndServe(options.inspectAddress, cairoRunner.InspectHandler())
+	}
 	return nil
 }
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(memoryCommand())
+}
+
+func memoryCommand() *cli.Command {
+	var strictHoles bool
+	var maxDiffs uint64
+	return &cli.Command{
+		Name:  "memory",
+		Usage: "inspects memory files, in the format of --memoryfile",
+		Subcommands: []*cli.Command{
+			{
+				Name:      "compare",
+				Usage:     "compares a memory file to a reference one, e.g. written by the python vm",
+				ArgsUsage: "<expected> <actual>",
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:        "strict_holes",
+						Usage:       "reports the holes of a file that the other file writes as zero",
+						Required:    false,
+						Destination: &strictHoles,
+					},
+					&cli.Uint64Flag{
+						Name:        "max_diffs",
+						Usage:       "maximum number of differing cells printed, 0 for all",
+						Value:       20,
+						Required:    false,
+						Destination: &maxDiffs,
+					},
+				},
+				Action: func(ctx *cli.Context) error {
+					if ctx.Args().Len() != 2 {
+						return fmt.Errorf("expected the paths of the two memory files")
+					}
+					expected, err := readMemoryFile(ctx.Args().Get(0))
+					if err != nil {
+						return err
+					}
+					actual, err := readMemoryFile(ctx.Args().Get(1))
+					if err != nil {
+						return err
+					}
+
+					diffs := vm.CompareMemory(expected, actual, strictHoles)
+					for i := range diffs {
+						if maxDiffs != 0 && uint64(i) == maxDiffs {
+							fmt.Printf("... and %d more\n", uint64(len(diffs))-maxDiffs)
+							break
+						}
+						fmt.Println(diffs[i].String())
+					}
+					if len(diffs) > 0 {
+						return fmt.Errorf("the memories differ at %d addresses", len(diffs))
+					}
+					fmt.Println("The memories are the same")
+					return nil
+				},
+			},
+		},
+	}
+}
+
+func readMemoryFile(path string) ([]*fp.Element, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read memory file: %w", err)
+	}
+	records, err := vm.DecodeMemoryFile(content)
+	if err != nil {
+		return nil, fmt.Errorf("memory file %s: %w", path, err)
+	}
+	memory, err := vm.MemoryFileCells(records)
+	if err != nil {
+		return nil, fmt.Errorf("memory file %s: %w", path, err)
+	}
+	return memory, nil
+}
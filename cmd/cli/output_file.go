@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// Formats of the file written with --output_file
+const (
+	outputFileText   = "text"
+	outputFileBinary = "binary"
+)
+
+// writeOutputFile writes the program output, either one felt per line printed
+// with the felt format of the run, or as 32 bytes big endian felts
+func writeOutputFile(path string, format string, output []*fp.Element) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	for _, felt := range output {
+		if format == outputFileBinary {
+			bytes := felt.Bytes()
+			_, err = writer.Write(bytes[:])
+		} else {
+			_, err = fmt.Fprintln(writer, utils.FeltString(felt))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Default limit on the size of a compiled program, far above the largest programs
+// run in practice
+const defaultMaxProgramSize = 256 << 20
+
+// readProgram reads a compiled program from a file, from stdin when the location is
+// `-`, or from an http(s) url. Reading fails when the program is larger than
+// maxSize bytes or, if a checksum is given, when its sha256 digest doesn't match
+func readProgram(location string, maxSize uint64, checksum string) ([]byte, error) {
+	var source io.ReadCloser
+	switch {
+	case location == "-":
+		source = io.NopCloser(os.Stdin)
+	case strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://"):
+		client := http.Client{Timeout: time.Minute}
+		response, err := client.Get(location)
+		if err != nil {
+			return nil, err
+		}
+		if response.StatusCode != http.StatusOK {
+			response.Body.Close()
+			return nil, fmt.Errorf("cannot download %s: %s", location, response.Status)
+		}
+		source = response.Body
+	default:
+		file, err := os.Open(location)
+		if err != nil {
+			return nil, err
+		}
+		source = file
+	}
+	defer source.Close()
+
+	if maxSize == 0 {
+		maxSize = defaultMaxProgramSize
+	}
+	content, err := io.ReadAll(io.LimitReader(source, int64(maxSize)+1))
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(content)) > maxSize {
+		return nil, fmt.Errorf("program is larger than %d bytes, see --max_program_size", maxSize)
+	}
+
+	if checksum != "" {
+		digest := sha256.Sum256(content)
+		if actual := hex.EncodeToString(digest[:]); !strings.EqualFold(actual, checksum) {
+			return nil, fmt.Errorf("program sha256 checksum is %s, expected %s", actual, checksum)
+		}
+	}
+	return content, nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+)
+
+// interval between two updates of the progress line
+const progressInterval = time.Second
+
+// isTerminal tells whether the file is a terminal rather than a pipe or a file, the
+// progress line being left out of logs
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// progressLine rewrites a single line of the terminal with the progress of the run
+type progressLine struct {
+	file  *os.File
+	width int
+}
+
+func (line *progressLine) report(progress runner.Progress) {
+	text := fmt.Sprintf("%s steps, %s steps/s, %s elapsed", formatCount(float64(progress.Steps)), formatCount(progress.StepsPerSecond), progress.Elapsed.Round(time.Second))
+	if progress.ExpectedSteps != 0 {
+		text += fmt.Sprintf(", %.1f%% of %s steps", 100*float64(progress.Steps)/float64(progress.ExpectedSteps), formatCount(float64(progress.ExpectedSteps)))
+	}
+	if progress.ETA != 0 {
+		text += fmt.Sprintf(", ETA %s", progress.ETA.Round(time.Second))
+	}
+	// the previous line is padded over when longer
+	padding := ""
+	if len(text) < line.width {
+		padding = strings.Repeat(" ", line.width-len(text))
+	}
+	line.width = len(text)
+	fmt.Fprintf(line.file, "\r%s%s", text, padding)
+}
+
+// clear erases the line so that the next output starts on a clean line
+func (line *progressLine) clear() {
+	if line.width == 0 {
+		return
+	}
+	fmt.Fprintf(line.file, "\r%s\r", strings.Repeat(" ", line.width))
+	line.width = 0
+}
+
+// Formats a count with a k, M or G suffix
+func formatCount(count float64) string {
+	switch {
+	case count >= 1e9:
+		return fmt.Sprintf("%.2fG", count/1e9)
+	case count >= 1e6:
+		return fmt.Sprintf("%.2fM", count/1e6)
+	case count >= 1e3:
+		return fmt.Sprintf("%.1fk", count/1e3)
+	default:
+		return fmt.Sprintf("%.0f", count)
+	}
+}
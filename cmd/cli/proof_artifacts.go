@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(validateProofArtifactsCommand())
+}
+
+func validateProofArtifactsCommand() *cli.Command {
+	var traceLocation string
+	var memoryLocation string
+	var airPublicInputLocation string
+	return &cli.Command{
+		Name:  "validate-proof-artifacts",
+		Usage: "checks the structural invariants of the trace, memory and air_public_input of a proof mode run before handing them to a prover",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "tracefile",
+				Usage:       "relocated trace, in the format of --tracefile",
+				Required:    true,
+				Destination: &traceLocation,
+			},
+			&cli.StringFlag{
+				Name:        "memoryfile",
+				Usage:       "relocated memory, in the format of --memoryfile",
+				Required:    true,
+				Destination: &memoryLocation,
+			},
+			&cli.StringFlag{
+				Name:        "air_public_input",
+				Usage:       "air_public_input of the run",
+				Required:    true,
+				Destination: &airPublicInputLocation,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			content, err := os.ReadFile(traceLocation)
+			if err != nil {
+				return fmt.Errorf("cannot read trace: %w", err)
+			}
+			if len(content)%24 != 0 {
+				return fmt.Errorf("trace of %d bytes, which is not a multiple of the 24 bytes of a step", len(content))
+			}
+			trace := vm.DecodeTrace(content)
+			memory, err := readMemoryFile(memoryLocation)
+			if err != nil {
+				return err
+			}
+			content, err = os.ReadFile(airPublicInputLocation)
+			if err != nil {
+				return fmt.Errorf("cannot read air_public_input: %w", err)
+			}
+			var airPublicInput runner.AirPublicInput
+			if err := json.Unmarshal(content, &airPublicInput); err != nil {
+				return fmt.Errorf("cannot decode air_public_input: %w", err)
+			}
+
+			if err := runner.ValidateProofArtifacts(trace, memory, &airPublicInput); err != nil {
+				return fmt.Errorf("invalid proof artifacts:\n%w", err)
+			}
+			fmt.Printf("The artifacts of the %d steps with layout %s are valid\n", len(trace), airPublicInput.Layout)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,140 @@
+//go:build !minimal
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/urfave/cli/v2"
+)
+
+// Flags of the commands loading a contract class from a node instead of a file
+type rpcClassFlags struct {
+	classHash string
+	rpcURL    string
+	block     string
+}
+
+func (flags *rpcClassFlags) cliFlags() []cli.Flag {
+	return []cli.Flag{
+		&cli.StringFlag{
+			Name:        "class_hash",
+			Usage:       "hash of a class fetched from the node of --rpc_url instead of a class file",
+			Required:    false,
+			Destination: &flags.classHash,
+		},
+		&cli.StringFlag{
+			Name:        "rpc_url",
+			Usage:       "url of the Starknet JSON-RPC node --class_hash is fetched from",
+			Required:    false,
+			Destination: &flags.rpcURL,
+		},
+		&cli.StringFlag{
+			Name:        "block",
+			Usage:       "block at which --class_hash is fetched: latest, pending, a block number or a block hash",
+			Required:    false,
+			Value:       "latest",
+			Destination: &flags.block,
+		},
+	}
+}
+
+// loadClass reads the class file given as argument or, with --class_hash, fetches
+// the class from the node
+func (flags *rpcClassFlags) loadClass(ctx *cli.Context) ([]byte, error) {
+	pathToFile := ctx.Args().Get(0)
+	if flags.classHash == "" {
+		if pathToFile == "" {
+			return nil, fmt.Errorf("path to contract class not set")
+		}
+		return readProgram(pathToFile, 0, "")
+	}
+	if pathToFile != "" {
+		return nil, fmt.Errorf("a class file and --class_hash cannot be used together")
+	}
+	if flags.rpcURL == "" {
+		return nil, fmt.Errorf("--class_hash requires --rpc_url")
+	}
+	return flags.fetch("starknet_getClass")
+}
+
+// fetchCompiledClass fetches the CASM class the node compiled from the sierra class
+// of --class_hash
+func (flags *rpcClassFlags) fetchCompiledClass() ([]byte, error) {
+	return flags.fetch("starknet_getCompiledCasm")
+}
+
+func (flags *rpcClassFlags) fetch(method string) ([]byte, error) {
+	params := map[string]any{"class_hash": flags.classHash}
+	// the compiled class doesn't depend on the block
+	if method == "starknet_getClass" {
+		blockID, err := rpcBlockID(flags.block)
+		if err != nil {
+			return nil, err
+		}
+		params["block_id"] = blockID
+	}
+	result, err := callRPC(flags.rpcURL, method, params)
+	if err != nil {
+		return nil, fmt.Errorf("%s %s: %w", method, flags.classHash, err)
+	}
+	return result, nil
+}
+
+func rpcBlockID(block string) (any, error) {
+	switch {
+	case block == "latest" || block == "pending":
+		return block, nil
+	case strings.HasPrefix(block, "0x"):
+		return map[string]string{"block_hash": block}, nil
+	default:
+		number, err := strconv.ParseUint(block, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid block %s, expected latest, pending, a block number or a block hash", block)
+		}
+		return map[string]uint64{"block_number": number}, nil
+	}
+}
+
+// Sends a JSON-RPC 2.0 request and returns its result
+func callRPC(url string, method string, params any) (json.RawMessage, error) {
+	request, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      1,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client := http.Client{Timeout: time.Minute}
+	httpResponse, err := client.Post(url, "application/json", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", url, httpResponse.Status)
+	}
+
+	var response struct {
+		Result json.RawMessage `json:"result"`
+		Error  *struct {
+			Code    int    `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error %d: %s", response.Error.Code, response.Error.Message)
+	}
+	return response.Result, nil
+}
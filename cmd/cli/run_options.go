@@ -0,0 +1,341 @@
+package main
+
+import (
+	"math"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/urfave/cli/v2"
+)
+
+// Options of the run and cairo-run commands, set by the flags both commands share
+type runOptions struct {
+	proofmode               bool
+	maxsteps                uint64
+	entrypointOffset        uint64
+	collectTrace            bool
+	traceLocation           string
+	buildMemory             bool
+	memoryLocation          string
+	layoutName              string
+	layoutFile              string
+	layoutParamsFile        string
+	accelerateKeccak        bool
+	airPublicInputLocation  string
+	airPrivateInputLocation string
+	segmentMapLocation      string
+	feltFormat              string
+	sampleInterval          uint64
+	profileLocation         string
+	maxProgramSize          uint64
+	programChecksum         string
+	inspectAddress          string
+	inputCommitment         bool
+	outputFile              string
+	outputFileFormat        string
+	stackGuard              bool
+	builtinReturnChecks     cli.StringSlice
+	ecdsaWorkers            int
+	deferECDSA              bool
+	quiet                   bool
+	expectedSteps           uint64
+	programAllowlist        string
+	expectSteps             uint64
+	expectBuiltins          cli.StringSlice
+	padBuiltins             bool
+	pedersenTables          bool
+	deduceRatios            bool
+	statsdAddress           string
+	otlpEndpoint            string
+	relocateEagerly         bool
+	scheduleLog             string
+	replaySchedule          string
+	artifactMetadata        bool
+	// nil unless --secure_run is given, see secureRun
+	secureRunFlag *bool
+}
+
+// What the commands load for the run besides the program and its hints, which
+// depends on the format of the program
+type runInputs struct {
+	userArgs         []starknet.CairoFuncArgs
+	availableGas     uint64
+	loadablePrograms []*runner.Program
+	expectations     runner.ResourceExpectations
+	telemetry        runner.Telemetry
+}
+
+// Returns the flags setting the options. Both run commands list them, with a call each
+// for flags of their own, and only add the flags reading their format of program
+func (options *runOptions) flags() []cli.Flag {
+	return []cli.Flag{
+		&cli.BoolFlag{
+			Name:        "proofmode",
+			Usage:       "runs the cairo vm in proof mode",
+			Required:    false,
+			Destination: &options.proofmode,
+		},
+		&cli.Uint64Flag{
+			Name:        "maxsteps",
+			Usage:       "limits the execution steps to 'maxsteps'",
+			DefaultText: "2**64 - 1",
+			Value:       math.MaxUint64,
+			Required:    false,
+			Destination: &options.maxsteps,
+		},
+		&cli.Uint64Flag{
+			Name:        "entrypoint",
+			Usage:       "a PC offset that will be used as an entry point (by default it executes a main function)",
+			Value:       0,
+			Destination: &options.entrypointOffset,
+		},
+		&cli.BoolFlag{
+			Name:        "collect_trace",
+			Usage:       "collects the trace and builds the relocated trace after execution",
+			Required:    false,
+			Destination: &options.collectTrace,
+		},
+		&cli.StringFlag{
+			Name:        "tracefile",
+			Usage:       "location to store the relocated trace",
+			Required:    false,
+			Destination: &options.traceLocation,
+		},
+		&cli.BoolFlag{
+			Name:        "build_memory",
+			Usage:       "builds the relocated memory after execution",
+			Required:    false,
+			Destination: &options.buildMemory,
+		},
+		&cli.StringFlag{
+			Name:        "memoryfile",
+			Usage:       "location to store the relocated memory",
+			Required:    false,
+			Destination: &options.memoryLocation,
+		},
+		&cli.StringFlag{
+			Name:        "layout",
+			Usage:       "specifies the set of builtins to be used",
+			Required:    false,
+			Destination: &options.layoutName,
+		},
+		&cli.StringFlag{
+			Name:        "layout_file",
+			Usage:       "json file defining a custom layout, used instead of --layout",
+			Required:    false,
+			Destination: &options.layoutFile,
+		},
+		&cli.StringFlag{
+			Name:        "cairo_layout_params_file",
+			Usage:       "json file with the builtin ratios and the parameters of --layout dynamic, as for the Python VM",
+			Required:    false,
+			Destination: &options.layoutParamsFile,
+		},
+		&cli.BoolFlag{
+			Name:        "accelerate_keccak",
+			Usage:       "verifies the permutations of cairo_keccak natively when the layout has the keccak builtin, only for cairo zero programs and not in proof mode",
+			Required:    false,
+			Destination: &options.accelerateKeccak,
+		},
+		&cli.StringFlag{
+			Name:        "air_public_input",
+			Usage:       "location to store the air_public_input",
+			Required:    false,
+			Destination: &options.airPublicInputLocation,
+		},
+		&cli.StringFlag{
+			Name:        "air_private_input",
+			Usage:       "location to store the air_private_input",
+			Required:    false,
+			Destination: &options.airPrivateInputLocation,
+		},
+		&cli.StringFlag{
+			Name:        "segment_map",
+			Usage:       "location to store an HTML report of the memory segments after execution",
+			Required:    false,
+			Destination: &options.segmentMapLocation,
+		},
+		&cli.StringFlag{
+			Name:        "felt_format",
+			Usage:       "how felts are printed in the output and errors: dec, hex, signed or short_string",
+			Required:    false,
+			Destination: &options.feltFormat,
+		},
+		&cli.Uint64Flag{
+			Name:        "sample_interval",
+			Usage:       "records pc and ap every given number of steps to profile the run",
+			Required:    false,
+			Destination: &options.sampleInterval,
+		},
+		&cli.StringFlag{
+			Name:        "profile_location",
+			Usage:       "location to store the number of samples per pc, requires --sample_interval",
+			Required:    false,
+			Destination: &options.profileLocation,
+		},
+		&cli.Uint64Flag{
+			Name:        "max_program_size",
+			Usage:       "maximum size in bytes of the compiled program, 256 MiB by default",
+			Required:    false,
+			Destination: &options.maxProgramSize,
+		},
+		&cli.StringFlag{
+			Name:        "program_checksum",
+			Usage:       "expected sha256 digest of the compiled program, in hex",
+			Required:    false,
+			Destination: &options.programChecksum,
+		},
+		&cli.StringFlag{
+			Name:        "inspect",
+			Usage:       "address, e.g. :8080, on which to serve a web page inspecting the finished run",
+			Required:    false,
+			Destination: &options.inspectAddress,
+		},
+		&cli.BoolFlag{
+			Name:        "input_commitment",
+			Usage:       "prints a poseidon commitment to every value written by hints, i.e. to the nondeterministic inputs of the run",
+			Required:    false,
+			Destination: &options.inputCommitment,
+		},
+		&cli.StringFlag{
+			Name:        "output_file",
+			Usage:       "location to store the program output, besides printing it",
+			Required:    false,
+			Destination: &options.outputFile,
+		},
+		&cli.StringFlag{
+			Name:        "output_file_format",
+			Usage:       "format of --output_file: text for one felt per line, or binary for 32 bytes big endian felts",
+			Required:    false,
+			Value:       outputFileText,
+			Destination: &options.outputFileFormat,
+		},
+		&cli.BoolFlag{
+			Name:        "stack_guard",
+			Usage:       "fails with the function whose saved fp or return pc is written over, instead of the later invalid jump",
+			Required:    false,
+			Destination: &options.stackGuard,
+		},
+		&cli.StringSliceFlag{
+			Name:        "check_builtin_returns",
+			Usage:       "fails at the ret of the cairo zero function, repeatable or * for every function, when it doesn't return the builtin pointers it received",
+			Required:    false,
+			Destination: &options.builtinReturnChecks,
+		},
+		&cli.IntFlag{
+			Name:        "ecdsa_workers",
+			Usage:       "verifies the ECDSA signatures on that many goroutines, -1 for one per CPU, and on the vm thread when 0",
+			Required:    false,
+			Destination: &options.ecdsaWorkers,
+		},
+		&cli.BoolFlag{
+			Name:        "defer_ecdsa",
+			Usage:       "checks the ECDSA instances once the run ends, reporting all the invalid ones",
+			Required:    false,
+			Destination: &options.deferECDSA,
+		},
+		&cli.BoolFlag{
+			Name:        "quiet",
+			Usage:       "hides the progress line shown on terminals while the program runs",
+			Required:    false,
+			Destination: &options.quiet,
+		},
+		&cli.Uint64Flag{
+			Name:        "expected_steps",
+			Usage:       "estimate of the steps of the run for the ETA of the progress line, maxsteps by default",
+			Required:    false,
+			Destination: &options.expectedSteps,
+		},
+		&cli.StringFlag{
+			Name:        "program_allowlist",
+			Usage:       "file of the program hashes allowed to run, one per line, any other program is refused",
+			Required:    false,
+			Destination: &options.programAllowlist,
+		},
+		&cli.Uint64Flag{
+			Name:        "expect_steps",
+			Usage:       "fails the run unless it takes exactly that many steps",
+			Required:    false,
+			Destination: &options.expectSteps,
+		},
+		&cli.StringSliceFlag{
+			Name:        "expect_builtin",
+			Usage:       "fails the run unless the builtin has exactly that many instances, as builtin=instances, repeatable",
+			Required:    false,
+			Destination: &options.expectBuiltins,
+		},
+		&cli.BoolFlag{
+			Name:        "pad_builtins",
+			Usage:       "in proof mode, completes the partially used builtin instances with dummy values",
+			Required:    false,
+			Destination: &options.padBuiltins,
+		},
+		&cli.BoolFlag{
+			Name:        "pedersen_tables",
+			Usage:       "deduces the pedersen hashes from precomputed tables, faster for programs hashing a lot",
+			Required:    false,
+			Destination: &options.pedersenTables,
+		},
+		&cli.BoolFlag{
+			Name:        "deduce_dynamic_ratios",
+			Usage:       "with --layout dynamic, sets the builtin ratios to the largest ones allocating the instances the run used",
+			Required:    false,
+			Destination: &options.deduceRatios,
+		},
+		&cli.StringFlag{
+			Name:        "statsd_address",
+			Usage:       "sends the durations of the run phases and the resources it used to the statsd agent at host:port",
+			Required:    false,
+			Destination: &options.statsdAddress,
+		},
+		&cli.StringFlag{
+			Name:        "otlp_endpoint",
+			Usage:       "exports the spans of the run phases and the resources it used to the OpenTelemetry collector at the url, e.g. http://localhost:4318",
+			Required:    false,
+			Destination: &options.otlpEndpoint,
+		},
+		&cli.BoolFlag{
+			Name:        "secure_run",
+			Usage:       "validates every builtin segment once the run is over, deducing the outputs of their instances again",
+			DefaultText: "true in proof mode, false otherwise",
+			Required:    false,
+			Action: func(_ *cli.Context, secureRun bool) error {
+				options.secureRunFlag = &secureRun
+				return nil
+			},
+		},
+		&cli.BoolFlag{
+			Name:        "relocate_eagerly",
+			Usage:       "when the memory is built, relocates the program segment while the program runs instead of once the run is over, freezing it",
+			Required:    false,
+			Destination: &options.relocateEagerly,
+		},
+		&cli.StringFlag{
+			Name:        "schedule_log",
+			Usage:       "location to store the order the parallel ECDSA verifications and background relocations completed in, to replay it with --replay_schedule",
+			Required:    false,
+			Destination: &options.scheduleLog,
+		},
+		&cli.StringFlag{
+			Name:        "replay_schedule",
+			Usage:       "runs the concurrent tasks one at a time in the order of a log stored by --schedule_log, failing if the run diverges from it",
+			Required:    false,
+			Destination: &options.replaySchedule,
+		},
+		&cli.BoolFlag{
+			Name:        "artifact_metadata",
+			Usage:       "writes the VM version, program hash and layout of each artifact to a .meta.json file next to it, for check-artifacts",
+			Required:    false,
+			Destination: &options.artifactMetadata,
+		},
+	}
+}
+
+// Tells whether the builtin segments are validated once the run is over, which is
+// done by default in proof mode only, as by the Python VM
+func (options *runOptions) secureRun() bool {
+	if options.secureRunFlag != nil {
+		return *options.secureRunFlag
+	}
+	return options.proofmode
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+)
+
+// Returns the schedule of the concurrent tasks of the run: replaying the log of
+// --replay_schedule, recording for --schedule_log, or nil without either flag
+func newSchedule(options *runOptions) (*utils.Schedule, error) {
+	if options.replaySchedule != "" {
+		content, err := os.ReadFile(options.replaySchedule)
+		if err != nil {
+			return nil, fmt.Errorf("cannot read schedule: %w", err)
+		}
+		return utils.ReplaySchedule(strings.Fields(string(content))), nil
+	}
+	if options.scheduleLog != "" {
+		return utils.RecordSchedule(), nil
+	}
+	return nil, nil
+}
+
+// Writes the log of the recorded schedule, one task per line, or fails when the
+// replayed run diverged from the replayed log
+func finishSchedule(schedule *utils.Schedule, options *runOptions) error {
+	if err := schedule.Err(); err != nil {
+		return fmt.Errorf("the run diverged from the replayed schedule:\n%w", err)
+	}
+	if options.scheduleLog == "" || schedule.Replaying() {
+		return nil
+	}
+	var content strings.Builder
+	for _, id := range schedule.Log() {
+		content.WriteString(id)
+		content.WriteByte('\n')
+	}
+	if err := os.WriteFile(options.scheduleLog, []byte(content.String()), 0644); err != nil {
+		return fmt.Errorf("cannot write schedule log: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/NethermindEth/cairo-vm-go/pkg/telemetry"
+)
+
+const (
+	// prefix of the statsd names and OpenTelemetry service name
+	telemetryServiceName = "cairo_vm"
+	// time left to the collector to receive the spans and metrics of the run
+	telemetryFlushTimeout = 5 * time.Second
+)
+
+// newTelemetry builds the exporters of the --statsd_address and --otlp_endpoint
+// flags, the telemetry being nil when neither is set. The returned function exports
+// what is left to the collector and closes the exporters, a failure to export being
+// a warning rather than an error of the run
+func newTelemetry(statsdAddress string, otlpEndpoint string) (runner.Telemetry, func(), error) {
+	var telemetries []runner.Telemetry
+	var closers []func() error
+	if statsdAddress != "" {
+		statsd, err := telemetry.NewStatsd(statsdAddress, telemetryServiceName)
+		if err != nil {
+			return nil, nil, err
+		}
+		telemetries = append(telemetries, statsd)
+		closers = append(closers, statsd.Close)
+	}
+	if otlpEndpoint != "" {
+		otlp := telemetry.NewOTLP(otlpEndpoint, telemetryServiceName)
+		telemetries = append(telemetries, otlp)
+		closers = append(closers, func() error {
+			ctx, cancel := context.WithTimeout(context.Background(), telemetryFlushTimeout)
+			defer cancel()
+			return otlp.Flush(ctx)
+		})
+	}
+
+	closeTelemetry := func() {
+		for _, close := range closers {
+			if err := close(); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: telemetry: %v\n", err)
+			}
+		}
+	}
+	switch len(telemetries) {
+	case 0:
+		return nil, closeTelemetry, nil
+	case 1:
+		return telemetries[0], closeTelemetry, nil
+	default:
+		return telemetry.Multi(telemetries...), closeTelemetry, nil
+	}
+}
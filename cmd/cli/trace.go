@@ -0,0 +1,103 @@
+//go:build !minimal
+
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(pruneTraceCommand())
+}
+
+func pruneTraceCommand() *cli.Command {
+	var programPath string
+	var function string
+	var call uint64
+	var outputPath string
+	return &cli.Command{
+		Name:      "prune-trace",
+		Usage:     "keeps only the steps of a trace spent in the calls of a function, callees included",
+		ArgsUsage: "<trace>",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "program",
+				Usage:       "compiled cairo zero program the trace was collected from",
+				Required:    true,
+				Destination: &programPath,
+			},
+			&cli.StringFlag{
+				Name:        "function",
+				Usage:       "function or label whose calls are kept, e.g. fib or starkware.cairo.common.math.assert_nn",
+				Required:    true,
+				Destination: &function,
+			},
+			&cli.Uint64Flag{
+				Name:        "call",
+				Usage:       "keeps only the n-th call of the function, counting from 1, instead of all its calls",
+				Required:    false,
+				Destination: &call,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Usage:       "path of the pruned trace, in the format of --tracefile",
+				Required:    true,
+				Destination: &outputPath,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			pathToTrace := ctx.Args().Get(0)
+			if pathToTrace == "" {
+				return fmt.Errorf("path to trace not set")
+			}
+			program, err := loadZeroProgram(programPath)
+			if err != nil {
+				return err
+			}
+			offset, ok := program.Entrypoints[function]
+			if !ok {
+				offset, ok = program.Labels[function]
+			}
+			if !ok {
+				return fmt.Errorf("unknown function %s", function)
+			}
+
+			metadata, hasMetadata, err := checkTraceMetadata(pathToTrace, program)
+			if err != nil {
+				return err
+			}
+			content, err := os.ReadFile(pathToTrace)
+			if err != nil {
+				return fmt.Errorf("cannot read trace: %w", err)
+			}
+			// the trace is relocated, with the program segment starting at 1
+			calls := vm.CallSubtrees(vm.DecodeTrace(content), offset+1)
+			if call > uint64(len(calls)) {
+				return fmt.Errorf("%s is called %d times, there is no call %d", function, len(calls), call)
+			}
+			if call > 0 {
+				calls = calls[call-1 : call]
+			}
+
+			var pruned []vm.Trace
+			for i := range calls {
+				pruned = append(pruned, calls[i]...)
+			}
+			if err := os.WriteFile(outputPath, vm.EncodeTrace(pruned), 0644); err != nil {
+				return fmt.Errorf("cannot write trace: %w", err)
+			}
+			if hasMetadata {
+				if err := runner.WriteArtifactMetadata(outputPath, metadata); err != nil {
+					return err
+				}
+			}
+			fmt.Printf("Kept %d steps of %d calls of %s\n", len(pruned), len(calls), function)
+			return nil
+		},
+	}
+}
@@ -0,0 +1,256 @@
+//go:build !minimal
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"math/rand"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	starkcurve "github.com/consensys/gnark-crypto/ecc/stark-curve"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fr"
+	"github.com/urfave/cli/v2"
+)
+
+func init() {
+	registerCommands(genVectorsCommand())
+}
+
+// Vector of a builtin deducing its output cells from its input cells, the cells
+// of an instance being the inputs followed by the outputs
+type builtinVector struct {
+	Inputs  []string `json:"inputs"`
+	Outputs []string `json:"outputs"`
+}
+
+// Vector of the ECDSA builtin, whose instances are the public key and the message
+type ecdsaVector struct {
+	PubKey string `json:"pubkey"`
+	Msg    string `json:"msg"`
+	R      string `json:"r"`
+	S      string `json:"s"`
+}
+
+type vectorGenerator func(rng *rand.Rand, count int) (any, error)
+
+var vectorGenerators = map[string]vectorGenerator{
+	builtins.PedersenName: deductionVectors(builtins.PedersenType, func(rng *rand.Rand) []fp.Element {
+		return []fp.Element{randomFelt(rng, 252), randomFelt(rng, 252)}
+	}),
+	builtins.PoseidonName: deductionVectors(builtins.PoseidonType, func(rng *rand.Rand) []fp.Element {
+		return []fp.Element{randomFelt(rng, 252), randomFelt(rng, 252), randomFelt(rng, 252)}
+	}),
+	builtins.BitwiseName: deductionVectors(builtins.BitwiseType, func(rng *rand.Rand) []fp.Element {
+		return []fp.Element{randomFelt(rng, 251), randomFelt(rng, 251)}
+	}),
+	builtins.EcOpName: deductionVectors(builtins.ECOPType, func(rng *rand.Rand) []fp.Element {
+		p := randomPoint(rng)
+		q := randomPoint(rng)
+		return []fp.Element{p.X, p.Y, q.X, q.Y, randomFelt(rng, 251)}
+	}),
+	builtins.KeccakName: deductionVectors(builtins.KeccakType, func(rng *rand.Rand) []fp.Element {
+		inputs := make([]fp.Element, 8)
+		for i := range inputs {
+			inputs[i] = randomFelt(rng, 200)
+		}
+		return inputs
+	}),
+	builtins.ECDSAName: ecdsaVectors,
+}
+
+func genVectorsCommand() *cli.Command {
+	var builtinNames string
+	var count int
+	var seed int64
+	var outputPath string
+	return &cli.Command{
+		Name:  "gen-vectors",
+		Usage: "writes input and output vectors of the builtins computed by the VM, to be checked against cairo-lang",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name:        "builtins",
+				Usage:       "builtins to generate vectors for, separated by commas, all of them when empty",
+				Required:    false,
+				Destination: &builtinNames,
+			},
+			&cli.IntFlag{
+				Name:        "count",
+				Usage:       "number of vectors per builtin",
+				Required:    false,
+				Value:       16,
+				Destination: &count,
+			},
+			&cli.Int64Flag{
+				Name:        "seed",
+				Usage:       "seed of the random inputs, the same seed giving the same vectors",
+				Required:    false,
+				Destination: &seed,
+			},
+			&cli.StringFlag{
+				Name:        "output",
+				Usage:       "path of the vectors, printed when empty",
+				Required:    false,
+				Destination: &outputPath,
+			},
+		},
+		Action: func(ctx *cli.Context) error {
+			names := strings.FieldsFunc(builtinNames, func(r rune) bool { return r == ',' })
+			if len(names) == 0 {
+				for name := range vectorGenerators {
+					names = append(names, name)
+				}
+			}
+			if count <= 0 {
+				return fmt.Errorf("--count must be positive")
+			}
+			// each builtin gets its own generator, so its vectors don't depend on
+			// the other builtins selected
+			sort.Strings(names)
+			vectors := make(map[string]any, len(names))
+			for _, name := range names {
+				generate, ok := vectorGenerators[name]
+				if !ok {
+					return fmt.Errorf("no vectors for builtin %s", name)
+				}
+				var err error
+				vectors[name], err = generate(rand.New(rand.NewSource(seed)), count)
+				if err != nil {
+					return fmt.Errorf("%s vectors: %w", name, err)
+				}
+			}
+
+			content, err := json.MarshalIndent(map[string]any{"seed": seed, "vectors": vectors}, "", "  ")
+			if err != nil {
+				return err
+			}
+			if outputPath == "" {
+				fmt.Println(string(content))
+				return nil
+			}
+			if err := os.WriteFile(outputPath, content, 0644); err != nil {
+				return fmt.Errorf("cannot write vectors: %w", err)
+			}
+			return nil
+		},
+	}
+}
+
+// Writes random inputs to the instances of a builtin segment and reads back the
+// output cells the runner deduces
+func deductionVectors(builtin builtins.BuiltinType, inputs func(rng *rand.Rand) []fp.Element) vectorGenerator {
+	return func(rng *rand.Rand, count int) (any, error) {
+		// a segment runner, as Runner leaves the deduction caches unset
+		runner := builtins.NewSegmentRunner(builtins.Runner(builtin))
+		cellsPerInstance := runner.GetCellsPerInstance()
+		segment := memory.EmptySegmentWithLength(count * int(cellsPerInstance))
+		segment.WithBuiltinRunner(runner)
+
+		vectors := make([]builtinVector, count)
+		for i := range vectors {
+			offset := uint64(i) * cellsPerInstance
+			instanceInputs := inputs(rng)
+			for j := range instanceInputs {
+				value := memory.MemoryValueFromFieldElement(&instanceInputs[j])
+				if err := segment.Write(offset+uint64(j), &value); err != nil {
+					return nil, err
+				}
+				vectors[i].Inputs = append(vectors[i].Inputs, feltHex(&instanceInputs[j]))
+			}
+			for j := uint64(len(instanceInputs)); j < cellsPerInstance; j++ {
+				value, err := segment.Read(offset + j)
+				if err != nil {
+					return nil, err
+				}
+				vectors[i].Outputs = append(vectors[i].Outputs, feltHex(&value.Felt))
+			}
+		}
+		return vectors, nil
+	}
+}
+
+// Signs random messages with random keys, the signatures being checked by the
+// ECDSA builtin runner
+func ecdsaVectors(rng *rand.Rand, count int) (any, error) {
+	runner := &builtins.ECDSA{}
+	segment := memory.EmptySegmentWithLength(count * int(runner.GetCellsPerInstance()))
+	segment.WithBuiltinRunner(runner)
+
+	order := fr.Modulus()
+	vectors := make([]ecdsaVector, count)
+	for i := range vectors {
+		privateKey := randomScalar(rng, order)
+		var publicKey starkcurve.G1Affine
+		publicKey.ScalarMultiplicationBase(privateKey)
+		msg := randomFelt(rng, 251)
+
+		// s = (msg + r * privateKey) / k, with r the x coordinate of k * G
+		var r, s *big.Int
+		for {
+			k := randomScalar(rng, order)
+			var point starkcurve.G1Affine
+			point.ScalarMultiplicationBase(k)
+			r = point.X.BigInt(new(big.Int))
+			r.Mod(r, order)
+			s = new(big.Int).Mul(r, privateKey)
+			s.Add(s, msg.BigInt(new(big.Int)))
+			s.Mul(s, new(big.Int).ModInverse(k, order))
+			s.Mod(s, order)
+			if r.Sign() != 0 && s.Sign() != 0 {
+				break
+			}
+		}
+
+		offset := uint64(i) * runner.GetCellsPerInstance()
+		rFelt := new(fp.Element).SetBigInt(r)
+		sFelt := new(fp.Element).SetBigInt(s)
+		if err := runner.AddSignature(offset, rFelt, sFelt); err != nil {
+			return nil, err
+		}
+		pubKeyValue := memory.MemoryValueFromFieldElement(&publicKey.X)
+		msgValue := memory.MemoryValueFromFieldElement(&msg)
+		if err := segment.Write(offset, &pubKeyValue); err != nil {
+			return nil, err
+		}
+		if err := segment.Write(offset+1, &msgValue); err != nil {
+			return nil, err
+		}
+		vectors[i] = ecdsaVector{
+			PubKey: feltHex(&publicKey.X),
+			Msg:    feltHex(&msg),
+			R:      feltHex(rFelt),
+			S:      feltHex(sFelt),
+		}
+	}
+	return vectors, nil
+}
+
+// Returns a felt below 2**bits, reduced modulo the prime for 252 bits
+func randomFelt(rng *rand.Rand, bits uint) fp.Element {
+	value := new(big.Int).Rand(rng, new(big.Int).Lsh(big.NewInt(1), bits))
+	var felt fp.Element
+	felt.SetBigInt(value)
+	return felt
+}
+
+// Returns a scalar in [1, order)
+func randomScalar(rng *rand.Rand, order *big.Int) *big.Int {
+	scalar := new(big.Int).Rand(rng, new(big.Int).Sub(order, big.NewInt(1)))
+	return scalar.Add(scalar, big.NewInt(1))
+}
+
+func randomPoint(rng *rand.Rand) starkcurve.G1Affine {
+	var point starkcurve.G1Affine
+	point.ScalarMultiplicationBase(randomScalar(rng, fr.Modulus()))
+	return point
+}
+
+func feltHex(felt *fp.Element) string {
+	return "0x" + felt.Text(16)
+}
@@ -123,6 +123,7 @@ const (
 	traceSuffix          = "_trace"
 	memorySuffix         = "_memory"
 	airPublicInputSuffix = "_air_public_input.json"
+	metadataSuffix       = ".meta.json"
 )
 
 func clean(root string) {
@@ -154,7 +155,8 @@ func isGeneratedFile(path string) bool {
 		strings.HasSuffix(path, pyMemorySuffix) ||
 		strings.HasSuffix(path, traceSuffix) ||
 		strings.HasSuffix(path, memorySuffix) ||
-		strings.HasSuffix(path, airPublicInputSuffix)
+		strings.HasSuffix(path, airPublicInputSuffix) ||
+		strings.HasSuffix(path, metadataSuffix)
 }
 
 // If any other layouts are needed, add the suffix checks here.
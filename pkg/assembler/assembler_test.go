@@ -624,6 +624,34 @@ func TestCallAbsNegative(t *testing.T) {
 	)
 }
 
+func TestNegativeOffsetBounds(t *testing.T) {
+	encode := parseSingleInstruction("[fp + -32768] = [ap + 32767];")
+	assert.Equal(t, biased(-32768), uint16(encode))
+	assert.Equal(t, biased(32767), uint16(encode>>32))
+
+	// decoding the encoding gives back the original offsets
+	instr, err := DecodeInstruction(new(f.Element).SetUint64(encode))
+	assert.NoError(t, err)
+	assert.Equal(t, int16(-32768), instr.OffDest)
+	assert.Equal(t, int16(32767), instr.OffOp1)
+
+	_, _, err = CasmToBytecode("[fp + -32769] = [ap];")
+	assert.ErrorContains(t, err, "offset value outside of [-2**15, 2**15)")
+	_, _, err = CasmToBytecode("[fp] = [ap + 32768];")
+	assert.ErrorContains(t, err, "offset value outside of [-2**15, 2**15)")
+}
+
+func TestNegativeImmediateIsReducedModuloPrime(t *testing.T) {
+	_, imm := parseImmediateInstruction("[ap] = -1;")
+
+	// P - 1
+	expectedImm, err := new(f.Element).SetString(
+		"3618502788666131213697322783095070105623107215331596699973092056135872020480",
+	)
+	assert.NoError(t, err)
+	assert.Equal(t, *expectedImm, *imm)
+}
+
 func parseImmediateInstruction(casmCode string) (uint64, *f.Element) {
 	instructions, _, err := CasmToBytecode(casmCode)
 	if err != nil {
@@ -186,7 +186,7 @@ func signedOffset(neg bool, value int) (int16, error) {
 		value = -value
 	}
 	if value > math.MaxInt16 || value < math.MinInt16 {
-		return 0, fmt.Errorf("offset value outside of (-2**16, 2**16)")
+		return 0, fmt.Errorf("offset value outside of [-2**15, 2**15)")
 	}
 	return int16(value), nil
 }
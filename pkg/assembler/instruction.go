@@ -1,7 +1,9 @@
 package assembler
 
 import (
+	"encoding/json"
 	"fmt"
+	"math/big"
 
 	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
@@ -146,6 +148,55 @@ const (
 	OpCodeAssertEq
 )
 
+// Opcode extensions reuse the flags of the instruction with new semantics. They are
+// encoded above the flags, starting at bit 63, and are only accepted by the layouts
+// enabling them
+type OpcodeExtension uint8
+
+func (ext OpcodeExtension) String() string {
+	switch ext {
+	case OpcodeExtensionStone:
+		return "stone"
+	case OpcodeExtensionBlake:
+		return "blake"
+	case OpcodeExtensionBlakeFinalize:
+		return "blake_finalize"
+	case OpcodeExtensionQM31:
+		return "qm31_operation"
+	default:
+		return fmt.Sprintf("unknown opcode extension %d", uint8(ext))
+	}
+}
+
+func (ext OpcodeExtension) MarshalJSON() ([]byte, error) {
+	return json.Marshal(ext.String())
+}
+
+func (ext *OpcodeExtension) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("unmarshal opcode extension: %w", err)
+	}
+	for e := OpcodeExtensionStone; e <= OpcodeExtensionQM31; e++ {
+		if e.String() == name {
+			*ext = e
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshal unknown opcode extension: %s", name)
+}
+
+const (
+	// The original instruction set, executed by every layout
+	OpcodeExtensionStone OpcodeExtension = iota
+	// Blake2s compression of a message
+	OpcodeExtensionBlake
+	// Blake2s compression of the last block of a message
+	OpcodeExtensionBlakeFinalize
+	// Addition and multiplication over the QM31 extension field of M31
+	OpcodeExtensionQM31
+)
+
 type Word interface{}
 
 type Immediate = string
@@ -168,6 +219,9 @@ type Instruction struct {
 
 	// Defines which instruction needs to be executed
 	Opcode Opcode
+
+	// Changes the semantics of the instruction when it isn't stone
+	OpcodeExtension OpcodeExtension
 }
 
 func (instr Instruction) Size() uint8 {
@@ -189,6 +243,7 @@ func (i Instruction) String() string {
         Pc Update: %s
         Ap Update: %s
         Opcode: %s
+        Opcode Extension: %s
     `,
 		i.OffDest,
 		i.DstRegister,
@@ -200,6 +255,7 @@ func (i Instruction) String() string {
 		i.PcUpdate,
 		i.ApUpdate,
 		i.Opcode,
+		i.OpcodeExtension,
 	)
 }
 
@@ -208,6 +264,8 @@ const (
 	op0Offset   = 16
 	op1Offset   = 32
 	flagsOffset = 48
+	// Bits above the 15 flags
+	opcodeExtensionOffset = 63
 
 	// Relative to flagsOffset
 	dstRegBit         = 0
@@ -237,10 +295,13 @@ const (
 *    Decode the bytecode into an instruction
  */
 func DecodeInstruction(rawInstruction *f.Element) (*Instruction, error) {
-	if !rawInstruction.IsUint64() {
-		return nil, fmt.Errorf("%s is bigger than 64 bits", rawInstruction.Text(10))
+	raw := rawInstruction.BigInt(new(big.Int))
+	extension := new(big.Int).Rsh(raw, opcodeExtensionOffset)
+	if !extension.IsUint64() || extension.Uint64() > uint64(OpcodeExtensionQM31) {
+		return nil, fmt.Errorf("%s has an unknown opcode extension %s", rawInstruction.Text(10), extension.Text(10))
 	}
-	offDstEnc, offOp0Enc, offOp1Enc, flags := decodeInstructionValues(rawInstruction.Uint64())
+	encoding := raw.Uint64() &^ (1 << opcodeExtensionOffset)
+	offDstEnc, offOp0Enc, offOp1Enc, flags := decodeInstructionValues(encoding)
 
 	// Create empty instruction
 	instruction := new(Instruction)
@@ -255,9 +316,34 @@ func DecodeInstruction(rawInstruction *f.Element) (*Instruction, error) {
 		return nil, fmt.Errorf("flags: %w", err)
 	}
 
+	instruction.OpcodeExtension = OpcodeExtension(extension.Uint64())
+	if err := checkOpcodeExtension(instruction); err != nil {
+		return nil, fmt.Errorf("opcode extension %s: %w", instruction.OpcodeExtension, err)
+	}
+
 	return instruction, nil
 }
 
+// Opcode extensions only give meaning to some combinations of flags, as defined
+// by the reference VM
+func checkOpcodeExtension(instruction *Instruction) error {
+	switch instruction.OpcodeExtension {
+	case OpcodeExtensionBlake, OpcodeExtensionBlakeFinalize:
+		if instruction.Opcode != OpCodeNop ||
+			(instruction.Op1Source != FpPlusOffOp1 && instruction.Op1Source != ApPlusOffOp1) ||
+			instruction.Res != Op1 ||
+			instruction.PcUpdate != PcUpdateNextInstr ||
+			(instruction.ApUpdate != SameAp && instruction.ApUpdate != Add1) {
+			return fmt.Errorf("blake instructions must have no opcode, an fp or ap based op1, op1 res logic, a regular pc update and no ap update other than add 1")
+		}
+	case OpcodeExtensionQM31:
+		if instruction.Res != AddOperands && instruction.Res != MulOperands {
+			return fmt.Errorf("qm31 instructions must have add or mul res logic")
+		}
+	}
+	return nil
+}
+
 // break the instruction into 4 segments of 16 bits
 // |         off0            |
 // |         off1            |
@@ -402,6 +488,10 @@ func encodeOneInstruction(instruction *Instruction) (*f.Element, error) {
 
 	// Create a new f.Element from the raw instruction
 	element := new(f.Element).SetUint64(rawInstruction)
+	if instruction.OpcodeExtension != OpcodeExtensionStone {
+		extension := new(big.Int).Lsh(big.NewInt(int64(instruction.OpcodeExtension)), opcodeExtensionOffset)
+		element.Add(element, new(f.Element).SetBigInt(extension))
+	}
 
 	return element, nil
 }
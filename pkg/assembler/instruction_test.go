@@ -152,13 +152,53 @@ func TestAddAp(t *testing.T) {
 	assert.Equal(t, expected, *decoded)
 }
 
-func TestBiggerThan64Bits(t *testing.T) {
-	instruction := new(f.Element).SetBigInt(big.NewInt(1).Lsh(big.NewInt(1), 64))
+func TestBiggerThan66Bits(t *testing.T) {
+	// bits above the 63 bits of the stone encoding hold the opcode extension
+	instruction := new(f.Element).SetBigInt(big.NewInt(1).Lsh(big.NewInt(1), 66))
 
 	_, err := DecodeInstruction(instruction)
 
 	require.Error(t, err)
-	assert.ErrorContains(t, err, "is bigger than 64 bits")
+	assert.ErrorContains(t, err, "has an unknown opcode extension 8")
+}
+
+func TestDecodeOpcodeExtension(t *testing.T) {
+	// [ap + 0] = [fp + 1] + [fp + 2] over qm31
+	expected := Instruction{
+		OffDest:         0,
+		OffOp0:          1,
+		OffOp1:          2,
+		DstRegister:     Ap,
+		Op0Register:     Fp,
+		Op1Source:       FpPlusOffOp1,
+		Res:             AddOperands,
+		PcUpdate:        PcUpdateNextInstr,
+		ApUpdate:        SameAp,
+		Opcode:          OpCodeAssertEq,
+		OpcodeExtension: OpcodeExtensionQM31,
+	}
+	encoded, err := encodeOneInstruction(&expected)
+	require.NoError(t, err)
+	require.Equal(t, uint64(OpcodeExtensionQM31), new(big.Int).Rsh(encoded.BigInt(new(big.Int)), 63).Uint64())
+
+	decoded, err := DecodeInstruction(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, expected, *decoded)
+
+	// blake instructions can't assert an equality
+	expected.OpcodeExtension = OpcodeExtensionBlake
+	expected.Res = Op1
+	encoded, err = encodeOneInstruction(&expected)
+	require.NoError(t, err)
+	_, err = DecodeInstruction(encoded)
+	assert.ErrorContains(t, err, "opcode extension blake: blake instructions must have no opcode")
+
+	expected.Opcode = OpCodeNop
+	encoded, err = encodeOneInstruction(&expected)
+	require.NoError(t, err)
+	decoded, err = DecodeInstruction(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, expected, *decoded)
 }
 
 func TestInvalidOpOneAddress(t *testing.T) {
@@ -0,0 +1,53 @@
+// Package field is an experimental abstraction over the fields the VM can run over.
+// The VM core only runs over the STARK field with fp.Element, which satisfies
+// Element as is. The M31 and QM31 fields used by newer provers implement it too, so
+// that the core arithmetic can be ported to them without touching the default path.
+package field
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// Element is the arithmetic the VM core needs from a field element E, following the
+// API of fp.Element: methods set their receiver to the result and return it
+type Element[E any] interface {
+	*E
+	Add(x, y *E) *E
+	Sub(x, y *E) *E
+	Mul(x, y *E) *E
+	Neg(x *E) *E
+	// Sets the receiver to the inverse of x, or to zero if x is zero
+	Inverse(x *E) *E
+	SetUint64(v uint64) *E
+	SetOne() *E
+	IsZero() bool
+	Equal(x *E) bool
+	Text(base int) string
+}
+
+// The STARK field stays the default backend of the VM, instantiating Div checks
+// fp.Element satisfies Element
+var _ = Div[fp.Element, *fp.Element]
+
+// Div sets z to x / y and returns it. Dividing by zero sets z to zero, like the
+// inverse of zero
+func Div[E any, P Element[E]](z, x, y *E) *E {
+	var inverse E
+	P(&inverse).Inverse(y)
+	return P(z).Mul(x, &inverse)
+}
+
+// Exp sets z to x**exponent and returns it
+func Exp[E any, P Element[E]](z, x *E, exponent uint64) *E {
+	var result, base E
+	P(&result).SetOne()
+	base = *x
+	for ; exponent > 0; exponent >>= 1 {
+		if exponent&1 == 1 {
+			P(&result).Mul(&result, &base)
+		}
+		P(&base).Mul(&base, &base)
+	}
+	*z = result
+	return z
+}
@@ -0,0 +1,120 @@
+package field
+
+import (
+	"math/big"
+	"math/rand"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// Checks the field axioms the VM relies on over random elements
+func testFieldAxioms[E any, P Element[E]](t *testing.T, random func(r *rand.Rand) E) {
+	r := rand.New(rand.NewSource(0))
+	var zero, one E
+	P(&one).SetOne()
+	require.True(t, P(&zero).IsZero())
+
+	for i := 0; i < 1000; i++ {
+		x, y, w := random(r), random(r), random(r)
+		var a, b, c E
+
+		// commutativity
+		P(&a).Add(&x, &y)
+		P(&b).Add(&y, &x)
+		require.True(t, P(&a).Equal(&b))
+		P(&a).Mul(&x, &y)
+		P(&b).Mul(&y, &x)
+		require.True(t, P(&a).Equal(&b))
+
+		// distributivity
+		P(&a).Add(&y, &w)
+		P(&a).Mul(&x, &a)
+		P(&b).Mul(&x, &y)
+		P(&c).Mul(&x, &w)
+		P(&b).Add(&b, &c)
+		require.True(t, P(&a).Equal(&b), "%s * (%s + %s)", P(&x).Text(10), P(&y).Text(10), P(&w).Text(10))
+
+		// inverses
+		P(&a).Sub(&x, &y)
+		P(&a).Add(&a, &y)
+		require.True(t, P(&a).Equal(&x))
+		P(&a).Neg(&x)
+		P(&a).Add(&a, &x)
+		require.True(t, P(&a).IsZero())
+		if !P(&y).IsZero() {
+			Div[E, P](&a, &x, &y)
+			P(&a).Mul(&a, &y)
+			require.True(t, P(&a).Equal(&x))
+		}
+	}
+
+	P(&zero).Inverse(&zero)
+	require.True(t, P(&zero).IsZero())
+}
+
+func TestStarkFieldAxioms(t *testing.T) {
+	testFieldAxioms(t, func(r *rand.Rand) fp.Element {
+		var x fp.Element
+		x.SetUint64(r.Uint64())
+		return *x.Mul(&x, new(fp.Element).SetUint64(r.Uint64()))
+	})
+}
+
+func TestM31FieldAxioms(t *testing.T) {
+	testFieldAxioms(t, func(r *rand.Rand) M31 {
+		// edge values are more likely than with a uniform draw
+		if r.Intn(4) == 0 {
+			return M31(M31Modulus - 1 - r.Intn(2))
+		}
+		return NewM31(r.Uint64())
+	})
+}
+
+func TestQM31FieldAxioms(t *testing.T) {
+	testFieldAxioms(t, func(r *rand.Rand) QM31 {
+		return QM31{NewM31(r.Uint64()), NewM31(r.Uint64()), NewM31(r.Uint64()), NewM31(r.Uint64())}
+	})
+}
+
+func TestM31(t *testing.T) {
+	require.Equal(t, M31(0), NewM31(M31Modulus))
+	require.Equal(t, M31(5), NewM31(2*M31Modulus+5))
+
+	max := M31(M31Modulus - 1)
+	var z M31
+	require.Equal(t, M31(M31Modulus-2), *z.Add(&max, &max))
+	require.Equal(t, M31(1), *z.Mul(&max, &max))
+	require.Equal(t, max, *z.Sub(new(M31), new(M31).SetOne()))
+
+	two := M31(2)
+	require.Equal(t, M31(1<<30), *z.Inverse(&two))
+	require.Equal(t, M31(1), *Exp(&z, &two, 31))
+}
+
+func TestQM31(t *testing.T) {
+	i := QM31{0, 1}
+	u := QM31{0, 0, 1}
+	var z QM31
+	require.Equal(t, QM31{M31Modulus - 1}, *z.Mul(&i, &i))
+	require.Equal(t, QM31{2, 1}, *z.Mul(&u, &u))
+	require.Equal(t, "(0 + 0*i) + (1 + 0*i)*u", u.String())
+
+	x := QM31{1, 2, 3, M31Modulus - 1}
+	felt := x.Felt()
+	require.Equal(t, "0x7ffffffe000000003000000002000000001", "0x"+felt.Text(16))
+	unpacked, err := QM31FromFelt(&felt)
+	require.NoError(t, err)
+	require.Equal(t, x, unpacked)
+
+	var unreduced fp.Element
+	unreduced.SetUint64(M31Modulus)
+	_, err = QM31FromFelt(&unreduced)
+	require.ErrorContains(t, err, "coordinate 0 is 2147483647, not reduced modulo 2**31 - 1")
+
+	var large fp.Element
+	large.Exp(*new(fp.Element).SetUint64(2), big.NewInt(144))
+	_, err = QM31FromFelt(&large)
+	require.ErrorContains(t, err, "more than 144 bits")
+}
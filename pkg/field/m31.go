@@ -0,0 +1,79 @@
+package field
+
+import (
+	"strconv"
+)
+
+// Modulus of the M31 field, the mersenne prime 2**31 - 1
+const M31Modulus = 1<<31 - 1
+
+// M31 is an element of the field of integers modulo 2**31 - 1, always reduced
+type M31 uint32
+
+var _ = Div[M31, *M31]
+
+func NewM31(v uint64) M31 {
+	return M31(v % M31Modulus)
+}
+
+func (z *M31) Add(x, y *M31) *M31 {
+	*z = reduceM31(uint64(*x) + uint64(*y))
+	return z
+}
+
+func (z *M31) Sub(x, y *M31) *M31 {
+	*z = reduceM31(uint64(*x) + M31Modulus - uint64(*y))
+	return z
+}
+
+func (z *M31) Mul(x, y *M31) *M31 {
+	*z = reduceM31(uint64(*x) * uint64(*y))
+	return z
+}
+
+func (z *M31) Neg(x *M31) *M31 {
+	*z = reduceM31(M31Modulus - uint64(*x))
+	return z
+}
+
+// Inverse uses Fermat's little theorem, x**(p - 2) being zero when x is zero
+func (z *M31) Inverse(x *M31) *M31 {
+	return Exp(z, x, M31Modulus-2)
+}
+
+func (z *M31) SetUint64(v uint64) *M31 {
+	*z = NewM31(v)
+	return z
+}
+
+func (z *M31) SetOne() *M31 {
+	*z = 1
+	return z
+}
+
+func (z *M31) IsZero() bool {
+	return *z == 0
+}
+
+func (z *M31) Equal(x *M31) bool {
+	return *z == *x
+}
+
+func (z *M31) Text(base int) string {
+	return strconv.FormatUint(uint64(*z), base)
+}
+
+func (z M31) String() string {
+	return z.Text(10)
+}
+
+// Reduces values below 2**62, the largest product of two elements
+func reduceM31(v uint64) M31 {
+	// 2**31 = 1 mod p, so the high bits can be folded onto the low ones
+	v = (v & M31Modulus) + (v >> 31)
+	v = (v & M31Modulus) + (v >> 31)
+	if v >= M31Modulus {
+		v -= M31Modulus
+	}
+	return M31(v)
+}
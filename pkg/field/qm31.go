@@ -0,0 +1,174 @@
+package field
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// Number of bits of each coordinate of a QM31 packed in a felt
+const qm31CoordinateBits = 36
+
+// QM31 is an element of the degree 4 extension of M31 used by stwo, built as
+// CM31[u] / (u**2 - 2 - i) over CM31 = M31[i] / (i**2 + 1). The coordinates
+// [a, b, c, d] stand for (a + b*i) + (c + d*i)*u
+type QM31 [4]M31
+
+var _ = Div[QM31, *QM31]
+
+// a complex M31, a + b*i
+type cm31 struct {
+	a, b M31
+}
+
+func (x cm31) add(y cm31) cm31 {
+	var z cm31
+	z.a.Add(&x.a, &y.a)
+	z.b.Add(&x.b, &y.b)
+	return z
+}
+
+func (x cm31) sub(y cm31) cm31 {
+	var z cm31
+	z.a.Sub(&x.a, &y.a)
+	z.b.Sub(&x.b, &y.b)
+	return z
+}
+
+// (a + b*i)(c + d*i) = (ac - bd) + (ad + bc)*i
+func (x cm31) mul(y cm31) cm31 {
+	var z cm31
+	var ac, bd, ad, bc M31
+	ac.Mul(&x.a, &y.a)
+	bd.Mul(&x.b, &y.b)
+	ad.Mul(&x.a, &y.b)
+	bc.Mul(&x.b, &y.a)
+	z.a.Sub(&ac, &bd)
+	z.b.Add(&ad, &bc)
+	return z
+}
+
+// 1 / (a + b*i) = (a - b*i) / (a**2 + b**2)
+func (x cm31) inverse() cm31 {
+	var norm, squared, inverse M31
+	norm.Mul(&x.a, &x.a)
+	squared.Mul(&x.b, &x.b)
+	norm.Add(&norm, &squared)
+	inverse.Inverse(&norm)
+
+	var z cm31
+	z.a.Mul(&x.a, &inverse)
+	z.b.Neg(&x.b)
+	z.b.Mul(&z.b, &inverse)
+	return z
+}
+
+// the non residue u**2 = 2 + i
+var qm31NonResidue = cm31{a: 2, b: 1}
+
+func (z *QM31) parts() (cm31, cm31) {
+	return cm31{z[0], z[1]}, cm31{z[2], z[3]}
+}
+
+func (z *QM31) setParts(x, y cm31) *QM31 {
+	*z = QM31{x.a, x.b, y.a, y.b}
+	return z
+}
+
+func (z *QM31) Add(x, y *QM31) *QM31 {
+	for i := range z {
+		z[i].Add(&x[i], &y[i])
+	}
+	return z
+}
+
+func (z *QM31) Sub(x, y *QM31) *QM31 {
+	for i := range z {
+		z[i].Sub(&x[i], &y[i])
+	}
+	return z
+}
+
+// (x1 + y1*u)(x2 + y2*u) = (x1x2 + (2 + i)y1y2) + (x1y2 + y1x2)*u
+func (z *QM31) Mul(x, y *QM31) *QM31 {
+	x1, y1 := x.parts()
+	x2, y2 := y.parts()
+	return z.setParts(
+		x1.mul(x2).add(qm31NonResidue.mul(y1.mul(y2))),
+		x1.mul(y2).add(y1.mul(x2)),
+	)
+}
+
+func (z *QM31) Neg(x *QM31) *QM31 {
+	for i := range z {
+		z[i].Neg(&x[i])
+	}
+	return z
+}
+
+// 1 / (x + y*u) = (x - y*u) / (x**2 - (2 + i)y**2)
+func (z *QM31) Inverse(x *QM31) *QM31 {
+	real, imaginary := x.parts()
+	inverse := real.mul(real).sub(qm31NonResidue.mul(imaginary.mul(imaginary))).inverse()
+	return z.setParts(real.mul(inverse), cm31{}.sub(imaginary).mul(inverse))
+}
+
+func (z *QM31) SetUint64(v uint64) *QM31 {
+	*z = QM31{NewM31(v)}
+	return z
+}
+
+func (z *QM31) SetOne() *QM31 {
+	*z = QM31{1}
+	return z
+}
+
+func (z *QM31) IsZero() bool {
+	return *z == QM31{}
+}
+
+func (z *QM31) Equal(x *QM31) bool {
+	return *z == *x
+}
+
+func (z *QM31) Text(base int) string {
+	return fmt.Sprintf("(%s + %s*i) + (%s + %s*i)*u", z[0].Text(base), z[1].Text(base), z[2].Text(base), z[3].Text(base))
+}
+
+func (z QM31) String() string {
+	return z.Text(10)
+}
+
+// Felt packs the coordinates in a felt, 36 bits each starting with a, as the
+// qm31 opcode extension expects its operands
+func (z *QM31) Felt() fp.Element {
+	packed := new(big.Int)
+	for i := len(z) - 1; i >= 0; i-- {
+		packed.Lsh(packed, qm31CoordinateBits)
+		packed.Or(packed, big.NewInt(int64(z[i])))
+	}
+	var felt fp.Element
+	felt.SetBigInt(packed)
+	return felt
+}
+
+// QM31FromFelt unpacks a felt packed by QM31.Felt. It fails if the felt has more
+// than 144 bits or if a coordinate isn't reduced modulo 2**31 - 1
+func QM31FromFelt(felt *fp.Element) (QM31, error) {
+	packed := felt.BigInt(new(big.Int))
+	if packed.BitLen() > 4*qm31CoordinateBits {
+		return QM31{}, fmt.Errorf("%s is not a packed qm31: more than %d bits", felt.Text(10), 4*qm31CoordinateBits)
+	}
+	mask := big.NewInt(1<<qm31CoordinateBits - 1)
+	var z QM31
+	for i := range z {
+		coordinate := new(big.Int).And(packed, mask).Uint64()
+		if coordinate >= M31Modulus {
+			return QM31{}, fmt.Errorf("%s is not a packed qm31: coordinate %d is %d, not reduced modulo 2**31 - 1", felt.Text(10), i, coordinate)
+		}
+		z[i] = M31(coordinate)
+		packed.Rsh(packed, qm31CoordinateBits)
+	}
+	return z, nil
+}
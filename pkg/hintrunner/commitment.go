@@ -0,0 +1,63 @@
+package hintrunner
+
+import (
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// InputCommitment hashes every memory write done by a hint, i.e. all the
+// nondeterministic data a run depends on: program input, signatures, oracle
+// responses... Writes are absorbed in order by a Poseidon sponge of rate 2, like
+// `poseidon_hash_many` of the cairo common library, so the commitment can be
+// recomputed by a cairo program. Each write is absorbed as the 6 felts
+//
+//	segment, offset, 0, value, 0, 0        for a felt value
+//	segment, offset, 1, segment, offset, 0 for an address value
+//
+// Segment indexes are absorbed as felts, temporary segments being negative
+type InputCommitment struct {
+	state  [3]fp.Element
+	writes uint64
+}
+
+func (commitment *InputCommitment) absorb(address mem.MemoryAddress, value *mem.MemoryValue) {
+	var felts [6]fp.Element
+	felts[0].SetInt64(int64(address.SegmentIndex))
+	felts[1].SetUint64(address.Offset)
+	if value.IsAddress() {
+		target, _ := value.MemoryAddress()
+		felts[2].SetOne()
+		felts[3].SetInt64(int64(target.SegmentIndex))
+		felts[4].SetUint64(target.Offset)
+	} else {
+		felt, _ := value.FieldElement()
+		felts[3].Set(felt)
+	}
+	for i := 0; i < len(felts); i += 2 {
+		commitment.state[0].Add(&commitment.state[0], &felts[i])
+		commitment.state[1].Add(&commitment.state[1], &felts[i+1])
+		commitment.permute()
+	}
+	commitment.writes++
+}
+
+func (commitment *InputCommitment) permute() {
+	state := builtins.PoseidonPerm(&commitment.state[0], &commitment.state[1], &commitment.state[2])
+	copy(commitment.state[:], state)
+}
+
+// Writes gives the number of hint writes absorbed so far
+func (commitment *InputCommitment) Writes() uint64 {
+	return commitment.writes
+}
+
+// Digest gives the commitment to the writes absorbed so far, padding them with
+// a single 1 before the last permutation. More writes can be absorbed afterwards
+func (commitment *InputCommitment) Digest() fp.Element {
+	final := *commitment
+	one := fp.One()
+	final.state[0].Add(&final.state[0], &one)
+	final.permute()
+	return final.state[0]
+}
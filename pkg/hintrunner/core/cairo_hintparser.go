@@ -5,6 +5,7 @@ import (
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
@@ -299,6 +300,6 @@ func GetHintByName(hint starknet.Hint) (hinter.Hinter, error) {
 			sqrt: parseCellRefer(args.Sqrt),
 		}, nil
 	default:
-		return nil, fmt.Errorf("unknown hint: %v", hint.Name)
+		return nil, utils.WithErrorCode(utils.ErrorCodeUnknownHint, fmt.Errorf("unknown hint: %v", hint.Name))
 	}
 }
@@ -58,6 +58,33 @@ func (hint *AllocSegment) Execute(vm *VM.VirtualMachine, _ *hinter.HintRunnerCon
 	return nil
 }
 
+// AllocSegmentArena allocates the segment of the segment arena builtin, whose
+// instances the entry code and the dictionary libfuncs write
+type AllocSegmentArena struct {
+	Dst hinter.Reference
+}
+
+func (hint *AllocSegmentArena) String() string {
+	return "AllocSegmentArena"
+}
+
+func (hint *AllocSegmentArena) Execute(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+	newSegment := vm.Memory.AllocateBuiltinSegment(&builtins.SegmentArena{})
+	memAddress := mem.MemoryValueFromMemoryAddress(&newSegment)
+
+	regAddr, err := hint.Dst.Get(vm)
+	if err != nil {
+		return fmt.Errorf("get register %s: %w", hint.Dst, err)
+	}
+
+	err = vm.Memory.WriteToAddress(&regAddr, &memAddress)
+	if err != nil {
+		return fmt.Errorf("write to address %s: %w", regAddr, err)
+	}
+
+	return nil
+}
+
 type EvalCircuit struct {
 	AddModN   hinter.Reference
 	AddModPtr hinter.Reference
@@ -1972,15 +1999,15 @@ func (hint *ExternalWriteArgsToMemory) Execute(vm *VM.VirtualMachine, ctx *hinte
 				return fmt.Errorf("write array base: %v", err)
 			}
 			apOffset++
-			arrayEnd := arrayBase
-			for _, val := range arg.Array {
-				mv := mem.MemoryValueFromFieldElement(&val)
-				err := vm.Memory.Write(arrayEnd.SegmentIndex, arrayEnd.Offset, &mv)
-				if err != nil {
-					return fmt.Errorf("write array element: %v", err)
-				}
-				arrayEnd.Offset += 1
+			elements := make([]mem.MemoryValue, len(arg.Array))
+			for i := range arg.Array {
+				elements[i] = mem.MemoryValueFromFieldElement(&arg.Array[i])
 			}
+			if err := vm.Memory.WriteRange(&arrayBase, elements); err != nil {
+				return fmt.Errorf("write array elements: %v", err)
+			}
+			arrayEnd := arrayBase
+			arrayEnd.Offset += uint64(len(elements))
 			mv = mem.MemoryValueFromMemoryAddress(&arrayEnd)
 			err = vm.Memory.Write(1, apOffset, &mv)
 			if err != nil {
@@ -47,6 +47,21 @@ func TestAllocSegment(t *testing.T) {
 
 }
 
+func TestAllocSegmentArena(t *testing.T) {
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Ap = 3
+
+	alloc := AllocSegmentArena{hinter.ApCellRef(0)}
+	require.NoError(t, alloc.Execute(vm, nil))
+	require.Equal(t, 3, len(vm.Memory.Segments))
+	require.Equal(
+		t,
+		mem.MemoryValueFromSegmentAndOffset(2, 0),
+		utils.ReadFrom(vm, VM.ExecutionSegment, vm.Context.Ap),
+	)
+	require.IsType(t, &builtins.SegmentArena{}, vm.Memory.Segments[2].BuiltinRunner)
+}
+
 func TestTestLessThanTrue(t *testing.T) {
 	vm := VM.DefaultVirtualMachine()
 	vm.Context.Ap = 0
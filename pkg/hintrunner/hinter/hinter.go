@@ -5,6 +5,7 @@ import (
 
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
 type Hinter interface {
@@ -21,6 +22,14 @@ type HintRunnerContext struct {
 	ScopeManager              ScopeManager
 	// points towards free memory of a segment
 	ConstantSizeSegment mem.MemoryAddress
+	// lets keccak hints verify permutations natively instead of running the
+	// Cairo verification, only to be enabled outside of proof mode
+	KeccakAcceleration bool
+	// code the load_program hint can load, by the hash of the program
+	LoadablePrograms map[fp.Element][]*fp.Element
+	// runs the programs of the run_nested_program hint, nil when the runner has no
+	// nested program
+	NestedRunner NestedRunner
 }
 
 func InitializeDefaultContext() *HintRunnerContext {
@@ -0,0 +1,27 @@
+package hinter
+
+import "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+
+// NestedRun is a run of a program that a hint asks for, e.g. a bootloader running
+// the programs it loads. The program runs in a VM of its own, with its own memory,
+// hints context and step limit, so that it cannot alter the state of the run of the
+// hint
+type NestedRun struct {
+	// hash of the program, see runner.Runner.AddNestedProgram
+	ProgramHash fp.Element
+	// limits the steps of the nested run, zero for the step limit of the parent run
+	MaxSteps uint64
+}
+
+// NestedRunResult is what a nested run gives back to the hint
+type NestedRunResult struct {
+	// cells of the output segment of the nested run
+	Output []*fp.Element
+	Steps  uint64
+}
+
+// NestedRunner runs programs on behalf of the hints. It rejects the runs nested too
+// deeply and the ones of a program already running, which would never end
+type NestedRunner interface {
+	RunNested(run *NestedRun) (NestedRunResult, error)
+}
@@ -220,6 +220,10 @@ func (bop BinaryOp) Resolve(vm *VM.VirtualMachine) (mem.MemoryValue, error) {
 		mv := mem.EmptyMemoryValueAsFelt()
 		err := mv.Mul(&lhs, &rhs)
 		return mv, err
+	case Sub:
+		mv := mem.EmptyMemoryValueAs(lhs.IsAddress() && !rhs.IsAddress())
+		err := mv.Sub(&lhs, &rhs)
+		return mv, err
 	default:
 		return mem.UnknownValue, fmt.Errorf("unknown binary operator: %d", bop.Operator)
 	}
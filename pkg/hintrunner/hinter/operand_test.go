@@ -186,3 +186,39 @@ func TestResolveMulOp(t *testing.T) {
 	require.Equal(t, memory.MemoryValueFromInt(500), res)
 
 }
+
+func TestResolveSubOp(t *testing.T) {
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Fp = 0
+	vm.Context.Ap = 5
+	utils.WriteTo(
+		vm,
+		VM.ExecutionSegment, vm.Context.Ap+7,
+		memory.MemoryValueFromInt(3),
+	)
+	utils.WriteTo(
+		vm,
+		VM.ExecutionSegment, vm.Context.Fp+20,
+		memory.MemoryValueFromSegmentAndOffset(4, 29),
+	)
+
+	// felts wrap around the prime
+	bop := BinaryOp{
+		Operator: Sub,
+		Lhs:      Deref{ApCellRef(7)},
+		Rhs:      Immediate(f.NewElement(5)),
+	}
+	res, err := bop.Resolve(vm)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromInt(-2), res)
+
+	// subtracting a felt from an address gives an address
+	bop = BinaryOp{
+		Operator: Sub,
+		Lhs:      Deref{FpCellRef(20)},
+		Rhs:      Deref{ApCellRef(7)},
+	}
+	res, err = bop.Resolve(vm)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromSegmentAndOffset(4, 26), res)
+}
@@ -5,6 +5,7 @@ import (
 
 	h "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 )
 
 type HintRunner struct {
@@ -12,6 +13,8 @@ type HintRunner struct {
 	context h.HintRunnerContext
 	// A mapping from program counter to hint implementation
 	hints map[uint64][]h.Hinter
+	// Writes of the hints are absorbed in it when set
+	commitment *InputCommitment
 }
 
 func NewHintRunner(hints map[uint64][]h.Hinter, newHintRunnerContext *h.HintRunnerContext) HintRunner {
@@ -27,17 +30,57 @@ func NewHintRunner(hints map[uint64][]h.Hinter, newHintRunnerContext *h.HintRunn
 }
 
 func (hr *HintRunner) RunHint(vm *VM.VirtualMachine) error {
+	// hints belong to the program, code loaded in other segments runs without hints
+	if vm.Context.Pc.SegmentIndex != VM.ProgramSegment {
+		return nil
+	}
 	hints := hr.hints[vm.Context.Pc.Offset]
 	if len(hints) == 0 {
 		return nil
 	}
 
+	if hr.commitment != nil {
+		// other observers, such as the write history of the runner, keep observing
+		previous := vm.Memory.WriteObserver()
+		vm.Memory.SetWriteObserver(func(address mem.MemoryAddress, value *mem.MemoryValue) {
+			hr.commitment.absorb(address, value)
+			if previous != nil {
+				previous(address, value)
+			}
+		})
+		defer vm.Memory.SetWriteObserver(previous)
+	}
+
+	pc := vm.Context.Pc
 	for _, hint := range hints {
 		err := hint.Execute(vm, &hr.context)
 		if err != nil {
 			return fmt.Errorf("execute hint %s: %v", hint, err)
 		}
+		// a hint returning early from a function moves pc, in which case the
+		// hints of the instruction being jumped to are run instead
+		if vm.Context.Pc != pc {
+			return hr.RunHint(vm)
+		}
 	}
 
 	return nil
 }
+
+// EnableInputCommitment starts hashing every memory write done by a hint, see
+// InputCommitment
+func (hr *HintRunner) EnableInputCommitment() {
+	if hr.commitment == nil {
+		hr.commitment = &InputCommitment{}
+	}
+}
+
+// InputCommitment gives the commitment to the hint writes, nil unless
+// EnableInputCommitment was called
+func (hr *HintRunner) InputCommitment() *InputCommitment {
+	return hr.commitment
+}
+
+func (hr *HintRunner) Context() *h.HintRunnerContext {
+	return &hr.context
+}
@@ -7,7 +7,9 @@ import (
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/utils"
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -54,3 +56,72 @@ func TestNoHint(t *testing.T) {
 	require.Nil(t, err)
 	require.Equal(t, 2, len(vm.Memory.Segments))
 }
+
+type jumpHint struct {
+	pc uint64
+}
+
+func (hint *jumpHint) String() string {
+	return "Jump"
+}
+
+func (hint *jumpHint) Execute(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+	vm.Context.Pc.Offset = hint.pc
+	return nil
+}
+
+func TestHintMovingPc(t *testing.T) {
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Ap = 3
+
+	var ap hinter.ApCellRef = 5
+	hr := NewHintRunner(map[uint64][]hinter.Hinter{
+		// the allocation following the jump is skipped
+		10: {&jumpHint{pc: 20}, &core.AllocSegment{Dst: ap}},
+		20: {&core.AllocSegment{Dst: ap}},
+	}, nil)
+
+	vm.Context.Pc = memory.MemoryAddress{
+		SegmentIndex: 0,
+		Offset:       10,
+	}
+	err := hr.RunHint(vm)
+	require.Nil(t, err)
+	require.Equal(t, uint64(20), vm.Context.Pc.Offset)
+	require.Equal(t, 3, len(vm.Memory.Segments))
+}
+
+func TestInputCommitment(t *testing.T) {
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Ap = 3
+
+	var ap hinter.ApCellRef = 5
+	hr := NewHintRunner(map[uint64][]hinter.Hinter{
+		10: {&core.AllocSegment{Dst: ap}},
+	}, nil)
+	require.Nil(t, hr.InputCommitment())
+	hr.EnableInputCommitment()
+
+	vm.Context.Pc = memory.MemoryAddress{
+		SegmentIndex: 0,
+		Offset:       10,
+	}
+	require.NoError(t, hr.RunHint(vm))
+	// writes done outside of hints are not committed to
+	one := memory.MemoryValueFromUint(uint64(1))
+	require.NoError(t, vm.Memory.Write(VM.ExecutionSegment, 0, &one))
+	require.Equal(t, uint64(1), hr.InputCommitment().Writes())
+
+	// the allocated segment 2:0 is written at 1:8, then the sponge is padded with 1
+	state := make([]fp.Element, 3)
+	for _, felts := range [][2]uint64{{1, 8}, {1, 2}, {0, 0}, {1, 0}} {
+		state[0].Add(&state[0], new(fp.Element).SetUint64(felts[0]))
+		state[1].Add(&state[1], new(fp.Element).SetUint64(felts[1]))
+		state = builtins.PoseidonPerm(&state[0], &state[1], &state[2])
+	}
+	require.Equal(t, state[0], hr.InputCommitment().Digest())
+
+	// the same as poseidon_hash_many of the absorbed felts
+	written := []*fp.Element{new(fp.Element).SetUint64(1), new(fp.Element).SetUint64(8), new(fp.Element).SetUint64(1), new(fp.Element).SetUint64(2), {}, {}}
+	require.Equal(t, builtins.PoseidonHashMany(written), hr.InputCommitment().Digest())
+}
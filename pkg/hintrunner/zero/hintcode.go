@@ -119,6 +119,10 @@ const (
 	verifyZeroAltCode         string = "from starkware.cairo.common.cairo_secp.secp_utils import pack\n\nq, r = divmod(pack(ids.val, PRIME), SECP_P)\nassert r == 0, f\"verify_zero: Invalid input {ids.val.d0, ids.val.d1, ids.val.d2}.\"\nids.q = q % PRIME"
 	divModNPackedDivmodV1Code string = "from starkware.cairo.common.cairo_secp.secp_utils import N, pack\nfrom starkware.python.math_utils import div_mod, safe_div\n\na = pack(ids.a, PRIME)\nb = pack(ids.b, PRIME)\nvalue = res = div_mod(a, b, N)"
 	importSECP256R1NCode      string = "from starkware.cairo.common.cairo_secp.secp256r1_utils import SECP256R1_N as N"
+	// Not a cairo-lang hint: the y parity of the public key spares the builtin one of its two verifications
+	verifyECDSASignatureWithYParityCode string = "ecdsa_builtin.add_signature(ids.ecdsa_ptr.address_, (ids.signature_r, ids.signature_s), y_parity=ids.y_parity)"
+	// Not a cairo-lang hint: the y coordinate of the public key spares the builtin its recovery besides the second verification
+	verifyECDSASignatureWithPublicKeyCode string = "ecdsa_builtin.add_signature(ids.ecdsa_ptr.address_, (ids.signature_r, ids.signature_s), public_key_y=ids.public_key_y)"
 
 	// ------ Blake Hash hints related code ------
 	blake2sAddUint256BigendCode string = "B = 32\nMASK = 2 ** 32 - 1\nsegments.write_arg(ids.data, [(ids.high >> (B * (3 - i))) & MASK for i in range(4)])\nsegments.write_arg(ids.data + 4, [(ids.low >> (B * (3 - i))) & MASK for i in range(4)])"
@@ -153,6 +157,9 @@ const (
 	splitOutput0Code                     string = "ids.output0_low = ids.output0 & ((1 << 128) - 1)\nids.output0_high = ids.output0 >> 128"
 	SplitNBytesCode                      string = "ids.n_words_to_copy, ids.n_bytes_left = divmod(ids.n_bytes, ids.BYTES_IN_WORD)"
 
+	// ------ Poseidon hints related code ------
+	poseidonPermutationCode string = "from starkware.cairo.common.poseidon_hash import poseidon_perm\n_poseidon_state_size_felts = int(ids.POSEIDON_STATE_SIZE_FELTS)\nassert _poseidon_state_size_felts == 3\n\noutput_values = poseidon_perm(*memory.get_range(\n    ids.poseidon_ptr - _poseidon_state_size_felts, _poseidon_state_size_felts))\nsegments.write_arg(ids.poseidon_ptr, output_values)"
+	poseidonFinalizeCode    string = "# Add dummy pairs of input and output.\nfrom starkware.cairo.common.poseidon_hash import poseidon_perm\n_block_size = int(ids.BLOCK_SIZE)\nassert 0 <= _block_size < 10\ninp = [0] * int(ids.POSEIDON_STATE_SIZE_FELTS)\npadding = (inp + poseidon_perm(*inp)) * _block_size\nsegments.write_arg(ids.poseidon_ptr_end, padding)"
 	// ------ Dictionaries hints related code ------
 	dictNewCode                           string = "if '__dict_manager' not in globals():\n    from starkware.cairo.common.dict import DictManager\n    __dict_manager = DictManager()\n\nmemory[ap] = __dict_manager.new_dict(segments, initial_dict)\ndel initial_dict"
 	defaultDictNewCode                    string = "if '__dict_manager' not in globals():\n    from starkware.cairo.common.dict import DictManager\n    __dict_manager = DictManager()\n\nmemory[ap] = __dict_manager.new_default_dict(segments, ids.default_value)"
@@ -189,4 +196,9 @@ const (
 	getHighLenCode            string = "ids.len_hi = max(ids.scalar_u.d2.bit_length(), ids.scalar_v.d2.bit_length())-1"
 	normalizeAddressCode      string = "# Verify the assumptions on the relationship between 2**250, ADDR_BOUND and PRIME.\nADDR_BOUND = ids.ADDR_BOUND % PRIME\nassert (2**250 < ADDR_BOUND <= 2**251) and (2 * 2**250 < PRIME) and (\n        ADDR_BOUND * 2 > PRIME), \\\n    'normalize_address() cannot be used with the current constants.'\nids.is_small = 1 if ids.addr < ADDR_BOUND else 0"
 	sha256AndBlake2sInputCode string = "ids.full_word = int(ids.n_bytes >= 4)"
+	// Not a cairo-lang hint: loads code whose hash is known to the program, as the bootloader does
+	loadProgramCode string = "ids.program_address, ids.program_size = load_program(ids.program_hash)"
+	// Not a cairo-lang hint: runs a program known to the program in a VM of its own and
+	// gives back its output
+	runNestedProgramCode string = "ids.output_address, ids.output_size = run_nested_program(ids.program_hash)"
 )
@@ -101,9 +101,12 @@ type RegisterOffset struct {
 	Offset   *OffsetExp `@@`
 }
 
+// Negative numbers are parenthesized inside a sum, e.g. "fp + (-3)", but not when
+// they are the whole expression, e.g. "cast(-1, felt)"
 type OffsetExp struct {
-	Number    string `@Number |`
-	NegNumber string `"(" "-" @Number ")"`
+	Number        string `@Number |`
+	NegNumber     string `"(" "-" @Number ")" |`
+	LeadingNegNum string `"-" @Number`
 }
 
 type DerefExp struct {
@@ -150,8 +153,12 @@ func (expression DerefCastExp) Evaluate() (hinter.Reference, error) {
 			nil
 	case hinter.BinaryOp:
 		if left, ok := result.Lhs.(hinter.Deref); ok {
-			if right, ok := result.Rhs.(hinter.Immediate); ok {
-				if offset, ok := utils.Int16FromFelt((*fp.Element)(&right)); ok {
+			if right, ok := result.Rhs.(hinter.Immediate); ok && result.Operator != hinter.Mul {
+				felt := fp.Element(right)
+				if result.Operator == hinter.Sub {
+					felt.Neg(&felt)
+				}
+				if offset, ok := utils.Int16FromFelt(&felt); ok {
 					return hinter.DoubleDeref{
 							Deref:  left,
 							Offset: offset,
@@ -186,13 +193,14 @@ func (expression ArithExp) Evaluate() (hinter.Reference, error) {
 			if !ok {
 				return nil, fmt.Errorf("invalid arithmetic expression")
 			}
-			off, ok := utils.Int16FromFelt((*fp.Element)(&rightResult))
+			felt := fp.Element(rightResult)
+			if term.Operator == "-" {
+				felt.Neg(&felt)
+			}
+			off, ok := utils.Int16FromFelt(&felt)
 			if !ok {
 				return nil, fmt.Errorf("invalid arithmetic expression")
 			}
-			if term.Operator == "-" {
-				off = -off
-			}
 
 			leftResult = leftResult.AddOffset(off)
 		}
@@ -284,14 +292,18 @@ func (expression CellRefExp) Evaluate() (hinter.Reference, error) {
 }
 
 func (expression RegisterOffset) Evaluate() (hinter.Reference, error) {
-	offsetValue, _ := expression.Offset.Evaluate()
+	offsetValue, err := expression.Offset.Evaluate()
+	if err != nil {
+		return nil, err
+	}
+	// negating before the conversion keeps "fp - 32768" valid
+	if expression.Operator == "-" {
+		offsetValue.Neg(offsetValue)
+	}
 	offset, ok := utils.Int16FromBigInt(offsetValue)
 	if !ok {
 		return nil, fmt.Errorf("offset does not fit in int16")
 	}
-	if expression.Operator == "-" {
-		offset = -offset
-	}
 
 	return EvaluateRegister(expression.Register, offset)
 }
@@ -315,8 +327,8 @@ func (expression OffsetExp) Evaluate() (*big.Int, error) {
 			return nil, fmt.Errorf("expected a number")
 		}
 		return bigIntValue, nil
-	case expression.NegNumber != "":
-		bigIntValue, ok := new(big.Int).SetString(expression.NegNumber, 10)
+	case expression.NegNumber != "" || expression.LeadingNegNum != "":
+		bigIntValue, ok := new(big.Int).SetString(expression.NegNumber+expression.LeadingNegNum, 10)
 		if !ok {
 			return nil, fmt.Errorf("expected a number")
 		}
@@ -355,13 +367,13 @@ func (expression DDerefExp) Evaluate() (hinter.DoubleDeref, error) {
 		if err != nil {
 			return hinter.DoubleDeref{}, err
 		}
+		if expression.DerefOffsetExp.Operator == "-" {
+			offsetValue.Neg(offsetValue)
+		}
 		offset, ok := utils.Int16FromBigInt(offsetValue)
 		if !ok {
 			return hinter.DoubleDeref{}, fmt.Errorf("offset does not fit in int16")
 		}
-		if expression.DerefOffsetExp.Operator == "-" {
-			offset = -offset
-		}
 		return hinter.DoubleDeref{
 			Deref:  derefExp,
 			Offset: offset,
@@ -387,6 +399,8 @@ func parseOperator(op string) (hinter.Operator, error) {
 		return hinter.Add, nil
 	case "*":
 		return hinter.Mul, nil
+	case "-":
+		return hinter.Sub, nil
 	default:
 		return 0, fmt.Errorf("unexpected op: %q", op)
 	}
@@ -107,3 +107,65 @@ func TestHintParser(t *testing.T) {
 		}
 	}
 }
+
+func TestHintParserNegativeValues(t *testing.T) {
+	testSet := []struct {
+		Parameter         string
+		ExpectedReference hinter.Reference
+	}{
+		{
+			Parameter:         "cast(-1, felt)",
+			ExpectedReference: hinter.Immediate(*feltInt64(-1)),
+		},
+		{
+			Parameter:         "cast(fp - 32768, felt*)",
+			ExpectedReference: hinter.FpCellRef(-32768),
+		},
+		{
+			Parameter:         "cast(ap + (-32768), felt*)",
+			ExpectedReference: hinter.ApCellRef(-32768),
+		},
+		{
+			Parameter:         "cast(ap + (-3) - (-5), felt*)",
+			ExpectedReference: hinter.ApCellRef(2),
+		},
+		{
+			Parameter: "[cast([fp + (-4)] - 2, felt*)]",
+			ExpectedReference: hinter.DoubleDeref{
+				Deref:  hinter.Deref{Deref: hinter.FpCellRef(-4)},
+				Offset: -2,
+			},
+		},
+		{
+			Parameter: "[cast([ap + (-1)] + (-32768), felt*)]",
+			ExpectedReference: hinter.DoubleDeref{
+				Deref:  hinter.Deref{Deref: hinter.ApCellRef(-1)},
+				Offset: -32768,
+			},
+		},
+		{
+			Parameter: "cast([fp + (-3)] - 1, felt)",
+			ExpectedReference: hinter.BinaryOp{
+				Operator: hinter.Sub,
+				Lhs:      hinter.Deref{Deref: hinter.FpCellRef(-3)},
+				Rhs:      hinter.Immediate(*feltInt64(1)),
+			},
+		},
+	}
+
+	for _, test := range testSet {
+		output, err := ParseIdentifier(test.Parameter)
+		require.NoError(t, err, test.Parameter)
+		require.Equal(t, test.ExpectedReference, output, test.Parameter)
+	}
+
+	for _, parameter := range []string{
+		"cast(fp + 32768, felt*)",
+		"cast(ap - 32769, felt*)",
+		"cast(ap + (-32769), felt*)",
+		"[cast([ap] * 2, felt*)]",
+	} {
+		_, err := ParseIdentifier(parameter)
+		require.Error(t, err, parameter)
+	}
+}
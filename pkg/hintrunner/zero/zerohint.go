@@ -6,6 +6,7 @@ import (
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	zero "github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
 )
 
@@ -23,11 +24,34 @@ func (hint *GenericZeroHinter) Execute(vm *VM.VirtualMachine, ctx *hinter.HintRu
 	return hint.Op(vm, ctx)
 }
 
+// HintProvider implements hints unknown to the VM, e.g. the hints of a plugin. It
+// returns false for the hint codes it doesn't implement either
+type HintProvider interface {
+	Hinter(code string, references map[string]hinter.Reference) (hinter.Hinter, bool)
+}
+
+var hintProviders []HintProvider
+
+// RegisterHintProvider makes the provider responsible for the hint codes the VM
+// doesn't implement. Providers are queried in registration order
+func RegisterHintProvider(provider HintProvider) {
+	hintProviders = append(hintProviders, provider)
+}
+
 func GetZeroHints(cairoZeroJson *zero.ZeroProgram) (map[uint64][]hinter.Hinter, error) {
+	return GetZeroHintsWithMocks(cairoZeroJson, nil)
+}
+
+// GetZeroHintsWithMocks is GetZeroHints with the hints selected by mocks replaced,
+// see HintMocks. The hints of the program are otherwise created as usual
+func GetZeroHintsWithMocks(cairoZeroJson *zero.ZeroProgram, mocks *HintMocks) (map[uint64][]hinter.Hinter, error) {
 	numHints := 0
 	for _, rawHints := range cairoZeroJson.Hints {
 		numHints += len(rawHints)
 	}
+	if err := mocks.check(cairoZeroJson); err != nil {
+		return nil, err
+	}
 	hints := make(map[uint64][]hinter.Hinter, numHints)
 	for counter, rawHints := range cairoZeroJson.Hints {
 		pc, err := strconv.ParseUint(counter, 10, 64)
@@ -35,8 +59,22 @@ func GetZeroHints(cairoZeroJson *zero.ZeroProgram) (map[uint64][]hinter.Hinter,
 			return nil, err
 		}
 
+		if mock, ok := mocks.atPc(pc); ok {
+			hint, err := newPcMockHinter(cairoZeroJson, pc, rawHints, mock)
+			if err != nil {
+				return nil, err
+			}
+			hints[pc] = []hinter.Hinter{hint}
+			continue
+		}
+
 		for _, rawHint := range rawHints {
-			hint, err := GetHintFromCode(cairoZeroJson, rawHint)
+			var hint hinter.Hinter
+			if mock, ok := mocks.withCode(rawHint.Code); ok {
+				hint, err = newCodeMockHinter(cairoZeroJson, pc, rawHint, mock)
+			} else {
+				hint, err = GetHintFromCode(cairoZeroJson, rawHint)
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -133,7 +171,11 @@ func GetHintFromCode(program *zero.ZeroProgram, rawHint zero.Hint) (hinter.Hinte
 		return createInvModPUint512Hinter(resolver)
 	// Signature hints
 	case verifyECDSASignatureCode:
-		return createVerifyECDSASignatureHinter(resolver)
+		return createVerifyECDSASignatureHinter(resolver, false)
+	case verifyECDSASignatureWithYParityCode:
+		return createVerifyECDSASignatureHinter(resolver, true)
+	case verifyECDSASignatureWithPublicKeyCode:
+		return createVerifyECDSASignatureWithPublicKeyHinter(resolver)
 	case getPointFromXCode:
 		return createGetPointFromXHinter(resolver)
 	case divModNSafeDivCode:
@@ -276,6 +318,11 @@ func GetHintFromCode(program *zero.ZeroProgram, rawHint zero.Hint) (hinter.Hinte
 		return createSplitOutput0Hinter(resolver)
 	case SplitNBytesCode:
 		return createSplitNBytesHinter(resolver)
+	// Poseidon hints
+	case poseidonPermutationCode:
+		return createPoseidonPermutationHinter(resolver)
+	case poseidonFinalizeCode:
+		return createPoseidonFinalizeHinter(resolver)
 	// Usort hints
 	case usortEnterScopeCode:
 		return createUsortEnterScopeHinter()
@@ -355,8 +402,17 @@ func GetHintFromCode(program *zero.ZeroProgram, rawHint zero.Hint) (hinter.Hinte
 		return createNormalizeAddressHinter(resolver)
 	case sha256AndBlake2sInputCode:
 		return createSha256AndBlake2sInputHinter(resolver)
+	case loadProgramCode:
+		return createLoadProgramHinter(resolver)
+	case runNestedProgramCode:
+		return createRunNestedProgramHinter(resolver)
 	default:
-		return nil, fmt.Errorf("not identified hint: \n%s", rawHint.Code)
+		for _, provider := range hintProviders {
+			if hint, ok := provider.Hinter(rawHint.Code, resolver.refs); ok {
+				return hint, nil
+			}
+		}
+		return nil, utils.WithErrorCode(utils.ErrorCodeUnknownHint, fmt.Errorf("not identified hint: \n%s", rawHint.Code))
 	}
 }
 
@@ -22,10 +22,14 @@ import (
 //
 // There are 2 versions of this hint, depending on whether `_block_size` should be lower than 10 or 1000
 // Corresponding hintcodes are cairoKeccakFinalizeCode and cairoKeccakFinalizeBlockSize1000Code
+//
+// When keccak acceleration is enabled in the context, the permutations are checked
+// natively and the hint returns from `finalize_keccak` instead of letting the Cairo
+// code verify them, see `finalizeKeccakNatively`
 func newCairoKeccakFinalizeHint(keccakPtrEnd hinter.Reference) hinter.Hinter {
 	return &GenericZeroHinter{
 		Name: "CairoKeccakFinalize",
-		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+		Op: func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext) error {
 			//> _keccak_state_size_felts = int(ids.KECCAK_STATE_SIZE_FELTS)
 			//> _block_size = int(ids.BLOCK_SIZE)
 			//> assert 0 <= _keccak_state_size_felts < 100
@@ -50,6 +54,7 @@ func newCairoKeccakFinalizeHint(keccakPtrEnd hinter.Reference) hinter.Hinter {
 			if err != nil {
 				return err
 			}
+			keccakPtrEndValue := *keccakPtrEnd
 
 			for i := 0; i < len(result); i++ {
 				resultMV := memory.MemoryValueFromUint(result[i])
@@ -64,11 +69,102 @@ func newCairoKeccakFinalizeHint(keccakPtrEnd hinter.Reference) hinter.Hinter {
 				keccakPtrEnd = &keccakPtrEndIncremented
 			}
 
+			if ctx.KeccakAcceleration {
+				return finalizeKeccakNatively(vm, &keccakPtrEndValue)
+			}
 			return nil
 		},
 	}
 }
 
+// finalizeKeccakNatively checks every (input, output) pair of states written between
+// `keccak_ptr_start` and `keccak_ptr_end` and then returns from `finalize_keccak` as the
+// `ret` instruction would, skipping the steps the Cairo code spends verifying them with
+// the bitwise builtin. The keccak segment ends up with the same content but the
+// implicit `range_check_ptr` and `bitwise_ptr` are returned unchanged, which is why
+// this is only meant for simulation runs.
+//
+// The frame of `finalize_keccak{range_check_ptr, bitwise_ptr}(keccak_ptr_start, keccak_ptr_end)`
+// is expected at fp. If it doesn't match, the Cairo code is left to run as usual
+func finalizeKeccakNatively(vm *VM.VirtualMachine, keccakPtrEnd *memory.MemoryAddress) error {
+	const keccakStateSize = 25
+
+	fp := vm.Context.Fp
+	if fp < 6 {
+		return nil
+	}
+	frameAddr := func(offset uint64) *memory.MemoryAddress {
+		return &memory.MemoryAddress{SegmentIndex: VM.ExecutionSegment, Offset: fp - offset}
+	}
+	frameAddress := func(offset uint64) (memory.MemoryAddress, bool) {
+		mv, err := vm.Memory.PeekFromAddress(frameAddr(offset))
+		if err != nil {
+			return memory.UnknownAddress, false
+		}
+		addr, err := mv.MemoryAddress()
+		if err != nil {
+			return memory.UnknownAddress, false
+		}
+		return *addr, true
+	}
+
+	end, ok := frameAddress(3)
+	if !ok || end != *keccakPtrEnd {
+		return nil
+	}
+	start, ok := frameAddress(4)
+	if !ok || start.SegmentIndex != end.SegmentIndex || start.Offset > end.Offset || (end.Offset-start.Offset)%(2*keccakStateSize) != 0 {
+		return nil
+	}
+	callerFp, ok := frameAddress(2)
+	if !ok || callerFp.SegmentIndex != VM.ExecutionSegment {
+		return nil
+	}
+	returnPc, ok := frameAddress(1)
+	if !ok || returnPc.SegmentIndex != vm.Context.Pc.SegmentIndex {
+		return nil
+	}
+
+	for offset := start.Offset; offset < end.Offset; offset += 2 * keccakStateSize {
+		values, err := vm.Memory.GetConsecutiveMemoryValues(
+			memory.MemoryAddress{SegmentIndex: start.SegmentIndex, Offset: offset}, 2*keccakStateSize,
+		)
+		if err != nil {
+			return err
+		}
+		var state [keccakStateSize]uint64
+		for i := range state {
+			state[i], err = values[i].Uint64()
+			if err != nil {
+				return fmt.Errorf("keccak state at offset %d: %w", offset, err)
+			}
+		}
+		builtins.KeccakF1600(&state)
+		for i := range state {
+			output, err := values[keccakStateSize+i].Uint64()
+			if err != nil || output != state[i] {
+				return fmt.Errorf("keccak state at offset %d is not the permutation of its input", offset+keccakStateSize)
+			}
+		}
+	}
+
+	// range_check_ptr and bitwise_ptr are the return values of the function
+	for i := uint64(0); i < 2; i++ {
+		implicitArg, err := vm.Memory.ReadFromAddress(frameAddr(6 - i))
+		if err != nil {
+			return err
+		}
+		err = vm.Memory.Write(VM.ExecutionSegment, vm.Context.Ap+i, &implicitArg)
+		if err != nil {
+			return err
+		}
+	}
+	vm.Context.Ap += 2
+	vm.Context.Fp = callerFp.Offset
+	vm.Context.Pc = returnPc
+	return nil
+}
+
 func createCairoKeccakFinalizeHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
 	keccakPtrEnd, err := resolver.GetReference("keccak_ptr_end")
 	if err != nil {
@@ -387,21 +483,7 @@ func newKeccakWriteArgsHint(inputs, low, high hinter.Reference) hinter.Hinter {
 			highResultFeltHigh, _ := fp.BigEndian.Element(&highResulBytes32High)
 			mvHighHigh := memory.MemoryValueFromFieldElement(&highResultFeltHigh)
 
-			err = vm.Memory.Write(inputsPtr.SegmentIndex, inputsPtr.Offset, &mvLowLow)
-			if err != nil {
-				return err
-			}
-
-			err = vm.Memory.Write(inputsPtr.SegmentIndex, inputsPtr.Offset+1, &mvLowHigh)
-			if err != nil {
-				return err
-			}
-			err = vm.Memory.Write(inputsPtr.SegmentIndex, inputsPtr.Offset+2, &mvHighLow)
-			if err != nil {
-				return err
-			}
-
-			return vm.Memory.Write(inputsPtr.SegmentIndex, inputsPtr.Offset+3, &mvHighHigh)
+			return vm.Memory.WriteRange(inputsPtr, []memory.MemoryValue{mvLowLow, mvLowHigh, mvHighLow, mvHighHigh})
 		},
 	}
 }
@@ -522,17 +604,12 @@ func newBlockPermutationHint(keccakPtr hinter.Reference) hinter.Hinter {
 
 			builtins.KeccakF1600(&keccakInput)
 
-			for i := 0; i < 25; i++ {
-				inputValue := memory.MemoryValueFromUint(keccakInput[i])
-				memoryOffset := uint64(i)
-
-				err = vm.Memory.Write(keccakWritePtr.SegmentIndex, keccakWritePtr.Offset+memoryOffset, &inputValue)
-				if err != nil {
-					return err
-				}
+			var outputValues [25]memory.MemoryValue
+			for i := range keccakInput {
+				outputValues[i] = memory.MemoryValueFromUint(keccakInput[i])
 			}
 
-			return nil
+			return vm.Memory.WriteRange(keccakWritePtr, outputValues[:])
 		},
 	}
 }
@@ -5,8 +5,13 @@ import (
 	"testing"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	runnerutil "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
 )
 
 func TestZeroHintKeccak(t *testing.T) {
@@ -1268,3 +1273,72 @@ func TestZeroHintKeccak(t *testing.T) {
 		},
 	})
 }
+
+func TestCairoKeccakFinalizeAcceleration(t *testing.T) {
+	setup := func(corrupt bool) (*VM.VirtualMachine, hinter.Hinter) {
+		vm := VM.DefaultVirtualMachine()
+		keccakSegment := vm.Memory.AllocateEmptySegment()
+
+		var state [25]uint64
+		for i := range state {
+			state[i] = uint64(i)
+			mv := memory.MemoryValueFromUint(state[i])
+			runnerutil.WriteTo(vm, keccakSegment.SegmentIndex, uint64(i), mv)
+		}
+		builtins.KeccakF1600(&state)
+		if corrupt {
+			state[3]++
+		}
+		for i := range state {
+			mv := memory.MemoryValueFromUint(state[i])
+			runnerutil.WriteTo(vm, keccakSegment.SegmentIndex, uint64(25+i), mv)
+		}
+
+		// frame of finalize_keccak{range_check_ptr, bitwise_ptr}(keccak_ptr_start, keccak_ptr_end)
+		keccakPtrEnd := memory.MemoryAddress{SegmentIndex: keccakSegment.SegmentIndex, Offset: 50}
+		callerFp := memory.MemoryAddress{SegmentIndex: VM.ExecutionSegment, Offset: 0}
+		returnPc := memory.MemoryAddress{SegmentIndex: VM.ProgramSegment, Offset: 42}
+		frame := []memory.MemoryValue{
+			memory.MemoryValueFromUint(uint64(7)),
+			memory.MemoryValueFromUint(uint64(8)),
+			memory.MemoryValueFromMemoryAddress(&keccakSegment),
+			memory.MemoryValueFromMemoryAddress(&keccakPtrEnd),
+			memory.MemoryValueFromMemoryAddress(&callerFp),
+			memory.MemoryValueFromMemoryAddress(&returnPc),
+		}
+		for i, mv := range frame {
+			runnerutil.WriteTo(vm, VM.ExecutionSegment, uint64(i), mv)
+		}
+		vm.Context.Fp = 6
+		vm.Context.Ap = 8
+
+		return vm, newCairoKeccakFinalizeHint(hinter.Deref{Deref: hinter.FpCellRef(-3)})
+	}
+
+	t.Run("returns from finalize_keccak", func(t *testing.T) {
+		vm, hint := setup(false)
+		ctx := hinter.InitializeDefaultContext()
+		ctx.KeccakAcceleration = true
+		require.NoError(t, hint.Execute(vm, ctx))
+
+		require.Equal(t, VM.Context{Pc: memory.MemoryAddress{SegmentIndex: VM.ProgramSegment, Offset: 42}, Fp: 0, Ap: 10}, vm.Context)
+		require.Equal(t, memory.MemoryValueFromUint(uint64(7)), runnerutil.ReadFrom(vm, VM.ExecutionSegment, 8))
+		require.Equal(t, memory.MemoryValueFromUint(uint64(8)), runnerutil.ReadFrom(vm, VM.ExecutionSegment, 9))
+		// the padding is still written
+		require.Equal(t, memory.MemoryValueFromUint(uint64(0)), runnerutil.ReadFrom(vm, 2, 50))
+	})
+
+	t.Run("wrong permutation", func(t *testing.T) {
+		vm, hint := setup(true)
+		ctx := hinter.InitializeDefaultContext()
+		ctx.KeccakAcceleration = true
+		require.ErrorContains(t, hint.Execute(vm, ctx), "keccak state at offset 25 is not the permutation of its input")
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		vm, hint := setup(true)
+		context := vm.Context
+		require.NoError(t, hint.Execute(vm, hinter.InitializeDefaultContext()))
+		require.Equal(t, context, vm.Context)
+	})
+}
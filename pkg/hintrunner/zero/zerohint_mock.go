@@ -0,0 +1,103 @@
+package zero
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	zero "github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+)
+
+// HintMock runs instead of hints of the program, e.g. to stub an oracle or a
+// signature check in the unit tests of Cairo code. It is given the references of
+// the hints it replaces by their short name, as HintProvider
+type HintMock func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext, references map[string]hinter.Reference) error
+
+// HintMocks selects the hints replaced by GetZeroHintsWithMocks. Unlike a
+// HintProvider, mocks only apply to the hints created by that call, and thus to a
+// single run, and they can replace the hints the VM implements. Mocking a pc or a
+// code which has no hint in the program is an error, so that a mock can't silently
+// be left unused
+type HintMocks struct {
+	// replaces every hint with the given code
+	ByCode map[string]HintMock
+	// replaces all the hints of a pc by a single mock, over ByCode
+	ByPc map[uint64]HintMock
+}
+
+func (mocks *HintMocks) atPc(pc uint64) (HintMock, bool) {
+	if mocks == nil {
+		return nil, false
+	}
+	mock, ok := mocks.ByPc[pc]
+	return mock, ok
+}
+
+func (mocks *HintMocks) withCode(code string) (HintMock, bool) {
+	if mocks == nil {
+		return nil, false
+	}
+	mock, ok := mocks.ByCode[code]
+	return mock, ok
+}
+
+func (mocks *HintMocks) check(program *zero.ZeroProgram) error {
+	if mocks == nil {
+		return nil
+	}
+	for pc := range mocks.ByPc {
+		if len(program.Hints[strconv.FormatUint(pc, 10)]) == 0 {
+			return fmt.Errorf("cannot mock the hints at pc %d: the program has none", pc)
+		}
+	}
+	for code := range mocks.ByCode {
+		if !programHasHint(program, code) {
+			return fmt.Errorf("cannot mock hint: the program has no hint with code\n%s", code)
+		}
+	}
+	return nil
+}
+
+func programHasHint(program *zero.ZeroProgram, code string) bool {
+	for _, rawHints := range program.Hints {
+		for i := range rawHints {
+			if rawHints[i].Code == code {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func newCodeMockHinter(program *zero.ZeroProgram, pc uint64, rawHint zero.Hint, mock HintMock) (hinter.Hinter, error) {
+	resolver, err := getParameters(program, rawHint)
+	if err != nil {
+		return nil, err
+	}
+	return newMockHinter(pc, resolver.refs, mock), nil
+}
+
+// The mock of a pc gets the references of all the hints it replaces
+func newPcMockHinter(program *zero.ZeroProgram, pc uint64, rawHints []zero.Hint, mock HintMock) (hinter.Hinter, error) {
+	references := make(map[string]hinter.Reference)
+	for _, rawHint := range rawHints {
+		resolver, err := getParameters(program, rawHint)
+		if err != nil {
+			return nil, err
+		}
+		for name, reference := range resolver.refs {
+			references[name] = reference
+		}
+	}
+	return newMockHinter(pc, references, mock), nil
+}
+
+func newMockHinter(pc uint64, references map[string]hinter.Reference, mock HintMock) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: fmt.Sprintf("MockedHint(pc %d)", pc),
+		Op: func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext) error {
+			return mock(vm, ctx, references)
+		},
+	}
+}
@@ -0,0 +1,70 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	zero "github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetZeroHintsWithMocks(t *testing.T) {
+	reference := zero.Reference{Value: "[cast(fp + (-3), felt*)]"}
+	oracle := zero.Hint{
+		Code: "ids.x = oracle()",
+		FlowTrackingData: zero.FlowTrackingData{
+			ReferenceIds: map[string]uint64{"__main__.main.x": 0},
+		},
+	}
+	program := &zero.ZeroProgram{
+		Hints: map[string][]zero.Hint{
+			"0": {{Code: allocSegmentCode}, {Code: vmEnterScopeCode}},
+			"2": {oracle},
+			"4": {oracle},
+		},
+		Identifiers: map[string]*zero.Identifier{
+			"__main__.main.x": {References: []zero.Reference{reference}},
+		},
+		ReferenceManager: zero.ReferenceManager{References: []zero.Reference{reference}},
+	}
+
+	// the oracle is known to no one
+	_, err := GetZeroHints(program)
+	require.ErrorContains(t, err, "not identified hint")
+
+	var calls []string
+	mock := func(name string) HintMock {
+		return func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext, references map[string]hinter.Reference) error {
+			call := name
+			for reference := range references {
+				call += " " + reference
+			}
+			calls = append(calls, call)
+			return nil
+		}
+	}
+	hints, err := GetZeroHintsWithMocks(program, &HintMocks{
+		ByCode: map[string]HintMock{oracle.Code: mock("oracle"), allocSegmentCode: mock("alloc")},
+		ByPc:   map[uint64]HintMock{0: mock("pc")},
+	})
+	require.NoError(t, err)
+	require.Len(t, hints, 3)
+	for _, pc := range []uint64{0, 2, 4} {
+		require.Len(t, hints[pc], 1)
+		require.NoError(t, hints[pc][0].Execute(nil, nil))
+	}
+	require.Equal(t, "MockedHint(pc 2)", hints[2][0].String())
+	require.Equal(t, []string{"pc", "oracle x", "oracle x"}, calls)
+
+	// only the oracle is mocked, the other hints are created as usual
+	hints, err = GetZeroHintsWithMocks(program, &HintMocks{ByCode: map[string]HintMock{oracle.Code: mock("oracle")}})
+	require.NoError(t, err)
+	require.Len(t, hints[0], 2)
+	require.Equal(t, "AllocSegment", hints[0][0].String())
+
+	_, err = GetZeroHintsWithMocks(program, &HintMocks{ByPc: map[uint64]HintMock{1: mock("pc")}})
+	require.EqualError(t, err, "cannot mock the hints at pc 1: the program has none")
+	_, err = GetZeroHintsWithMocks(program, &HintMocks{ByCode: map[string]HintMock{"other()": mock("other")}})
+	require.EqualError(t, err, "cannot mock hint: the program has no hint with code\nother()")
+}
@@ -827,3 +827,142 @@ func createSha256AndBlake2sInputHinter(resolver hintReferenceResolver) (hinter.H
 
 	return newSha256AndBlake2sInputHint(fullWord, nBytes), nil
 }
+
+// LoadProgram hint loads the program of hash `program_hash` into a new segment,
+// which pc is then allowed to run through. The code is looked up by its hash, so
+// only code matching the hash the Cairo program expects can be loaded
+//
+// `newLoadProgramHint` takes 3 operanders as arguments
+//   - `programHash` is the hash of the program to load, see runner.Program.Hash
+//   - `programAddress` is the variable that will store the address of the loaded code
+//   - `programSize` is the variable that will store the size of the loaded code
+func newLoadProgramHint(programHash, programAddress, programSize hinter.Reference) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: "LoadProgram",
+		Op: func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext) error {
+			//> ids.program_address, ids.program_size = load_program(ids.program_hash)
+
+			hash, err := hinter.ResolveAsFelt(vm, programHash)
+			if err != nil {
+				return err
+			}
+
+			code, ok := ctx.LoadablePrograms[*hash]
+			if !ok {
+				return fmt.Errorf("no loadable program has hash %s", hash)
+			}
+
+			segment, err := vm.Memory.AllocateSegment(code)
+			if err != nil {
+				return err
+			}
+			if err := vm.RegisterCodeSegment(segment.SegmentIndex); err != nil {
+				return err
+			}
+
+			programAddressAddr, err := programAddress.Get(vm)
+			if err != nil {
+				return err
+			}
+			programAddressMv := memory.MemoryValueFromMemoryAddress(&segment)
+			if err := vm.Memory.WriteToAddress(&programAddressAddr, &programAddressMv); err != nil {
+				return err
+			}
+
+			programSizeAddr, err := programSize.Get(vm)
+			if err != nil {
+				return err
+			}
+			programSizeMv := memory.MemoryValueFromInt(len(code))
+			return vm.Memory.WriteToAddress(&programSizeAddr, &programSizeMv)
+		},
+	}
+}
+
+func createLoadProgramHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
+	programHash, err := resolver.GetReference("program_hash")
+	if err != nil {
+		return nil, err
+	}
+
+	programAddress, err := resolver.GetReference("program_address")
+	if err != nil {
+		return nil, err
+	}
+
+	programSize, err := resolver.GetReference("program_size")
+	if err != nil {
+		return nil, err
+	}
+
+	return newLoadProgramHint(programHash, programAddress, programSize), nil
+}
+
+// RunNestedProgram hint runs the program of hash `program_hash` in a nested VM, with
+// memory of its own, and copies its output into a new segment. The nested run is
+// limited like the run of the hint and cannot run a program already running
+//
+// `newRunNestedProgramHint` takes 3 operanders as arguments
+//   - `programHash` is the hash of the program to run, see runner.Runner.AddNestedProgram
+//   - `outputAddress` is the variable that will store the address of the output
+//   - `outputSize` is the variable that will store the size of the output
+func newRunNestedProgramHint(programHash, outputAddress, outputSize hinter.Reference) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: "RunNestedProgram",
+		Op: func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext) error {
+			//> ids.output_address, ids.output_size = run_nested_program(ids.program_hash)
+
+			hash, err := hinter.ResolveAsFelt(vm, programHash)
+			if err != nil {
+				return err
+			}
+			if ctx.NestedRunner == nil {
+				return fmt.Errorf("no nested program has hash %s", hash)
+			}
+			result, err := ctx.NestedRunner.RunNested(&hinter.NestedRun{ProgramHash: *hash})
+			if err != nil {
+				return err
+			}
+
+			segment, err := vm.Memory.AllocateSegment(result.Output)
+			if err != nil {
+				return err
+			}
+
+			outputAddressAddr, err := outputAddress.Get(vm)
+			if err != nil {
+				return err
+			}
+			outputAddressMv := memory.MemoryValueFromMemoryAddress(&segment)
+			if err := vm.Memory.WriteToAddress(&outputAddressAddr, &outputAddressMv); err != nil {
+				return err
+			}
+
+			outputSizeAddr, err := outputSize.Get(vm)
+			if err != nil {
+				return err
+			}
+			outputSizeMv := memory.MemoryValueFromInt(len(result.Output))
+			return vm.Memory.WriteToAddress(&outputSizeAddr, &outputSizeMv)
+		},
+	}
+}
+
+func createRunNestedProgramHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
+	programHash, err := resolver.GetReference("program_hash")
+	if err != nil {
+		return nil, err
+	}
+
+	outputAddress, err := resolver.GetReference("output_address")
+	if err != nil {
+		return nil, err
+	}
+
+	outputSize, err := resolver.GetReference("output_size")
+	if err != nil {
+		return nil, err
+	}
+
+	return newRunNestedProgramHint(programHash, outputAddress, outputSize), nil
+}
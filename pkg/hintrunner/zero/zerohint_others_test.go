@@ -6,7 +6,9 @@ import (
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
 )
 
 func TestZeroHintOthers(t *testing.T) {
@@ -675,5 +677,103 @@ func TestZeroHintOthers(t *testing.T) {
 				check: varValueEquals("full_word", feltUint64(1)),
 			},
 		},
+		"LoadProgram": {
+			{
+				operanders: []*hintOperander{
+					{Name: "program_hash", Kind: apRelative, Value: feltUint64(42)},
+					{Name: "program_address", Kind: uninitialized},
+					{Name: "program_size", Kind: uninitialized},
+				},
+				ctxInit: func(ctx *hinter.HintRunnerContext) {
+					ctx.LoadablePrograms = map[fp.Element][]*fp.Element{
+						*feltUint64(42): {feltUint64(7), feltUint64(8)},
+					}
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newLoadProgramHint(ctx.operanders["program_hash"], ctx.operanders["program_address"], ctx.operanders["program_size"])
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					consecutiveVarAddrResolvedValueEquals("program_address", []*fp.Element{feltUint64(7), feltUint64(8)})(t, ctx)
+					varValueEquals("program_size", feltUint64(2))(t, ctx)
+					// the loaded segment is registered as code
+					ctx.vm.Context.Pc = memory.MemoryAddress{SegmentIndex: 2, Offset: 2}
+					require.ErrorContains(t, ctx.vm.RunStep(nil), "pc 2:2 is past the end of its code segment of size 2")
+				},
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "program_hash", Kind: apRelative, Value: feltUint64(43)},
+					{Name: "program_address", Kind: uninitialized},
+					{Name: "program_size", Kind: uninitialized},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newLoadProgramHint(ctx.operanders["program_hash"], ctx.operanders["program_address"], ctx.operanders["program_size"])
+				},
+				errCheck: func(t *testing.T, ctx *hintTestContext, err error) {
+					require.EqualError(t, err, "no loadable program has hash 43")
+				},
+			},
+		},
+		"RunNestedProgram": {
+			{
+				operanders: []*hintOperander{
+					{Name: "program_hash", Kind: apRelative, Value: feltUint64(42)},
+					{Name: "output_address", Kind: uninitialized},
+					{Name: "output_size", Kind: uninitialized},
+				},
+				ctxInit: func(ctx *hinter.HintRunnerContext) {
+					ctx.NestedRunner = testNestedRunner{
+						*feltUint64(42): {feltUint64(7), feltUint64(8)},
+					}
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newRunNestedProgramHint(ctx.operanders["program_hash"], ctx.operanders["output_address"], ctx.operanders["output_size"])
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					consecutiveVarAddrResolvedValueEquals("output_address", []*fp.Element{feltUint64(7), feltUint64(8)})(t, ctx)
+					varValueEquals("output_size", feltUint64(2))(t, ctx)
+				},
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "program_hash", Kind: apRelative, Value: feltUint64(43)},
+					{Name: "output_address", Kind: uninitialized},
+					{Name: "output_size", Kind: uninitialized},
+				},
+				ctxInit: func(ctx *hinter.HintRunnerContext) {
+					ctx.NestedRunner = testNestedRunner{}
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newRunNestedProgramHint(ctx.operanders["program_hash"], ctx.operanders["output_address"], ctx.operanders["output_size"])
+				},
+				errCheck: func(t *testing.T, ctx *hintTestContext, err error) {
+					require.EqualError(t, err, "no nested program has hash 43")
+				},
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "program_hash", Kind: apRelative, Value: feltUint64(42)},
+					{Name: "output_address", Kind: uninitialized},
+					{Name: "output_size", Kind: uninitialized},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newRunNestedProgramHint(ctx.operanders["program_hash"], ctx.operanders["output_address"], ctx.operanders["output_size"])
+				},
+				errCheck: func(t *testing.T, ctx *hintTestContext, err error) {
+					require.EqualError(t, err, "no nested program has hash 42")
+				},
+			},
+		},
 	})
 }
+
+// Runs the nested programs by giving back their output
+type testNestedRunner map[fp.Element][]*fp.Element
+
+func (runner testNestedRunner) RunNested(run *hinter.NestedRun) (hinter.NestedRunResult, error) {
+	output, ok := runner[run.ProgramHash]
+	if !ok {
+		return hinter.NestedRunResult{}, fmt.Errorf("no nested program has hash %s", &run.ProgramHash)
+	}
+	return hinter.NestedRunResult{Output: output}, nil
+}
@@ -0,0 +1,131 @@
+package zero
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// The Poseidon hints let layouts without the poseidon builtin hash with a sponge in
+// plain memory, as cairo_keccak does for keccak: the program writes each state of
+// POSEIDON_STATE_SIZE_FELTS felts at poseidon_ptr and the hints write the permuted
+// state right after it, computed by the permutation of the builtin
+
+const (
+	poseidonStateSizeFelts = 3
+	poseidonBlockSize      = 3
+)
+
+// PoseidonPermutation writes the Hades permutation of the state preceding
+// `poseidon_ptr` to consecutive memory cells, starting at `poseidon_ptr`
+//
+// `newPoseidonPermutationHint` takes 1 operander as argument
+//   - `poseidonPtr` is the address right after the state to permute, where the
+//     permuted state is written
+func newPoseidonPermutationHint(poseidonPtr hinter.Reference) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: "PoseidonPermutation",
+		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+			//> from starkware.cairo.common.poseidon_hash import poseidon_perm
+			//> _poseidon_state_size_felts = int(ids.POSEIDON_STATE_SIZE_FELTS)
+			//> assert _poseidon_state_size_felts == 3
+			//>
+			//> output_values = poseidon_perm(*memory.get_range(
+			//>     ids.poseidon_ptr - _poseidon_state_size_felts, _poseidon_state_size_felts))
+			//> segments.write_arg(ids.poseidon_ptr, output_values)
+
+			poseidonWritePtr, err := hinter.ResolveAsAddress(vm, poseidonPtr)
+			if err != nil {
+				return err
+			}
+
+			readAddr, err := poseidonWritePtr.AddOffset(-poseidonStateSizeFelts)
+			if err != nil {
+				return err
+			}
+			inputValues, err := vm.Memory.ReadRange(&readAddr, poseidonStateSizeFelts)
+			if err != nil {
+				return err
+			}
+
+			var state [poseidonStateSizeFelts]*fp.Element
+			for i := range inputValues {
+				state[i], err = inputValues[i].FieldElement()
+				if err != nil {
+					return fmt.Errorf("poseidon state element %d: %w", i, err)
+				}
+			}
+
+			return vm.Memory.WriteRange(poseidonWritePtr, poseidonPermutationValues(state[0], state[1], state[2]))
+		},
+	}
+}
+
+func createPoseidonPermutationHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
+	poseidonPtr, err := resolver.GetReference("poseidon_ptr")
+	if err != nil {
+		return nil, err
+	}
+
+	return newPoseidonPermutationHint(poseidonPtr), nil
+}
+
+// PoseidonFinalize fills the last block of the sponge with dummy pairs of the zero
+// state and its permutation, __block_size__ times
+//
+// `newPoseidonFinalizeHint` takes 1 operander as argument
+//   - `poseidonPtrEnd` is the address where the dummy pairs are written
+func newPoseidonFinalizeHint(poseidonPtrEnd hinter.Reference) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: "PoseidonFinalize",
+		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+			//> # Add dummy pairs of input and output.
+			//> from starkware.cairo.common.poseidon_hash import poseidon_perm
+			//> _block_size = int(ids.BLOCK_SIZE)
+			//> assert 0 <= _block_size < 10
+			//> inp = [0] * int(ids.POSEIDON_STATE_SIZE_FELTS)
+			//> padding = (inp + poseidon_perm(*inp)) * _block_size
+			//> segments.write_arg(ids.poseidon_ptr_end, padding)
+
+			var zero fp.Element
+			pair := make([]memory.MemoryValue, poseidonStateSizeFelts, 2*poseidonStateSizeFelts)
+			for i := range pair {
+				pair[i] = memory.MemoryValueFromFieldElement(&zero)
+			}
+			pair = append(pair, poseidonPermutationValues(&zero, &zero, &zero)...)
+
+			padding := make([]memory.MemoryValue, 0, poseidonBlockSize*len(pair))
+			for i := 0; i < poseidonBlockSize; i++ {
+				padding = append(padding, pair...)
+			}
+
+			poseidonPtrEnd, err := hinter.ResolveAsAddress(vm, poseidonPtrEnd)
+			if err != nil {
+				return err
+			}
+			return vm.Memory.WriteRange(poseidonPtrEnd, padding)
+		},
+	}
+}
+
+func createPoseidonFinalizeHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
+	poseidonPtrEnd, err := resolver.GetReference("poseidon_ptr_end")
+	if err != nil {
+		return nil, err
+	}
+
+	return newPoseidonFinalizeHint(poseidonPtrEnd), nil
+}
+
+func poseidonPermutationValues(x, y, z *fp.Element) []memory.MemoryValue {
+	permuted := builtins.PoseidonPerm(x, y, z)
+	values := make([]memory.MemoryValue, len(permuted))
+	for i := range permuted {
+		values[i] = memory.MemoryValueFromFieldElement(&permuted[i])
+	}
+	return values
+}
@@ -0,0 +1,63 @@
+package zero
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+func poseidonPermFelts(x, y, z uint64) []*fp.Element {
+	permuted := builtins.PoseidonPerm(feltUint64(x), feltUint64(y), feltUint64(z))
+	return []*fp.Element{&permuted[0], &permuted[1], &permuted[2]}
+}
+
+func TestZeroHintPoseidon(t *testing.T) {
+	runHinterTests(t, map[string][]hintTestCase{
+		"PoseidonPermutation": {
+			{
+				operanders: []*hintOperander{
+					{Name: "poseidon_ptr", Kind: apRelative, Value: addr(8)},
+					{Name: "state.0", Kind: apRelative, Value: feltUint64(1)},
+					{Name: "state.1", Kind: apRelative, Value: feltUint64(2)},
+					{Name: "state.2", Kind: apRelative, Value: feltUint64(3)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newPoseidonPermutationHint(ctx.operanders["poseidon_ptr"])
+				},
+				check: consecutiveVarAddrResolvedValueEquals("poseidon_ptr", poseidonPermFelts(1, 2, 3)),
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "poseidon_ptr", Kind: apRelative, Value: addr(8)},
+					{Name: "state.0", Kind: apRelative, Value: addr(0)},
+					{Name: "state.1", Kind: apRelative, Value: feltUint64(2)},
+					{Name: "state.2", Kind: apRelative, Value: feltUint64(3)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newPoseidonPermutationHint(ctx.operanders["poseidon_ptr"])
+				},
+				errCheck: errorTextContains("poseidon state element 0"),
+			},
+		},
+		"PoseidonFinalize": {
+			{
+				operanders: []*hintOperander{
+					{Name: "poseidon_ptr_end", Kind: apRelative, Value: addr(10)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newPoseidonFinalizeHint(ctx.operanders["poseidon_ptr_end"])
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					pair := append([]*fp.Element{feltUint64(0), feltUint64(0), feltUint64(0)}, poseidonPermFelts(0, 0, 0)...)
+					padding := []*fp.Element{}
+					for i := 0; i < poseidonBlockSize; i++ {
+						padding = append(padding, pair...)
+					}
+					consecutiveVarAddrResolvedValueEquals("poseidon_ptr_end", padding)(t, ctx)
+				},
+			},
+		},
+	})
+}
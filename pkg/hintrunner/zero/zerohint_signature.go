@@ -172,13 +172,15 @@ func createVerifyZeroV3Hinter(resolver hintReferenceResolver) (hinter.Hinter, er
 
 // VerifyECDSASignature hint writes an ECDSA signature to a given address
 //
-// `newVerifyECDSASignatureHint` takes 3 operanders as arguments
+// `newVerifyECDSASignatureHint` takes 4 operanders as arguments
 //   - `ecdsaPtr` is the pointer variable that stores the address
 //     where to write the signature
 //   - `signature_r` and `signature_s` are the r and s parts of the signature
+//   - `yParity` is the parity of the y coordinate of the public key, 0 or 1. It is
+//     nil for the cairo-lang hint, in which case the builtin tries both y and -y
 //
 // `newVerifyECDSASignatureHint` uses the ECDSA builtin to perform this operation
-func newVerifyECDSASignatureHint(ecdsaPtr, signature_r, signature_s hinter.Reference) hinter.Hinter {
+func newVerifyECDSASignatureHint(ecdsaPtr, signature_r, signature_s, yParity hinter.Reference) hinter.Hinter {
 	return &GenericZeroHinter{
 		Name: "VerifyECDSASignature",
 		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
@@ -199,18 +201,80 @@ func newVerifyECDSASignatureHint(ecdsaPtr, signature_r, signature_s hinter.Refer
 				return err
 			}
 
-			ECDSA_segment, ok := vm.Memory.FindSegmentWithBuiltin(builtins.ECDSAName)
-			if !ok {
-				return fmt.Errorf("ECDSA segment not found")
+			ECDSA_builtinRunner, err := ecdsaBuiltinRunnerAt(vm, ecdsaPtrAddr)
+			if err != nil {
+				return err
+			}
+			if yParity == nil {
+				return ECDSA_builtinRunner.AddSignature(ecdsaPtrAddr.Offset, signature_rFelt, signature_sFelt)
+			}
+			yParityValue, err := hinter.ResolveAsUint64(vm, yParity)
+			if err != nil {
+				return err
+			}
+			if yParityValue > 1 {
+				return fmt.Errorf("y_parity must be 0 or 1, got %d", yParityValue)
+			}
+			return ECDSA_builtinRunner.AddSignatureWithYParity(ecdsaPtrAddr.Offset, signature_rFelt, signature_sFelt, yParityValue == 1)
+		},
+	}
+}
+
+// The signature is registered in the builtin owning the segment ecdsa_ptr points to,
+// rather than in the first ECDSA segment found in memory
+func ecdsaBuiltinRunnerAt(vm *VM.VirtualMachine, ecdsaPtrAddr *mem.MemoryAddress) (*builtins.ECDSA, error) {
+	ECDSA_builtinRunner, err := builtins.ECDSARunnerAt(vm.Memory, ecdsaPtrAddr)
+	if err != nil {
+		return nil, fmt.Errorf("ecdsa_ptr %w", err)
+	}
+	return ECDSA_builtinRunner, nil
+}
+
+// VerifyECDSASignatureWithPublicKey hint writes an ECDSA signature along with the
+// y coordinate of the public key, so that the builtin verifies it once without
+// recovering y
+//
+// `newVerifyECDSASignatureWithPublicKeyHint` takes 4 operanders as arguments
+//   - `ecdsaPtr` is the pointer variable that stores the address
+//     where to write the signature
+//   - `signature_r` and `signature_s` are the r and s parts of the signature
+//   - `publicKeyY` is the y coordinate of the public key
+func newVerifyECDSASignatureWithPublicKeyHint(ecdsaPtr, signature_r, signature_s, publicKeyY hinter.Reference) hinter.Hinter {
+	return &GenericZeroHinter{
+		Name: "VerifyECDSASignatureWithPublicKey",
+		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+			//> ecdsa_builtin.add_signature(ids.ecdsa_ptr.address_, (ids.signature_r, ids.signature_s), public_key_y=ids.public_key_y)
+
+			ecdsaPtrAddr, err := hinter.ResolveAsAddress(vm, ecdsaPtr)
+			if err != nil {
+				return err
+			}
+
+			signature_rFelt, err := hinter.ResolveAsFelt(vm, signature_r)
+			if err != nil {
+				return err
 			}
 
-			ECDSA_builtinRunner := (ECDSA_segment.BuiltinRunner).(*builtins.ECDSA)
-			return ECDSA_builtinRunner.AddSignature(ecdsaPtrAddr.Offset, signature_rFelt, signature_sFelt)
+			signature_sFelt, err := hinter.ResolveAsFelt(vm, signature_s)
+			if err != nil {
+				return err
+			}
+
+			publicKeyYFelt, err := hinter.ResolveAsFelt(vm, publicKeyY)
+			if err != nil {
+				return err
+			}
+
+			ECDSA_builtinRunner, err := ecdsaBuiltinRunnerAt(vm, ecdsaPtrAddr)
+			if err != nil {
+				return err
+			}
+			return ECDSA_builtinRunner.AddSignatureWithPublicKey(ecdsaPtrAddr.Offset, signature_rFelt, signature_sFelt, publicKeyYFelt)
 		},
 	}
 }
 
-func createVerifyECDSASignatureHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
+func createVerifyECDSASignatureWithPublicKeyHinter(resolver hintReferenceResolver) (hinter.Hinter, error) {
 	ecdsaPtr, err := resolver.GetReference("ecdsa_ptr")
 	if err != nil {
 		return nil, err
@@ -226,7 +290,39 @@ func createVerifyECDSASignatureHinter(resolver hintReferenceResolver) (hinter.Hi
 		return nil, err
 	}
 
-	return newVerifyECDSASignatureHint(ecdsaPtr, signature_r, signature_s), nil
+	publicKeyY, err := resolver.GetReference("public_key_y")
+	if err != nil {
+		return nil, err
+	}
+
+	return newVerifyECDSASignatureWithPublicKeyHint(ecdsaPtr, signature_r, signature_s, publicKeyY), nil
+}
+
+func createVerifyECDSASignatureHinter(resolver hintReferenceResolver, withYParity bool) (hinter.Hinter, error) {
+	ecdsaPtr, err := resolver.GetReference("ecdsa_ptr")
+	if err != nil {
+		return nil, err
+	}
+
+	signature_r, err := resolver.GetReference("signature_r")
+	if err != nil {
+		return nil, err
+	}
+
+	signature_s, err := resolver.GetReference("signature_s")
+	if err != nil {
+		return nil, err
+	}
+
+	var yParity hinter.Reference
+	if withYParity {
+		yParity, err = resolver.GetReference("y_parity")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return newVerifyECDSASignatureHint(ecdsaPtr, signature_r, signature_s, yParity), nil
 }
 
 // GetPointFromX hint calculates the y-coordinate of a point
@@ -7,6 +7,7 @@ import (
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 	"github.com/stretchr/testify/require"
 )
 
@@ -60,12 +61,106 @@ func TestVerifyZeroHint(t *testing.T) {
 				},
 				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
 					ecdsaPtr := ctx.operanders["ecdsaPtr"].(*hinter.DoubleDeref).Deref
-					return newVerifyECDSASignatureHint(ecdsaPtr, ctx.operanders["signature_r"], ctx.operanders["signature_s"])
+					return newVerifyECDSASignatureHint(ecdsaPtr, ctx.operanders["signature_r"], ctx.operanders["signature_s"], nil)
 				},
 				errCheck: func(t *testing.T, ctx *hintTestContext, err error) {
 					require.NoError(t, err)
 				},
 			},
+			// ecdsa_ptr points to the message cell of the second instance
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsa", Kind: reference, Value: addrBuiltin(builtins.ECDSAType, 0)},
+					// builtin segments are allocated right after the program and execution segments
+					{Name: "ecdsaPtr", Kind: apRelative, Value: addrWithSegment(2, 3)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newVerifyECDSASignatureHint(ctx.operanders["ecdsaPtr"], ctx.operanders["signature_r"], ctx.operanders["signature_s"], nil)
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					segment, ok := ctx.vm.Memory.FindSegmentWithBuiltin(builtins.ECDSAName)
+					require.True(t, ok)
+					_, ok = segment.BuiltinRunner.(*builtins.ECDSA).Signatures[2]
+					require.True(t, ok)
+				},
+			},
+			// ecdsa_ptr doesn't point to the ecdsa segment
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsa", Kind: reference, Value: addrBuiltin(builtins.ECDSAType, 0)},
+					{Name: "ecdsaPtr", Kind: apRelative, Value: addr(5)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newVerifyECDSASignatureHint(ctx.operanders["ecdsaPtr"], ctx.operanders["signature_r"], ctx.operanders["signature_s"], nil)
+				},
+				errCheck: errorTextContains("does not point to the ecdsa builtin segment"),
+			},
+			// the y parity is registered along the signature
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsaPtr", Kind: reference, Value: addrBuiltin(builtins.ECDSAType, 0)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+					{Name: "y_parity", Kind: apRelative, Value: &utils.FeltOne},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					ecdsaPtr := ctx.operanders["ecdsaPtr"].(*hinter.DoubleDeref).Deref
+					return newVerifyECDSASignatureHint(ecdsaPtr, ctx.operanders["signature_r"], ctx.operanders["signature_s"], ctx.operanders["y_parity"])
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					segment, ok := ctx.vm.Memory.FindSegmentWithBuiltin(builtins.ECDSAName)
+					require.True(t, ok)
+					require.Equal(t, map[uint64]bool{0: true}, segment.BuiltinRunner.(*builtins.ECDSA).YParities)
+				},
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsaPtr", Kind: reference, Value: addrBuiltin(builtins.ECDSAType, 0)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+					{Name: "y_parity", Kind: apRelative, Value: feltUint64(2)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					ecdsaPtr := ctx.operanders["ecdsaPtr"].(*hinter.DoubleDeref).Deref
+					return newVerifyECDSASignatureHint(ecdsaPtr, ctx.operanders["signature_r"], ctx.operanders["signature_s"], ctx.operanders["y_parity"])
+				},
+				errCheck: errorTextContains("y_parity must be 0 or 1, got 2"),
+			},
+		},
+		"VerifyECDSASignatureWithPublicKey": {
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsaPtr", Kind: reference, Value: addrBuiltin(builtins.ECDSAType, 0)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+					{Name: "public_key_y", Kind: apRelative, Value: feltUint64(42)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					ecdsaPtr := ctx.operanders["ecdsaPtr"].(*hinter.DoubleDeref).Deref
+					return newVerifyECDSASignatureWithPublicKeyHint(ecdsaPtr, ctx.operanders["signature_r"], ctx.operanders["signature_s"], ctx.operanders["public_key_y"])
+				},
+				check: func(t *testing.T, ctx *hintTestContext) {
+					segment, ok := ctx.vm.Memory.FindSegmentWithBuiltin(builtins.ECDSAName)
+					require.True(t, ok)
+					require.Equal(t, map[uint64]fp.Element{0: *feltUint64(42)}, segment.BuiltinRunner.(*builtins.ECDSA).PublicKeysY)
+				},
+			},
+			{
+				operanders: []*hintOperander{
+					{Name: "ecdsaPtr", Kind: apRelative, Value: addr(5)},
+					{Name: "signature_r", Kind: apRelative, Value: feltString("3086480810278599376317923499561306189851900463386393948998357832163236918254")},
+					{Name: "signature_s", Kind: apRelative, Value: feltString("598673427589502599949712887611119751108407514580626464031881322743364689811")},
+					{Name: "public_key_y", Kind: apRelative, Value: feltUint64(42)},
+				},
+				makeHinter: func(ctx *hintTestContext) hinter.Hinter {
+					return newVerifyECDSASignatureWithPublicKeyHint(ctx.operanders["ecdsaPtr"], ctx.operanders["signature_r"], ctx.operanders["signature_s"], ctx.operanders["public_key_y"])
+				},
+				errCheck: errorTextContains("does not point to the ecdsa builtin segment"),
+			},
 		},
 		"GetPointFromX": {
 			{
@@ -304,55 +304,27 @@ func newUint256UnsignedDivRemHint(a, div, quotient, remainder hinter.Reference)
 			//> ids.remainder.low = remainder & ((1 << 128) - 1)
 			//> ids.remainder.high = remainder >> 128
 
-			aLow, aHigh, err := GetUint256AsFelts(vm, a)
+			a, err := GetUint256(vm, a)
 			if err != nil {
 				return err
 			}
 
-			var aLowBig big.Int
-			aLow.BigInt(&aLowBig)
-			var aHighBig big.Int
-			aHigh.BigInt(&aHighBig)
-
-			divLow, divHigh, err := GetUint256AsFelts(vm, div)
+			div, err := GetUint256(vm, div)
 			if err != nil {
 				return err
 			}
 
-			var divLowBig big.Int
-			divLow.BigInt(&divLowBig)
-			var divHighBig big.Int
-			divHigh.BigInt(&divHighBig)
-
-			aBig := new(big.Int).Add(new(big.Int).Lsh(&aHighBig, 128), &aLowBig)
-			divBig := new(big.Int).Add(new(big.Int).Lsh(&divHighBig, 128), &divLowBig)
-			quotBig := new(big.Int).Div(aBig, divBig)
-			remBig := new(big.Int).Mod(aBig, divBig)
-
-			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
-
-			lowQuot := new(fp.Element).SetBigInt(new(big.Int).And(quotBig, mask))
-			highQuot := new(fp.Element).SetBigInt(new(big.Int).Rsh(quotBig, 128))
-
-			lowRem := new(fp.Element).SetBigInt(new(big.Int).And(remBig, mask))
-			highRem := new(fp.Element).SetBigInt(new(big.Int).Rsh(remBig, 128))
-
-			quotientAddr, err := quotient.Get(vm)
+			quotientValue, remainderValue, err := a.DivMod(div)
 			if err != nil {
 				return err
 			}
 
-			err = vm.Memory.WriteUint256ToAddress(quotientAddr, lowQuot, highQuot)
+			err = WriteUint256(vm, quotient, quotientValue)
 			if err != nil {
 				return err
 			}
 
-			remainderAddr, err := remainder.Get(vm)
-			if err != nil {
-				return err
-			}
-
-			return vm.Memory.WriteUint256ToAddress(remainderAddr, lowRem, highRem)
+			return WriteUint256(vm, remainder, remainderValue)
 		},
 	}
 }
@@ -402,16 +374,11 @@ func newUint256UnsignedDivRemExpandedHint(a, div, quotient, remainder hinter.Ref
 			//> ids.remainder.low = remainder & ((1 << 128) - 1)
 			//> ids.remainder.high = remainder >> 128
 
-			aLow, aHigh, err := GetUint256AsFelts(vm, a)
+			a, err := GetUint256(vm, a)
 			if err != nil {
 				return err
 			}
 
-			var aLowBig big.Int
-			aLow.BigInt(&aLowBig)
-			var aHighBig big.Int
-			aHigh.BigInt(&aHighBig)
-
 			//> struct Uint256_expand {
 			//> 	B0: felt,
 			//> 	b01: felt,
@@ -424,42 +391,19 @@ func newUint256UnsignedDivRemExpandedHint(a, div, quotient, remainder hinter.Ref
 			if err != nil {
 				return err
 			}
+			div := utils.NewUint256(divUint256Expanded[1], divUint256Expanded[3])
 
-			divB01, divB23 := divUint256Expanded[1], divUint256Expanded[3]
-			var divB23Big big.Int
-			divB23.BigInt(&divB23Big)
-			var divB01Big big.Int
-			divB01.BigInt(&divB01Big)
-
-			aBig := new(big.Int).Add(new(big.Int).Lsh(&aHighBig, 128), &aLowBig)
-			divBig := new(big.Int).Add(new(big.Int).Lsh(&divB23Big, 128), &divB01Big)
-			quotBig := new(big.Int).Div(aBig, divBig)
-			remBig := new(big.Int).Mod(aBig, divBig)
-
-			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
-
-			lowQuot := new(fp.Element).SetBigInt(new(big.Int).And(quotBig, mask))
-			highQuot := new(fp.Element).SetBigInt(new(big.Int).Rsh(quotBig, 128))
-
-			lowRem := new(fp.Element).SetBigInt(new(big.Int).And(remBig, mask))
-			highRem := new(fp.Element).SetBigInt(new(big.Int).Rsh(remBig, 128))
-
-			quotientAddr, err := quotient.Get(vm)
+			quotientValue, remainderValue, err := a.DivMod(div)
 			if err != nil {
 				return err
 			}
 
-			err = vm.Memory.WriteUint256ToAddress(quotientAddr, lowQuot, highQuot)
+			err = WriteUint256(vm, quotient, quotientValue)
 			if err != nil {
 				return err
 			}
 
-			remainderAddr, err := remainder.Get(vm)
-			if err != nil {
-				return err
-			}
-
-			return vm.Memory.WriteUint256ToAddress(remainderAddr, lowRem, highRem)
+			return WriteUint256(vm, remainder, remainderValue)
 		},
 	}
 }
@@ -512,71 +456,40 @@ func newUint256MulDivModHint(a, b, div, quotientLow, quotientHigh, remainder hin
 			//> ids.remainder.low = remainder & ((1 << 128) - 1)
 			//> ids.remainder.high = remainder >> 128
 
-			aLow, aHigh, err := GetUint256AsFelts(vm, a)
+			a, err := GetUint256(vm, a)
 			if err != nil {
 				return err
 			}
 
-			var aLowBig big.Int
-			aLow.BigInt(&aLowBig)
-			var aHighBig big.Int
-			aHigh.BigInt(&aHighBig)
-			bLow, bHigh, err := GetUint256AsFelts(vm, b)
+			b, err := GetUint256(vm, b)
 			if err != nil {
 				return err
 			}
 
-			var bLowBig big.Int
-			bLow.BigInt(&bLowBig)
-			var bHighBig big.Int
-			bHigh.BigInt(&bHighBig)
-			divLow, divHigh, err := GetUint256AsFelts(vm, div)
+			div, err := GetUint256(vm, div)
 			if err != nil {
 				return err
 			}
 
-			var divLowBig big.Int
-			divLow.BigInt(&divLowBig)
-			var divHighBig big.Int
-			divHigh.BigInt(&divHighBig)
-			a := new(big.Int).Add(new(big.Int).Lsh(&aHighBig, 128), &aLowBig)
-			b := new(big.Int).Add(new(big.Int).Lsh(&bHighBig, 128), &bLowBig)
-			div := new(big.Int).Add(new(big.Int).Lsh(&divHighBig, 128), &divLowBig)
-			quot := new(big.Int).Div(new(big.Int).Mul(a, b), div)
-			rem := new(big.Int).Mod(new(big.Int).Mul(a, b), div)
-			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
-			lowQuotLow := new(fp.Element).SetBigInt(new(big.Int).And(quot, mask))
-			lowQuotHigh := new(fp.Element).SetBigInt(new(big.Int).And(new(big.Int).Rsh(quot, 128), mask))
-			highQuotLow := new(fp.Element).SetBigInt(new(big.Int).And(new(big.Int).Rsh(quot, 256), mask))
-			highQuotHigh := new(fp.Element).SetBigInt(new(big.Int).Rsh(quot, 384))
-			lowRem := new(fp.Element).SetBigInt(new(big.Int).And(rem, mask))
-			highRem := new(fp.Element).SetBigInt(new(big.Int).Rsh(rem, 128))
-			quotientLowAddr, err := quotientLow.Get(vm)
-			if err != nil {
-				return err
+			divisor := div.BigInt()
+			if divisor.Sign() == 0 {
+				return fmt.Errorf("division by zero")
 			}
+			product := a.Mul(b)
+			quot, rem := new(big.Int).DivMod(product.BigInt(), divisor, new(big.Int))
+			quotientValue := utils.Uint512FromBigInt(quot)
 
-			err = vm.Memory.WriteUint256ToAddress(quotientLowAddr, lowQuotLow, lowQuotHigh)
+			err = WriteUint256(vm, quotientLow, quotientValue.Low())
 			if err != nil {
 				return err
 			}
 
-			quotientHighAddr, err := quotientHigh.Get(vm)
+			err = WriteUint256(vm, quotientHigh, quotientValue.High())
 			if err != nil {
 				return err
 			}
 
-			err = vm.Memory.WriteUint256ToAddress(quotientHighAddr, highQuotLow, highQuotHigh)
-			if err != nil {
-				return err
-			}
-
-			remainderAddr, err := remainder.Get(vm)
-			if err != nil {
-				return err
-			}
-
-			return vm.Memory.WriteUint256ToAddress(remainderAddr, lowRem, highRem)
+			return WriteUint256(vm, remainder, utils.Uint256FromBigInt(rem))
 		},
 	}
 }
@@ -642,41 +555,16 @@ func newUint256SubHint(a, b, res hinter.Reference) hinter.Hinter {
 			// ids.res.low = res_split[0]
 			// ids.res.high = res_split[1]
 
-			aLow, aHigh, err := GetUint256AsFelts(vm, a)
+			a, err := GetUint256(vm, a)
 			if err != nil {
 				return err
 			}
-			bLow, bHigh, err := GetUint256AsFelts(vm, b)
+			b, err := GetUint256(vm, b)
 			if err != nil {
 				return err
 			}
 
-			a := Pack(128, aLow, aHigh)
-			b := Pack(128, bLow, bHigh)
-
-			modulus := new(big.Int).Lsh(big.NewInt(1), 256)
-			resBig := new(big.Int).Sub(&a, &b)
-			resBig.Mod(resBig, modulus)
-
-			split := func(num big.Int, numBitsShift uint16, length int) []fp.Element {
-				a := make([]fp.Element, length)
-				mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(numBitsShift)), big.NewInt(1))
-
-				for i := 0; i < length; i++ {
-					a[i] = *new(fp.Element).SetBigInt(new(big.Int).And(&num, mask))
-					num.Rsh(&num, uint(numBitsShift))
-				}
-
-				return a
-			}
-
-			resSplit := split(*resBig, 128, 2)
-			resAddr, err := res.Get(vm)
-			if err != nil {
-				return err
-			}
-
-			return vm.Memory.WriteUint256ToAddress(resAddr, &resSplit[0], &resSplit[1])
+			return WriteUint256(vm, res, a.Sub(b))
 		},
 	}
 }
@@ -736,17 +624,13 @@ func newSplitXXHint(x, xx hinter.Reference) hinter.Hinter {
 				return fmt.Errorf("invalid value for (PRIME + 3) // 8")
 			}
 
-			xxLow, xxHigh, err := GetUint256AsFelts(vm, xx)
+			xxValue, err := GetUint256(vm, xx)
 			if err != nil {
 				return err
 			}
 
-			var xxLowBig, xxHighBig big.Int
-			xxLow.BigInt(&xxLowBig)
-			xxHigh.BigInt(&xxHighBig)
-
 			//> xx = ids.xx.low + (ids.xx.high<<128)
-			xx := new(big.Int).Add(new(big.Int).Lsh(&xxHighBig, 128), &xxLowBig)
+			xx := xxValue.BigInt()
 
 			//> x = pow(xx, (PRIME + 3) // 8, PRIME)
 			xBig := new(big.Int).Exp(xx, modifiedPRIME, &PRIME)
@@ -767,15 +651,7 @@ func newSplitXXHint(x, xx hinter.Reference) hinter.Hinter {
 
 			//> ids.x.low = x & ((1<<128)-1)
 			//> ids.x.high = x >> 128
-			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
-			xLow := new(fp.Element).SetBigInt(new(big.Int).And(xBig, mask))
-			xHigh := new(fp.Element).SetBigInt(new(big.Int).Rsh(xBig, 128))
-
-			xAddr, err := x.Get(vm)
-			if err != nil {
-				return err
-			}
-			return vm.Memory.WriteUint256ToAddress(xAddr, xLow, xHigh)
+			return WriteUint256(vm, x, utils.Uint256FromBigInt(xBig))
 		},
 	}
 }
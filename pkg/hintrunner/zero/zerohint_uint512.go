@@ -1,9 +1,8 @@
 package zero
 
 import (
-	"math/big"
-
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
@@ -11,15 +10,13 @@ import (
 const (
 	P_LOW  = "201385395114098847380338600778089168199"
 	P_HIGH = "64323764613183177041862057485226039389"
-
-	BITSHIFT = 128
 )
 
 // InvModPUint512 hint computes the inverse modulo a prime number `p` of 512 bits
 // `newInvModPUint512Hint` takes 2 operanders as arguments
 //   - `x` is the `uint512` variable that will be inverted modulo `p`
 //   - `x_inverse_mod_p` is the variable that will store the result of the hint in memory
-func newInvModPUint512Hint(x, xInverseModP hinter.Reference) hinter.Hinter {
+func newInvModPUint512Hint(x, xInverseModPRef hinter.Reference) hinter.Hinter {
 	return &GenericZeroHinter{
 		Name: "InvModPUint512",
 		Op: func(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
@@ -36,7 +33,7 @@ func newInvModPUint512Hint(x, xInverseModP hinter.Reference) hinter.Hinter {
 			//> ids.x_inverse_mod_p.low = x_inverse_mod_p_split[0]
 			//> ids.x_inverse_mod_p.high = x_inverse_mod_p_split[1]
 
-			xLoLow, xLoHigh, xHiLow, xHiHigh, err := GetUint512AsFelts(vm, x)
+			x, err := GetUint512(vm, x)
 			if err != nil {
 				return err
 			}
@@ -48,25 +45,13 @@ func newInvModPUint512Hint(x, xInverseModP hinter.Reference) hinter.Hinter {
 			if err != nil {
 				return err
 			}
+			p := utils.NewUint256(pLow, pHigh)
 
-			x := Pack(BITSHIFT, xLoLow, xLoHigh, xHiLow, xHiHigh)
-			p := Pack(BITSHIFT, pLow, pHigh)
-
-			xInverseModPBig := new(big.Int).Exp(&x, big.NewInt(-1), &p)
-
-			// split big.Int into two fp.Elements
-			xInverseModPSplit := make([]fp.Element, 2)
-			mask := new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), uint(BITSHIFT)), big.NewInt(1))
-
-			xInverseModPSplit[0] = *new(fp.Element).SetBigInt(new(big.Int).And(xInverseModPBig, mask))
-			xInverseModPBig.Rsh(xInverseModPBig, uint(BITSHIFT))
-			xInverseModPSplit[1] = *new(fp.Element).SetBigInt(xInverseModPBig)
-
-			resAddr, err := xInverseModP.Get(vm)
+			xInverseModP, err := x.InvMod(p.BigInt())
 			if err != nil {
 				return err
 			}
-			return vm.Memory.WriteUint256ToAddress(resAddr, &xInverseModPSplit[0], &xInverseModPSplit[1])
+			return WriteUint256(vm, xInverseModPRef, xInverseModP)
 		},
 	}
 }
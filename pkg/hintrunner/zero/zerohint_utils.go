@@ -4,6 +4,7 @@ import (
 	"math/big"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
@@ -78,44 +79,49 @@ func GetUint256ExpandAsFelts(vm *VM.VirtualMachine, ref hinter.Reference) ([]*fp
 	return uint256Expanded, nil
 }
 
-func GetUint512AsFelts(vm *VM.VirtualMachine, ref hinter.Reference) (*fp.Element, *fp.Element, *fp.Element, *fp.Element, error) {
-	var fps [4]*fp.Element
+// GetUint256 reads the two limbs of the `uint256` variable at ref
+func GetUint256(vm *VM.VirtualMachine, ref hinter.Reference) (utils.Uint256, error) {
+	low, high, err := GetUint256AsFelts(vm, ref)
+	if err != nil {
+		return utils.Uint256{}, err
+	}
+	return utils.NewUint256(low, high), nil
+}
+
+// GetUint512 reads the four limbs `d0` to `d3` of the `uint512` variable at ref
+func GetUint512(vm *VM.VirtualMachine, ref hinter.Reference) (utils.Uint512, error) {
+	var limbs [4]*fp.Element
 	firstRefAddr, err := ref.Get(vm)
 	if err != nil {
-		return nil, nil, nil, nil, err
+		return utils.Uint512{}, err
 	}
 	for i := 0; i < 4; i++ {
 		addr, err := firstRefAddr.AddOffset(int16(i))
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return utils.Uint512{}, err
 		}
 
 		mv, err := vm.Memory.ReadFromAddress(&addr)
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return utils.Uint512{}, err
 		}
 
-		fp, err := mv.FieldElement()
+		limbs[i], err = mv.FieldElement()
 		if err != nil {
-			return nil, nil, nil, nil, err
+			return utils.Uint512{}, err
 		}
-		fps[i] = fp
 	}
 
-	return fps[0], fps[1], fps[2], fps[3], nil
+	return utils.Uint512{D0: *limbs[0], D1: *limbs[1], D2: *limbs[2], D3: *limbs[3]}, nil
 }
 
-func Pack(numBitsShift int, limbs ...*fp.Element) big.Int {
-	result := new(big.Int)
-
-	for i, limb := range limbs {
-		limbBig := limb.BigInt(new(big.Int))
-		shiftAmount := big.NewInt(int64(numBitsShift * i))
-		shiftedLimb := new(big.Int).Lsh(limbBig, uint(shiftAmount.Uint64()))
-		result.Or(result, shiftedLimb)
+// WriteUint256 writes the low and high limbs of value to the `uint256` variable at ref
+func WriteUint256(vm *VM.VirtualMachine, ref hinter.Reference, value utils.Uint256) error {
+	addr, err := ref.Get(vm)
+	if err != nil {
+		return err
 	}
-
-	return *result
+	return vm.Memory.WriteUint256ToAddress(addr, &value.Low, &value.High)
 }
 
 // This helper function is used in FastEcAddAssignNewY and
@@ -0,0 +1,266 @@
+// Package oracle provides Cairo Zero hints whose values come from a JSON-RPC
+// endpoint, so that programs can be fed off-chain data while they are developed.
+//
+// The hints are declared in a JSON file mapping hint codes to RPC methods:
+//
+//	{
+//	  "endpoint": "http://localhost:3000",
+//	  "hints": [{
+//	    "code": "# oracle price",
+//	    "method": "get_price",
+//	    "params": ["asset"],
+//	    "results": [{"name": "price", "type": "felt"}, {"name": "history", "type": "felt*"}]
+//	  }]
+//	}
+//
+// When a declared hint runs, the values of the ids listed in params are sent as
+// the named params of a JSON-RPC 2.0 request, each one as a 0x prefixed hex string.
+// The result must be an object holding a field for each declared result. A felt
+// result is written to the cell of its id, and a felt* result is written to a new
+// segment whose address is written to the cell of its id. Felts of the response are
+// decimal or 0x prefixed hex strings, or JSON numbers.
+//
+// Oracle responses are not part of what a proof attests, which is why oracles are
+// meant for development and are not available in proof mode.
+package oracle
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+const (
+	ResultFelt        = "felt"
+	ResultFeltPointer = "felt*"
+)
+
+type Config struct {
+	// url receiving the JSON-RPC requests
+	Endpoint string `json:"endpoint"`
+	Hints    []Hint `json:"hints"`
+}
+
+// Hint declares the RPC method called by a hint code and how its result is written
+type Hint struct {
+	Code    string   `json:"code"`
+	Method  string   `json:"method"`
+	Params  []string `json:"params"`
+	Results []Result `json:"results"`
+}
+
+type Result struct {
+	// id written with the field of the same name of the result
+	Name string `json:"name"`
+	// either felt or felt*
+	Type string `json:"type"`
+}
+
+func ConfigFromFile(path string) (Config, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, err
+	}
+	var config Config
+	if err := json.Unmarshal(content, &config); err != nil {
+		return Config{}, fmt.Errorf("oracle config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+type Oracle struct {
+	endpoint string
+	client   *http.Client
+	hints    map[string]*Hint
+	nextId   uint64
+}
+
+// New validates the configuration and returns an oracle calling its endpoint.
+// Requests time out after a minute
+func New(config Config) (*Oracle, error) {
+	if config.Endpoint == "" {
+		return nil, errors.New("oracle endpoint not set")
+	}
+	oracle := &Oracle{
+		endpoint: config.Endpoint,
+		client:   &http.Client{Timeout: time.Minute},
+		hints:    make(map[string]*Hint, len(config.Hints)),
+	}
+	for i := range config.Hints {
+		hint := &config.Hints[i]
+		if hint.Method == "" {
+			return nil, fmt.Errorf("oracle hint %q: method not set", hint.Code)
+		}
+		if _, ok := oracle.hints[hint.Code]; ok {
+			return nil, fmt.Errorf("oracle hint %q: declared twice", hint.Code)
+		}
+		for _, result := range hint.Results {
+			if result.Type != ResultFelt && result.Type != ResultFeltPointer {
+				return nil, fmt.Errorf("oracle hint %q: result %s has type %q, expected felt or felt*", hint.Code, result.Name, result.Type)
+			}
+		}
+		oracle.hints[hint.Code] = hint
+	}
+	return oracle, nil
+}
+
+// Hinter returns the hinter of a hint code, or false if the code is not declared
+// in the configuration of the oracle
+func (oracle *Oracle) Hinter(code string, references map[string]hinter.Reference) (hinter.Hinter, bool) {
+	hint, ok := oracle.hints[code]
+	if !ok {
+		return nil, false
+	}
+	return &oracleHint{oracle: oracle, hint: hint, references: references}, true
+}
+
+type oracleHint struct {
+	oracle     *Oracle
+	hint       *Hint
+	references map[string]hinter.Reference
+}
+
+func (hint *oracleHint) String() string {
+	return "Oracle"
+}
+
+func (hint *oracleHint) Execute(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+	method := hint.hint.Method
+	params := make(map[string]string, len(hint.hint.Params))
+	for _, name := range hint.hint.Params {
+		reference, ok := hint.references[name]
+		if !ok {
+			return fmt.Errorf("oracle %s: unknown id %s", method, name)
+		}
+		address, err := reference.Get(vm)
+		if err != nil {
+			return fmt.Errorf("oracle %s: param %s: %w", method, name, err)
+		}
+		value, err := vm.Memory.ReadFromAddressAsElement(&address)
+		if err != nil {
+			return fmt.Errorf("oracle %s: param %s: %w", method, name, err)
+		}
+		params[name] = "0x" + value.Text(16)
+	}
+
+	result, err := hint.oracle.call(method, params)
+	if err != nil {
+		return fmt.Errorf("oracle %s: %w", method, err)
+	}
+
+	for _, declared := range hint.hint.Results {
+		if err := hint.writeResult(vm, &declared, result); err != nil {
+			return fmt.Errorf("oracle %s: result %s: %w", method, declared.Name, err)
+		}
+	}
+	return nil
+}
+
+func (hint *oracleHint) writeResult(vm *VM.VirtualMachine, declared *Result, result map[string]json.RawMessage) error {
+	reference, ok := hint.references[declared.Name]
+	if !ok {
+		return errors.New("unknown id")
+	}
+	raw, ok := result[declared.Name]
+	if !ok {
+		return errors.New("missing from the response")
+	}
+	address, err := reference.Get(vm)
+	if err != nil {
+		return err
+	}
+
+	var value memory.MemoryValue
+	if declared.Type == ResultFelt {
+		felt, err := decodeFelt(raw)
+		if err != nil {
+			return err
+		}
+		value = memory.MemoryValueFromFieldElement(&felt)
+	} else {
+		var elements []json.RawMessage
+		if err := json.Unmarshal(raw, &elements); err != nil {
+			return fmt.Errorf("expected an array: %w", err)
+		}
+		segment := vm.Memory.AllocateEmptySegment()
+		for i := range elements {
+			felt, err := decodeFelt(elements[i])
+			if err != nil {
+				return fmt.Errorf("element %d: %w", i, err)
+			}
+			element := memory.MemoryValueFromFieldElement(&felt)
+			if err := vm.Memory.Write(segment.SegmentIndex, uint64(i), &element); err != nil {
+				return err
+			}
+		}
+		value = memory.MemoryValueFromMemoryAddress(&segment)
+	}
+	return vm.Memory.WriteToAddress(&address, &value)
+}
+
+// Felts are either strings, decimal or 0x prefixed hex, or numbers
+func decodeFelt(raw json.RawMessage) (fp.Element, error) {
+	text := string(raw)
+	if len(raw) > 0 && raw[0] == '"' {
+		if err := json.Unmarshal(raw, &text); err != nil {
+			return fp.Element{}, err
+		}
+	}
+	var felt fp.Element
+	if _, err := felt.SetString(text); err != nil {
+		return fp.Element{}, fmt.Errorf("invalid felt %s", raw)
+	}
+	return felt, nil
+}
+
+type rpcResponse struct {
+	Result json.RawMessage `json:"result"`
+	Error  *struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// Sends a JSON-RPC request and returns the fields of its result
+func (oracle *Oracle) call(method string, params map[string]string) (map[string]json.RawMessage, error) {
+	oracle.nextId++
+	request, err := json.Marshal(map[string]any{
+		"jsonrpc": "2.0",
+		"id":      oracle.nextId,
+		"method":  method,
+		"params":  params,
+	})
+	if err != nil {
+		return nil, err
+	}
+	httpResponse, err := oracle.client.Post(oracle.endpoint, "application/json", bytes.NewReader(request))
+	if err != nil {
+		return nil, err
+	}
+	defer httpResponse.Body.Close()
+	if httpResponse.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s answered %s", oracle.endpoint, httpResponse.Status)
+	}
+
+	var response rpcResponse
+	if err := json.NewDecoder(httpResponse.Body).Decode(&response); err != nil {
+		return nil, fmt.Errorf("invalid response: %w", err)
+	}
+	if response.Error != nil {
+		return nil, fmt.Errorf("error %d: %s", response.Error.Code, response.Error.Message)
+	}
+	var result map[string]json.RawMessage
+	if err := json.Unmarshal(response.Result, &result); err != nil {
+		return nil, fmt.Errorf("result is not an object: %w", err)
+	}
+	return result, nil
+}
@@ -0,0 +1,87 @@
+package oracle
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOracleHint(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			Method string            `json:"method"`
+			Params map[string]string `json:"params"`
+		}
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		if request.Method != "get_price" {
+			w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "error": {"code": -32601, "message": "method not found"}}`))
+			return
+		}
+		require.Equal(t, map[string]string{"asset": "0x2a"}, request.Params)
+		w.Write([]byte(`{"jsonrpc": "2.0", "id": 1, "result": {"price": "1234", "history": [5, "0x6"]}}`))
+	}))
+	defer server.Close()
+
+	oracle, err := New(Config{
+		Endpoint: server.URL,
+		Hints: []Hint{{
+			Code:    "# oracle price",
+			Method:  "get_price",
+			Params:  []string{"asset"},
+			Results: []Result{{Name: "price", Type: ResultFelt}, {Name: "history", Type: ResultFeltPointer}},
+		}, {
+			Code:    "# oracle unknown",
+			Method:  "unknown",
+			Results: []Result{},
+		}},
+	})
+	require.NoError(t, err)
+
+	_, ok := oracle.Hinter("# not an oracle", nil)
+	require.False(t, ok)
+
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Ap = 3
+	asset := memory.MemoryValueFromInt(42)
+	require.NoError(t, vm.Memory.Write(VM.ExecutionSegment, 0, &asset))
+	hint, ok := oracle.Hinter("# oracle price", map[string]hinter.Reference{
+		"asset":   hinter.ApCellRef(-3),
+		"price":   hinter.ApCellRef(0),
+		"history": hinter.ApCellRef(1),
+	})
+	require.True(t, ok)
+	require.NoError(t, hint.Execute(vm, nil))
+
+	price, err := vm.Memory.Read(VM.ExecutionSegment, 3)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromInt(1234), price)
+	history, err := vm.Memory.Read(VM.ExecutionSegment, 4)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromSegmentAndOffset(2, 0), history)
+	for i, expected := range []int{5, 6} {
+		value, err := vm.Memory.Read(2, uint64(i))
+		require.NoError(t, err)
+		require.Equal(t, memory.MemoryValueFromInt(expected), value)
+	}
+
+	hint, ok = oracle.Hinter("# oracle unknown", nil)
+	require.True(t, ok)
+	require.ErrorContains(t, hint.Execute(vm, nil), "oracle unknown: error -32601: method not found")
+}
+
+func TestOracleConfig(t *testing.T) {
+	_, err := New(Config{})
+	require.ErrorContains(t, err, "oracle endpoint not set")
+
+	_, err = New(Config{
+		Endpoint: "http://localhost",
+		Hints:    []Hint{{Code: "# oracle", Method: "m", Results: []Result{{Name: "x", Type: "u256"}}}},
+	})
+	require.ErrorContains(t, err, `result x has type "u256", expected felt or felt*`)
+}
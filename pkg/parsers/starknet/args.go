@@ -1,7 +1,9 @@
 package starknet
 
 import (
+	"encoding/hex"
 	"fmt"
+	"os"
 	"regexp"
 	"strings"
 
@@ -54,3 +56,50 @@ func ParseCairoProgramArgs(input string) ([]CairoFuncArgs, error) {
 
 	return result, nil
 }
+
+// Formats of the files loaded as array arguments by BlobArgFromFile
+const (
+	BlobFormatBinary = "bin"
+	BlobFormatHex    = "hex"
+)
+
+// PackBytes splits data into big endian chunks of bytesPerFelt bytes, from 1 to 31
+// so that every chunk fits in a felt, and returns the felts of the chunks. The last
+// chunk is shorter when the length of data is not a multiple of bytesPerFelt
+func PackBytes(data []byte, bytesPerFelt int) ([]fp.Element, error) {
+	if bytesPerFelt < 1 || bytesPerFelt > 31 {
+		return nil, fmt.Errorf("cannot pack %d bytes per felt, expected 1 to 31", bytesPerFelt)
+	}
+	felts := make([]fp.Element, (len(data)+bytesPerFelt-1)/bytesPerFelt)
+	for i := range felts {
+		chunk := data[i*bytesPerFelt : min((i+1)*bytesPerFelt, len(data))]
+		felts[i].SetBytes(chunk)
+	}
+	return felts, nil
+}
+
+// BlobArgFromFile loads a file as an array argument. A binary file is packed as is,
+// while a hex file holds the hex encoding of the bytes, optionally 0x prefixed,
+// whitespace being ignored
+func BlobArgFromFile(path string, format string, bytesPerFelt int) (CairoFuncArgs, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return CairoFuncArgs{}, err
+	}
+	switch format {
+	case BlobFormatBinary:
+	case BlobFormatHex:
+		text := strings.Join(strings.Fields(string(content)), "")
+		content, err = hex.DecodeString(strings.TrimPrefix(text, "0x"))
+		if err != nil {
+			return CairoFuncArgs{}, fmt.Errorf("blob %s: %w", path, err)
+		}
+	default:
+		return CairoFuncArgs{}, fmt.Errorf("unknown blob format %s, expected bin or hex", format)
+	}
+	felts, err := PackBytes(content, bytesPerFelt)
+	if err != nil {
+		return CairoFuncArgs{}, fmt.Errorf("blob %s: %w", path, err)
+	}
+	return CairoFuncArgs{Array: felts}, nil
+}
@@ -2,6 +2,8 @@ package starknet
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -113,3 +115,39 @@ func TestParseStarknetProgramArgs(t *testing.T) {
 		})
 	}
 }
+
+func TestPackBytes(t *testing.T) {
+	felts, err := PackBytes([]byte{1, 2, 3, 4, 5}, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []fp.Element{fp.NewElement(0x0102), fp.NewElement(0x0304), fp.NewElement(5)}, felts)
+
+	data := make([]byte, 62)
+	data[30], data[61] = 1, 2
+	felts, err = PackBytes(data, 31)
+	require.NoError(t, err)
+	assert.Equal(t, []fp.Element{fp.NewElement(1), fp.NewElement(2)}, felts)
+
+	_, err = PackBytes(data, 32)
+	require.ErrorContains(t, err, "cannot pack 32 bytes per felt")
+}
+
+func TestBlobArgFromFile(t *testing.T) {
+	dir := t.TempDir()
+	binary := filepath.Join(dir, "blob.bin")
+	require.NoError(t, os.WriteFile(binary, []byte{0xca, 0xfe, 0x01}, 0o644))
+	hexadecimal := filepath.Join(dir, "blob.hex")
+	require.NoError(t, os.WriteFile(hexadecimal, []byte("0xcafe\n01\n"), 0o644))
+
+	expected := CairoFuncArgs{Array: []fp.Element{fp.NewElement(0xcafe), fp.NewElement(1)}}
+	arg, err := BlobArgFromFile(binary, BlobFormatBinary, 2)
+	require.NoError(t, err)
+	assert.Equal(t, expected, arg)
+	arg, err = BlobArgFromFile(hexadecimal, BlobFormatHex, 2)
+	require.NoError(t, err)
+	assert.Equal(t, expected, arg)
+
+	_, err = BlobArgFromFile(binary, BlobFormatHex, 2)
+	require.ErrorContains(t, err, "invalid byte")
+	_, err = BlobArgFromFile(binary, "base64", 2)
+	require.ErrorContains(t, err, "unknown blob format base64")
+}
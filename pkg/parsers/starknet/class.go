@@ -0,0 +1,168 @@
+package starknet
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// SierraContractClass is a Starknet contract class compiled to Sierra, as written
+// by scarb or returned by the starknet_getClass RPC method. It can't be run as is,
+// its compiled CASM class has to be converted with CompiledClassToProgram
+type SierraContractClass struct {
+	SierraProgram        []fp.Element    `json:"sierra_program"`
+	ContractClassVersion string          `json:"contract_class_version"`
+	Abi                  json.RawMessage `json:"abi"`
+}
+
+func SierraContractClassFromJSON(content json.RawMessage) (*SierraContractClass, error) {
+	var class SierraContractClass
+	if err := json.Unmarshal(content, &class); err != nil {
+		return nil, err
+	}
+	// the RPC returns the ABI as a string holding its JSON
+	if len(class.Abi) > 0 && class.Abi[0] == '"' {
+		var abi string
+		if err := json.Unmarshal(class.Abi, &abi); err != nil {
+			return nil, err
+		}
+		class.Abi = json.RawMessage(abi)
+	}
+	return &class, nil
+}
+
+// Contract entry points take the calldata as a span and return their retdata as a
+// span wrapped in a panic result
+var (
+	contractEntryPointInputArgs = []Arg{{
+		GenericID: "core::array::Span::<core::felt252>",
+		Size:      2,
+		DebugName: "Span<felt252>",
+	}}
+	contractEntryPointReturnArgs = []Arg{{
+		GenericID:      "core::panics::PanicResult::<(core::array::Span::<core::felt252>,)>",
+		Size:           3,
+		DebugName:      "core::panics::PanicResult::<(core::array::Span::<core::felt252>,)>",
+		PanicInnerType: PanicInnerType{Size: 2, DebugName: "Span<felt252>"},
+	}}
+)
+
+type compiledClassEntryPoint struct {
+	Selector fp.Element `json:"selector"`
+	Offset   fp.Element `json:"offset"`
+	Builtins []string   `json:"builtins"`
+}
+
+// Same as EntryPointByFunction, with builtin names since builtins.BuiltinType
+// doesn't marshal the gas and system builtins
+type programEntryPoint struct {
+	Offset     int      `json:"offset"`
+	Builtins   []string `json:"builtins"`
+	InputArgs  []Arg    `json:"input_args"`
+	ReturnArgs []Arg    `json:"return_arg"`
+}
+
+// CompiledClassToProgram converts a compiled CASM contract class into a program
+// run by cairo-run, by adding the entry_points_by_function of its entry points.
+// Entry points are named after the functions of the ABI whose selector they have,
+// and after their hex selector when the ABI is empty or doesn't list them. The ABI
+// is kept in the program, and the rest of the class, hints included, is left as is.
+// As cairo-run runs the main function, the entry point named main, when not empty,
+// is also added as main
+func CompiledClassToProgram(compiledClass json.RawMessage, abi json.RawMessage, main string) (json.RawMessage, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(compiledClass, &fields); err != nil {
+		return nil, err
+	}
+	if _, ok := fields["bytecode"]; !ok {
+		return nil, errors.New("compiled class has no bytecode")
+	}
+	var entryPointsByType struct {
+		External    []compiledClassEntryPoint `json:"EXTERNAL"`
+		L1Handler   []compiledClassEntryPoint `json:"L1_HANDLER"`
+		Constructor []compiledClassEntryPoint `json:"CONSTRUCTOR"`
+	}
+	if err := json.Unmarshal(fields["entry_points_by_type"], &entryPointsByType); err != nil {
+		return nil, fmt.Errorf("entry_points_by_type: %w", err)
+	}
+	names, err := abiFunctionNames(abi)
+	if err != nil {
+		return nil, fmt.Errorf("abi: %w", err)
+	}
+
+	entryPoints := make(map[string]programEntryPoint)
+	for _, byType := range [][]compiledClassEntryPoint{entryPointsByType.External, entryPointsByType.L1Handler, entryPointsByType.Constructor} {
+		for i := range byType {
+			entryPoint := &byType[i]
+			name, ok := names[entryPoint.Selector]
+			if !ok {
+				name = "0x" + entryPoint.Selector.Text(16)
+			}
+			if !entryPoint.Offset.IsUint64() {
+				return nil, fmt.Errorf("entry point %s: invalid offset %s", name, &entryPoint.Offset)
+			}
+			if _, ok := entryPoints[name]; ok {
+				return nil, fmt.Errorf("entry point %s: declared twice", name)
+			}
+			entryPoints[name] = programEntryPoint{
+				Offset: int(entryPoint.Offset.Uint64()),
+				// implicit arguments of every contract function
+				Builtins:   append(entryPoint.Builtins, builtins.GasBuiltinName, builtins.SystemBuiltinName),
+				InputArgs:  contractEntryPointInputArgs,
+				ReturnArgs: contractEntryPointReturnArgs,
+			}
+		}
+	}
+
+	if main != "" {
+		entryPoint, ok := entryPoints[main]
+		if !ok {
+			return nil, fmt.Errorf("no entry point is named %s", main)
+		}
+		entryPoints["main"] = entryPoint
+	}
+
+	if fields["entry_points_by_function"], err = json.Marshal(entryPoints); err != nil {
+		return nil, err
+	}
+	if len(abi) > 0 {
+		fields["abi"] = abi
+	}
+	return json.Marshal(fields)
+}
+
+// Maps the selectors of the functions, l1 handlers and constructors of an ABI to
+// their names, looking into the interfaces the ABI lists
+func abiFunctionNames(abi json.RawMessage) (map[fp.Element]string, error) {
+	names := make(map[fp.Element]string)
+	if len(abi) == 0 {
+		return names, nil
+	}
+	type abiItem struct {
+		Type  string            `json:"type"`
+		Name  string            `json:"name"`
+		Items []json.RawMessage `json:"items"`
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(abi, &items); err != nil {
+		return nil, err
+	}
+	for len(items) > 0 {
+		var item abiItem
+		if err := json.Unmarshal(items[0], &item); err != nil {
+			return nil, err
+		}
+		items = items[1:]
+		switch item.Type {
+		case "function", "l1_handler", "constructor":
+			names[utils.StarknetKeccak([]byte(item.Name))] = item.Name
+		case "interface":
+			items = append(items, item.Items...)
+		}
+	}
+	return names, nil
+}
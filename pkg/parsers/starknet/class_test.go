@@ -0,0 +1,73 @@
+package starknet
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+)
+
+func TestSierraContractClassAbi(t *testing.T) {
+	// the RPC returns the ABI as a string, scarb writes it as an array
+	for _, abi := range []string{
+		`"[{\"type\": \"function\", \"name\": \"transfer\"}]"`,
+		`[{"type": "function", "name": "transfer"}]`,
+	} {
+		class, err := SierraContractClassFromJSON([]byte(`
+           {
+              "sierra_program": ["0x1", "0x6"],
+              "contract_class_version": "0.1.0",
+              "abi": ` + abi + `
+           }
+        `))
+		require.NoError(t, err)
+		require.Len(t, class.SierraProgram, 2)
+		require.Equal(t, "0.1.0", class.ContractClassVersion)
+		require.JSONEq(t, `[{"type": "function", "name": "transfer"}]`, string(class.Abi))
+	}
+}
+
+func TestCompiledClassToProgram(t *testing.T) {
+	compiledClass := []byte(`
+       {
+          "prime": "0x800000000000011000000000000000000000000000000000000000000000001",
+          "compiler_version": "2.6.0",
+          "bytecode": ["0x480680017fff8000", "0x1", "0x208b7fff7fff7ffe"],
+          "hints": [],
+          "entry_points_by_type": {
+             "EXTERNAL": [
+                {"selector": "0x83afd3f4caedc6eebf44246fe54e38c95e3179a5ec9ea81740eca5b482d12e", "offset": 0, "builtins": ["range_check"]},
+                {"selector": "0x5", "offset": 2, "builtins": []}
+             ],
+             "L1_HANDLER": [],
+             "CONSTRUCTOR": []
+          }
+       }
+    `)
+	abi := []byte(`[{"type": "interface", "name": "IToken", "items": [{"type": "function", "name": "transfer"}]}]`)
+
+	content, err := CompiledClassToProgram(compiledClass, abi, "transfer")
+	require.NoError(t, err)
+	program, err := StarknetProgramFromJSON(content)
+	require.NoError(t, err)
+	require.Equal(t, "2.6.0", program.CompilerVersion)
+	require.Len(t, program.Bytecode, 3)
+	require.JSONEq(t, string(abi), string(program.Abi))
+
+	// the entry points are named after the ABI, or their selector
+	require.Len(t, program.EntryPointsByFunction, 3)
+	transfer := program.EntryPointsByFunction["transfer"]
+	require.Equal(t, 0, transfer.Offset)
+	require.Equal(t, []builtins.BuiltinType{builtins.RangeCheckType, builtins.GasBuiltinType, builtins.SystemBuiltinType}, transfer.Builtins)
+	require.Equal(t, 2, transfer.InputArgs[0].Size)
+	require.Equal(t, 3, transfer.ReturnArgs[0].Size)
+	require.Equal(t, transfer, program.EntryPointsByFunction["main"])
+	require.Equal(t, 2, program.EntryPointsByFunction["0x5"].Offset)
+
+	_, err = CompiledClassToProgram(compiledClass, nil, "transfer")
+	require.EqualError(t, err, "no entry point is named transfer")
+
+	_, err = CompiledClassToProgram([]byte(`{"sierra_program": []}`), nil, "")
+	require.EqualError(t, err, "compiled class has no bytecode")
+}
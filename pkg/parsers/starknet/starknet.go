@@ -89,6 +89,9 @@ type StarknetProgram struct {
 	EntryPointsByType     EntryPointByType                `json:"entry_points_by_type"`
 	EntryPointsByFunction map[string]EntryPointByFunction `json:"entry_points_by_function"`
 	Hints                 []Hints                         `json:"hints" validate:"required"`
+	// ABI of the contract class the program was converted from, see
+	// CompiledClassToProgram
+	Abi json.RawMessage `json:"abi,omitempty"`
 }
 
 func StarknetProgramFromFile(pathToFile string) (*StarknetProgram, error) {
@@ -1,10 +1,16 @@
 package zero
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"encoding/json"
+	"fmt"
+	"io"
 	"os"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
 type FlowTrackingData struct {
@@ -78,6 +84,9 @@ type ZeroProgram struct {
 	ReferenceManager ReferenceManager       `json:"reference_manager"`
 	Attributes       []AttributeScope       `json:"attributes"`
 	DebugInfo        DebugInfo              `json:"debug_info"`
+	// ABI of the contract class the program was converted from, see
+	// DeprecatedContractClass.ToProgram
+	Abi json.RawMessage `json:"abi,omitempty"`
 }
 
 type Identifier struct {
@@ -124,3 +133,93 @@ func ZeroProgramFromJSON(content json.RawMessage) (*ZeroProgram, error) {
 	var zero ZeroProgram
 	return &zero, json.Unmarshal(content, &zero)
 }
+
+// DeprecatedContractClass is a Starknet contract class compiled from Cairo Zero,
+// whose program is run by calling the wrapper of an entry point
+type DeprecatedContractClass struct {
+	Program           ZeroProgram                 `json:"program"`
+	EntryPointsByType DeprecatedEntryPointsByType `json:"entry_points_by_type"`
+	Abi               json.RawMessage             `json:"abi"`
+}
+
+type DeprecatedEntryPoint struct {
+	Selector fp.Element `json:"selector"`
+	Offset   fp.Element `json:"offset"`
+}
+
+type DeprecatedEntryPointsByType struct {
+	External    []DeprecatedEntryPoint `json:"EXTERNAL"`
+	L1Handler   []DeprecatedEntryPoint `json:"L1_HANDLER"`
+	Constructor []DeprecatedEntryPoint `json:"CONSTRUCTOR"`
+}
+
+// DeprecatedContractClassFromJSON parses a class as compiled by cairo-lang, or as
+// returned by the starknet_getClass RPC method whose program is gzip compressed and
+// base64 encoded
+func DeprecatedContractClassFromJSON(content json.RawMessage) (*DeprecatedContractClass, error) {
+	type plainClass DeprecatedContractClass
+	var class struct {
+		plainClass
+		Program json.RawMessage `json:"program"`
+	}
+	if err := json.Unmarshal(content, &class); err != nil {
+		return nil, err
+	}
+	program, err := decodeClassProgram(class.Program)
+	if err != nil {
+		return nil, fmt.Errorf("class program: %w", err)
+	}
+	deprecatedClass := DeprecatedContractClass(class.plainClass)
+	deprecatedClass.Program = *program
+	return &deprecatedClass, nil
+}
+
+func decodeClassProgram(content json.RawMessage) (*ZeroProgram, error) {
+	if len(content) > 0 && content[0] == '"' {
+		var encoded string
+		if err := json.Unmarshal(content, &encoded); err != nil {
+			return nil, err
+		}
+		compressed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, err
+		}
+		reader, err := gzip.NewReader(bytes.NewReader(compressed))
+		if err != nil {
+			return nil, err
+		}
+		defer reader.Close()
+		content, err = io.ReadAll(reader)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ZeroProgramFromJSON(content)
+}
+
+// ToProgram returns the program of the class along with the ABI of the class, so
+// that it can be written as a program file and run with the entry point offsets
+// of the class
+func (class *DeprecatedContractClass) ToProgram() *ZeroProgram {
+	program := class.Program
+	program.Abi = class.Abi
+	return &program
+}
+
+// EntryPointOffset returns the pc of the entry point of a selector, whichever its
+// type
+func (class *DeprecatedContractClass) EntryPointOffset(selector *fp.Element) (uint64, error) {
+	entryPoints := &class.EntryPointsByType
+	for _, byType := range [][]DeprecatedEntryPoint{entryPoints.External, entryPoints.L1Handler, entryPoints.Constructor} {
+		for i := range byType {
+			if !byType[i].Selector.Equal(selector) {
+				continue
+			}
+			if !byType[i].Offset.IsUint64() {
+				return 0, fmt.Errorf("entry point 0x%s: invalid offset %s", selector.Text(16), &byType[i].Offset)
+			}
+			return byType[i].Offset.Uint64(), nil
+		}
+	}
+	return 0, fmt.Errorf("no entry point has selector 0x%s", selector.Text(16))
+}
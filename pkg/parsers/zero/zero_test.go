@@ -1,9 +1,14 @@
 package zero
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"fmt"
 	"testing"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 
 	"github.com/stretchr/testify/require"
 )
@@ -425,3 +430,72 @@ func TestDebugInfo(t *testing.T) {
 		zeroProgram,
 	)
 }
+
+func TestDeprecatedContractClass(t *testing.T) {
+	content := []byte(`
+        {
+            "abi": [],
+            "entry_points_by_type": {
+                "CONSTRUCTOR": [],
+                "EXTERNAL": [
+                    {"offset": "0x3a", "selector": "0x362398bec32bc0ebb411203221a35a0301193a96f317ebe5e40be9f60d15320"}
+                ],
+                "L1_HANDLER": [
+                    {"offset": "0x5c", "selector": "0x2"}
+                ]
+            },
+            "program": {
+                "builtins": ["pedersen", "range_check"],
+                "data": ["0x208b7fff7fff7ffe"],
+                "main_scope": "__main__"
+            }
+        }
+    `)
+	class, err := DeprecatedContractClassFromJSON(content)
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x208b7fff7fff7ffe"}, class.Program.Data)
+	require.Equal(t, []builtins.BuiltinType{builtins.PedersenType, builtins.RangeCheckType}, class.Program.Builtins)
+
+	selector, err := new(fp.Element).SetString("0x362398bec32bc0ebb411203221a35a0301193a96f317ebe5e40be9f60d15320")
+	require.NoError(t, err)
+	offset, err := class.EntryPointOffset(selector)
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x3a), offset)
+
+	offset, err = class.EntryPointOffset(new(fp.Element).SetUint64(2))
+	require.NoError(t, err)
+	require.Equal(t, uint64(0x5c), offset)
+
+	_, err = class.EntryPointOffset(new(fp.Element).SetUint64(3))
+	require.EqualError(t, err, "no entry point has selector 0x3")
+}
+
+func TestDeprecatedContractClassCompressedProgram(t *testing.T) {
+	// starknet_getClass returns the program gzip compressed and base64 encoded
+	var compressed bytes.Buffer
+	writer := gzip.NewWriter(&compressed)
+	_, err := writer.Write([]byte(`{"builtins": ["range_check"], "data": ["0x208b7fff7fff7ffe"], "main_scope": "__main__"}`))
+	require.NoError(t, err)
+	require.NoError(t, writer.Close())
+
+	content := fmt.Sprintf(`
+        {
+            "abi": [{"name": "get_balance", "type": "function", "inputs": [], "outputs": []}],
+            "entry_points_by_type": {"CONSTRUCTOR": [], "EXTERNAL": [], "L1_HANDLER": []},
+            "program": %q
+        }
+    `, base64.StdEncoding.EncodeToString(compressed.Bytes()))
+	class, err := DeprecatedContractClassFromJSON([]byte(content))
+	require.NoError(t, err)
+	require.Equal(t, []string{"0x208b7fff7fff7ffe"}, class.Program.Data)
+	require.Equal(t, []builtins.BuiltinType{builtins.RangeCheckType}, class.Program.Builtins)
+
+	// the program keeps the ABI of the class
+	program := class.ToProgram()
+	require.Equal(t, class.Program.Data, program.Data)
+	require.JSONEq(t, `[{"name": "get_balance", "type": "function", "inputs": [], "outputs": []}]`, string(program.Abi))
+	require.Nil(t, class.Program.Abi)
+
+	_, err = DeprecatedContractClassFromJSON([]byte(`{"program": "not base64"}`))
+	require.ErrorContains(t, err, "class program")
+}
@@ -0,0 +1,85 @@
+package plugin
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Builtin is a builtin runner implemented by a plugin. It can be part of a custom
+// layout, and programs can declare it once its name is registered with
+// builtins.RegisterBuiltin
+type Builtin struct {
+	plugin      *Plugin
+	info        BuiltinInfo
+	stopPointer uint64
+}
+
+func (b *Builtin) String() string {
+	return b.info.Name
+}
+
+// Lets the plugin read the builtin segment, the only memory it has access to
+func (b *Builtin) segmentHandler(segment *memory.Segment) callbackHandler {
+	return func(method string, params map[string]any) (any, error) {
+		if method != "read" {
+			return nil, fmt.Errorf("method %s is not allowed for builtins", method)
+		}
+		offset, ok := params["offset"].(uint64)
+		if !ok {
+			return nil, fmt.Errorf("expected an offset, got %v", params["offset"])
+		}
+		value := segment.Peek(offset)
+		return encodeValue(&value), nil
+	}
+}
+
+func (b *Builtin) CheckWrite(segment *memory.Segment, offset uint64, value *memory.MemoryValue) error {
+	params := map[string]any{
+		"builtin": b.info.Name,
+		"offset":  offset,
+		"value":   encodeValue(value),
+	}
+	_, err := b.plugin.call("check_write", params, b.segmentHandler(segment))
+	return err
+}
+
+func (b *Builtin) InferValue(segment *memory.Segment, offset uint64) error {
+	params := map[string]any{
+		"builtin": b.info.Name,
+		"offset":  offset,
+	}
+	result, err := b.plugin.call("infer_value", params, b.segmentHandler(segment))
+	if err != nil {
+		return err
+	}
+	value, err := decodeValue(result)
+	if err != nil {
+		return b.plugin.errorf("infer_value: %v", err)
+	}
+	if !value.Known() {
+		return fmt.Errorf("%s: cannot infer value at offset %d", b.info.Name, offset)
+	}
+	return segment.Write(offset, &value)
+}
+
+func (b *Builtin) GetAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint64) (uint64, error) {
+	instances, err := builtins.GetBuiltinAllocatedInstances(b.info.Ratio, b.info.CellsPerInstance, segmentUsedSize, 1, vmCurrentStep)
+	if err != nil {
+		return 0, err
+	}
+	return instances * b.info.CellsPerInstance, nil
+}
+
+func (b *Builtin) GetCellsPerInstance() uint64 {
+	return b.info.CellsPerInstance
+}
+
+func (b *Builtin) GetStopPointer() uint64 {
+	return b.stopPointer
+}
+
+func (b *Builtin) SetStopPointer(stopPointer uint64) {
+	b.stopPointer = stopPointer
+}
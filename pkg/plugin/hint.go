@@ -0,0 +1,92 @@
+package plugin
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Hint executes a hint code in the plugin process
+type Hint struct {
+	plugin     *Plugin
+	code       string
+	references map[string]hinter.Reference
+}
+
+// Hinter returns the hinter of a hint code, or false if the plugin does not implement
+// it. The references are the ids the hint has access to
+func (plugin *Plugin) Hinter(code string, references map[string]hinter.Reference) (hinter.Hinter, bool) {
+	if !plugin.hints[code] {
+		return nil, false
+	}
+	return &Hint{plugin: plugin, code: code, references: references}, true
+}
+
+func (hint *Hint) String() string {
+	return "Plugin"
+}
+
+func (hint *Hint) Execute(vm *VM.VirtualMachine, _ *hinter.HintRunnerContext) error {
+	ids := make(map[string]any, len(hint.references))
+	for name, reference := range hint.references {
+		address, err := reference.Get(vm)
+		if err != nil {
+			// references which aren't memory cells, e.g. constants, aren't sent
+			continue
+		}
+		ids[name] = encodeAddress(&address)
+	}
+	params := map[string]any{
+		"code": hint.code,
+		"pc":   encodeAddress(&vm.Context.Pc),
+		"ap":   vm.Context.Ap,
+		"fp":   vm.Context.Fp,
+		"ids":  ids,
+	}
+	_, err := hint.plugin.call("execute_hint", params, func(method string, params map[string]any) (any, error) {
+		switch method {
+		case "read":
+			address, err := decodeAddress(params["address"])
+			if err != nil {
+				return nil, err
+			}
+			value, err := vm.Memory.PeekFromAddress(&address)
+			if err != nil {
+				return nil, err
+			}
+			return encodeValue(&value), nil
+		case "write":
+			address, err := decodeAddress(params["address"])
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeValue(params["value"])
+			if err != nil {
+				return nil, err
+			}
+			if !value.Known() {
+				return nil, errors.New("cannot write an unknown value")
+			}
+			return nil, vm.Memory.WriteToAddress(&address, &value)
+		case "allocate":
+			address := vm.Memory.AllocateEmptySegment()
+			return encodeAddress(&address), nil
+		default:
+			return nil, fmt.Errorf("unknown method %s", method)
+		}
+	})
+	return err
+}
+
+// Builtin returns a runner delegating the checks and deductions of the builtin to
+// the plugin
+func (plugin *Plugin) Builtin(name string) (memory.BuiltinRunner, error) {
+	info, ok := plugin.builtins[name]
+	if !ok {
+		return nil, plugin.errorf("builtin %s is not provided", name)
+	}
+	return &Builtin{plugin: plugin, info: info}, nil
+}
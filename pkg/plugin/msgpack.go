@@ -0,0 +1,254 @@
+package plugin
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+)
+
+// Subset of msgpack (https://msgpack.org) used by the plugin protocol: nil, booleans,
+// integers, strings, binary data, arrays and maps with string keys. Decoded integers
+// are uint64 when non negative and int64 otherwise, arrays are []any and maps are
+// map[string]any
+
+func encodeMsgpack(w io.Writer, value any) error {
+	var buf []byte
+	buf, err := appendMsgpack(buf, value)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(buf)
+	return err
+}
+
+func appendMsgpack(buf []byte, value any) ([]byte, error) {
+	switch v := value.(type) {
+	case nil:
+		return append(buf, 0xc0), nil
+	case bool:
+		if v {
+			return append(buf, 0xc3), nil
+		}
+		return append(buf, 0xc2), nil
+	case int:
+		return appendInt(buf, int64(v)), nil
+	case int64:
+		return appendInt(buf, v), nil
+	case uint64:
+		return appendUint(buf, v), nil
+	case int16:
+		return appendInt(buf, int64(v)), nil
+	case string:
+		buf = appendLength(buf, len(v), 0xa0, 32, 0xd9, 0xda, 0xdb)
+		return append(buf, v...), nil
+	case []byte:
+		buf = appendLength(buf, len(v), 0, 0, 0xc4, 0xc5, 0xc6)
+		return append(buf, v...), nil
+	case []string:
+		buf = appendLength(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			buf = appendLength(buf, len(item), 0xa0, 32, 0xd9, 0xda, 0xdb)
+			buf = append(buf, item...)
+		}
+		return buf, nil
+	case []any:
+		buf = appendLength(buf, len(v), 0x90, 16, 0, 0xdc, 0xdd)
+		for _, item := range v {
+			var err error
+			if buf, err = appendMsgpack(buf, item); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	case map[string]any:
+		buf = appendLength(buf, len(v), 0x80, 16, 0, 0xde, 0xdf)
+		// sorted keys keep the encoding deterministic
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			buf = appendLength(buf, len(key), 0xa0, 32, 0xd9, 0xda, 0xdb)
+			buf = append(buf, key...)
+			var err error
+			if buf, err = appendMsgpack(buf, v[key]); err != nil {
+				return nil, err
+			}
+		}
+		return buf, nil
+	default:
+		return nil, fmt.Errorf("msgpack: cannot encode %T", value)
+	}
+}
+
+func appendUint(buf []byte, v uint64) []byte {
+	switch {
+	case v < 0x80:
+		return append(buf, byte(v))
+	case v <= math.MaxUint8:
+		return append(buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xcd), uint16(v))
+	case v <= math.MaxUint32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xce), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xcf), v)
+	}
+}
+
+func appendInt(buf []byte, v int64) []byte {
+	switch {
+	case v >= 0:
+		return appendUint(buf, uint64(v))
+	case v >= -32:
+		return append(buf, byte(v))
+	case v >= math.MinInt8:
+		return append(buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		return binary.BigEndian.AppendUint16(append(buf, 0xd1), uint16(v))
+	case v >= math.MinInt32:
+		return binary.BigEndian.AppendUint32(append(buf, 0xd2), uint32(v))
+	default:
+		return binary.BigEndian.AppendUint64(append(buf, 0xd3), uint64(v))
+	}
+}
+
+// Appends the header of a string, binary, array or map. A zero fixMax means the
+// type has no fix format and a zero code8 that it has no 8 bits length format
+func appendLength(buf []byte, length int, fix byte, fixMax int, code8, code16, code32 byte) []byte {
+	switch {
+	case length < fixMax:
+		return append(buf, fix|byte(length))
+	case code8 != 0 && length <= math.MaxUint8:
+		return append(buf, code8, byte(length))
+	case length <= math.MaxUint16:
+		return binary.BigEndian.AppendUint16(append(buf, code16), uint16(length))
+	default:
+		return binary.BigEndian.AppendUint32(append(buf, code32), uint32(length))
+	}
+}
+
+func decodeMsgpack(r io.Reader) (any, error) {
+	code, err := readBytes(r, 1)
+	if err != nil {
+		return nil, err
+	}
+	c := code[0]
+	switch {
+	case c < 0x80:
+		return uint64(c), nil
+	case c >= 0xe0:
+		return int64(int8(c)), nil
+	case c&0xe0 == 0xa0:
+		return decodeString(r, int(c&0x1f))
+	case c&0xf0 == 0x90:
+		return decodeArray(r, int(c&0x0f))
+	case c&0xf0 == 0x80:
+		return decodeMap(r, int(c&0x0f))
+	}
+
+	switch c {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xcc, 0xcd, 0xce, 0xcf:
+		return readUint(r, 1<<(c-0xcc))
+	case 0xd0, 0xd1, 0xd2, 0xd3:
+		size := 1 << (c - 0xd0)
+		v, err := readUint(r, size)
+		if err != nil {
+			return nil, err
+		}
+		// sign extend from the encoded size
+		shift := 64 - 8*size
+		signed := int64(v<<shift) >> shift
+		if signed >= 0 {
+			return uint64(signed), nil
+		}
+		return signed, nil
+	case 0xd9, 0xda, 0xdb:
+		length, err := readUint(r, 1<<(c-0xd9))
+		if err != nil {
+			return nil, err
+		}
+		return decodeString(r, int(length))
+	case 0xc4, 0xc5, 0xc6:
+		length, err := readUint(r, 1<<(c-0xc4))
+		if err != nil {
+			return nil, err
+		}
+		return readBytes(r, int(length))
+	case 0xdc, 0xdd:
+		length, err := readUint(r, 2<<(c-0xdc))
+		if err != nil {
+			return nil, err
+		}
+		return decodeArray(r, int(length))
+	case 0xde, 0xdf:
+		length, err := readUint(r, 2<<(c-0xde))
+		if err != nil {
+			return nil, err
+		}
+		return decodeMap(r, int(length))
+	}
+	return nil, fmt.Errorf("msgpack: unsupported type code 0x%x", c)
+}
+
+func readBytes(r io.Reader, n int) ([]byte, error) {
+	buf := make([]byte, n)
+	_, err := io.ReadFull(r, buf)
+	return buf, err
+}
+
+func readUint(r io.Reader, size int) (uint64, error) {
+	buf, err := readBytes(r, size)
+	if err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range buf {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+func decodeString(r io.Reader, length int) (string, error) {
+	buf, err := readBytes(r, length)
+	return string(buf), err
+}
+
+func decodeArray(r io.Reader, length int) ([]any, error) {
+	array := make([]any, length)
+	for i := range array {
+		var err error
+		if array[i], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return array, nil
+}
+
+func decodeMap(r io.Reader, length int) (map[string]any, error) {
+	m := make(map[string]any, length)
+	for i := 0; i < length; i++ {
+		key, err := decodeMsgpack(r)
+		if err != nil {
+			return nil, err
+		}
+		keyString, ok := key.(string)
+		if !ok {
+			return nil, errors.New("msgpack: map keys must be strings")
+		}
+		if m[keyString], err = decodeMsgpack(r); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,50 @@
+package plugin
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMsgpackRoundTrip(t *testing.T) {
+	values := []any{
+		nil,
+		true,
+		false,
+		uint64(0),
+		uint64(127),
+		uint64(128),
+		uint64(math.MaxUint16 + 1),
+		uint64(math.MaxUint64),
+		int64(-1),
+		int64(-33),
+		int64(math.MinInt16),
+		int64(math.MinInt64),
+		"",
+		strings.Repeat("a", 40),
+		strings.Repeat("b", 300),
+		[]byte{1, 2, 3},
+		[]any{uint64(1), "two", []any{}},
+		map[string]any{"id": uint64(7), "params": map[string]any{"offset": uint64(3)}},
+	}
+	for _, value := range values {
+		var buffer bytes.Buffer
+		require.NoError(t, encodeMsgpack(&buffer, value))
+		decoded, err := decodeMsgpack(&buffer)
+		require.NoError(t, err)
+		require.Equal(t, value, decoded)
+		require.Zero(t, buffer.Len())
+	}
+}
+
+func TestMsgpackKnownEncodings(t *testing.T) {
+	var buffer bytes.Buffer
+	require.NoError(t, encodeMsgpack(&buffer, map[string]any{"a": int64(-1), "b": []string{"c"}}))
+	require.Equal(t, []byte{0x82, 0xa1, 'a', 0xff, 0xa1, 'b', 0x91, 0xa1, 'c'}, buffer.Bytes())
+
+	_, err := decodeMsgpack(bytes.NewReader([]byte{0xcb}))
+	require.ErrorContains(t, err, "unsupported type code 0xcb")
+}
@@ -0,0 +1,255 @@
+// Package plugin lets external processes provide hints and builtins to the VM, so
+// that closed-source or experimental components can be used without recompiling it.
+//
+// A plugin is an executable exchanging msgpack messages with the VM over its stdin
+// and stdout. Requests are maps with an "id", a "method" and its "params", which
+// are answered by a map with the same "id" and either a "result" or an "error"
+// string. Both sides send requests: while a request of the VM is being handled, the
+// plugin can call back into the VM to access memory, the VM answering each callback
+// before the plugin answers the original request.
+//
+// Field elements are encoded as 32 bytes big endian binaries, memory addresses as
+// [segment, offset] arrays and unknown memory values as nil.
+//
+// Requests sent by the VM:
+//
+//	hello        {"protocol": 1}
+//	             -> {"hints": [hint code], "builtins": [{"name", "cells_per_instance", "ratio"}]}
+//	execute_hint {"code", "pc", "ap", "fp", "ids": {name: address}}
+//	             -> nil
+//	check_write  {"builtin", "offset", "value"}
+//	             -> nil, or an error when the value is not valid
+//	infer_value  {"builtin", "offset"}
+//	             -> the value of the cell
+//
+// Callbacks sent by the plugin:
+//
+//	read         {"address"} -> value
+//	write        {"address", "value"} -> nil
+//	allocate     {} -> address of a new segment, only while executing a hint
+//
+// While checking or inferring builtin values, only the builtin segment can be read,
+// with a read callback taking the {"offset"} of the cell.
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+const ProtocolVersion = 1
+
+type BuiltinInfo struct {
+	Name             string
+	CellsPerInstance uint64
+	Ratio            uint64
+}
+
+type Plugin struct {
+	path   string
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	stdout *bufio.Reader
+	nextId uint64
+	// hint codes implemented by the plugin
+	hints    map[string]bool
+	builtins map[string]BuiltinInfo
+}
+
+// Handles a callback of the plugin
+type callbackHandler func(method string, params map[string]any) (any, error)
+
+// Starts the plugin executable and retrieves the hints and builtins it provides
+func Start(path string, args ...string) (*Plugin, error) {
+	cmd := exec.Command(path, args...)
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", path, err)
+	}
+	plugin := &Plugin{
+		path:     path,
+		cmd:      cmd,
+		stdin:    stdin,
+		stdout:   bufio.NewReader(stdout),
+		hints:    make(map[string]bool),
+		builtins: make(map[string]BuiltinInfo),
+	}
+	if err := plugin.hello(); err != nil {
+		plugin.Close()
+		return nil, err
+	}
+	return plugin, nil
+}
+
+func (plugin *Plugin) hello() error {
+	result, err := plugin.call("hello", map[string]any{"protocol": ProtocolVersion}, nil)
+	if err != nil {
+		return err
+	}
+	hello, ok := result.(map[string]any)
+	if !ok {
+		return plugin.errorf("hello: expected a map, got %T", result)
+	}
+	hints, _ := hello["hints"].([]any)
+	for _, hint := range hints {
+		code, ok := hint.(string)
+		if !ok {
+			return plugin.errorf("hello: hint codes must be strings")
+		}
+		plugin.hints[code] = true
+	}
+	builtins, _ := hello["builtins"].([]any)
+	for _, builtin := range builtins {
+		fields, ok := builtin.(map[string]any)
+		if !ok {
+			return plugin.errorf("hello: builtins must be maps")
+		}
+		var info BuiltinInfo
+		info.Name, ok = fields["name"].(string)
+		if !ok || info.Name == "" {
+			return plugin.errorf("hello: builtin without a name")
+		}
+		info.CellsPerInstance, ok = fields["cells_per_instance"].(uint64)
+		if !ok || info.CellsPerInstance == 0 {
+			return plugin.errorf("hello: builtin %s: cells_per_instance must be positive", info.Name)
+		}
+		info.Ratio, _ = fields["ratio"].(uint64)
+		plugin.builtins[info.Name] = info
+	}
+	return nil
+}
+
+// Returns the hint codes the plugin implements
+func (plugin *Plugin) Hints() []string {
+	codes := make([]string, 0, len(plugin.hints))
+	for code := range plugin.hints {
+		codes = append(codes, code)
+	}
+	return codes
+}
+
+// Closes the stdin of the plugin and waits for it to exit
+func (plugin *Plugin) Close() error {
+	plugin.stdin.Close()
+	return plugin.cmd.Wait()
+}
+
+func (plugin *Plugin) errorf(format string, args ...any) error {
+	return fmt.Errorf("plugin %s: %s", plugin.path, fmt.Sprintf(format, args...))
+}
+
+// Sends a request and waits for its response, answering the callbacks of the plugin
+// in the meantime
+func (plugin *Plugin) call(method string, params map[string]any, handler callbackHandler) (any, error) {
+	plugin.nextId++
+	id := plugin.nextId
+	request := map[string]any{"id": id, "method": method, "params": params}
+	if err := encodeMsgpack(plugin.stdin, request); err != nil {
+		return nil, plugin.errorf("%s: %v", method, err)
+	}
+
+	for {
+		decoded, err := decodeMsgpack(plugin.stdout)
+		if err != nil {
+			return nil, plugin.errorf("%s: %v", method, err)
+		}
+		message, ok := decoded.(map[string]any)
+		if !ok {
+			return nil, plugin.errorf("%s: expected a map, got %T", method, decoded)
+		}
+
+		if callback, ok := message["method"].(string); ok {
+			var result any
+			err := errors.New("callbacks are not allowed during " + method)
+			if handler != nil {
+				callbackParams, _ := message["params"].(map[string]any)
+				result, err = handler(callback, callbackParams)
+			}
+			response := map[string]any{"id": message["id"], "result": result}
+			if err != nil {
+				response = map[string]any{"id": message["id"], "error": err.Error()}
+			}
+			if err := encodeMsgpack(plugin.stdin, response); err != nil {
+				return nil, plugin.errorf("%s: %v", method, err)
+			}
+			continue
+		}
+
+		if message["id"] != id {
+			return nil, plugin.errorf("%s: response id %v does not match request id %d", method, message["id"], id)
+		}
+		if errorMessage, ok := message["error"].(string); ok {
+			return nil, plugin.errorf("%s: %s", method, errorMessage)
+		}
+		return message["result"], nil
+	}
+}
+
+func encodeAddress(address *memory.MemoryAddress) []any {
+	return []any{uint64(address.SegmentIndex), address.Offset}
+}
+
+func decodeAddress(value any) (memory.MemoryAddress, error) {
+	array, ok := value.([]any)
+	if !ok || len(array) != 2 {
+		return memory.UnknownAddress, fmt.Errorf("expected an address, got %v", value)
+	}
+	segment, ok := array[0].(uint64)
+	if !ok {
+		return memory.UnknownAddress, fmt.Errorf("expected an address, got %v", value)
+	}
+	offset, ok := array[1].(uint64)
+	if !ok {
+		return memory.UnknownAddress, fmt.Errorf("expected an address, got %v", value)
+	}
+	return memory.MemoryAddress{SegmentIndex: int(segment), Offset: offset}, nil
+}
+
+func encodeValue(value *memory.MemoryValue) any {
+	if !value.Known() {
+		return nil
+	}
+	if address, err := value.MemoryAddress(); err == nil {
+		return encodeAddress(address)
+	}
+	felt, _ := value.FieldElement()
+	bytes := felt.Bytes()
+	return bytes[:]
+}
+
+func decodeValue(value any) (memory.MemoryValue, error) {
+	switch v := value.(type) {
+	case nil:
+		return memory.UnknownValue, nil
+	case []byte:
+		if len(v) != fp.Bytes {
+			return memory.UnknownValue, fmt.Errorf("field elements must be %d bytes long", fp.Bytes)
+		}
+		var felt fp.Element
+		if err := felt.SetBytesCanonical(v); err != nil {
+			return memory.UnknownValue, err
+		}
+		return memory.MemoryValueFromFieldElement(&felt), nil
+	default:
+		address, err := decodeAddress(value)
+		if err != nil {
+			return memory.UnknownValue, err
+		}
+		return memory.MemoryValueFromMemoryAddress(&address), nil
+	}
+}
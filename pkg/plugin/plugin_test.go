@@ -0,0 +1,176 @@
+package plugin
+
+import (
+	"bufio"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// When the variable is set the test binary acts as a plugin providing a "double"
+// hint, writing 2 * ids.x at ap, and a "square" builtin whose instances are an
+// input cell followed by its square
+const testPluginEnv = "CAIRO_VM_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(testPluginEnv) == "1" {
+		if err := runTestPlugin(); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+func runTestPlugin() error {
+	in := bufio.NewReader(os.Stdin)
+	nextId := uint64(0)
+	// sends a callback to the vm and returns its result
+	callback := func(method string, params map[string]any) (any, error) {
+		nextId++
+		if err := encodeMsgpack(os.Stdout, map[string]any{"id": nextId, "method": method, "params": params}); err != nil {
+			return nil, err
+		}
+		response, err := decodeMsgpack(in)
+		if err != nil {
+			return nil, err
+		}
+		fields := response.(map[string]any)
+		if message, ok := fields["error"].(string); ok {
+			return nil, errors.New(message)
+		}
+		return fields["result"], nil
+	}
+	readFelt := func(params map[string]any) (fp.Element, error) {
+		value, err := callback("read", params)
+		if err != nil {
+			return fp.Element{}, err
+		}
+		mv, err := decodeValue(value)
+		if err != nil {
+			return fp.Element{}, err
+		}
+		felt, err := mv.FieldElement()
+		if err != nil {
+			return fp.Element{}, err
+		}
+		return *felt, nil
+	}
+
+	for {
+		decoded, err := decodeMsgpack(in)
+		if err != nil {
+			// the vm closed stdin
+			return nil
+		}
+		request := decoded.(map[string]any)
+		params, _ := request["params"].(map[string]any)
+
+		var result any
+		switch request["method"] {
+		case "hello":
+			result = map[string]any{
+				"hints":    []string{"double"},
+				"builtins": []any{map[string]any{"name": "square", "cells_per_instance": uint64(2)}},
+			}
+		case "execute_hint":
+			ids := params["ids"].(map[string]any)
+			x, err := readFelt(map[string]any{"address": ids["x"]})
+			if err != nil {
+				return err
+			}
+			x.Double(&x)
+			value := memory.MemoryValueFromFieldElement(&x)
+			ap := memory.MemoryAddress{SegmentIndex: VM.ExecutionSegment, Offset: params["ap"].(uint64)}
+			_, err = callback("write", map[string]any{"address": encodeAddress(&ap), "value": encodeValue(&value)})
+			if err != nil {
+				return err
+			}
+		case "check_write":
+			if _, ok := params["value"].([]byte); !ok {
+				err := encodeMsgpack(os.Stdout, map[string]any{"id": request["id"], "error": "square only accepts felts"})
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		case "infer_value":
+			input, err := readFelt(map[string]any{"offset": params["offset"].(uint64) - 1})
+			if err != nil {
+				return err
+			}
+			input.Square(&input)
+			value := memory.MemoryValueFromFieldElement(&input)
+			result = encodeValue(&value)
+		}
+		if err := encodeMsgpack(os.Stdout, map[string]any{"id": request["id"], "result": result}); err != nil {
+			return err
+		}
+	}
+}
+
+func startTestPlugin(t *testing.T) *Plugin {
+	t.Setenv(testPluginEnv, "1")
+	plugin, err := Start(os.Args[0])
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		require.NoError(t, plugin.Close())
+	})
+	return plugin
+}
+
+func TestPluginHint(t *testing.T) {
+	plugin := startTestPlugin(t)
+	require.Equal(t, []string{"double"}, plugin.Hints())
+
+	_, ok := plugin.Hinter("unknown", nil)
+	require.False(t, ok)
+
+	vm := VM.DefaultVirtualMachine()
+	vm.Context.Ap = 3
+	x := memory.MemoryValueFromInt(21)
+	require.NoError(t, vm.Memory.Write(VM.ExecutionSegment, 0, &x))
+
+	hint, ok := plugin.Hinter("double", map[string]hinter.Reference{
+		"x": hinter.ApCellRef(-3),
+		// not a memory cell, it is not sent to the plugin
+		"c": hinter.Immediate(fp.NewElement(1)),
+	})
+	require.True(t, ok)
+	require.NoError(t, hint.Execute(vm, nil))
+
+	value, err := vm.Memory.Read(VM.ExecutionSegment, 3)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromInt(42), value)
+}
+
+func TestPluginBuiltin(t *testing.T) {
+	plugin := startTestPlugin(t)
+
+	_, err := plugin.Builtin("cube")
+	require.ErrorContains(t, err, "builtin cube is not provided")
+
+	runner, err := plugin.Builtin("square")
+	require.NoError(t, err)
+	require.Equal(t, "square", runner.String())
+	require.Equal(t, uint64(2), runner.GetCellsPerInstance())
+
+	mem := memory.InitializeEmptyMemory()
+	addr := mem.AllocateBuiltinSegment(runner)
+
+	input := memory.MemoryValueFromInt(5)
+	require.NoError(t, mem.Write(addr.SegmentIndex, 2, &input))
+	value, err := mem.Read(addr.SegmentIndex, 3)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromInt(25), value)
+
+	// the plugin rejects addresses
+	address := memory.MemoryValueFromSegmentAndOffset(0, 0)
+	require.ErrorContains(t, mem.Write(addr.SegmentIndex, 4, &address), "square only accepts felts")
+}
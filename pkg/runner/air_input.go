@@ -1,6 +1,15 @@
 package runner
 
 import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -24,6 +33,9 @@ func (runner *Runner) GetAirPublicInput(relocatedMemory []*fp.Element, publicMem
 	for name, segment := range memorySegmentsAddresses {
 		memorySegments[name] = segment
 	}
+	if err := checkAirMemorySegments(memorySegments); err != nil {
+		return AirPublicInput{}, err
+	}
 	publicMemory := make([]AirPublicMemoryEntry, len(publicMemoryAddresses))
 
 	for i, address := range publicMemoryAddresses {
@@ -34,15 +46,72 @@ func (runner *Runner) GetAirPublicInput(relocatedMemory []*fp.Element, publicMem
 		}
 	}
 
-	return AirPublicInput{
+	airPublicInput := AirPublicInput{
 		Layout:         runner.layout.Name,
 		RcMin:          rcMin,
 		RcMax:          rcMax,
 		NSteps:         len(runner.vm.Trace),
-		DynamicParams:  nil,
+		DynamicParams:  runner.layout.DynamicParams,
 		MemorySegments: memorySegments,
 		PublicMemory:   publicMemory,
-	}, nil
+	}
+	if output, ok := runner.outputRunner(); ok {
+		outputBase := memorySegments[builtins.OutputName].BeginAddr
+		for _, page := range output.Pages() {
+			airPublicInput.OutputPages = append(airPublicInput.OutputPages, AirOutputPage{
+				ID:        page.ID,
+				BeginAddr: outputBase + page.Start,
+				Size:      page.Size,
+			})
+		}
+		if attributes := output.Attributes(); len(attributes) > 0 {
+			airPublicInput.OutputAttributes = attributes
+		}
+	}
+	return airPublicInput, nil
+}
+
+// Checks that the memory segments of the public input don't overlap once relocated,
+// the stop pointer of each one being between its begin address and the one of the
+// next segment
+func checkAirMemorySegments(memorySegments map[string]AirMemorySegmentEntry) error {
+	names := make([]string, 0, len(memorySegments))
+	for name := range memorySegments {
+		names = append(names, name)
+	}
+	// the empty segments come before the segment starting at the same address
+	slices.SortFunc(names, func(a, b string) int {
+		if c := cmp.Compare(memorySegments[a].BeginAddr, memorySegments[b].BeginAddr); c != 0 {
+			return c
+		}
+		if c := cmp.Compare(memorySegments[a].StopPtr, memorySegments[b].StopPtr); c != 0 {
+			return c
+		}
+		return cmp.Compare(a, b)
+	})
+	for i, name := range names {
+		segment := memorySegments[name]
+		if segment.StopPtr < segment.BeginAddr {
+			return fmt.Errorf("memory segment %s: stop pointer %d is below its begin address %d", name, segment.StopPtr, segment.BeginAddr)
+		}
+		if i+1 < len(names) {
+			next := names[i+1]
+			if segment.StopPtr > memorySegments[next].BeginAddr {
+				return fmt.Errorf("memory segment %s: stop pointer %d is past the begin address %d of memory segment %s", name, segment.StopPtr, memorySegments[next].BeginAddr, next)
+			}
+		}
+	}
+	return nil
+}
+
+// Returns the runner of the output builtin when the layout has one
+func (runner *Runner) outputRunner() (*builtins.Output, bool) {
+	for _, bRunner := range runner.layout.Builtins {
+		if output, ok := bRunner.Runner.(*builtins.Output); ok {
+			return output, true
+		}
+	}
+	return nil, false
 }
 
 type AirPublicInput struct {
@@ -53,6 +122,18 @@ type AirPublicInput struct {
 	DynamicParams  interface{}                      `json:"dynamic_params"`
 	MemorySegments map[string]AirMemorySegmentEntry `json:"memory_segments"`
 	PublicMemory   []AirPublicMemoryEntry           `json:"public_memory"`
+	// pages and attributes the program added to its output, left out of the json
+	// when the output has none, as the public input of the unpaged outputs
+	OutputPages      []AirOutputPage     `json:"output_pages,omitempty"`
+	OutputAttributes map[string][]uint64 `json:"output_attributes,omitempty"`
+}
+
+// AirOutputPage is a page of the output, whose cells are in the public memory with
+// the id of the page
+type AirOutputPage struct {
+	ID        uint64 `json:"id"`
+	BeginAddr uint64 `json:"begin_addr"`
+	Size      uint64 `json:"size"`
 }
 
 type AirMemorySegmentEntry struct {
@@ -66,6 +147,9 @@ type AirPublicMemoryEntry struct {
 	Page    uint16 `json:"page"`
 }
 
+// GetAirPrivateInput collects the private inputs of the builtins of the layout, in
+// the order of the layout. Builtins without instances, such as the output builtin,
+// and those without a segment in the run are left out
 func (runner *Runner) GetAirPrivateInput(tracePath, memoryPath string) (AirPrivateInput, error) {
 	airPrivateInput := AirPrivateInput{
 		TracePath:  tracePath,
@@ -73,58 +157,211 @@ func (runner *Runner) GetAirPrivateInput(tracePath, memoryPath string) (AirPriva
 	}
 
 	for _, bRunner := range runner.layout.Builtins {
-		builtinName := bRunner.Runner.String()
-		builtinSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtinName)
-		if ok {
-			// some checks might be missing here
-			switch builtinName {
-			case builtins.RangeCheckName:
-				{
-					airPrivateInput.RangeCheck = bRunner.Runner.(*builtins.RangeCheck).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.BitwiseName:
-				{
-					airPrivateInput.Bitwise = bRunner.Runner.(*builtins.Bitwise).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.PoseidonName:
-				{
-					airPrivateInput.Poseidon = bRunner.Runner.(*builtins.Poseidon).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.PedersenName:
-				{
-					airPrivateInput.Pedersen = bRunner.Runner.(*builtins.Pedersen).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.EcOpName:
-				{
-					airPrivateInput.EcOp = bRunner.Runner.(*builtins.EcOp).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.KeccakName:
-				{
-					airPrivateInput.Keccak = bRunner.Runner.(*builtins.Keccak).GetAirPrivateInput(builtinSegment)
-				}
-			case builtins.ECDSAName:
-				{
-					ecdsaAirPrivateInput, err := bRunner.Runner.(*builtins.ECDSA).GetAirPrivateInput(builtinSegment)
-					if err != nil {
-						return AirPrivateInput{}, err
-					}
-					airPrivateInput.Ecdsa = ecdsaAirPrivateInput
-				}
-			}
+		builtin, ok := bRunner.Runner.(builtins.AirPrivateInputBuiltin)
+		if !ok {
+			continue
+		}
+		builtinSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtin.String())
+		if !ok {
+			continue
 		}
+		input, err := builtin.AirPrivateInput(runner.vm.Memory, builtinSegment)
+		if err != nil {
+			return AirPrivateInput{}, err
+		}
+		airPrivateInput.Builtins = append(airPrivateInput.Builtins, AirPrivateBuiltinInput{
+			Name:  builtin.AirPrivateInputName(),
+			Input: input,
+		})
 	}
 
 	return airPrivateInput, nil
 }
 
+// WriteAirPrivateInput writes the AIR private input of the run to a json file, the
+// trace and memory paths being the files the prover reads them from. It is streamed
+// to the file with EncodeAirPrivateInput
+func (runner *Runner) WriteAirPrivateInput(location, tracePath, memoryPath string) error {
+	file, err := os.Create(location)
+	if err != nil {
+		return fmt.Errorf("cannot write air_private_input: %w", err)
+	}
+	writer := bufio.NewWriter(file)
+	err = runner.EncodeAirPrivateInput(writer, tracePath, memoryPath)
+	if err == nil {
+		err = writer.Flush()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		// a partial private input would only fail in the prover
+		os.Remove(location)
+		return fmt.Errorf("cannot write air_private_input: %w", err)
+	}
+	return nil
+}
+
+// EncodeAirPrivateInput writes the json of GetAirPrivateInput, indented as
+// json.MarshalIndent does, without building it first. The instances of the
+// builtins implementing builtins.AirPrivateInputStreamer are encoded one at a time,
+// and the other builtins one builtin at a time, so that runs with millions of
+// instances don't hold both their private input and its json in memory
+func (runner *Runner) EncodeAirPrivateInput(w io.Writer, tracePath, memoryPath string) error {
+	encoder := airPrivateInputEncoder{w: w}
+	encoder.writeString("{")
+	encoder.writeValue("trace_path", tracePath)
+	encoder.writeValue("memory_path", memoryPath)
+
+	for _, bRunner := range runner.layout.Builtins {
+		builtin, ok := bRunner.Runner.(builtins.AirPrivateInputBuiltin)
+		if !ok {
+			continue
+		}
+		builtinSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtin.String())
+		if !ok {
+			continue
+		}
+		name := builtin.AirPrivateInputName()
+		streamer, ok := builtin.(builtins.AirPrivateInputStreamer)
+		if !ok {
+			input, err := builtin.AirPrivateInput(runner.vm.Memory, builtinSegment)
+			if err != nil {
+				return err
+			}
+			encoder.writeValue(name, input)
+			continue
+		}
+
+		encoder.writeKey(name)
+		encoder.writeString("[")
+		instances := 0
+		err := streamer.StreamAirPrivateInput(runner.vm.Memory, builtinSegment, func(instance any) error {
+			if instances > 0 {
+				encoder.writeString(",")
+			}
+			encoder.writeString("\n    ")
+			encoder.writeIndented(name, instance, "    ")
+			instances++
+			return encoder.err
+		})
+		if err != nil {
+			return err
+		}
+		if instances > 0 {
+			encoder.writeString("\n  ")
+		}
+		encoder.writeString("]")
+	}
+
+	encoder.writeString("\n}")
+	return encoder.err
+}
+
+// Writes the members of the AIR private input, keeping the first error
+type airPrivateInputEncoder struct {
+	w       io.Writer
+	members int
+	// reused to indent the json of the values
+	buffer bytes.Buffer
+	err    error
+}
+
+func (encoder *airPrivateInputEncoder) writeString(s string) {
+	if encoder.err == nil {
+		_, encoder.err = io.WriteString(encoder.w, s)
+	}
+}
+
+func (encoder *airPrivateInputEncoder) writeKey(key string) {
+	if encoder.members > 0 {
+		encoder.writeString(",")
+	}
+	encoder.members++
+	encoder.writeString("\n  ")
+	encoder.writeIndented(key, key, "  ")
+	encoder.writeString(": ")
+}
+
+func (encoder *airPrivateInputEncoder) writeValue(key string, value any) {
+	encoder.writeKey(key)
+	encoder.writeIndented(key, value, "  ")
+}
+
+// Writes the json of the value, indented by two spaces per level after the prefix
+func (encoder *airPrivateInputEncoder) writeIndented(key string, value any, prefix string) {
+	if encoder.err != nil {
+		return
+	}
+	valueJson, err := json.Marshal(value)
+	if err != nil {
+		encoder.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	encoder.buffer.Reset()
+	if err := json.Indent(&encoder.buffer, valueJson, prefix, "  "); err != nil {
+		encoder.err = fmt.Errorf("%s: %w", key, err)
+		return
+	}
+	_, encoder.err = encoder.buffer.WriteTo(encoder.w)
+}
+
 type AirPrivateInput struct {
-	TracePath  string                                 `json:"trace_path"`
-	MemoryPath string                                 `json:"memory_path"`
-	Pedersen   []builtins.AirPrivateBuiltinPedersen   `json:"pedersen"`
-	RangeCheck []builtins.AirPrivateBuiltinRangeCheck `json:"range_check"`
-	Ecdsa      []builtins.AirPrivateBuiltinECDSA      `json:"ecdsa"`
-	Bitwise    []builtins.AirPrivateBuiltinBitwise    `json:"bitwise"`
-	EcOp       []builtins.AirPrivateBuiltinEcOp       `json:"ec_op"`
-	Keccak     []builtins.AirPrivateBuiltinKeccak     `json:"keccak"`
-	Poseidon   []builtins.AirPrivateBuiltinPoseidon   `json:"poseidon"`
+	TracePath  string
+	MemoryPath string
+	// keyed by their name in the json, in the order of the layout
+	Builtins []AirPrivateBuiltinInput
+}
+
+type AirPrivateBuiltinInput struct {
+	Name  string
+	Input any
+}
+
+// Input returns the private input of the builtin, nil when the layout doesn't have
+// it or the run had no segment for it
+func (input *AirPrivateInput) Input(name string) any {
+	for _, builtin := range input.Builtins {
+		if builtin.Name == name {
+			return builtin.Input
+		}
+	}
+	return nil
+}
+
+// MarshalJSON writes the paths first and then the builtins in the order of the
+// layout, as the Python VM does
+func (input AirPrivateInput) MarshalJSON() ([]byte, error) {
+	var buffer bytes.Buffer
+	buffer.WriteByte('{')
+	write := func(key string, value any) error {
+		if buffer.Len() > 1 {
+			buffer.WriteByte(',')
+		}
+		keyJson, err := json.Marshal(key)
+		if err != nil {
+			return err
+		}
+		valueJson, err := json.Marshal(value)
+		if err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+		buffer.Write(keyJson)
+		buffer.WriteByte(':')
+		buffer.Write(valueJson)
+		return nil
+	}
+	if err := write("trace_path", input.TracePath); err != nil {
+		return nil, err
+	}
+	if err := write("memory_path", input.MemoryPath); err != nil {
+		return nil, err
+	}
+	for _, builtin := range input.Builtins {
+		if err := write(builtin.Name, builtin.Input); err != nil {
+			return nil, err
+		}
+	}
+	buffer.WriteByte('}')
+	return buffer.Bytes(), nil
 }
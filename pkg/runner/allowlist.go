@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// ProgramAllowlist is the set of the hashes of the programs a proving service accepts
+// to run, as computed by Program.Hash
+type ProgramAllowlist map[fp.Element]struct{}
+
+// ReadProgramAllowlist reads an allowlist file, with a program hash per line in hex
+// or decimal. Empty lines and the text following a # are ignored
+func ReadProgramAllowlist(path string) (ProgramAllowlist, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read program allowlist: %w", err)
+	}
+	defer file.Close()
+
+	allowlist := ProgramAllowlist{}
+	scanner := bufio.NewScanner(file)
+	for line := 1; scanner.Scan(); line++ {
+		text, _, _ := strings.Cut(scanner.Text(), "#")
+		text = strings.TrimSpace(text)
+		if text == "" {
+			continue
+		}
+		var hash fp.Element
+		if _, err := hash.SetString(text); err != nil {
+			return nil, fmt.Errorf("program allowlist %s, line %d: invalid program hash %q", path, line, text)
+		}
+		allowlist[hash] = struct{}{}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("cannot read program allowlist: %w", err)
+	}
+	return allowlist, nil
+}
+
+// CheckHash returns an error unless the program hash is in the allowlist
+func (allowlist ProgramAllowlist) CheckHash(hash *fp.Element) error {
+	if _, ok := allowlist[*hash]; !ok {
+		return fmt.Errorf("program 0x%s is not in the allowlist", hash.Text(16))
+	}
+	return nil
+}
+
+// CheckProgram returns an error unless the program is in the allowlist, e.g. before
+// running it
+func (allowlist ProgramAllowlist) CheckProgram(program *Program) error {
+	hash := program.Hash()
+	return allowlist.CheckHash(&hash)
+}
+
+// CheckArtifact returns an error unless the artifact was produced by a program of
+// the allowlist
+func (allowlist ProgramAllowlist) CheckArtifact(metadata *ArtifactMetadata) error {
+	var hash fp.Element
+	if _, err := hash.SetString(metadata.ProgramHash); err != nil {
+		return fmt.Errorf("%s has an invalid program hash %q", metadata.Artifact, metadata.ProgramHash)
+	}
+	if err := allowlist.CheckHash(&hash); err != nil {
+		return fmt.Errorf("%s: %w", metadata.Artifact, err)
+	}
+	return nil
+}
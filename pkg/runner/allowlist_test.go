@@ -0,0 +1,35 @@
+package runner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgramAllowlist(t *testing.T) {
+	allowed := createRunner("ret;", "small")
+	other := createRunner("[ap] = 1, ap++;\nret;", "small")
+	hash := allowed.program.Hash()
+
+	path := filepath.Join(t.TempDir(), "allowlist")
+	content := "# programs of the service\n\n0x" + hash.Text(16) + " # ret\n  42\n"
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	allowlist, err := ReadProgramAllowlist(path)
+	require.NoError(t, err)
+	require.Len(t, allowlist, 2)
+
+	require.NoError(t, allowlist.CheckProgram(allowed.program))
+	otherHash := other.program.Hash()
+	require.EqualError(t, allowlist.CheckProgram(other.program), "program 0x"+otherHash.Text(16)+" is not in the allowlist")
+
+	trace := allowed.ArtifactMetadata(TraceArtifact)
+	require.NoError(t, allowlist.CheckArtifact(&trace))
+	trace = other.ArtifactMetadata(TraceArtifact)
+	require.EqualError(t, allowlist.CheckArtifact(&trace), "trace: program 0x"+otherHash.Text(16)+" is not in the allowlist")
+
+	require.NoError(t, os.WriteFile(path, []byte("0x1\nhash\n"), 0644))
+	_, err = ReadProgramAllowlist(path)
+	require.EqualError(t, err, "program allowlist "+path+", line 2: invalid program hash \"hash\"")
+}
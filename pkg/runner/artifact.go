@@ -0,0 +1,147 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime/debug"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// Version of the VM stamped into the metadata of the artifacts. It is read from the
+// build info of the binary unless set at build time with
+// -ldflags "-X github.com/NethermindEth/cairo-vm-go/pkg/runner.Version=v1.0.0"
+var Version = ""
+
+const modulePath = "github.com/NethermindEth/cairo-vm-go"
+
+// VMVersion returns Version, or else the version of the module the binary was built
+// from, which is suffixed by its commit when it has no version
+func VMVersion() string {
+	if Version != "" {
+		return Version
+	}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "unknown"
+	}
+	version := info.Main.Version
+	if info.Main.Path != modulePath {
+		version = ""
+		for _, dep := range info.Deps {
+			if dep.Path == modulePath {
+				version = dep.Version
+			}
+		}
+	}
+	if version != "" && version != "(devel)" {
+		return version
+	}
+	version = "(devel)"
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			version += "+" + setting.Value
+		}
+	}
+	return version
+}
+
+// Artifacts written by a run
+const (
+	TraceArtifact           = "trace"
+	MemoryArtifact          = "memory"
+	AirPublicInputArtifact  = "air_public_input"
+	AirPrivateInputArtifact = "air_private_input"
+)
+
+// ArtifactMetadata identifies the run an artifact was produced by. It is written
+// next to the artifact rather than in a header, so that the artifacts keep the
+// format the provers expect
+type ArtifactMetadata struct {
+	Artifact    string `json:"artifact"`
+	VMVersion   string `json:"vm_version"`
+	ProgramHash string `json:"program_hash"`
+	Layout      string `json:"layout"`
+}
+
+// ArtifactMetadata returns the metadata of an artifact of the run
+func (runner *Runner) ArtifactMetadata(artifact string) ArtifactMetadata {
+	if runner.programHash == nil {
+		hash := runner.program.Hash()
+		runner.programHash = &hash
+	}
+	return artifactMetadata(artifact, runner.programHash, runner.layout.Name)
+}
+
+func artifactMetadata(artifact string, programHash *fp.Element, layout string) ArtifactMetadata {
+	return ArtifactMetadata{
+		Artifact:    artifact,
+		VMVersion:   VMVersion(),
+		ProgramHash: "0x" + programHash.Text(16),
+		Layout:      layout,
+	}
+}
+
+// Path of the metadata of an artifact
+func ArtifactMetadataPath(artifactPath string) string {
+	return artifactPath + ".meta.json"
+}
+
+func WriteArtifactMetadata(artifactPath string, metadata ArtifactMetadata) error {
+	content, err := json.MarshalIndent(metadata, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(ArtifactMetadataPath(artifactPath), content, 0644); err != nil {
+		return fmt.Errorf("cannot write metadata of %s: %w", artifactPath, err)
+	}
+	return nil
+}
+
+// ReadArtifactMetadata reads the metadata of an artifact, the boolean being false
+// when the artifact has none, e.g. as it was written by another VM
+func ReadArtifactMetadata(artifactPath string) (ArtifactMetadata, bool, error) {
+	content, err := os.ReadFile(ArtifactMetadataPath(artifactPath))
+	if errors.Is(err, fs.ErrNotExist) {
+		return ArtifactMetadata{}, false, nil
+	}
+	if err != nil {
+		return ArtifactMetadata{}, false, fmt.Errorf("cannot read metadata of %s: %w", artifactPath, err)
+	}
+	var metadata ArtifactMetadata
+	if err := json.Unmarshal(content, &metadata); err != nil {
+		return ArtifactMetadata{}, false, fmt.Errorf("invalid metadata of %s: %w", artifactPath, err)
+	}
+	return metadata, true, nil
+}
+
+// CheckCompatible returns an error unless both artifacts were produced by the same
+// version of the VM, for the same program and layout
+func (metadata *ArtifactMetadata) CheckCompatible(other *ArtifactMetadata) error {
+	if metadata.VMVersion != other.VMVersion {
+		return fmt.Errorf("%s was produced by VM version %s but %s by version %s", metadata.Artifact, metadata.VMVersion, other.Artifact, other.VMVersion)
+	}
+	if metadata.ProgramHash != other.ProgramHash {
+		return fmt.Errorf("%s was produced by program %s but %s by program %s", metadata.Artifact, metadata.ProgramHash, other.Artifact, other.ProgramHash)
+	}
+	if metadata.Layout != other.Layout {
+		return fmt.Errorf("%s was produced with layout %s but %s with layout %s", metadata.Artifact, metadata.Layout, other.Artifact, other.Layout)
+	}
+	return nil
+}
+
+// CheckProgram returns an error unless the artifact was produced by this version of
+// the VM for the program, e.g. before loading it along the program
+func (metadata *ArtifactMetadata) CheckProgram(program *Program) error {
+	if version := VMVersion(); metadata.VMVersion != version {
+		return fmt.Errorf("%s was produced by VM version %s, this VM is version %s", metadata.Artifact, metadata.VMVersion, version)
+	}
+	hash := program.Hash()
+	if programHash := "0x" + hash.Text(16); metadata.ProgramHash != programHash {
+		return fmt.Errorf("%s was produced by program %s, not by program %s", metadata.Artifact, metadata.ProgramHash, programHash)
+	}
+	return nil
+}
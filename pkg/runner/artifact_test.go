@@ -0,0 +1,48 @@
+package runner
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestArtifactMetadata(t *testing.T) {
+	runner := createRunner("ret;", "small")
+	hash := runner.program.Hash()
+
+	trace := runner.ArtifactMetadata(TraceArtifact)
+	require.Equal(t, ArtifactMetadata{
+		Artifact:    TraceArtifact,
+		VMVersion:   VMVersion(),
+		ProgramHash: "0x" + hash.Text(16),
+		Layout:      "small",
+	}, trace)
+
+	tracePath := filepath.Join(t.TempDir(), "trace")
+	_, ok, err := ReadArtifactMetadata(tracePath)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	require.NoError(t, WriteArtifactMetadata(tracePath, trace))
+	read, ok, err := ReadArtifactMetadata(tracePath)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, trace, read)
+	require.NoError(t, read.CheckProgram(runner.program))
+
+	memory := runner.ArtifactMetadata(MemoryArtifact)
+	require.NoError(t, trace.CheckCompatible(&memory))
+
+	other := createRunner("[ap] = 1, ap++;\nret;", "small")
+	require.ErrorContains(t, trace.CheckProgram(other.program), "trace was produced by program "+trace.ProgramHash+", not by program")
+	otherMemory := other.ArtifactMetadata(MemoryArtifact)
+	require.ErrorContains(t, trace.CheckCompatible(&otherMemory), "trace was produced by program "+trace.ProgramHash+" but memory by program")
+
+	memory.Layout = "plain"
+	require.EqualError(t, trace.CheckCompatible(&memory), "trace was produced with layout small but memory with layout plain")
+
+	memory.VMVersion = "v0.0.1"
+	require.EqualError(t, trace.CheckCompatible(&memory), "trace was produced by VM version "+trace.VMVersion+" but memory by version v0.0.1")
+	require.ErrorContains(t, memory.CheckProgram(runner.program), "memory was produced by VM version v0.0.1, this VM is version")
+}
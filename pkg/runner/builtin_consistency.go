@@ -0,0 +1,97 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// BuiltinConsistency compares the stop pointer of a builtin segment with the cells
+// written to it. The prover reads the instances up to the stop pointer, so a stop
+// pointer that doesn't cover the written cells, that covers instances never written
+// or that ends in the middle of an instance, produces a trace it rejects
+type BuiltinConsistency struct {
+	Builtin      string `json:"builtin"`
+	SegmentIndex int    `json:"segment_index"`
+	StopPointer  uint64 `json:"stop_pointer"`
+	// offset past the last written cell, zero for an empty segment
+	WrittenCells     uint64 `json:"written_cells"`
+	CellsPerInstance uint64 `json:"cells_per_instance"`
+	// what is wrong with the segment, empty when it is consistent
+	Problems []string `json:"problems,omitempty"`
+}
+
+func (consistency *BuiltinConsistency) Consistent() bool {
+	return len(consistency.Problems) == 0
+}
+
+// BuiltinConsistencyReport has the consistency of every builtin segment of the
+// layout, in the order of the segments
+type BuiltinConsistencyReport []BuiltinConsistency
+
+// Err joins the problems of the inconsistent segments, nil when there are none
+func (report BuiltinConsistencyReport) Err() error {
+	var errs []error
+	for i := range report {
+		consistency := &report[i]
+		if consistency.Consistent() {
+			continue
+		}
+		errs = append(errs, fmt.Errorf(
+			"builtin %s (segment %d, stop pointer %d, %d cells written, %d cells per instance): %s",
+			consistency.Builtin, consistency.SegmentIndex, consistency.StopPointer, consistency.WrittenCells,
+			consistency.CellsPerInstance, strings.Join(consistency.Problems, ", "),
+		))
+	}
+	return errors.Join(errs...)
+}
+
+// CheckBuiltinConsistency cross-checks the stop pointer of each builtin segment of
+// the layout, read by FinalizeBuiltins, with the cells written to the segment: no
+// cell may be written past the stop pointer, the instances before it must be
+// written and it must end on an instance boundary. It must be called in proof mode
+// once the builtins are finalized, before FinalizeSegments pads them
+func (runner *Runner) CheckBuiltinConsistency() (BuiltinConsistencyReport, error) {
+	if !runner.isProofMode() {
+		return nil, errors.New("the builtin consistency can only be checked in proof mode")
+	}
+	if runner.vm == nil {
+		return nil, errors.New("cannot check the builtin consistency before the run")
+	}
+
+	layoutBuiltins := make(map[string]bool, len(runner.layout.Builtins))
+	for _, bRunner := range runner.layout.Builtins {
+		layoutBuiltins[bRunner.Runner.String()] = true
+	}
+	var report BuiltinConsistencyReport
+	for segmentIndex, segment := range runner.vm.Memory.Segments {
+		name := segment.BuiltinRunner.String()
+		if !layoutBuiltins[name] {
+			continue
+		}
+		written := uint64(len(segment.Data))
+		for written > 0 && !segment.Data[written-1].Known() {
+			written--
+		}
+		consistency := BuiltinConsistency{
+			Builtin:      name,
+			SegmentIndex: segmentIndex,
+			StopPointer:  segment.BuiltinRunner.GetStopPointer(),
+			WrittenCells: written,
+			// the cells of the output builtin are independent
+			CellsPerInstance: max(segment.BuiltinRunner.GetCellsPerInstance(), 1),
+		}
+		if consistency.StopPointer%consistency.CellsPerInstance != 0 {
+			consistency.Problems = append(consistency.Problems, fmt.Sprintf("the stop pointer is not a multiple of the %d cells of an instance", consistency.CellsPerInstance))
+		}
+		if written > consistency.StopPointer {
+			consistency.Problems = append(consistency.Problems, fmt.Sprintf("%d cells are written past the stop pointer", written-consistency.StopPointer))
+		}
+		// the deduced cells of the last instance may be left unread, not whole instances
+		if used := (written + consistency.CellsPerInstance - 1) / consistency.CellsPerInstance * consistency.CellsPerInstance; used < consistency.StopPointer {
+			consistency.Problems = append(consistency.Problems, fmt.Sprintf("the %d cells before the stop pointer past offset %d are not written", consistency.StopPointer-used, used))
+		}
+		report = append(report, consistency)
+	}
+	return report, nil
+}
@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckBuiltinConsistency(t *testing.T) {
+	program := fuzzProgram([]byte{4, 4, 4, 4})
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	_, err = runner.CheckBuiltinConsistency()
+	require.EqualError(t, err, "cannot check the builtin consistency before the run")
+	require.NoError(t, runner.Run())
+	require.NoError(t, runner.EndRun())
+	require.NoError(t, runner.FinalizeBuiltins())
+
+	report, err := runner.CheckBuiltinConsistency()
+	require.NoError(t, err)
+	require.NoError(t, report.Err())
+	require.Len(t, report, len(runner.layout.Builtins))
+	require.Equal(t, BuiltinConsistency{
+		Builtin:          builtins.OutputName,
+		SegmentIndex:     2,
+		StopPointer:      4,
+		WrittenCells:     4,
+		CellsPerInstance: 1,
+	}, report[0])
+
+	output, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtins.OutputName)
+	require.True(t, ok)
+	output.BuiltinRunner.SetStopPointer(3)
+	report, err = runner.CheckBuiltinConsistency()
+	require.NoError(t, err)
+	require.EqualError(t, report.Err(), "builtin output (segment 2, stop pointer 3, 4 cells written, 1 cells per instance): 1 cells are written past the stop pointer")
+
+	// a pedersen instance has 3 cells
+	pedersen, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtins.PedersenName)
+	require.True(t, ok)
+	output.BuiltinRunner.SetStopPointer(4)
+	pedersen.BuiltinRunner.SetStopPointer(4)
+	report, err = runner.CheckBuiltinConsistency()
+	require.NoError(t, err)
+	require.True(t, report[0].Consistent())
+	require.Equal(t, []string{
+		"the stop pointer is not a multiple of the 3 cells of an instance",
+		"the 4 cells before the stop pointer past offset 0 are not written",
+	}, report[1].Problems)
+
+	runner, err = NewRunner(program, map[uint64][]hinter.Hinter{}, ExecutionModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	_, err = runner.CheckBuiltinConsistency()
+	require.EqualError(t, err, "the builtin consistency can only be checked in proof mode")
+}
+
+// A Cairo Zero proof-mode program whose main writes an output, hashes two values
+// and checks a range, then returns the updated builtin pointers
+func proofModeBuiltinsProgram() *Program {
+	program := createProgramWithBuiltins(`
+        ap += 3;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 7, ap++;
+        [ap - 1] = [[fp - 5]];
+        [ap - 1] = [[fp - 4]];
+        [ap - 1] = [[fp - 4] + 1];
+        [ap] = [[fp - 4] + 2], ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap] = [fp - 5] + 1, ap++;
+        [ap] = [fp - 4] + 3, ap++;
+        [ap] = [fp - 3] + 1, ap++;
+        ret;
+    `, builtins.OutputType, builtins.PedersenType, builtins.RangeCheckType)
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+	return program
+}
+
+func TestFinalizeBuiltinsCairoZeroProofMode(t *testing.T) {
+	program := proofModeBuiltinsProgram()
+	for _, layout := range []string{"small", "recursive", "starknet", "all_cairo"} {
+		t.Run(layout, func(t *testing.T) {
+			runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, layout, nil, 0)
+			require.NoError(t, err)
+			require.NoError(t, runner.Run())
+			require.NoError(t, runner.EndRun())
+			require.NoError(t, runner.FinalizeBuiltins())
+			require.NoError(t, runner.FinalizeSegments())
+
+			report, err := runner.CheckBuiltinConsistency()
+			require.NoError(t, err)
+			require.NoError(t, report.Err())
+			stopPointers := map[string]uint64{}
+			for _, builtin := range report {
+				stopPointers[builtin.Builtin] = builtin.StopPointer
+			}
+			require.Equal(t, uint64(1), stopPointers[builtins.OutputName])
+			require.Equal(t, uint64(3), stopPointers[builtins.PedersenName])
+			require.Equal(t, uint64(1), stopPointers[builtins.RangeCheckName])
+
+			relocatedMemory, segmentsOffsets := runner.BuildMemory()
+			_, err = runner.GetAirPublicInput(relocatedMemory, runner.GetPublicMemoryAddresses(segmentsOffsets))
+			require.NoError(t, err)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+// Offsets of the functions of the programs generated by the fuzzer
+const (
+	fuzzStartPc = 0
+	fuzzEndPc   = 4
+	fuzzMainPc  = 6
+	// fp of main in either mode, the cells below hold the stack and the call frame
+	fuzzSimulationMainFp = 3
+	fuzzProofMainFp      = 5
+)
+
+// Builds a program whose main function computes random values on the stack and
+// writes some of them to the output builtin. They are laid out like a program
+// compiled with `--proof_mode` so the same bytecode runs in both modes:
+//
+//	__start__: ap += 1; call main;
+//	__end__:   jmp rel 0;
+//	main:      ...; ret;
+func fuzzProgram(ops []byte) *Program {
+	var code strings.Builder
+	code.WriteString("ap += 1;\ncall rel 4;\njmp rel 0;\n")
+	// the arithmetic operations need two values on the stack
+	code.WriteString("[ap] = 1, ap++;\n[ap] = 2, ap++;\n")
+	outputs := 0
+	for i, op := range ops {
+		switch op % 5 {
+		case 0:
+			fmt.Fprintf(&code, "[ap] = %d, ap++;\n", i*int(op))
+		case 1:
+			code.WriteString("[ap] = [ap - 1] + [ap - 2], ap++;\n")
+		case 2:
+			code.WriteString("[ap] = [ap - 1] * [ap - 2], ap++;\n")
+		case 3:
+			fmt.Fprintf(&code, "[ap] = [ap - 1] + %d, ap++;\n", op)
+		case 4:
+			fmt.Fprintf(&code, "[ap - 1] = [[fp - 3] + %d];\n", outputs)
+			outputs++
+		}
+	}
+	// return the updated output pointer
+	fmt.Fprintf(&code, "[ap] = [fp - 3] + %d, ap++;\nret;\n", outputs)
+
+	program := createProgramWithBuiltins(code.String(), builtins.OutputType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{
+		"__start__": fuzzStartPc,
+		"__end__":   fuzzEndPc,
+	}
+	return program
+}
+
+// FuzzSimulationVsProofMode runs the same random program in execution and proof
+// mode and checks that both compute the same output and main frame. Fuzzing runs
+// in parallel workers, e.g.
+//
+//	go test ./pkg/runner -run '^$' -fuzz FuzzSimulationVsProofMode -parallel 8
+func FuzzSimulationVsProofMode(f *testing.F) {
+	f.Add([]byte{})
+	f.Add([]byte{4})
+	f.Add([]byte{1, 2, 4, 3, 4, 0, 1, 4})
+	f.Add([]byte{10, 21, 32, 43, 54, 65, 76, 87, 98, 109, 120, 131, 142})
+
+	f.Fuzz(func(t *testing.T, ops []byte) {
+		if len(ops) > 256 {
+			ops = ops[:256]
+		}
+		program := fuzzProgram(ops)
+
+		simulation, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ExecutionModeZero, false, 1<<20, "small", nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, simulation.Run())
+
+		proof, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+		require.NoError(t, err)
+		require.NoError(t, proof.Run())
+		require.NoError(t, proof.EndRun())
+		require.NoError(t, proof.FinalizeSegments())
+
+		require.Equal(t, simulation.Output(), proof.Output(), "output diverges")
+
+		simulationSegment := simulation.vm.Memory.Segments[vm.ExecutionSegment]
+		simulationFrame := simulationSegment.Data[fuzzSimulationMainFp:simulationSegment.Len()]
+		proofSegment := proof.vm.Memory.Segments[vm.ExecutionSegment]
+		proofFrame := proofSegment.Data[fuzzProofMainFp:proofSegment.Len()]
+		require.Equal(t, len(simulationFrame), len(proofFrame), "main frame size diverges")
+		for i := range simulationFrame {
+			// the returned output pointer is relative to each output segment
+			if simulationFrame[i].IsAddress() {
+				continue
+			}
+			require.Equal(t, simulationFrame[i], proofFrame[i], "main frame diverges at fp + %d", i)
+		}
+	})
+}
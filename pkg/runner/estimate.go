@@ -0,0 +1,71 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// ResourceEstimate is the approximate outcome of Estimate. The resources are not
+// guaranteed to be the ones of a run: they can only be relied on for fee or
+// capacity estimations
+type ResourceEstimate struct {
+	Resources ExecutionResources `json:"resources"`
+	// false when the estimation stopped at its budget, the resources being a lower
+	// bound of the ones of the whole run
+	Complete bool `json:"complete"`
+}
+
+// Extrapolate scales the builtin instances and the memory holes of an incomplete
+// estimate to a run of steps steps, assuming the program keeps using them at the
+// same rate. A complete estimate is returned as is
+func (estimate *ResourceEstimate) Extrapolate(steps uint64) ExecutionResources {
+	resources := estimate.Resources
+	if estimate.Complete || resources.NSteps == 0 || steps <= resources.NSteps {
+		return resources
+	}
+	scale := float64(steps) / float64(resources.NSteps)
+	extrapolated := ExecutionResources{
+		NSteps:                 steps,
+		NMemoryHoles:           uint64(float64(resources.NMemoryHoles) * scale),
+		BuiltinInstanceCounter: make(map[string]uint64, len(resources.BuiltinInstanceCounter)),
+	}
+	for name, instances := range resources.BuiltinInstanceCounter {
+		extrapolated.BuiltinInstanceCounter[name] = uint64(float64(instances) * scale)
+	}
+	return extrapolated
+}
+
+// Estimate runs the main entrypoint in a simulation mode, faster than a run, to
+// estimate its resources when a full run is too slow. The builtins deduce their
+// values without checking the writes, so that e.g. the ECDSA signatures are not
+// verified, the trace is not collected and the execution stops after budget steps.
+// A program failing these checks gets an estimate rather than an error. The runner
+// cannot run the program afterwards
+func (runner *Runner) Estimate(budget uint64) (ResourceEstimate, error) {
+	if runner.vm != nil {
+		return ResourceEstimate{}, errors.New("cannot estimate the resources once the run has started")
+	}
+	runner.collectTrace = false
+	end, err := runner.initializeMainEntrypoint()
+	if err != nil {
+		return ResourceEstimate{}, fmt.Errorf("initializing main entry point: %w", err)
+	}
+	runner.runFinished = true
+	for _, segment := range runner.vm.Memory.Segments {
+		if _, ok := segment.BuiltinRunner.(*mem.NoBuiltin); !ok {
+			segment.BuiltinMode = mem.DeduceOnly
+		}
+	}
+
+	for !runner.vm.Context.Pc.Equal(&end) {
+		if runner.steps() >= budget {
+			return ResourceEstimate{Resources: runner.ExecutionResources()}, nil
+		}
+		if err := runner.vm.RunStep(&runner.hintrunner); err != nil {
+			return ResourceEstimate{}, fmt.Errorf("pc %s step %d: %w", runner.pc(), runner.steps(), err)
+		}
+	}
+	return ResourceEstimate{Resources: runner.ExecutionResources(), Complete: true}, nil
+}
@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEstimate(t *testing.T) {
+	code := `
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2];
+        [ap - 1] = [[fp - 3] + 5];
+        [ap - 1] = [[fp - 3] + 6];
+        ret;
+    `
+	runner := createRunner(code, "starknet_with_keccak", builtins.BitwiseType)
+	estimate, err := runner.Estimate(100)
+	require.NoError(t, err)
+	require.True(t, estimate.Complete)
+	require.Equal(t, uint64(8), estimate.Resources.NSteps)
+	require.Equal(t, map[string]uint64{"bitwise_builtin": 2}, estimate.Resources.BuiltinInstanceCounter)
+	require.Equal(t, estimate.Resources, estimate.Extrapolate(1000))
+	require.EqualError(t, runner.Run(), "cannot re-run using the same runner")
+
+	// the estimation stops at its budget
+	runner = createRunner(code, "starknet_with_keccak", builtins.BitwiseType)
+	estimate, err = runner.Estimate(4)
+	require.NoError(t, err)
+	require.False(t, estimate.Complete)
+	require.Equal(t, uint64(4), estimate.Resources.NSteps)
+	require.Equal(t, map[string]uint64{"bitwise_builtin": 1}, estimate.Resources.BuiltinInstanceCounter)
+	extrapolated := estimate.Extrapolate(40)
+	require.Equal(t, uint64(40), extrapolated.NSteps)
+	require.Equal(t, map[string]uint64{"bitwise_builtin": 10}, extrapolated.BuiltinInstanceCounter)
+
+	// the writes to the builtins are not checked
+	runner = createRunner(`
+        [ap] = 0x100000000000000000000000000000000;
+        [ap] = [[fp - 3]];
+        ret;
+    `, "small", builtins.RangeCheckType)
+	estimate, err = runner.Estimate(100)
+	require.NoError(t, err)
+	require.Equal(t, map[string]uint64{"range_check_builtin": 1}, estimate.Resources.BuiltinInstanceCounter)
+
+	_, err = runner.Estimate(100)
+	require.EqualError(t, err, "cannot estimate the resources once the run has started")
+}
@@ -0,0 +1,68 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ResourceExpectations are the exact resources a run is expected to use, so that CI
+// can run golden programs and fail when a change of the compiler or of the VM makes
+// them use more or fewer steps or builtin instances
+type ResourceExpectations struct {
+	// not checked when nil
+	Steps *uint64
+	// instances of each builtin, keyed by the name of the builtin, e.g. "pedersen"
+	Builtins map[string]uint64
+}
+
+// AddBuiltin adds the expectation of a builtin=instances text, e.g. pedersen=123.
+// The name may also have the _builtin suffix of the resources, e.g.
+// pedersen_builtin=123
+func (expectations *ResourceExpectations) AddBuiltin(text string) error {
+	name, count, ok := strings.Cut(text, "=")
+	name = strings.TrimSuffix(strings.TrimSpace(name), "_builtin")
+	if !ok || name == "" {
+		return fmt.Errorf("invalid builtin expectation %q: expected builtin=instances", text)
+	}
+	instances, err := strconv.ParseUint(strings.TrimSpace(count), 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid builtin expectation %q: invalid instances %q", text, count)
+	}
+	if _, ok := expectations.Builtins[name]; ok {
+		return fmt.Errorf("builtin %s is expected more than once", name)
+	}
+	if expectations.Builtins == nil {
+		expectations.Builtins = map[string]uint64{}
+	}
+	expectations.Builtins[name] = instances
+	return nil
+}
+
+// Check returns an error listing every resource whose usage differs from the
+// expectations, or nil when they all match. A builtin that the program doesn't use
+// cannot be expected, so that a typo in its name doesn't pass silently
+func (expectations *ResourceExpectations) Check(resources *ExecutionResources) error {
+	var errs []error
+	if expectations.Steps != nil && *expectations.Steps != resources.NSteps {
+		errs = append(errs, fmt.Errorf("steps: expected %d, got %d", *expectations.Steps, resources.NSteps))
+	}
+
+	names := make([]string, 0, len(expectations.Builtins))
+	for name := range expectations.Builtins {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		expected := expectations.Builtins[name]
+		instances, ok := resources.BuiltinInstanceCounter[name+"_builtin"]
+		if !ok {
+			errs = append(errs, fmt.Errorf("builtin %s: not a builtin of the program", name))
+		} else if instances != expected {
+			errs = append(errs, fmt.Errorf("builtin %s: expected %d instances, got %d", name, expected, instances))
+		}
+	}
+	return errors.Join(errs...)
+}
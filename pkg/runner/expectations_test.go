@@ -0,0 +1,46 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceExpectationsAddBuiltin(t *testing.T) {
+	var expectations ResourceExpectations
+	require.NoError(t, expectations.AddBuiltin("pedersen=123"))
+	require.NoError(t, expectations.AddBuiltin("range_check_builtin = 4"))
+	require.Equal(t, map[string]uint64{"pedersen": 123, "range_check": 4}, expectations.Builtins)
+
+	require.ErrorContains(t, expectations.AddBuiltin("bitwise"), "expected builtin=instances")
+	require.ErrorContains(t, expectations.AddBuiltin("=1"), "expected builtin=instances")
+	require.ErrorContains(t, expectations.AddBuiltin("bitwise=-1"), `invalid instances "-1"`)
+	require.ErrorContains(t, expectations.AddBuiltin("pedersen_builtin=1"), "pedersen is expected more than once")
+}
+
+func TestResourceExpectationsCheck(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2];
+        ret;
+    `, "starknet_with_keccak", builtins.BitwiseType)
+	require.NoError(t, runner.Run())
+	resources := runner.ExecutionResources()
+
+	steps := resources.NSteps
+	expectations := ResourceExpectations{Steps: &steps}
+	require.NoError(t, expectations.AddBuiltin("bitwise=1"))
+	require.NoError(t, expectations.Check(&resources))
+
+	steps++
+	expectations.Builtins["bitwise"] = 2
+	expectations.Builtins["pedersen"] = 0
+	err := expectations.Check(&resources)
+	require.ErrorContains(t, err, "steps: expected 7, got 6")
+	require.ErrorContains(t, err, "builtin bitwise: expected 2 instances, got 1")
+	require.ErrorContains(t, err, "builtin pedersen: not a builtin of the program")
+}
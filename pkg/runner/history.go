@@ -0,0 +1,131 @@
+package runner
+
+import (
+	"errors"
+
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// A write to a memory cell, with the step it was done at
+type cellWrite struct {
+	step  uint64
+	value mem.MemoryValue
+	// done when relocating the temporary segments, the value having been written at
+	// a temporary address before
+	relocation bool
+}
+
+type writeHistory struct {
+	writes     map[mem.MemoryAddress]cellWrite
+	relocating bool
+}
+
+// CellAtStep tells what a memory cell held when a step of the run started, before the
+// hints of the step ran
+type CellAtStep struct {
+	Address mem.MemoryAddress `json:"address"`
+	Step    uint64            `json:"step"`
+	// unknown, i.e. null in json, when the cell wasn't written yet
+	Value mem.MemoryValue `json:"value"`
+	// step the value was written at, none when the cell was known before the first
+	// step or deduced by a builtin
+	WrittenAt *uint64 `json:"written_at,omitempty"`
+	// the value was written into a temporary segment and copied to the cell when the
+	// temporary segments were relocated, at the end of the run
+	RelocatedFrom *mem.MemoryAddress `json:"relocated_from,omitempty"`
+	// value of the cell at the end of the run when it differs, as addresses into
+	// temporary segments are relocated
+	FinalValue *mem.MemoryValue `json:"final_value,omitempty"`
+	// the cell belongs to a builtin segment and was deduced by the builtin when read
+	Deduced bool `json:"deduced,omitempty"`
+}
+
+// RecordWrites makes the runner remember the step each memory cell is written at, so
+// that CellAt can tell what a cell held at any step of the run. It must be called
+// before running the program
+func (runner *Runner) RecordWrites() error {
+	if runner.vm != nil {
+		return errors.New("cannot record the memory writes once the run has started")
+	}
+	runner.writeHistory = &writeHistory{writes: map[mem.MemoryAddress]cellWrite{}}
+	return nil
+}
+
+func (runner *Runner) observeWrite(address mem.MemoryAddress, value *mem.MemoryValue) {
+	history := runner.writeHistory
+	// rewriting a cell with the same value keeps the step of the first write
+	if _, ok := history.writes[address]; ok {
+		return
+	}
+	history.writes[address] = cellWrite{step: runner.vm.Step, value: *value, relocation: history.relocating}
+}
+
+// CellAt returns what the cell at address held when the given step started, from
+// the writes recorded since RecordWrites. Values written to temporary segments are
+// traced back to the step they were written at, rather than to the relocation
+func (runner *Runner) CellAt(address mem.MemoryAddress, step uint64) (CellAtStep, error) {
+	history := runner.writeHistory
+	if history == nil {
+		return CellAtStep{}, errors.New("the memory writes were not recorded")
+	}
+	final, err := runner.vm.Memory.Peek(address.SegmentIndex, address.Offset)
+	if err != nil {
+		return CellAtStep{}, err
+	}
+
+	cell := CellAtStep{Address: address, Step: step}
+	write, ok := history.writes[address]
+	if ok && write.relocation {
+		var source mem.MemoryAddress
+		if source, ok = runner.relocationSource(address); ok {
+			cell.RelocatedFrom = &source
+			write, ok = history.writes[source]
+		}
+	}
+
+	if !ok {
+		// not written during the run
+		if final.Known() {
+			cell.Value = final
+			if address.SegmentIndex >= 0 {
+				_, noBuiltin := runner.vm.Memory.Segments[address.SegmentIndex].BuiltinRunner.(*mem.NoBuiltin)
+				cell.Deduced = !noBuiltin
+			}
+		}
+		return cell, nil
+	}
+	if write.step >= step {
+		return cell, nil
+	}
+	cell.Value = write.value
+	cell.WrittenAt = &write.step
+	if final.Known() && !final.Equal(&write.value) {
+		cell.FinalValue = &final
+	}
+	return cell, nil
+}
+
+// Finds the temporary cell relocated to the address. The known cells of a temporary
+// segment are relocated one after the other from its base address, see
+// mem.Memory.RelocateTemporarySegments
+func (runner *Runner) relocationSource(address mem.MemoryAddress) (mem.MemoryAddress, bool) {
+	memory := runner.vm.Memory
+	for index := 1; index < len(memory.TemporarySegments); index++ {
+		base, ok := memory.RelocationRule(index)
+		if !ok || base.SegmentIndex != address.SegmentIndex || address.Offset < base.Offset {
+			continue
+		}
+		position := address.Offset - base.Offset
+		segment := memory.TemporarySegments[index]
+		for offset := uint64(0); offset < segment.Len(); offset++ {
+			if !segment.Data[offset].Known() {
+				continue
+			}
+			if position == 0 {
+				return mem.MemoryAddress{SegmentIndex: -index, Offset: offset}, true
+			}
+			position--
+		}
+	}
+	return mem.UnknownAddress, false
+}
@@ -0,0 +1,81 @@
+package runner
+
+import (
+	"testing"
+
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCellAt(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 7, ap++;
+        [ap] = 8, ap++;
+        ret;
+    `, "plain")
+	_, err := runner.CellAt(mem.MemoryAddress{SegmentIndex: 1}, 0)
+	require.Error(t, err)
+	require.NoError(t, runner.RecordWrites())
+	require.NoError(t, runner.Run())
+
+	// written by the second instruction, at step 1
+	address := mem.MemoryAddress{SegmentIndex: 1, Offset: 3}
+	cell, err := runner.CellAt(address, 1)
+	require.NoError(t, err)
+	require.Equal(t, CellAtStep{Address: address, Step: 1}, cell)
+	cell, err = runner.CellAt(address, 2)
+	require.NoError(t, err)
+	step := uint64(1)
+	require.Equal(t, CellAtStep{Address: address, Step: 2, Value: mem.MemoryValueFromInt(8), WrittenAt: &step}, cell)
+
+	// the program is known before the first step
+	cell, err = runner.CellAt(mem.MemoryAddress{SegmentIndex: 0, Offset: 0}, 0)
+	require.NoError(t, err)
+	require.True(t, cell.Value.Known())
+	require.Nil(t, cell.WrittenAt)
+
+	// a value written into a temporary segment after a gap, and a pointer into the
+	// temporary segment, both relocated
+	memory := runner.vm.Memory
+	temporary := memory.AllocateEmptyTemporarySegment()
+	source := mem.MemoryAddress{SegmentIndex: temporary.SegmentIndex, Offset: 2}
+	value := mem.MemoryValueFromInt(5)
+	require.NoError(t, memory.WriteToAddress(&source, &value))
+	holder := memory.AllocateEmptySegment()
+	pointer := mem.MemoryValueFromMemoryAddress(&source)
+	require.NoError(t, memory.WriteToAddress(&holder, &pointer))
+	target := memory.AllocateEmptySegment()
+	memory.AddRelocationRule(-temporary.SegmentIndex, target)
+	runner.vm.Step++
+	require.NoError(t, runner.RelocateTemporarySegments())
+
+	// the known cells are relocated one after the other from the base address
+	written := runner.vm.Step - 1
+	cell, err = runner.CellAt(target, written)
+	require.NoError(t, err)
+	require.False(t, cell.Value.Known())
+	require.Equal(t, &source, cell.RelocatedFrom)
+	cell, err = runner.CellAt(target, written+1)
+	require.NoError(t, err)
+	require.Equal(t, CellAtStep{
+		Address:       target,
+		Step:          written + 1,
+		Value:         value,
+		WrittenAt:     &written,
+		RelocatedFrom: &source,
+	}, cell)
+	cell, err = runner.CellAt(holder, written+1)
+	require.NoError(t, err)
+	final := mem.MemoryAddress{SegmentIndex: target.SegmentIndex, Offset: 2}
+	finalValue := mem.MemoryValueFromMemoryAddress(&final)
+	require.Equal(t, CellAtStep{
+		Address:    holder,
+		Step:       written + 1,
+		Value:      pointer,
+		WrittenAt:  &written,
+		FinalValue: &finalValue,
+	}, cell)
+
+	_, err = runner.CellAt(mem.MemoryAddress{SegmentIndex: 10}, 0)
+	require.EqualError(t, err, "segment 10: unallocated")
+}
@@ -0,0 +1,135 @@
+package runner
+
+import (
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+)
+
+// Reads the implicit arguments of the functions from their ImplicitArgs, Args and
+// Return identifiers. The functions whose Return type has an unknown size are left
+// out, as the position of their returned implicit arguments can't be known
+func extractImplicitArgs(json *zero.ZeroProgram) map[string][]vm.ImplicitArg {
+	var implicitArgs map[string][]vm.ImplicitArg
+	for key, ident := range json.Identifiers {
+		if ident.IdentifierType != "function" {
+			continue
+		}
+		implicitStruct, ok := json.Identifiers[key+".ImplicitArgs"]
+		if !ok {
+			continue
+		}
+		argsStruct, ok := json.Identifiers[key+".Args"]
+		if !ok {
+			continue
+		}
+		returnType, ok := json.Identifiers[key+".Return"]
+		if !ok {
+			continue
+		}
+		returnSize, ok := cairoTypeSize(json, returnType.CairoType)
+		if !ok {
+			continue
+		}
+
+		// the arguments end at fp - 3 and the returned values at ap - 1, the implicit
+		// ones coming first
+		argsSize := uint64(implicitStruct.Size + argsStruct.Size)
+		implicitSize := uint64(implicitStruct.Size)
+		args := make([]vm.ImplicitArg, 0, len(implicitStruct.Members))
+		for name, member := range implicitStruct.Members {
+			offset, ok := memberOffset(member)
+			if !ok || offset >= implicitSize {
+				continue
+			}
+			args = append(args, vm.ImplicitArg{
+				Name:         name,
+				ArgOffset:    2 + argsSize - offset,
+				ReturnOffset: implicitSize + returnSize - offset,
+			})
+		}
+		if implicitArgs == nil {
+			implicitArgs = make(map[string][]vm.ImplicitArg)
+		}
+		implicitArgs[key[len(json.MainScope)+1:]] = args
+	}
+	return implicitArgs
+}
+
+func memberOffset(member any) (uint64, bool) {
+	fields, ok := member.(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	offset, ok := fields["offset"].(float64)
+	if !ok || offset < 0 {
+		return 0, false
+	}
+	return uint64(offset), true
+}
+
+// Size of a cairo zero type such as `felt`, `Uint256*` or `(res: felt, p: Point)`,
+// structs being looked up in the identifiers
+func cairoTypeSize(json *zero.ZeroProgram, cairoType string) (uint64, bool) {
+	cairoType = strings.TrimSpace(cairoType)
+	switch {
+	case cairoType == "felt" || cairoType == "codeoffset" || strings.HasSuffix(cairoType, "*"):
+		return 1, true
+	case strings.HasPrefix(cairoType, "(") && strings.HasSuffix(cairoType, ")"):
+		var size uint64
+		for _, member := range splitTupleMembers(cairoType[1 : len(cairoType)-1]) {
+			// members are either named, as `res: felt`, or not
+			if i := strings.IndexByte(member, ':'); i != -1 && !strings.ContainsAny(member[:i], "(*") {
+				member = member[i+1:]
+			}
+			memberSize, ok := cairoTypeSize(json, member)
+			if !ok {
+				return 0, false
+			}
+			size += memberSize
+		}
+		return size, true
+	}
+
+	// aliases are followed, at most once per identifier
+	for hops := 0; hops < len(json.Identifiers); hops++ {
+		ident, ok := json.Identifiers[cairoType]
+		if !ok {
+			return 0, false
+		}
+		switch ident.IdentifierType {
+		case "struct":
+			return uint64(ident.Size), true
+		case "alias":
+			cairoType = ident.Destination
+		default:
+			return 0, false
+		}
+	}
+	return 0, false
+}
+
+// Splits the members of a tuple on the commas which aren't nested in another tuple
+func splitTupleMembers(members string) []string {
+	var split []string
+	depth := 0
+	start := 0
+	for i, c := range members {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				split = append(split, members[start:i])
+				start = i + 1
+			}
+		}
+	}
+	if last := strings.TrimSpace(members[start:]); last != "" {
+		split = append(split, last)
+	}
+	return split
+}
@@ -0,0 +1,237 @@
+package runner
+
+import (
+	"encoding/json"
+	"html/template"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Maximum number of trace entries returned by a single trace search
+const maxTraceSearchResults = 1000
+
+// Resources summarizes what a finished run used
+type Resources struct {
+	Layout string
+	Steps  uint64
+	Memory mem.Usage
+	// hex commitment to the values written by hints, when enabled
+	InputCommitment string `json:",omitempty"`
+	// public keys of the ECDSA builtin found in its cache, when the builtin is used
+	ECDSAKeyCache *builtins.KeyCacheStats `json:",omitempty"`
+	// steps, holes and builtin instances as reported by the Python VM
+	Execution ExecutionResources
+}
+
+// TraceEntry is a step of the trace, with the registers before the step
+type TraceEntry struct {
+	Step uint64            `json:"step"`
+	Pc   mem.MemoryAddress `json:"pc"`
+	Ap   uint64            `json:"ap"`
+	Fp   uint64            `json:"fp"`
+}
+
+var inspectTemplate = template.Must(template.New("inspect").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cairo run</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 8px; text-align: right; }
+</style>
+</head>
+<body>
+<h1>Cairo run</h1>
+<p>{{.Resources.Steps}} steps with layout {{.Resources.Layout}}, {{.Resources.Memory.UsedCells}} memory cells used.
+<a href="segments">Segment map</a>, <a href="resources">resources</a> and <a href="output">output</a> as json.</p>
+<h2>Output</h2>
+{{- if .Output}}
+<ol start="0">
+{{- range .Output}}
+<li>{{.}}</li>
+{{- end}}
+</ol>
+{{- else}}
+<p>The program has no output.</p>
+{{- end}}
+<h2>Segments</h2>
+<table>
+<tr><th>segment</th><th>builtin</th><th>used cells</th><th>allocated cells</th></tr>
+{{- range .Resources.Memory.Segments}}
+<tr><td>{{.Index}}</td><td>{{.Builtin}}</td><td>{{.UsedCells}}</td><td>{{.AllocatedCells}}</td></tr>
+{{- end}}
+</table>
+<h2>Trace</h2>
+{{- if .Traced}}
+<form action="trace">
+<label>Steps at pc offset <input name="pc" type="number" min="0"></label>
+<label>in segment <input name="segment" type="number" value="0"></label>
+<input type="submit" value="Search">
+</form>
+{{- else}}
+<p>The trace was not collected, run with --collect_trace or --proofmode to search it.</p>
+{{- end}}
+<h2>Memory</h2>
+{{- if .Recorded}}
+<form action="cell">
+<label>Value of cell <input name="address" placeholder="1:5"></label>
+<label>when step <input name="step" type="number" min="0"> started</label>
+<input type="submit" value="Show">
+</form>
+{{- else}}
+<p>The memory writes were not recorded.</p>
+{{- end}}
+</body>
+</html>
+`))
+
+// InspectHandler serves a small web UI over the artifacts of a finished run: its
+// output, the resources used, the segment map and a search of the trace by pc.
+func (runner *Runner) InspectHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		err := inspectTemplate.Execute(w, struct {
+			Resources Resources
+			Output    []string
+			Traced    bool
+			Recorded  bool
+		}{
+			Resources: runner.resources(),
+			Output:    runner.formattedOutput(),
+			Traced:    runner.vm.Trace != nil,
+			Recorded:  runner.writeHistory != nil,
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/output", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, runner.formattedOutput())
+	})
+	mux.HandleFunc("/resources", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, runner.resources())
+	})
+	mux.HandleFunc("/segments", func(w http.ResponseWriter, r *http.Request) {
+		segmentMap := runner.BuildSegmentMap()
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := segmentMap.WriteHTML(w); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+	mux.HandleFunc("/trace", func(w http.ResponseWriter, r *http.Request) {
+		if runner.vm.Trace == nil {
+			http.Error(w, "the trace was not collected", http.StatusNotFound)
+			return
+		}
+		pc, err := parseTracePc(r.URL.Query().Get("pc"))
+		if err != nil {
+			http.Error(w, "invalid pc: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if segment := r.URL.Query().Get("segment"); segment != "" {
+			if pc.SegmentIndex, err = strconv.Atoi(segment); err != nil {
+				http.Error(w, "invalid segment: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		writeJSON(w, runner.searchTrace(&pc, maxTraceSearchResults))
+	})
+	mux.HandleFunc("/cell", func(w http.ResponseWriter, r *http.Request) {
+		if runner.writeHistory == nil {
+			http.Error(w, "the memory writes were not recorded", http.StatusNotFound)
+			return
+		}
+		address, err := mem.ParseMemoryAddress(r.URL.Query().Get("address"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// the memory at the end of the run by default
+		step := runner.steps() + 1
+		if text := r.URL.Query().Get("step"); text != "" {
+			if step, err = strconv.ParseUint(text, 10, 64); err != nil {
+				http.Error(w, "invalid step: "+err.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		cell, err := runner.CellAt(address, step)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeJSON(w, cell)
+	})
+	return mux
+}
+
+func (runner *Runner) resources() Resources {
+	resources := Resources{
+		Layout:    runner.layout.Name,
+		Steps:     runner.steps(),
+		Memory:    runner.MemoryUsage(),
+		Execution: runner.ExecutionResources(),
+	}
+	if commitment, ok := runner.InputCommitment(); ok {
+		resources.InputCommitment = "0x" + commitment.Text(16)
+	}
+	if stats, ok := runner.ECDSAKeyCacheStats(); ok {
+		resources.ECDSAKeyCache = &stats
+	}
+	return resources
+}
+
+func (runner *Runner) formattedOutput() []string {
+	output := runner.Output()
+	formatted := make([]string, len(output))
+	for i := range output {
+		formatted[i] = utils.FeltString(output[i])
+	}
+	return formatted
+}
+
+// Returns the first steps executed at pc, up to limit of them
+func (runner *Runner) searchTrace(pc *mem.MemoryAddress, limit int) []TraceEntry {
+	entries := []TraceEntry{}
+	for step := range runner.vm.Trace {
+		context := &runner.vm.Trace[step]
+		if !context.Pc.Equal(pc) {
+			continue
+		}
+		if len(entries) == limit {
+			break
+		}
+		entries = append(entries, TraceEntry{Step: uint64(step), Pc: context.Pc, Ap: context.Ap, Fp: context.Fp})
+	}
+	return entries
+}
+
+// The pc is either an address, "segment:offset", or an offset in the program segment
+func parseTracePc(text string) (mem.MemoryAddress, error) {
+	if strings.Contains(text, ":") {
+		return mem.ParseMemoryAddress(text)
+	}
+	offset, err := strconv.ParseUint(text, 10, 64)
+	if err != nil {
+		return mem.UnknownAddress, err
+	}
+	return mem.MemoryAddress{SegmentIndex: 0, Offset: offset}, nil
+}
+
+func writeJSON(w http.ResponseWriter, value any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
@@ -0,0 +1,73 @@
+package runner
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInspectHandler(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 7, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = [fp - 3] + 1, ap++;
+        ret;
+    `, "small", builtins.OutputType)
+	runner.collectTrace = true
+	require.NoError(t, runner.RecordWrites())
+	require.NoError(t, runner.Run())
+
+	server := httptest.NewServer(runner.InspectHandler())
+	defer server.Close()
+	get := func(path string) (int, string) {
+		response, err := http.Get(server.URL + path)
+		require.NoError(t, err)
+		defer response.Body.Close()
+		body, err := io.ReadAll(response.Body)
+		require.NoError(t, err)
+		return response.StatusCode, string(body)
+	}
+
+	status, body := get("/")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "4 steps with layout small")
+	require.Contains(t, body, "<li>7</li>")
+
+	status, body = get("/output")
+	require.Equal(t, http.StatusOK, status)
+	require.JSONEq(t, `["7"]`, body)
+
+	var resources Resources
+	_, body = get("/resources")
+	require.NoError(t, json.Unmarshal([]byte(body), &resources))
+	require.Equal(t, uint64(4), resources.Steps)
+	require.Equal(t, "small", resources.Layout)
+
+	status, body = get("/segments")
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, "<svg")
+
+	_, body = get("/trace?pc=2")
+	require.JSONEq(t, `[{"step": 1, "pc": "0:2", "ap": 4, "fp": 3}]`, body)
+	_, body = get("/trace?pc=0:2")
+	require.JSONEq(t, `[{"step": 1, "pc": "0:2", "ap": 4, "fp": 3}]`, body)
+	_, body = get("/trace?pc=2&segment=1")
+	require.JSONEq(t, `[]`, body)
+	status, _ = get("/trace?pc=first")
+	require.Equal(t, http.StatusBadRequest, status)
+	_, body = get("/cell?address=1:4&step=2")
+	require.JSONEq(t, `{"address": "1:4", "step": 2, "value": null}`, body)
+	_, body = get("/cell?address=1:4")
+	require.JSONEq(t, `{"address": "1:4", "step": 5, "value": "2:1", "written_at": 2}`, body)
+	_, body = get("/cell?address=2:0&step=2")
+	require.JSONEq(t, `{"address": "2:0", "step": 2, "value": "0x7", "written_at": 1}`, body)
+	status, _ = get("/cell?address=4")
+	require.Equal(t, http.StatusBadRequest, status)
+	status, _ = get("/unknown")
+	require.Equal(t, http.StatusNotFound, status)
+}
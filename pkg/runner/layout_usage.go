@@ -0,0 +1,122 @@
+package runner
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+)
+
+// instructions take three of the range check units of each step, for their offsets
+const instructionRcUnits = 3
+
+// LayoutUsage is what proving a run takes with a layout: the steps of its padded
+// trace and the units of the range check and diluted pools of that trace
+type LayoutUsage struct {
+	// steps of the trace once padded for proof mode, a power of two
+	PaddedSteps uint64 `json:"padded_steps"`
+	// range check units taken by the range check builtin and, for the range of the
+	// checked values, out of the units the instructions leave in the padded trace
+	RcUnits          uint64 `json:"rc_units"`
+	RcUnitsAvailable uint64 `json:"rc_units_available"`
+	// diluted units taken by the bitwise builtin and the range of the diluted values,
+	// out of the units of the padded trace. Zero when the layout has no diluted pool
+	DilutedUnits          uint64 `json:"diluted_units"`
+	DilutedUnitsAvailable uint64 `json:"diluted_units_available"`
+}
+
+// Fits tells whether a prover handling traces of up to maxSteps steps can prove the
+// run
+func (usage *LayoutUsage) Fits(maxSteps uint64) bool {
+	return usage.PaddedSteps <= maxSteps
+}
+
+// ComputeLayoutUsage computes, without running the program, the trace proof mode
+// pads resources to on the layout, e.g. for a scheduler to pick the prover of a
+// program from the resources of a run in execution mode. rcRange is the difference
+// between the largest and the smallest range checked value, the offsets of the
+// instructions included, at most 2^16. The trace is padded the same way as EndRun
+// does: to the smallest power of two with room for the instances of every builtin,
+// the range checked values and the diluted values
+func ComputeLayoutUsage(layout *builtins.Layout, resources *ExecutionResources, rcRange uint64) (LayoutUsage, error) {
+	// every builtin of the layout has a segment in proof mode, empty when unused
+	usedCells := make(map[string]uint64, len(layout.Builtins))
+	for i := range layout.Builtins {
+		usedCells[layout.Builtins[i].Runner.String()] = 0
+	}
+	for name, instances := range resources.BuiltinInstanceCounter {
+		name = strings.TrimSuffix(name, "_builtin")
+		if _, ok := usedCells[name]; !ok {
+			return LayoutUsage{}, fmt.Errorf("builtin %s is not in layout %s", name, layout.Name)
+		}
+		for i := range layout.Builtins {
+			if runner := layout.Builtins[i].Runner; runner.String() == name {
+				// the output builtin has no instances, each of its cells counts as one
+				usedCells[name] = instances * max(runner.GetCellsPerInstance(), 1)
+			}
+		}
+	}
+
+	for steps := utils.NextPowerOfTwo(max(resources.NSteps, 1)); steps != 0; steps <<= 1 {
+		usage, err := layoutUsageAt(layout, usedCells, rcRange, steps)
+		if err == nil {
+			return usage, nil
+		}
+	}
+	return LayoutUsage{}, fmt.Errorf("no trace of layout %s has room for the resources", layout.Name)
+}
+
+// layoutUsageAt computes the usage of a trace of the given steps, with an error when
+// the trace has no room for the cells used by the builtin segments, keyed by builtin
+// name, for the range checked values or for the diluted values
+func layoutUsageAt(layout *builtins.Layout, usedCells map[string]uint64, rcRange uint64, steps uint64) (LayoutUsage, error) {
+	usage := LayoutUsage{PaddedSteps: steps, RcUnits: rcRange}
+	var dilutedPerStep, dilutedRange uint64
+	if pool := layout.DilutedPool; pool != nil {
+		dilutedPerStep = pool.UnitsPerStep
+		dilutedRange = 1 << pool.NBits
+		usage.DilutedUnits = dilutedRange
+	}
+	if layout.RcUnits > instructionRcUnits {
+		usage.RcUnitsAvailable = (layout.RcUnits - instructionRcUnits) * steps
+	}
+	usage.DilutedUnitsAvailable = dilutedPerStep * steps
+
+	for i := range layout.Builtins {
+		runner := layout.Builtins[i].Runner
+		used, ok := usedCells[runner.String()]
+		if !ok {
+			continue
+		}
+		allocated, err := runner.GetAllocatedSize(used, steps)
+		if err != nil {
+			return usage, err
+		}
+		if used > allocated {
+			return usage, fmt.Errorf("builtin %s used size: %d exceeds allocated size: %d ", runner.String(), used, allocated)
+		}
+		switch runner := runner.(type) {
+		case *builtins.RangeCheck:
+			// range_check96 instances are checked by the range check 96 component
+			if layout.Builtins[i].Builtin == builtins.RangeCheckType {
+				usage.RcUnits += used * runner.RangeCheckNParts
+			}
+		case *builtins.Bitwise:
+			// the diluted pool holds the values of all the allocated instances
+			if pool := layout.DilutedPool; pool != nil {
+				instances := allocated / runner.GetCellsPerInstance()
+				usage.DilutedUnits += instances * runner.DilutedUnitsPerInstance(pool.Spacing, pool.NBits)
+			}
+		}
+	}
+
+	if usage.RcUnits > usage.RcUnitsAvailable {
+		unused := usage.RcUnitsAvailable - min(usage.RcUnitsAvailable, usage.RcUnits-rcRange)
+		return usage, fmt.Errorf("RangeCheck usage is %d, but the upper bound is %d", unused, rcRange)
+	}
+	if usage.DilutedUnits > usage.DilutedUnitsAvailable {
+		return usage, fmt.Errorf("diluted units used are %d, but %d are available", usage.DilutedUnits, usage.DilutedUnitsAvailable)
+	}
+	return usage, nil
+}
@@ -0,0 +1,125 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComputeLayoutUsage(t *testing.T) {
+	program := createProgramWithBuiltins(`
+        ap += 1;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 12, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = 10, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2], ap++;
+        [ap] = [fp - 3] + 5, ap++;
+        ret;
+    `, builtins.BitwiseType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "recursive", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.Run())
+	rcMin, rcMax := runner.getPermRangeCheckLimits()
+	resources := runner.ExecutionResources()
+	usage, err := ComputeLayoutUsage(&runner.layout, &resources, uint64(rcMax-rcMin))
+	require.NoError(t, err)
+
+	// the padding of EndRun gives room for an instance of every builtin of the layout
+	// and for the diluted values
+	require.NoError(t, runner.EndRun())
+	require.Equal(t, runner.steps(), usage.PaddedSteps)
+	require.Greater(t, usage.PaddedSteps, resources.NSteps)
+	require.True(t, usage.Fits(usage.PaddedSteps))
+	require.False(t, usage.Fits(usage.PaddedSteps/2))
+	require.LessOrEqual(t, usage.RcUnits, usage.RcUnitsAvailable)
+	require.LessOrEqual(t, usage.DilutedUnits, usage.DilutedUnitsAvailable)
+
+	resources.BuiltinInstanceCounter["keccak_builtin"] = 1
+	_, err = ComputeLayoutUsage(&runner.layout, &resources, uint64(rcMax-rcMin))
+	require.EqualError(t, err, "builtin keccak is not in layout recursive")
+}
+
+// Checks the diluted pools of the layouts with a bitwise builtin, which match the ones
+// of cairo-lang, against the padding of a run using the bitwise builtin
+func TestComputeLayoutUsageBitwiseLayouts(t *testing.T) {
+	testCases := []struct {
+		layout       string
+		unitsPerStep uint64
+		paddedSteps  uint64
+	}{
+		// the 2^16 diluted values and the 68 units of a bitwise instance every 8 steps
+		// take the 16 units of each step of a 2^14 steps trace
+		{layout: "recursive", unitsPerStep: 16, paddedSteps: 1 << 14},
+		{layout: "recursive_large_output", unitsPerStep: 16, paddedSteps: 1 << 14},
+		{layout: "recursive_with_poseidon", unitsPerStep: 8, paddedSteps: 1 << 15},
+		// the keccak component of 16 instances takes more steps than the diluted values
+		{layout: "all_cairo", unitsPerStep: 16, paddedSteps: 1 << 15},
+		{layout: "all_solidity", unitsPerStep: 16, paddedSteps: 1 << 13},
+		{layout: "starknet", unitsPerStep: 2, paddedSteps: 1 << 17},
+		{layout: "starknet_with_keccak", unitsPerStep: 4, paddedSteps: 1 << 15},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.layout, func(t *testing.T) {
+			program := createProgramWithBuiltins(`
+                ap += 1;
+                call rel 4;
+                jmp rel 0;
+                [ap] = 12, ap++;
+                [ap - 1] = [[fp - 3]];
+                [ap] = 10, ap++;
+                [ap - 1] = [[fp - 3] + 1];
+                [ap] = [[fp - 3] + 2], ap++;
+                [ap] = [fp - 3] + 5, ap++;
+                ret;
+            `, builtins.BitwiseType)
+			program.Entrypoints["main"] = fuzzMainPc
+			program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+
+			runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, tc.layout, nil, 0)
+			require.NoError(t, err)
+			require.Equal(t, &builtins.DilutedPool{UnitsPerStep: tc.unitsPerStep, Spacing: 4, NBits: 16}, runner.layout.DilutedPool)
+			require.NoError(t, runner.Run())
+			rcMin, rcMax := runner.getPermRangeCheckLimits()
+			resources := runner.ExecutionResources()
+			usage, err := ComputeLayoutUsage(&runner.layout, &resources, uint64(rcMax-rcMin))
+			require.NoError(t, err)
+			require.Equal(t, tc.paddedSteps, usage.PaddedSteps)
+			require.Equal(t, tc.unitsPerStep*usage.PaddedSteps, usage.DilutedUnitsAvailable)
+			require.Greater(t, usage.DilutedUnits, uint64(1<<16))
+			require.LessOrEqual(t, usage.DilutedUnits, usage.DilutedUnitsAvailable)
+
+			require.NoError(t, runner.EndRun())
+			require.Equal(t, usage.PaddedSteps, runner.steps())
+		})
+	}
+}
+
+func TestComputeLayoutUsageDilutedPool(t *testing.T) {
+	layout, err := builtins.GetLayout("recursive")
+	require.NoError(t, err)
+	resources := ExecutionResources{NSteps: 1 << 10, BuiltinInstanceCounter: map[string]uint64{}}
+
+	// the 2^16 diluted values and the 68 units of a bitwise instance every 8 steps
+	// take 8.5 of the 16 units of each step past the 2^16 values
+	usage, err := ComputeLayoutUsage(&layout, &resources, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<14), usage.PaddedSteps)
+	require.Equal(t, 16*usage.PaddedSteps, usage.DilutedUnitsAvailable)
+	require.LessOrEqual(t, usage.DilutedUnits, usage.DilutedUnitsAvailable)
+
+	noPool, err := builtins.GetLayout("recursive")
+	require.NoError(t, err)
+	noPool.DilutedPool = nil
+	usage, err = ComputeLayoutUsage(&noPool, &resources, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1<<10), usage.PaddedSteps)
+}
@@ -0,0 +1,126 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// DefaultMaxNestingDepth is the number of nested runs a run can be nested in, unless
+// changed by SetMaxNestingDepth
+const DefaultMaxNestingDepth = 8
+
+// Programs the hints of a run and of its nested runs can run, by hash
+type nestedPrograms struct {
+	programs map[fp.Element]nestedProgram
+	maxDepth int
+}
+
+type nestedProgram struct {
+	program *Program
+	hints   map[uint64][]hinter.Hinter
+}
+
+// AddNestedProgram lets the hints run the program, found by the hash it returns, in
+// a VM of its own, see hinter.NestedRunner. The nested runs share the programs of
+// the run. It must be called before running the program
+func (runner *Runner) AddNestedProgram(program *Program, hints map[uint64][]hinter.Hinter) (fp.Element, error) {
+	if runner.vm != nil {
+		return fp.Element{}, errors.New("cannot add a nested program once the run has started")
+	}
+	if runner.nested == nil {
+		runner.nested = &nestedPrograms{programs: map[fp.Element]nestedProgram{}, maxDepth: DefaultMaxNestingDepth}
+	}
+	hash := program.Hash()
+	runner.nested.programs[hash] = nestedProgram{program: program, hints: hints}
+	return hash, nil
+}
+
+// SetMaxNestingDepth limits the number of nested runs a nested run can be nested
+// in, the nested runs of the hints of the run having a depth of one. It must be
+// called after adding the nested programs and before running the program
+func (runner *Runner) SetMaxNestingDepth(depth int) error {
+	if runner.vm != nil {
+		return errors.New("cannot set the nesting depth once the run has started")
+	}
+	if runner.nested == nil {
+		return errors.New("cannot set the nesting depth without nested programs")
+	}
+	runner.nested.maxDepth = depth
+	return nil
+}
+
+// Runs the nested programs for the hints of a run
+type nestedRunner struct {
+	programs *nestedPrograms
+	layout   *builtins.Layout
+	maxSteps uint64
+	// hashes of the programs being run, the outermost first
+	running []fp.Element
+}
+
+var _ hinter.NestedRunner = (*nestedRunner)(nil)
+
+// Called once the vm is created, for the hints of the run to run the nested programs
+func (runner *Runner) initializeNestedRuns() {
+	if runner.nested == nil {
+		return
+	}
+	runner.hintrunner.Context().NestedRunner = &nestedRunner{
+		programs: runner.nested,
+		layout:   &runner.layout,
+		maxSteps: runner.maxsteps,
+		running:  append(slices.Clone(runner.enclosingPrograms), runner.program.Hash()),
+	}
+}
+
+func (nested *nestedRunner) RunNested(run *hinter.NestedRun) (hinter.NestedRunResult, error) {
+	program, ok := nested.programs.programs[run.ProgramHash]
+	if !ok {
+		return hinter.NestedRunResult{}, fmt.Errorf("no nested program has hash %s", &run.ProgramHash)
+	}
+	if slices.Contains(nested.running, run.ProgramHash) {
+		return hinter.NestedRunResult{}, fmt.Errorf("nested run of program %s: the program is already running", &run.ProgramHash)
+	}
+	if len(nested.running) > nested.programs.maxDepth {
+		return hinter.NestedRunResult{}, fmt.Errorf("nested run of program %s: runs cannot be nested more than %d deep", &run.ProgramHash, nested.programs.maxDepth)
+	}
+
+	// the builtin runners hold the state of their segments, so the nested run gets
+	// runners of its own
+	layout, err := nestedLayout(nested.layout)
+	if err != nil {
+		return hinter.NestedRunResult{}, fmt.Errorf("nested run of program %s: %w", &run.ProgramHash, err)
+	}
+	maxSteps := nested.maxSteps
+	if run.MaxSteps != 0 {
+		maxSteps = min(maxSteps, run.MaxSteps)
+	}
+	runner, err := NewRunner(program.program, program.hints, ExecutionModeZero, false, maxSteps, "", nil, 0)
+	if err != nil {
+		return hinter.NestedRunResult{}, fmt.Errorf("nested run of program %s: %w", &run.ProgramHash, err)
+	}
+	runner.layout = layout
+	runner.nested = nested.programs
+	runner.enclosingPrograms = nested.running
+	if err := runner.Run(); err != nil {
+		return hinter.NestedRunResult{}, fmt.Errorf("nested run of program %s: %w", &run.ProgramHash, err)
+	}
+	return hinter.NestedRunResult{Output: runner.Output(), Steps: runner.steps()}, nil
+}
+
+// Builds a layout like the one of the parent run
+func nestedLayout(parent *builtins.Layout) (builtins.Layout, error) {
+	if parent.DynamicParams != nil {
+		return parent.DynamicParams.Layout()
+	}
+	layout, err := builtins.GetLayout(parent.Name)
+	if err != nil {
+		return builtins.Layout{}, fmt.Errorf("cannot build layout %s: %w", parent.Name, err)
+	}
+	return layout, nil
+}
@@ -0,0 +1,112 @@
+package runner
+
+import (
+	"math"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/zero"
+	VM "github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// Hints running a nested program at the first pc of a program, the result of the
+// run being handed to done
+func nestedRunHints(run *hinter.NestedRun, done func(hinter.NestedRunResult, error)) map[uint64][]hinter.Hinter {
+	return map[uint64][]hinter.Hinter{0: {&zero.GenericZeroHinter{
+		Name: "RunNested",
+		Op: func(vm *VM.VirtualMachine, ctx *hinter.HintRunnerContext) error {
+			done(ctx.NestedRunner.RunNested(run))
+			return nil
+		},
+	}}}
+}
+
+func TestNestedRun(t *testing.T) {
+	child := createProgramWithBuiltins(`
+        [ap] = 42;
+        [ap] = [[fp - 3]];
+        ret;
+    `, builtins.OutputType)
+	parent := createProgram(`
+        ret;
+    `)
+
+	var result hinter.NestedRunResult
+	var err error
+	hints := nestedRunHints(&hinter.NestedRun{ProgramHash: child.Hash()}, func(r hinter.NestedRunResult, e error) { result, err = r, e })
+	runner, runnerErr := NewRunner(parent, hints, ExecutionModeZero, false, math.MaxUint64, "small", nil, 0)
+	require.NoError(t, runnerErr)
+	hash, addErr := runner.AddNestedProgram(child, nil)
+	require.NoError(t, addErr)
+	require.Equal(t, child.Hash(), hash)
+
+	require.NoError(t, runner.Run())
+	require.NoError(t, err)
+	require.Equal(t, []*fp.Element{new(fp.Element).SetUint64(42)}, result.Output)
+	require.Equal(t, uint64(3), result.Steps)
+	// the nested run has its own memory
+	require.Empty(t, runner.Output())
+
+	_, addErr = runner.AddNestedProgram(child, nil)
+	require.EqualError(t, addErr, "cannot add a nested program once the run has started")
+}
+
+func TestNestedRunLimits(t *testing.T) {
+	loop := createProgram(`
+        jmp rel 0;
+    `)
+	loopHash := loop.Hash()
+	// a program running itself, whose hints are known once its hash is
+	recursive := createProgram(`
+        ret;
+        ret;
+    `)
+	recursiveHash := recursive.Hash()
+
+	// the hints swallow the errors of their nested runs, only the first is kept
+	var err error
+	done := func(_ hinter.NestedRunResult, e error) {
+		if err == nil {
+			err = e
+		}
+	}
+	newRunner := func(run *hinter.NestedRun) Runner {
+		runner, runnerErr := NewRunner(createProgram("ret;"), nestedRunHints(run, done), ExecutionModeZero, false, 100, "small", nil, 0)
+		require.NoError(t, runnerErr)
+		_, runnerErr = runner.AddNestedProgram(loop, nil)
+		require.NoError(t, runnerErr)
+		_, runnerErr = runner.AddNestedProgram(recursive, nestedRunHints(&hinter.NestedRun{ProgramHash: recursiveHash}, done))
+		require.NoError(t, runnerErr)
+		return runner
+	}
+
+	// the nested runs are limited by the step limit of the run, or by their own
+	err = nil
+	runner := newRunner(&hinter.NestedRun{ProgramHash: loopHash})
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, err, "max step limit exceeded (100)")
+	err = nil
+	runner = newRunner(&hinter.NestedRun{ProgramHash: loopHash, MaxSteps: 10})
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, err, "max step limit exceeded (10)")
+
+	// a program cannot run itself
+	err = nil
+	runner = newRunner(&hinter.NestedRun{ProgramHash: recursiveHash})
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, err, "the program is already running")
+
+	err = nil
+	runner = newRunner(&hinter.NestedRun{ProgramHash: loopHash})
+	require.NoError(t, runner.SetMaxNestingDepth(0))
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, err, "runs cannot be nested more than 0 deep")
+
+	err = nil
+	runner = newRunner(&hinter.NestedRun{ProgramHash: fp.Element{}})
+	require.NoError(t, runner.Run())
+	require.EqualError(t, err, "no nested program has hash 0")
+}
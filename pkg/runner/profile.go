@@ -0,0 +1,89 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+)
+
+// HotSpot is a pc at which some of the sampled steps were executed
+type HotSpot struct {
+	Pc      uint64
+	Samples uint64
+}
+
+// Profile aggregates the samples of a run per pc. As only one step out of Interval
+// is recorded, the numbers are an approximation of where the steps were spent
+type Profile struct {
+	Interval uint64
+	Samples  uint64
+	// sorted by decreasing number of samples
+	HotSpots []HotSpot
+}
+
+// SetSampleInterval makes the vm record pc and ap every `interval` steps, zero
+// disabling sampling. It must be called before running the program.
+func (runner *Runner) SetSampleInterval(interval uint64) error {
+	if runner.vm != nil {
+		return errors.New("cannot change the sample interval once the run has started")
+	}
+	runner.sampleInterval = interval
+	return nil
+}
+
+// Samples returns the steps recorded by the sampling tracer
+func (runner *Runner) Samples() []vm.Sample {
+	if runner.vm == nil {
+		return nil
+	}
+	return runner.vm.Samples
+}
+
+// BuildProfile groups the samples of the run by pc
+func (runner *Runner) BuildProfile() Profile {
+	return buildProfile(runner.sampleInterval, runner.Samples())
+}
+
+func buildProfile(interval uint64, samples []vm.Sample) Profile {
+	counts := make(map[uint64]uint64)
+	for i := range samples {
+		counts[samples[i].Pc.Offset]++
+	}
+
+	profile := Profile{
+		Interval: interval,
+		Samples:  uint64(len(samples)),
+		HotSpots: make([]HotSpot, 0, len(counts)),
+	}
+	for pc, count := range counts {
+		profile.HotSpots = append(profile.HotSpots, HotSpot{Pc: pc, Samples: count})
+	}
+	sort.Slice(profile.HotSpots, func(i, j int) bool {
+		a, b := profile.HotSpots[i], profile.HotSpots[j]
+		if a.Samples != b.Samples {
+			return a.Samples > b.Samples
+		}
+		return a.Pc < b.Pc
+	})
+	return profile
+}
+
+// WriteText writes one line per hot spot with its pc, number of samples and share
+// of the samples
+func (profile *Profile) WriteText(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# %d samples, one every %d steps\n", profile.Samples, profile.Interval)
+	if err != nil {
+		return err
+	}
+	for _, hotSpot := range profile.HotSpots {
+		share := 100 * float64(hotSpot.Samples) / float64(profile.Samples)
+		_, err := fmt.Fprintf(w, "%d\t%d\t%.2f%%\n", hotSpot.Pc, hotSpot.Samples, share)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
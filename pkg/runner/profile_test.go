@@ -0,0 +1,29 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildProfile(t *testing.T) {
+	sample := func(step, pc uint64) vm.Sample {
+		return vm.Sample{Step: step, Pc: memory.MemoryAddress{SegmentIndex: vm.ProgramSegment, Offset: pc}}
+	}
+	profile := buildProfile(10, []vm.Sample{
+		sample(0, 0), sample(10, 4), sample(20, 6), sample(30, 4),
+	})
+
+	require.Equal(t, Profile{
+		Interval: 10,
+		Samples:  4,
+		HotSpots: []HotSpot{{Pc: 4, Samples: 2}, {Pc: 0, Samples: 1}, {Pc: 6, Samples: 1}},
+	}, profile)
+
+	var buffer bytes.Buffer
+	require.NoError(t, profile.WriteText(&buffer))
+	require.Equal(t, "# 4 samples, one every 10 steps\n4\t2\t50.00%\n0\t1\t25.00%\n6\t1\t25.00%\n", buffer.String())
+}
@@ -5,6 +5,8 @@ import (
 
 	sn "github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
 	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
@@ -20,6 +22,9 @@ type Program struct {
 	Builtins               []builtins.BuiltinType
 	GotGasBuiltin          bool
 	GotSegmentArenaBuiltin bool
+	// implicit arguments of the cairo zero functions by entrypoint name, for the
+	// functions whose arguments and return values have a known size
+	ImplicitArgs map[string][]vm.ImplicitArg
 }
 
 type CairoProgram struct{}
@@ -41,10 +46,11 @@ func LoadCairoZeroProgram(cairoZeroJson *zero.ZeroProgram) (*Program, error) {
 	entrypoints, labels := extractEntrypointsAndLabels(cairoZeroJson)
 
 	return &Program{
-		Bytecode:    bytecode,
-		Entrypoints: entrypoints,
-		Labels:      labels,
-		Builtins:    cairoZeroJson.Builtins,
+		Bytecode:     bytecode,
+		Entrypoints:  entrypoints,
+		Labels:       labels,
+		Builtins:     cairoZeroJson.Builtins,
+		ImplicitArgs: extractImplicitArgs(cairoZeroJson),
 	}, nil
 }
 
@@ -107,3 +113,12 @@ func extractCairoBuiltins(cairoProgram *sn.StarknetProgram) []builtins.BuiltinTy
 	}
 	return builtins
 }
+
+// Hash gives the Pedersen hash chain of the bytecode prefixed by its length, as
+// computed by `hash_chain` of the cairo common library. It identifies the code a
+// load_program hint loads
+func (p *Program) Hash() fp.Element {
+	length := new(fp.Element).SetUint64(uint64(len(p.Bytecode)))
+	data := append([]*fp.Element{length}, p.Bytecode...)
+	return utils.PedersenHashMany(data)
+}
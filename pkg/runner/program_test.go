@@ -4,9 +4,11 @@ import (
 	"testing"
 
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	pedersenhash "github.com/consensys/gnark-crypto/ecc/stark-curve/pedersen-hash"
 	"github.com/stretchr/testify/require"
 
 	zero "github.com/NethermindEth/cairo-vm-go/pkg/parsers/zero"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
 )
 
 func TestLoadCairoZeroProgram(t *testing.T) {
@@ -66,3 +68,67 @@ func TestLoadCairoZeroProgram(t *testing.T) {
 		program,
 	)
 }
+
+func TestProgramHash(t *testing.T) {
+	a, b := new(fp.Element).SetUint64(7), new(fp.Element).SetUint64(8)
+	program := Program{Bytecode: []*fp.Element{a, b}}
+
+	// hash_chain of [2, 7, 8]
+	inner := pedersenhash.Pedersen(a, b)
+	expected := pedersenhash.Pedersen(new(fp.Element).SetUint64(2), &inner)
+	require.Equal(t, expected, program.Hash())
+
+	runner := createRunner("ret;", "plain")
+	hash, err := runner.AddLoadableProgram(&program)
+	require.NoError(t, err)
+	require.Equal(t, expected, hash)
+	require.Equal(t, program.Bytecode, runner.hintrunner.Context().LoadablePrograms[hash])
+	require.NoError(t, runner.Run())
+	_, err = runner.AddLoadableProgram(&program)
+	require.ErrorContains(t, err, "once the run has started")
+}
+
+func TestLoadCairoZeroProgramImplicitArgs(t *testing.T) {
+	// hash(hash_ptr, range_check_ptr, x, y) -> (res: felt, point: Point), the size
+	// of bar's return type being unknown
+	content := []byte(`
+        {
+            "data": [],
+            "main_scope": "__main__",
+            "identifiers": {
+                "__main__.Point": {"type": "struct", "size": 2, "members": {}},
+                "__main__.PointAlias": {"type": "alias", "destination": "__main__.Point"},
+                "__main__.hash": {"pc": 0, "type": "function"},
+                "__main__.hash.ImplicitArgs": {
+                    "type": "struct",
+                    "size": 2,
+                    "members": {
+                        "hash_ptr": {"cairo_type": "starkware.cairo.common.cairo_builtins.HashBuiltin*", "offset": 0},
+                        "range_check_ptr": {"cairo_type": "felt", "offset": 1}
+                    }
+                },
+                "__main__.hash.Args": {"type": "struct", "size": 2, "members": {}},
+                "__main__.hash.Return": {"type": "type_definition", "cairo_type": "(res: felt, point: __main__.PointAlias)"},
+                "__main__.main": {"pc": 4, "type": "function"},
+                "__main__.main.ImplicitArgs": {"type": "struct", "size": 0, "members": {}},
+                "__main__.main.Args": {"type": "struct", "size": 0, "members": {}},
+                "__main__.main.Return": {"type": "type_definition", "cairo_type": "()"},
+                "__main__.bar": {"pc": 6, "type": "function"},
+                "__main__.bar.ImplicitArgs": {"type": "struct", "size": 0, "members": {}},
+                "__main__.bar.Args": {"type": "struct", "size": 0, "members": {}},
+                "__main__.bar.Return": {"type": "type_definition", "cairo_type": "__main__.Unknown"}
+            }
+        }
+    `)
+	cairoZeroJson, err := zero.ZeroProgramFromJSON(content)
+	require.NoError(t, err)
+	program, err := LoadCairoZeroProgram(cairoZeroJson)
+	require.NoError(t, err)
+
+	require.Len(t, program.ImplicitArgs, 2)
+	require.Empty(t, program.ImplicitArgs["main"])
+	require.ElementsMatch(t, []vm.ImplicitArg{
+		{Name: "hash_ptr", ArgOffset: 6, ReturnOffset: 5},
+		{Name: "range_check_ptr", ArgOffset: 5, ReturnOffset: 4},
+	}, program.ImplicitArgs["hash"])
+}
@@ -0,0 +1,88 @@
+package runner
+
+import (
+	"errors"
+	"math"
+	"time"
+)
+
+// Progress of a run, reported periodically while the program runs
+type Progress struct {
+	Steps   uint64
+	Elapsed time.Duration
+	// average since the run started
+	StepsPerSecond float64
+	// steps the run is expected to take, zero when unknown
+	ExpectedSteps uint64
+	// estimated time left to reach ExpectedSteps, zero when unknown
+	ETA time.Duration
+}
+
+// the clock is only read every that many steps, reading it at every step would
+// slow the vm down
+const progressCheckSteps = 1 << 14
+
+type progressReporter struct {
+	interval      time.Duration
+	expectedSteps uint64
+	report        func(Progress)
+	start         time.Time
+	lastReport    time.Time
+}
+
+// SetProgressReporter makes the runner call report with the progress of the run
+// every interval while the program runs. The ETA is computed from expectedSteps,
+// an estimate of the steps of the run, or from the max steps when it is zero. It
+// must be called before running the program.
+func (runner *Runner) SetProgressReporter(interval time.Duration, expectedSteps uint64, report func(Progress)) error {
+	if runner.vm != nil {
+		return errors.New("cannot set a progress reporter once the run has started")
+	}
+	if interval <= 0 {
+		return errors.New("the interval of the progress reports must be positive")
+	}
+	if expectedSteps == 0 && runner.maxsteps != math.MaxUint64 {
+		expectedSteps = runner.maxsteps
+	}
+	runner.progress = &progressReporter{
+		interval:      interval,
+		expectedSteps: expectedSteps,
+		report:        report,
+	}
+	return nil
+}
+
+// Called before each step of the vm
+func (reporter *progressReporter) tick(steps uint64) {
+	if steps%progressCheckSteps != 0 {
+		return
+	}
+	now := time.Now()
+	if reporter.start.IsZero() {
+		reporter.start = now
+		reporter.lastReport = now
+		return
+	}
+	if now.Sub(reporter.lastReport) < reporter.interval {
+		return
+	}
+	reporter.lastReport = now
+	reporter.report(reporter.progress(steps, now))
+}
+
+func (reporter *progressReporter) progress(steps uint64, now time.Time) Progress {
+	elapsed := now.Sub(reporter.start)
+	progress := Progress{
+		Steps:         steps,
+		Elapsed:       elapsed,
+		ExpectedSteps: reporter.expectedSteps,
+	}
+	if elapsed > 0 {
+		progress.StepsPerSecond = float64(steps) / elapsed.Seconds()
+	}
+	if progress.StepsPerSecond > 0 && reporter.expectedSteps > steps {
+		seconds := float64(reporter.expectedSteps-steps) / progress.StepsPerSecond
+		progress.ETA = time.Duration(seconds * float64(time.Second))
+	}
+	return progress
+}
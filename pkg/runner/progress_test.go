@@ -0,0 +1,56 @@
+package runner
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestProgressReporter(t *testing.T) {
+	var reports []Progress
+	reporter := &progressReporter{
+		interval:      time.Nanosecond,
+		expectedSteps: 4 * progressCheckSteps,
+		report:        func(progress Progress) { reports = append(reports, progress) },
+	}
+	for steps := uint64(0); steps <= 2*progressCheckSteps; steps++ {
+		reporter.tick(steps)
+	}
+	// the first check starts the clock, the clock is read once per check
+	require.Len(t, reports, 2)
+	require.Equal(t, uint64(progressCheckSteps), reports[0].Steps)
+	require.Equal(t, uint64(2*progressCheckSteps), reports[1].Steps)
+
+	start := time.Now()
+	reporter.start = start
+	progress := reporter.progress(progressCheckSteps, start.Add(2*time.Second))
+	require.Equal(t, Progress{
+		Steps:          progressCheckSteps,
+		Elapsed:        2 * time.Second,
+		StepsPerSecond: progressCheckSteps / 2,
+		ExpectedSteps:  4 * progressCheckSteps,
+		ETA:            6 * time.Second,
+	}, progress)
+
+	// no ETA once the expected steps are exceeded
+	progress = reporter.progress(5*progressCheckSteps, start.Add(2*time.Second))
+	require.Zero(t, progress.ETA)
+}
+
+func TestSetProgressReporter(t *testing.T) {
+	runner := createRunner("ret;", "plain")
+	require.ErrorContains(t, runner.SetProgressReporter(0, 0, func(Progress) {}), "must be positive")
+	// maxsteps is unlimited
+	require.NoError(t, runner.SetProgressReporter(time.Second, 0, func(Progress) {}))
+	require.Zero(t, runner.progress.expectedSteps)
+
+	runner.maxsteps = 1000
+	require.NoError(t, runner.SetProgressReporter(time.Second, 0, func(Progress) {}))
+	require.Equal(t, uint64(1000), runner.progress.expectedSteps)
+	require.NoError(t, runner.SetProgressReporter(time.Second, 10, func(Progress) {}))
+	require.Equal(t, uint64(10), runner.progress.expectedSteps)
+
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.SetProgressReporter(time.Second, 0, func(Progress) {}), "once the run has started")
+}
@@ -0,0 +1,145 @@
+package runner
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// the steps of the trace whose pc isn't in memory are only reported up to this many
+const maxReportedTraceErrors = 10
+
+// ValidateProofArtifacts checks the structural invariants the prover relies on in a
+// relocated trace, relocated memory and AIR public input of a proof mode run,
+// without proving it: the trace has a power of two steps, the public memory is in
+// the memory, the memory segments don't overlap and each builtin segment fits in
+// the cells the layout allocates to it for the steps of the trace. It only takes
+// seconds where a prover would fail after hours, and returns every problem found
+func ValidateProofArtifacts(trace []vm.Trace, memory []*fp.Element, publicInput *AirPublicInput) error {
+	var errs []error
+	errs = append(errs, validateProofTrace(trace, memory, publicInput)...)
+	errs = append(errs, validatePublicMemory(memory, publicInput)...)
+	if publicInput.RcMin > publicInput.RcMax {
+		errs = append(errs, fmt.Errorf("rc_min %d is greater than rc_max %d", publicInput.RcMin, publicInput.RcMax))
+	}
+	if err := checkAirMemorySegments(publicInput.MemorySegments); err != nil {
+		errs = append(errs, err)
+	}
+	errs = append(errs, validateBuiltinSegments(publicInput)...)
+	return errors.Join(errs...)
+}
+
+func validateProofTrace(trace []vm.Trace, memory []*fp.Element, publicInput *AirPublicInput) []error {
+	if len(trace) == 0 {
+		return []error{errors.New("the trace is empty")}
+	}
+	var errs []error
+	if steps := uint64(len(trace)); utils.NextPowerOfTwo(steps) != steps {
+		errs = append(errs, fmt.Errorf("the trace has %d steps, not a power of two", steps))
+	}
+	if len(trace) != publicInput.NSteps {
+		errs = append(errs, fmt.Errorf("the trace has %d steps but the public input has n_steps %d", len(trace), publicInput.NSteps))
+	}
+
+	// the registers of the first and last steps are the bounds of the program and
+	// execution segments
+	first, last := trace[0], trace[len(trace)-1]
+	program, ok := publicInput.MemorySegments["program"]
+	if !ok {
+		errs = append(errs, errors.New("the public input has no program segment"))
+	} else if first.Pc != program.BeginAddr || last.Pc != program.StopPtr {
+		errs = append(errs, fmt.Errorf("the trace runs from pc %d to pc %d but the program segment is [%d, %d]", first.Pc, last.Pc, program.BeginAddr, program.StopPtr))
+	}
+	execution, ok := publicInput.MemorySegments["execution"]
+	if !ok {
+		errs = append(errs, errors.New("the public input has no execution segment"))
+	} else if first.Ap != execution.BeginAddr || last.Ap != execution.StopPtr {
+		errs = append(errs, fmt.Errorf("the trace runs from ap %d to ap %d but the execution segment is [%d, %d]", first.Ap, last.Ap, execution.BeginAddr, execution.StopPtr))
+	}
+
+	reported := 0
+	for step := range trace {
+		if pc := trace[step].Pc; pc >= uint64(len(memory)) || memory[pc] == nil {
+			if reported == maxReportedTraceErrors {
+				errs = append(errs, errors.New("more steps have their pc out of memory"))
+				break
+			}
+			errs = append(errs, fmt.Errorf("step %d: pc %d is not in memory", step, pc))
+			reported++
+		}
+	}
+	return errs
+}
+
+func validatePublicMemory(memory []*fp.Element, publicInput *AirPublicInput) []error {
+	var errs []error
+	for _, entry := range publicInput.PublicMemory {
+		value, err := new(fp.Element).SetString(entry.Value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("public memory address %d: invalid value %s: %w", entry.Address, entry.Value, err))
+			continue
+		}
+		address := uint64(entry.Address)
+		if address >= uint64(len(memory)) || memory[address] == nil {
+			errs = append(errs, fmt.Errorf("public memory address %d is not in memory", address))
+			continue
+		}
+		if !memory[address].Equal(value) {
+			errs = append(errs, fmt.Errorf("public memory address %d is %s but the memory has %s", address, value, memory[address]))
+		}
+	}
+	return errs
+}
+
+// Checks that every builtin of the layout has a segment in the public input, which
+// fits in the instances the layout allocates to the builtin for the steps
+func validateBuiltinSegments(publicInput *AirPublicInput) []error {
+	layout, err := publicInputLayout(publicInput)
+	if err != nil {
+		return []error{err}
+	}
+	var errs []error
+	for _, bRunner := range layout.Builtins {
+		name := bRunner.Runner.String()
+		segment, ok := publicInput.MemorySegments[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("builtin %s of layout %s has no memory segment", name, layout.Name))
+			continue
+		}
+		if segment.StopPtr < segment.BeginAddr {
+			// reported by checkAirMemorySegments
+			continue
+		}
+		used := segment.StopPtr - segment.BeginAddr
+		allocated, err := bRunner.Runner.GetAllocatedSize(used, uint64(publicInput.NSteps))
+		if err != nil {
+			errs = append(errs, fmt.Errorf("builtin %s: %w", name, err))
+		} else if used > allocated {
+			errs = append(errs, fmt.Errorf("builtin %s: %d cells used but the layout allocates %d for %d steps", name, used, allocated, publicInput.NSteps))
+		}
+	}
+	return errs
+}
+
+// Builds the layout of the public input, from its dynamic params for the dynamic
+// layout
+func publicInputLayout(publicInput *AirPublicInput) (builtins.Layout, error) {
+	if publicInput.Layout != builtins.DynamicLayoutName {
+		return builtins.GetLayout(publicInput.Layout)
+	}
+	// the params are decoded as a generic json value
+	content, err := json.Marshal(publicInput.DynamicParams)
+	if err != nil {
+		return builtins.Layout{}, fmt.Errorf("invalid dynamic params: %w", err)
+	}
+	var params builtins.DynamicLayoutParams
+	if err := json.Unmarshal(content, &params); err != nil {
+		return builtins.Layout{}, fmt.Errorf("invalid dynamic params: %w", err)
+	}
+	return params.Layout()
+}
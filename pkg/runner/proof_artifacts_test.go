@@ -0,0 +1,57 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateProofArtifacts(t *testing.T) {
+	program := fuzzProgram([]byte{4, 4, 4, 4})
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, true, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.Run())
+	require.NoError(t, runner.EndRun())
+	require.NoError(t, runner.FinalizeBuiltins())
+	require.NoError(t, runner.FinalizeSegments())
+	encodedTrace, err := runner.BuildTrace()
+	require.NoError(t, err)
+	trace := vm.DecodeTrace(encodedTrace)
+	memory, segmentsOffsets := runner.BuildMemory()
+	publicInput, err := runner.GetAirPublicInput(memory, runner.GetPublicMemoryAddresses(segmentsOffsets))
+	require.NoError(t, err)
+	require.NoError(t, ValidateProofArtifacts(trace, memory, &publicInput))
+
+	// a truncated trace, which still ends in the end loop
+	require.EqualError(t, ValidateProofArtifacts(trace[:len(trace)-1], memory, &publicInput), ""+
+		"the trace has 511 steps, not a power of two\n"+
+		"the trace has 511 steps but the public input has n_steps 512")
+	require.ErrorContains(t, ValidateProofArtifacts(trace[1:], memory, &publicInput), "but the program segment is [1, 5]")
+
+	// a public memory value that isn't the one of the memory
+	entry := publicInput.PublicMemory[0]
+	publicInput.PublicMemory[0].Value = "0x2a"
+	require.ErrorContains(t, ValidateProofArtifacts(trace, memory, &publicInput), "public memory address 1 is 42 but the memory has")
+	publicInput.PublicMemory[0] = entry
+
+	// a builtin segment larger than the 64 instances of the 512 steps at ratio 8
+	output := publicInput.MemorySegments[builtins.OutputName]
+	pedersen := publicInput.MemorySegments[builtins.PedersenName]
+	publicInput.MemorySegments[builtins.PedersenName] = AirMemorySegmentEntry{BeginAddr: pedersen.BeginAddr, StopPtr: pedersen.BeginAddr + 65*3}
+	require.ErrorContains(t, ValidateProofArtifacts(trace, memory, &publicInput), "builtin pedersen: 195 cells used but the layout allocates 192 for 512 steps")
+	publicInput.MemorySegments[builtins.PedersenName] = pedersen
+	delete(publicInput.MemorySegments, builtins.OutputName)
+	require.EqualError(t, ValidateProofArtifacts(trace, memory, &publicInput), "builtin output of layout small has no memory segment")
+	publicInput.MemorySegments[builtins.OutputName] = output
+
+	// an instruction missing from the memory
+	pc := trace[0].Pc
+	instruction := memory[pc]
+	memory[pc] = nil
+	require.ErrorContains(t, ValidateProofArtifacts(trace, memory, &publicInput), "step 0: pc 1 is not in memory")
+	memory[pc] = instruction
+	require.NoError(t, ValidateProofArtifacts(trace, memory, &publicInput))
+}
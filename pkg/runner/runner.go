@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"slices"
 	"strings"
+	"time"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/assembler"
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner"
@@ -33,12 +34,56 @@ type Runner struct {
 	vm         *vm.VirtualMachine
 	hintrunner hintrunner.HintRunner
 	// config
-	collectTrace bool
-	maxsteps     uint64
-	runnerMode   RunnerMode
+	collectTrace   bool
+	maxsteps       uint64
+	runnerMode     RunnerMode
+	sampleInterval uint64
 	// auxiliary
 	runFinished bool
 	layout      builtins.Layout
+	// memory cells written before execution starts
+	presetCells []PresetCell
+	// trusted data preloaded as segments, by segment index
+	presetSegments map[int][]mem.MemoryValue
+	invariants     []vm.Invariant
+	// builtins made to fail at one of their instances
+	builtinFailures map[string]mem.InjectedFailure
+	stackGuard      bool
+	// implicit arguments checked on return, by offset of their function
+	builtinReturns map[uint64][]vm.ImplicitArg
+	// verify the ECDSA signatures on that many goroutines, when parallelECDSA is set
+	parallelECDSA bool
+	ecdsaWorkers  int
+	deferredECDSA bool
+	// signatures registered before the run, by offset in the ECDSA segment
+	ecdsaSignatures map[uint64][2]fp.Element
+	// hash of the program stamped into the artifacts, computed once
+	programHash *fp.Element
+	progress    *progressReporter
+	// steps the memory cells were written at, when enabled by RecordWrites
+	writeHistory *writeHistory
+	// relocate the segments that can't change in the background, see RelocateEagerly
+	eagerRelocation bool
+	// complete the partially used builtin instances when finalizing the segments
+	padBuiltins bool
+	telemetry   Telemetry
+	// deduce the Pedersen hashes from precomputed tables, see EnablePedersenTables
+	pedersenTables bool
+	// deduce the ratios of the dynamic layout from the run, see DeduceDynamicRatios
+	deduceRatios bool
+	// programs the hints can run, see AddNestedProgram
+	nested *nestedPrograms
+	// hashes of the programs whose nested run this run is, the outermost first
+	enclosingPrograms []fp.Element
+	// order of the concurrent tasks, see ScheduleConcurrency
+	schedule *utils.Schedule
+}
+
+// PresetCell is a memory value to be written at a given address before the
+// execution starts
+type PresetCell struct {
+	Address mem.MemoryAddress
+	Value   mem.MemoryValue
 }
 
 type CairoRunner struct{}
@@ -158,7 +203,8 @@ func AssembleProgram(cairoProgram *starknet.StarknetProgram, userArgs []starknet
 
 // RunEntryPoint is like Run, but it executes the program starting from the given PC offset.
 // This PC offset is expected to be a start from some function inside the loaded program.
-func (runner *Runner) RunEntryPoint(pc uint64) error {
+func (runner *Runner) RunEntryPoint(pc uint64) (err error) {
+	defer runner.recordExecution(time.Now(), &err)
 	if runner.runFinished {
 		return errors.New("cannot re-run using the same runner")
 	}
@@ -185,10 +231,86 @@ func (runner *Runner) RunEntryPoint(pc uint64) error {
 		return err
 	}
 
-	return nil
+	return runner.waitECDSAVerifications()
+}
+
+// RunDeprecatedEntryPoint executes the wrapper of an entry point of a Cairo Zero
+// contract class starting at the given pc offset, and returns its retdata. As for
+// the Starknet OS, the wrapper is called with the selector, the syscall pointer
+// followed by the builtin pointers of the program, then the calldata size and
+// pointer. The syscall segment is left empty, so syscalls need hints providing them
+func (runner *Runner) RunDeprecatedEntryPoint(pc uint64, selector *fp.Element, calldata []*fp.Element) ([]*fp.Element, error) {
+	if runner.runFinished {
+		return nil, errors.New("cannot re-run using the same runner")
+	}
+
+	memory, err := runner.initializeSegments()
+	if err != nil {
+		return nil, err
+	}
+
+	builtinsStack, err := runner.initializeBuiltins(memory)
+	if err != nil {
+		return nil, err
+	}
+
+	syscallPtr := memory.AllocateEmptySegment()
+	calldataPtr, err := memory.AllocateSegment(calldata)
+	if err != nil {
+		return nil, err
+	}
+	stack := []mem.MemoryValue{
+		mem.MemoryValueFromFieldElement(selector),
+		mem.MemoryValueFromMemoryAddress(&syscallPtr),
+	}
+	stack = append(stack, builtinsStack...)
+	stack = append(stack,
+		mem.MemoryValueFromInt(len(calldata)),
+		mem.MemoryValueFromMemoryAddress(&calldataPtr),
+	)
+
+	returnFp := memory.AllocateEmptySegment()
+	mvReturnFp := mem.MemoryValueFromMemoryAddress(&returnFp)
+	end, err := runner.initializeEntrypoint(pc, nil, &mvReturnFp, memory, stack)
+	if err != nil {
+		return nil, err
+	}
+	if err := runner.RunUntilPc(&end); err != nil {
+		return nil, err
+	}
+	if err := runner.waitECDSAVerifications(); err != nil {
+		return nil, err
+	}
+
+	// the wrapper returns the implicit arguments followed by retdata_size and retdata
+	ap := runner.vm.Context.AddressAp()
+	retdataSizeAddr := mem.MemoryAddress{SegmentIndex: ap.SegmentIndex, Offset: ap.Offset - 2}
+	retdataSize, err := runner.vm.Memory.ReadFromAddressAsElement(&retdataSizeAddr)
+	if err != nil {
+		return nil, fmt.Errorf("reading retdata size: %w", err)
+	}
+	if !retdataSize.IsUint64() {
+		return nil, fmt.Errorf("invalid retdata size %s", &retdataSize)
+	}
+	retdataPtrAddr := mem.MemoryAddress{SegmentIndex: ap.SegmentIndex, Offset: ap.Offset - 1}
+	retdataPtr, err := runner.vm.Memory.ReadFromAddressAsAddress(&retdataPtrAddr)
+	if err != nil {
+		return nil, fmt.Errorf("reading retdata: %w", err)
+	}
+	retdata := make([]*fp.Element, retdataSize.Uint64())
+	for i := range retdata {
+		address := mem.MemoryAddress{SegmentIndex: retdataPtr.SegmentIndex, Offset: retdataPtr.Offset + uint64(i)}
+		felt, err := runner.vm.Memory.ReadFromAddressAsElement(&address)
+		if err != nil {
+			return nil, fmt.Errorf("reading retdata: %w", err)
+		}
+		retdata[i] = &felt
+	}
+	return retdata, nil
 }
 
-func (runner *Runner) Run() error {
+func (runner *Runner) Run() (err error) {
+	defer runner.recordExecution(time.Now(), &err)
 	if runner.runFinished {
 		return errors.New("cannot re-run using the same runner")
 	}
@@ -211,7 +333,7 @@ func (runner *Runner) Run() error {
 			return err
 		}
 	}
-	return nil
+	return runner.waitECDSAVerifications()
 }
 
 func (runner *Runner) initializeSegments() (*mem.Memory, error) {
@@ -336,25 +458,53 @@ func (runner *Runner) initializeBuiltins(memory *mem.Memory) ([]mem.MemoryValue,
 				if err != nil {
 					return []mem.MemoryValue{}, err
 				}
-				return []mem.MemoryValue{}, fmt.Errorf("builtin %s not found in the layout: %s", builtinName, runner.layout.Name)
+				return []mem.MemoryValue{}, utils.WithErrorCode(utils.ErrorCodeMissingBuiltin, fmt.Errorf("builtin %s not found in the layout: %s", builtinName, runner.layout.Name))
 			}
 		}
 	}
 	stack := []mem.MemoryValue{}
 
-	for _, bRunner := range runner.layout.Builtins {
+	for i := range runner.layout.Builtins {
+		bRunner := &runner.layout.Builtins[i]
+		if ecdsa, ok := bRunner.Runner.(*builtins.ECDSA); ok {
+			ecdsa.ScheduleVerifications(runner.schedule)
+			if runner.parallelECDSA {
+				ecdsa.EnableParallelVerification(runner.ecdsaWorkers)
+			}
+			if runner.deferredECDSA {
+				ecdsa.EnableDeferredVerification()
+			}
+		}
+		if pedersen, ok := bRunner.Runner.(*builtins.Pedersen); ok && runner.pedersenTables {
+			pedersen.UsePrecomputedTables()
+		}
 		if runner.runnerMode == ExecutionModeCairo {
 			if slices.Contains(runner.program.Builtins, bRunner.Builtin) {
 				builtinSegment := memory.AllocateBuiltinSegment(bRunner.Runner)
+				memory.Segments[builtinSegment.SegmentIndex].BuiltinMode = bRunner.Mode
+				memory.Segments[builtinSegment.SegmentIndex].Failure = runner.builtinFailure(bRunner)
+				if err := runner.addECDSASignatures(memory, builtinSegment); err != nil {
+					return nil, err
+				}
 				stack = append(stack, mem.MemoryValueFromMemoryAddress(&builtinSegment))
 			}
 		} else {
 			builtinSegment := memory.AllocateBuiltinSegment(bRunner.Runner)
+			memory.Segments[builtinSegment.SegmentIndex].BuiltinMode = bRunner.Mode
+			memory.Segments[builtinSegment.SegmentIndex].Failure = runner.builtinFailure(bRunner)
+			if err := runner.addECDSASignatures(memory, builtinSegment); err != nil {
+				return nil, err
+			}
 			if slices.Contains(runner.program.Builtins, bRunner.Builtin) {
 				stack = append(stack, mem.MemoryValueFromMemoryAddress(&builtinSegment))
 			}
 		}
 	}
+	if len(runner.ecdsaSignatures) > 0 {
+		if _, ok := memory.FindSegmentWithBuiltin(builtins.ECDSAName); !ok {
+			return nil, errors.New("signatures were added but the run has no ecdsa builtin segment")
+		}
+	}
 	// Write builtins costs segment address to the end of the program segment if gas builtin is present
 	// todo: remove false on comparison with starkware runner
 	if runner.program.GotGasBuiltin && false {
@@ -370,6 +520,10 @@ func (runner *Runner) isProofMode() bool {
 	return runner.runnerMode == ProofModeCairo || runner.runnerMode == ProofModeZero
 }
 
+func (runner *Runner) isCairoZero() bool {
+	return runner.runnerMode == ExecutionModeZero || runner.runnerMode == ProofModeZero
+}
+
 func (runner *Runner) initializeVm(
 	initialPC *mem.MemoryAddress, stack []mem.MemoryValue, memory *mem.Memory,
 ) error {
@@ -382,6 +536,12 @@ func (runner *Runner) initializeVm(
 		}
 	}
 	initialFp := offset + stackSize
+	if err := runner.allocatePresetSegments(memory); err != nil {
+		return err
+	}
+	if err := runner.writePresetCells(memory); err != nil {
+		return err
+	}
 	var err error
 	// initialize vm
 	runner.vm, err = vm.NewVirtualMachine(vm.Context{
@@ -389,12 +549,394 @@ func (runner *Runner) initializeVm(
 		Ap: initialFp,
 		Fp: initialFp,
 	}, memory, vm.VirtualMachineConfig{
-		ProofMode:    runner.isProofMode(),
-		CollectTrace: runner.collectTrace,
+		ProofMode:        runner.isProofMode(),
+		CollectTrace:     runner.collectTrace,
+		SampleInterval:   runner.sampleInterval,
+		OpcodeExtensions: runner.layout.OpcodeExtensions,
+		Invariants:       runner.invariants,
+		StackGuard:       runner.stackGuard,
+		FunctionName:     runner.functionName,
+		ImplicitArgs:     runner.builtinReturns,
+		Schedule:         runner.schedule,
 	})
+	if err == nil && runner.writeHistory != nil {
+		memory.SetWriteObserver(runner.observeWrite)
+	}
+	if err == nil {
+		runner.initializeNestedRuns()
+	}
+	if err == nil && runner.eagerRelocation {
+		err = runner.vm.RelocateInBackground(vm.ProgramSegment)
+	}
 	return err
 }
 
+// RelocateEagerly makes the runner relocate the program segment, which can't change
+// during the run, in the background as soon as the run starts, so that BuildMemory
+// returns sooner once the run is over. The program segment is frozen: a write
+// changing one of its cells fails. The preset segments are relocated with the other
+// segments, since the program may still write to them. It must be called before
+// running the program
+func (runner *Runner) RelocateEagerly() error {
+	if runner.vm != nil {
+		return errors.New("cannot relocate eagerly once the run has started")
+	}
+	runner.eagerRelocation = true
+	return nil
+}
+
+// PadBuiltins makes FinalizeSegments complete the partially used instances of the
+// builtins with dummy values, zero for their unknown input cells and the values
+// deduced from the inputs for their output cells, so that every instance is valid.
+// It must be called before running the program
+func (runner *Runner) PadBuiltins() error {
+	if runner.vm != nil {
+		return errors.New("cannot pad the builtins once the run has started")
+	}
+	runner.padBuiltins = true
+	return nil
+}
+
+// SetLayout replaces the layout the runner was created with, typically by one loaded
+// from a layout file. It must be called before running the program. In proof mode the
+// builtins of the layout must apply every operation they implement.
+func (runner *Runner) SetLayout(layout builtins.Layout) error {
+	if runner.vm != nil {
+		return errors.New("cannot change the layout once the run has started")
+	}
+	if runner.isProofMode() {
+		for i := range layout.Builtins {
+			if builtin := &layout.Builtins[i]; builtin.Unchecked() {
+				return fmt.Errorf("layout %s: builtin %s: mode %s leaves the builtin values unchecked, which proof mode does not allow", layout.Name, builtin.Runner, builtin.Mode)
+			}
+		}
+	}
+	runner.layout = layout
+	return nil
+}
+
+// DeduceDynamicRatios makes EndRun set the ratios of the builtins of the dynamic
+// layout to the largest ones allocating the instances the run used, rather than
+// the ratios of the layout params, which then only need to list the used builtins.
+// The deduced ratios are written to the AIR public input. It must be called after
+// the dynamic layout is set and before running the program
+func (runner *Runner) DeduceDynamicRatios() error {
+	if runner.vm != nil {
+		return errors.New("cannot deduce the ratios once the run has started")
+	}
+	if runner.layout.DynamicParams == nil {
+		return fmt.Errorf("cannot deduce the ratios of layout %s, only the ones of the dynamic layout", runner.layout.Name)
+	}
+	runner.deduceRatios = true
+	return nil
+}
+
+// EnableKeccakAcceleration lets the hints of the cairo_keccak library verify the
+// keccak permutations natively, cutting the steps spent by `finalize_keccak`. The
+// builtin pointers returned by the library differ from a regular run so it is only
+// available in execution mode, for cairo zero programs and layouts including the
+// keccak builtin. It has to be called after the layout is set
+func (runner *Runner) EnableKeccakAcceleration() error {
+	if runner.vm != nil {
+		return errors.New("cannot enable keccak acceleration once the run has started")
+	}
+	if runner.isProofMode() {
+		return errors.New("keccak acceleration is not available in proof mode")
+	}
+	// the accelerated hints belong to the cairo_keccak library of cairo zero
+	if !runner.isCairoZero() {
+		return errors.New("keccak acceleration is only available for cairo zero programs")
+	}
+	hasKeccak := slices.ContainsFunc(runner.layout.Builtins, func(builtin builtins.LayoutBuiltin) bool {
+		return builtin.Builtin == builtins.KeccakType
+	})
+	if !hasKeccak {
+		return fmt.Errorf("keccak acceleration requires the keccak builtin, which layout %s does not include", runner.layout.Name)
+	}
+	runner.hintrunner.Context().KeccakAcceleration = true
+	return nil
+}
+
+// AddLoadableProgram lets the load_program hint load the code of a program, found
+// by the hash it returns, see Program.Hash. It must be called before running the
+// program
+func (runner *Runner) AddLoadableProgram(program *Program) (fp.Element, error) {
+	if runner.vm != nil {
+		return fp.Element{}, errors.New("cannot add a loadable program once the run has started")
+	}
+	context := runner.hintrunner.Context()
+	if context.LoadablePrograms == nil {
+		context.LoadablePrograms = make(map[fp.Element][]*fp.Element)
+	}
+	hash := program.Hash()
+	context.LoadablePrograms[hash] = program.Bytecode
+	return hash, nil
+}
+
+// EnableParallelECDSA makes the ECDSA builtin verify the signatures on a pool of
+// workers goroutines, one per CPU when workers is not positive. An invalid
+// signature then fails the run once it ends rather than at the step writing it,
+// with the offset of its instance in the segment. It must be called before running
+// the program
+func (runner *Runner) EnableParallelECDSA(workers int) error {
+	if runner.vm != nil {
+		return errors.New("cannot enable parallel ECDSA verification once the run has started")
+	}
+	runner.parallelECDSA = true
+	runner.ecdsaWorkers = workers
+	return nil
+}
+
+// ScheduleConcurrency makes the parallel ECDSA verifications and the background
+// relocations of the run log the order they complete in to the schedule, or run in
+// the order it replays, see utils.Schedule. Replaying the log of a run reproduces its
+// artifacts on any machine, and the schedule reports where the replayed run
+// diverged. It must be called before running the program
+func (runner *Runner) ScheduleConcurrency(schedule *utils.Schedule) error {
+	if runner.vm != nil {
+		return errors.New("cannot schedule the concurrent tasks once the run has started")
+	}
+	runner.schedule = schedule
+	return nil
+}
+
+// EnablePedersenTables makes the Pedersen builtin deduce its hashes from
+// precomputed tables, about twice as fast for hash-heavy programs. The tables take
+// about a megabyte and are built on the first hash, which doesn't pay off for
+// programs hashing little. It must be called before running the program
+func (runner *Runner) EnablePedersenTables() error {
+	if runner.vm != nil {
+		return errors.New("cannot enable the Pedersen tables once the run has started")
+	}
+	runner.pedersenTables = true
+	return nil
+}
+
+// EnableDeferredECDSA makes the ECDSA builtin check the instances once the run
+// ends instead of at the step writing them, keeping the curve operations out of
+// the execution. The invalid instances are then reported together, with their
+// offset in the segment. It must be called before running the program
+func (runner *Runner) EnableDeferredECDSA() error {
+	if runner.vm != nil {
+		return errors.New("cannot defer ECDSA verification once the run has started")
+	}
+	runner.deferredECDSA = true
+	return nil
+}
+
+// AddECDSASignature registers the (r, s) signature of the instance at the given
+// offset of an ECDSA segment, for embedders running Starknet-style contracts whose
+// signatures don't come from the add_signature hint. The segment is the position of
+// the ECDSA segment in allocation order, 0 being the segment allocated by the runner
+// and the following ones the additional segments, see
+// builtins.AllocateAdditionalSegment. Signatures added before the run can only go to
+// the segment allocated by the runner and are registered once it is allocated, and
+// the ones added during the run must be added before their instance is written.
+// Hints add signatures with builtins.AddECDSASignature
+func (runner *Runner) AddECDSASignature(segment int, offset uint64, r, s *fp.Element) error {
+	if runner.vm == nil {
+		if segment != 0 {
+			return fmt.Errorf("ecdsa segment %d: only the segment allocated by the runner can get signatures before the run", segment)
+		}
+		if runner.ecdsaSignatures == nil {
+			runner.ecdsaSignatures = make(map[uint64][2]fp.Element)
+		}
+		runner.ecdsaSignatures[offset] = [2]fp.Element{*r, *s}
+		return nil
+	}
+	segments := runner.vm.Memory.FindSegmentsWithBuiltin(builtins.ECDSAName)
+	if len(segments) == 0 {
+		return errors.New("the run has no ecdsa builtin segment")
+	}
+	if segment < 0 || segment >= len(segments) {
+		return fmt.Errorf("ecdsa segment %d: the run has %d ecdsa segments", segment, len(segments))
+	}
+	index := slices.Index(runner.vm.Memory.Segments, segments[segment])
+	address := mem.MemoryAddress{SegmentIndex: index, Offset: offset}
+	return builtins.AddECDSASignature(runner.vm.Memory, &address, r, s)
+}
+
+func (runner *Runner) addECDSASignatures(memory *mem.Memory, segment mem.MemoryAddress) error {
+	if memory.Segments[segment.SegmentIndex].BuiltinRunner.String() != builtins.ECDSAName {
+		return nil
+	}
+	for offset, signature := range runner.ecdsaSignatures {
+		address := mem.MemoryAddress{SegmentIndex: segment.SegmentIndex, Offset: offset}
+		if err := builtins.AddECDSASignature(memory, &address, &signature[0], &signature[1]); err != nil {
+			return fmt.Errorf("signature at offset %d: %w", offset, err)
+		}
+	}
+	return nil
+}
+
+// Joins the signature verifications of the ECDSA segments, see EnableParallelECDSA
+// and EnableDeferredECDSA
+func (runner *Runner) waitECDSAVerifications() error {
+	for _, segment := range runner.vm.Memory.FindSegmentsWithBuiltin(builtins.ECDSAName) {
+		if ecdsa, ok := segment.BuiltinRunner.(*builtins.ECDSA); ok {
+			if err := ecdsa.WaitVerifications(); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// EnableInputCommitment makes the run hash every value written to memory by a
+// hint, which commits to all the nondeterministic inputs the run depends on. It
+// must be called before running the program
+func (runner *Runner) EnableInputCommitment() error {
+	if runner.vm != nil {
+		return errors.New("cannot enable the input commitment once the run has started")
+	}
+	runner.hintrunner.EnableInputCommitment()
+	return nil
+}
+
+// InputCommitment gives the commitment to the values written by hints so far,
+// and false if it wasn't enabled with EnableInputCommitment
+func (runner *Runner) InputCommitment() (fp.Element, bool) {
+	commitment := runner.hintrunner.InputCommitment()
+	if commitment == nil {
+		return fp.Element{}, false
+	}
+	return commitment.Digest(), true
+}
+
+// PresetMemory registers memory cells that are written right after the segments
+// and builtins are initialized and before the first instruction is executed.
+// It must be called before running the program. Segments which are referenced by
+// the cells but not allocated at that point are allocated as empty segments.
+func (runner *Runner) PresetMemory(cells []PresetCell) error {
+	if runner.vm != nil {
+		return errors.New("cannot preset memory once the run has started")
+	}
+	runner.presetCells = append(runner.presetCells, cells...)
+	return nil
+}
+
+// AddInvariant registers an invariant checked while the program runs, failing the
+// run as soon as it doesn't hold. It must be called before running the program.
+func (runner *Runner) AddInvariant(invariant vm.Invariant) error {
+	if runner.vm != nil {
+		return errors.New("cannot add an invariant once the run has started")
+	}
+	if invariant.Check == nil {
+		return fmt.Errorf("invariant %s has no check", invariant.Name)
+	}
+	runner.invariants = append(runner.invariants, invariant)
+	return nil
+}
+
+// AddLabelInvariant registers an invariant checked each time the program reaches
+// one of the given labels or functions
+func (runner *Runner) AddLabelInvariant(name string, labels []string, check func(vm *vm.VirtualMachine) error) error {
+	pcs := make([]mem.MemoryAddress, 0, len(labels))
+	for _, label := range labels {
+		offset, ok := runner.program.Labels[label]
+		if !ok {
+			offset, ok = runner.program.Entrypoints[label]
+		}
+		if !ok {
+			return fmt.Errorf("invariant %s: unknown label %s", name, label)
+		}
+		pcs = append(pcs, mem.MemoryAddress{SegmentIndex: vm.ProgramSegment, Offset: offset})
+	}
+	return runner.AddInvariant(vm.Invariant{Name: name, Pcs: pcs, Check: check})
+}
+
+// InjectBuiltinFailure makes the checks and deductions of an instance of a builtin
+// fail with the given error, see mem.InjectedFailure. It is meant for negative
+// tests and has to be called after the layout is set and before running the program
+func (runner *Runner) InjectBuiltinFailure(builtinName string, instance uint64, err error) error {
+	if runner.vm != nil {
+		return errors.New("cannot inject a builtin failure once the run has started")
+	}
+	if err == nil {
+		return errors.New("cannot inject a builtin failure without an error")
+	}
+	inLayout := slices.ContainsFunc(runner.layout.Builtins, func(layoutBuiltin builtins.LayoutBuiltin) bool {
+		return layoutBuiltin.Runner.String() == builtinName
+	})
+	if !inLayout {
+		return fmt.Errorf("cannot inject a failure into builtin %s, which layout %s does not include", builtinName, runner.layout.Name)
+	}
+	if runner.builtinFailures == nil {
+		runner.builtinFailures = make(map[string]mem.InjectedFailure)
+	}
+	runner.builtinFailures[builtinName] = mem.InjectedFailure{Instance: instance, Err: err}
+	return nil
+}
+
+// Returns the failure injected into a layout builtin, nil if there is none
+func (runner *Runner) builtinFailure(layoutBuiltin *builtins.LayoutBuiltin) *mem.InjectedFailure {
+	failure, ok := runner.builtinFailures[layoutBuiltin.Runner.String()]
+	if !ok {
+		return nil
+	}
+	return &failure
+}
+
+// PresetTrustedSegment registers trusted data, such as a large calldata blob,
+// loaded as the segment of the given index right after the segments and builtins
+// are initialized. Its values are not validated, see mem.AllocateTrustedSegment.
+// Segments below the index that are not allocated at that point are allocated as
+// empty segments, and the index cannot be the one of a segment allocated by the
+// runner, such as the program, the execution or the builtin segments
+func (runner *Runner) PresetTrustedSegment(index int, data []mem.MemoryValue) error {
+	if runner.vm != nil {
+		return errors.New("cannot preset a segment once the run has started")
+	}
+	if index < 0 {
+		return fmt.Errorf("preset segment %d: temporary segments are not supported", index)
+	}
+	if _, ok := runner.presetSegments[index]; ok {
+		return fmt.Errorf("preset segment %d: already preset", index)
+	}
+	if runner.presetSegments == nil {
+		runner.presetSegments = make(map[int][]mem.MemoryValue)
+	}
+	runner.presetSegments[index] = data
+	return nil
+}
+
+func (runner *Runner) allocatePresetSegments(memory *mem.Memory) error {
+	indexes := make([]int, 0, len(runner.presetSegments))
+	for index := range runner.presetSegments {
+		indexes = append(indexes, index)
+	}
+	slices.Sort(indexes)
+	for _, index := range indexes {
+		if index < len(memory.Segments) {
+			return fmt.Errorf("preset segment %d: already allocated by the runner", index)
+		}
+		for index > len(memory.Segments) {
+			memory.AllocateEmptySegment()
+		}
+		memory.AllocateTrustedSegment(runner.presetSegments[index])
+	}
+	return nil
+}
+
+func (runner *Runner) writePresetCells(memory *mem.Memory) error {
+	for i := range runner.presetCells {
+		cell := &runner.presetCells[i]
+		if !cell.Value.Known() {
+			return fmt.Errorf("preset cell %s: unknown value", cell.Address)
+		}
+		if cell.Address.SegmentIndex < 0 {
+			return fmt.Errorf("preset cell %s: temporary segments are not supported", cell.Address)
+		}
+		for cell.Address.SegmentIndex >= len(memory.Segments) {
+			memory.AllocateEmptySegment()
+		}
+		if err := memory.WriteToAddress(&cell.Address, &cell.Value); err != nil {
+			return fmt.Errorf("preset cell %s: %w", cell.Address, err)
+		}
+	}
+	return nil
+}
+
 // run until the program counter equals the `pc` parameter
 func (runner *Runner) RunUntilPc(pc *mem.MemoryAddress) error {
 	for !runner.vm.Context.Pc.Equal(pc) {
@@ -406,6 +948,9 @@ func (runner *Runner) RunUntilPc(pc *mem.MemoryAddress) error {
 				runner.maxsteps,
 			)
 		}
+		if runner.progress != nil {
+			runner.progress.tick(runner.steps())
+		}
 		if err := runner.vm.RunStep(&runner.hintrunner); err != nil {
 			return fmt.Errorf("pc %s step %d: %w", runner.pc(), runner.steps(), err)
 		}
@@ -424,6 +969,9 @@ func (runner *Runner) RunFor(steps uint64) error {
 				runner.maxsteps,
 			)
 		}
+		if runner.progress != nil {
+			runner.progress.tick(runner.steps())
+		}
 		if err := runner.vm.RunStep(&runner.hintrunner); err != nil {
 			return fmt.Errorf(
 				"pc %s step %d: %w",
@@ -446,81 +994,124 @@ func (runner *Runner) EndRun() error {
 			return err
 		}
 	}
+	if err := runner.deduceDynamicRatios(); err != nil {
+		return err
+	}
 	for runner.checkUsedCells() != nil {
 		pow2Steps := utils.NextPowerOfTwo(runner.vm.Step + 1)
 		if err := runner.RunFor(pow2Steps); err != nil {
 			return err
 		}
+		if err := runner.deduceDynamicRatios(); err != nil {
+			return err
+		}
 	}
-	return nil
+	if err := runner.checkModBuiltins(); err != nil {
+		return err
+	}
+	if err := runner.checkECDSAInstances(); err != nil {
+		return err
+	}
+	return runner.waitECDSAVerifications()
 }
 
-// checkUsedCells returns error if not enough steps were made to allocate required number of cells for builtins
-// or there are not enough trace cells to fill the entire range check range
-func (runner *Runner) checkUsedCells() error {
-	for _, bRunner := range runner.layout.Builtins {
-		builtinName := bRunner.Runner.String()
-		builtinSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtinName)
-		if ok {
-			segmentUsedSize := builtinSegment.Len()
-			allocatedSize, err := bRunner.Runner.GetAllocatedSize(segmentUsedSize, runner.steps())
-			if err != nil {
+// checkECDSAInstances rejects the ECDSA instances with a single written cell, whose
+// signature is never verified since CheckWrite needs both of them
+func (runner *Runner) checkECDSAInstances() error {
+	for _, segment := range runner.vm.Memory.FindSegmentsWithBuiltin(builtins.ECDSAName) {
+		if ecdsa, ok := segment.BuiltinRunner.(*builtins.ECDSA); ok {
+			if err := ecdsa.CheckInstances(segment); err != nil {
 				return err
 			}
-			if segmentUsedSize > allocatedSize {
-				return fmt.Errorf("builtin %s used size: %d exceeds allocated size: %d ", builtinName, segmentUsedSize, allocatedSize)
-			}
 		}
 	}
-	return runner.checkRangeCheckUsage()
+	return nil
 }
 
-// Checks if there are not enough trace cells to fill the entire range check range. Each step has assigned a number of range check units. If the number of unused range check units is less than the range of potential values to be checked (defined by rcMin and rcMax), the number of trace cells must be increased, by running additional steps.
-func (runner *Runner) checkRangeCheckUsage() error {
-	rcMin, rcMax := runner.getPermRangeCheckLimits()
-	var rcUnitsUsedByBuiltins uint64
-	for _, builtin := range runner.program.Builtins {
-		if builtin == builtins.RangeCheckType {
-			for _, layoutBuiltin := range runner.layout.Builtins {
-				if builtin == layoutBuiltin.Builtin {
-					rangeCheckRunner, ok := layoutBuiltin.Runner.(*builtins.RangeCheck)
-					if !ok {
-						return fmt.Errorf("error type casting to *builtins.RangeCheck")
-					}
-					rangeCheckSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(rangeCheckRunner.String())
-					if ok {
-						rcUnitsUsedByBuiltins += rangeCheckSegment.Len() * rangeCheckRunner.RangeCheckNParts
-					}
-				}
+// checkModBuiltins verifies the instances of the add_mod and mul_mod builtins, whose
+// values are only filled by the fill_memory hint and never checked on write
+func (runner *Runner) checkModBuiltins() error {
+	for _, bRunner := range runner.layout.Builtins {
+		modRunner, ok := bRunner.Runner.(*builtins.ModBuiltin)
+		if !ok {
+			continue
+		}
+		for _, builtinSegment := range runner.vm.Memory.FindSegmentsWithBuiltin(modRunner.String()) {
+			// the segment has its own runner
+			segmentRunner, ok := builtinSegment.BuiltinRunner.(*builtins.ModBuiltin)
+			if !ok {
+				continue
+			}
+			if err := segmentRunner.CheckInstances(runner.vm.Memory, builtinSegment); err != nil {
+				return err
 			}
 		}
 	}
-	// Out of the range check units allowed per step three are used for the instruction.
-	unusedRcUnits := (runner.layout.RcUnits-3)*runner.vm.Step - rcUnitsUsedByBuiltins
-	rcUsageUpperBound := uint64(rcMax - rcMin)
-	if unusedRcUnits < rcUsageUpperBound {
-		return fmt.Errorf("RangeCheck usage is %d, but the upper bound is %d", unusedRcUnits, rcUsageUpperBound)
+	return nil
+}
+
+// RunSecurityChecks validates every builtin segment once the run is finalized: the
+// cells used past the stop pointers, the instances missing input cells and the output
+// cells that don't match the values deduced by the builtins. An invalid program would
+// otherwise produce a trace rejected by the prover with much less helpful errors
+func (runner *Runner) RunSecurityChecks() error {
+	for _, segment := range runner.vm.Memory.Segments {
+		if err := builtins.CheckSegment(segment); err != nil {
+			return fmt.Errorf("builtin %s: %w", segment.BuiltinRunner, err)
+		}
 	}
 	return nil
 }
 
-// getPermRangeCheckLimits returns the minimum and maximum values used by the range check units in the program. To find the values, maximum and minimum values from the range check segment are compared with maximum and minimum values of instructions offsets calculated during running the instructions.
+// checkUsedCells returns error if not enough steps were made to allocate required number of cells for builtins,
+// to fill the entire range check range with trace cells or to hold the diluted values of the builtins
+func (runner *Runner) checkUsedCells() error {
+	rcMin, rcMax := runner.getPermRangeCheckLimits()
+	_, err := layoutUsageAt(&runner.layout, runner.builtinUsedCells(), uint64(rcMax-rcMin), runner.steps())
+	return err
+}
+
+// Sets the ratios of the dynamic layout for the steps run so far, when enabled by
+// DeduceDynamicRatios. Their allocations are too small if the trace needs more
+// steps, which checkUsedCells then reports
+func (runner *Runner) deduceDynamicRatios() error {
+	if !runner.deduceRatios {
+		return nil
+	}
+	_, err := runner.layout.DeduceDynamicRatios(runner.builtinUsedCells(), runner.steps())
+	return err
+}
+
+// Returns the cells used by the segments of each builtin of the layout, by name
+func (runner *Runner) builtinUsedCells() map[string]uint64 {
+	usedCells := make(map[string]uint64, len(runner.layout.Builtins))
+	for _, bRunner := range runner.layout.Builtins {
+		builtinName := bRunner.Runner.String()
+		for _, builtinSegment := range runner.vm.Memory.FindSegmentsWithBuiltin(builtinName) {
+			usedCells[builtinName] += builtinSegment.Len()
+		}
+	}
+	return usedCells
+}
+
+// getPermRangeCheckLimits returns the minimum and maximum values used by the range check units in the program. To find the values, the smallest and largest 16-bit parts of the values written to the range check and range check 96 segments, tracked by their runners, are compared with maximum and minimum values of instructions offsets calculated during running the instructions.
 func (runner *Runner) getPermRangeCheckLimits() (uint16, uint16) {
 	rcMin, rcMax := runner.vm.RcLimitsMin, runner.vm.RcLimitsMax
 
-	for _, builtin := range runner.program.Builtins {
-		if builtin == builtins.RangeCheckType {
-			bRunner := builtins.Runner(builtin)
-			rangeCheckRunner, _ := bRunner.(*builtins.RangeCheck)
-			rangeCheckSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(rangeCheckRunner.String())
-			if ok {
-				rangeCheckUsageMin, rangeCheckUsageMax := rangeCheckRunner.GetRangeCheckUsage(rangeCheckSegment)
-				if rangeCheckUsageMin < rcMin {
-					rcMin = rangeCheckUsageMin
-				}
-				if rangeCheckUsageMax > rcMax {
-					rcMax = rangeCheckUsageMax
-				}
+	for _, name := range []string{builtins.RangeCheckName, builtins.RangeCheck96Name} {
+		for _, rangeCheckSegment := range runner.vm.Memory.FindSegmentsWithBuiltin(name) {
+			rangeCheckRunner, ok := rangeCheckSegment.BuiltinRunner.(*builtins.RangeCheck)
+			if !ok {
+				continue
+			}
+			// the values aren't checked, nor tracked, without validation
+			if rangeCheckSegment.BuiltinMode == mem.DeduceOnly {
+				usageMin, usageMax := rangeCheckRunner.GetRangeCheckUsage(rangeCheckSegment)
+				rcMin, rcMax = min(rcMin, usageMin), max(rcMax, usageMax)
+				continue
+			}
+			if usageMin, usageMax, ok := rangeCheckRunner.RangeCheckUsage(); ok {
+				rcMin, rcMax = min(rcMin, usageMin), max(rcMax, usageMax)
 			}
 		}
 	}
@@ -541,8 +1132,12 @@ func (runner *Runner) FinalizeSegments() error {
 	}
 	runner.vm.Memory.Segments[vm.ProgramSegment].Finalize(programSize, publicMemory)
 	for _, bRunner := range runner.layout.Builtins {
-		builtinSegment, ok := runner.vm.Memory.FindSegmentWithBuiltin(bRunner.Runner.String())
-		if ok {
+		for _, builtinSegment := range runner.vm.Memory.FindSegmentsWithBuiltin(bRunner.Runner.String()) {
+			if padded, ok := builtinSegment.BuiltinRunner.(builtins.PaddedBuiltin); ok && runner.padBuiltins {
+				if err := padded.Pad(builtinSegment); err != nil {
+					return fmt.Errorf("builtin %s: padding: %w", bRunner.Runner.String(), err)
+				}
+			}
 			size, err := bRunner.Runner.GetAllocatedSize(builtinSegment.Len(), runner.vm.Step)
 			if err != nil {
 				return fmt.Errorf("builtin %s: %v", bRunner.Runner.String(), err)
@@ -553,7 +1148,11 @@ func (runner *Runner) FinalizeSegments() error {
 				if !ok {
 					return fmt.Errorf("builtin %s: %v", bRunner.String(), err)
 				}
-				builtinSegment.Finalize(size, bRunner.GetOutputPublicMemory(*builtinSegment))
+				publicMemory, err := bRunner.GetOutputPublicMemory(*builtinSegment)
+				if err != nil {
+					return fmt.Errorf("builtin %s: %w", bRunner.String(), err)
+				}
+				builtinSegment.Finalize(size, publicMemory)
 				continue
 			}
 			builtinSegment.Finalize(size, nil)
@@ -565,16 +1164,85 @@ func (runner *Runner) FinalizeSegments() error {
 
 // BuildMemory relocates the memory and returns it
 func (runner *Runner) BuildMemory() ([]*fp.Element, []uint64) {
+	defer runner.recordSpan(RelocateSpan, time.Now(), map[string]string{"artifact": "memory"}, nil)
 	return runner.vm.RelocateMemory()
 }
 
 // BuildTrace relocates the trace and returns it
 func (runner *Runner) BuildTrace() ([]byte, error) {
+	defer runner.recordSpan(RelocateSpan, time.Now(), map[string]string{"artifact": "trace"}, nil)
 	relocatedTrace := make([]vm.Trace, len(runner.vm.Trace))
 	runner.vm.RelocateTrace(&relocatedTrace)
 	return vm.EncodeTrace(relocatedTrace), nil
 }
 
+// MemoryUsage reports how much memory the segments of the last run use
+func (runner *Runner) MemoryUsage() mem.Usage {
+	return runner.vm.Memory.Usage()
+}
+
+// ExecutionResources are the resources used by a run, in the format of the Python VM
+// so that they can be compared with other implementations or used to estimate fees
+type ExecutionResources struct {
+	NSteps       uint64 `json:"n_steps"`
+	NMemoryHoles uint64 `json:"n_memory_holes"`
+	// instances used by each builtin, keyed by the name of the builtin followed by
+	// "_builtin", e.g. "pedersen_builtin"
+	BuiltinInstanceCounter map[string]uint64 `json:"builtin_instance_counter"`
+}
+
+// ExecutionResources computes the resources of the last run from the usage of its
+// segments. The holes are the unknown cells of the segments other than the builtin
+// ones, which are filled by the builtins when proving. Only the builtins of the
+// program are counted, not the other builtins of the layout
+func (runner *Runner) ExecutionResources() ExecutionResources {
+	resources := ExecutionResources{
+		NSteps:                 runner.steps(),
+		BuiltinInstanceCounter: map[string]uint64{},
+	}
+	for _, segment := range runner.vm.Memory.Segments {
+		used := segment.Len()
+		if _, ok := segment.BuiltinRunner.(*mem.NoBuiltin); ok {
+			for offset := uint64(0); offset < used; offset++ {
+				if mv := segment.Peek(offset); !mv.Known() {
+					resources.NMemoryHoles++
+				}
+			}
+			continue
+		}
+		if !slices.Contains(runner.program.Builtins, builtins.BuiltinTypeFromName(segment.BuiltinRunner.String())) {
+			continue
+		}
+		instances := used
+		// the output builtin has no instances, each of its cells counts as one
+		if cellsPerInstance := segment.BuiltinRunner.GetCellsPerInstance(); cellsPerInstance != 0 {
+			instances = (used + cellsPerInstance - 1) / cellsPerInstance
+		}
+		resources.BuiltinInstanceCounter[segment.BuiltinRunner.String()+"_builtin"] += instances
+	}
+	return resources
+}
+
+// ECDSAKeyCacheStats sums the public key cache stats of the ECDSA segments of the
+// last run, and returns false if the run has no such segment
+func (runner *Runner) ECDSAKeyCacheStats() (builtins.KeyCacheStats, bool) {
+	segments := runner.vm.Memory.FindSegmentsWithBuiltin(builtins.ECDSAName)
+	var stats builtins.KeyCacheStats
+	for _, segment := range segments {
+		if ecdsa, ok := segment.BuiltinRunner.(*builtins.ECDSA); ok {
+			stats = stats.Add(ecdsa.KeyCacheStats())
+		}
+	}
+	return stats, len(segments) > 0
+}
+
+// ReleaseMemory gives the segments storage back for reuse by future runs. It must
+// only be called once the artifacts of the run are no longer needed, including the
+// relocated memory returned by BuildMemory
+func (runner *Runner) ReleaseMemory() {
+	runner.vm.Memory.Release()
+}
+
 func (runner *Runner) pc() mem.MemoryAddress {
 	return runner.vm.Context.Pc
 }
@@ -607,6 +1275,10 @@ func (runner *Runner) Output() []*fp.Element {
 }
 
 func (runner *Runner) RelocateTemporarySegments() error {
+	if runner.writeHistory != nil {
+		runner.writeHistory.relocating = true
+		defer func() { runner.writeHistory.relocating = false }()
+	}
 	if err := runner.vm.Memory.RelocateTemporarySegments(); err != nil {
 		return err
 	}
@@ -627,6 +1299,20 @@ func (ctx *InlineCasmContext) AddInlineCASM(code string) {
 	ctx.currentCodeOffset += int(total_size)
 }
 
+// Order in which the entry code passes the builtins it initializes, the order of
+// the builtins in the layouts. The output builtin comes first in proof mode, while
+// the gas builtin and the segment arena aren't builtin segments of the layout
+var entryCodeBuiltinsOrder = []builtins.BuiltinType{
+	builtins.PedersenType,
+	builtins.RangeCheckType,
+	builtins.BitwiseType,
+	builtins.ECOPType,
+	builtins.PoseidonType,
+	builtins.RangeCheck96Type,
+	builtins.AddModeType,
+	builtins.MulModType,
+}
+
 // Function derived from the cairo-lang-runner crate.
 // https://github.com/starkware-libs/cairo/blob/40a7b60687682238f7f71ef7c59c986cc5733915/crates/cairo-lang-runner/src/lib.rs#L703
 // / Returns the instructions to add to the beginning of the code to successfully call the main
@@ -647,16 +1333,10 @@ func GetEntryCodeInstructions(function starknet.EntryPointByFunction, proofmode
 		}
 	}
 
-	for _, builtin := range []builtins.BuiltinType{
-		builtins.MulModType,
-		builtins.AddModeType,
-		builtins.RangeCheck96Type,
-		builtins.PoseidonType,
-		builtins.ECOPType,
-		builtins.BitwiseType,
-		builtins.RangeCheckType,
-		builtins.PedersenType,
-	} {
+	// visiting the builtins in reverse layout order gives the first one the highest
+	// fp offset, and leaves programBuiltins in layout order
+	for i := len(entryCodeBuiltinsOrder) - 1; i >= 0; i-- {
+		builtin := entryCodeBuiltinsOrder[i]
 		if slices.Contains(function.Builtins, builtin) {
 			builtinsOffsetsMap[builtin] = builtinOffset
 			builtinOffset += 1
@@ -684,7 +1364,7 @@ func GetEntryCodeInstructions(function starknet.EntryPointByFunction, proofmode
 
 	if gotSegmentArena {
 		hints[uint64(ctx.currentCodeOffset)] = append(hints[uint64(ctx.currentCodeOffset)], []hinter.Hinter{
-			&core.AllocSegment{
+			&core.AllocSegmentArena{
 				Dst: hinter.ApCellRef(0),
 			},
 			&core.AllocSegment{
@@ -746,10 +1426,12 @@ func GetEntryCodeInstructions(function starknet.EntryPointByFunction, proofmode
 		adjustedRetOffset += retArgs.Size
 	}
 
-	// builtins have to be ordered by the highest id to generate proper offsets
-	slices.Reverse(function.Builtins)
+	// builtins have to be ordered by the highest id to generate proper offsets. The
+	// function builtins are cloned since they are part of the caller's program
+	reversedBuiltins := slices.Clone(function.Builtins)
+	slices.Reverse(reversedBuiltins)
 
-	for _, builtin := range function.Builtins {
+	for _, builtin := range reversedBuiltins {
 		adjustedRetOffset += 1
 		if _, ok := builtinsOffsetsMap[builtin]; ok {
 			builtinsOffsetsMap[builtin] = adjustedRetOffset
@@ -898,11 +1580,13 @@ func GetFooterInstructions() []*fp.Element {
 	return []*fp.Element{new(fp.Element).SetUint64(2345108766317314046)}
 }
 
+// FinalizeBuiltins checks the final builtin pointers a Cairo 1 program leaves on
+// top of the stack in proof mode, after the entry code copied them there, and sets
+// them as the stop pointers of the builtins. In the proof mode of Cairo Zero they
+// are the ones main returns before the end loop. It fails with the name of the
+// builtin whose pointer doesn't match the cells used by the builtin.
 func (runner *Runner) FinalizeBuiltins() error {
-	// Finalization of builtins is done only in proofmode with air public input
-	// It could also be implemented in execution mode, if cairo pie output was
-	// implemented.
-	if runner.runnerMode == ProofModeCairo {
+	if runner.isProofMode() {
 		builtinNameToStackPointer := map[builtins.BuiltinType]uint64{}
 		for i, builtin := range runner.program.Builtins {
 			builtinNameToStackPointer[builtin] = runner.vm.Context.Ap - uint64(len(runner.program.Builtins)-i-1)
@@ -912,6 +1596,26 @@ func (runner *Runner) FinalizeBuiltins() error {
 			return err
 		}
 	}
+	// the entry code of the proof mode squashes the dictionaries left by main, while in
+	// execution mode main may return them unsquashed
+	if runner.runnerMode == ProofModeCairo {
+		return runner.checkSegmentArena()
+	}
+	return nil
+}
+
+// checkSegmentArena verifies that every dictionary allocated in the segment arenas was
+// squashed by the end of the run
+func (runner *Runner) checkSegmentArena() error {
+	for _, arenaSegment := range runner.vm.Memory.FindSegmentsWithBuiltin(builtins.SegmentArenaName) {
+		arena, ok := arenaSegment.BuiltinRunner.(*builtins.SegmentArena)
+		if !ok {
+			continue
+		}
+		if err := arena.CheckSquashed(runner.vm.Memory, arenaSegment); err != nil {
+			return fmt.Errorf("segment arena: %w", err)
+		}
+	}
 	return nil
 }
 
@@ -943,20 +1647,42 @@ func CheckOnlyArrayFeltInputAndReturntValue(mainFunc starknet.EntryPointByFuncti
 	return nil
 }
 
+// GetAirMemorySegmentsAddresses gives the relocated begin address and stop pointer
+// of every builtin segment, for the memory segments of the AIR public input. The
+// prover knows a single segment per builtin, whose stop pointer must be set by
+// FinalizeBuiltins when the builtin is used and cannot go past the segment
 func (runner *Runner) GetAirMemorySegmentsAddresses() (map[string]AirMemorySegmentEntry, error) {
 	segmentsOffsets, _ := runner.vm.Memory.RelocationOffsets()
 	memorySegmentsAddresses := make(map[string]AirMemorySegmentEntry)
 	for segmentIndex, segment := range runner.vm.Memory.Segments {
-		if segment.BuiltinRunner.String() == "no builtin" {
+		// the segment arena isn't a builtin of the layouts, the prover doesn't know it
+		name := segment.BuiltinRunner.String()
+		if name == "no builtin" || name == builtins.SegmentArenaName {
 			continue
 		}
-		if segmentIndex >= len(segmentsOffsets) {
+		if segmentIndex+1 >= len(segmentsOffsets) {
 			return nil, fmt.Errorf("segment index %d not found in segments offsets", segmentIndex)
 		}
-		bRunner := segment.BuiltinRunner
-		stopPtr := bRunner.GetStopPointer()
+		if _, ok := memorySegmentsAddresses[name]; ok {
+			return nil, fmt.Errorf("builtin %s: the public input has room for a single segment per builtin", name)
+		}
+		// FinalizeBuiltins checks that the stop pointers match the cells used
+		stopPtr := segment.BuiltinRunner.GetStopPointer()
+		if stopPtr == 0 && slices.ContainsFunc(segment.Data, func(mv mem.MemoryValue) bool { return mv.Known() }) {
+			return nil, fmt.Errorf("builtin %s: the segment is used but has no stop pointer, the final builtin pointers must be read by FinalizeBuiltins", name)
+		}
 		baseOffset := segmentsOffsets[segmentIndex]
-		memorySegmentsAddresses[bRunner.String()] = AirMemorySegmentEntry{BeginAddr: baseOffset, StopPtr: baseOffset + stopPtr}
+		if size := segmentsOffsets[segmentIndex+1] - baseOffset; stopPtr > size {
+			return nil, fmt.Errorf("builtin %s: stop pointer %d is past the end of the segment of size %d", name, stopPtr, size)
+		}
+		memorySegmentsAddresses[name] = AirMemorySegmentEntry{BeginAddr: baseOffset, StopPtr: baseOffset + stopPtr}
+	}
+	for _, bRunner := range runner.layout.Builtins {
+		if name := bRunner.Runner.String(); runner.isProofMode() {
+			if _, ok := memorySegmentsAddresses[name]; !ok {
+				return nil, fmt.Errorf("builtin %s of layout %s has no segment", name, runner.layout.Name)
+			}
+		}
 	}
 	return memorySegmentsAddresses, nil
 }
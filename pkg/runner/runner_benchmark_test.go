@@ -2,6 +2,7 @@ package runner
 
 import (
 	"math"
+	"runtime"
 	"testing"
 
 	hintrunner "github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/zero"
@@ -216,6 +217,10 @@ func BenchmarkRunnerWithFibonacci(b *testing.B) {
         }
     `)
 
+	var before runtime.MemStats
+	runtime.ReadMemStats(&before)
+
+	b.ReportAllocs()
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		cairoZeroJson, err := zero.ZeroProgramFromJSON(compiledJson)
@@ -243,4 +248,12 @@ func BenchmarkRunnerWithFibonacci(b *testing.B) {
 			panic(err)
 		}
 	}
+	b.StopTimer()
+
+	// the run is about 10M steps long, which makes GC pauses caused by
+	// allocations in the step loop visible
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+	b.ReportMetric(float64(after.NumGC-before.NumGC)/float64(b.N), "gc/op")
 }
@@ -1,12 +1,21 @@
 package runner
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/assembler"
+	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/core"
 	"github.com/NethermindEth/cairo-vm-go/pkg/hintrunner/hinter"
+	"github.com/NethermindEth/cairo-vm-go/pkg/parsers/starknet"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
@@ -114,6 +123,98 @@ func TestStepLimitExceeded(t *testing.T) {
 	assert.Equal(t, uint64(3), runner.steps())
 }
 
+func TestPresetMemory(t *testing.T) {
+	program := createProgram(`
+        [ap] = [fp + 8], ap++;
+        [ap] = [[fp + 9]], ap++;
+        ret;
+    `)
+
+	hints := make(map[uint64][]hinter.Hinter)
+	runner, err := NewRunner(program, hints, ExecutionModeZero, false, math.MaxUint64, "plain", nil, 0)
+	require.NoError(t, err)
+
+	// segments 0 to 3 are the program, execution, return fp and end pc segments,
+	// segment 5 doesn't exist yet and must be allocated
+	presetAddress := memory.MemoryAddress{SegmentIndex: 5, Offset: 2}
+	require.NoError(t, runner.PresetMemory([]PresetCell{
+		{Address: memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 10}, Value: memory.MemoryValueFromInt(7)},
+		{Address: memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 11}, Value: memory.MemoryValueFromMemoryAddress(&presetAddress)},
+		{Address: presetAddress, Value: memory.MemoryValueFromInt(42)},
+	}))
+
+	require.NoError(t, runner.Run())
+
+	executionSegment := runner.vm.Memory.Segments[vm.ExecutionSegment]
+	assert.Equal(t, memory.MemoryValueFromInt(7), executionSegment.Peek(2))
+	assert.Equal(t, memory.MemoryValueFromInt(42), executionSegment.Peek(3))
+	assert.Len(t, runner.vm.Memory.Segments, 6)
+
+	require.ErrorContains(t, runner.PresetMemory(nil), "once the run has started")
+}
+
+func TestPresetMemoryConflict(t *testing.T) {
+	program := createProgram("ret;")
+
+	hints := make(map[uint64][]hinter.Hinter)
+	runner, err := NewRunner(program, hints, ExecutionModeZero, false, math.MaxUint64, "plain", nil, 0)
+	require.NoError(t, err)
+
+	// the first cell of the execution segment holds the return fp
+	require.NoError(t, runner.PresetMemory([]PresetCell{
+		{Address: memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 0}, Value: memory.MemoryValueFromInt(1)},
+	}))
+	require.ErrorContains(t, runner.Run(), "preset cell 1:0")
+}
+
+func TestPresetTrustedSegment(t *testing.T) {
+	program := createProgram(`
+        [ap] = [[fp + 8]], ap++;
+        ret;
+    `)
+
+	hints := make(map[uint64][]hinter.Hinter)
+	runner, err := NewRunner(program, hints, ExecutionModeZero, false, math.MaxUint64, "plain", nil, 0)
+	require.NoError(t, err)
+
+	blobAddress := memory.MemoryAddress{SegmentIndex: 5, Offset: 1}
+	require.NoError(t, runner.PresetTrustedSegment(5, []memory.MemoryValue{memory.MemoryValueFromInt(1), memory.MemoryValueFromInt(2)}))
+	require.ErrorContains(t, runner.PresetTrustedSegment(5, nil), "already preset")
+	require.NoError(t, runner.PresetMemory([]PresetCell{
+		{Address: memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 10}, Value: memory.MemoryValueFromMemoryAddress(&blobAddress)},
+	}))
+
+	require.NoError(t, runner.Run())
+	assert.Equal(t, memory.MemoryValueFromInt(2), runner.vm.Memory.Segments[vm.ExecutionSegment].Peek(2))
+	assert.Len(t, runner.vm.Memory.Segments, 6)
+	require.ErrorContains(t, runner.PresetTrustedSegment(6, nil), "once the run has started")
+
+	// the execution segment is allocated by the runner
+	runner, err = NewRunner(program, hints, ExecutionModeZero, false, math.MaxUint64, "plain", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.PresetTrustedSegment(vm.ExecutionSegment, nil))
+	require.ErrorContains(t, runner.Run(), "preset segment 1: already allocated by the runner")
+
+	// the program can still write to a preset segment when the memory is relocated
+	// eagerly, growing it included
+	program = createProgram(`
+        [ap] = 7, ap++;
+        [ap - 1] = [[fp + 8]];
+        ret;
+    `)
+	runner, err = NewRunner(program, hints, ExecutionModeZero, false, math.MaxUint64, "plain", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.PresetTrustedSegment(5, []memory.MemoryValue{memory.MemoryValueFromInt(1)}))
+	require.NoError(t, runner.PresetMemory([]PresetCell{
+		{Address: memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 10}, Value: memory.MemoryValueFromMemoryAddress(&blobAddress)},
+	}))
+	require.NoError(t, runner.RelocateEagerly())
+	require.NoError(t, runner.Run())
+	assert.False(t, runner.vm.Memory.Segments[5].Frozen())
+	relocated, offsets := runner.BuildMemory()
+	assert.Equal(t, uint64(7), relocated[offsets[5]+1].Uint64())
+}
+
 func TestStepLimitExceededProofMode(t *testing.T) {
 	program := createProgram(`
         [ap] = 2;
@@ -231,6 +332,101 @@ func TestBitwiseBuiltinError(t *testing.T) {
 	require.ErrorContains(t, err, "input value at offset 0 is unknown")
 }
 
+func TestRunSecurityChecks(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2];
+        [ap - 1] = [[fp - 3] + 6];
+        ret;
+    `, "starknet_with_keccak", builtins.BitwiseType)
+	require.NoError(t, runner.Run())
+	require.EqualError(t, runner.RunSecurityChecks(), "builtin bitwise: missing input cells at offsets [5]")
+}
+
+func TestExecutionResources(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2];
+        [ap - 1] = [[fp - 3] + 5];
+        [ap - 1] = [[fp - 3] + 6];
+        ret;
+    `, "starknet_with_keccak", builtins.BitwiseType)
+	require.NoError(t, runner.Run())
+
+	resources := runner.ExecutionResources()
+	require.Equal(t, uint64(8), resources.NSteps)
+	require.Equal(t, map[string]uint64{"bitwise_builtin": 2}, resources.BuiltinInstanceCounter)
+	require.Zero(t, resources.NMemoryHoles)
+}
+
+func TestGetAirPrivateInput(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        ret;
+    `, "starknet_with_keccak", builtins.BitwiseType)
+	require.NoError(t, runner.Run())
+
+	input, err := runner.GetAirPrivateInput("trace.bin", "memory.bin")
+	require.NoError(t, err)
+	names := []string{}
+	for _, builtin := range input.Builtins {
+		names = append(names, builtin.Name)
+	}
+	// the layout order, without the output builtin
+	require.Equal(t, []string{"pedersen", "range_check", "ecdsa", "bitwise", "ec_op", "keccak", "poseidon"}, names)
+	require.Equal(t, []builtins.AirPrivateBuiltinBitwise{{Index: 0, X: "0xe", Y: "0x7"}}, input.Input("bitwise"))
+	require.Nil(t, input.Input("add_mod"))
+
+	json, err := input.MarshalJSON()
+	require.NoError(t, err)
+	require.Equal(t,
+		`{"trace_path":"trace.bin","memory_path":"memory.bin","pedersen":[],"range_check":[],"ecdsa":[],`+
+			`"bitwise":[{"index":0,"x":"0xe","y":"0x7"}],"ec_op":[],"keccak":[],"poseidon":[]}`,
+		string(json),
+	)
+}
+
+func TestEncodeAirPrivateInput(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 14, ap++;
+        [ap] = 7, ap++;
+        [ap - 2] = [[fp - 4]];
+        [ap - 1] = [[fp - 4] + 1];
+        [ap - 2] = [[fp - 4] + 5];
+        [ap - 1] = [[fp - 4] + 6];
+        [ap - 1] = [[fp - 5]];
+        ret;
+    `, "all_cairo", builtins.RangeCheckType, builtins.BitwiseType, builtins.AddModeType)
+	require.NoError(t, runner.Run())
+
+	input, err := runner.GetAirPrivateInput("trace.bin", "memory.bin")
+	require.NoError(t, err)
+	require.Equal(t, []builtins.AirPrivateBuiltinRangeCheck{{Index: 0, Value: "0x7"}}, input.Input("range_check"))
+	require.Equal(t, []builtins.AirPrivateBuiltinBitwise{{Index: 0, X: "0xe", Y: "0x7"}, {Index: 1, X: "0xe", Y: "0x7"}}, input.Input("bitwise"))
+	expected, err := json.MarshalIndent(input, "", "  ")
+	require.NoError(t, err)
+
+	// streamed to the same json as the built private input
+	var encoded bytes.Buffer
+	require.NoError(t, runner.EncodeAirPrivateInput(&encoded, "trace.bin", "memory.bin"))
+	require.Equal(t, string(expected), encoded.String())
+
+	location := filepath.Join(t.TempDir(), "air_private_input.json")
+	require.NoError(t, runner.WriteAirPrivateInput(location, "trace.bin", "memory.bin"))
+	written, err := os.ReadFile(location)
+	require.NoError(t, err)
+	require.Equal(t, string(expected), string(written))
+}
+
 func TestOutputBuiltin(t *testing.T) {
 	// Output builtin is located at fp - 3
 	runner := createRunner(`
@@ -250,6 +446,384 @@ func TestOutputBuiltin(t *testing.T) {
 	require.Equal(t, []*fp.Element{&val1, &val2}, output)
 }
 
+func TestAirPublicInputOutputPages(t *testing.T) {
+	// writes 4 values to the output, the last 3 in pages 1 and 2
+	program := fuzzProgram([]byte{4, 4, 4, 4})
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.Run())
+	require.NoError(t, runner.EndRun())
+
+	output, ok := runner.outputRunner()
+	require.True(t, ok)
+	require.NoError(t, output.AddPage(2, 3, 1))
+	require.NoError(t, output.AddPage(1, 1, 2))
+	output.AddAttribute("gps_fact_topology", []uint64{2, 1, 0, 2})
+	require.NoError(t, runner.FinalizeBuiltins())
+	require.NoError(t, runner.FinalizeSegments())
+
+	relocatedMemory, segmentsOffsets := runner.BuildMemory()
+	airPublicInput, err := runner.GetAirPublicInput(relocatedMemory, runner.GetPublicMemoryAddresses(segmentsOffsets))
+	require.NoError(t, err)
+
+	outputBase := airPublicInput.MemorySegments[builtins.OutputName].BeginAddr
+	require.Equal(t, []AirOutputPage{
+		{ID: 1, BeginAddr: outputBase + 1, Size: 2},
+		{ID: 2, BeginAddr: outputBase + 3, Size: 1},
+	}, airPublicInput.OutputPages)
+	require.Equal(t, map[string][]uint64{"gps_fact_topology": {2, 1, 0, 2}}, airPublicInput.OutputAttributes)
+
+	pages := map[uint64]uint16{}
+	for _, entry := range airPublicInput.PublicMemory {
+		if uint64(entry.Address) >= outputBase && uint64(entry.Address) < outputBase+4 {
+			pages[uint64(entry.Address)-outputBase] = entry.Page
+		}
+	}
+	require.Equal(t, map[uint64]uint16{0: 0, 1: 1, 2: 1, 3: 2}, pages)
+}
+
+func TestAirPublicInputMemorySegments(t *testing.T) {
+	program := fuzzProgram([]byte{4, 4, 4, 4})
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.Run())
+	require.NoError(t, runner.EndRun())
+	require.NoError(t, runner.FinalizeSegments())
+
+	// the stop pointers are read from the stack
+	relocatedMemory, segmentsOffsets := runner.BuildMemory()
+	_, err = runner.GetAirPublicInput(relocatedMemory, runner.GetPublicMemoryAddresses(segmentsOffsets))
+	require.EqualError(t, err, "builtin output: the segment is used but has no stop pointer, the final builtin pointers must be read by FinalizeBuiltins")
+	require.NoError(t, runner.FinalizeBuiltins())
+	airPublicInput, err := runner.GetAirPublicInput(relocatedMemory, runner.GetPublicMemoryAddresses(segmentsOffsets))
+	require.NoError(t, err)
+
+	// every builtin of the layout has a segment, the unused ones being empty
+	memorySegments := airPublicInput.MemorySegments
+	require.Len(t, memorySegments, 2+len(runner.layout.Builtins))
+	for _, bRunner := range runner.layout.Builtins {
+		segment, ok := memorySegments[bRunner.Runner.String()]
+		require.True(t, ok)
+		if bRunner.Builtin == builtins.OutputType {
+			require.Equal(t, segment.BeginAddr+4, segment.StopPtr)
+		} else {
+			require.Equal(t, segment.BeginAddr, segment.StopPtr)
+		}
+	}
+}
+
+func TestCheckAirMemorySegments(t *testing.T) {
+	memorySegments := map[string]AirMemorySegmentEntry{
+		"program":     {BeginAddr: 1, StopPtr: 5},
+		"execution":   {BeginAddr: 12, StopPtr: 40},
+		"output":      {BeginAddr: 50, StopPtr: 50},
+		"range_check": {BeginAddr: 50, StopPtr: 60},
+	}
+	require.NoError(t, checkAirMemorySegments(memorySegments))
+
+	memorySegments["execution"] = AirMemorySegmentEntry{BeginAddr: 12, StopPtr: 51}
+	require.EqualError(t, checkAirMemorySegments(memorySegments), "memory segment execution: stop pointer 51 is past the begin address 50 of memory segment output")
+	memorySegments["execution"] = AirMemorySegmentEntry{BeginAddr: 12, StopPtr: 11}
+	require.EqualError(t, checkAirMemorySegments(memorySegments), "memory segment execution: stop pointer 11 is below its begin address 12")
+}
+
+func TestRelocateEagerly(t *testing.T) {
+	program := fuzzProgram([]byte{1, 2, 4, 3, 4, 0, 1, 4})
+	buildMemory := func(eager bool) []*fp.Element {
+		runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+		require.NoError(t, err)
+		if eager {
+			require.NoError(t, runner.RelocateEagerly())
+		}
+		require.NoError(t, runner.Run())
+		require.ErrorContains(t, runner.RelocateEagerly(), "cannot relocate eagerly once the run has started")
+		require.NoError(t, runner.EndRun())
+		require.NoError(t, runner.FinalizeSegments())
+		require.Equal(t, eager, runner.vm.Memory.Segments[vm.ProgramSegment].Frozen())
+		memory, _ := runner.BuildMemory()
+		return memory
+	}
+	require.Equal(t, buildMemory(false), buildMemory(true))
+}
+
+func TestEnablePedersenTables(t *testing.T) {
+	runner := createRunner(`
+        [ap] = 1, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = 2, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2], ap++;
+        [ap] = [fp - 3] + 3, ap++;
+        ret;
+    `, "small", builtins.PedersenType)
+	require.NoError(t, runner.EnablePedersenTables())
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.EnablePedersenTables(), "cannot enable the Pedersen tables once the run has started")
+
+	segment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtins.PedersenName)
+	require.True(t, ok)
+	hash, err := new(fp.Element).SetString("0x5bb9440e27889a364bcb678b1f679ecd1347acdedcbf36e83494f857cc58026")
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromFieldElement(hash), segment.Data[2])
+}
+
+func TestPadBuiltins(t *testing.T) {
+	// main only reads the and cell of its bitwise instance
+	program := createProgramWithBuiltins(`
+        ap += 1;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 12, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = 10, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2], ap++;
+        [ap] = [fp - 3] + 5, ap++;
+        ret;
+    `, builtins.BitwiseType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+
+	bitwiseCells := func(pad bool, inject bool) []memory.MemoryValue {
+		runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "recursive", nil, 0)
+		require.NoError(t, err)
+		if pad {
+			require.NoError(t, runner.PadBuiltins())
+		}
+		// the failure is injected into an instance the run doesn't reach
+		if inject {
+			require.NoError(t, runner.InjectBuiltinFailure(builtins.BitwiseName, 7, errors.New("injected failure")))
+		}
+		require.NoError(t, runner.Run())
+		require.ErrorContains(t, runner.PadBuiltins(), "cannot pad the builtins once the run has started")
+		require.NoError(t, runner.EndRun())
+		require.NoError(t, runner.FinalizeSegments())
+		segment, ok := runner.vm.Memory.FindSegmentWithBuiltin(builtins.BitwiseName)
+		require.True(t, ok)
+		return segment.Data[:5]
+	}
+
+	unpadded := bitwiseCells(false, false)
+	require.Equal(t, memory.MemoryValueFromUint(uint64(8)), unpadded[2])
+	require.False(t, unpadded[3].Known())
+	require.False(t, unpadded[4].Known())
+
+	// the builtin is padded as well when a failure is injected into it
+	for _, inject := range []bool{false, true} {
+		padded := bitwiseCells(true, inject)
+		for cell, value := range []uint64{12, 10, 8, 6, 14} {
+			require.Equal(t, memory.MemoryValueFromUint(value), padded[cell], "inject %t", inject)
+		}
+	}
+}
+
+func TestEndRunIncompleteECDSAInstance(t *testing.T) {
+	// main only writes the message of its ecdsa instance
+	program := createProgramWithBuiltins(`
+        ap += 1;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 2718, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [fp - 3] + 2, ap++;
+        ret;
+    `, builtins.ECDSAType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.Run())
+	require.EqualError(t, runner.EndRun(), "ecdsa instance 0: message 2718 is written without a public key (both cells of an instance must be written before the run ends)")
+}
+
+func TestFinalizeAdditionalECDSASegment(t *testing.T) {
+	// main writes the first ecdsa instance
+	program := createProgramWithBuiltins(`
+        ap += 1;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 2718, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = 1735102664668487605176656616876767369909409133946409161569774794110049207117, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = [fp - 3] + 2, ap++;
+        ret;
+    `, builtins.ECDSAType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.NoError(t, runner.AddECDSASignature(0, 0, r, s))
+	require.NoError(t, runner.Run())
+
+	// a second segment holding two instances
+	addr, err := builtins.AllocateAdditionalSegment(runner.vm.Memory, builtins.ECDSAName)
+	require.NoError(t, err)
+	second := runner.vm.Memory.Segments[addr.SegmentIndex]
+	ecdsa := second.BuiltinRunner.(*builtins.ECDSA)
+	msgValue := memory.MemoryValueFromUint(uint64(2718))
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	for offset := uint64(0); offset < 4; offset += 2 {
+		require.NoError(t, ecdsa.AddSignature(offset, r, s))
+		require.NoError(t, second.Write(offset+1, &msgValue))
+		require.NoError(t, second.Write(offset, &pubkeyValue))
+	}
+
+	require.Equal(t, uint64(6), runner.builtinUsedCells()[builtins.ECDSAName])
+	require.NoError(t, runner.EndRun())
+	require.NoError(t, runner.FinalizeSegments())
+	// both segments are finalized to the size allocated by the layout
+	var layoutRunner memory.BuiltinRunner
+	for _, bRunner := range runner.layout.Builtins {
+		if bRunner.Builtin == builtins.ECDSAType {
+			layoutRunner = bRunner.Runner
+		}
+	}
+	segments := runner.vm.Memory.FindSegmentsWithBuiltin(builtins.ECDSAName)
+	require.Len(t, segments, 2)
+	for _, segment := range segments {
+		size, err := layoutRunner.GetAllocatedSize(segment.Len(), runner.vm.Step)
+		require.NoError(t, err)
+		require.Equal(t, size, segment.Len())
+	}
+	// the 4 cells written to the second segment are padded to its allocation
+	require.Greater(t, segments[1].Len(), uint64(4))
+}
+
+func TestAdditionalBuiltinSegments(t *testing.T) {
+	runner := createRunner(`ret;`, "all_cairo")
+	require.NoError(t, runner.Run())
+	mem := runner.vm.Memory
+	felt := memory.MemoryValueFromInt(11)
+
+	// the mod instances of the second segment are checked
+	require.NoError(t, runner.checkModBuiltins())
+	mulMod, err := builtins.AllocateAdditionalSegment(mem, "MulMod")
+	require.NoError(t, err)
+	require.NoError(t, mem.Write(mulMod.SegmentIndex, 0, &felt))
+	require.ErrorContains(t, runner.checkModBuiltins(), "MulMod instance 0")
+
+	// and so are the dictionaries of the second segment arena
+	mem.AllocateBuiltinSegment(&builtins.SegmentArena{})
+	arena := mem.AllocateBuiltinSegment(&builtins.SegmentArena{})
+	info := mem.AllocateEmptySegment()
+	for offset, value := range []memory.MemoryValue{memory.MemoryValueFromMemoryAddress(&info), memory.MemoryValueFromInt(1), memory.MemoryValueFromInt(0)} {
+		require.NoError(t, mem.Write(arena.SegmentIndex, uint64(offset), &value))
+	}
+	require.EqualError(t, runner.checkSegmentArena(), "segment arena: 1 dictionaries allocated but 0 squashed")
+
+	// signatures go to the ecdsa segment they are added to
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	ecdsa, err := builtins.AllocateAdditionalSegment(mem, builtins.ECDSAName)
+	require.NoError(t, err)
+	require.NoError(t, runner.AddECDSASignature(1, 0, r, s))
+	require.EqualError(t, runner.AddECDSASignature(2, 0, r, s), "ecdsa segment 2: the run has 2 ecdsa segments")
+	segments := mem.FindSegmentsWithBuiltin(builtins.ECDSAName)
+	require.Empty(t, segments[0].BuiltinRunner.(*builtins.ECDSA).Signatures)
+	require.Len(t, segments[1].BuiltinRunner.(*builtins.ECDSA).Signatures, 1)
+	require.Equal(t, mem.Segments[ecdsa.SegmentIndex], segments[1])
+
+	runner = createRunner(`ret;`, "all_cairo")
+	require.EqualError(t, runner.AddECDSASignature(1, 0, r, s), "ecdsa segment 1: only the segment allocated by the runner can get signatures before the run")
+}
+
+func TestDeduceDynamicRatios(t *testing.T) {
+	program := createProgramWithBuiltins(`
+        ap += 1;
+        call rel 4;
+        jmp rel 0;
+        [ap] = 12, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = 10, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2], ap++;
+        [ap] = [fp - 3] + 5, ap++;
+        ret;
+    `, builtins.BitwiseType)
+	program.Entrypoints["main"] = fuzzMainPc
+	program.Labels = map[string]uint64{"__start__": fuzzStartPc, "__end__": fuzzEndPc}
+
+	runner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.ErrorContains(t, runner.DeduceDynamicRatios(), "cannot deduce the ratios of layout small")
+
+	// a single instance for every step
+	params := builtins.DynamicLayoutParams{RcUnits: 4, UsesBitwiseBuiltin: true, BitwiseRatio: 1}
+	layout, err := params.Layout()
+	require.NoError(t, err)
+	require.NoError(t, runner.SetLayout(layout))
+	require.NoError(t, runner.DeduceDynamicRatios())
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.DeduceDynamicRatios(), "cannot deduce the ratios once the run has started")
+	require.NoError(t, runner.EndRun())
+
+	// the bitwise instance of the program takes the whole trace
+	dynamicParams := runner.layout.DynamicParams
+	require.Equal(t, runner.steps(), dynamicParams.BitwiseRatio)
+	require.Equal(t, uint64(1), params.BitwiseRatio)
+	allocated, err := runner.layout.Builtins[1].Runner.GetAllocatedSize(0, runner.steps())
+	require.NoError(t, err)
+	require.Equal(t, uint64(5), allocated)
+}
+
+func TestLayoutBuiltinModes(t *testing.T) {
+	layoutWith := func(builtin builtins.BuiltinType, mode memory.BuiltinMode) builtins.Layout {
+		definition := builtins.LayoutDefinition{
+			Name:     "modes",
+			RcUnits:  4,
+			Builtins: []builtins.LayoutBuiltinDefinition{{Builtin: builtin, Ratio: 8, Mode: mode}},
+		}
+		layout, err := definition.Build()
+		require.NoError(t, err)
+		return layout
+	}
+
+	// 2**128 is out of the range check bounds
+	rangeCheckCode := `
+        [ap] = 340282366920938463463374607431768211456, ap++;
+        [ap - 1] = [[fp - 3]];
+        ret;
+    `
+	runner := createRunner(rangeCheckCode, "small", builtins.RangeCheckType)
+	require.NoError(t, runner.SetLayout(layoutWith(builtins.RangeCheckType, memory.ValidateAndDeduce)))
+	require.ErrorContains(t, runner.Run(), "range_check")
+
+	runner = createRunner(rangeCheckCode, "small", builtins.RangeCheckType)
+	require.NoError(t, runner.SetLayout(layoutWith(builtins.RangeCheckType, memory.DeduceOnly)))
+	require.NoError(t, runner.Run())
+
+	pedersenCode := `
+        [ap] = 5, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [[fp - 3] + 2], ap++;
+        ret;
+    `
+	runner = createRunner(pedersenCode, "small", builtins.PedersenType)
+	require.NoError(t, runner.SetLayout(layoutWith(builtins.PedersenType, memory.ValidateAndDeduce)))
+	require.NoError(t, runner.Run())
+
+	runner = createRunner(pedersenCode, "small", builtins.PedersenType)
+	require.NoError(t, runner.SetLayout(layoutWith(builtins.PedersenType, memory.ValidateOnly)))
+	require.ErrorContains(t, runner.Run(), "deduction is disabled")
+
+	// the modes that leave the builtin unchecked are refused in proof mode, the others
+	// behave like the default one
+	program := createProgramWithBuiltins("ret;", builtins.PedersenType)
+	proofRunner, err := NewRunner(program, map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "small", nil, 0)
+	require.NoError(t, err)
+	require.ErrorContains(t, proofRunner.SetLayout(layoutWith(builtins.PedersenType, memory.ValidateOnly)), "layout modes: builtin pedersen: mode validate leaves the builtin values unchecked")
+	require.ErrorContains(t, proofRunner.SetLayout(layoutWith(builtins.RangeCheckType, memory.DeduceOnly)), "mode deduce leaves the builtin values unchecked")
+	require.NoError(t, proofRunner.SetLayout(layoutWith(builtins.PedersenType, memory.DeduceOnly)))
+	require.NoError(t, proofRunner.SetLayout(layoutWith(builtins.RangeCheckType, memory.ValidateOnly)))
+}
+
 func TestPedersenBuiltin(t *testing.T) {
 	val1 := fp.NewElement(5)
 	val2 := fp.NewElement(7)
@@ -319,6 +893,29 @@ func TestRangeCheckBuiltin(t *testing.T) {
 	requireEqualSegments(t, createSegment(nil, 5, felt), rangeCheck)
 }
 
+func TestPermRangeCheckLimits(t *testing.T) {
+	// without range checks, only the offsets of the instructions are used
+	runner := createRunner(`
+        [ap] = 5;
+        ret;
+    `, "all_cairo", builtins.RangeCheck96Type)
+	require.NoError(t, runner.Run())
+	rcMin, rcMax := runner.getPermRangeCheckLimits()
+	require.Equal(t, runner.vm.RcLimitsMin, rcMin)
+	require.Equal(t, runner.vm.RcLimitsMax, rcMax)
+
+	// the parts of the values written to the range check 96 are included
+	runner = createRunner(`
+        [ap] = 0xffff;
+        [ap] = [[fp - 3]];
+        ret;
+    `, "all_cairo", builtins.RangeCheck96Type)
+	require.NoError(t, runner.Run())
+	rcMin, rcMax = runner.getPermRangeCheckLimits()
+	require.Equal(t, uint16(0), rcMin)
+	require.Equal(t, uint16(0xffff), rcMax)
+}
+
 func TestRangeCheckBuiltinError(t *testing.T) {
 	// first test fails due to out of bound check
 	runner := createRunner(`
@@ -386,6 +983,36 @@ func TestRangeCheck96BuiltinError(t *testing.T) {
 	require.ErrorContains(t, err, "cannot infer value")
 }
 
+func TestAddECDSASignature(t *testing.T) {
+	// writes the message then the public key of the first ecdsa instance
+	code := `
+        [ap] = 2718, ap++;
+        [ap] = 1735102664668487605176656616876767369909409133946409161569774794110049207117, ap++;
+        [ap - 2] = [[fp - 3] + 1];
+        [ap - 1] = [[fp - 3]];
+        ret;
+    `
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+
+	runner := createRunner(code, "starknet", builtins.ECDSAType)
+	require.NoError(t, runner.AddECDSASignature(0, 0, r, s))
+	require.NoError(t, runner.Run())
+
+	runner = createRunner(code, "starknet", builtins.ECDSAType)
+	require.ErrorContains(t, runner.Run(), "signature is missing")
+
+	// signatures are verified once the run ends, with the offset of their instance
+	runner = createRunner(code, "starknet", builtins.ECDSAType)
+	require.NoError(t, runner.EnableDeferredECDSA())
+	require.NoError(t, runner.AddECDSASignature(0, 1, r, new(fp.Element).SetUint64(31231231313)))
+	require.EqualError(t, runner.Run(), "ecdsa instance at offset 0: signature is not valid")
+
+	runner = createRunner(code, "plain", builtins.OutputType)
+	require.NoError(t, runner.AddECDSASignature(0, 0, r, s))
+	require.EqualError(t, runner.Run(), "initializing main entry point: signatures were added but the run has no ecdsa builtin segment")
+}
+
 func TestEcOpBuiltin(t *testing.T) {
 	// first, store P.x, P.y, Q.x, Q.y and m in the data segment
 	// then store them the EcOp builtin segment
@@ -509,3 +1136,330 @@ func createProgramWithBuiltins(code string, builtins ...builtins.BuiltinType) *P
 	program.Builtins = builtins
 	return program
 }
+
+func TestNegativeConstants(t *testing.T) {
+	// Output builtin is located at fp - 3
+	runner := createRunner(`
+        [ap] = -1, ap++;
+        [ap] = [ap + -1] * -2, ap++;
+        [ap] = [ap - 1] + -7, ap++;
+        [ap + -1] = [[fp + -3]];
+        [ap - 3] = [[fp - 3] + 1];
+        ret;
+    `, "small", builtins.OutputType)
+	err := runner.Run()
+	require.NoError(t, err)
+
+	// main frame starts after the output pointer, the return fp and the return pc
+	executionSegment := runner.vm.Memory.Segments[vm.ExecutionSegment]
+	minusOne := fp.NewElement(1)
+	minusOne.Neg(&minusOne)
+	minusFive := fp.NewElement(5)
+	minusFive.Neg(&minusFive)
+	for i, expected := range []fp.Element{minusOne, fp.NewElement(2), minusFive} {
+		value := executionSegment.Peek(uint64(3 + i))
+		require.Equal(t, memory.MemoryValueFromFieldElement(&expected), value)
+	}
+
+	output := runner.Output()
+	require.Equal(t, []*fp.Element{&minusFive, &minusOne}, output)
+	require.Equal(t, "-5", utils.FormatFelt(output[0], utils.FeltFormatDefault))
+	require.Equal(t, "-1", utils.FormatFelt(output[1], utils.FeltFormatSigned))
+}
+
+func TestEntryCodeInstructions(t *testing.T) {
+	function := starknet.EntryPointByFunction{
+		Offset: 0,
+		// signature order differs from the layout order on purpose
+		Builtins:   []builtins.BuiltinType{builtins.RangeCheckType, builtins.PedersenType, builtins.SegmentArenaType},
+		ReturnArgs: []starknet.Arg{{Size: 1}},
+	}
+	code, hints, codeSize, programBuiltins, gotGasBuiltin, gotSegmentArena := GetEntryCodeInstructions(function, false)
+
+	// same instructions as the create_entry_code function of the rust runner
+	expected, _, err := assembler.CasmToBytecode(`
+		[ap + 2] = 0, ap++;
+		[ap + 0] = [[ap + -1]], ap++;
+		[ap + 0] = [[ap + -2] + 1], ap++;
+		[ap + -1] = [[ap + -3] + 2];
+		[ap + 0] = [fp + -3], ap++;
+		[ap + 0] = [fp + -4], ap++;
+		[ap + 0] = [ap + -5] + 3, ap++;
+		call rel 5;
+		[ap + 0] = [ap + -3], ap++;
+		[ap + 0] = [ap + -5], ap++;
+		ret;
+	`)
+	require.NoError(t, err)
+	require.Equal(t, expected, code)
+	require.Equal(t, len(expected), codeSize)
+
+	require.Equal(t, []builtins.BuiltinType{builtins.PedersenType, builtins.RangeCheckType}, programBuiltins)
+	require.False(t, gotGasBuiltin)
+	require.True(t, gotSegmentArena)
+	require.Equal(t, map[uint64][]hinter.Hinter{
+		0: {
+			&core.AllocSegmentArena{Dst: hinter.ApCellRef(0)},
+			&core.AllocSegment{Dst: hinter.ApCellRef(1)},
+		},
+	}, hints)
+
+	// the builtins of the program are left untouched, so generating the entry code
+	// again gives the same bytecode
+	require.Equal(t, []builtins.BuiltinType{builtins.RangeCheckType, builtins.PedersenType, builtins.SegmentArenaType}, function.Builtins)
+	again, _, _, _, _, _ := GetEntryCodeInstructions(function, false)
+	require.Equal(t, code, again)
+}
+
+func TestInvariants(t *testing.T) {
+	code := `
+        [ap] = 5, ap++;
+        [ap] = 6, ap++;
+        ret;
+    `
+	// main frame starts after the return fp and the return pc
+	firstCell := memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 2}
+
+	runner := createRunner(code, "plain")
+	runner.program.Labels = map[string]uint64{"second": 2}
+	require.NoError(t, runner.AddLabelInvariant("first cell", []string{"second"}, vm.CellEquals(firstCell, memory.MemoryValueFromUint(uint64(5)))))
+	require.ErrorContains(t, runner.AddLabelInvariant("unknown", []string{"third"}, vm.ApAtMost(0)), "invariant unknown: unknown label third")
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.AddInvariant(vm.Invariant{Name: "late", Check: vm.ApAtMost(0)}), "once the run has started")
+
+	runner = createRunner(code, "plain")
+	require.NoError(t, runner.AddInvariant(vm.Invariant{Name: "small stack", Check: vm.ApAtMost(3)}))
+	err := runner.Run()
+	require.ErrorContains(t, err, "invariant small stack violated with ap 4 and fp 2: ap is 4, above 3")
+	var violation *vm.InvariantViolation
+	require.ErrorAs(t, err, &violation)
+	require.Equal(t, uint64(2), violation.Step)
+	require.Equal(t, uint64(4), violation.Context.Pc.Offset)
+}
+
+func TestInjectBuiltinFailure(t *testing.T) {
+	code := `
+        [ap] = 7, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = 8, ap++;
+        [ap - 1] = [[fp - 3] + 1];
+        [ap] = [fp - 3] + 2, ap++;
+        ret;
+    `
+	injected := errors.New("injected failure")
+
+	runner := createRunner(code, "plain", builtins.OutputType)
+	require.ErrorContains(t, runner.InjectBuiltinFailure(builtins.PedersenName, 0, injected), "layout plain does not include")
+
+	runner = createRunner(code, "small", builtins.OutputType)
+	require.ErrorContains(t, runner.InjectBuiltinFailure(builtins.OutputName, 0, nil), "without an error")
+	require.NoError(t, runner.InjectBuiltinFailure(builtins.OutputName, 1, injected))
+	err := runner.Run()
+	require.ErrorIs(t, err, injected)
+	// the first cell is written at step 1 and the failing one at step 3
+	require.ErrorContains(t, err, "pc 0:5 step 3")
+	require.ErrorContains(t, runner.InjectBuiltinFailure(builtins.OutputName, 0, injected), "once the run has started")
+}
+
+func TestInjectBuiltinFailureProofArtifacts(t *testing.T) {
+	run := func(failingBuiltin string) *Runner {
+		runner, err := NewRunner(proofModeBuiltinsProgram(), map[uint64][]hinter.Hinter{}, ProofModeZero, false, 1<<20, "all_cairo", nil, 0)
+		require.NoError(t, err)
+		if failingBuiltin != "" {
+			// the failure is injected into an instance the run doesn't reach
+			require.NoError(t, runner.InjectBuiltinFailure(failingBuiltin, 7, errors.New("injected failure")))
+		}
+		require.NoError(t, runner.Run())
+		require.NoError(t, runner.EndRun())
+		require.NoError(t, runner.FinalizeBuiltins())
+		return &runner
+	}
+	expected, err := run("").GetAirPrivateInput("trace", "memory")
+	require.NoError(t, err)
+
+	layout, err := builtins.GetLayout("all_cairo")
+	require.NoError(t, err)
+	for _, layoutBuiltin := range layout.Builtins {
+		name := layoutBuiltin.Runner.String()
+		t.Run(name, func(t *testing.T) {
+			runner := run(name)
+			segment, ok := runner.vm.Memory.FindSegmentWithBuiltin(name)
+			require.True(t, ok)
+			require.NotNil(t, segment.Failure)
+			require.IsType(t, layoutBuiltin.Runner, segment.BuiltinRunner)
+
+			require.NoError(t, runner.RunSecurityChecks())
+			airPrivateInput, err := runner.GetAirPrivateInput("trace", "memory")
+			require.NoError(t, err)
+			require.Equal(t, expected, airPrivateInput)
+		})
+	}
+}
+
+func TestInjectBuiltinFailureECDSAVerifications(t *testing.T) {
+	// writes the message then the public key of the first ecdsa instance
+	code := `
+        [ap] = 2718, ap++;
+        [ap] = 1735102664668487605176656616876767369909409133946409161569774794110049207117, ap++;
+        [ap - 2] = [[fp - 3] + 1];
+        [ap - 1] = [[fp - 3]];
+        ret;
+    `
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	invalidS := new(fp.Element).SetUint64(31231231313)
+
+	// the invalid signature of the first instance is still reported when a failure
+	// is injected into another instance
+	for _, enable := range []func(runner *Runner) error{
+		func(runner *Runner) error { return runner.EnableDeferredECDSA() },
+		func(runner *Runner) error { return runner.EnableParallelECDSA(2) },
+	} {
+		runner := createRunner(code, "starknet", builtins.ECDSAType)
+		require.NoError(t, runner.InjectBuiltinFailure(builtins.ECDSAName, 5, errors.New("injected failure")))
+		require.NoError(t, enable(&runner))
+		require.NoError(t, runner.AddECDSASignature(0, 0, r, invalidS))
+		require.EqualError(t, runner.Run(), "ecdsa instance at offset 0: signature is not valid")
+	}
+}
+
+func TestScheduleConcurrency(t *testing.T) {
+	// writes the message then the public key of the first ecdsa instance
+	code := `
+        [ap] = 2718, ap++;
+        [ap] = 1735102664668487605176656616876767369909409133946409161569774794110049207117, ap++;
+        [ap - 2] = [[fp - 3] + 1];
+        [ap - 1] = [[fp - 3]];
+        ret;
+    `
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	buildMemory := func(schedule *utils.Schedule) []*fp.Element {
+		runner := createRunner(code, "starknet", builtins.ECDSAType)
+		require.NoError(t, runner.ScheduleConcurrency(schedule))
+		require.NoError(t, runner.EnableParallelECDSA(2))
+		require.NoError(t, runner.RelocateEagerly())
+		require.NoError(t, runner.AddECDSASignature(0, 0, r, s))
+		require.NoError(t, runner.Run())
+		require.ErrorContains(t, runner.ScheduleConcurrency(schedule), "once the run has started")
+		memory, _ := runner.BuildMemory()
+		return memory
+	}
+
+	recording := utils.RecordSchedule()
+	memory := buildMemory(recording)
+	log := recording.Log()
+	require.ElementsMatch(t, []string{"ecdsa:0/0", "relocation:0/0"}, log)
+
+	replaying := utils.ReplaySchedule(log)
+	require.Equal(t, memory, buildMemory(replaying))
+	require.NoError(t, replaying.Err())
+
+	// the verification of the instance is missing from the replayed log
+	replaying = utils.ReplaySchedule([]string{"relocation:0/0"})
+	require.Equal(t, memory, buildMemory(replaying))
+	require.EqualError(t, replaying.Err(), "task ecdsa:0/0 is missing from the schedule")
+}
+
+func TestStackGuard(t *testing.T) {
+	// f, at pc 3, writes over the return pc saved by its call
+	code := `
+        call rel 3;
+        ret;
+        [fp - 1] = 5;
+        ret;
+    `
+	runner := createRunner(code, "plain")
+	require.NoError(t, runner.EnableStackGuard())
+	runner.program.Entrypoints["f"] = 3
+	err := runner.Run()
+	var corruption *vm.StackCorruption
+	require.ErrorAs(t, err, &corruption)
+	require.Equal(t, vm.ReturnPcOverwritten, corruption.Kind)
+	require.ErrorContains(t, err, "return pc overwritten in the frame of f with fp 4: opcode assertions")
+	require.ErrorContains(t, runner.EnableStackGuard(), "once the run has started")
+
+	runner = createRunner(code, "plain")
+	require.False(t, errors.As(runner.Run(), &corruption))
+
+	// the cell the call saves the return pc to is already written
+	runner = createRunner(`
+        [ap + 1] = 5;
+        call rel 3;
+        ret;
+        ret;
+    `, "plain")
+	require.NoError(t, runner.EnableStackGuard())
+	require.ErrorContains(t, runner.Run(), "return pc overwritten in the frame of main with fp 4")
+}
+
+func TestCheckBuiltinReturns(t *testing.T) {
+	// main passes its output pointer to f, at pc 5, which writes a cell and
+	// returns the pointer moved by one instance
+	code := `
+        [ap] = [fp - 3], ap++;
+        call rel 4;
+        [ap] = [ap - 1], ap++;
+        ret;
+        [ap] = 7, ap++;
+        [ap - 1] = [[fp - 3]];
+        [ap] = [fp - 3] + 1, ap++;
+        ret;
+    `
+	outputArg := []vm.ImplicitArg{{Name: "output_ptr", ArgOffset: 3, ReturnOffset: 1}}
+	newRunner := func(code string) Runner {
+		runner := createRunner(code, "small", builtins.OutputType)
+		runner.program.Entrypoints["f"] = 5
+		runner.program.ImplicitArgs = map[string][]vm.ImplicitArg{"main": outputArg, "f": outputArg}
+		return runner
+	}
+
+	runner := newRunner(code)
+	require.ErrorContains(t, runner.CheckBuiltinReturns("g"), "cannot check the builtin returns of g: unknown function")
+	require.NoError(t, runner.CheckBuiltinReturns())
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.CheckBuiltinReturns(), "once the run has started")
+
+	// f returns the value it wrote instead of the pointer
+	runner = newRunner(strings.Replace(code, "[ap] = [fp - 3] + 1, ap++;", "[ap] = 8, ap++;", 1))
+	require.NoError(t, runner.CheckBuiltinReturns("f"))
+	err := runner.Run()
+	var corruption *vm.StackCorruption
+	require.ErrorAs(t, err, &corruption)
+	require.Equal(t, vm.BuiltinPtrNotReturned, corruption.Kind)
+	require.ErrorContains(t, err, "builtin pointer not returned in the frame of f with fp 6: output_ptr returned as 8, received 2:0")
+
+	// main returns its saved fp, and isn't checked unless asked
+	runner = newRunner(strings.Replace(code, "[ap] = [ap - 1], ap++;", "[ap] = [fp - 2], ap++;", 1))
+	require.NoError(t, runner.CheckBuiltinReturns("f"))
+	require.NoError(t, runner.Run())
+	runner = newRunner(strings.Replace(code, "[ap] = [ap - 1], ap++;", "[ap] = [fp - 2], ap++;", 1))
+	require.NoError(t, runner.CheckBuiltinReturns("main"))
+	require.ErrorContains(t, runner.Run(), "builtin pointer not returned in the frame of main")
+
+	// cairo programs have no implicit arguments to check, nor the keccak hints of cairo zero
+	cairoRunner, err := NewRunner(createProgram("ret;"), map[uint64][]hinter.Hinter{}, ExecutionModeCairo, false, 1<<20, "all_cairo", nil, 0)
+	require.NoError(t, err)
+	require.ErrorContains(t, cairoRunner.CheckBuiltinReturns(), "cannot check the builtin returns of a cairo program")
+	require.EqualError(t, cairoRunner.EnableKeccakAcceleration(), "keccak acceleration is only available for cairo zero programs")
+}
+
+func TestRunDeprecatedEntryPoint(t *testing.T) {
+	// a wrapper checking the selector and returning the calldata
+	runner := createRunner(`
+        [fp - 6] = 42;
+        [ap] = [fp - 5], ap++;
+        [ap] = [fp - 4], ap++;
+        [ap] = [fp - 3], ap++;
+        ret;
+    `, "plain")
+	selector := new(fp.Element).SetUint64(42)
+	calldata := []*fp.Element{new(fp.Element).SetUint64(7), new(fp.Element).SetUint64(8)}
+	retdata, err := runner.RunDeprecatedEntryPoint(0, selector, calldata)
+	require.NoError(t, err)
+	require.Equal(t, calldata, retdata)
+
+	// the syscall pointer is returned as the implicit argument
+	syscallPtr, err := runner.vm.Memory.ReadFromAddressAsAddress(&memory.MemoryAddress{SegmentIndex: vm.ExecutionSegment, Offset: 6})
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryAddress{SegmentIndex: 2, Offset: 0}, syscallPtr)
+}
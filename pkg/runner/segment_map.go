@@ -0,0 +1,224 @@
+package runner
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Span is a contiguous range of cells [Start, Start + Size) inside a segment
+type Span struct {
+	Start uint64
+	Size  uint64
+}
+
+// SegmentMapEntry describes the layout of a single segment after a run
+type SegmentMapEntry struct {
+	Index int
+	// program, execution, the builtin name or "segment" for any other segment
+	Name string
+	// effective size of the segment, i.e. the rightmost written index + 1
+	Size uint64
+	// number of cells holding a known value
+	KnownCells uint64
+	// ranges of cells that were never written, inside the effective size
+	Holes []Span
+	// ranges of cells that belong to the public memory
+	PublicMemory []Span
+}
+
+// SegmentMap is a snapshot of all segments in memory, suited for visualization
+type SegmentMap struct {
+	Segments   []SegmentMapEntry
+	TotalSize  uint64
+	TotalHoles uint64
+}
+
+// BuildSegmentMap collects the size, holes and public memory spans of every segment
+// in the runner memory. It should be called after the run has finished.
+func (runner *Runner) BuildSegmentMap() SegmentMap {
+	if runner.vm == nil {
+		panic("cannot build the segment map of an uninitialized runner")
+	}
+	return buildSegmentMap(runner.vm.Memory)
+}
+
+func buildSegmentMap(memory *mem.Memory) SegmentMap {
+	segmentMap := SegmentMap{
+		Segments: make([]SegmentMapEntry, 0, len(memory.Segments)),
+	}
+	for i, segment := range memory.Segments {
+		entry := SegmentMapEntry{
+			Index: i,
+			Name:  segmentName(i, segment),
+			Size:  segment.Len(),
+		}
+
+		var holeStart uint64
+		inHole := false
+		for j := uint64(0); j < entry.Size; j++ {
+			if j < segment.RealLen() && segment.Data[j].Known() {
+				entry.KnownCells++
+				if inHole {
+					entry.Holes = append(entry.Holes, Span{Start: holeStart, Size: j - holeStart})
+					inHole = false
+				}
+				continue
+			}
+			if !inHole {
+				holeStart = j
+				inHole = true
+			}
+		}
+		if inHole {
+			entry.Holes = append(entry.Holes, Span{Start: holeStart, Size: entry.Size - holeStart})
+		}
+
+		for _, offset := range segment.PublicMemoryOffsets {
+			address := uint64(offset.Address)
+			last := len(entry.PublicMemory) - 1
+			if last >= 0 && entry.PublicMemory[last].Start+entry.PublicMemory[last].Size == address {
+				entry.PublicMemory[last].Size++
+				continue
+			}
+			entry.PublicMemory = append(entry.PublicMemory, Span{Start: address, Size: 1})
+		}
+
+		segmentMap.TotalSize += entry.Size
+		segmentMap.TotalHoles += entry.Size - entry.KnownCells
+		segmentMap.Segments = append(segmentMap.Segments, entry)
+	}
+	return segmentMap
+}
+
+func segmentName(index int, segment *mem.Segment) string {
+	if _, ok := segment.BuiltinRunner.(*mem.NoBuiltin); !ok {
+		return segment.BuiltinRunner.String()
+	}
+	switch index {
+	case vm.ProgramSegment:
+		return "program"
+	case vm.ExecutionSegment:
+		return "execution"
+	default:
+		return "segment"
+	}
+}
+
+// width in pixels of the bar representing the biggest segment
+const segmentMapBarWidth = 800
+
+// bar is the visual representation of a segment: the segment spans the full
+// width of the bar, and holes and public memory are drawn over it
+type bar struct {
+	SegmentMapEntry
+	Width        float64
+	Holes        []rect
+	PublicMemory []rect
+}
+
+type rect struct {
+	X     float64
+	Width float64
+}
+
+func (segmentMap *SegmentMap) bars() []bar {
+	var maxSize uint64
+	for i := range segmentMap.Segments {
+		maxSize = max(maxSize, segmentMap.Segments[i].Size)
+	}
+	scale := 0.0
+	if maxSize > 0 {
+		scale = float64(segmentMapBarWidth) / float64(maxSize)
+	}
+	toRects := func(spans []Span) []rect {
+		rects := make([]rect, len(spans))
+		for i, span := range spans {
+			// make sure even single cell spans are visible
+			rects[i] = rect{X: float64(span.Start) * scale, Width: max(float64(span.Size)*scale, 1)}
+		}
+		return rects
+	}
+
+	bars := make([]bar, len(segmentMap.Segments))
+	for i, entry := range segmentMap.Segments {
+		bars[i] = bar{
+			SegmentMapEntry: entry,
+			Width:           max(float64(entry.Size)*scale, 1),
+			Holes:           toRects(entry.Holes),
+			PublicMemory:    toRects(entry.PublicMemory),
+		}
+	}
+	return bars
+}
+
+var segmentMapTemplate = template.Must(template.New("segment_map").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Cairo VM segment map</title>
+<style>
+body { font-family: monospace; }
+table { border-collapse: collapse; }
+td, th { padding: 2px 8px; text-align: left; }
+.known { fill: #4e79a7; }
+.builtin { fill: #f28e2b; }
+.hole { fill: #d0d0d0; }
+.public { fill: #59a14f; }
+</style>
+</head>
+<body>
+<h1>Segment map</h1>
+<p>Total size: {{.Map.TotalSize}} cells, holes: {{.Map.TotalHoles}} cells</p>
+<p>
+<svg width="12" height="12"><rect class="known" width="12" height="12"/></svg> written
+<svg width="12" height="12"><rect class="builtin" width="12" height="12"/></svg> builtin
+<svg width="12" height="12"><rect class="hole" width="12" height="12"/></svg> hole
+<svg width="12" height="12"><rect class="public" width="12" height="12"/></svg> public memory
+</p>
+<table>
+<tr><th>index</th><th>name</th><th>size</th><th>known</th><th>holes</th><th>layout</th></tr>
+{{- range .Bars}}
+<tr>
+<td>{{.Index}}</td>
+<td>{{.Name}}</td>
+<td>{{.Size}}</td>
+<td>{{.KnownCells}}</td>
+<td>{{len .SegmentMapEntry.Holes}}</td>
+<td>
+<svg width="{{$.BarWidth}}" height="20">
+<rect class="{{if or (eq .Name "program") (eq .Name "execution") (eq .Name "segment")}}known{{else}}builtin{{end}}" x="0" y="0" width="{{.Width}}" height="14"/>
+{{- range .Holes}}
+<rect class="hole" x="{{.X}}" y="0" width="{{.Width}}" height="14"/>
+{{- end}}
+{{- range .PublicMemory}}
+<rect class="public" x="{{.X}}" y="15" width="{{.Width}}" height="4"/>
+{{- end}}
+</svg>
+</td>
+</tr>
+{{- end}}
+</table>
+</body>
+</html>
+`))
+
+// WriteHTML renders the segment map as an HTML page with an SVG bar per segment
+func (segmentMap *SegmentMap) WriteHTML(w io.Writer) error {
+	err := segmentMapTemplate.Execute(w, struct {
+		Map      *SegmentMap
+		Bars     []bar
+		BarWidth int
+	}{
+		Map:      segmentMap,
+		Bars:     segmentMap.bars(),
+		BarWidth: segmentMapBarWidth,
+	})
+	if err != nil {
+		return fmt.Errorf("render segment map: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,45 @@
+package runner
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildSegmentMap(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	mem.Segments = append(
+		mem.Segments,
+		createSegment(nil, 1, 2, 3),
+		createSegment(
+			[]memory.PublicMemoryOffset{{Address: 0}, {Address: 1}, {Address: 4}},
+			1, nil, nil, 4, 5,
+		),
+	)
+	mem.AllocateBuiltinSegment(builtins.Runner(builtins.OutputType))
+
+	segmentMap := buildSegmentMap(mem)
+
+	require.Equal(t, []SegmentMapEntry{
+		{Index: 0, Name: "program", Size: 3, KnownCells: 3},
+		{
+			Index:        1,
+			Name:         "execution",
+			Size:         5,
+			KnownCells:   3,
+			Holes:        []Span{{Start: 1, Size: 2}},
+			PublicMemory: []Span{{Start: 0, Size: 2}, {Start: 4, Size: 1}},
+		},
+		{Index: 2, Name: builtins.OutputName},
+	}, segmentMap.Segments)
+	require.Equal(t, uint64(8), segmentMap.TotalSize)
+	require.Equal(t, uint64(2), segmentMap.TotalHoles)
+
+	var html bytes.Buffer
+	require.NoError(t, segmentMap.WriteHTML(&html))
+	require.Contains(t, html.String(), "<td>execution</td>")
+	require.Contains(t, html.String(), `class="hole"`)
+}
@@ -0,0 +1,80 @@
+package runner
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// EnableStackGuard makes the vm track the call frames and fail with a
+// vm.StackCorruption naming the function whose saved fp or return pc is written
+// over, instead of the later error of a ret jumping to garbage. It must be called
+// before running the program.
+func (runner *Runner) EnableStackGuard() error {
+	if runner.vm != nil {
+		return errors.New("cannot enable the stack guard once the run has started")
+	}
+	runner.stackGuard = true
+	return nil
+}
+
+// CheckBuiltinReturns makes the ret of the given functions, or of every function
+// of the program when none is given, fail with a vm.BuiltinPtrNotReturned
+// vm.StackCorruption naming the function when it doesn't return the builtin
+// pointers it received moved by whole instances, as when a function forgets to
+// return its updated builtin pointer. The implicit arguments of the functions come
+// from the identifiers of the cairo zero program. It enables the stack guard and
+// must be called before running the program.
+func (runner *Runner) CheckBuiltinReturns(functions ...string) error {
+	if runner.vm != nil {
+		return errors.New("cannot check the builtin returns once the run has started")
+	}
+	if !runner.isCairoZero() {
+		return errors.New("cannot check the builtin returns of a cairo program, only cairo zero programs list the implicit arguments of their functions")
+	}
+	if len(functions) == 0 {
+		for function := range runner.program.ImplicitArgs {
+			functions = append(functions, function)
+		}
+	}
+	if runner.builtinReturns == nil {
+		runner.builtinReturns = make(map[uint64][]vm.ImplicitArg)
+	}
+	for _, function := range functions {
+		offset, ok := runner.program.Entrypoints[function]
+		if !ok {
+			return fmt.Errorf("cannot check the builtin returns of %s: unknown function", function)
+		}
+		args, ok := runner.program.ImplicitArgs[function]
+		if !ok {
+			return fmt.Errorf("cannot check the builtin returns of %s: its implicit arguments are unknown", function)
+		}
+		runner.builtinReturns[offset] = args
+	}
+	runner.stackGuard = true
+	return nil
+}
+
+// Names the function of a pc after the closest function of the program starting
+// at or before it
+func (runner *Runner) functionName(pc mem.MemoryAddress) string {
+	if pc.SegmentIndex != vm.ProgramSegment {
+		return ""
+	}
+	function := ""
+	var start uint64
+	for name, offset := range runner.program.Entrypoints {
+		if offset > pc.Offset || (function != "" && offset < start) {
+			continue
+		}
+		// identifiers aliasing the same pc are named deterministically
+		if function != "" && offset == start && name > function {
+			continue
+		}
+		function = name
+		start = offset
+	}
+	return function
+}
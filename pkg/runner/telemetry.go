@@ -0,0 +1,108 @@
+package runner
+
+import (
+	"errors"
+	"slices"
+	"time"
+)
+
+// Names of the spans of a run. The runner records the execute and relocate spans, the
+// load span is recorded by the caller that loads the program, see RecordLoad
+const (
+	LoadSpan     = "load"
+	ExecuteSpan  = "execute"
+	RelocateSpan = "relocate"
+)
+
+// Names of the metrics recorded at the end of the execution
+const (
+	StepsMetric            = "steps"
+	MemoryHolesMetric      = "memory_holes"
+	BuiltinInstancesMetric = "builtin_instances"
+)
+
+// TelemetrySpan is a phase of a run
+type TelemetrySpan struct {
+	Name       string
+	Start      time.Time
+	Duration   time.Duration
+	Attributes map[string]string
+	// error the phase ended with, nil when it succeeded
+	Err error
+}
+
+// TelemetryMetric is a measure of a run, the attributes telling it apart from the other
+// measures of the same name, e.g. the builtin of a builtin_instances metric
+type TelemetryMetric struct {
+	Name       string
+	Value      uint64
+	Attributes map[string]string
+}
+
+// Telemetry receives the spans and metrics of a run, for the services embedding the
+// VM to export them, e.g. with the exporters of the telemetry package. Its methods
+// are called from the goroutine running the program
+type Telemetry interface {
+	RecordSpan(span TelemetrySpan)
+	RecordMetric(metric TelemetryMetric)
+}
+
+// SetTelemetry makes the runner record the spans of the execution and of the
+// relocations, and the resources used once the execution is over. It must be called
+// before running the program
+func (runner *Runner) SetTelemetry(telemetry Telemetry) error {
+	if runner.vm != nil {
+		return errors.New("cannot set the telemetry once the run has started")
+	}
+	runner.telemetry = telemetry
+	return nil
+}
+
+// RecordLoad records the load span of a program, which started at start and ended
+// now, when telemetry is not nil
+func RecordLoad(telemetry Telemetry, start time.Time, err error) {
+	if telemetry == nil {
+		return
+	}
+	telemetry.RecordSpan(TelemetrySpan{Name: LoadSpan, Start: start, Duration: time.Since(start), Err: err})
+}
+
+func (runner *Runner) recordSpan(name string, start time.Time, attributes map[string]string, err error) {
+	if runner.telemetry == nil {
+		return
+	}
+	runner.telemetry.RecordSpan(TelemetrySpan{
+		Name:       name,
+		Start:      start,
+		Duration:   time.Since(start),
+		Attributes: attributes,
+		Err:        err,
+	})
+}
+
+// Deferred by the run methods, records the execute span and, when the vm got to run,
+// the resources it used
+func (runner *Runner) recordExecution(start time.Time, err *error) {
+	if runner.telemetry == nil {
+		return
+	}
+	runner.recordSpan(ExecuteSpan, start, nil, *err)
+	if runner.vm == nil {
+		return
+	}
+	resources := runner.ExecutionResources()
+	runner.telemetry.RecordMetric(TelemetryMetric{Name: StepsMetric, Value: resources.NSteps})
+	runner.telemetry.RecordMetric(TelemetryMetric{Name: MemoryHolesMetric, Value: resources.NMemoryHoles})
+	builtinNames := make([]string, 0, len(resources.BuiltinInstanceCounter))
+	for builtin := range resources.BuiltinInstanceCounter {
+		builtinNames = append(builtinNames, builtin)
+	}
+	slices.Sort(builtinNames)
+	for _, builtin := range builtinNames {
+		runner.telemetry.RecordMetric(TelemetryMetric{
+			Name:       BuiltinInstancesMetric,
+			Value:      resources.BuiltinInstanceCounter[builtin],
+			Attributes: map[string]string{"builtin": builtin},
+		})
+	}
+}
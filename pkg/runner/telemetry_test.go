@@ -0,0 +1,53 @@
+package runner
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
+	"github.com/stretchr/testify/require"
+)
+
+type telemetryRecorder struct {
+	spans   []TelemetrySpan
+	metrics []TelemetryMetric
+}
+
+func (r *telemetryRecorder) RecordSpan(span TelemetrySpan) {
+	r.spans = append(r.spans, span)
+}
+
+func (r *telemetryRecorder) RecordMetric(metric TelemetryMetric) {
+	r.metrics = append(r.metrics, metric)
+}
+
+func TestTelemetry(t *testing.T) {
+	runner := createRunner("ret;", "small", builtins.OutputType)
+	recorder := &telemetryRecorder{}
+	require.NoError(t, runner.SetTelemetry(recorder))
+	require.NoError(t, runner.Run())
+	require.ErrorContains(t, runner.SetTelemetry(recorder), "cannot set the telemetry once the run has started")
+	runner.BuildMemory()
+
+	require.Len(t, recorder.spans, 2)
+	require.Equal(t, ExecuteSpan, recorder.spans[0].Name)
+	require.NoError(t, recorder.spans[0].Err)
+	require.Equal(t, RelocateSpan, recorder.spans[1].Name)
+	require.Equal(t, map[string]string{"artifact": "memory"}, recorder.spans[1].Attributes)
+	require.Equal(t, []TelemetryMetric{
+		{Name: StepsMetric, Value: 1},
+		{Name: MemoryHolesMetric, Value: 0},
+		{Name: BuiltinInstancesMetric, Value: 0, Attributes: map[string]string{"builtin": "output_builtin"}},
+	}, recorder.metrics)
+}
+
+func TestTelemetryFailedExecution(t *testing.T) {
+	runner := createRunner("jmp rel 0;", "plain")
+	runner.maxsteps = 10
+	recorder := &telemetryRecorder{}
+	require.NoError(t, runner.SetTelemetry(recorder))
+	require.ErrorContains(t, runner.Run(), "max step limit exceeded")
+
+	require.Len(t, recorder.spans, 1)
+	require.ErrorContains(t, recorder.spans[0].Err, "max step limit exceeded")
+	require.Equal(t, TelemetryMetric{Name: StepsMetric, Value: 10}, recorder.metrics[0])
+}
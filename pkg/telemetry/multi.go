@@ -0,0 +1,22 @@
+package telemetry
+
+import "github.com/NethermindEth/cairo-vm-go/pkg/runner"
+
+type multi []runner.Telemetry
+
+// Multi records the spans and metrics to each of the telemetries, in order
+func Multi(telemetries ...runner.Telemetry) runner.Telemetry {
+	return multi(telemetries)
+}
+
+func (m multi) RecordSpan(span runner.TelemetrySpan) {
+	for _, telemetry := range m {
+		telemetry.RecordSpan(span)
+	}
+}
+
+func (m multi) RecordMetric(metric runner.TelemetryMetric) {
+	for _, telemetry := range m {
+		telemetry.RecordMetric(metric)
+	}
+}
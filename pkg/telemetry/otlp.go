@@ -0,0 +1,261 @@
+package telemetry
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+)
+
+const (
+	// instrumentation scope of the spans and metrics
+	scopeName = "github.com/NethermindEth/cairo-vm-go"
+	// OTLP span kind and status codes
+	spanKindInternal = 1
+	statusCodeError  = 2
+)
+
+// OTLP buffers the spans and metrics of a run and posts them to an OpenTelemetry
+// collector on Flush. The spans of an exporter share a trace, one per run
+type OTLP struct {
+	endpoint    string
+	serviceName string
+	client      *http.Client
+	traceID     string
+
+	mu      sync.Mutex
+	spans   []runner.TelemetrySpan
+	metrics []otlpMetric
+}
+
+type otlpMetric struct {
+	metric runner.TelemetryMetric
+	time   time.Time
+}
+
+var _ runner.Telemetry = (*OTLP)(nil)
+
+// NewOTLP exports to the collector at endpoint, e.g. http://localhost:4318, the spans
+// and metrics having the service.name resource attribute set to serviceName
+func NewOTLP(endpoint string, serviceName string) *OTLP {
+	return &OTLP{
+		endpoint:    strings.TrimSuffix(endpoint, "/"),
+		serviceName: serviceName,
+		client:      &http.Client{Timeout: 10 * time.Second},
+		traceID:     randomID(16),
+	}
+}
+
+func (o *OTLP) RecordSpan(span runner.TelemetrySpan) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.spans = append(o.spans, span)
+}
+
+func (o *OTLP) RecordMetric(metric runner.TelemetryMetric) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.metrics = append(o.metrics, otlpMetric{metric: metric, time: time.Now()})
+}
+
+// Flush posts the spans and metrics recorded since the last flush
+func (o *OTLP) Flush(ctx context.Context) error {
+	o.mu.Lock()
+	spans, metrics := o.spans, o.metrics
+	o.spans, o.metrics = nil, nil
+	o.mu.Unlock()
+
+	if len(spans) > 0 {
+		if err := o.post(ctx, "/v1/traces", o.tracesRequest(spans)); err != nil {
+			return fmt.Errorf("cannot export spans: %w", err)
+		}
+	}
+	if len(metrics) > 0 {
+		if err := o.post(ctx, "/v1/metrics", o.metricsRequest(metrics)); err != nil {
+			return fmt.Errorf("cannot export metrics: %w", err)
+		}
+	}
+	return nil
+}
+
+func (o *OTLP) post(ctx context.Context, path string, request any) error {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, o.endpoint+path, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	response, err := o.client.Do(httpRequest)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("collector answered %s", response.Status)
+	}
+	return nil
+}
+
+// The types below are the parts of the OTLP/HTTP JSON encoding the exporter uses, in
+// which the 64-bit integers are strings and the ids are hex encoded
+
+type otlpKeyValue struct {
+	Key   string `json:"key"`
+	Value struct {
+		StringValue string `json:"stringValue"`
+	} `json:"value"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            *otlpStatus    `json:"status,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code"`
+	Message string `json:"message,omitempty"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpTracesRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpDataPoint struct {
+	AsInt        string         `json:"asInt"`
+	TimeUnixNano string         `json:"timeUnixNano"`
+	Attributes   []otlpKeyValue `json:"attributes,omitempty"`
+}
+
+type otlpGaugeMetric struct {
+	Name  string `json:"name"`
+	Gauge struct {
+		DataPoints []otlpDataPoint `json:"dataPoints"`
+	} `json:"gauge"`
+}
+
+type otlpScopeMetrics struct {
+	Scope   otlpScope         `json:"scope"`
+	Metrics []otlpGaugeMetric `json:"metrics"`
+}
+
+type otlpResourceMetrics struct {
+	Resource     otlpResource       `json:"resource"`
+	ScopeMetrics []otlpScopeMetrics `json:"scopeMetrics"`
+}
+
+type otlpMetricsRequest struct {
+	ResourceMetrics []otlpResourceMetrics `json:"resourceMetrics"`
+}
+
+func (o *OTLP) resource() otlpResource {
+	return otlpResource{Attributes: otlpAttributes(map[string]string{"service.name": o.serviceName})}
+}
+
+func (o *OTLP) tracesRequest(spans []runner.TelemetrySpan) *otlpTracesRequest {
+	scopeSpans := otlpScopeSpans{Scope: otlpScope{Name: scopeName}}
+	for i := range spans {
+		span := otlpSpan{
+			TraceID:           o.traceID,
+			SpanID:            randomID(8),
+			Name:              spans[i].Name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: unixNano(spans[i].Start),
+			EndTimeUnixNano:   unixNano(spans[i].Start.Add(spans[i].Duration)),
+			Attributes:        otlpAttributes(spans[i].Attributes),
+		}
+		if spans[i].Err != nil {
+			span.Status = &otlpStatus{Code: statusCodeError, Message: spans[i].Err.Error()}
+		}
+		scopeSpans.Spans = append(scopeSpans.Spans, span)
+	}
+	return &otlpTracesRequest{
+		ResourceSpans: []otlpResourceSpans{{Resource: o.resource(), ScopeSpans: []otlpScopeSpans{scopeSpans}}},
+	}
+}
+
+// The data points of the metrics of the same name are grouped, in the order they were
+// first recorded
+func (o *OTLP) metricsRequest(metrics []otlpMetric) *otlpMetricsRequest {
+	scopeMetrics := otlpScopeMetrics{Scope: otlpScope{Name: scopeName}}
+	indexes := map[string]int{}
+	for i := range metrics {
+		index, ok := indexes[metrics[i].metric.Name]
+		if !ok {
+			index = len(scopeMetrics.Metrics)
+			indexes[metrics[i].metric.Name] = index
+			scopeMetrics.Metrics = append(scopeMetrics.Metrics, otlpGaugeMetric{Name: metrics[i].metric.Name})
+		}
+		gauge := &scopeMetrics.Metrics[index].Gauge
+		gauge.DataPoints = append(gauge.DataPoints, otlpDataPoint{
+			AsInt:        strconv.FormatUint(metrics[i].metric.Value, 10),
+			TimeUnixNano: unixNano(metrics[i].time),
+			Attributes:   otlpAttributes(metrics[i].metric.Attributes),
+		})
+	}
+	return &otlpMetricsRequest{
+		ResourceMetrics: []otlpResourceMetrics{{Resource: o.resource(), ScopeMetrics: []otlpScopeMetrics{scopeMetrics}}},
+	}
+}
+
+// Attributes sorted by key, for the requests to be stable
+func otlpAttributes(attributes map[string]string) []otlpKeyValue {
+	keyValues := make([]otlpKeyValue, 0, len(attributes))
+	for key, value := range attributes {
+		keyValue := otlpKeyValue{Key: key}
+		keyValue.Value.StringValue = value
+		keyValues = append(keyValues, keyValue)
+	}
+	slices.SortFunc(keyValues, func(a, b otlpKeyValue) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return keyValues
+}
+
+func unixNano(t time.Time) string {
+	return strconv.FormatInt(t.UnixNano(), 10)
+}
+
+// Returns a random id of size bytes, hex encoded
+func randomID(size int) string {
+	id := make([]byte, size)
+	// crypto/rand never fails on the supported platforms
+	_, _ = rand.Read(id)
+	return hex.EncodeToString(id)
+}
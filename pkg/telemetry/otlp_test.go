@@ -0,0 +1,82 @@
+package telemetry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOTLP(t *testing.T) {
+	requests := map[string][]byte{}
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body json.RawMessage
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		require.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		requests[r.URL.Path] = body
+	}))
+	defer collector.Close()
+
+	otlp := NewOTLP(collector.URL+"/", "prover")
+	start := time.Unix(1, 0)
+	otlp.RecordSpan(runner.TelemetrySpan{Name: runner.LoadSpan, Start: start, Duration: time.Second})
+	otlp.RecordSpan(runner.TelemetrySpan{
+		Name:     runner.ExecuteSpan,
+		Start:    start.Add(time.Second),
+		Duration: time.Second,
+		Err:      errors.New("failed"),
+	})
+	otlp.RecordMetric(runner.TelemetryMetric{Name: runner.StepsMetric, Value: 42})
+	otlp.RecordMetric(runner.TelemetryMetric{Name: runner.BuiltinInstancesMetric, Value: 1, Attributes: map[string]string{"builtin": "output_builtin"}})
+	otlp.RecordMetric(runner.TelemetryMetric{Name: runner.BuiltinInstancesMetric, Value: 2, Attributes: map[string]string{"builtin": "range_check_builtin"}})
+	require.NoError(t, otlp.Flush(context.Background()))
+
+	var traces otlpTracesRequest
+	require.NoError(t, json.Unmarshal(requests["/v1/traces"], &traces))
+	require.Len(t, traces.ResourceSpans, 1)
+	require.Equal(t, "service.name", traces.ResourceSpans[0].Resource.Attributes[0].Key)
+	require.Equal(t, "prover", traces.ResourceSpans[0].Resource.Attributes[0].Value.StringValue)
+	spans := traces.ResourceSpans[0].ScopeSpans[0].Spans
+	require.Len(t, spans, 2)
+	require.Equal(t, "load", spans[0].Name)
+	require.Equal(t, "1000000000", spans[0].StartTimeUnixNano)
+	require.Equal(t, "2000000000", spans[0].EndTimeUnixNano)
+	require.Nil(t, spans[0].Status)
+	require.Equal(t, &otlpStatus{Code: statusCodeError, Message: "failed"}, spans[1].Status)
+	// the spans of a run share its trace
+	require.Len(t, spans[0].TraceID, 32)
+	require.Equal(t, spans[0].TraceID, spans[1].TraceID)
+	require.NotEqual(t, spans[0].SpanID, spans[1].SpanID)
+
+	var metrics otlpMetricsRequest
+	require.NoError(t, json.Unmarshal(requests["/v1/metrics"], &metrics))
+	gauges := metrics.ResourceMetrics[0].ScopeMetrics[0].Metrics
+	require.Len(t, gauges, 2)
+	require.Equal(t, "steps", gauges[0].Name)
+	require.Equal(t, "42", gauges[0].Gauge.DataPoints[0].AsInt)
+	require.Equal(t, "builtin_instances", gauges[1].Name)
+	require.Len(t, gauges[1].Gauge.DataPoints, 2)
+	require.Equal(t, "range_check_builtin", gauges[1].Gauge.DataPoints[1].Attributes[0].Value.StringValue)
+
+	// nothing is left to export
+	requests = map[string][]byte{}
+	require.NoError(t, otlp.Flush(context.Background()))
+	require.Empty(t, requests)
+}
+
+func TestOTLPCollectorError(t *testing.T) {
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer collector.Close()
+
+	otlp := NewOTLP(collector.URL, "prover")
+	otlp.RecordMetric(runner.TelemetryMetric{Name: runner.StepsMetric, Value: 42})
+	require.EqualError(t, otlp.Flush(context.Background()), "cannot export metrics: collector answered 503 Service Unavailable")
+}
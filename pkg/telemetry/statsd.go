@@ -0,0 +1,75 @@
+// Package telemetry exports the spans and metrics of runs, see runner.SetTelemetry,
+// to a statsd agent or to an OpenTelemetry collector.
+//
+// The exporters only depend on the standard library: Statsd sends the DogStatsD line
+// protocol over UDP and OTLP posts the OTLP/HTTP JSON encoding, which every
+// OpenTelemetry collector accepts on its /v1/traces and /v1/metrics endpoints.
+package telemetry
+
+import (
+	"fmt"
+	"net"
+	"slices"
+	"strings"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+)
+
+// Statsd sends each span as a timing, in milliseconds, and each metric as a gauge.
+// Attributes are sent as DogStatsD tags, with an error tag on the spans that failed.
+// Like any statsd client, it drops what it cannot send
+type Statsd struct {
+	conn   net.Conn
+	prefix string
+}
+
+var _ runner.Telemetry = (*Statsd)(nil)
+
+// NewStatsd sends to the agent at address, a host:port, the names being prefixed with
+// prefix and a dot unless it is empty
+func NewStatsd(address string, prefix string) (*Statsd, error) {
+	conn, err := net.Dial("udp", address)
+	if err != nil {
+		return nil, fmt.Errorf("cannot connect to the statsd agent: %w", err)
+	}
+	if prefix != "" {
+		prefix += "."
+	}
+	return &Statsd{conn: conn, prefix: prefix}, nil
+}
+
+func (s *Statsd) RecordSpan(span runner.TelemetrySpan) {
+	tags := statsdTags(span.Attributes)
+	if span.Err != nil {
+		tags = append(tags, "error:true")
+	}
+	milliseconds := float64(span.Duration.Microseconds()) / 1000
+	s.send(fmt.Sprintf("%s%s:%g|ms", s.prefix, span.Name, milliseconds), tags)
+}
+
+func (s *Statsd) RecordMetric(metric runner.TelemetryMetric) {
+	s.send(fmt.Sprintf("%s%s:%d|g", s.prefix, metric.Name, metric.Value), statsdTags(metric.Attributes))
+}
+
+// Close closes the connection to the agent
+func (s *Statsd) Close() error {
+	return s.conn.Close()
+}
+
+func (s *Statsd) send(line string, tags []string) {
+	if len(tags) > 0 {
+		line += "|#" + strings.Join(tags, ",")
+	}
+	// UDP is best effort, a lost line must not fail the run
+	_, _ = s.conn.Write([]byte(line))
+}
+
+// Tags sorted by key, for the lines to be stable
+func statsdTags(attributes map[string]string) []string {
+	tags := make([]string, 0, len(attributes))
+	for key, value := range attributes {
+		tags = append(tags, key+":"+value)
+	}
+	slices.Sort(tags)
+	return tags
+}
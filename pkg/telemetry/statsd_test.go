@@ -0,0 +1,49 @@
+package telemetry
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/runner"
+	"github.com/stretchr/testify/require"
+)
+
+func TestStatsd(t *testing.T) {
+	agent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer agent.Close()
+
+	statsd, err := NewStatsd(agent.LocalAddr().String(), "cairo_vm")
+	require.NoError(t, err)
+	defer statsd.Close()
+
+	receive := func() string {
+		buffer := make([]byte, 1024)
+		require.NoError(t, agent.SetReadDeadline(time.Now().Add(5*time.Second)))
+		n, _, err := agent.ReadFrom(buffer)
+		require.NoError(t, err)
+		return string(buffer[:n])
+	}
+
+	statsd.RecordSpan(runner.TelemetrySpan{
+		Name:       runner.RelocateSpan,
+		Duration:   1500 * time.Microsecond,
+		Attributes: map[string]string{"artifact": "trace"},
+	})
+	require.Equal(t, "cairo_vm.relocate:1.5|ms|#artifact:trace", receive())
+
+	statsd.RecordSpan(runner.TelemetrySpan{Name: runner.ExecuteSpan, Duration: 2 * time.Second, Err: errors.New("failed")})
+	require.Equal(t, "cairo_vm.execute:2000|ms|#error:true", receive())
+
+	statsd.RecordMetric(runner.TelemetryMetric{Name: runner.StepsMetric, Value: 42})
+	require.Equal(t, "cairo_vm.steps:42|g", receive())
+
+	statsd.RecordMetric(runner.TelemetryMetric{
+		Name:       runner.BuiltinInstancesMetric,
+		Value:      3,
+		Attributes: map[string]string{"builtin": "pedersen_builtin", "layout": "all_cairo"},
+	})
+	require.Equal(t, "cairo_vm.builtin_instances:3|g|#builtin:pedersen_builtin,layout:all_cairo", receive())
+}
@@ -0,0 +1,117 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrorCode identifies a class of failures common enough to be explained to the user
+type ErrorCode string
+
+const (
+	ErrorCodeUnknownHint        ErrorCode = "unknown_hint"
+	ErrorCodeMissingBuiltin     ErrorCode = "missing_builtin"
+	ErrorCodeInconsistentMemory ErrorCode = "inconsistent_memory"
+	ErrorCodeUnsatisfiedAssert  ErrorCode = "unsatisfied_assert"
+	ErrorCodeInvalidPc          ErrorCode = "invalid_pc"
+)
+
+// CodedError tags an error with the class it belongs to. Its message is the message
+// of the tagged error, so tagging doesn't change what is printed
+type CodedError struct {
+	Code ErrorCode
+	Err  error
+}
+
+func (e *CodedError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CodedError) Unwrap() error {
+	return e.Err
+}
+
+func WithErrorCode(code ErrorCode, err error) error {
+	return &CodedError{Code: code, Err: err}
+}
+
+// ErrorExplanation describes what a class of failures means and what usually
+// causes it
+type ErrorExplanation struct {
+	Summary      string
+	LikelyCauses []string
+}
+
+var errorExplanations = map[ErrorCode]ErrorExplanation{
+	ErrorCodeUnknownHint: {
+		Summary: "the program uses a hint the VM doesn't implement",
+		LikelyCauses: []string{
+			"the hint comes from a library or compiler version more recent than the VM",
+			"the hint is specific to the program and has to be provided by a hint plugin",
+			"the code of the hint was edited, hints are recognized by their exact code",
+		},
+	},
+	ErrorCodeMissingBuiltin: {
+		Summary: "the program uses a builtin the layout doesn't include",
+		LikelyCauses: []string{
+			"the layout is too small for the program, `layouts show` lists the builtins of each layout",
+			"no layout was given and the default plain layout has no builtins",
+		},
+	},
+	ErrorCodeInconsistentMemory: {
+		Summary: "a memory cell was written twice with different values, memory cells are write once",
+		LikelyCauses: []string{
+			"a hint wrote to a cell already written by the program or by another hint",
+			"a pointer was computed with a wrong offset and refers to a used cell",
+		},
+	},
+	ErrorCodeUnsatisfiedAssert: {
+		Summary: "an assert_eq instruction compared two different values",
+		LikelyCauses: []string{
+			"an assertion of the program doesn't hold for the given input",
+			"a hint wrote a wrong nondeterministic value that the program then checked",
+		},
+	},
+	ErrorCodeInvalidPc: {
+		Summary: "pc left the program, the VM only executes the program and the registered code segments",
+		LikelyCauses: []string{
+			"a jump or a call used a computed destination holding a wrong value",
+			"a ret used a return pc overwritten by the function, `--stack_guard` names the function",
+			"the program falls through its last instruction without a ret or a jump",
+		},
+	},
+}
+
+// ErrorCodeOf returns the code of the outermost coded error in the chain of err
+func ErrorCodeOf(err error) (ErrorCode, bool) {
+	var coded *CodedError
+	if !errors.As(err, &coded) {
+		return "", false
+	}
+	return coded.Code, true
+}
+
+func ExplanationOf(code ErrorCode) (ErrorExplanation, bool) {
+	explanation, ok := errorExplanations[code]
+	return explanation, ok
+}
+
+// ExplainError returns an explanation of the failure class of err and its likely
+// causes, or an empty string when err doesn't belong to a known class
+func ExplainError(err error) string {
+	code, ok := ErrorCodeOf(err)
+	if !ok {
+		return ""
+	}
+	explanation, ok := ExplanationOf(code)
+	if !ok {
+		return ""
+	}
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "%s: %s\nlikely causes:", code, explanation.Summary)
+	for _, cause := range explanation.LikelyCauses {
+		fmt.Fprintf(&builder, "\n  - %s", cause)
+	}
+	return builder.String()
+}
@@ -0,0 +1,37 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExplainError(t *testing.T) {
+	err := fmt.Errorf("runtime error: %w", WithErrorCode(ErrorCodeUnknownHint, errors.New("not identified hint")))
+	require.Equal(t, "runtime error: not identified hint", err.Error())
+
+	code, ok := ErrorCodeOf(err)
+	require.True(t, ok)
+	require.Equal(t, ErrorCodeUnknownHint, code)
+	require.Equal(t, `unknown_hint: the program uses a hint the VM doesn't implement
+likely causes:
+  - the hint comes from a library or compiler version more recent than the VM
+  - the hint is specific to the program and has to be provided by a hint plugin
+  - the code of the hint was edited, hints are recognized by their exact code`, ExplainError(err))
+
+	// the outermost code is the most specific one
+	err = WithErrorCode(ErrorCodeUnsatisfiedAssert, WithErrorCode(ErrorCodeInconsistentMemory, errors.New("rewriting value")))
+	code, _ = ErrorCodeOf(err)
+	require.Equal(t, ErrorCodeUnsatisfiedAssert, code)
+
+	require.Empty(t, ExplainError(errors.New("uncoded")))
+	require.Empty(t, ExplainError(WithErrorCode("unknown", errors.New("unexplained"))))
+
+	for _, code := range []ErrorCode{ErrorCodeUnknownHint, ErrorCodeMissingBuiltin, ErrorCodeInconsistentMemory, ErrorCodeUnsatisfiedAssert, ErrorCodeInvalidPc} {
+		explanation, ok := ExplanationOf(code)
+		require.True(t, ok, code)
+		require.NotEmpty(t, explanation.LikelyCauses, code)
+	}
+}
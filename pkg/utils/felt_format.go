@@ -0,0 +1,112 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// FeltFormat selects how field elements are printed
+type FeltFormat uint8
+
+const (
+	// Decimal, except for small negative values printed as -x
+	FeltFormatDefault FeltFormat = iota
+	// Canonical decimal representation in [0, P)
+	FeltFormatDecimal
+	// Canonical hexadecimal representation, 0x prefixed
+	FeltFormatHex
+	// Decimal in (-P/2, P/2], i.e. P - 1 is printed as -1
+	FeltFormatSigned
+	// Quoted ascii string when the bytes of the felt are all printable, decimal otherwise
+	FeltFormatShortString
+)
+
+var feltFormatNames = map[FeltFormat]string{
+	FeltFormatDefault:     "default",
+	FeltFormatDecimal:     "dec",
+	FeltFormatHex:         "hex",
+	FeltFormatSigned:      "signed",
+	FeltFormatShortString: "short_string",
+}
+
+func (format FeltFormat) String() string {
+	return feltFormatNames[format]
+}
+
+func ParseFeltFormat(name string) (FeltFormat, error) {
+	if name == "" {
+		return FeltFormatDefault, nil
+	}
+	for format, formatName := range feltFormatNames {
+		if formatName == name {
+			return format, nil
+		}
+	}
+	return FeltFormatDefault, fmt.Errorf("unknown felt format %s, expected one of dec, hex, signed or short_string", name)
+}
+
+// Format used by every printer of the VM: memory values, errors and program output.
+// It is meant to be set once at startup
+var currentFeltFormat = FeltFormatDefault
+
+func SetFeltFormat(format FeltFormat) {
+	currentFeltFormat = format
+}
+
+func CurrentFeltFormat() FeltFormat {
+	return currentFeltFormat
+}
+
+// Formats a felt with the format set with SetFeltFormat
+func FeltString(felt *fp.Element) string {
+	return FormatFelt(felt, currentFeltFormat)
+}
+
+func FormatFelt(felt *fp.Element, format FeltFormat) string {
+	switch format {
+	case FeltFormatDecimal:
+		return felt.BigInt(new(big.Int)).Text(10)
+	case FeltFormatHex:
+		return "0x" + felt.Text(16)
+	case FeltFormatSigned:
+		value := felt.BigInt(new(big.Int))
+		if value.Cmp(halfPrime()) > 0 {
+			value.Sub(value, fp.Modulus())
+		}
+		return value.Text(10)
+	case FeltFormatShortString:
+		if shortString, ok := decodeShortString(felt); ok {
+			return "'" + shortString + "'"
+		}
+		return felt.BigInt(new(big.Int)).Text(10)
+	default:
+		return felt.Text(10)
+	}
+}
+
+func halfPrime() *big.Int {
+	half := fp.Modulus()
+	return half.Rsh(half, 1)
+}
+
+// Short strings are at most 31 ascii characters encoded big endian in a felt
+func decodeShortString(felt *fp.Element) (string, bool) {
+	if felt.IsZero() {
+		return "", false
+	}
+	bytes := felt.Bytes()
+	var builder strings.Builder
+	for _, b := range bytes {
+		if b == 0 && builder.Len() == 0 {
+			continue
+		}
+		if b < 0x20 || b > 0x7e {
+			return "", false
+		}
+		builder.WriteByte(b)
+	}
+	return builder.String(), true
+}
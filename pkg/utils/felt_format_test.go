@@ -0,0 +1,54 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFelt(t *testing.T) {
+	minusOne := fp.NewElement(1)
+	minusOne.Neg(&minusOne)
+	minusBig := fp.NewElement(1 << 20)
+	minusBig.Neg(&minusBig)
+	hello := new(fp.Element).SetBytes([]byte("hello"))
+	small := fp.NewElement(42)
+
+	tests := []struct {
+		felt     *fp.Element
+		format   FeltFormat
+		expected string
+	}{
+		{&minusOne, FeltFormatDefault, "-1"},
+		{&minusBig, FeltFormatDefault, "3618502788666131213697322783095070105623107215331596699973092056135870971905"},
+		{&minusOne, FeltFormatDecimal, "3618502788666131213697322783095070105623107215331596699973092056135872020480"},
+		{&minusOne, FeltFormatHex, "0x800000000000011000000000000000000000000000000000000000000000000"},
+		{&small, FeltFormatHex, "0x2a"},
+		{&minusOne, FeltFormatSigned, "-1"},
+		{&minusBig, FeltFormatSigned, "-1048576"},
+		{&small, FeltFormatSigned, "42"},
+		{hello, FeltFormatShortString, "'hello'"},
+		{&small, FeltFormatShortString, "'*'"},
+		{&minusOne, FeltFormatShortString, "3618502788666131213697322783095070105623107215331596699973092056135872020480"},
+		{new(fp.Element), FeltFormatShortString, "0"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected, FormatFelt(test.felt, test.format), "format %s", test.format)
+	}
+}
+
+func TestParseFeltFormat(t *testing.T) {
+	for _, format := range []FeltFormat{FeltFormatDefault, FeltFormatDecimal, FeltFormatHex, FeltFormatSigned, FeltFormatShortString} {
+		parsed, err := ParseFeltFormat(format.String())
+		require.NoError(t, err)
+		assert.Equal(t, format, parsed)
+	}
+	parsed, err := ParseFeltFormat("")
+	require.NoError(t, err)
+	assert.Equal(t, FeltFormatDefault, parsed)
+
+	_, err = ParseFeltFormat("octal")
+	require.ErrorContains(t, err, "unknown felt format octal")
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"fmt"
 
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 	"github.com/holiman/uint256"
 	"golang.org/x/crypto/sha3"
 )
@@ -31,6 +32,18 @@ func Keccak256(data []byte) ([]byte, error) {
 	return hasher.Sum(nil), nil // Return the hash and a nil error.
 }
 
+// StarknetKeccak computes the Keccak-256 hash of the input data truncated to its
+// 250 low bits, from which Starknet derives the selectors of entry point names.
+func StarknetKeccak(data []byte) fp.Element {
+	hasher := sha3.NewLegacyKeccak256()
+	hasher.Write(data)
+	hash := hasher.Sum(nil)
+	hash[0] &= 0x03
+	var felt fp.Element
+	felt.SetBytes(hash)
+	return felt
+}
+
 // ConvertToByteData converts a slice of uint64 to a slice of byte.
 func ConvertToByteData(input []uint64) []byte {
 	byteData := make([]byte, len(input)*8) // 8 bytes per uint64
@@ -40,6 +40,16 @@ func TestKeccak256(t *testing.T) {
 	//57e46e893805ca9503660cd6ef2b39e24750a502ed7c71270f214dcb114b7113
 }
 
+func TestStarknetKeccak(t *testing.T) {
+	selector := StarknetKeccak([]byte("transfer"))
+	require.Equal(t, "83afd3f4caedc6eebf44246fe54e38c95e3179a5ec9ea81740eca5b482d12e", selector.Text(16))
+
+	// keccak("") is c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470,
+	// whose 6 high bits are cleared
+	empty := StarknetKeccak(nil)
+	require.Equal(t, "1d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", empty.Text(16))
+}
+
 func TestU128Split(t *testing.T) {
 	tests := []struct {
 		input        string
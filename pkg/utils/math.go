@@ -118,24 +118,22 @@ func Int16FromFelt(n *fp.Element) (int16, bool) {
 	return Int16FromBigInt(bigN)
 }
 
+// Int16FromBigInt interprets n modulo P as a signed value in (-P/2, P/2], so that both
+// -3 and P - 3 give -3, and reports whether it fits in [-2**15, 2**15) like the
+// offsets of the reference VM
 func Int16FromBigInt(n *big.Int) (int16, bool) {
 	mod := fp.Modulus()
-	negN := new(big.Int).Sub(mod, n)
-	maxInt16 := new(big.Int).SetInt64(int64(math.MaxInt16))
-
-	var result int64
-	if n.Cmp(negN) == 1 {
-		if negN.Cmp(maxInt16) == 1 {
-			return 0, false
-		}
-		result = -negN.Int64()
-	} else {
-		if n.Cmp(maxInt16) == 1 {
-			return 0, false
-		}
-		result = n.Int64()
+	value := new(big.Int).Mod(n, mod)
+	if value.Cmp(new(big.Int).Rsh(mod, 1)) == 1 {
+		value.Sub(value, mod)
+	}
+	if !value.IsInt64() {
+		return 0, false
+	}
+	result := value.Int64()
+	if result > math.MaxInt16 || result < math.MinInt16 {
+		return 0, false
 	}
-
 	return int16(result), true
 }
 
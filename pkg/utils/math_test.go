@@ -36,6 +36,40 @@ func TestOffsetRightNoOverflow(t *testing.T) {
 	assert.False(t, isOverflow)
 }
 
+func TestInt16FromBigInt(t *testing.T) {
+	prime := fp.Modulus()
+	fromPrime := func(delta int64) *big.Int {
+		return new(big.Int).Add(prime, big.NewInt(delta))
+	}
+	testCases := []struct {
+		value    *big.Int
+		expected int16
+		ok       bool
+	}{
+		{big.NewInt(0), 0, true},
+		{big.NewInt(32767), 32767, true},
+		{big.NewInt(32768), 0, false},
+		{big.NewInt(-3), -3, true},
+		{big.NewInt(-32768), -32768, true},
+		{big.NewInt(-32769), 0, false},
+		{big.NewInt(-100000), 0, false},
+		{fromPrime(-3), -3, true},
+		{fromPrime(-32768), -32768, true},
+		{fromPrime(-32769), 0, false},
+		{fromPrime(5), 5, true},
+	}
+	for _, test := range testCases {
+		result, ok := Int16FromBigInt(test.value)
+		assert.Equal(t, test.ok, ok, test.value.String())
+		assert.Equal(t, test.expected, result, test.value.String())
+	}
+
+	felt := new(fp.Element).SetInt64(-32768)
+	result, ok := Int16FromFelt(felt)
+	assert.True(t, ok)
+	assert.Equal(t, int16(-32768), result)
+}
+
 func TestFeltDivRem(t *testing.T) {
 	type testCase struct {
 		a   fp.Element
@@ -0,0 +1,33 @@
+package utils
+
+import (
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	pedersenhash "github.com/consensys/gnark-crypto/ecc/stark-curve/pedersen-hash"
+)
+
+// PedersenHash computes the Pedersen hash of two felts, as the pedersen builtin does.
+func PedersenHash(a, b *fp.Element) fp.Element {
+	return pedersenhash.Pedersen(a, b)
+}
+
+// PedersenHashMany computes the Pedersen hash chain of the data, as
+// `compute_hash_chain` of cairo-lang: the last element is hashed with the one before
+// it, and so on until the first one, i.e. h(d0, h(d1, ... h(dn-1, dn))). A single
+// element is its own hash and an empty chain hashes to zero.
+func PedersenHashMany(data []*fp.Element) fp.Element {
+	if len(data) == 0 {
+		return fp.Element{}
+	}
+	hash := *data[len(data)-1]
+	for i := len(data) - 2; i >= 0; i-- {
+		hash = pedersenhash.Pedersen(data[i], &hash)
+	}
+	return hash
+}
+
+// ComputeHashOnElements computes the Pedersen hash of the data followed by its
+// length, starting from zero, as `compute_hash_on_elements` of cairo-lang, with
+// which Starknet hashes calldata and transactions.
+func ComputeHashOnElements(data []*fp.Element) fp.Element {
+	return pedersenhash.PedersenArray(data...)
+}
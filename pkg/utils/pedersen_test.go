@@ -0,0 +1,85 @@
+package utils
+
+import (
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+func feltsFromStrings(t *testing.T, values ...string) []*fp.Element {
+	felts := make([]*fp.Element, len(values))
+	for i, value := range values {
+		felt, err := new(fp.Element).SetString(value)
+		require.NoError(t, err)
+		felts[i] = felt
+	}
+	return felts
+}
+
+func TestPedersenHash(t *testing.T) {
+	inputs := feltsFromStrings(t, "1", "2")
+	expected := feltsFromStrings(t, "0x5bb9440e27889a364bcb678b1f679ecd1347acdedcbf36e83494f857cc58026")
+	require.Equal(t, *expected[0], PedersenHash(inputs[0], inputs[1]))
+}
+
+func TestPedersenHashMany(t *testing.T) {
+	data := feltsFromStrings(t, "1", "2", "3")
+	inner := PedersenHash(data[1], data[2])
+	require.Equal(t, PedersenHash(data[0], &inner), PedersenHashMany(data))
+
+	require.Equal(t, *data[0], PedersenHashMany(data[:1]))
+	require.Equal(t, fp.Element{}, PedersenHashMany(nil))
+}
+
+func TestComputeHashOnElements(t *testing.T) {
+	// h(h(h(0, 1), 2), 2), the length being hashed last
+	data := feltsFromStrings(t, "1", "2")
+	var hash fp.Element
+	hash = PedersenHash(&hash, data[0])
+	hash = PedersenHash(&hash, data[1])
+	two := new(fp.Element).SetUint64(2)
+	require.Equal(t, PedersenHash(&hash, two), ComputeHashOnElements(data))
+
+	// h(0, 0)
+	expected := feltsFromStrings(t, "0x49ee3eba8c1600700ee1b87eb599f16716b0b1022947733551fde4050ca6804")
+	require.Equal(t, *expected[0], ComputeHashOnElements(nil))
+}
+
+func TestPedersenHashWithTables(t *testing.T) {
+	var minusOne fp.Element
+	minusOne.SetOne().Neg(&minusOne)
+	inputs := append(
+		feltsFromStrings(t, "0", "1", "2", "0x800000000000011000000000000000000000000000000000000000000000000", "0xff00ff"),
+		&minusOne,
+	)
+	for i := 0; i < 8; i++ {
+		var random fp.Element
+		_, err := random.SetRandom()
+		require.NoError(t, err)
+		inputs = append(inputs, &random)
+	}
+	for _, a := range inputs {
+		for _, b := range inputs {
+			require.Equal(t, PedersenHash(a, b), PedersenHashWithTables(a, b), "a=%s, b=%s", a, b)
+		}
+	}
+}
+
+func BenchmarkPedersenHash(b *testing.B) {
+	x, y := new(fp.Element).SetUint64(3), new(fp.Element).SetUint64(4)
+	x.Neg(x)
+	for i := 0; i < b.N; i++ {
+		*x = PedersenHash(x, y)
+	}
+}
+
+func BenchmarkPedersenHashWithTables(b *testing.B) {
+	x, y := new(fp.Element).SetUint64(3), new(fp.Element).SetUint64(4)
+	x.Neg(x)
+	PedersenHashWithTables(x, y)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		*x = PedersenHashWithTables(x, y)
+	}
+}
@@ -0,0 +1,158 @@
+package utils
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// Schedule logs the order in which the tasks run on goroutines complete, and
+// replays a logged order, so that the features running tasks concurrently produce
+// the same artifacts on every run. A task is named by its domain, one per pool of
+// tasks, and by a key unique in the domain, neither depending on timing, so that the
+// log of a run can be replayed on any machine. When replaying, no goroutine is
+// started: the tasks are queued and Settle runs them on the caller in the logged
+// order. All the methods are no-ops on a nil schedule, which runs the tasks on
+// goroutines without logging
+type Schedule struct {
+	mu        sync.Mutex
+	replaying bool
+	log       []string
+	// log entries already run, when replaying
+	replayed []bool
+	// domains created so far, by feature
+	domains map[string]int
+	// tasks waiting for Settle, by domain, in the order they were queued
+	queued map[string][]scheduledTask
+	// domains settled at least once
+	settled map[string]bool
+	errs    []error
+}
+
+type scheduledTask struct {
+	key string
+	run func()
+}
+
+// RecordSchedule returns a schedule logging the order the tasks complete in
+func RecordSchedule() *Schedule {
+	return &Schedule{domains: make(map[string]int)}
+}
+
+// ReplaySchedule returns a schedule running the tasks in the order of a log
+// returned by Log
+func ReplaySchedule(log []string) *Schedule {
+	return &Schedule{
+		replaying: true,
+		log:       log,
+		replayed:  make([]bool, len(log)),
+		domains:   make(map[string]int),
+		queued:    make(map[string][]scheduledTask),
+		settled:   make(map[string]bool),
+	}
+}
+
+// Replaying tells whether the tasks have to be queued rather than started
+func (s *Schedule) Replaying() bool {
+	return s != nil && s.replaying
+}
+
+// Domain returns a new domain for the tasks of a feature, numbered by creation
+// order. It must be called from the vm thread so that the numbering is the same on
+// every run
+func (s *Schedule) Domain(feature string) string {
+	if s == nil {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	n := s.domains[feature]
+	s.domains[feature] = n + 1
+	return fmt.Sprintf("%s:%d", feature, n)
+}
+
+// Done logs the completion of a task, when recording
+func (s *Schedule) Done(domain, key string) {
+	if s == nil || s.replaying {
+		return
+	}
+	s.mu.Lock()
+	s.log = append(s.log, domain+"/"+key)
+	s.mu.Unlock()
+}
+
+// Queue keeps a task for the next Settle of its domain, when replaying
+func (s *Schedule) Queue(domain, key string, task func()) {
+	s.mu.Lock()
+	s.queued[domain] = append(s.queued[domain], scheduledTask{key: key, run: task})
+	s.mu.Unlock()
+}
+
+// Settle runs the queued tasks of a domain in the logged order, when replaying.
+// A task missing from the log is a divergence, reported by Err, and still runs after
+// the logged ones, in the order it was queued
+func (s *Schedule) Settle(domain string) {
+	if !s.Replaying() {
+		return
+	}
+	s.mu.Lock()
+	tasks := s.queued[domain]
+	delete(s.queued, domain)
+	s.settled[domain] = true
+
+	order := make([]func(), 0, len(tasks))
+	for i, id := range s.log {
+		if s.replayed[i] || !strings.HasPrefix(id, domain+"/") {
+			continue
+		}
+		key := id[len(domain)+1:]
+		for j := range tasks {
+			if tasks[j].run != nil && tasks[j].key == key {
+				order = append(order, tasks[j].run)
+				tasks[j].run = nil
+				s.replayed[i] = true
+				break
+			}
+		}
+	}
+	for j := range tasks {
+		if tasks[j].run != nil {
+			s.errs = append(s.errs, fmt.Errorf("task %s/%s is missing from the schedule", domain, tasks[j].key))
+			order = append(order, tasks[j].run)
+		}
+	}
+	s.mu.Unlock()
+
+	for _, task := range order {
+		task()
+	}
+}
+
+// Log returns the ids of the tasks in the order they completed, when recording
+func (s *Schedule) Log() []string {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.log...)
+}
+
+// Err reports how the replayed run diverged from the logged one: the tasks missing
+// from the log and the logged tasks of a settled domain that never ran
+func (s *Schedule) Err() error {
+	if !s.Replaying() {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	errs := append([]error(nil), s.errs...)
+	for i, id := range s.log {
+		domain, _, _ := strings.Cut(id, "/")
+		if !s.replayed[i] && s.settled[domain] {
+			errs = append(errs, fmt.Errorf("task %s of the schedule never ran", id))
+		}
+	}
+	return errors.Join(errs...)
+}
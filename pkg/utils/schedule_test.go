@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScheduleRecordReplay(t *testing.T) {
+	recording := RecordSchedule()
+	domain := recording.Domain("task")
+	require.Equal(t, "task:0", domain)
+	require.Equal(t, "task:1", recording.Domain("task"))
+	require.Equal(t, "other:0", recording.Domain("other"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(key string) {
+			defer wg.Done()
+			recording.Done(domain, key)
+		}(strconv.Itoa(i))
+	}
+	wg.Wait()
+	log := recording.Log()
+	require.Len(t, log, 8)
+	require.NoError(t, recording.Err())
+
+	// the tasks run in the logged order whatever the order they are queued in
+	replaying := ReplaySchedule(log)
+	require.True(t, replaying.Replaying())
+	domain = replaying.Domain("task")
+	var order []string
+	for i := 7; i >= 0; i-- {
+		key := strconv.Itoa(i)
+		replaying.Queue(domain, key, func() {
+			order = append(order, domain+"/"+key)
+		})
+	}
+	require.Empty(t, order)
+	replaying.Settle(domain)
+	require.Equal(t, log, order)
+	require.NoError(t, replaying.Err())
+}
+
+func TestScheduleDivergence(t *testing.T) {
+	schedule := ReplaySchedule([]string{"task:0/1", "task:0/2", "other:0/1"})
+	domain := schedule.Domain("task")
+	var order []string
+	for _, key := range []string{"3", "1"} {
+		key := key
+		schedule.Queue(domain, key, func() {
+			order = append(order, key)
+		})
+	}
+	schedule.Settle(domain)
+	// the task missing from the log still runs, after the logged ones, and the
+	// domains never settled are not reported
+	require.Equal(t, []string{"1", "3"}, order)
+	require.EqualError(t, schedule.Err(), "task task:0/3 is missing from the schedule\ntask task:0/2 of the schedule never ran")
+}
+
+func TestNilSchedule(t *testing.T) {
+	var schedule *Schedule
+	require.False(t, schedule.Replaying())
+	require.Equal(t, "", schedule.Domain("task"))
+	schedule.Done("", "0")
+	schedule.Settle("")
+	require.Nil(t, schedule.Log())
+	require.NoError(t, schedule.Err())
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+var (
+	mask128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+	mod256  = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// Uint256 is the `Uint256` struct of the cairo common library: an integer
+// stored as two felts, its low and high 128 bits
+type Uint256 struct {
+	Low  fp.Element
+	High fp.Element
+}
+
+// Uint512 is the `Uint512` struct of the cairo common library: an integer
+// stored as four felts of 128 bits, d0 being the least significant one
+type Uint512 struct {
+	D0 fp.Element
+	D1 fp.Element
+	D2 fp.Element
+	D3 fp.Element
+}
+
+func NewUint256(low, high *fp.Element) Uint256 {
+	return Uint256{Low: *low, High: *high}
+}
+
+// Uint256FromBigInt splits a non negative integer into 128 bits limbs. As in the
+// python hints, the high limb is n >> 128 and is not truncated to 128 bits
+func Uint256FromBigInt(n *big.Int) Uint256 {
+	var u Uint256
+	u.Low.SetBigInt(new(big.Int).And(n, mask128))
+	u.High.SetBigInt(new(big.Int).Rsh(n, 128))
+	return u
+}
+
+// BigInt packs the limbs as low + high * 2**128. Limbs are not required to fit in
+// 128 bits, like the `pack` function of the python hints
+func (u Uint256) BigInt() *big.Int {
+	high := u.High.BigInt(new(big.Int))
+	high.Lsh(high, 128)
+	return high.Add(high, u.Low.BigInt(new(big.Int)))
+}
+
+func (u Uint256) String() string {
+	return u.BigInt().String()
+}
+
+// Add returns u + v modulo 2**256
+func (u Uint256) Add(v Uint256) Uint256 {
+	sum := new(big.Int).Add(u.BigInt(), v.BigInt())
+	return Uint256FromBigInt(sum.Mod(sum, mod256))
+}
+
+// Sub returns u - v modulo 2**256
+func (u Uint256) Sub(v Uint256) Uint256 {
+	diff := new(big.Int).Sub(u.BigInt(), v.BigInt())
+	return Uint256FromBigInt(diff.Mod(diff, mod256))
+}
+
+// Mul returns the full product of u and v
+func (u Uint256) Mul(v Uint256) Uint512 {
+	return Uint512FromBigInt(new(big.Int).Mul(u.BigInt(), v.BigInt()))
+}
+
+// DivMod returns the quotient and the remainder of the euclidean division of u by v
+func (u Uint256) DivMod(v Uint256) (Uint256, Uint256, error) {
+	divisor := v.BigInt()
+	if divisor.Sign() == 0 {
+		return Uint256{}, Uint256{}, fmt.Errorf("division by zero")
+	}
+	quotient, remainder := new(big.Int).DivMod(u.BigInt(), divisor, new(big.Int))
+	return Uint256FromBigInt(quotient), Uint256FromBigInt(remainder), nil
+}
+
+// AddMod returns (u + v) % p
+func (u Uint256) AddMod(v Uint256, p *big.Int) Uint256 {
+	sum := new(big.Int).Add(u.BigInt(), v.BigInt())
+	return Uint256FromBigInt(sum.Mod(sum, p))
+}
+
+// SubMod returns (u - v) % p
+func (u Uint256) SubMod(v Uint256, p *big.Int) Uint256 {
+	diff := new(big.Int).Sub(u.BigInt(), v.BigInt())
+	return Uint256FromBigInt(diff.Mod(diff, p))
+}
+
+// MulMod returns (u * v) % p
+func (u Uint256) MulMod(v Uint256, p *big.Int) Uint256 {
+	product := new(big.Int).Mul(u.BigInt(), v.BigInt())
+	return Uint256FromBigInt(product.Mod(product, p))
+}
+
+// InvMod returns the inverse of u modulo p, which must be coprime with u
+func (u Uint256) InvMod(p *big.Int) (Uint256, error) {
+	inverse := new(big.Int).ModInverse(u.BigInt(), p)
+	if inverse == nil {
+		return Uint256{}, fmt.Errorf("%s is not invertible modulo %s", u, p)
+	}
+	return Uint256FromBigInt(inverse), nil
+}
+
+// Uint512FromBigInt splits a non negative integer into 128 bits limbs, the most
+// significant limb being n >> 384
+func Uint512FromBigInt(n *big.Int) Uint512 {
+	var u Uint512
+	u.D0.SetBigInt(new(big.Int).And(n, mask128))
+	u.D1.SetBigInt(new(big.Int).And(new(big.Int).Rsh(n, 128), mask128))
+	u.D2.SetBigInt(new(big.Int).And(new(big.Int).Rsh(n, 256), mask128))
+	u.D3.SetBigInt(new(big.Int).Rsh(n, 384))
+	return u
+}
+
+// Uint512FromUint256 returns the integer whose low and high 256 bits are low and high
+func Uint512FromUint256(low, high Uint256) Uint512 {
+	return Uint512{D0: low.Low, D1: low.High, D2: high.Low, D3: high.High}
+}
+
+// BigInt packs the limbs as the sum of d_i * 2**(128 * i)
+func (u Uint512) BigInt() *big.Int {
+	result := new(big.Int)
+	for _, limb := range []*fp.Element{&u.D3, &u.D2, &u.D1, &u.D0} {
+		result.Lsh(result, 128)
+		result.Add(result, limb.BigInt(new(big.Int)))
+	}
+	return result
+}
+
+func (u Uint512) String() string {
+	return u.BigInt().String()
+}
+
+// Low returns the low 256 bits, i.e. the limbs d0 and d1
+func (u Uint512) Low() Uint256 {
+	return Uint256{Low: u.D0, High: u.D1}
+}
+
+// High returns the high 256 bits, i.e. the limbs d2 and d3
+func (u Uint512) High() Uint256 {
+	return Uint256{Low: u.D2, High: u.D3}
+}
+
+// Mod returns u % p, p being at most 256 bits long
+func (u Uint512) Mod(p *big.Int) Uint256 {
+	return Uint256FromBigInt(new(big.Int).Mod(u.BigInt(), p))
+}
+
+// InvMod returns the inverse of u modulo p, which must be coprime with u
+func (u Uint512) InvMod(p *big.Int) (Uint256, error) {
+	inverse := new(big.Int).ModInverse(u.BigInt(), p)
+	if inverse == nil {
+		return Uint256{}, fmt.Errorf("%s is not invertible modulo %s", u, p)
+	}
+	return Uint256FromBigInt(inverse), nil
+}
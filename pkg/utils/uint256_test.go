@@ -0,0 +1,93 @@
+package utils
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func bigIntString(s string) *big.Int {
+	n, ok := new(big.Int).SetString(s, 0)
+	if !ok {
+		panic("invalid number " + s)
+	}
+	return n
+}
+
+func TestUint256Split(t *testing.T) {
+	n := bigIntString("0x1234567890abcdef1234567890abcdeffedcba0987654321fedcba0987654321")
+	u := Uint256FromBigInt(n)
+	assert.Equal(t, *new(fp.Element).SetBigInt(bigIntString("0xfedcba0987654321fedcba0987654321")), u.Low)
+	assert.Equal(t, *new(fp.Element).SetBigInt(bigIntString("0x1234567890abcdef1234567890abcdef")), u.High)
+	assert.Equal(t, n, u.BigInt())
+
+	// limbs above 128 bits are packed as a sum, like in the python hints
+	overflowing := NewUint256(new(fp.Element).SetBigInt(mask128), new(fp.Element).SetUint64(1))
+	overflowing.Low.Add(&overflowing.Low, &FeltOne)
+	assert.Equal(t, bigIntString("0x200000000000000000000000000000000"), overflowing.BigInt())
+}
+
+func TestUint256Arithmetic(t *testing.T) {
+	max := Uint256FromBigInt(new(big.Int).Sub(mod256, big.NewInt(1)))
+	one := Uint256FromBigInt(big.NewInt(1))
+	two := Uint256FromBigInt(big.NewInt(2))
+
+	// wraps around 2**256
+	assert.Equal(t, Uint256{}, max.Add(one))
+	assert.Equal(t, max, one.Sub(two))
+
+	product := max.Mul(max)
+	expected := new(big.Int).Mul(max.BigInt(), max.BigInt())
+	assert.Equal(t, expected, product.BigInt())
+	assert.Equal(t, Uint512FromUint256(Uint256{Low: product.D0, High: product.D1}, Uint256{Low: product.D2, High: product.D3}), product)
+
+	seven := Uint256FromBigInt(big.NewInt(7))
+	quotient, remainder, err := max.DivMod(seven)
+	require.NoError(t, err)
+	q, r := new(big.Int).DivMod(max.BigInt(), big.NewInt(7), new(big.Int))
+	assert.Equal(t, q, quotient.BigInt())
+	assert.Equal(t, r, remainder.BigInt())
+
+	_, _, err = max.DivMod(Uint256{})
+	require.ErrorContains(t, err, "division by zero")
+}
+
+func TestUint256ModularArithmetic(t *testing.T) {
+	p := big.NewInt(97)
+	a := Uint256FromBigInt(big.NewInt(90))
+	b := Uint256FromBigInt(big.NewInt(10))
+
+	assert.Equal(t, big.NewInt(3), a.AddMod(b, p).BigInt())
+	assert.Equal(t, big.NewInt(17), b.SubMod(a, p).BigInt())
+	assert.Equal(t, big.NewInt(27), a.MulMod(b, p).BigInt())
+
+	inverse, err := b.InvMod(p)
+	require.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), b.MulMod(inverse, p).BigInt())
+
+	_, err = b.InvMod(big.NewInt(20))
+	require.ErrorContains(t, err, "10 is not invertible modulo 20")
+}
+
+func TestUint512(t *testing.T) {
+	n := new(big.Int).Lsh(big.NewInt(5), 400)
+	n.Add(n, big.NewInt(3))
+	u := Uint512FromBigInt(n)
+	assert.Equal(t, *new(fp.Element).SetUint64(3), u.D0)
+	assert.Equal(t, FeltZero, u.D1)
+	assert.Equal(t, FeltZero, u.D2)
+	assert.Equal(t, *new(fp.Element).SetUint64(5 << 16), u.D3)
+	assert.Equal(t, n, u.BigInt())
+	assert.Equal(t, Uint256{Low: u.D0}, u.Low())
+	assert.Equal(t, Uint256{High: u.D3}, u.High())
+
+	p := big.NewInt(1000003)
+	assert.Equal(t, new(big.Int).Mod(n, p), u.Mod(p).BigInt())
+	inverse, err := u.InvMod(p)
+	require.NoError(t, err)
+	check := new(big.Int).Mul(n, inverse.BigInt())
+	assert.Equal(t, big.NewInt(1), check.Mod(check, p))
+}
@@ -0,0 +1,184 @@
+package builtins
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// AirPrivateInputBuiltin is implemented by the builtins whose instances are part of
+// the AIR private input, i.e. all of them except the output and the custom builtins
+type AirPrivateInputBuiltin interface {
+	memory.BuiltinRunner
+	// AirPrivateInputName returns the key of the builtin in the AIR private input, its
+	// name in the layouts of the prover
+	AirPrivateInputName() string
+	// AirPrivateInput returns the private input of the instances of the builtin
+	// segment, marshalled as the prover expects it
+	AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error)
+}
+
+// AirPrivateInputStreamer is implemented by the builtins whose private input is a
+// list of instances, so that it can be encoded one instance at a time instead of
+// building the whole list first
+type AirPrivateInputStreamer interface {
+	AirPrivateInputBuiltin
+	// StreamAirPrivateInput calls yield with the private input of each instance of
+	// the segment, in the order of the list returned by AirPrivateInput
+	StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error
+}
+
+var (
+	_ AirPrivateInputStreamer = (*RangeCheck)(nil)
+	_ AirPrivateInputStreamer = (*Pedersen)(nil)
+	_ AirPrivateInputStreamer = (*ECDSA)(nil)
+	_ AirPrivateInputStreamer = (*Bitwise)(nil)
+	_ AirPrivateInputStreamer = (*EcOp)(nil)
+	_ AirPrivateInputStreamer = (*Keccak)(nil)
+	_ AirPrivateInputStreamer = (*Poseidon)(nil)
+)
+
+var (
+	_ AirPrivateInputBuiltin = (*RangeCheck)(nil)
+	_ AirPrivateInputBuiltin = (*Pedersen)(nil)
+	_ AirPrivateInputBuiltin = (*ECDSA)(nil)
+	_ AirPrivateInputBuiltin = (*Bitwise)(nil)
+	_ AirPrivateInputBuiltin = (*EcOp)(nil)
+	_ AirPrivateInputBuiltin = (*Keccak)(nil)
+	_ AirPrivateInputBuiltin = (*Poseidon)(nil)
+	_ AirPrivateInputBuiltin = (*ModBuiltin)(nil)
+)
+
+// range_check or range_check96
+func (r *RangeCheck) AirPrivateInputName() string {
+	return r.String()
+}
+
+func (r *RangeCheck) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return r.GetAirPrivateInput(segment), nil
+}
+
+func (r *RangeCheck) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return r.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinRangeCheck](yield))
+}
+
+func (p *Pedersen) AirPrivateInputName() string {
+	return PedersenName
+}
+
+func (p *Pedersen) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return p.GetAirPrivateInput(segment), nil
+}
+
+func (p *Pedersen) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return p.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinPedersen](yield))
+}
+
+func (e *ECDSA) AirPrivateInputName() string {
+	return ECDSAName
+}
+
+func (e *ECDSA) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return e.GetAirPrivateInput(segment)
+}
+
+func (e *ECDSA) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return e.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinECDSA](yield))
+}
+
+func (b *Bitwise) AirPrivateInputName() string {
+	return BitwiseName
+}
+
+func (b *Bitwise) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return b.GetAirPrivateInput(segment), nil
+}
+
+func (b *Bitwise) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return b.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinBitwise](yield))
+}
+
+func (e *EcOp) AirPrivateInputName() string {
+	return EcOpName
+}
+
+func (e *EcOp) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return e.GetAirPrivateInput(segment), nil
+}
+
+func (e *EcOp) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return e.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinEcOp](yield))
+}
+
+func (k *Keccak) AirPrivateInputName() string {
+	return KeccakName
+}
+
+func (k *Keccak) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return k.GetAirPrivateInput(segment), nil
+}
+
+func (k *Keccak) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return k.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinKeccak](yield))
+}
+
+func (p *Poseidon) AirPrivateInputName() string {
+	return PoseidonName
+}
+
+func (p *Poseidon) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return p.GetAirPrivateInput(segment), nil
+}
+
+func (p *Poseidon) StreamAirPrivateInput(mem *memory.Memory, segment *memory.Segment, yield func(instance any) error) error {
+	return p.eachAirPrivateInput(segment, yieldAny[AirPrivateBuiltinPoseidon](yield))
+}
+
+// add_mod or mul_mod, where the builtin is named AddMod or MulMod in the VM
+func (m *ModBuiltin) AirPrivateInputName() string {
+	if m.modBuiltinType == Mul {
+		return "mul_mod"
+	}
+	return "add_mod"
+}
+
+func (m *ModBuiltin) AirPrivateInput(mem *memory.Memory, segment *memory.Segment) (any, error) {
+	return m.GetAirPrivateInput(mem, segment)
+}
+
+// Wraps the yield of StreamAirPrivateInput, for the typed instances of a builtin
+func yieldAny[T any](yield func(instance any) error) func(T) error {
+	return func(instance T) error {
+		return yield(instance)
+	}
+}
+
+// Calls yield with the index and the hex values of the input cells of each instance
+// of the segment that has any of them written, in order, the cells not written
+// being empty strings. The inputs are reused between the calls
+func eachAirPrivateInstance(segment *memory.Segment, cellsPerInstance, inputCellsPerInstance uint64, yield func(idx int, inputs []string) error) error {
+	inputs := make([]string, inputCellsPerInstance)
+	valueBig := big.Int{}
+	for base := uint64(0); base < segment.RealLen(); base += cellsPerInstance {
+		written := false
+		for i := range inputs {
+			// peeked, as Read would extend the segment and run the deductions
+			value := segment.Peek(base + uint64(i))
+			if !value.Known() {
+				inputs[i] = ""
+				continue
+			}
+			value.Felt.BigInt(&valueBig)
+			inputs[i] = fmt.Sprintf("0x%x", &valueBig)
+			written = true
+		}
+		if !written {
+			continue
+		}
+		if err := yield(int(base/cellsPerInstance), inputs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
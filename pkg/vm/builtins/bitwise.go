@@ -3,8 +3,6 @@ package builtins
 import (
 	"errors"
 	"fmt"
-	"math/big"
-	"sort"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -97,6 +95,73 @@ func (b *Bitwise) InferValue(segment *memory.Segment, offset uint64) error {
 	return nil
 }
 
+// VerifyInstances checks the instances of the segment in a single pass, for the
+// security checks: their inputs must fit in 251 bits, as the Python VM asserts when
+// deducing, and the output cells written by the program must be the and, xor and or
+// of the inputs. Each instance reads its inputs once, where deducing its output cells
+// again would read them per cell. The instances missing an input are left to the
+// security checks, which report them
+func (b *Bitwise) VerifyInstances(segment *memory.Segment) error {
+	used := segment.Len()
+	for base := uint64(0); base < used; base += cellsPerBitwise {
+		x, y := segment.Peek(base), segment.Peek(base+1)
+		if !x.Known() || !y.Known() {
+			continue
+		}
+		var inputs [inputCellsPerBitwise][32]byte
+		for cell, value := range []*memory.MemoryValue{&x, &y} {
+			felt, err := value.FieldElement()
+			if err != nil {
+				return fmt.Errorf("instance %d: input cell %d: %w", base/cellsPerBitwise, cell, err)
+			}
+			inputs[cell] = felt.Bytes()
+			// the bits above the 251 lowest ones are in the first byte
+			if inputs[cell][0]&0xf8 != 0 {
+				return fmt.Errorf("instance %d: input cell %d is %s, which is not smaller than 2^251", base/cellsPerBitwise, cell, felt)
+			}
+		}
+
+		var outputs [cellsPerBitwise - inputCellsPerBitwise][32]byte
+		for i := range inputs[0] {
+			outputs[0][i] = inputs[0][i] & inputs[1][i]
+			outputs[1][i] = inputs[0][i] ^ inputs[1][i]
+			outputs[2][i] = inputs[0][i] | inputs[1][i]
+		}
+		for i := range outputs {
+			cell := uint64(inputCellsPerBitwise + i)
+			written := segment.Peek(base + cell)
+			if !written.Known() {
+				continue
+			}
+			var deduced fp.Element
+			deduced.SetBytes(outputs[i][:])
+			deducedValue := memory.MemoryValueFromFieldElement(&deduced)
+			if !deducedValue.Equal(&written) {
+				return fmt.Errorf("instance %d: cell %d is %s but the builtin deduces %s", base/cellsPerBitwise, cell, &written, &deducedValue)
+			}
+		}
+	}
+	return nil
+}
+
+// DilutedUnitsPerInstance returns the units of the diluted pool an instance takes,
+// with the spacing and the bits of the pool, as get_used_diluted_check_units of the
+// Python VM: the 251 bits of the inputs are split into groups of a diluted value each,
+// and each group takes four units plus one when it is trimmed by the end of the bits
+func (b *Bitwise) DilutedUnitsPerInstance(spacing uint64, nBits uint64) uint64 {
+	const totalBits = 251
+	var groups, trimmed uint64
+	for start := uint64(0); start < totalBits; start += spacing * nBits {
+		for bit := start; bit < start+spacing && bit < totalBits; bit++ {
+			groups++
+			if bit+spacing*(nBits-1)+1 > totalBits {
+				trimmed++
+			}
+		}
+	}
+	return 4*groups + trimmed
+}
+
 func (b *Bitwise) String() string {
 	return BitwiseName
 }
@@ -112,46 +177,21 @@ type AirPrivateBuiltinBitwise struct {
 }
 
 func (b *Bitwise) GetAirPrivateInput(bitwiseSegment *memory.Segment) []AirPrivateBuiltinBitwise {
-	valueMapping := make(map[int]AirPrivateBuiltinBitwise)
-	for index, value := range bitwiseSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		idx, typ := index/cellsPerBitwise, index%cellsPerBitwise
-		if typ >= 2 {
-			continue
-		}
-
-		builtinValue, exists := valueMapping[idx]
-		if !exists {
-			builtinValue = AirPrivateBuiltinBitwise{Index: idx}
-		}
-
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		if typ == 0 {
-			builtinValue.X = valueHex
-		} else {
-			builtinValue.Y = valueHex
-		}
-		valueMapping[idx] = builtinValue
-	}
-
 	values := make([]AirPrivateBuiltinBitwise, 0)
-
-	sortedIndexes := make([]int, 0, len(valueMapping))
-	for index := range valueMapping {
-		sortedIndexes = append(sortedIndexes, index)
-	}
-	sort.Ints(sortedIndexes)
-	for _, index := range sortedIndexes {
-		value := valueMapping[index]
+	_ = b.eachAirPrivateInput(bitwiseSegment, func(value AirPrivateBuiltinBitwise) error {
 		values = append(values, value)
-	}
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (b *Bitwise) eachAirPrivateInput(bitwiseSegment *memory.Segment, yield func(AirPrivateBuiltinBitwise) error) error {
+	return eachAirPrivateInstance(bitwiseSegment, cellsPerBitwise, inputCellsPerBitwise, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinBitwise{Index: idx, X: inputs[0], Y: inputs[1]})
+	})
+}
+
 func (b *Bitwise) GetCellsPerInstance() uint64 {
 	return cellsPerBitwise
 }
@@ -163,3 +203,16 @@ func (b *Bitwise) GetStopPointer() uint64 {
 func (b *Bitwise) SetStopPointer(stopPointer uint64) {
 	b.stopPointer = stopPointer
 }
+
+// the builtin has no state besides its stop pointer
+func (b *Bitwise) Snapshot() Snapshot {
+	return Snapshot{builtin: BitwiseName, stopPointer: b.stopPointer}
+}
+
+func (b *Bitwise) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(b, &snapshot); err != nil {
+		return err
+	}
+	b.stopPointer = snapshot.stopPointer
+	return nil
+}
@@ -39,3 +39,45 @@ func TestBitwise(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb", xOrYFelt.Text(16))
 }
+
+func TestBitwiseVerifyInstances(t *testing.T) {
+	bitwise := &Bitwise{}
+	segment := memory.EmptySegment().WithBuiltinRunner(bitwise)
+	felt := func(value uint64) memory.MemoryValue {
+		return memory.MemoryValueFromUint(value)
+	}
+	// a deduced instance, one whose outputs are written by the program and a last one
+	// without its or cell
+	require.NoError(t, segment.WriteRange(0, []memory.MemoryValue{felt(12), felt(10)}))
+	for offset := uint64(2); offset < 5; offset++ {
+		_, err := segment.Read(offset)
+		require.NoError(t, err)
+	}
+	segment.BuiltinMode = memory.ValidateOnly
+	require.NoError(t, segment.WriteRange(5, []memory.MemoryValue{felt(3), felt(5), felt(1), felt(6), felt(7)}))
+	require.NoError(t, segment.WriteRange(10, []memory.MemoryValue{felt(3), felt(5), felt(1)}))
+	require.NoError(t, bitwise.VerifyInstances(segment))
+	require.NoError(t, CheckSegment(segment))
+
+	xor := felt(7)
+	require.NoError(t, segment.Write(13, &xor))
+	require.EqualError(t, bitwise.VerifyInstances(segment), "instance 2: cell 3 is 7 but the builtin deduces 6")
+
+	var large fp.Element
+	large.SetOne().Neg(&large)
+	segment = memory.EmptySegment().WithBuiltinRunner(bitwise)
+	largeValue := memory.MemoryValueFromFieldElement(&large)
+	require.NoError(t, segment.WriteRange(0, []memory.MemoryValue{felt(1), largeValue}))
+	require.ErrorContains(t, CheckSegment(segment), "instance 0: input cell 1 is")
+	require.ErrorContains(t, CheckSegment(segment), "which is not smaller than 2^251")
+}
+
+func TestBitwiseDilutedUnitsPerInstance(t *testing.T) {
+	bitwise := &Bitwise{}
+	// the values of the Python and Rust VMs
+	require.Equal(t, uint64(535), bitwise.DilutedUnitsPerInstance(12, 2))
+	require.Equal(t, uint64(150), bitwise.DilutedUnitsPerInstance(30, 56))
+	require.Equal(t, uint64(250), bitwise.DilutedUnitsPerInstance(50, 25))
+	// the diluted pool of the layouts
+	require.Equal(t, uint64(68), bitwise.DilutedUnitsPerInstance(4, 16))
+}
@@ -2,11 +2,14 @@ package builtins
 
 import (
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 	"strconv"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
 type BuiltinType uint8
@@ -33,9 +36,9 @@ func Runner(name BuiltinType) memory.BuiltinRunner {
 	case OutputType:
 		return &Output{}
 	case RangeCheckType:
-		return &RangeCheck{0, 8, 0}
+		return &RangeCheck{RangeCheckNParts: 8}
 	case RangeCheck96Type:
-		return &RangeCheck{0, 6, 0}
+		return &RangeCheck{RangeCheckNParts: 6}
 	case PedersenType:
 		return &Pedersen{}
 	case ECDSAType:
@@ -53,12 +56,190 @@ func Runner(name BuiltinType) memory.BuiltinRunner {
 	case MulModType:
 		return &ModBuiltin{modBuiltinType: Mul}
 	case SegmentArenaType:
-		panic("Not implemented")
+		return &SegmentArena{}
 	default:
+		if custom, ok := customBuiltin(name); ok {
+			return custom.NewRunner(0)
+		}
 		panic("Unknown builtin")
 	}
 }
 
+// Operations implemented by a builtin runner
+type BuiltinOperations struct {
+	// CheckWrite rejects invalid values
+	Validate bool
+	// InferValue computes unknown cells
+	Deduce bool
+}
+
+// Matches the validation and auto-deduction rules of the Python VM, except for the
+// output builtin which also rejects addresses in this VM
+var builtinOperations = map[BuiltinType]BuiltinOperations{
+	OutputType:       {Validate: true},
+	RangeCheckType:   {Validate: true},
+	RangeCheck96Type: {Validate: true},
+	PedersenType:     {Deduce: true},
+	ECDSAType:        {Validate: true},
+	KeccakType:       {Deduce: true},
+	BitwiseType:      {Deduce: true},
+	ECOPType:         {Deduce: true},
+	PoseidonType:     {Deduce: true},
+	// mod builtins cells are filled by the fill_memory hint
+	AddModeType: {},
+	MulModType:  {},
+}
+
+func Operations(builtin BuiltinType) BuiltinOperations {
+	if custom, ok := customBuiltin(builtin); ok {
+		return custom.Operations
+	}
+	return builtinOperations[builtin]
+}
+
+// Returns a runner with the same configuration as the given one but with its own state,
+// to be attached to an additional segment of the same builtin. Sharing a runner between
+// segments would mix up per-instance state such as the ECDSA signatures or the deduction
+// caches, as instances are identified by their offset inside the segment
+func NewSegmentRunner(runner memory.BuiltinRunner) memory.BuiltinRunner {
+	switch r := runner.(type) {
+	case *Output:
+		return &Output{}
+	case *RangeCheck:
+		return &RangeCheck{ratio: r.ratio, RangeCheckNParts: r.RangeCheckNParts}
+	case *Pedersen:
+		return &Pedersen{ratio: r.ratio, tables: r.tables}
+	case *ECDSA:
+		return &ECDSA{ratio: r.ratio, pool: r.pool.fresh(), schedule: r.schedule, deferred: r.deferred}
+	case *Keccak:
+		return &Keccak{ratio: r.ratio, cache: make(map[uint64]fp.Element)}
+	case *Bitwise:
+		return &Bitwise{ratio: r.ratio}
+	case *EcOp:
+		return &EcOp{ratio: r.ratio, cache: make(map[uint64]fp.Element)}
+	case *Poseidon:
+		return &Poseidon{ratio: r.ratio, cache: make(map[uint64]fp.Element)}
+	case *ModBuiltin:
+		return NewModBuiltin(r.ratio, r.wordBitLen, r.batchSize, r.modBuiltinType)
+	case *SegmentArena:
+		return &SegmentArena{}
+	case CustomRunner:
+		return r.NewSegmentRunner()
+	default:
+		panic(fmt.Sprintf("cannot create a segment runner for builtin %s", runner))
+	}
+}
+
+// Returns a deep copy of a runner, state included, so that a copy of the memory can
+// keep using it without affecting the original runner
+func CloneRunner(runner memory.BuiltinRunner) memory.BuiltinRunner {
+	switch r := runner.(type) {
+	case *memory.NoBuiltin:
+		return r
+	case *Output:
+		clone := *r
+		clone.pages = slices.Clone(r.pages)
+		clone.attributes = maps.Clone(r.attributes)
+		return &clone
+	case *RangeCheck:
+		clone := *r
+		return &clone
+	case *Pedersen:
+		clone := *r
+		clone.deduced = r.deduced.clone()
+		return &clone
+	case *ECDSA:
+		clone := *r
+		clone.Signatures = maps.Clone(r.Signatures)
+		clone.YParities = maps.Clone(r.YParities)
+		clone.PublicKeysY = maps.Clone(r.PublicKeysY)
+		clone.keys = maps.Clone(r.keys)
+		clone.deferredInstances = maps.Clone(r.deferredInstances)
+		clone.verified = r.verified.clone()
+		// the instances handed to the pool are verified in the clone, which must
+		// report the failures of the verifications still pending too
+		clone.pool = r.pool.fork()
+		return &clone
+	case *Keccak:
+		clone := *r
+		clone.cache = maps.Clone(r.cache)
+		return &clone
+	case *Bitwise:
+		clone := *r
+		return &clone
+	case *EcOp:
+		clone := *r
+		clone.cache = maps.Clone(r.cache)
+		return &clone
+	case *Poseidon:
+		clone := *r
+		clone.cache = maps.Clone(r.cache)
+		return &clone
+	case *ModBuiltin:
+		clone := *r
+		return &clone
+	case *SegmentArena:
+		clone := *r
+		return &clone
+	case CustomRunner:
+		return r.Clone()
+	default:
+		panic(fmt.Sprintf("cannot clone runner for builtin %s", runner))
+	}
+}
+
+// Allocates a new segment for a builtin already present in memory. The new segment
+// gets its own runner so its state is kept apart from the other segments of the builtin,
+// and the failure injected into the first segment, if any
+func AllocateAdditionalSegment(mem *memory.Memory, builtinName string) (memory.MemoryAddress, error) {
+	segment, ok := mem.FindSegmentWithBuiltin(builtinName)
+	if !ok {
+		return memory.UnknownAddress, fmt.Errorf("%s builtin segment not found", builtinName)
+	}
+	addr := mem.AllocateBuiltinSegment(NewSegmentRunner(segment.BuiltinRunner))
+	mem.Segments[addr.SegmentIndex].BuiltinMode = segment.BuiltinMode
+	mem.Segments[addr.SegmentIndex].Failure = segment.Failure
+	return addr, nil
+}
+
+// SnapshotRunner is implemented by the runners of all the builtins, so that the
+// state of a run can be checkpointed and rolled back along its memory. The runners
+// of custom builtins may implement it too
+type SnapshotRunner interface {
+	memory.BuiltinRunner
+	Snapshot() Snapshot
+	Restore(snapshot Snapshot) error
+}
+
+var (
+	_ SnapshotRunner = (*Output)(nil)
+	_ SnapshotRunner = (*RangeCheck)(nil)
+	_ SnapshotRunner = (*Pedersen)(nil)
+	_ SnapshotRunner = (*ECDSA)(nil)
+	_ SnapshotRunner = (*Keccak)(nil)
+	_ SnapshotRunner = (*Bitwise)(nil)
+	_ SnapshotRunner = (*EcOp)(nil)
+	_ SnapshotRunner = (*Poseidon)(nil)
+	_ SnapshotRunner = (*ModBuiltin)(nil)
+	_ SnapshotRunner = (*SegmentArena)(nil)
+)
+
+// Copy of the internal state of a builtin runner, such as its deduction cache and
+// its stop pointer, taken to roll the runner back after a speculative execution.
+// It can only be restored into a runner of the same builtin
+type Snapshot struct {
+	builtin     string
+	stopPointer uint64
+	state       any
+}
+
+func checkSnapshot(runner memory.BuiltinRunner, snapshot *Snapshot) error {
+	if snapshot.builtin != runner.String() {
+		return fmt.Errorf("cannot restore a %s snapshot into the %s builtin", snapshot.builtin, runner)
+	}
+	return nil
+}
+
 func BuiltinTypeFromName(name string) BuiltinType {
 	switch name {
 	case OutputName:
@@ -90,6 +271,9 @@ func BuiltinTypeFromName(name string) BuiltinType {
 	case SystemBuiltinName:
 		return SystemBuiltinType
 	default:
+		if builtinType, ok := customBuiltinByName[name]; ok {
+			return builtinType
+		}
 		panic("Unknown builtin")
 	}
 }
@@ -124,6 +308,27 @@ func getBuiltinAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint64, ratio
 	return allocatedInstances * cellsPerInstance, nil
 }
 
+// PreconditionError is returned by a builtin when one of its instances cannot be
+// validated or deduced because the program didn't satisfy a requirement of the
+// builtin, e.g. an input cell was not written or a signature was not registered.
+type PreconditionError struct {
+	// name of the builtin reporting the error
+	Builtin string
+	// index of the faulting instance inside the builtin segment
+	Instance uint64
+	// description of the unsatisfied requirement
+	Reason string
+	// optional pointer to what the program is expected to do
+	Hint string
+}
+
+func (e *PreconditionError) Error() string {
+	if e.Hint == "" {
+		return fmt.Sprintf("%s instance %d: %s", e.Builtin, e.Instance, e.Reason)
+	}
+	return fmt.Sprintf("%s instance %d: %s (%s)", e.Builtin, e.Instance, e.Reason, e.Hint)
+}
+
 func (b BuiltinType) MarshalJSON() ([]byte, error) {
 	switch b {
 	case OutputType:
@@ -153,6 +358,9 @@ func (b BuiltinType) MarshalJSON() ([]byte, error) {
 	case GasBuiltinType:
 		return []byte(GasBuiltinName), nil
 	}
+	if custom, ok := customBuiltin(b); ok {
+		return []byte(custom.Name), nil
+	}
 	return nil, fmt.Errorf("marshal unknown builtin: %d", uint8(b))
 }
 
@@ -192,7 +400,11 @@ func (b *BuiltinType) UnmarshalJSON(data []byte) error {
 	case SystemBuiltinName:
 		*b = SystemBuiltinType
 	default:
-		return fmt.Errorf("unmarshal unknown builtin: %s", builtinName)
+		builtinType, ok := customBuiltinByName[builtinName]
+		if !ok {
+			return fmt.Errorf("unmarshal unknown builtin: %s", builtinName)
+		}
+		*b = builtinType
 	}
 	return nil
 }
@@ -0,0 +1,181 @@
+package builtins
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// Checks the operations table against the actual behavior of each runner: a
+// validating builtin rejects an invalid write and a deducing builtin computes
+// its output cells from its inputs
+func TestBuiltinOperationsConformance(t *testing.T) {
+	felt := func(v uint64) memory.MemoryValue {
+		return memory.MemoryValueFromUint(v)
+	}
+	bigFelt := func(v *big.Int) memory.MemoryValue {
+		return memory.MemoryValueFromFieldElement(new(fp.Element).SetBigInt(v))
+	}
+	hexFelt := func(v string) memory.MemoryValue {
+		element, _ := new(fp.Element).SetString(v)
+		return memory.MemoryValueFromFieldElement(element)
+	}
+	address := memory.MemoryValueFromSegmentAndOffset(1, 0)
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+
+	testCases := []struct {
+		builtin BuiltinType
+		runner  memory.BuiltinRunner
+		// writes making the last one fail for validating builtins
+		invalidWrites []memory.MemoryValue
+		// inputs from which the cell following them is deduced
+		inputs []memory.MemoryValue
+	}{
+		{builtin: OutputType, runner: &Output{}, invalidWrites: []memory.MemoryValue{address}},
+		{
+			builtin:       RangeCheckType,
+			runner:        &RangeCheck{ratio: 8, RangeCheckNParts: 8},
+			invalidWrites: []memory.MemoryValue{bigFelt(new(big.Int).Lsh(big.NewInt(1), 128))},
+		},
+		{
+			builtin:       RangeCheck96Type,
+			runner:        &RangeCheck{ratio: 8, RangeCheckNParts: 6},
+			invalidWrites: []memory.MemoryValue{bigFelt(new(big.Int).Lsh(big.NewInt(1), 96))},
+		},
+		{builtin: PedersenType, runner: &Pedersen{ratio: 8}, inputs: []memory.MemoryValue{felt(1), felt(2)}},
+		{
+			builtin:       ECDSAType,
+			runner:        &ECDSA{ratio: 512},
+			invalidWrites: []memory.MemoryValue{memory.MemoryValueFromFieldElement(pubkey), felt(2718)},
+		},
+		{
+			builtin: KeccakType,
+			runner:  &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)},
+			inputs:  []memory.MemoryValue{felt(1), felt(2), felt(3), felt(4), felt(5), felt(6), felt(7), felt(8)},
+		},
+		{
+			builtin: ECOPType,
+			runner:  &EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)},
+			inputs: []memory.MemoryValue{
+				hexFelt("0x49EE3EBA8C1600700EE1B87EB599F16716B0B1022947733551FDE4050CA6804"),
+				hexFelt("0x3CA0CFE4B3BC6DDF346D49D06EA0ED34E621062C0E056C1D0405D266E10268A"),
+				hexFelt("0x1EF15C18599971B7BECED415A40F0C7DEACFD9B0D1819E03D723D8BC943CFCA"),
+				hexFelt("0x5668060AA49730B7BE4801DF46EC62DE53ECD11ABE43A32873000C36E8DC1F"),
+				felt(3),
+			},
+		},
+		{builtin: BitwiseType, runner: &Bitwise{ratio: 8}, inputs: []memory.MemoryValue{felt(12), felt(10)}},
+		{
+			builtin: PoseidonType,
+			runner:  &Poseidon{ratio: 8, cache: make(map[uint64]fp.Element)},
+			inputs:  []memory.MemoryValue{felt(1), felt(2), felt(3)},
+		},
+		{builtin: AddModeType, runner: NewModBuiltin(128, 96, 1, Add)},
+		{builtin: MulModType, runner: NewModBuiltin(256, 96, 1, Mul)},
+	}
+
+	for _, tc := range testCases {
+		operations := Operations(tc.builtin)
+		t.Run(tc.runner.String(), func(t *testing.T) {
+			require.Equal(t, operations.Validate, tc.invalidWrites != nil)
+			require.Equal(t, operations.Deduce, tc.inputs != nil)
+
+			if operations.Validate {
+				segment := memory.EmptySegment().WithBuiltinRunner(tc.runner)
+				var err error
+				for i := range tc.invalidWrites {
+					err = segment.Write(uint64(i), &tc.invalidWrites[i])
+				}
+				require.Error(t, err)
+
+				// the same writes are accepted once validation is disabled
+				segment = memory.EmptySegment().WithBuiltinRunner(NewSegmentRunner(tc.runner))
+				segment.BuiltinMode = memory.DeduceOnly
+				for i := range tc.invalidWrites {
+					require.NoError(t, segment.Write(uint64(i), &tc.invalidWrites[i]))
+				}
+			}
+
+			segment := memory.EmptySegment().WithBuiltinRunner(NewSegmentRunner(tc.runner))
+			for i := range tc.inputs {
+				require.NoError(t, segment.Write(uint64(i), &tc.inputs[i]))
+			}
+			_, err := segment.Read(uint64(len(tc.inputs)))
+			if operations.Deduce {
+				require.NoError(t, err)
+
+				disabled := memory.EmptySegment().WithBuiltinRunner(NewSegmentRunner(tc.runner))
+				disabled.BuiltinMode = memory.ValidateOnly
+				for i := range tc.inputs {
+					require.NoError(t, disabled.Write(uint64(i), &tc.inputs[i]))
+				}
+				_, err = disabled.Read(uint64(len(tc.inputs)))
+				require.ErrorContains(t, err, "deduction is disabled")
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
+
+// Checks that restoring a snapshot rolls back what a run changes in each runner,
+// its stop pointer included
+func TestBuiltinSnapshotRestore(t *testing.T) {
+	felt := func(v uint64) memory.MemoryValue {
+		return memory.MemoryValueFromUint(v)
+	}
+
+	testCases := []struct {
+		runner SnapshotRunner
+		// written after the snapshot, the cell following them being read
+		inputs []memory.MemoryValue
+	}{
+		{runner: &Output{}, inputs: []memory.MemoryValue{felt(1)}},
+		{runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, inputs: []memory.MemoryValue{felt(7)}},
+		{runner: &RangeCheck{ratio: 8, RangeCheckNParts: 6}, inputs: []memory.MemoryValue{felt(1 << 20)}},
+		{runner: &Pedersen{ratio: 8}, inputs: []memory.MemoryValue{felt(1), felt(2)}},
+		{
+			runner: &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)},
+			inputs: []memory.MemoryValue{felt(1), felt(2), felt(3), felt(4), felt(5), felt(6), felt(7), felt(8)},
+		},
+		{runner: &Bitwise{ratio: 8}, inputs: []memory.MemoryValue{felt(12), felt(10)}},
+		{runner: &EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)}},
+		{
+			runner: &Poseidon{ratio: 8, cache: make(map[uint64]fp.Element)},
+			inputs: []memory.MemoryValue{felt(1), felt(2), felt(3)},
+		},
+		{runner: NewModBuiltin(128, 96, 1, Add)},
+		{runner: NewModBuiltin(256, 96, 1, Mul)},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.runner.String(), func(t *testing.T) {
+			tc.runner.SetStopPointer(5)
+			before := CloneRunner(tc.runner)
+			snapshot := tc.runner.Snapshot()
+
+			segment := memory.EmptySegment().WithBuiltinRunner(tc.runner)
+			for i := range tc.inputs {
+				require.NoError(t, segment.Write(uint64(i), &tc.inputs[i]))
+			}
+			// fails for the builtins which don't deduce
+			_, _ = segment.Read(uint64(len(tc.inputs)))
+			tc.runner.SetStopPointer(10)
+
+			require.NoError(t, tc.runner.Restore(snapshot))
+			require.Equal(t, before, tc.runner)
+		})
+	}
+
+	arena := &SegmentArena{}
+	arena.SetStopPointer(3)
+	snapshot := arena.Snapshot()
+	arena.SetStopPointer(6)
+	require.NoError(t, arena.Restore(snapshot))
+	require.Equal(t, uint64(3), arena.GetStopPointer())
+
+	require.ErrorContains(t, (&Bitwise{}).Restore(NewModBuiltin(128, 96, 1, Add).Snapshot()), "cannot restore a AddMod snapshot into the bitwise builtin")
+}
@@ -0,0 +1,224 @@
+package builtins
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Name of the layout whose parameters are given at run time
+const DynamicLayoutName = "dynamic"
+
+// DynamicLayoutParams are the parameters of the dynamic layout, in the format of the
+// cairo_layout_params_file of the Python and Rust VMs. A builtin is part of the
+// layout when its uses_ flag is set, with the given ratio. The parameters are also
+// written to the AIR public input, for the prover to build the same layout
+type DynamicLayoutParams struct {
+	RcUnits                uint64 `json:"rc_units"`
+	LogDilutedUnitsPerStep uint64 `json:"log_diluted_units_per_step"`
+	// only used by the prover
+	CpuComponentStep   uint64 `json:"cpu_component_step"`
+	MemoryUnitsPerStep uint64 `json:"memory_units_per_step"`
+
+	UsesPedersenBuiltin     bool   `json:"uses_pedersen_builtin"`
+	PedersenRatio           uint64 `json:"pedersen_ratio"`
+	UsesRangeCheckBuiltin   bool   `json:"uses_range_check_builtin"`
+	RangeCheckRatio         uint64 `json:"range_check_ratio"`
+	UsesEcdsaBuiltin        bool   `json:"uses_ecdsa_builtin"`
+	EcdsaRatio              uint64 `json:"ecdsa_ratio"`
+	UsesBitwiseBuiltin      bool   `json:"uses_bitwise_builtin"`
+	BitwiseRatio            uint64 `json:"bitwise_ratio"`
+	UsesEcOpBuiltin         bool   `json:"uses_ec_op_builtin"`
+	EcOpRatio               uint64 `json:"ec_op_ratio"`
+	UsesKeccakBuiltin       bool   `json:"uses_keccak_builtin"`
+	KeccakRatio             uint64 `json:"keccak_ratio"`
+	UsesPoseidonBuiltin     bool   `json:"uses_poseidon_builtin"`
+	PoseidonRatio           uint64 `json:"poseidon_ratio"`
+	UsesRangeCheck96Builtin bool   `json:"uses_range_check96_builtin"`
+	RangeCheck96Ratio       uint64 `json:"range_check96_ratio"`
+	RangeCheck96RatioDen    uint64 `json:"range_check96_ratio_den"`
+	UsesAddModBuiltin       bool   `json:"uses_add_mod_builtin"`
+	AddModRatio             uint64 `json:"add_mod_ratio"`
+	AddModRatioDen          uint64 `json:"add_mod_ratio_den"`
+	UsesMulModBuiltin       bool   `json:"uses_mul_mod_builtin"`
+	MulModRatio             uint64 `json:"mul_mod_ratio"`
+	MulModRatioDen          uint64 `json:"mul_mod_ratio_den"`
+}
+
+// Reads the parameters of the dynamic layout from a json file. Unknown fields are
+// ignored, as the files shared with the prover may have more parameters
+func DynamicLayoutParamsFromFile(path string) (DynamicLayoutParams, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return DynamicLayoutParams{}, err
+	}
+	var params DynamicLayoutParams
+	if err := json.Unmarshal(content, &params); err != nil {
+		return DynamicLayoutParams{}, fmt.Errorf("layout params %s: %w", path, err)
+	}
+	return params, nil
+}
+
+// Definition returns the definition of the dynamic layout with these parameters, with
+// the builtins in the order of the other layouts
+func (params *DynamicLayoutParams) Definition() (LayoutDefinition, error) {
+	definition := LayoutDefinition{
+		Name:     DynamicLayoutName,
+		RcUnits:  params.RcUnits,
+		Builtins: []LayoutBuiltinDefinition{{Builtin: OutputType}},
+	}
+	if params.LogDilutedUnitsPerStep != 0 {
+		definition.DilutedPool = &DilutedPool{
+			UnitsPerStep: 1 << params.LogDilutedUnitsPerStep,
+			Spacing:      4,
+			NBits:        16,
+		}
+	}
+
+	builtins := []struct {
+		uses     bool
+		builtin  BuiltinType
+		ratio    uint64
+		ratioDen uint64
+	}{
+		{params.UsesPedersenBuiltin, PedersenType, params.PedersenRatio, 1},
+		{params.UsesRangeCheckBuiltin, RangeCheckType, params.RangeCheckRatio, 1},
+		{params.UsesEcdsaBuiltin, ECDSAType, params.EcdsaRatio, 1},
+		{params.UsesBitwiseBuiltin, BitwiseType, params.BitwiseRatio, 1},
+		{params.UsesEcOpBuiltin, ECOPType, params.EcOpRatio, 1},
+		{params.UsesKeccakBuiltin, KeccakType, params.KeccakRatio, 1},
+		{params.UsesPoseidonBuiltin, PoseidonType, params.PoseidonRatio, 1},
+		{params.UsesRangeCheck96Builtin, RangeCheck96Type, params.RangeCheck96Ratio, params.RangeCheck96RatioDen},
+		{params.UsesAddModBuiltin, AddModeType, params.AddModRatio, params.AddModRatioDen},
+		{params.UsesMulModBuiltin, MulModType, params.MulModRatio, params.MulModRatioDen},
+	}
+	for _, builtin := range builtins {
+		if !builtin.uses {
+			continue
+		}
+		name, _ := builtin.builtin.MarshalJSON()
+		if builtin.ratio == 0 {
+			return LayoutDefinition{}, fmt.Errorf("layout params: %s is used but its ratio is not set", name)
+		}
+		// a denominator spreads several instances over the ratio steps, which the
+		// runners don't support
+		if builtin.ratioDen > 1 {
+			return LayoutDefinition{}, fmt.Errorf("layout params: %s ratio denominator %d is not supported, only 1 is", name, builtin.ratioDen)
+		}
+		definition.Builtins = append(definition.Builtins, LayoutBuiltinDefinition{Builtin: builtin.builtin, Ratio: builtin.ratio})
+		if builtin.builtin == AddModeType || builtin.builtin == MulModType {
+			last := &definition.Builtins[len(definition.Builtins)-1]
+			last.WordBitLen = 96
+			last.BatchSize = 1
+		}
+	}
+	return definition, nil
+}
+
+// Layout builds the dynamic layout with these parameters
+func (params *DynamicLayoutParams) Layout() (Layout, error) {
+	definition, err := params.Definition()
+	if err != nil {
+		return Layout{}, err
+	}
+	layout, err := definition.Build()
+	if err != nil {
+		return Layout{}, err
+	}
+	layout.DynamicParams = params
+	return layout, nil
+}
+
+// DeduceDynamicRatios sets the ratio of each builtin of the dynamic layout, but the
+// output, to the largest power of two that allocates, in a trace of steps steps, the
+// cells used by the builtin, keyed by builtin name. Each builtin then takes the
+// smallest part of the trace it can, and the parameters of the layout, which are
+// written to the AIR public input, get the deduced ratios. steps must be a power
+// of two. It returns false when a builtin uses more cells than steps can allocate,
+// its ratio being set to one, in which case the trace needs more steps
+func (layout *Layout) DeduceDynamicRatios(usedCells map[string]uint64, steps uint64) (bool, error) {
+	if layout.DynamicParams == nil {
+		return false, fmt.Errorf("layout %s is not the dynamic layout", layout.Name)
+	}
+	params := *layout.DynamicParams
+	fits := true
+	for i := range layout.Builtins {
+		builtin := &layout.Builtins[i]
+		if builtin.Builtin == OutputType {
+			continue
+		}
+		used := usedCells[builtin.Runner.String()]
+		ratio := steps
+		for {
+			if err := setRatio(builtin.Runner, ratio); err != nil {
+				return false, err
+			}
+			allocated, err := builtin.Runner.GetAllocatedSize(used, steps)
+			if err == nil && allocated >= used {
+				break
+			}
+			if ratio == 1 {
+				fits = false
+				break
+			}
+			ratio /= 2
+		}
+		params.setRatio(builtin.Builtin, ratio)
+	}
+	layout.DynamicParams = &params
+	return fits, nil
+}
+
+func (params *DynamicLayoutParams) setRatio(builtin BuiltinType, ratio uint64) {
+	switch builtin {
+	case PedersenType:
+		params.PedersenRatio = ratio
+	case RangeCheckType:
+		params.RangeCheckRatio = ratio
+	case ECDSAType:
+		params.EcdsaRatio = ratio
+	case BitwiseType:
+		params.BitwiseRatio = ratio
+	case ECOPType:
+		params.EcOpRatio = ratio
+	case KeccakType:
+		params.KeccakRatio = ratio
+	case PoseidonType:
+		params.PoseidonRatio = ratio
+	case RangeCheck96Type:
+		params.RangeCheck96Ratio = ratio
+	case AddModeType:
+		params.AddModRatio = ratio
+	case MulModType:
+		params.MulModRatio = ratio
+	}
+}
+
+func setRatio(runner memory.BuiltinRunner, ratio uint64) error {
+	switch r := runner.(type) {
+	case *RangeCheck:
+		r.ratio = ratio
+	case *Pedersen:
+		r.ratio = ratio
+	case *ECDSA:
+		r.ratio = ratio
+	case *Bitwise:
+		r.ratio = ratio
+	case *EcOp:
+		r.ratio = ratio
+	case *Keccak:
+		r.ratio = ratio
+	case *Poseidon:
+		r.ratio = ratio
+	case *ModBuiltin:
+		r.ratio = ratio
+	default:
+		return fmt.Errorf("the ratio of builtin %s cannot be deduced", runner)
+	}
+	return nil
+}
+
+var errDynamicLayout = errors.New("layout dynamic takes its parameters from a layout params file")
@@ -2,6 +2,7 @@ package builtins
 
 import (
 	"fmt"
+	"maps"
 	"math/big"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
@@ -19,9 +20,40 @@ const (
 )
 
 type ECDSA struct {
-	Signatures  map[uint64]ecdsa.Signature
+	Signatures map[uint64]ecdsa.Signature
+	// parity of the y coordinate of the public key, true when odd, for the signatures
+	// added with AddSignatureWithYParity
+	YParities map[uint64]bool
+	// y coordinate of the public key for the signatures added with
+	// AddSignatureWithPublicKey, which spares its recovery
+	PublicKeysY map[uint64]fp.Element
+	// y and -y of the public keys recovered so far, by x coordinate. The same keys
+	// usually sign many messages so they are recovered once per run
+	keys        map[fp.Element][2]fp.Element
+	keyStats    KeyCacheStats
 	ratio       uint64
 	stopPointer uint64
+	// set by EnableParallelVerification
+	pool *verificationPool
+	// set by ScheduleVerifications
+	schedule *utils.Schedule
+	// set by EnableDeferredVerification, the public key and message of the
+	// instances checked by WaitVerifications
+	deferred          bool
+	deferredInstances map[uint64][2]fp.Element
+	// instances whose signature was verified, or handed to the verification pool
+	verified verifiedInstances
+}
+
+// KeyCacheStats counts the public keys found in the cache of the ECDSA builtin
+// and the ones which had to be recovered
+type KeyCacheStats struct {
+	Hits   uint64
+	Misses uint64
+}
+
+func (stats KeyCacheStats) Add(other KeyCacheStats) KeyCacheStats {
+	return KeyCacheStats{Hits: stats.Hits + other.Hits, Misses: stats.Misses + other.Misses}
 }
 
 // verify_ecdsa_signature(message_hash, public_key, sig_r, sig_s)
@@ -37,6 +69,10 @@ func (e *ECDSA) CheckWrite(segment *memory.Segment, offset uint64, value *memory
 	if !msg.Known() || !pub.Known() {
 		return nil
 	}
+	instance := pubOffset / cellsPerECDSA
+	if e.verified.has(instance) {
+		return nil
+	}
 
 	pubX, err := pub.FieldElement() //X element of the sig
 	if err != nil {
@@ -48,35 +84,115 @@ func (e *ECDSA) CheckWrite(segment *memory.Segment, offset uint64, value *memory
 		return err
 	}
 
-	//Recover Y part of the public key
-	posY, negY, err := recoverY(pubX)
-	if err != nil {
+	if e.deferred {
+		if e.deferredInstances == nil {
+			e.deferredInstances = make(map[uint64][2]fp.Element)
+		}
+		e.deferredInstances[pubOffset] = [2]fp.Element{*pubX, *msgField}
+		return nil
+	}
+	if err := e.checkInstance(pubOffset, pubX, msgField); err != nil {
 		return err
 	}
+	e.verified.add(instance)
+	return nil
+}
+
+func (e *ECDSA) checkInstance(pubOffset uint64, pubX *fp.Element, msgField *fp.Element) error {
+	//Recover Y part of the public key, unless it was given with the signature
+	posY, knownY := e.PublicKeysY[pubOffset]
+	var negY fp.Element
+	if !knownY {
+		var err error
+		posY, negY, err = e.recoverKey(pubX)
+		if err != nil {
+			return err
+		}
+	}
 
-	//Try first with positive y
+	// -y is on the curve whenever y is, and a given y must match x
 	key := starkcurve.G1Affine{X: *pubX, Y: posY}
 	if !key.IsOnCurve() {
 		return fmt.Errorf("key is not on curve")
 	}
 
-	pubKey := &ecdsa.PublicKey{A: key}
 	sig, ok := e.Signatures[pubOffset]
 	if !ok {
-		return fmt.Errorf("signature is missing from ECDSA builtin")
+		return &PreconditionError{
+			Builtin:  ECDSAName,
+			Instance: pubOffset / cellsPerECDSA,
+			Reason:   fmt.Sprintf("signature is missing for pubkey %s and message %s", pubX, msgField),
+			Hint:     "signatures must be registered through the ecdsa_builtin.add_signature hint before the instance is written",
+		}
 	}
 
-	msgBytes := msgField.Bytes()
-	valid, err := pubKey.Verify(sig.Bytes(), msgBytes[:], nil)
+	verification := signatureVerification{
+		pubX: *pubX,
+		posY: posY,
+		negY: negY,
+		sig:  sig,
+		msg:  *msgField,
+		// a given y is the only one tried
+		exactY: knownY,
+	}
+	verification.yOdd, verification.hasYParity = e.YParities[pubOffset]
+	if e.pool != nil {
+		e.pool.dispatch(pubOffset, &verification)
+		return nil
+	}
+	return verification.verify()
+}
+
+// signatureVerification holds what is needed to verify the signature of an
+// instance, so that it can be verified away from the segment
+type signatureVerification struct {
+	pubX       fp.Element
+	posY       fp.Element
+	negY       fp.Element
+	sig        ecdsa.Signature
+	msg        fp.Element
+	yOdd       bool
+	hasYParity bool
+	exactY     bool
+}
+
+func (v *signatureVerification) verify() error {
+	pubKey := &ecdsa.PublicKey{A: starkcurve.G1Affine{X: v.pubX, Y: v.posY}}
+	msgBytes := v.msg.Bytes()
+	if v.exactY {
+		valid, err := pubKey.Verify(v.sig.Bytes(), msgBytes[:], nil)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return fmt.Errorf("signature is not valid for the given public key")
+		}
+		return nil
+	}
+	// With a known parity only the matching y is tried
+	if v.hasYParity {
+		if isOdd(&v.posY) != v.yOdd {
+			pubKey.A.Y = v.negY
+		}
+		valid, err := pubKey.Verify(v.sig.Bytes(), msgBytes[:], nil)
+		if err != nil {
+			return err
+		}
+		if !valid {
+			return fmt.Errorf("signature is not valid for the given y parity")
+		}
+		return nil
+	}
+
+	valid, err := pubKey.Verify(v.sig.Bytes(), msgBytes[:], nil)
 	if err != nil {
 		return err
 	}
 
 	if !valid {
 		// Now try with Neg Y. Already know the point is on the curve so no need to check again
-		key = starkcurve.G1Affine{X: *pubX, Y: negY}
-		pubKey = &ecdsa.PublicKey{A: key}
-		valid, err := pubKey.Verify(sig.Bytes(), msgBytes[:], nil)
+		pubKey = &ecdsa.PublicKey{A: starkcurve.G1Affine{X: v.pubX, Y: v.negY}}
+		valid, err := pubKey.Verify(v.sig.Bytes(), msgBytes[:], nil)
 		if err != nil {
 			return err
 		}
@@ -87,6 +203,54 @@ func (e *ECDSA) CheckWrite(segment *memory.Segment, offset uint64, value *memory
 	return nil
 }
 
+func (e *ECDSA) recoverKey(x *fp.Element) (fp.Element, fp.Element, error) {
+	if ys, ok := e.keys[*x]; ok {
+		e.keyStats.Hits++
+		return ys[0], ys[1], nil
+	}
+	e.keyStats.Misses++
+	posY, negY, err := recoverY(x)
+	if err != nil {
+		return fp.Element{}, fp.Element{}, err
+	}
+	if e.keys == nil {
+		e.keys = make(map[fp.Element][2]fp.Element)
+	}
+	e.keys[*x] = [2]fp.Element{posY, negY}
+	return posY, negY, nil
+}
+
+// KeyCacheStats gives the hits and misses of the public key cache
+func (e *ECDSA) KeyCacheStats() KeyCacheStats {
+	return e.keyStats
+}
+
+// CheckInstances reports the instances of the segment with only one of their public
+// key and message written. CheckWrite waits for both cells to verify the signature,
+// so such an instance is never verified during the run, while the prover requires
+// every instance to be complete. The Python and Rust VMs reject them at the end of a
+// proof mode run as well
+func (e *ECDSA) CheckInstances(segment *memory.Segment) error {
+	for pubOffset := uint64(0); pubOffset < segment.Len(); pubOffset += cellsPerECDSA {
+		pub := segment.Peek(pubOffset)
+		msg := segment.Peek(pubOffset + 1)
+		if pub.Known() == msg.Known() {
+			continue
+		}
+		reason := fmt.Sprintf("public key %s is written without a message", &pub)
+		if msg.Known() {
+			reason = fmt.Sprintf("message %s is written without a public key", &msg)
+		}
+		return &PreconditionError{
+			Builtin:  ECDSAName,
+			Instance: pubOffset / cellsPerECDSA,
+			Reason:   reason,
+			Hint:     "both cells of an instance must be written before the run ends",
+		}
+	}
+	return nil
+}
+
 func (e *ECDSA) InferValue(segment *memory.Segment, offset uint64) error {
 	return fmt.Errorf("can't infer value")
 }
@@ -120,6 +284,9 @@ Hint that will call this function looks like this:
 	},
 */
 func (e *ECDSA) AddSignature(pubOffset uint64, r, s *fp.Element) error {
+	// Signatures are keyed by the offset of the instance first cell, so an offset
+	// pointing to the message cell refers to the same instance
+	pubOffset -= pubOffset % cellsPerECDSA
 	if e.Signatures == nil {
 		e.Signatures = make(map[uint64]ecdsa.Signature)
 	}
@@ -136,9 +303,103 @@ func (e *ECDSA) AddSignature(pubOffset uint64, r, s *fp.Element) error {
 	}
 
 	e.Signatures[pubOffset] = sig
+	delete(e.YParities, pubOffset)
+	delete(e.PublicKeysY, pubOffset)
+	// a new signature is verified on the next write of the instance
+	e.verified.remove(pubOffset / cellsPerECDSA)
+	return nil
+}
+
+// AddSignatureWithYParity adds a signature along with the parity of the y
+// coordinate of the public key, so that its check verifies the signature once
+// instead of trying both y and -y
+func (e *ECDSA) AddSignatureWithYParity(pubOffset uint64, r, s *fp.Element, yOdd bool) error {
+	if err := e.AddSignature(pubOffset, r, s); err != nil {
+		return err
+	}
+	if e.YParities == nil {
+		e.YParities = make(map[uint64]bool)
+	}
+	e.YParities[pubOffset-pubOffset%cellsPerECDSA] = yOdd
+	return nil
+}
+
+// AddSignatureWithPublicKey adds a signature along with the y coordinate of the
+// public key, so that its check neither recovers y nor tries -y. The check fails
+// when the point isn't on the curve
+func (e *ECDSA) AddSignatureWithPublicKey(pubOffset uint64, r, s, y *fp.Element) error {
+	if err := e.AddSignature(pubOffset, r, s); err != nil {
+		return err
+	}
+	if e.PublicKeysY == nil {
+		e.PublicKeysY = make(map[uint64]fp.Element)
+	}
+	e.PublicKeysY[pubOffset-pubOffset%cellsPerECDSA] = *y
+	return nil
+}
+
+type ecdsaState struct {
+	signatures        map[uint64]ecdsa.Signature
+	yParities         map[uint64]bool
+	publicKeysY       map[uint64]fp.Element
+	deferredInstances map[uint64][2]fp.Element
+	verified          verifiedInstances
+}
+
+func (e *ECDSA) Snapshot() Snapshot {
+	return Snapshot{builtin: ECDSAName, stopPointer: e.stopPointer, state: ecdsaState{
+		signatures:        maps.Clone(e.Signatures),
+		yParities:         maps.Clone(e.YParities),
+		publicKeysY:       maps.Clone(e.PublicKeysY),
+		deferredInstances: maps.Clone(e.deferredInstances),
+		verified:          e.verified.clone(),
+	}}
+}
+
+func (e *ECDSA) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(e, &snapshot); err != nil {
+		return err
+	}
+	e.stopPointer = snapshot.stopPointer
+	state := snapshot.state.(ecdsaState)
+	e.Signatures = maps.Clone(state.signatures)
+	e.YParities = maps.Clone(state.yParities)
+	e.PublicKeysY = maps.Clone(state.publicKeysY)
+	e.deferredInstances = maps.Clone(state.deferredInstances)
+	e.verified = state.verified.clone()
 	return nil
 }
 
+// ECDSARunnerAt returns the ECDSA builtin runner of the segment an address points
+// to. As each ECDSA segment has its own runner, signatures are registered in the
+// runner of the segment of their instance rather than in the first ECDSA segment
+func ECDSARunnerAt(mem *memory.Memory, address *memory.MemoryAddress) (*ECDSA, error) {
+	if address.SegmentIndex < 0 || address.SegmentIndex >= len(mem.Segments) {
+		return nil, fmt.Errorf("%s does not point to an allocated segment", address)
+	}
+	segment := mem.Segments[address.SegmentIndex]
+	runner, ok := segment.BuiltinRunner.(*ECDSA)
+	if !ok {
+		return nil, fmt.Errorf(
+			"%s does not point to the %s builtin segment: segment builtin is %s",
+			address, ECDSAName, segment.BuiltinRunner,
+		)
+	}
+	return runner, nil
+}
+
+// AddECDSASignature registers the signature of the ECDSA instance an address points
+// to, which must be done before the instance is written. Besides the add_signature
+// hint of Cairo Zero, it lets the Cairo 1 hints and the syscall handlers of
+// embedders provide signatures
+func AddECDSASignature(mem *memory.Memory, address *memory.MemoryAddress, r, s *fp.Element) error {
+	runner, err := ECDSARunnerAt(mem, address)
+	if err != nil {
+		return err
+	}
+	return runner.AddSignature(address.Offset, r, s)
+}
+
 func (e *ECDSA) String() string {
 	return ECDSAName
 }
@@ -166,6 +427,10 @@ func recoverY(x *fp.Element) (fp.Element, fp.Element, error) {
 	return *y, negY, nil
 }
 
+func isOdd(y *fp.Element) bool {
+	return y.BigInt(new(big.Int)).Bit(0) == 1
+}
+
 type AirPrivateBuiltinECDSASignatureInput struct {
 	R string `json:"r"`
 	W string `json:"w"`
@@ -178,17 +443,47 @@ type AirPrivateBuiltinECDSA struct {
 	SignatureInput AirPrivateBuiltinECDSASignatureInput `json:"signature_input"`
 }
 
+// GetAirPrivateInput returns the instances of the segment sorted by index. Every
+// instance written by the program must be complete and have its signature
+// registered, while the signatures of instances left empty are ignored
 func (e *ECDSA) GetAirPrivateInput(ecdsaSegment *memory.Segment) ([]AirPrivateBuiltinECDSA, error) {
 	values := make([]AirPrivateBuiltinECDSA, 0)
-	for addrOffset, signature := range e.Signatures {
+	err := e.eachAirPrivateInput(ecdsaSegment, func(value AirPrivateBuiltinECDSA) error {
+		values = append(values, value)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// Calls yield with the private input of each instance of the segment, in order
+func (e *ECDSA) eachAirPrivateInput(ecdsaSegment *memory.Segment, yield func(AirPrivateBuiltinECDSA) error) error {
+	frModulusBig, _ := new(big.Int).SetString("3618502788666131213697322783095070105526743751716087489154079457884512865583", 10)
+	for addrOffset := uint64(0); addrOffset < ecdsaSegment.RealLen(); addrOffset += cellsPerECDSA {
 		idx := addrOffset / cellsPerECDSA
-		pubKey, err := ecdsaSegment.Read(addrOffset)
-		if err != nil {
-			return values, err
+		// peeked, as Read would extend the segment and run the deductions
+		pubKey := ecdsaSegment.Peek(addrOffset)
+		msg := ecdsaSegment.Peek(addrOffset + 1)
+		if !pubKey.Known() && !msg.Known() {
+			continue
 		}
-		msg, err := ecdsaSegment.Read(addrOffset + 1)
-		if err != nil {
-			return values, err
+		if !pubKey.Known() || !msg.Known() {
+			return &PreconditionError{
+				Builtin:  ECDSAName,
+				Instance: idx,
+				Reason:   "instance is incomplete, either its pubkey or its message is not written",
+			}
+		}
+		signature, ok := e.Signatures[addrOffset]
+		if !ok {
+			return &PreconditionError{
+				Builtin:  ECDSAName,
+				Instance: idx,
+				Reason:   fmt.Sprintf("signature is missing for pubkey %s and message %s", &pubKey.Felt, &msg.Felt),
+				Hint:     "the private input needs the signature of every instance written by the program",
+			}
 		}
 
 		pubKeyBig := big.Int{}
@@ -200,16 +495,17 @@ func (e *ECDSA) GetAirPrivateInput(ecdsaSegment *memory.Segment) ([]AirPrivateBu
 
 		rBig := new(big.Int).SetBytes(signature.R[:])
 		sBig := new(big.Int).SetBytes(signature.S[:])
-		frModulusBig, _ := new(big.Int).SetString("3618502788666131213697322783095070105526743751716087489154079457884512865583", 10)
 		wBig := new(big.Int).ModInverse(sBig, frModulusBig)
 		signatureInput := AirPrivateBuiltinECDSASignatureInput{
 			R: fmt.Sprintf("0x%x", rBig),
 			W: fmt.Sprintf("0x%x", wBig),
 		}
 
-		values = append(values, AirPrivateBuiltinECDSA{Index: int(idx), PubKey: pubKeyHex, Msg: msgHex, SignatureInput: signatureInput})
+		if err := yield(AirPrivateBuiltinECDSA{Index: int(idx), PubKey: pubKeyHex, Msg: msgHex, SignatureInput: signatureInput}); err != nil {
+			return err
+		}
 	}
-	return values, nil
+	return nil
 }
 
 func (e *ECDSA) GetCellsPerInstance() uint64 {
@@ -0,0 +1,182 @@
+package builtins
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+)
+
+// SignatureError is the error of an instance whose signature check was postponed,
+// by EnableParallelVerification or EnableDeferredVerification
+type SignatureError struct {
+	// offset of the first cell of the instance in the segment
+	Offset uint64
+	Err    error
+}
+
+func (e *SignatureError) Error() string {
+	return fmt.Sprintf("ecdsa instance at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *SignatureError) Unwrap() error {
+	return e.Err
+}
+
+// EnableParallelVerification makes CheckWrite verify the signatures of the
+// instances on a pool of goroutines instead of the vm thread. The cheap checks,
+// such as the key being on the curve or the signature being registered, still fail
+// the write, while an invalid signature is only reported by WaitVerifications,
+// which must be called before the segment is finalized. No more than workers
+// signatures are verified at once, or one per CPU when workers is not positive
+func (e *ECDSA) EnableParallelVerification(workers int) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	e.pool = newVerificationPool(workers, e.schedule)
+}
+
+// ScheduleVerifications makes the verification pool log the order the signatures
+// are verified in to a schedule, or replay it, see utils.Schedule. The offsets of the
+// instances name the verifications. It must be called before
+// EnableParallelVerification
+func (e *ECDSA) ScheduleVerifications(schedule *utils.Schedule) {
+	e.schedule = schedule
+}
+
+// EnableDeferredVerification makes CheckWrite only record the public key and the
+// message of the instances, which are all checked by WaitVerifications. Signatures
+// can then be added until the end of the run. Combined with parallel verification,
+// the deferred signatures are verified on the pool
+func (e *ECDSA) EnableDeferredVerification() {
+	e.deferred = true
+}
+
+// WaitVerifications checks the deferred instances and waits for the signatures
+// dispatched so far to be verified. The failures are joined in a single error, as
+// SignatureError by increasing offset. It returns nil when neither parallel nor
+// deferred verification is enabled
+func (e *ECDSA) WaitVerifications() error {
+	var failures []*SignatureError
+	if e.deferred {
+		offsets := make([]uint64, 0, len(e.deferredInstances))
+		for offset := range e.deferredInstances {
+			offsets = append(offsets, offset)
+		}
+		slices.Sort(offsets)
+		for _, offset := range offsets {
+			instance := e.deferredInstances[offset]
+			if err := e.checkInstance(offset, &instance[0], &instance[1]); err != nil {
+				failures = append(failures, &SignatureError{Offset: offset, Err: err})
+			}
+		}
+		e.deferredInstances = nil
+	}
+	if e.pool != nil {
+		failures = append(failures, e.pool.wait()...)
+	}
+	if len(failures) == 0 {
+		return nil
+	}
+
+	slices.SortFunc(failures, func(a, b *SignatureError) int {
+		return cmp.Compare(a.Offset, b.Offset)
+	})
+	errs := make([]error, len(failures))
+	for i := range failures {
+		errs[i] = failures[i]
+	}
+	return errors.Join(errs...)
+}
+
+type verificationPool struct {
+	workers int
+	slots   chan struct{}
+	pending sync.WaitGroup
+	// optional, with the domain of the verifications of the pool
+	schedule *utils.Schedule
+	domain   string
+
+	mu       sync.Mutex
+	failures []*SignatureError
+}
+
+func newVerificationPool(workers int, schedule *utils.Schedule) *verificationPool {
+	return &verificationPool{
+		workers:  workers,
+		slots:    make(chan struct{}, workers),
+		schedule: schedule,
+		domain:   schedule.Domain("ecdsa"),
+	}
+}
+
+// Returns a pool of the same size without the pending verifications, nil for a
+// nil pool
+func (p *verificationPool) fresh() *verificationPool {
+	if p == nil {
+		return nil
+	}
+	return newVerificationPool(p.workers, p.schedule)
+}
+
+// Returns a pool of the same size for a copy of the runner, once the pending
+// verifications are done, which already holds their failures so that both runners
+// report them. Nil for a nil pool
+func (p *verificationPool) fork() *verificationPool {
+	if p == nil {
+		return nil
+	}
+	p.schedule.Settle(p.domain)
+	p.pending.Wait()
+	clone := newVerificationPool(p.workers, p.schedule)
+	p.mu.Lock()
+	clone.failures = slices.Clone(p.failures)
+	p.mu.Unlock()
+	return clone
+}
+
+// Blocks while all the workers are busy, so that the vm doesn't pile up
+// verifications faster than they are done. When replaying a schedule, the
+// verification is only queued until the pool waits
+func (p *verificationPool) dispatch(offset uint64, verification *signatureVerification) {
+	key := strconv.FormatUint(offset, 10)
+	if p.schedule.Replaying() {
+		p.schedule.Queue(p.domain, key, func() {
+			p.verify(offset, verification)
+		})
+		return
+	}
+	p.slots <- struct{}{}
+	p.pending.Add(1)
+	go func() {
+		defer func() {
+			<-p.slots
+			p.pending.Done()
+		}()
+		p.verify(offset, verification)
+		p.schedule.Done(p.domain, key)
+	}()
+}
+
+func (p *verificationPool) verify(offset uint64, verification *signatureVerification) {
+	if err := verification.verify(); err != nil {
+		p.mu.Lock()
+		p.failures = append(p.failures, &SignatureError{Offset: offset, Err: err})
+		p.mu.Unlock()
+	}
+}
+
+func (p *verificationPool) wait() []*SignatureError {
+	p.schedule.Settle(p.domain)
+	p.pending.Wait()
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	failures := p.failures
+	p.failures = nil
+	return failures
+}
@@ -26,6 +26,163 @@ func TestECDSA(t *testing.T) {
 	require.NoError(t, segment.Write(0, &pubkeyValue))
 
 }
+
+func TestECDSAYParity(t *testing.T) {
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// the signature is only valid for one of the two y
+	valid := 0
+	for _, yOdd := range []bool{false, true} {
+		ecdsa := &ECDSA{}
+		segment := memory.EmptySegmentWithLength(2)
+		segment.WithBuiltinRunner(ecdsa)
+		require.NoError(t, ecdsa.AddSignatureWithYParity(1, r, s, yOdd))
+		require.Equal(t, map[uint64]bool{0: yOdd}, ecdsa.YParities)
+		require.NoError(t, segment.Write(1, &msgValue))
+		if err := segment.Write(0, &pubkeyValue); err == nil {
+			valid++
+		} else {
+			require.ErrorContains(t, err, "signature is not valid for the given y parity")
+
+			// adding the signature again without the parity falls back to both y
+			require.NoError(t, ecdsa.AddSignature(0, r, s))
+			require.Empty(t, ecdsa.YParities)
+			require.NoError(t, ecdsa.CheckWrite(segment, 0, &pubkeyValue))
+		}
+	}
+	require.Equal(t, 1, valid)
+}
+
+func TestECDSAPublicKey(t *testing.T) {
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+	posY, negY, err := recoverY(pubkey)
+	require.NoError(t, err)
+
+	// the signature is only valid for one of the two y, and neither is recovered
+	valid := 0
+	for _, y := range []fp.Element{posY, negY} {
+		ecdsa := &ECDSA{}
+		segment := memory.EmptySegmentWithLength(2)
+		segment.WithBuiltinRunner(ecdsa)
+		require.NoError(t, ecdsa.AddSignatureWithPublicKey(1, r, s, &y))
+		require.Equal(t, map[uint64]fp.Element{0: y}, ecdsa.PublicKeysY)
+		require.NoError(t, segment.Write(1, &msgValue))
+		if err := segment.Write(0, &pubkeyValue); err == nil {
+			valid++
+		} else {
+			require.ErrorContains(t, err, "signature is not valid for the given public key")
+		}
+		require.Zero(t, ecdsa.KeyCacheStats())
+	}
+	require.Equal(t, 1, valid)
+
+	// a y which doesn't match x is not on the curve
+	ecdsa := &ECDSA{}
+	segment := memory.EmptySegmentWithLength(2)
+	segment.WithBuiltinRunner(ecdsa)
+	require.NoError(t, ecdsa.AddSignatureWithPublicKey(0, r, s, new(fp.Element).SetOne()))
+	require.NoError(t, segment.Write(1, &msgValue))
+	require.ErrorContains(t, segment.Write(0, &pubkeyValue), "key is not on curve")
+
+	// adding the signature again without the key falls back to its recovery
+	require.NoError(t, ecdsa.AddSignature(0, r, s))
+	require.Empty(t, ecdsa.PublicKeysY)
+	require.NoError(t, ecdsa.CheckWrite(segment, 0, &pubkeyValue))
+}
+
+func TestECDSAKeyCache(t *testing.T) {
+	ecdsa := &ECDSA{}
+	segment := memory.EmptySegmentWithLength(4)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// both instances are signed by the same key, which is only recovered once
+	for offset := uint64(0); offset < 4; offset += cellsPerECDSA {
+		require.NoError(t, ecdsa.AddSignature(offset, r, s))
+		require.NoError(t, segment.Write(offset+1, &msgValue))
+		require.NoError(t, segment.Write(offset, &pubkeyValue))
+	}
+	require.Equal(t, KeyCacheStats{Hits: 1, Misses: 1}, ecdsa.KeyCacheStats())
+	require.Len(t, ecdsa.keys, 1)
+}
+
+func TestECDSAVerifiedInstances(t *testing.T) {
+	ecdsa := &ECDSA{}
+	segment := memory.EmptySegmentWithLength(2)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	require.NoError(t, ecdsa.AddSignature(0, r, s))
+	require.NoError(t, segment.Write(1, &msgValue))
+	require.NoError(t, segment.Write(0, &pubkeyValue))
+	require.True(t, ecdsa.verified.has(0))
+	snapshot := ecdsa.Snapshot()
+
+	// rewriting the cells of a verified instance doesn't verify its signature again
+	ecdsa.keys = nil
+	require.NoError(t, segment.Write(0, &pubkeyValue))
+	require.NoError(t, segment.Write(1, &msgValue))
+	require.Zero(t, ecdsa.KeyCacheStats().Hits)
+
+	// until a new signature is added for it
+	require.NoError(t, ecdsa.AddSignature(0, s, r))
+	require.False(t, ecdsa.verified.has(0))
+	require.ErrorContains(t, segment.Write(0, &pubkeyValue), "signature is not valid")
+
+	require.NoError(t, ecdsa.Restore(snapshot))
+	require.True(t, ecdsa.verified.has(0))
+	require.False(t, CloneRunner(ecdsa).(*ECDSA).verified.has(1))
+}
+
+func TestECDSACheckInstances(t *testing.T) {
+	ecdsa := &ECDSA{}
+	segment := memory.EmptySegmentWithLength(6)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	require.NoError(t, ecdsa.AddSignature(0, r, s))
+	require.NoError(t, segment.Write(0, &pubkeyValue))
+	require.NoError(t, segment.Write(1, &msgValue))
+	require.NoError(t, ecdsa.CheckInstances(segment))
+
+	// a single written cell is accepted during the run, but not at its end
+	require.NoError(t, segment.Write(5, &msgValue))
+	require.EqualError(t, ecdsa.CheckInstances(segment), "ecdsa instance 2: message 2718 is written without a public key (both cells of an instance must be written before the run ends)")
+	require.NoError(t, segment.Write(2, &pubkeyValue))
+	var precondition *PreconditionError
+	require.ErrorAs(t, ecdsa.CheckInstances(segment), &precondition)
+	require.Equal(t, uint64(1), precondition.Instance)
+	require.Contains(t, precondition.Reason, "is written without a message")
+}
+
 func TestECDSAInvalidSig(t *testing.T) {
 	ecdsa := &ECDSA{}
 	segment := memory.EmptySegmentWithLength(5)
@@ -45,3 +202,216 @@ func TestECDSAInvalidSig(t *testing.T) {
 	require.ErrorContains(t, err, "signature is not valid")
 
 }
+
+func TestECDSAMissingSignature(t *testing.T) {
+	ecdsa := &ECDSA{}
+	segment := memory.EmptySegmentWithLength(6)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	require.NoError(t, segment.Write(4, &pubkeyValue))
+	err := segment.Write(5, &msgValue)
+
+	var preconditionErr *PreconditionError
+	require.ErrorAs(t, err, &preconditionErr)
+	require.Equal(t, ECDSAName, preconditionErr.Builtin)
+	require.Equal(t, uint64(2), preconditionErr.Instance)
+	require.ErrorContains(t, err, "ecdsa instance 2: signature is missing for pubkey 1735102664668487605176656616876767369909409133946409161569774794110049207117 and message 2718")
+	require.ErrorContains(t, err, "add_signature")
+}
+
+func TestECDSAParallelVerification(t *testing.T) {
+	ecdsa := &ECDSA{}
+	ecdsa.EnableParallelVerification(2)
+	segment := memory.EmptySegmentWithLength(8)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	invalidS, _ := new(fp.Element).SetString("31231231313")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// the instances at offsets 2 and 6 have invalid signatures, which don't fail
+	// the writes
+	for offset := uint64(0); offset < 8; offset += cellsPerECDSA {
+		if offset%4 == 0 {
+			require.NoError(t, ecdsa.AddSignature(offset, r, s))
+		} else {
+			require.NoError(t, ecdsa.AddSignature(offset, r, invalidS))
+		}
+		require.NoError(t, segment.Write(offset+1, &msgValue))
+		require.NoError(t, segment.Write(offset, &pubkeyValue))
+	}
+
+	err := ecdsa.WaitVerifications()
+	require.EqualError(t, err, "ecdsa instance at offset 2: signature is not valid\necdsa instance at offset 6: signature is not valid")
+	var signatureErr *SignatureError
+	require.ErrorAs(t, err, &signatureErr)
+	require.Equal(t, uint64(2), signatureErr.Offset)
+	// the errors are only reported once
+	require.NoError(t, ecdsa.WaitVerifications())
+
+	// a missing signature still fails the write
+	require.NoError(t, segment.Write(9, &msgValue))
+	var preconditionErr *PreconditionError
+	require.ErrorAs(t, segment.Write(8, &pubkeyValue), &preconditionErr)
+}
+
+func TestECDSACloneParallelVerification(t *testing.T) {
+	ecdsa := &ECDSA{}
+	ecdsa.EnableParallelVerification(2)
+	segment := memory.EmptySegmentWithLength(2)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	invalidS, _ := new(fp.Element).SetString("31231231313")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+	require.NoError(t, ecdsa.AddSignature(0, r, invalidS))
+	require.NoError(t, segment.Write(1, &msgValue))
+	require.NoError(t, segment.Write(0, &pubkeyValue))
+
+	// the verification may still be pending when the runner is cloned, both runners
+	// report its failure
+	clone := CloneRunner(ecdsa).(*ECDSA)
+	require.EqualError(t, clone.WaitVerifications(), "ecdsa instance at offset 0: signature is not valid")
+	require.EqualError(t, ecdsa.WaitVerifications(), "ecdsa instance at offset 0: signature is not valid")
+	require.NotSame(t, ecdsa.pool, clone.pool)
+}
+
+func TestECDSADeferredVerification(t *testing.T) {
+	ecdsa := &ECDSA{}
+	ecdsa.EnableDeferredVerification()
+	segment := memory.EmptySegmentWithLength(6)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	invalidS, _ := new(fp.Element).SetString("31231231313")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// no check is done while the instances are written, not even of the signatures
+	for offset := uint64(0); offset < 6; offset += cellsPerECDSA {
+		require.NoError(t, segment.Write(offset+1, &msgValue))
+		require.NoError(t, segment.Write(offset, &pubkeyValue))
+	}
+	require.Zero(t, ecdsa.KeyCacheStats())
+
+	// the signature of the first instance is added after its write, the second one
+	// is invalid and the last one is missing
+	require.NoError(t, ecdsa.AddSignature(0, r, s))
+	require.NoError(t, ecdsa.AddSignature(2, r, invalidS))
+
+	err := ecdsa.WaitVerifications()
+	require.ErrorContains(t, err, "ecdsa instance at offset 2: signature is not valid\necdsa instance at offset 4: ecdsa instance 2: signature is missing")
+	var preconditionErr *PreconditionError
+	require.ErrorAs(t, err, &preconditionErr)
+	require.Equal(t, KeyCacheStats{Hits: 2, Misses: 1}, ecdsa.KeyCacheStats())
+	require.NoError(t, ecdsa.WaitVerifications())
+}
+
+func TestECDSAAirPrivateInput(t *testing.T) {
+	// deferred, so that instances can be written without their signature
+	ecdsa := &ECDSA{}
+	ecdsa.EnableDeferredVerification()
+	segment := memory.EmptySegmentWithLength(8)
+	segment.WithBuiltinRunner(ecdsa)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// the third instance is left empty, its signature is ignored
+	for _, offset := range []uint64{6, 0, 2} {
+		require.NoError(t, segment.Write(offset+1, &msgValue))
+		require.NoError(t, segment.Write(offset, &pubkeyValue))
+	}
+	for _, offset := range []uint64{6, 4, 2, 0} {
+		require.NoError(t, ecdsa.AddSignature(offset, r, s))
+	}
+
+	values, err := ecdsa.GetAirPrivateInput(segment)
+	require.NoError(t, err)
+	require.Len(t, values, 3)
+	for i, index := range []int{0, 1, 3} {
+		require.Equal(t, index, values[i].Index)
+		require.Equal(t, "0x"+msg.Text(16), values[i].Msg)
+	}
+	require.Equal(t, uint64(8), segment.RealLen())
+
+	delete(ecdsa.Signatures, 2)
+	_, err = ecdsa.GetAirPrivateInput(segment)
+	require.ErrorContains(t, err, "ecdsa instance 1: signature is missing")
+
+	require.NoError(t, ecdsa.AddSignature(2, r, s))
+	require.NoError(t, segment.Write(4, &pubkeyValue))
+	_, err = ecdsa.GetAirPrivateInput(segment)
+	require.ErrorContains(t, err, "ecdsa instance 2: instance is incomplete")
+}
+
+func TestECDSAMultipleSegments(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	firstAddr := mem.AllocateBuiltinSegment(&ECDSA{})
+	secondAddr, err := AllocateAdditionalSegment(mem, ECDSAName)
+	require.NoError(t, err)
+
+	segments := mem.FindSegmentsWithBuiltin(ECDSAName)
+	require.Len(t, segments, 2)
+	require.NotSame(t, segments[0].BuiltinRunner, segments[1].BuiltinRunner)
+
+	pubkey, _ := new(fp.Element).SetString("1735102664668487605176656616876767369909409133946409161569774794110049207117")
+	msg, _ := new(fp.Element).SetString("2718")
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+
+	pubkeyValue := memory.MemoryValueFromFieldElement(pubkey)
+	msgValue := memory.MemoryValueFromFieldElement(msg)
+
+	// only the first segment gets a signature for its first instance
+	require.NoError(t, segments[0].BuiltinRunner.(*ECDSA).AddSignature(firstAddr.Offset, r, s))
+	require.NoError(t, mem.Write(firstAddr.SegmentIndex, 0, &pubkeyValue))
+	require.NoError(t, mem.Write(firstAddr.SegmentIndex, 1, &msgValue))
+
+	require.NoError(t, mem.Write(secondAddr.SegmentIndex, 0, &pubkeyValue))
+	err = mem.Write(secondAddr.SegmentIndex, 1, &msgValue)
+	require.ErrorContains(t, err, "signature is missing")
+}
+
+func TestECDSASnapshotRestore(t *testing.T) {
+	ecdsa := &ECDSA{}
+	r, _ := new(fp.Element).SetString("3086480810278599376317923499561306189851900463386393948998357832163236918254")
+	s, _ := new(fp.Element).SetString("598673427589502599949712887611119751108407514580626464031881322743364689811")
+
+	require.NoError(t, ecdsa.AddSignature(0, r, s))
+	snapshot := ecdsa.Snapshot()
+
+	require.NoError(t, ecdsa.AddSignature(2, r, s))
+	require.Len(t, ecdsa.Signatures, 2)
+
+	require.NoError(t, ecdsa.Restore(snapshot))
+	require.Len(t, ecdsa.Signatures, 1)
+	require.Contains(t, ecdsa.Signatures, uint64(0))
+
+	// the snapshot is not affected by writes made after restoring it
+	require.NoError(t, ecdsa.AddSignature(4, r, s))
+	require.NoError(t, ecdsa.Restore(snapshot))
+	require.Len(t, ecdsa.Signatures, 1)
+
+	require.ErrorContains(t, ecdsa.Restore((&Output{}).Snapshot()), "cannot restore a output snapshot into the ecdsa builtin")
+}
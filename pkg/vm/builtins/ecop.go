@@ -3,8 +3,7 @@ package builtins
 import (
 	"errors"
 	"fmt"
-	"math/big"
-	"sort"
+	"maps"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
@@ -67,9 +66,12 @@ func (e *EcOp) InferValue(segment *mem.Segment, offset uint64) error {
 	// assert all values are known
 	for i := range inputs {
 		if !inputs[i].Known() {
-			return fmt.Errorf(
-				"cannot infer value: input value at offset %d is unknown", inputOff+uint64(i),
-			)
+			return &PreconditionError{
+				Builtin:  EcOpName,
+				Instance: inputOff / cellsPerEcOp,
+				Reason:   fmt.Sprintf("cannot infer value: input value at offset %d is unknown", inputOff+uint64(i)),
+				Hint:     "p, q and m must be written before reading the result point",
+			}
 		}
 	}
 
@@ -78,7 +80,11 @@ func (e *EcOp) InferValue(segment *mem.Segment, offset uint64) error {
 	for i := range inputs {
 		felt, err := inputs[i].FieldElement()
 		if err != nil {
-			return err
+			return &PreconditionError{
+				Builtin:  EcOpName,
+				Instance: inputOff / cellsPerEcOp,
+				Reason:   fmt.Sprintf("input value at offset %d is the address %s, not a felt", inputOff+uint64(i), &inputs[i]),
+			}
 		}
 		inputsFelt[i] = felt
 	}
@@ -91,16 +97,28 @@ func (e *EcOp) InferValue(segment *mem.Segment, offset uint64) error {
 	p := point{*inputsFelt[0], *inputsFelt[1]}
 	q := point{*inputsFelt[2], *inputsFelt[3]}
 	if !p.onCurve(&utils.Alpha, &utils.Beta) {
-		return fmt.Errorf("point P(%s, %s) is not on the curve", &p.X, &p.Y)
+		return &PreconditionError{
+			Builtin:  EcOpName,
+			Instance: inputOff / cellsPerEcOp,
+			Reason:   fmt.Sprintf("point P(%s, %s) is not on the curve", &p.X, &p.Y),
+		}
 	}
 	if !q.onCurve(&utils.Alpha, &utils.Beta) {
-		return fmt.Errorf("point Q(%s, %s) is not on the curve", &q.X, &q.Y)
+		return &PreconditionError{
+			Builtin:  EcOpName,
+			Instance: inputOff / cellsPerEcOp,
+			Reason:   fmt.Sprintf("point Q(%s, %s) is not on the curve", &q.X, &q.Y),
+		}
 	}
 
 	// calculate the elliptic curve operation
 	r, err := ecop(&p, &q, inputsFelt[4], &utils.Alpha)
 	if err != nil {
-		return err
+		return &PreconditionError{
+			Builtin:  EcOpName,
+			Instance: inputOff / cellsPerEcOp,
+			Reason:   err.Error(),
+		}
 	}
 
 	// store the resulting point `r`
@@ -166,8 +184,8 @@ func ecop(p *point, q *point, m, alpha *fp.Element) (point, error) {
 		// `ecdouble` assumes `y` coordinates are always different
 		if doublePoint.X.Equal(&partialSum.X) || doublePoint.Y.Equal(&utils.FeltZero) {
 			return point{}, fmt.Errorf(
-				"EcOp requires from P(%s, %s) and Q(%s, %s) that P.X != Q.X and Q.Y != 0 ",
-				&p.X, &p.Y, &q.X, &q.Y,
+				"cannot compute P + m * Q with P(%s, %s), m = %s and Q(%s, %s): the computation reached two points with the same x coordinate or a point with y = 0 to double",
+				&p.X, &p.Y, m, &q.X, &q.Y,
 			)
 		}
 		and := uint256.Int{}
@@ -250,52 +268,21 @@ type AirPrivateBuiltinEcOp struct {
 }
 
 func (e *EcOp) GetAirPrivateInput(ecOpSegment *mem.Segment) []AirPrivateBuiltinEcOp {
-	valueMapping := make(map[int]AirPrivateBuiltinEcOp)
-	for index, value := range ecOpSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		idx, typ := index/cellsPerEcOp, index%cellsPerEcOp
-		if typ >= inputCellsPerEcOp {
-			continue
-		}
-
-		builtinValue, exists := valueMapping[idx]
-		if !exists {
-			builtinValue = AirPrivateBuiltinEcOp{Index: idx}
-		}
-
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		if typ == 0 {
-			builtinValue.PX = valueHex
-		} else if typ == 1 {
-			builtinValue.PY = valueHex
-		} else if typ == 2 {
-			builtinValue.QX = valueHex
-		} else if typ == 3 {
-			builtinValue.QY = valueHex
-		} else if typ == 4 {
-			builtinValue.M = valueHex
-		}
-		valueMapping[idx] = builtinValue
-	}
-
 	values := make([]AirPrivateBuiltinEcOp, 0)
-
-	sortedIndexes := make([]int, 0, len(valueMapping))
-	for index := range valueMapping {
-		sortedIndexes = append(sortedIndexes, index)
-	}
-	sort.Ints(sortedIndexes)
-	for _, index := range sortedIndexes {
-		value := valueMapping[index]
+	_ = e.eachAirPrivateInput(ecOpSegment, func(value AirPrivateBuiltinEcOp) error {
 		values = append(values, value)
-	}
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (e *EcOp) eachAirPrivateInput(ecOpSegment *mem.Segment, yield func(AirPrivateBuiltinEcOp) error) error {
+	return eachAirPrivateInstance(ecOpSegment, cellsPerEcOp, inputCellsPerEcOp, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinEcOp{Index: idx, PX: inputs[0], PY: inputs[1], QX: inputs[2], QY: inputs[3], M: inputs[4]})
+	})
+}
+
 func (e *EcOp) GetCellsPerInstance() uint64 {
 	return cellsPerEcOp
 }
@@ -307,3 +294,16 @@ func (e *EcOp) GetStopPointer() uint64 {
 func (e *EcOp) SetStopPointer(stopPointer uint64) {
 	e.stopPointer = stopPointer
 }
+
+func (e *EcOp) Snapshot() Snapshot {
+	return Snapshot{builtin: EcOpName, stopPointer: e.stopPointer, state: maps.Clone(e.cache)}
+}
+
+func (e *EcOp) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(e, &snapshot); err != nil {
+		return err
+	}
+	e.stopPointer = snapshot.stopPointer
+	e.cache = maps.Clone(snapshot.state.(map[uint64]fp.Element))
+	return nil
+}
@@ -62,6 +62,49 @@ func TestEcOp(t *testing.T) {
 	require.Equal(t, r.Y, *ry)
 }
 
+func TestEcOpInferValueErrors(t *testing.T) {
+	px, _ := new(fp.Element).SetString("0x49EE3EBA8C1600700EE1B87EB599F16716B0B1022947733551FDE4050CA6804")
+	py, _ := new(fp.Element).SetString("0x3CA0CFE4B3BC6DDF346D49D06EA0ED34E621062C0E056C1D0405D266E10268A")
+	one := new(fp.Element).SetOne()
+	address := memory.MemoryAddress{SegmentIndex: 2, Offset: 0}
+
+	tests := []struct {
+		name   string
+		inputs []any
+		err    string
+	}{
+		{"unknown input", []any{px, py, px, nil, one}, "input value at offset 3 is unknown"},
+		{"address input", []any{px, py, px, py, &address}, "input value at offset 4 is the address 2:0, not a felt"},
+		{"p not on curve", []any{px, one, px, py, one}, "point P("},
+		{"q not on curve", []any{px, py, one, py, one}, "point Q("},
+		// P + Q with P = Q needs a doubling that the builtin doesn't do
+		{"same x", []any{px, py, px, py, one}, "the same x coordinate"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			segment := memory.EmptySegmentWithLength(cellsPerEcOp)
+			segment.WithBuiltinRunner(&EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)})
+			for i, input := range test.inputs {
+				if input == nil {
+					continue
+				}
+				value, err := memory.MemoryValueFromAny(input)
+				require.NoError(t, err)
+				require.NoError(t, segment.Write(uint64(i), &value))
+			}
+
+			_, err := segment.Read(5)
+			var precondition *PreconditionError
+			require.ErrorAs(t, err, &precondition)
+			require.Equal(t, EcOpName, precondition.Builtin)
+			require.Contains(t, precondition.Reason, test.err)
+			// nothing is deduced
+			cell := segment.Peek(5)
+			require.False(t, cell.Known())
+		})
+	}
+}
+
 // performs elliptic curve multiplication on point `p` with scalar `m` and param `alpha`.
 // `m` value gets modified in place
 func ecmult(p *point, m *uint256.Int, alpha *fp.Element) point {
@@ -4,8 +4,8 @@ import (
 	"encoding/binary"
 	"errors"
 	"fmt"
+	"maps"
 	"math/big"
-	"sort"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -24,6 +24,9 @@ const (
 	cellsPerKeccak              = 16
 	inputCellsPerKeccak         = 8
 	instancesPerComponentKeccak = 16
+	// size of each of the input and output cells, the 1600 bits of the state split
+	// into 8 cells
+	keccakInputBits = 200
 )
 
 type Keccak struct {
@@ -52,11 +55,29 @@ func (k *Keccak) InferValue(segment *memory.Segment, offset uint64) error {
 	for i := uint64(0); i < inputCellsPerKeccak; i++ {
 		value := segment.Peek(startOffset + i)
 		if !value.Known() {
-			return fmt.Errorf("cannot infer value: input value at offset %d is unknown", startOffset+i)
+			return &PreconditionError{
+				Builtin:  KeccakName,
+				Instance: startOffset / cellsPerKeccak,
+				Reason:   fmt.Sprintf("cannot infer value: input value at offset %d is unknown", startOffset+i),
+				Hint:     "all 8 input cells must be written before reading an output cell",
+			}
 		}
 		v, err := value.FieldElement()
 		if err != nil {
-			return fmt.Errorf("Keccak input has to be felt")
+			return &PreconditionError{
+				Builtin:  KeccakName,
+				Instance: startOffset / cellsPerKeccak,
+				Reason:   fmt.Sprintf("input value at offset %d has to be felt", startOffset+i),
+			}
+		}
+		// the Python VM rejects inputs larger than the 200 bits of a cell rather than
+		// truncating them
+		if v.BigInt(new(big.Int)).BitLen() > keccakInputBits {
+			return &PreconditionError{
+				Builtin:  KeccakName,
+				Instance: startOffset / cellsPerKeccak,
+				Reason:   fmt.Sprintf("input value at offset %d is %s, which doesn't fit in %d bits", startOffset+i, v, keccakInputBits),
+			}
 		}
 		var out [32]byte
 		fp.LittleEndian.PutElement(&out, *v)
@@ -108,62 +129,48 @@ type AirPrivateBuiltinKeccak struct {
 }
 
 func (k *Keccak) GetAirPrivateInput(keccakSegment *memory.Segment) []AirPrivateBuiltinKeccak {
-	valueMapping := make(map[int]AirPrivateBuiltinKeccak)
-	for index, value := range keccakSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		idx, stateIndex := index/cellsPerKeccak, index%cellsPerKeccak
-		if stateIndex >= inputCellsPerKeccak {
-			continue
-		}
-
-		builtinValue, exists := valueMapping[idx]
-		if !exists {
-			builtinValue = AirPrivateBuiltinKeccak{Index: idx}
-		}
-
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		if stateIndex == 0 {
-			builtinValue.InputS0 = valueHex
-		} else if stateIndex == 1 {
-			builtinValue.InputS1 = valueHex
-		} else if stateIndex == 2 {
-			builtinValue.InputS2 = valueHex
-		} else if stateIndex == 3 {
-			builtinValue.InputS3 = valueHex
-		} else if stateIndex == 4 {
-			builtinValue.InputS4 = valueHex
-		} else if stateIndex == 5 {
-			builtinValue.InputS5 = valueHex
-		} else if stateIndex == 6 {
-			builtinValue.InputS6 = valueHex
-		} else if stateIndex == 7 {
-			builtinValue.InputS7 = valueHex
-		}
-		valueMapping[idx] = builtinValue
-	}
-
 	values := make([]AirPrivateBuiltinKeccak, 0)
-
-	sortedIndexes := make([]int, 0, len(valueMapping))
-	for index := range valueMapping {
-		sortedIndexes = append(sortedIndexes, index)
-	}
-	sort.Ints(sortedIndexes)
-	for _, index := range sortedIndexes {
-		value := valueMapping[index]
+	_ = k.eachAirPrivateInput(keccakSegment, func(value AirPrivateBuiltinKeccak) error {
 		values = append(values, value)
-	}
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (k *Keccak) eachAirPrivateInput(keccakSegment *memory.Segment, yield func(AirPrivateBuiltinKeccak) error) error {
+	return eachAirPrivateInstance(keccakSegment, cellsPerKeccak, inputCellsPerKeccak, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinKeccak{
+			Index:   idx,
+			InputS0: inputs[0],
+			InputS1: inputs[1],
+			InputS2: inputs[2],
+			InputS3: inputs[3],
+			InputS4: inputs[4],
+			InputS5: inputs[5],
+			InputS6: inputs[6],
+			InputS7: inputs[7],
+		})
+	})
+}
+
 func (k *Keccak) GetCellsPerInstance() uint64 {
 	return cellsPerKeccak
 }
 
+func (k *Keccak) Snapshot() Snapshot {
+	return Snapshot{builtin: KeccakName, stopPointer: k.stopPointer, state: maps.Clone(k.cache)}
+}
+
+func (k *Keccak) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(k, &snapshot); err != nil {
+		return err
+	}
+	k.stopPointer = snapshot.stopPointer
+	k.cache = maps.Clone(snapshot.state.(map[uint64]fp.Element))
+	return nil
+}
+
 func (k *Keccak) GetStopPointer() uint64 {
 	return k.stopPointer
 }
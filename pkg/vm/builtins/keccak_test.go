@@ -1,6 +1,7 @@
 package builtins
 
 import (
+	"math/big"
 	"testing"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
@@ -48,3 +49,40 @@ func TestKeccakBuiltin(t *testing.T) {
 	require.NoError(t, err)
 	assert.Equal(t, ans, &expected)
 }
+
+func TestKeccakInputTooLarge(t *testing.T) {
+	keccak := &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)}
+	segment := memory.EmptySegmentWithLength(cellsPerKeccak)
+	segment.WithBuiltinRunner(keccak)
+
+	for i := uint64(0); i < inputCellsPerKeccak; i++ {
+		// the largest input, 2^200 - 1, except for the third cell
+		value := new(fp.Element).Exp(fp.NewElement(2), big.NewInt(keccakInputBits))
+		if i != 2 {
+			one := fp.One()
+			value.Sub(value, &one)
+		}
+		mv := memory.MemoryValueFromFieldElement(value)
+		require.NoError(t, segment.Write(i, &mv))
+	}
+
+	_, err := segment.Read(inputCellsPerKeccak)
+	var precondition *PreconditionError
+	require.ErrorAs(t, err, &precondition)
+	require.Equal(t, uint64(0), precondition.Instance)
+	require.Contains(t, precondition.Reason, "input value at offset 2")
+	require.Contains(t, precondition.Reason, "doesn't fit in 200 bits")
+}
+
+func TestKeccakSnapshotRestore(t *testing.T) {
+	keccak := &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)}
+	snapshot := keccak.Snapshot()
+
+	keccak.cache[8] = fp.One()
+	require.NoError(t, keccak.Restore(snapshot))
+	require.Empty(t, keccak.cache)
+
+	// restored cache is still usable for deductions
+	keccak.cache[8] = fp.One()
+	require.Len(t, keccak.cache, 1)
+}
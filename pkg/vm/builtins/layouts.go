@@ -1,17 +1,34 @@
 package builtins
 
 import (
+	"bytes"
+	"embed"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
+	"path"
+	"slices"
+	"sort"
+	"strings"
 
+	"github.com/NethermindEth/cairo-vm-go/pkg/assembler"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
+// Layout definitions shipped with the VM, one json file per layout
+//
+//go:embed layouts/*.json
+var layoutFiles embed.FS
+
 type LayoutBuiltin struct {
 	// Runner for the builtin
 	Runner memory.BuiltinRunner
 	// Builtin id from starknet parser
 	Builtin BuiltinType
+	// Operations applied to the segment of the builtin
+	Mode memory.BuiltinMode
 }
 
 type Layout struct {
@@ -21,136 +38,212 @@ type Layout struct {
 	RcUnits uint64
 	// List of builtins to be included in given layout
 	Builtins []LayoutBuiltin
+	// Diluted pool parameters, nil if the layout has no diluted pool
+	DilutedPool *DilutedPool
+	// Fraction of the steps reserved for public memory cells, zero when unspecified
+	PublicMemoryFraction uint64
+	// Opcode extensions the prover of the layout supports, on top of the stone opcodes
+	OpcodeExtensions []assembler.OpcodeExtension
+	// Parameters of the dynamic layout, nil for the other layouts
+	DynamicParams *DynamicLayoutParams
 }
 
-func getPlainLayout() Layout {
-	return Layout{Name: "plain", RcUnits: 16, Builtins: []LayoutBuiltin{}}
+type DilutedPool struct {
+	UnitsPerStep uint64 `json:"units_per_step"`
+	Spacing      uint64 `json:"spacing"`
+	NBits        uint64 `json:"n_bits"`
 }
 
-func getSmallLayout() Layout {
-	return Layout{Name: "small", RcUnits: 16, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 8}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 512}, Builtin: ECDSAType},
-	}}
+// Serializable description of a layout, from which the builtin runners are created
+type LayoutDefinition struct {
+	Name                 string                      `json:"name"`
+	RcUnits              uint64                      `json:"rc_units"`
+	Builtins             []LayoutBuiltinDefinition   `json:"builtins"`
+	DilutedPool          *DilutedPool                `json:"diluted_pool,omitempty"`
+	PublicMemoryFraction uint64                      `json:"public_memory_fraction,omitempty"`
+	OpcodeExtensions     []assembler.OpcodeExtension `json:"opcode_extensions,omitempty"`
 }
 
-func getDexLayout() Layout {
-	return Layout{Name: "dex", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 8}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 512}, Builtin: ECDSAType},
-	}}
+type LayoutBuiltinDefinition struct {
+	Builtin BuiltinType `json:"builtin"`
+	// Number of steps per builtin instance
+	Ratio uint64 `json:"ratio,omitempty"`
+	// Only used by the mod builtins
+	WordBitLen uint64 `json:"word_bit_len,omitempty"`
+	BatchSize  uint64 `json:"batch_size,omitempty"`
+	// Defaults to validating and deducing, as far as the builtin supports each. Turning
+	// off an operation the builtin supports is refused in proof mode
+	Mode memory.BuiltinMode `json:"mode,omitempty"`
 }
 
-func getRecursiveLayout() Layout {
-	return Layout{Name: "recursive", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 128}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &Bitwise{ratio: 8}, Builtin: BitwiseType},
-	}}
+// Returns the names of the layouts shipped with the VM, sorted alphabetically
+func LayoutNames() []string {
+	entries, err := layoutFiles.ReadDir("layouts")
+	if err != nil {
+		panic(err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
 }
 
-func getStarknetLayout() Layout {
-	return Layout{Name: "starknet", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 32}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 16, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 2048}, Builtin: ECDSAType},
-		{Runner: &Bitwise{ratio: 64}, Builtin: BitwiseType},
-		{Runner: &EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)}, Builtin: ECOPType},
-		{Runner: &Poseidon{ratio: 32, cache: make(map[uint64]fp.Element)}, Builtin: PoseidonType},
-	}}
+// Returns the embedded json definition of a layout
+func LayoutDefinitionJSON(layout string) ([]byte, error) {
+	content, err := layoutFiles.ReadFile(path.Join("layouts", layout+".json"))
+	if err != nil {
+		return nil, fmt.Errorf("Layout %s not found", layout)
+	}
+	return content, nil
 }
 
-func getStarknetWithKeccakLayout() Layout {
-	return Layout{Name: "starknet_with_keccak", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 32}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 16, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 2048}, Builtin: ECDSAType},
-		{Runner: &Bitwise{ratio: 64}, Builtin: BitwiseType},
-		{Runner: &EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)}, Builtin: ECOPType},
-		{Runner: &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)}, Builtin: KeccakType},
-		{Runner: &Poseidon{ratio: 32, cache: make(map[uint64]fp.Element)}, Builtin: PoseidonType},
-	}}
+func GetLayoutDefinition(layout string) (LayoutDefinition, error) {
+	content, err := LayoutDefinitionJSON(layout)
+	if err != nil {
+		return LayoutDefinition{}, err
+	}
+	return parseLayoutDefinition(layout, content)
 }
 
-func getRecursiveLargeOutputLayout() Layout {
-	return Layout{Name: "recursive_large_output", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 128}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &Bitwise{ratio: 8}, Builtin: BitwiseType},
-		{Runner: &Poseidon{ratio: 8, cache: make(map[uint64]fp.Element)}, Builtin: PoseidonType},
-	}}
+// Reads a layout definition from a json file, for provers with capacities not matching
+// any of the layouts shipped with the VM
+func LayoutDefinitionFromFile(path string) (LayoutDefinition, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return LayoutDefinition{}, err
+	}
+	return parseLayoutDefinition(path, content)
 }
 
-func getRecursiveWithPoseidonLayout() Layout {
-	return Layout{Name: "recursive_with_poseidon", RcUnits: 4, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 256}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 16, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &Bitwise{ratio: 16}, Builtin: BitwiseType},
-		{Runner: &Poseidon{ratio: 64, cache: make(map[uint64]fp.Element)}, Builtin: PoseidonType},
-	}}
+func parseLayoutDefinition(source string, content []byte) (LayoutDefinition, error) {
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	decoder.DisallowUnknownFields()
+	var definition LayoutDefinition
+	if err := decoder.Decode(&definition); err != nil {
+		return LayoutDefinition{}, fmt.Errorf("layout %s: %w", source, err)
+	}
+	return definition, nil
 }
 
-func getAllSolidityLayout() Layout {
-	return Layout{Name: "recursive_with_poseidon", RcUnits: 8, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 8}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 512}, Builtin: ECDSAType},
-		{Runner: &Bitwise{ratio: 256}, Builtin: BitwiseType},
-		{Runner: &EcOp{ratio: 256, cache: make(map[uint64]fp.Element)}, Builtin: ECOPType},
-	}}
+func (definition *LayoutDefinition) validate() error {
+	if definition.Name == "" {
+		return errors.New("layout name is empty")
+	}
+	if definition.RcUnits == 0 {
+		return fmt.Errorf("layout %s: rc_units must be positive", definition.Name)
+	}
+	if pool := definition.DilutedPool; pool != nil {
+		if pool.UnitsPerStep == 0 || pool.Spacing == 0 || pool.NBits == 0 {
+			return fmt.Errorf("layout %s: diluted pool parameters must be positive", definition.Name)
+		}
+		if pool.Spacing*pool.NBits > 251 {
+			return fmt.Errorf("layout %s: diluted values of %d bits with spacing %d do not fit in a felt", definition.Name, pool.NBits, pool.Spacing)
+		}
+	}
+	for i, extension := range definition.OpcodeExtensions {
+		if extension == assembler.OpcodeExtensionStone {
+			return fmt.Errorf("layout %s: opcode extension %d: stone opcodes are always supported", definition.Name, i)
+		}
+		if slices.Contains(definition.OpcodeExtensions[:i], extension) {
+			return fmt.Errorf("layout %s: opcode extension %d: duplicated opcode extension", definition.Name, i)
+		}
+	}
+	seen := make(map[BuiltinType]bool, len(definition.Builtins))
+	for i, builtin := range definition.Builtins {
+		if seen[builtin.Builtin] {
+			return fmt.Errorf("layout %s: builtin %d: duplicated builtin", definition.Name, i)
+		}
+		seen[builtin.Builtin] = true
+	}
+	return nil
 }
 
-func getAllCairoLayout() Layout {
-	return Layout{Name: "all_cairo", RcUnits: 8, Builtins: []LayoutBuiltin{
-		{Runner: &Output{}, Builtin: OutputType},
-		{Runner: &Pedersen{ratio: 256}, Builtin: PedersenType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 8}, Builtin: RangeCheckType},
-		{Runner: &ECDSA{ratio: 2048}, Builtin: ECDSAType},
-		{Runner: &Bitwise{ratio: 16}, Builtin: BitwiseType},
-		{Runner: &EcOp{ratio: 1024, cache: make(map[uint64]fp.Element)}, Builtin: ECOPType},
-		{Runner: &Keccak{ratio: 2048, cache: make(map[uint64]fp.Element)}, Builtin: KeccakType},
-		{Runner: &Poseidon{ratio: 256, cache: make(map[uint64]fp.Element)}, Builtin: PoseidonType},
-		{Runner: &RangeCheck{ratio: 8, RangeCheckNParts: 6}, Builtin: RangeCheck96Type},
-		{Runner: &ModBuiltin{ratio: 128, wordBitLen: 96, batchSize: 1, modBuiltinType: Add}, Builtin: AddModeType},
-		{Runner: &ModBuiltin{ratio: 256, wordBitLen: 96, batchSize: 1, modBuiltinType: Mul}, Builtin: MulModType},
-	}}
+// Reports whether the mode of the builtin turns off one of the operations it implements,
+// which leaves values of its segment unchecked: written values of a validating builtin
+// or output cells of a deducing builtin filled in by hints
+func (builtin *LayoutBuiltin) Unchecked() bool {
+	operations := Operations(builtin.Builtin)
+	return (builtin.Mode == memory.DeduceOnly && operations.Validate) ||
+		(builtin.Mode == memory.ValidateOnly && operations.Deduce)
 }
 
-func GetLayout(layout string) (Layout, error) {
-	switch layout {
-	case "plain":
-		return getPlainLayout(), nil
-	case "small":
-		return getSmallLayout(), nil
-	case "dex":
-		return getDexLayout(), nil
-	case "recursive":
-		return getRecursiveLayout(), nil
-	case "starknet":
-		return getStarknetLayout(), nil
-	case "starknet_with_keccak":
-		return getStarknetWithKeccakLayout(), nil
-	case "recursive_large_output":
-		return getRecursiveLargeOutputLayout(), nil
-	case "recursive_with_poseidon":
-		return getRecursiveWithPoseidonLayout(), nil
-	case "all_solidity":
-		return getAllSolidityLayout(), nil
-	case "all_cairo":
-		return getAllCairoLayout(), nil
-	case "":
-		return getPlainLayout(), nil
+// Creates a layout with fresh builtin runners out of its definition
+func (definition *LayoutDefinition) Build() (Layout, error) {
+	if err := definition.validate(); err != nil {
+		return Layout{}, err
+	}
+	layout := Layout{
+		Name:                 definition.Name,
+		RcUnits:              definition.RcUnits,
+		Builtins:             make([]LayoutBuiltin, 0, len(definition.Builtins)),
+		DilutedPool:          definition.DilutedPool,
+		PublicMemoryFraction: definition.PublicMemoryFraction,
+		OpcodeExtensions:     definition.OpcodeExtensions,
+	}
+	for i, builtin := range definition.Builtins {
+		runner, err := builtin.runner()
+		if err != nil {
+			return Layout{}, fmt.Errorf("layout %s: builtin %d: %w", definition.Name, i, err)
+		}
+		layout.Builtins = append(layout.Builtins, LayoutBuiltin{Runner: runner, Builtin: builtin.Builtin, Mode: builtin.Mode})
+	}
+	return layout, nil
+}
+
+func (definition *LayoutBuiltinDefinition) runner() (memory.BuiltinRunner, error) {
+	if definition.Builtin != OutputType && definition.Ratio == 0 {
+		return nil, errors.New("ratio must be positive")
+	}
+
+	ratio := definition.Ratio
+	switch definition.Builtin {
+	case OutputType:
+		return &Output{}, nil
+	case RangeCheckType:
+		return &RangeCheck{ratio: ratio, RangeCheckNParts: 8}, nil
+	case RangeCheck96Type:
+		return &RangeCheck{ratio: ratio, RangeCheckNParts: 6}, nil
+	case PedersenType:
+		return &Pedersen{ratio: ratio}, nil
+	case ECDSAType:
+		return &ECDSA{ratio: ratio}, nil
+	case BitwiseType:
+		return &Bitwise{ratio: ratio}, nil
+	case ECOPType:
+		return &EcOp{ratio: ratio, cache: make(map[uint64]fp.Element)}, nil
+	case KeccakType:
+		return &Keccak{ratio: ratio, cache: make(map[uint64]fp.Element)}, nil
+	case PoseidonType:
+		return &Poseidon{ratio: ratio, cache: make(map[uint64]fp.Element)}, nil
+	case AddModeType, MulModType:
+		if definition.WordBitLen == 0 || definition.BatchSize == 0 {
+			return nil, errors.New("word_bit_len and batch_size must be positive")
+		}
+		modBuiltinType := Add
+		if definition.Builtin == MulModType {
+			modBuiltinType = Mul
+		}
+		return NewModBuiltin(ratio, definition.WordBitLen, definition.BatchSize, modBuiltinType), nil
 	default:
-		return Layout{}, fmt.Errorf("Layout %s not found", layout)
+		if custom, ok := customBuiltin(definition.Builtin); ok {
+			return custom.NewRunner(ratio), nil
+		}
+		return nil, fmt.Errorf("builtin %d cannot be part of a layout", definition.Builtin)
+	}
+}
+
+func GetLayout(layout string) (Layout, error) {
+	if layout == "" {
+		layout = "plain"
+	}
+	if layout == DynamicLayoutName {
+		return Layout{}, errDynamicLayout
+	}
+	definition, err := GetLayoutDefinition(layout)
+	if err != nil {
+		return Layout{}, err
 	}
+	return definition.Build()
 }
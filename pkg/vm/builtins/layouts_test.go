@@ -0,0 +1,217 @@
+package builtins
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/assembler"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedLayouts(t *testing.T) {
+	names := LayoutNames()
+	require.Contains(t, names, "plain")
+	require.Contains(t, names, "all_cairo")
+
+	for _, name := range names {
+		layout, err := GetLayout(name)
+		require.NoError(t, err, name)
+		require.Equal(t, name, layout.Name)
+		require.NotZero(t, layout.RcUnits, name)
+	}
+
+	_, err := GetLayout("unknown")
+	require.ErrorContains(t, err, "Layout unknown not found")
+}
+
+func TestLayoutModBuiltins(t *testing.T) {
+	layout, err := GetLayout("all_cairo")
+	require.NoError(t, err)
+
+	addMod := layout.Builtins[len(layout.Builtins)-2]
+	require.Equal(t, AddModeType, addMod.Builtin)
+	require.Equal(t, NewModBuiltin(128, 96, 1, Add), addMod.Runner)
+}
+
+func TestLayoutDefinitionValidation(t *testing.T) {
+	definition := LayoutDefinition{
+		Name:     "custom",
+		RcUnits:  4,
+		Builtins: []LayoutBuiltinDefinition{{Builtin: OutputType}, {Builtin: PedersenType}},
+	}
+	_, err := definition.Build()
+	require.ErrorContains(t, err, "layout custom: builtin 1: ratio must be positive")
+}
+
+func TestLayoutDefinitionFromFile(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+		return path
+	}
+
+	definition, err := LayoutDefinitionFromFile(write("custom.json", `{
+		"name": "custom",
+		"rc_units": 4,
+		"builtins": [{"builtin": "output"}, {"builtin": "bitwise", "ratio": 32}],
+		"diluted_pool": {"units_per_step": 16, "spacing": 4, "n_bits": 16},
+		"public_memory_fraction": 8
+	}`))
+	require.NoError(t, err)
+	layout, err := definition.Build()
+	require.NoError(t, err)
+	require.Equal(t, "custom", layout.Name)
+	require.Equal(t, &Bitwise{ratio: 32}, layout.Builtins[1].Runner)
+	require.Equal(t, &DilutedPool{UnitsPerStep: 16, Spacing: 4, NBits: 16}, layout.DilutedPool)
+	require.Equal(t, uint64(8), layout.PublicMemoryFraction)
+
+	_, err = LayoutDefinitionFromFile(write("typo.json", `{"name": "typo", "rc_unit": 4}`))
+	require.ErrorContains(t, err, `unknown field "rc_unit"`)
+
+	definition, err = LayoutDefinitionFromFile(write("duplicated.json", `{
+		"name": "duplicated",
+		"rc_units": 4,
+		"builtins": [{"builtin": "pedersen", "ratio": 8}, {"builtin": "pedersen", "ratio": 8}]
+	}`))
+	require.NoError(t, err)
+	_, err = definition.Build()
+	require.ErrorContains(t, err, "layout duplicated: builtin 1: duplicated builtin")
+
+	definition, err = LayoutDefinitionFromFile(write("modes.json", `{
+		"name": "modes",
+		"rc_units": 4,
+		"builtins": [{"builtin": "range_check", "ratio": 8, "mode": "validate"}, {"builtin": "pedersen", "ratio": 8, "mode": "validate"}]
+	}`))
+	require.NoError(t, err)
+	require.Equal(t, memory.ValidateOnly, definition.Builtins[0].Mode)
+	layout, err = definition.Build()
+	require.NoError(t, err)
+	// the range check only validates anyway, while the pedersen builtin no longer deduces
+	require.False(t, layout.Builtins[0].Unchecked())
+	require.True(t, layout.Builtins[1].Unchecked())
+
+	definition, err = LayoutDefinitionFromFile(write("diluted.json", `{
+		"name": "diluted",
+		"rc_units": 4,
+		"builtins": [],
+		"diluted_pool": {"units_per_step": 16, "spacing": 0, "n_bits": 16}
+	}`))
+	require.NoError(t, err)
+	_, err = definition.Build()
+	require.ErrorContains(t, err, "diluted pool parameters must be positive")
+
+	definition, err = LayoutDefinitionFromFile(write("extensions.json", `{
+		"name": "extensions",
+		"rc_units": 4,
+		"builtins": [],
+		"opcode_extensions": ["blake", "qm31_operation"]
+	}`))
+	require.NoError(t, err)
+	layout, err = definition.Build()
+	require.NoError(t, err)
+	require.Equal(t, []assembler.OpcodeExtension{assembler.OpcodeExtensionBlake, assembler.OpcodeExtensionQM31}, layout.OpcodeExtensions)
+
+	definition, err = LayoutDefinitionFromFile(write("stone.json", `{
+		"name": "stone",
+		"rc_units": 4,
+		"builtins": [],
+		"opcode_extensions": ["stone"]
+	}`))
+	require.NoError(t, err)
+	_, err = definition.Build()
+	require.ErrorContains(t, err, "layout stone: opcode extension 0: stone opcodes are always supported")
+
+	_, err = LayoutDefinitionFromFile(write("unknown.json", `{"name": "unknown", "opcode_extensions": ["sha256"]}`))
+	require.ErrorContains(t, err, "unmarshal unknown opcode extension: sha256")
+}
+
+func TestDynamicLayout(t *testing.T) {
+	_, err := GetLayout(DynamicLayoutName)
+	require.ErrorContains(t, err, "layout params file")
+
+	path := filepath.Join(t.TempDir(), "params.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{
+		"rc_units": 4,
+		"log_diluted_units_per_step": 4,
+		"cpu_component_step": 8,
+		"memory_units_per_step": 8,
+		"uses_pedersen_builtin": true,
+		"pedersen_ratio": 256,
+		"uses_range_check_builtin": true,
+		"range_check_ratio": 8,
+		"uses_ecdsa_builtin": false,
+		"ecdsa_ratio": 0,
+		"uses_add_mod_builtin": true,
+		"add_mod_ratio": 128,
+		"add_mod_ratio_den": 1,
+		"uses_other_builtin": false
+	}`), 0644))
+	params, err := DynamicLayoutParamsFromFile(path)
+	require.NoError(t, err)
+	layout, err := params.Layout()
+	require.NoError(t, err)
+
+	require.Equal(t, DynamicLayoutName, layout.Name)
+	require.Equal(t, uint64(4), layout.RcUnits)
+	require.Equal(t, &DilutedPool{UnitsPerStep: 16, Spacing: 4, NBits: 16}, layout.DilutedPool)
+	require.Equal(t, &params, layout.DynamicParams)
+	require.Len(t, layout.Builtins, 4)
+	require.Equal(t, &Output{}, layout.Builtins[0].Runner)
+	require.Equal(t, &Pedersen{ratio: 256}, layout.Builtins[1].Runner)
+	require.Equal(t, &RangeCheck{ratio: 8, RangeCheckNParts: 8}, layout.Builtins[2].Runner)
+	require.Equal(t, NewModBuiltin(128, 96, 1, Add), layout.Builtins[3].Runner)
+
+	// the allocated cells follow the ratios of the params
+	size, err := layout.Builtins[1].Runner.GetAllocatedSize(0, 1024)
+	require.NoError(t, err)
+	require.Equal(t, uint64(1024/256*cellsPerPedersen), size)
+	_, err = layout.Builtins[1].Runner.GetAllocatedSize(0, 128)
+	require.ErrorContains(t, err, "number of steps must be at least 256")
+
+	params.UsesBitwiseBuiltin = true
+	_, err = params.Layout()
+	require.EqualError(t, err, "layout params: bitwise is used but its ratio is not set")
+
+	params.UsesBitwiseBuiltin = false
+	params.AddModRatioDen = 2
+	_, err = params.Layout()
+	require.EqualError(t, err, "layout params: AddMod ratio denominator 2 is not supported, only 1 is")
+}
+
+func TestDeduceDynamicRatios(t *testing.T) {
+	params := DynamicLayoutParams{
+		RcUnits:               4,
+		UsesPedersenBuiltin:   true,
+		PedersenRatio:         8,
+		UsesRangeCheckBuiltin: true,
+		RangeCheckRatio:       8,
+	}
+	layout, err := params.Layout()
+	require.NoError(t, err)
+
+	// 5 pedersen instances take 8 of the instances of a ratio of 128, and the unused
+	// range check gets a single instance
+	fits, err := layout.DeduceDynamicRatios(map[string]uint64{PedersenName: 5 * cellsPerPedersen}, 1024)
+	require.NoError(t, err)
+	require.True(t, fits)
+	require.Equal(t, &Pedersen{ratio: 128}, layout.Builtins[1].Runner)
+	require.Equal(t, &RangeCheck{ratio: 1024, RangeCheckNParts: 8}, layout.Builtins[2].Runner)
+	require.Equal(t, uint64(128), layout.DynamicParams.PedersenRatio)
+	require.Equal(t, uint64(1024), layout.DynamicParams.RangeCheckRatio)
+	// the params of the caller are left untouched
+	require.Equal(t, uint64(8), params.PedersenRatio)
+
+	// more instances than steps need a longer trace
+	fits, err = layout.DeduceDynamicRatios(map[string]uint64{PedersenName: 2000 * cellsPerPedersen}, 1024)
+	require.NoError(t, err)
+	require.False(t, fits)
+	require.Equal(t, uint64(1), layout.DynamicParams.PedersenRatio)
+
+	small, err := GetLayout("small")
+	require.NoError(t, err)
+	_, err = small.DeduceDynamicRatios(nil, 1024)
+	require.EqualError(t, err, "layout small is not the dynamic layout")
+}
@@ -121,7 +121,7 @@ func (m *ModBuiltin) String() string {
 }
 
 func (m *ModBuiltin) GetAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint64) (uint64, error) {
-	return 0, nil
+	return getBuiltinAllocatedSize(segmentUsedSize, vmCurrentStep, m.ratio, CELLS_PER_MOD, 1, CELLS_PER_MOD)
 }
 
 // Reads N_WORDS from memory, starting at address = addr.
@@ -278,7 +278,8 @@ func (m *ModBuiltin) fillOffsets(mem *memory.Memory, offsetsPtr memory.MemoryAdd
 			return err
 		}
 
-		offset, err := mem.ReadAsAddress(&addr)
+		// the offsets are felts, copied as they are
+		offset, err := mem.ReadAsElement(addr.SegmentIndex, addr.Offset)
 		if err != nil {
 			return err
 		}
@@ -288,7 +289,7 @@ func (m *ModBuiltin) fillOffsets(mem *memory.Memory, offsetsPtr memory.MemoryAdd
 			if err != nil {
 				return err
 			}
-			mv := memory.MemoryValueFromMemoryAddress(&offset)
+			mv := memory.MemoryValueFromFieldElement(&offset)
 			if err := mem.WriteToAddress(&copyAddr, &mv); err != nil {
 				return err
 			}
@@ -599,6 +600,98 @@ func FillMemory(mem *memory.Memory, addModInputAddress memory.MemoryAddress, nAd
 	return nil
 }
 
+// CheckInstances verifies the instances of the segment once the run is over, as
+// the Python VM security checks do: each instance continues the batch of the
+// previous one, with the same modulus and values table, the next offsets and n
+// decreased by the batch size, the last batch being full, and each operation
+// a op b = c holds modulo p
+func (m *ModBuiltin) CheckInstances(mem *memory.Memory, modSegment *memory.Segment) error {
+	segmentIndex := -1
+	for i := range mem.Segments {
+		if mem.Segments[i] == modSegment {
+			segmentIndex = i
+			break
+		}
+	}
+	if segmentIndex == -1 {
+		return fmt.Errorf("%s builtin: segment is not part of the memory", m.String())
+	}
+
+	nInstances := (modSegment.Len() + CELLS_PER_MOD - 1) / CELLS_PER_MOD
+	var previous *ModBuiltinInputs
+	for instance := uint64(0); instance < nInstances; instance++ {
+		instanceFail := func(reason string) error {
+			return &PreconditionError{Builtin: m.String(), Instance: instance, Reason: reason}
+		}
+		inputs, err := m.readInputs(mem, memory.MemoryAddress{SegmentIndex: segmentIndex, Offset: instance * CELLS_PER_MOD}, true)
+		if err != nil {
+			return fmt.Errorf("%s instance %d: %w", m.String(), instance, err)
+		}
+		if previous != nil && previous.n > m.batchSize {
+			if inputs.p.Cmp(&previous.p) != 0 {
+				return instanceFail(fmt.Sprintf("modulus %s differs from the modulus %s of the previous instance", &inputs.p, &previous.p))
+			}
+			if !inputs.valuesPtr.Equal(&previous.valuesPtr) {
+				return instanceFail(fmt.Sprintf("values_ptr %s differs from the values_ptr %s of the previous instance", &inputs.valuesPtr, &previous.valuesPtr))
+			}
+			expectedOffsetsPtr, err := previous.offsetsPtr.AddOffset(int16(3 * m.batchSize))
+			if err != nil {
+				return err
+			}
+			if !inputs.offsetsPtr.Equal(&expectedOffsetsPtr) {
+				return instanceFail(fmt.Sprintf("expected offsets_ptr %s, got %s", &expectedOffsetsPtr, &inputs.offsetsPtr))
+			}
+			if inputs.n != previous.n-m.batchSize {
+				return instanceFail(fmt.Sprintf("expected n %d, got %d", previous.n-m.batchSize, inputs.n))
+			}
+		}
+
+		for indexInBatch := uint64(0); indexInBatch < m.batchSize; indexInBatch++ {
+			var operands [3]*big.Int
+			for i := range operands {
+				offsetAddr, err := inputs.offsetsPtr.AddOffset(int16(3*indexInBatch) + int16(i))
+				if err != nil {
+					return err
+				}
+				offset, err := mem.ReadAsElement(offsetAddr.SegmentIndex, offsetAddr.Offset)
+				if err != nil {
+					return fmt.Errorf("%s instance %d: %w", m.String(), instance, err)
+				}
+				valueAddr, err := inputs.valuesPtr.AddOffset(int16(offset.Uint64()))
+				if err != nil {
+					return err
+				}
+				_, operands[i], err = m.readNWordsValue(mem, valueAddr)
+				if err != nil {
+					return fmt.Errorf("%s instance %d: %w", m.String(), instance, err)
+				}
+			}
+			a, b, c := operands[0], operands[1], operands[2]
+			result := new(big.Int)
+			symbol := "+"
+			if m.modBuiltinType == Add {
+				result.Add(a, b)
+			} else {
+				result.Mul(a, b)
+				symbol = "*"
+			}
+			result.Sub(result, c)
+			if inputs.p.Sign() == 0 || result.Mod(result, &inputs.p).Sign() != 0 {
+				return instanceFail(fmt.Sprintf("operation %d of the batch is wrong: %s %s %s != %s (mod %s)", indexInBatch, a, symbol, b, c, &inputs.p))
+			}
+		}
+		previous = &inputs
+	}
+	if previous != nil && previous.n != m.batchSize {
+		return &PreconditionError{
+			Builtin:  m.String(),
+			Instance: nInstances - 1,
+			Reason:   fmt.Sprintf("expected n of the last instance to be the batch size %d, got %d", m.batchSize, previous.n),
+		}
+	}
+	return nil
+}
+
 func (m *ModBuiltin) GetCellsPerInstance() uint64 {
 	return CELLS_PER_MOD
 }
@@ -610,3 +703,165 @@ func (m *ModBuiltin) GetStopPointer() uint64 {
 func (m *ModBuiltin) SetStopPointer(stopPointer uint64) {
 	m.stopPointer = stopPointer
 }
+
+// the values are filled in memory by the fill_memory hint, so the builtin has no
+// state besides its stop pointer
+func (m *ModBuiltin) Snapshot() Snapshot {
+	return Snapshot{builtin: m.String(), stopPointer: m.stopPointer}
+}
+
+func (m *ModBuiltin) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(m, &snapshot); err != nil {
+		return err
+	}
+	m.stopPointer = snapshot.stopPointer
+	return nil
+}
+
+type AirPrivateBuiltinMod struct {
+	Instances []AirPrivateBuiltinModInstance `json:"instances"`
+}
+
+type AirPrivateBuiltinModInstance struct {
+	Index      int                                   `json:"index"`
+	P0         string                                `json:"p0"`
+	P1         string                                `json:"p1"`
+	P2         string                                `json:"p2"`
+	P3         string                                `json:"p3"`
+	ValuesPtr  uint64                                `json:"values_ptr"`
+	OffsetsPtr uint64                                `json:"offsets_ptr"`
+	N          uint64                                `json:"n"`
+	Batch      map[int]AirPrivateBuiltinModOperation `json:"batch"`
+}
+
+// An operation a op b = c of a batch, the values being given by their words
+type AirPrivateBuiltinModOperation struct {
+	AOffset uint64 `json:"a_offset"`
+	A0      string `json:"a0"`
+	A1      string `json:"a1"`
+	A2      string `json:"a2"`
+	A3      string `json:"a3"`
+	BOffset uint64 `json:"b_offset"`
+	B0      string `json:"b0"`
+	B1      string `json:"b1"`
+	B2      string `json:"b2"`
+	B3      string `json:"b3"`
+	COffset uint64 `json:"c_offset"`
+	C0      string `json:"c0"`
+	C1      string `json:"c1"`
+	C2      string `json:"c2"`
+	C3      string `json:"c3"`
+}
+
+// GetAirPrivateInput returns the instances of the segment with the operations of
+// their batch, the values and offsets pointers being relocated. The memory is
+// expected to be complete, as after the fill_memory hint has run
+func (m *ModBuiltin) GetAirPrivateInput(mem *memory.Memory, modSegment *memory.Segment) (AirPrivateBuiltinMod, error) {
+	segmentsOffsets, _ := mem.RelocationOffsets()
+	instances := make([]AirPrivateBuiltinModInstance, 0)
+	for addrOffset := uint64(0); addrOffset+CELLS_PER_MOD <= modSegment.Len(); addrOffset += CELLS_PER_MOD {
+		idx := addrOffset / CELLS_PER_MOD
+		// peeked, as Read would extend the segment
+		var cells [CELLS_PER_MOD]memory.MemoryValue
+		for i := range cells {
+			cells[i] = modSegment.Peek(addrOffset + uint64(i))
+			if !cells[i].Known() {
+				return AirPrivateBuiltinMod{}, &PreconditionError{
+					Builtin:  m.String(),
+					Instance: idx,
+					Reason:   fmt.Sprintf("cell %d of the instance is not written", i),
+					Hint:     "the cells of the instances are written by the fill_memory hint",
+				}
+			}
+		}
+		valuesPtr, err := cells[VALUES_PTR_OFFSET].MemoryAddress()
+		if err != nil {
+			return AirPrivateBuiltinMod{}, err
+		}
+		offsetsPtr, err := cells[OFFSETS_PTR_OFFSET].MemoryAddress()
+		if err != nil {
+			return AirPrivateBuiltinMod{}, err
+		}
+		n, err := cells[N_OFFSET].Uint64()
+		if err != nil {
+			return AirPrivateBuiltinMod{}, err
+		}
+		relocatedValuesPtr, err := relocateModPointer(segmentsOffsets, valuesPtr)
+		if err != nil {
+			return AirPrivateBuiltinMod{}, err
+		}
+		relocatedOffsetsPtr, err := relocateModPointer(segmentsOffsets, offsetsPtr)
+		if err != nil {
+			return AirPrivateBuiltinMod{}, err
+		}
+
+		instance := AirPrivateBuiltinModInstance{
+			Index:      int(idx),
+			P0:         modWordHex(&cells[0]),
+			P1:         modWordHex(&cells[1]),
+			P2:         modWordHex(&cells[2]),
+			P3:         modWordHex(&cells[3]),
+			ValuesPtr:  relocatedValuesPtr,
+			OffsetsPtr: relocatedOffsetsPtr,
+			N:          n,
+			Batch:      make(map[int]AirPrivateBuiltinModOperation, m.batchSize),
+		}
+		for indexInBatch := uint64(0); indexInBatch < m.batchSize; indexInBatch++ {
+			var offsets [3]uint64
+			var words [3][N_WORDS]string
+			for i := range offsets {
+				offsetValue := peekModCell(mem, offsetsPtr, 3*indexInBatch+uint64(i))
+				if !offsetValue.Known() {
+					return AirPrivateBuiltinMod{}, &PreconditionError{
+						Builtin:  m.String(),
+						Instance: idx,
+						Reason:   fmt.Sprintf("offset %d of the offsets table is not written", 3*indexInBatch+uint64(i)),
+						Hint:     "the offsets are padded by the fill_memory hint",
+					}
+				}
+				offset, err := offsetValue.Uint64()
+				if err != nil {
+					return AirPrivateBuiltinMod{}, err
+				}
+				offsets[i] = offset
+				for j := range words[i] {
+					word := peekModCell(mem, valuesPtr, offset+uint64(j))
+					if !word.Known() {
+						return AirPrivateBuiltinMod{}, &PreconditionError{
+							Builtin:  m.String(),
+							Instance: idx,
+							Reason:   fmt.Sprintf("value at offset %d of the values table is not written", offset+uint64(j)),
+							Hint:     "the values are computed by the fill_memory hint",
+						}
+					}
+					words[i][j] = modWordHex(&word)
+				}
+			}
+			instance.Batch[int(indexInBatch)] = AirPrivateBuiltinModOperation{
+				AOffset: offsets[0], A0: words[0][0], A1: words[0][1], A2: words[0][2], A3: words[0][3],
+				BOffset: offsets[1], B0: words[1][0], B1: words[1][1], B2: words[1][2], B3: words[1][3],
+				COffset: offsets[2], C0: words[2][0], C1: words[2][1], C2: words[2][2], C3: words[2][3],
+			}
+		}
+		instances = append(instances, instance)
+	}
+	return AirPrivateBuiltinMod{Instances: instances}, nil
+}
+
+func peekModCell(mem *memory.Memory, base *memory.MemoryAddress, offset uint64) memory.MemoryValue {
+	if base.SegmentIndex < 0 || base.SegmentIndex >= len(mem.Segments) {
+		return memory.UnknownValue
+	}
+	return mem.Segments[base.SegmentIndex].Peek(base.Offset + offset)
+}
+
+func relocateModPointer(segmentsOffsets []uint64, ptr *memory.MemoryAddress) (uint64, error) {
+	if ptr.SegmentIndex < 0 || ptr.SegmentIndex >= len(segmentsOffsets) {
+		return 0, fmt.Errorf("cannot relocate pointer %s", ptr)
+	}
+	return segmentsOffsets[ptr.SegmentIndex] + ptr.Offset, nil
+}
+
+func modWordHex(value *memory.MemoryValue) string {
+	return "0x" + value.Felt.Text(16)
+}
@@ -156,3 +156,140 @@ func TestMulModBuiltinRunnerDivision(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, big.NewInt(22), res5)
 }
+
+func TestAddModAirPrivateInput(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	values := mem.AllocateEmptySegment()
+	offsets := mem.AllocateEmptySegment()
+	builtin := mem.AllocateBuiltinSegment(NewModBuiltin(0, 96, 1, Add))
+
+	// 17 + 40 = c (mod 67), c being computed by FillMemory
+	write := func(segment int, offset uint64, value memory.MemoryValue) {
+		require.NoError(t, mem.Write(segment, offset, &value))
+	}
+	for i, word := range []int{17, 0, 0, 0, 40, 0, 0, 0} {
+		write(values.SegmentIndex, uint64(i), memory.MemoryValueFromInt(word))
+	}
+	for i, offset := range []int{0, 4, 8} {
+		write(offsets.SegmentIndex, uint64(i), memory.MemoryValueFromInt(offset))
+	}
+	for i, word := range []int{67, 0, 0, 0} {
+		write(builtin.SegmentIndex, uint64(i), memory.MemoryValueFromInt(word))
+	}
+	write(builtin.SegmentIndex, VALUES_PTR_OFFSET, memory.MemoryValueFromMemoryAddress(&values))
+	write(builtin.SegmentIndex, OFFSETS_PTR_OFFSET, memory.MemoryValueFromMemoryAddress(&offsets))
+	write(builtin.SegmentIndex, N_OFFSET, memory.MemoryValueFromInt(1))
+
+	segment := mem.Segments[builtin.SegmentIndex]
+	runner := segment.BuiltinRunner.(*ModBuiltin)
+	require.NoError(t, FillMemory(mem, builtin, 1, memory.UnknownAddress, 0))
+
+	input, err := runner.GetAirPrivateInput(mem, segment)
+	require.NoError(t, err)
+	// the values segment starts at 1 and the offsets one after its 12 cells
+	require.Equal(t, AirPrivateBuiltinMod{
+		Instances: []AirPrivateBuiltinModInstance{{
+			Index:      0,
+			P0:         "0x43",
+			P1:         "0x0",
+			P2:         "0x0",
+			P3:         "0x0",
+			ValuesPtr:  1,
+			OffsetsPtr: 13,
+			N:          1,
+			Batch: map[int]AirPrivateBuiltinModOperation{
+				0: {
+					AOffset: 0, A0: "0x11", A1: "0x0", A2: "0x0", A3: "0x0",
+					BOffset: 4, B0: "0x28", B1: "0x0", B2: "0x0", B3: "0x0",
+					COffset: 8, C0: "0x39", C1: "0x0", C2: "0x0", C3: "0x0",
+				},
+			},
+		}},
+	}, input)
+}
+
+func TestAddModAirPrivateInputIncompleteInstance(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	builtin := mem.AllocateBuiltinSegment(NewModBuiltin(0, 96, 1, Add))
+	for i := uint64(0); i < CELLS_PER_MOD; i++ {
+		if i == VALUES_PTR_OFFSET {
+			continue
+		}
+		value := memory.MemoryValueFromInt(1)
+		require.NoError(t, mem.Write(builtin.SegmentIndex, i, &value))
+	}
+
+	segment := mem.Segments[builtin.SegmentIndex]
+	_, err := segment.BuiltinRunner.(*ModBuiltin).GetAirPrivateInput(mem, segment)
+	require.ErrorContains(t, err, "AddMod instance 0: cell 4 of the instance is not written")
+}
+
+// Writes mul_mod instances computing a * b = c over the values table, each value
+// being given as a single word, nil leaving it to FillMemory
+func writeMulModCircuit(t *testing.T, mem *memory.Memory, p int, n int, values []*int, offsets []int) memory.MemoryAddress {
+	valuesAddr := mem.AllocateEmptySegment()
+	offsetsAddr := mem.AllocateEmptySegment()
+	builtin := mem.AllocateBuiltinSegment(NewModBuiltin(0, 96, 1, Mul))
+
+	write := func(segment int, offset uint64, value memory.MemoryValue) {
+		require.NoError(t, mem.Write(segment, offset, &value))
+	}
+	for i, value := range values {
+		if value == nil {
+			continue
+		}
+		for j, word := range []int{*value, 0, 0, 0} {
+			write(valuesAddr.SegmentIndex, uint64(i*N_WORDS+j), memory.MemoryValueFromInt(word))
+		}
+	}
+	for i, offset := range offsets {
+		write(offsetsAddr.SegmentIndex, uint64(i), memory.MemoryValueFromInt(offset*N_WORDS))
+	}
+	for i, word := range []int{p, 0, 0, 0} {
+		write(builtin.SegmentIndex, uint64(i), memory.MemoryValueFromInt(word))
+	}
+	write(builtin.SegmentIndex, VALUES_PTR_OFFSET, memory.MemoryValueFromMemoryAddress(&valuesAddr))
+	write(builtin.SegmentIndex, OFFSETS_PTR_OFFSET, memory.MemoryValueFromMemoryAddress(&offsetsAddr))
+	write(builtin.SegmentIndex, N_OFFSET, memory.MemoryValueFromInt(n))
+	return builtin
+}
+
+func TestMulModFillMemoryAndCheckInstances(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	three, five, nine := 3, 5, 9
+	// 3 * 5 = x0 and x1 * 3 = 9 (mod 11), x1 being found by inversion
+	builtin := writeMulModCircuit(t, mem, 11, 2, []*int{&three, &five, nil, nil, &nine}, []int{0, 1, 2, 3, 0, 4})
+	require.NoError(t, FillMemory(mem, memory.UnknownAddress, 0, builtin, 2))
+
+	segment := mem.Segments[builtin.SegmentIndex]
+	runner := segment.BuiltinRunner.(*ModBuiltin)
+	require.NoError(t, runner.CheckInstances(mem, segment))
+
+	input, err := runner.GetAirPrivateInput(mem, segment)
+	require.NoError(t, err)
+	require.Len(t, input.Instances, 2)
+	require.Equal(t, "0x4", input.Instances[0].Batch[0].C0)
+	require.Equal(t, "0x3", input.Instances[1].Batch[0].A0)
+	require.Equal(t, uint64(1), input.Instances[1].N)
+}
+
+func TestMulModCheckInstancesWrongOperation(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	three, five, seven := 3, 5, 7
+	builtin := writeMulModCircuit(t, mem, 11, 1, []*int{&three, &five, &seven}, []int{0, 1, 2})
+
+	segment := mem.Segments[builtin.SegmentIndex]
+	err := segment.BuiltinRunner.(*ModBuiltin).CheckInstances(mem, segment)
+	require.ErrorContains(t, err, "MulMod instance 0: operation 0 of the batch is wrong: 3 * 5 != 7 (mod 11)")
+}
+
+func TestMulModCheckInstancesIncompleteBatch(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	three, five, four := 3, 5, 4
+	// n says two operations but the segment only has the first instance
+	builtin := writeMulModCircuit(t, mem, 11, 2, []*int{&three, &five, &four}, []int{0, 1, 2})
+
+	segment := mem.Segments[builtin.SegmentIndex]
+	err := segment.BuiltinRunner.(*ModBuiltin).CheckInstances(mem, segment)
+	require.ErrorContains(t, err, "expected n of the last instance to be the batch size 1, got 2")
+}
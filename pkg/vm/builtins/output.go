@@ -1,8 +1,11 @@
 package builtins
 
 import (
+	"cmp"
 	"errors"
 	"fmt"
+	"maps"
+	"slices"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 )
@@ -12,6 +15,7 @@ const OutputName = "output"
 type Output struct {
 	stopPointer uint64
 	pages       []Page
+	attributes  map[string][]uint64
 }
 
 func (o *Output) CheckWrite(segment *memory.Segment, offset uint64, value *memory.MemoryValue) error {
@@ -45,12 +49,84 @@ func (o *Output) SetStopPointer(stopPointer uint64) {
 	o.stopPointer = stopPointer
 }
 
+type outputState struct {
+	pages      []Page
+	attributes map[string][]uint64
+}
+
+func (o *Output) Snapshot() Snapshot {
+	return Snapshot{builtin: OutputName, stopPointer: o.stopPointer, state: outputState{
+		pages:      slices.Clone(o.pages),
+		attributes: maps.Clone(o.attributes),
+	}}
+}
+
+func (o *Output) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(o, &snapshot); err != nil {
+		return err
+	}
+	o.stopPointer = snapshot.stopPointer
+	state := snapshot.state.(outputState)
+	o.pages = slices.Clone(state.pages)
+	o.attributes = maps.Clone(state.attributes)
+	return nil
+}
+
+// Page is a range of the output segment the prover commits to apart from the rest
+// of the output, e.g. the data availability of the bootloader. Start is an offset in
+// the output segment
 type Page struct {
-	start uint64
-	size  uint64
+	ID    uint64
+	Start uint64
+	Size  uint64
+}
+
+// AddPage assigns the cells of the output segment in [start, start + size) to a
+// page, as output_builtin.add_page does in the Python VM. The page 0 holds the cells
+// of no other page, so it cannot be added
+func (o *Output) AddPage(id, start, size uint64) error {
+	if id == 0 {
+		return errors.New("page 0 is the default page and cannot be added")
+	}
+	for _, page := range o.pages {
+		if page.ID == id {
+			return fmt.Errorf("page %d was already added", id)
+		}
+		if start < page.Start+page.Size && page.Start < start+size {
+			return fmt.Errorf("page %d at [%d, %d) overlaps page %d at [%d, %d)", id, start, start+size, page.ID, page.Start, page.Start+page.Size)
+		}
+	}
+	o.pages = append(o.pages, Page{ID: id, Start: start, Size: size})
+	return nil
+}
+
+// Pages returns the pages added to the output, by increasing id
+func (o *Output) Pages() []Page {
+	pages := slices.Clone(o.pages)
+	slices.SortFunc(pages, func(a, b Page) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return pages
+}
+
+// AddAttribute sets an attribute of the output, e.g. the gps_fact_topology the
+// bootloader uses to describe the tree of the fact of its tasks. Setting an
+// attribute again replaces its values
+func (o *Output) AddAttribute(name string, values []uint64) {
+	if o.attributes == nil {
+		o.attributes = make(map[string][]uint64)
+	}
+	o.attributes[name] = slices.Clone(values)
+}
+
+// Attributes returns the attributes of the output, keyed by name
+func (o *Output) Attributes() map[string][]uint64 {
+	return maps.Clone(o.attributes)
 }
 
-func (output *Output) GetOutputPublicMemory(outputSegment memory.Segment) []memory.PublicMemoryOffset {
+// GetOutputPublicMemory returns the public memory of the output segment, each cell
+// in its page. The pages must fit in the segment
+func (output *Output) GetOutputPublicMemory(outputSegment memory.Segment) ([]memory.PublicMemoryOffset, error) {
 	publicMemory := make([]memory.PublicMemoryOffset, outputSegment.Len())
 
 	for i := uint64(0); i < outputSegment.Len(); i++ {
@@ -61,10 +137,12 @@ func (output *Output) GetOutputPublicMemory(outputSegment memory.Segment) []memo
 	}
 
 	for _, page := range output.pages {
-		for index := uint64(0); index < page.size; index++ {
-			publicMemory[page.start+index].Page = uint16(page.start)
+		if page.Start+page.Size > outputSegment.Len() {
+			return nil, fmt.Errorf("page %d at [%d, %d) is out of the %d cells of the output segment", page.ID, page.Start, page.Start+page.Size, outputSegment.Len())
+		}
+		for index := uint64(0); index < page.Size; index++ {
+			publicMemory[page.Start+index].Page = uint16(page.ID)
 		}
 	}
-	return publicMemory
-
+	return publicMemory, nil
 }
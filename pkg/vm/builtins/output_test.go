@@ -20,3 +20,39 @@ func TestOutput(t *testing.T) {
 	require.ErrorContains(t, err, "expected a felt but got an address")
 
 }
+
+func TestOutputPages(t *testing.T) {
+	output := &Output{}
+	require.EqualError(t, output.AddPage(0, 0, 1), "page 0 is the default page and cannot be added")
+	require.NoError(t, output.AddPage(2, 4, 2))
+	require.NoError(t, output.AddPage(1, 1, 2))
+	require.EqualError(t, output.AddPage(2, 8, 1), "page 2 was already added")
+	require.EqualError(t, output.AddPage(3, 2, 3), "page 3 at [2, 5) overlaps page 2 at [4, 6)")
+	require.Equal(t, []Page{{ID: 1, Start: 1, Size: 2}, {ID: 2, Start: 4, Size: 2}}, output.Pages())
+
+	segment := memory.EmptySegmentWithLength(6).WithBuiltinRunner(output)
+	publicMemory, err := output.GetOutputPublicMemory(*segment)
+	require.NoError(t, err)
+	pages := make([]uint16, len(publicMemory))
+	for i := range publicMemory {
+		require.Equal(t, uint16(i), publicMemory[i].Address)
+		pages[i] = publicMemory[i].Page
+	}
+	require.Equal(t, []uint16{0, 1, 1, 0, 2, 2}, pages)
+
+	segment = memory.EmptySegmentWithLength(5).WithBuiltinRunner(output)
+	_, err = output.GetOutputPublicMemory(*segment)
+	require.EqualError(t, err, "page 2 at [4, 6) is out of the 5 cells of the output segment")
+}
+
+func TestOutputAttributesSnapshot(t *testing.T) {
+	output := &Output{}
+	output.AddAttribute("gps_fact_topology", []uint64{1, 0})
+	snapshot := output.Snapshot()
+
+	require.NoError(t, output.AddPage(1, 0, 1))
+	output.AddAttribute("gps_fact_topology", []uint64{2, 1, 0, 0})
+	require.NoError(t, output.Restore(snapshot))
+	require.Empty(t, output.Pages())
+	require.Equal(t, map[string][]uint64{"gps_fact_topology": {1, 0}}, output.Attributes())
+}
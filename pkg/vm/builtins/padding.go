@@ -0,0 +1,74 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// PaddedBuiltin is implemented by the builtins whose partially used instances can be
+// completed with dummy values the prover accepts, for the proof mode runs
+type PaddedBuiltin interface {
+	memory.BuiltinRunner
+	// Pad completes the instances of the segment that have some of their cells written,
+	// leaving the unused instances as they are
+	Pad(segment *memory.Segment) error
+}
+
+var (
+	_ PaddedBuiltin = (*Pedersen)(nil)
+	_ PaddedBuiltin = (*Bitwise)(nil)
+	_ PaddedBuiltin = (*Keccak)(nil)
+	_ PaddedBuiltin = (*Poseidon)(nil)
+)
+
+// Zero is a valid input of these builtins
+func (p *Pedersen) Pad(segment *memory.Segment) error {
+	return padInstances(segment, inputCellsPerPedersen, cellsPerPedersen)
+}
+
+func (b *Bitwise) Pad(segment *memory.Segment) error {
+	return padInstances(segment, inputCellsPerBitwise, cellsPerBitwise)
+}
+
+func (k *Keccak) Pad(segment *memory.Segment) error {
+	return padInstances(segment, inputCellsPerKeccak, cellsPerKeccak)
+}
+
+func (p *Poseidon) Pad(segment *memory.Segment) error {
+	return padInstances(segment, inputCellsPerPoseidon, cellsPerPoseidon)
+}
+
+// Writes zero to the unknown input cells of the used instances, then deduces their
+// unknown output cells from the inputs
+func padInstances(segment *memory.Segment, inputCells, cellsPerInstance uint64) error {
+	zero := memory.MemoryValueFromInt(0)
+	instances := (segment.Len() + cellsPerInstance - 1) / cellsPerInstance
+	for instance := uint64(0); instance < instances; instance++ {
+		base := instance * cellsPerInstance
+		used := false
+		for cell := uint64(0); cell < cellsPerInstance && !used; cell++ {
+			mv := segment.Peek(base + cell)
+			used = mv.Known()
+		}
+		if !used {
+			continue
+		}
+
+		for cell := uint64(0); cell < cellsPerInstance; cell++ {
+			if mv := segment.Peek(base + cell); mv.Known() {
+				continue
+			}
+			var err error
+			if cell < inputCells {
+				err = segment.Write(base+cell, &zero)
+			} else {
+				_, err = segment.Read(base + cell)
+			}
+			if err != nil {
+				return fmt.Errorf("instance %d: %w", instance, err)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPadBitwise(t *testing.T) {
+	bitwise := &Bitwise{}
+	segment := memory.EmptySegment().WithBuiltinRunner(bitwise)
+	// first instance complete but for the or cell, the third with only x written
+	for offset, value := range map[uint64]uint64{0: 12, 1: 10, 2: 8, 3: 6, 10: 5} {
+		mv := memory.MemoryValueFromUint(value)
+		require.NoError(t, segment.Write(offset, &mv))
+	}
+
+	require.NoError(t, bitwise.Pad(segment))
+	expected := []uint64{12, 10, 8, 6, 14}
+	for cell, value := range expected {
+		require.Equal(t, memory.MemoryValueFromUint(value), segment.Peek(uint64(cell)))
+	}
+	// the unused second instance is left as it is
+	for offset := uint64(5); offset < 10; offset++ {
+		mv := segment.Peek(offset)
+		require.False(t, mv.Known())
+	}
+	for cell, value := range []uint64{5, 0, 0, 5, 5} {
+		require.Equal(t, memory.MemoryValueFromUint(value), segment.Peek(10+uint64(cell)))
+	}
+	// the security checks still report the unused instance
+	require.EqualError(t, CheckSegment(segment), "missing input cells at offsets [5 6]")
+}
+
+func TestPadPedersen(t *testing.T) {
+	pedersen := &Pedersen{}
+	segment := memory.EmptySegment().WithBuiltinRunner(pedersen)
+	x := memory.MemoryValueFromUint(uint64(1))
+	require.NoError(t, segment.Write(0, &x))
+
+	require.NoError(t, pedersen.Pad(segment))
+	one, zero := fp.NewElement(1), fp.Element{}
+	hash := utils.PedersenHash(&one, &zero)
+	require.Equal(t, memory.MemoryValueFromFieldElement(&hash), segment.Peek(2))
+	require.Equal(t, uint64(3), segment.Len())
+}
+
+func TestPadErrors(t *testing.T) {
+	bitwise := &Bitwise{}
+	segment := memory.EmptySegment().WithBuiltinRunner(bitwise)
+	segment.BuiltinMode = memory.ValidateOnly
+	x := memory.MemoryValueFromUint(uint64(1))
+	require.NoError(t, segment.Write(0, &x))
+	require.EqualError(t, bitwise.Pad(segment), "instance 0: bitwise: deduction is disabled")
+}
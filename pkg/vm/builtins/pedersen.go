@@ -3,11 +3,10 @@ package builtins
 import (
 	"errors"
 	"fmt"
-	"math/big"
-	"sort"
 
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
-	pedersenhash "github.com/consensys/gnark-crypto/ecc/stark-curve/pedersen-hash"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
 const (
@@ -20,6 +19,18 @@ const (
 type Pedersen struct {
 	ratio       uint64
 	stopPointer uint64
+	// deduce the hashes with utils.PedersenHashWithTables
+	tables bool
+	// instances whose hash was deduced by the builtin, which the security checks
+	// don't need to compute again
+	deduced verifiedInstances
+}
+
+// UsePrecomputedTables makes the builtin deduce the hashes from the precomputed
+// tables of utils.PedersenHashWithTables, about twice as fast, at the cost of the
+// megabyte the tables take and of building them on the first deduction
+func (p *Pedersen) UsePrecomputedTables() {
+	p.tables = true
 }
 
 func (p *Pedersen) CheckWrite(segment *mem.Segment, offset uint64, value *mem.MemoryValue) error {
@@ -56,9 +67,35 @@ func (p *Pedersen) InferValue(segment *mem.Segment, offset uint64) error {
 		return err
 	}
 
-	hash := pedersenhash.Pedersen(xFelt, yFelt)
+	var hash fp.Element
+	if p.tables {
+		hash = utils.PedersenHashWithTables(xFelt, yFelt)
+	} else {
+		hash = utils.PedersenHash(xFelt, yFelt)
+	}
 	hashValue := mem.MemoryValueFromFieldElement(&hash)
-	return segment.Write(xOffset+2, &hashValue)
+	if err := segment.Write(xOffset+2, &hashValue); err != nil {
+		return err
+	}
+	p.deduced.add(xOffset / cellsPerPedersen)
+	return nil
+}
+
+func (p *Pedersen) instanceVerified(instance uint64) bool {
+	return p.deduced.has(instance)
+}
+
+func (p *Pedersen) Snapshot() Snapshot {
+	return Snapshot{builtin: PedersenName, stopPointer: p.stopPointer, state: p.deduced.clone()}
+}
+
+func (p *Pedersen) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(p, &snapshot); err != nil {
+		return err
+	}
+	p.stopPointer = snapshot.stopPointer
+	p.deduced = snapshot.state.(verifiedInstances).clone()
+	return nil
 }
 
 func (p *Pedersen) String() string {
@@ -76,46 +113,21 @@ type AirPrivateBuiltinPedersen struct {
 }
 
 func (p *Pedersen) GetAirPrivateInput(pedersenSegment *mem.Segment) []AirPrivateBuiltinPedersen {
-	valueMapping := make(map[int]AirPrivateBuiltinPedersen)
-	for index, value := range pedersenSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		idx, typ := index/cellsPerPedersen, index%cellsPerPedersen
-		if typ == 2 {
-			continue
-		}
-
-		builtinValue, exists := valueMapping[idx]
-		if !exists {
-			builtinValue = AirPrivateBuiltinPedersen{Index: idx}
-		}
-
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		if typ == 0 {
-			builtinValue.X = valueHex
-		} else {
-			builtinValue.Y = valueHex
-		}
-		valueMapping[idx] = builtinValue
-	}
-
 	values := make([]AirPrivateBuiltinPedersen, 0)
-
-	sortedIndexes := make([]int, 0, len(valueMapping))
-	for index := range valueMapping {
-		sortedIndexes = append(sortedIndexes, index)
-	}
-	sort.Ints(sortedIndexes)
-	for _, index := range sortedIndexes {
-		value := valueMapping[index]
+	_ = p.eachAirPrivateInput(pedersenSegment, func(value AirPrivateBuiltinPedersen) error {
 		values = append(values, value)
-	}
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (p *Pedersen) eachAirPrivateInput(pedersenSegment *mem.Segment, yield func(AirPrivateBuiltinPedersen) error) error {
+	return eachAirPrivateInstance(pedersenSegment, cellsPerPedersen, inputCellsPerPedersen, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinPedersen{Index: idx, X: inputs[0], Y: inputs[1]})
+	})
+}
+
 func (p *Pedersen) GetCellsPerInstance() uint64 {
 	return cellsPerPedersen
 }
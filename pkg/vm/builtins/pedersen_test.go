@@ -10,7 +10,14 @@ import (
 )
 
 func TestPedersen(t *testing.T) {
-	pedersen := &Pedersen{}
+	tablesPedersen := &Pedersen{}
+	tablesPedersen.UsePrecomputedTables()
+	for _, pedersen := range []*Pedersen{{}, tablesPedersen} {
+		testPedersenHash(t, pedersen)
+	}
+}
+
+func testPedersenHash(t *testing.T, pedersen *Pedersen) {
 	segment := memory.EmptySegmentWithLength(3)
 	segment.WithBuiltinRunner(pedersen)
 
@@ -3,8 +3,7 @@ package builtins
 import (
 	"errors"
 	"fmt"
-	"math/big"
-	"sort"
+	"maps"
 
 	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -40,19 +39,30 @@ func (p *Poseidon) InferValue(segment *mem.Segment, offset uint64) error {
 	for i := 0; i < inputCellsPerPoseidon; i++ {
 		mv := segment.Peek(baseOffset + uint64(i))
 		if !mv.Known() {
-			return errors.New("cannot infer value")
+			return &PreconditionError{
+				Builtin:  PoseidonName,
+				Instance: baseOffset / cellsPerPoseidon,
+				Reason:   fmt.Sprintf("cannot infer value: input value at offset %d is unknown", baseOffset+uint64(i)),
+				Hint:     "the 3 input cells must be written before reading an output cell",
+			}
 		}
 		poseidonInputValue, err := mv.FieldElement()
 		if err != nil {
-			return err
+			return &PreconditionError{
+				Builtin:  PoseidonName,
+				Instance: baseOffset / cellsPerPoseidon,
+				Reason:   fmt.Sprintf("input value at offset %d has to be felt", baseOffset+uint64(i)),
+			}
 		}
 		poseidonInputValues[i] = poseidonInputValue
 	}
 
-	// poseidon hash calculation
+	// poseidon hash calculation, the whole output state being cached so that reading
+	// the other output cells of the instance doesn't permute again
 	hash := PoseidonPerm(poseidonInputValues[0], poseidonInputValues[1], poseidonInputValues[2])
-	for i := 0; i < 3; i++ {
-		p.cache[offset+uint64(i)] = hash[i]
+	outputOffset := baseOffset + inputCellsPerPoseidon
+	for i := range hash {
+		p.cache[outputOffset+uint64(i)] = hash[i]
 	}
 	value = p.cache[offset]
 	mv := mem.MemoryValueFromFieldElement(&value)
@@ -75,48 +85,21 @@ type AirPrivateBuiltinPoseidon struct {
 }
 
 func (p *Poseidon) GetAirPrivateInput(poseidonSegment *mem.Segment) []AirPrivateBuiltinPoseidon {
-	valueMapping := make(map[int]AirPrivateBuiltinPoseidon)
-	for index, value := range poseidonSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		idx, stateIndex := index/cellsPerPoseidon, index%cellsPerPoseidon
-		if stateIndex >= inputCellsPerPoseidon {
-			continue
-		}
-
-		builtinValue, exists := valueMapping[idx]
-		if !exists {
-			builtinValue = AirPrivateBuiltinPoseidon{Index: idx}
-		}
-
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		if stateIndex == 0 {
-			builtinValue.InputS0 = valueHex
-		} else if stateIndex == 1 {
-			builtinValue.InputS1 = valueHex
-		} else if stateIndex == 2 {
-			builtinValue.InputS2 = valueHex
-		}
-		valueMapping[idx] = builtinValue
-	}
-
 	values := make([]AirPrivateBuiltinPoseidon, 0)
-
-	sortedIndexes := make([]int, 0, len(valueMapping))
-	for index := range valueMapping {
-		sortedIndexes = append(sortedIndexes, index)
-	}
-	sort.Ints(sortedIndexes)
-	for _, index := range sortedIndexes {
-		value := valueMapping[index]
+	_ = p.eachAirPrivateInput(poseidonSegment, func(value AirPrivateBuiltinPoseidon) error {
 		values = append(values, value)
-	}
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (p *Poseidon) eachAirPrivateInput(poseidonSegment *mem.Segment, yield func(AirPrivateBuiltinPoseidon) error) error {
+	return eachAirPrivateInstance(poseidonSegment, cellsPerPoseidon, inputCellsPerPoseidon, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinPoseidon{Index: idx, InputS0: inputs[0], InputS1: inputs[1], InputS2: inputs[2]})
+	})
+}
+
 func (b *Poseidon) GetCellsPerInstance() uint64 {
 	return cellsPerPoseidon
 }
@@ -128,3 +111,16 @@ func (p *Poseidon) GetStopPointer() uint64 {
 func (p *Poseidon) SetStopPointer(stopPointer uint64) {
 	p.stopPointer = stopPointer
 }
+
+func (p *Poseidon) Snapshot() Snapshot {
+	return Snapshot{builtin: PoseidonName, stopPointer: p.stopPointer, state: maps.Clone(p.cache)}
+}
+
+func (p *Poseidon) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(p, &snapshot); err != nil {
+		return err
+	}
+	p.stopPointer = snapshot.stopPointer
+	p.cache = maps.Clone(snapshot.state.(map[uint64]fp.Element))
+	return nil
+}
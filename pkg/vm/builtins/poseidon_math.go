@@ -62,6 +62,35 @@ func PoseidonPerm(x, y, z *fp.Element) []fp.Element {
 	return state
 }
 
+// PoseidonHash computes the Poseidon hash of two felts, as `poseidon_hash` of
+// cairo-lang: the first element of the permutation of (x, y, 2)
+func PoseidonHash(x, y *fp.Element) fp.Element {
+	two := fp.NewElement(2)
+	return PoseidonPerm(x, y, &two)[0]
+}
+
+// PoseidonHashMany computes the Poseidon hash of a list of felts, as
+// `poseidon_hash_many` of cairo-lang with which Starknet computes class hashes: the
+// values, padded with a 1 and then zeros to an even length, are absorbed two at a
+// time by a sponge whose first element is the hash
+func PoseidonHashMany(values []*fp.Element) fp.Element {
+	one := fp.One()
+	padded := make([]*fp.Element, len(values), len(values)+2)
+	copy(padded, values)
+	padded = append(padded, &one)
+	if len(padded)%2 != 0 {
+		padded = append(padded, &fp.Element{})
+	}
+
+	state := make([]fp.Element, 3)
+	for i := 0; i < len(padded); i += 2 {
+		state[0].Add(&state[0], padded[i])
+		state[1].Add(&state[1], padded[i+1])
+		hadesPermutation(state)
+	}
+	return state[0]
+}
+
 var (
 	initialiseRoundKeys sync.Once
 	roundKeys           = [][]fp.Element{}
@@ -43,3 +43,66 @@ func TestPoseidon(t *testing.T) {
 		assert.Equal(t, v, hashValue.Text(16))
 	}
 }
+
+func TestPoseidonOutputReadFirst(t *testing.T) {
+	poseidon := &Poseidon{ratio: 32, cache: make(map[uint64]fp.Element)}
+	segment := memory.EmptySegmentWithLength(2 * cellsPerPoseidon)
+	segment.WithBuiltinRunner(poseidon)
+
+	// the second instance, whose last output cell is read first
+	for i := uint64(0); i < inputCellsPerPoseidon; i++ {
+		value := memory.MemoryValueFromUint(i + 1)
+		require.NoError(t, segment.Write(cellsPerPoseidon+i, &value))
+	}
+	_, err := segment.Read(cellsPerPoseidon - 1)
+	var precondition *PreconditionError
+	require.ErrorAs(t, err, &precondition)
+	require.Equal(t, uint64(0), precondition.Instance)
+
+	expected := PoseidonPerm(new(fp.Element).SetUint64(1), new(fp.Element).SetUint64(2), new(fp.Element).SetUint64(3))
+	for _, i := range []uint64{2, 0, 1} {
+		value, err := segment.Read(cellsPerPoseidon + inputCellsPerPoseidon + i)
+		require.NoError(t, err)
+		felt, err := value.FieldElement()
+		require.NoError(t, err)
+		require.Equal(t, expected[i], *felt)
+	}
+	// the permutation ran once for the three output cells
+	require.Len(t, poseidon.cache, cellsPerPoseidon-inputCellsPerPoseidon)
+}
+
+func TestPoseidonHash(t *testing.T) {
+	x, y := new(fp.Element).SetUint64(1), new(fp.Element).SetUint64(2)
+	two := new(fp.Element).SetUint64(2)
+	require.Equal(t, PoseidonPerm(x, y, two)[0], PoseidonHash(x, y))
+}
+
+func TestPoseidonHashMany(t *testing.T) {
+	felts := func(values ...uint64) []*fp.Element {
+		elements := make([]*fp.Element, len(values))
+		for i, value := range values {
+			elements[i] = new(fp.Element).SetUint64(value)
+		}
+		return elements
+	}
+	// the sponge of rate 2, run by hand on the padded values
+	sponge := func(pairs ...[2]uint64) fp.Element {
+		state := make([]fp.Element, 3)
+		for _, pair := range pairs {
+			state[0].Add(&state[0], new(fp.Element).SetUint64(pair[0]))
+			state[1].Add(&state[1], new(fp.Element).SetUint64(pair[1]))
+			state = PoseidonPerm(&state[0], &state[1], &state[2])
+		}
+		return state[0]
+	}
+
+	require.Equal(t, sponge([2]uint64{1, 0}), PoseidonHashMany(nil))
+	require.Equal(t, sponge([2]uint64{5, 1}), PoseidonHashMany(felts(5)))
+	require.Equal(t, sponge([2]uint64{5, 6}, [2]uint64{1, 0}), PoseidonHashMany(felts(5, 6)))
+	require.Equal(t, sponge([2]uint64{5, 6}, [2]uint64{7, 1}), PoseidonHashMany(felts(5, 6, 7)))
+
+	// the values are not modified by the padding
+	values := felts(5, 6, 7)
+	PoseidonHashMany(values[:1])
+	require.Equal(t, new(fp.Element).SetUint64(6), values[1])
+}
@@ -4,7 +4,6 @@ import (
 	"errors"
 	"fmt"
 	"math"
-	"math/big"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
@@ -27,6 +26,11 @@ type RangeCheck struct {
 	ratio            uint64
 	RangeCheckNParts uint64
 	stopPointer      uint64
+	// smallest and largest 16-bit parts of the values checked so far, see
+	// RangeCheckUsage
+	usageMin  uint16
+	usageMax  uint16
+	usageSeen bool
 }
 
 func (r *RangeCheck) CheckWrite(segment *memory.Segment, offset uint64, value *memory.MemoryValue) error {
@@ -53,9 +57,36 @@ func (r *RangeCheck) CheckWrite(segment *memory.Segment, offset uint64, value *m
 		}
 	}
 
+	partsMin, partsMax := r.partsRange(felt)
+	if !r.usageSeen {
+		r.usageMin, r.usageMax, r.usageSeen = partsMin, partsMax, true
+	} else {
+		r.usageMin = min(r.usageMin, partsMin)
+		r.usageMax = max(r.usageMax, partsMax)
+	}
 	return nil
 }
 
+// Returns the smallest and largest of the RangeCheckNParts 16-bit parts of a value
+// which passed the bound check
+func (r *RangeCheck) partsRange(felt *fp.Element) (uint16, uint16) {
+	words := felt.Bits()
+	var partsMin, partsMax uint16 = math.MaxUint16, 0
+	for i := uint64(0); i < r.RangeCheckNParts; i++ {
+		part := uint16((words[i/4] >> ((i % 4) * INNER_RC_BOUND_SHIFT)) & INNER_RC_BOUND_MASK)
+		partsMin = min(partsMin, part)
+		partsMax = max(partsMax, part)
+	}
+	return partsMin, partsMax
+}
+
+// RangeCheckUsage gives the smallest and largest 16-bit parts of the values written
+// to the segment so far, tracked by CheckWrite for the rc_min and rc_max of the AIR
+// public input. It returns false when no value was written
+func (r *RangeCheck) RangeCheckUsage() (uint16, uint16, bool) {
+	return r.usageMin, r.usageMax, r.usageSeen
+}
+
 func (r *RangeCheck) InferValue(segment *memory.Segment, offset uint64) error {
 	return errors.New("cannot infer value")
 }
@@ -72,7 +103,7 @@ func (r *RangeCheck) GetAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint
 	return getBuiltinAllocatedSize(segmentUsedSize, vmCurrentStep, r.ratio, inputCellsPerRangeCheck, instancesPerComponentRangeCheck, cellsPerRangeCheck)
 }
 
-// GetRangeCheckUsage returns the min and max values used in the range check segment. Since each range check instance consists of 16-bit parts, the min and max values are calculated by iterating over the segment data and extracting the 16-bit parts from each field element.
+// GetRangeCheckUsage returns the min and max values used in the range check segment. Since each range check instance consists of 16-bit parts, the min and max values are calculated by iterating over the segment data and extracting the RangeCheckNParts 16-bit parts from each field element.
 func (r *RangeCheck) GetRangeCheckUsage(rangeCheckSegment *memory.Segment) (uint16, uint16) {
 	var minVal, maxVal uint16 = math.MaxUint16, 0
 	for _, value := range rangeCheckSegment.Data {
@@ -80,18 +111,9 @@ func (r *RangeCheck) GetRangeCheckUsage(rangeCheckSegment *memory.Segment) (uint
 		if err != nil {
 			continue
 		}
-		feltDigits := valueFelt.Bits()
-		for _, digit := range feltDigits {
-			for i := 0; i < 4; i++ {
-				part := uint16((digit >> (i * INNER_RC_BOUND_SHIFT)) & INNER_RC_BOUND_MASK)
-				if part < minVal {
-					minVal = part
-				}
-				if part > maxVal {
-					maxVal = part
-				}
-			}
-		}
+		partsMin, partsMax := r.partsRange(valueFelt)
+		minVal = min(minVal, partsMin)
+		maxVal = max(maxVal, partsMax)
 	}
 	return minVal, maxVal
 }
@@ -103,18 +125,20 @@ type AirPrivateBuiltinRangeCheck struct {
 
 func (r *RangeCheck) GetAirPrivateInput(rangeCheckSegment *memory.Segment) []AirPrivateBuiltinRangeCheck {
 	values := make([]AirPrivateBuiltinRangeCheck, 0)
-	for index, value := range rangeCheckSegment.Data {
-		if !value.Known() {
-			continue
-		}
-		valueBig := big.Int{}
-		value.Felt.BigInt(&valueBig)
-		valueHex := fmt.Sprintf("0x%x", &valueBig)
-		values = append(values, AirPrivateBuiltinRangeCheck{Index: index, Value: valueHex})
-	}
+	_ = r.eachAirPrivateInput(rangeCheckSegment, func(value AirPrivateBuiltinRangeCheck) error {
+		values = append(values, value)
+		return nil
+	})
 	return values
 }
 
+// Calls yield with the private input of each instance of the segment, in order
+func (r *RangeCheck) eachAirPrivateInput(rangeCheckSegment *memory.Segment, yield func(AirPrivateBuiltinRangeCheck) error) error {
+	return eachAirPrivateInstance(rangeCheckSegment, cellsPerRangeCheck, inputCellsPerRangeCheck, func(idx int, inputs []string) error {
+		return yield(AirPrivateBuiltinRangeCheck{Index: idx, Value: inputs[0]})
+	})
+}
+
 func (r *RangeCheck) GetCellsPerInstance() uint64 {
 	return cellsPerRangeCheck
 }
@@ -126,3 +150,29 @@ func (r *RangeCheck) GetStopPointer() uint64 {
 func (r *RangeCheck) SetStopPointer(stopPointer uint64) {
 	r.stopPointer = stopPointer
 }
+
+type rangeCheckState struct {
+	usageMin  uint16
+	usageMax  uint16
+	usageSeen bool
+}
+
+func (r *RangeCheck) Snapshot() Snapshot {
+	return Snapshot{builtin: r.String(), stopPointer: r.stopPointer, state: rangeCheckState{
+		usageMin:  r.usageMin,
+		usageMax:  r.usageMax,
+		usageSeen: r.usageSeen,
+	}}
+}
+
+func (r *RangeCheck) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(r, &snapshot); err != nil {
+		return err
+	}
+	r.stopPointer = snapshot.stopPointer
+	state := snapshot.state.(rangeCheckState)
+	r.usageMin = state.usageMin
+	r.usageMax = state.usageMax
+	r.usageSeen = state.usageSeen
+	return nil
+}
@@ -10,13 +10,13 @@ import (
 )
 
 func TestRangeCheckWriteMemoryAddress(t *testing.T) {
-	builtin := RangeCheck{0, 8, 0}
+	builtin := RangeCheck{RangeCheckNParts: 8}
 	memoryAddress := memory.EmptyMemoryValueAsAddress()
 	assert.Error(t, builtin.CheckWrite(nil, 0, &memoryAddress))
 }
 
 func TestRangeCheckWriteOutOfRange(t *testing.T) {
-	builtin := RangeCheck{0, 8, 0}
+	builtin := RangeCheck{RangeCheckNParts: 8}
 	outOfRangeValueFelt, err := new(fp.Element).SetString("0x100000000000000000000000000000001")
 	require.NoError(t, err)
 	outOfRangeValue := memory.MemoryValueFromFieldElement(outOfRangeValueFelt)
@@ -24,7 +24,7 @@ func TestRangeCheckWriteOutOfRange(t *testing.T) {
 }
 
 func TestRangeCheckWrite(t *testing.T) {
-	builtin := RangeCheck{0, 8, 0}
+	builtin := RangeCheck{RangeCheckNParts: 8}
 	f, err := new(fp.Element).SetString("0x44")
 	require.NoError(t, err)
 	v := memory.MemoryValueFromFieldElement(f)
@@ -32,19 +32,19 @@ func TestRangeCheckWrite(t *testing.T) {
 }
 
 func TestRangeCheckInfer(t *testing.T) {
-	builtin := RangeCheck{0, 8, 0}
+	builtin := RangeCheck{RangeCheckNParts: 8}
 	segment := memory.EmptySegmentWithLength(3)
 	assert.ErrorContains(t, builtin.InferValue(segment, 0), "cannot infer value")
 }
 
 func TestRangeCheck96WriteMemoryAddress(t *testing.T) {
-	builtin := RangeCheck{0, 6, 0}
+	builtin := RangeCheck{RangeCheckNParts: 6}
 	memoryAddress := memory.EmptyMemoryValueAsAddress()
 	assert.Error(t, builtin.CheckWrite(nil, 0, &memoryAddress))
 }
 
 func TestRangeCheck96WriteOutOfRange(t *testing.T) {
-	builtin := RangeCheck{0, 6, 0}
+	builtin := RangeCheck{RangeCheckNParts: 6}
 	outOfRangeValueFelt, err := new(fp.Element).SetString("40564819207303340847894502572032")
 	require.NoError(t, err)
 	outOfRangeValue := memory.MemoryValueFromFieldElement(outOfRangeValueFelt)
@@ -52,7 +52,7 @@ func TestRangeCheck96WriteOutOfRange(t *testing.T) {
 }
 
 func TestRangeCheck96Write(t *testing.T) {
-	builtin := RangeCheck{0, 6, 0}
+	builtin := RangeCheck{RangeCheckNParts: 6}
 	f, err := new(fp.Element).SetString("19342813113834066795298816")
 	require.NoError(t, err)
 	v := memory.MemoryValueFromFieldElement(f)
@@ -60,7 +60,47 @@ func TestRangeCheck96Write(t *testing.T) {
 }
 
 func TestRangeCheck96Infer(t *testing.T) {
-	builtin := RangeCheck{0, 6, 0}
+	builtin := RangeCheck{RangeCheckNParts: 6}
 	segment := memory.EmptySegmentWithLength(3)
 	assert.ErrorContains(t, builtin.InferValue(segment, 0), "cannot infer value")
 }
+
+func TestRangeCheckUsage(t *testing.T) {
+	builtin := RangeCheck{RangeCheckNParts: 8}
+	segment := memory.EmptySegmentWithLength(3).WithBuiltinRunner(&builtin)
+	_, _, ok := builtin.RangeCheckUsage()
+	assert.False(t, ok)
+
+	// the parts of 2**112 + 5 are 5, six zeros and 1
+	for offset, value := range []string{"0x10000000000000000000000000005", "0xffffffffffffffffffffffffffffffff"} {
+		f, err := new(fp.Element).SetString(value)
+		require.NoError(t, err)
+		v := memory.MemoryValueFromFieldElement(f)
+		require.NoError(t, segment.Write(uint64(offset), &v))
+	}
+	usageMin, usageMax, ok := builtin.RangeCheckUsage()
+	require.True(t, ok)
+	assert.Equal(t, uint16(0), usageMin)
+	assert.Equal(t, uint16(0xffff), usageMax)
+	usageMin, usageMax = builtin.GetRangeCheckUsage(segment)
+	assert.Equal(t, uint16(0), usageMin)
+	assert.Equal(t, uint16(0xffff), usageMax)
+}
+
+func TestRangeCheck96Usage(t *testing.T) {
+	// only the 6 lower parts are used by a range check 96
+	builtin := RangeCheck{RangeCheckNParts: 6}
+	segment := memory.EmptySegmentWithLength(1).WithBuiltinRunner(&builtin)
+	f, err := new(fp.Element).SetString("0x000300040005000600070008")
+	require.NoError(t, err)
+	v := memory.MemoryValueFromFieldElement(f)
+	require.NoError(t, segment.Write(0, &v))
+
+	usageMin, usageMax, ok := builtin.RangeCheckUsage()
+	require.True(t, ok)
+	assert.Equal(t, uint16(3), usageMin)
+	assert.Equal(t, uint16(8), usageMax)
+	usageMin, usageMax = builtin.GetRangeCheckUsage(segment)
+	assert.Equal(t, uint16(3), usageMin)
+	assert.Equal(t, uint16(8), usageMax)
+}
@@ -0,0 +1,69 @@
+package builtins
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// CustomBuiltin is a builtin implemented outside the VM, e.g. an app-specific
+// builtin being experimented with. Its runner defines the cells per instance and
+// the CheckWrite and InferValue rules of the builtin
+type CustomBuiltin struct {
+	// name of the builtin in the programs and the layouts, which the runners must
+	// also return from String
+	Name string
+	// creates a runner with the ratio given by the layout, zero outside of one
+	NewRunner func(ratio uint64) memory.BuiltinRunner
+	// operations implemented by the runners, which the modes of the layouts are
+	// checked against
+	Operations BuiltinOperations
+}
+
+// CustomRunner is implemented by the runners of custom builtins which support
+// additional segments, see NewSegmentRunner, and being copied along the memory,
+// see CloneRunner
+type CustomRunner interface {
+	memory.BuiltinRunner
+	// returns a runner with the same configuration but a fresh state
+	NewSegmentRunner() memory.BuiltinRunner
+	// returns a deep copy of the runner
+	Clone() memory.BuiltinRunner
+}
+
+var (
+	customBuiltins      = make(map[BuiltinType]*CustomBuiltin)
+	customBuiltinByName = make(map[string]BuiltinType)
+)
+
+// RegisterBuiltin makes a custom builtin known to the VM under a new BuiltinType,
+// so that programs can declare it and layouts, including layout files, can
+// include it by name. Like RegisterHintProvider, it is meant to be called before
+// any program is loaded
+func RegisterBuiltin(builtin CustomBuiltin) (BuiltinType, error) {
+	if builtin.Name == "" {
+		return 0, errors.New("cannot register a builtin without a name")
+	}
+	if builtin.NewRunner == nil {
+		return 0, fmt.Errorf("cannot register builtin %s: NewRunner is not set", builtin.Name)
+	}
+	var existing BuiltinType
+	if existing.UnmarshalJSON([]byte(strconv.Quote(builtin.Name))) == nil {
+		return 0, fmt.Errorf("cannot register builtin %s: the name is already taken", builtin.Name)
+	}
+	if len(customBuiltins) >= math.MaxUint8-int(SystemBuiltinType) {
+		return 0, fmt.Errorf("cannot register builtin %s: too many builtins", builtin.Name)
+	}
+	builtinType := SystemBuiltinType + 1 + BuiltinType(len(customBuiltins))
+	customBuiltins[builtinType] = &builtin
+	customBuiltinByName[builtin.Name] = builtinType
+	return builtinType, nil
+}
+
+func customBuiltin(builtin BuiltinType) (*CustomBuiltin, bool) {
+	custom, ok := customBuiltins[builtin]
+	return custom, ok
+}
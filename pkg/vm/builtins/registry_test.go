@@ -0,0 +1,121 @@
+package builtins
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+// Custom builtin whose instances are made of a value and its double
+type doubleBuiltin struct {
+	ratio       uint64
+	stopPointer uint64
+}
+
+func (d *doubleBuiltin) String() string {
+	return "double"
+}
+
+func (d *doubleBuiltin) CheckWrite(segment *memory.Segment, offset uint64, value *memory.MemoryValue) error {
+	return nil
+}
+
+func (d *doubleBuiltin) InferValue(segment *memory.Segment, offset uint64) error {
+	if offset%2 == 0 {
+		return nil
+	}
+	input := segment.Peek(offset - 1)
+	felt, err := input.FieldElement()
+	if err != nil {
+		return err
+	}
+	double := memory.MemoryValueFromFieldElement(new(fp.Element).Double(felt))
+	return segment.Write(offset, &double)
+}
+
+func (d *doubleBuiltin) GetAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint64) (uint64, error) {
+	return getBuiltinAllocatedSize(segmentUsedSize, vmCurrentStep, d.ratio, 1, 1, 2)
+}
+
+func (d *doubleBuiltin) GetCellsPerInstance() uint64 {
+	return 2
+}
+
+func (d *doubleBuiltin) GetStopPointer() uint64 {
+	return d.stopPointer
+}
+
+func (d *doubleBuiltin) SetStopPointer(stopPointer uint64) {
+	d.stopPointer = stopPointer
+}
+
+func (d *doubleBuiltin) NewSegmentRunner() memory.BuiltinRunner {
+	return &doubleBuiltin{ratio: d.ratio}
+}
+
+func (d *doubleBuiltin) Clone() memory.BuiltinRunner {
+	clone := *d
+	return &clone
+}
+
+func TestRegisterBuiltin(t *testing.T) {
+	doubleType, err := RegisterBuiltin(CustomBuiltin{
+		Name: "double",
+		NewRunner: func(ratio uint64) memory.BuiltinRunner {
+			return &doubleBuiltin{ratio: ratio}
+		},
+		Operations: BuiltinOperations{Deduce: true},
+	})
+	require.NoError(t, err)
+	require.Greater(t, doubleType, SystemBuiltinType)
+
+	_, err = RegisterBuiltin(CustomBuiltin{Name: "double", NewRunner: func(uint64) memory.BuiltinRunner { return nil }})
+	require.EqualError(t, err, "cannot register builtin double: the name is already taken")
+	_, err = RegisterBuiltin(CustomBuiltin{Name: PedersenName, NewRunner: func(uint64) memory.BuiltinRunner { return nil }})
+	require.EqualError(t, err, "cannot register builtin pedersen: the name is already taken")
+	_, err = RegisterBuiltin(CustomBuiltin{Name: "triple"})
+	require.EqualError(t, err, "cannot register builtin triple: NewRunner is not set")
+
+	// programs and layouts name the builtin
+	var builtins []BuiltinType
+	require.NoError(t, json.Unmarshal([]byte(`["output", "double"]`), &builtins))
+	require.Equal(t, []BuiltinType{OutputType, doubleType}, builtins)
+	require.Equal(t, doubleType, BuiltinTypeFromName("double"))
+	require.Equal(t, BuiltinOperations{Deduce: true}, Operations(doubleType))
+
+	definition, err := parseLayoutDefinition("test", []byte(`{
+		"name": "doubling",
+		"rc_units": 4,
+		"builtins": [{"builtin": "output"}, {"builtin": "double", "ratio": 16, "mode": "deduce"}]
+	}`))
+	require.NoError(t, err)
+	layout, err := definition.Build()
+	require.NoError(t, err)
+	require.Equal(t, doubleType, layout.Builtins[1].Builtin)
+	require.Equal(t, &doubleBuiltin{ratio: 16}, layout.Builtins[1].Runner)
+
+	require.False(t, layout.Builtins[1].Unchecked())
+
+	// turning off its deduction leaves the builtin unchecked
+	definition.Builtins[1].Mode = memory.ValidateOnly
+	unchecked, err := definition.Build()
+	require.NoError(t, err)
+	require.True(t, unchecked.Builtins[1].Unchecked())
+
+	// the builtin deduces its cells like the ones of the VM
+	mem := memory.InitializeEmptyMemory()
+	addr := mem.AllocateBuiltinSegment(layout.Builtins[1].Runner)
+	input := memory.MemoryValueFromUint[uint64](21)
+	require.NoError(t, mem.Write(addr.SegmentIndex, 0, &input))
+	double, err := mem.Read(addr.SegmentIndex, 1)
+	require.NoError(t, err)
+	require.Equal(t, memory.MemoryValueFromUint[uint64](42), double)
+
+	additional, err := AllocateAdditionalSegment(mem, "double")
+	require.NoError(t, err)
+	require.NotSame(t, mem.Segments[addr.SegmentIndex].BuiltinRunner, mem.Segments[additional.SegmentIndex].BuiltinRunner)
+	require.Equal(t, layout.Builtins[1].Runner, CloneRunner(layout.Builtins[1].Runner))
+}
@@ -0,0 +1,131 @@
+package builtins
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Returns the number of cells of an instance that are written by the program, the
+// other cells of the instance being deduced by the builtin. Builtins without
+// instances, the output and the custom ones, have none
+func inputCellsPerInstance(runner memory.BuiltinRunner) (uint64, bool) {
+	switch runner.(type) {
+	case *RangeCheck:
+		return inputCellsPerRangeCheck, true
+	case *Pedersen:
+		return inputCellsPerPedersen, true
+	case *ECDSA:
+		return inputCellsPerECDSA, true
+	case *Keccak:
+		return inputCellsPerKeccak, true
+	case *Bitwise:
+		return inputCellsPerBitwise, true
+	case *EcOp:
+		return inputCellsPerEcOp, true
+	case *Poseidon:
+		return inputCellsPerPoseidon, true
+	case *ModBuiltin:
+		return CELLS_PER_MOD, true
+	default:
+		return 0, false
+	}
+}
+
+// Implemented by the builtins checking the deduced cells of all their instances in a
+// single pass, faster than deducing each cell again in a scratch segment
+type instancesVerifier interface {
+	VerifyInstances(segment *memory.Segment) error
+}
+
+var _ instancesVerifier = (*Bitwise)(nil)
+
+// Implemented by the builtins recording the instances whose output cells they
+// deduced themselves, which match their inputs without being deduced again
+type verifiedInstancesRunner interface {
+	instanceVerified(instance uint64) bool
+}
+
+var _ verifiedInstancesRunner = (*Pedersen)(nil)
+
+// CheckSegment runs the security checks of a builtin segment once the run is over,
+// the same as the Python VM secure run. The cells used by the builtin must not go
+// past its stop pointer, every instance must have all its input cells and the output
+// cells written by the program must match the values the builtin deduces from the
+// inputs. A program failing them produces a trace that the prover rejects
+func CheckSegment(segment *memory.Segment) error {
+	runner := segment.BuiltinRunner
+	inputCells, ok := inputCellsPerInstance(runner)
+	if !ok {
+		return nil
+	}
+	cellsPerInstance := runner.GetCellsPerInstance()
+	// the segments of a proof mode run are finalized to their allocated size
+	used := segment.WrittenLen()
+
+	// the stop pointer is only set when the program returns its builtin pointers
+	if stopPointer := runner.GetStopPointer(); stopPointer != 0 {
+		if stopPointer%cellsPerInstance != 0 {
+			return fmt.Errorf("stop pointer %d is not a multiple of the %d cells of an instance", stopPointer, cellsPerInstance)
+		}
+		if used > stopPointer {
+			return fmt.Errorf("out of bounds access: %d cells used past the stop pointer %d", used-stopPointer, stopPointer)
+		}
+	}
+
+	instances := (used + cellsPerInstance - 1) / cellsPerInstance
+	var missing []uint64
+	for instance := uint64(0); instance < instances; instance++ {
+		for cell := uint64(0); cell < inputCells; cell++ {
+			offset := instance*cellsPerInstance + cell
+			if mv := segment.Peek(offset); !mv.Known() {
+				missing = append(missing, offset)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("missing input cells at offsets %v", missing)
+	}
+
+	if inputCells == cellsPerInstance || !Operations(BuiltinTypeFromName(runner.String())).Deduce {
+		return nil
+	}
+	if verifier, ok := runner.(instancesVerifier); ok {
+		return verifier.VerifyInstances(segment)
+	}
+	verified, _ := runner.(verifiedInstancesRunner)
+	for instance := uint64(0); instance < instances; instance++ {
+		if verified != nil && verified.instanceVerified(instance) {
+			continue
+		}
+		if err := checkDeducedCells(segment, instance, inputCells, cellsPerInstance); err != nil {
+			return fmt.Errorf("instance %d: %w", instance, err)
+		}
+	}
+	return nil
+}
+
+// Deduces the output cells of an instance again, in a segment of its own so that the
+// deduction doesn't reuse the values of the checked segment
+func checkDeducedCells(segment *memory.Segment, instance, inputCells, cellsPerInstance uint64) error {
+	base := instance * cellsPerInstance
+	var scratch *memory.Segment
+	for cell := inputCells; cell < cellsPerInstance; cell++ {
+		written := segment.Peek(base + cell)
+		if !written.Known() {
+			continue
+		}
+		if scratch == nil {
+			scratch = memory.EmptySegmentWithLength(int(cellsPerInstance)).WithBuiltinRunner(NewSegmentRunner(segment.BuiltinRunner))
+			copy(scratch.Data[:inputCells], segment.Data[base:base+inputCells])
+		}
+		deduced, err := scratch.Read(cell)
+		if err != nil {
+			return fmt.Errorf("cell %d cannot be deduced: %w", cell, err)
+		}
+		if !deduced.Equal(&written) {
+			return fmt.Errorf("cell %d is %s but the builtin deduces %s", cell, &written, &deduced)
+		}
+	}
+	return nil
+}
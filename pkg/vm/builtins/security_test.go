@@ -0,0 +1,60 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckSegment(t *testing.T) {
+	x := fp.NewElement(5)
+	y := fp.NewElement(7)
+	xValue := memory.MemoryValueFromFieldElement(&x)
+	yValue := memory.MemoryValueFromFieldElement(&y)
+
+	segment := memory.EmptySegment().WithBuiltinRunner(&Pedersen{})
+	require.NoError(t, segment.Write(0, &xValue))
+	require.NoError(t, segment.Write(1, &yValue))
+	_, err := segment.Read(2)
+	require.NoError(t, err)
+	require.NoError(t, CheckSegment(segment))
+
+	// the hashes deduced by the builtin are not deduced again
+	pedersen := segment.BuiltinRunner.(*Pedersen)
+	require.True(t, pedersen.instanceVerified(0))
+	snapshot := pedersen.Snapshot()
+	require.NoError(t, pedersen.Restore((&Pedersen{}).Snapshot()))
+	require.False(t, pedersen.instanceVerified(0))
+	require.NoError(t, pedersen.Restore(snapshot))
+	require.True(t, pedersen.instanceVerified(0))
+
+	// an instance started without all its inputs
+	require.NoError(t, segment.Write(4, &yValue))
+	require.EqualError(t, CheckSegment(segment), "missing input cells at offsets [3]")
+	require.NoError(t, segment.Write(3, &xValue))
+	require.NoError(t, CheckSegment(segment))
+	// the instances allocated past the used ones by the proof mode are not checked
+	segment.Finalize(12, nil)
+	require.NoError(t, CheckSegment(segment))
+
+	// an output written by the program rather than deduced
+	segment.BuiltinMode = memory.ValidateOnly
+	require.NoError(t, segment.Write(5, &yValue))
+	require.ErrorContains(t, CheckSegment(segment), "instance 1: cell 2 is 7 but the builtin deduces")
+
+	segment = memory.EmptySegment().WithBuiltinRunner(&Bitwise{})
+	require.NoError(t, segment.WriteRange(0, []memory.MemoryValue{xValue, yValue}))
+	segment.BuiltinRunner.SetStopPointer(3)
+	require.EqualError(t, CheckSegment(segment), "stop pointer 3 is not a multiple of the 5 cells of an instance")
+	segment.BuiltinRunner.SetStopPointer(5)
+	require.NoError(t, CheckSegment(segment))
+	require.NoError(t, segment.Write(5, &xValue))
+	require.EqualError(t, CheckSegment(segment), "out of bounds access: 1 cells used past the stop pointer 5")
+
+	// builtins without instances are not checked
+	segment = memory.EmptySegment().WithBuiltinRunner(&Output{})
+	require.NoError(t, segment.Write(3, &xValue))
+	require.NoError(t, CheckSegment(segment))
+}
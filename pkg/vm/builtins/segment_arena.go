@@ -1,5 +1,135 @@
 package builtins
 
+import (
+	"errors"
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
 const (
 	SegmentArenaName string = "segment_arena"
+	// An instance of the segment arena is its state after a dictionary allocation or
+	// squash: the pointer to the info segment, the number of allocated dictionaries
+	// and the number of squashed ones
+	cellsPerSegmentArena = 3
+	// The info segment holds a (start, end, squashed index) triple per dictionary
+	cellsPerSegmentInfo = 3
 )
+
+// SegmentArena is the builtin Cairo 1 programs use to allocate the segments of their
+// Felt252Dict. The program writes all its cells, the runner only checks their types
+// and, at the end of the run, that every allocated dictionary was squashed
+type SegmentArena struct {
+	stopPointer uint64
+}
+
+func (s *SegmentArena) CheckWrite(segment *memory.Segment, offset uint64, value *memory.MemoryValue) error {
+	if offset%cellsPerSegmentArena == 0 {
+		if !value.IsAddress() {
+			return fmt.Errorf("expected the info segment pointer but got a felt: %s", value)
+		}
+		return nil
+	}
+	if !value.IsFelt() {
+		return fmt.Errorf("expected a felt but got an address: %s", value)
+	}
+	return nil
+}
+
+func (s *SegmentArena) InferValue(segment *memory.Segment, offset uint64) error {
+	return errors.New("cannot infer value")
+}
+
+func (s *SegmentArena) String() string {
+	return SegmentArenaName
+}
+
+func (s *SegmentArena) GetAllocatedSize(segmentUsedSize uint64, vmCurrentStep uint64) (uint64, error) {
+	return segmentUsedSize, nil
+}
+
+func (s *SegmentArena) GetCellsPerInstance() uint64 {
+	return cellsPerSegmentArena
+}
+
+func (s *SegmentArena) GetStopPointer() uint64 {
+	return s.stopPointer
+}
+
+func (s *SegmentArena) SetStopPointer(stopPointer uint64) {
+	s.stopPointer = stopPointer
+}
+
+// the builtin has no state besides its stop pointer
+func (s *SegmentArena) Snapshot() Snapshot {
+	return Snapshot{builtin: SegmentArenaName, stopPointer: s.stopPointer}
+}
+
+func (s *SegmentArena) Restore(snapshot Snapshot) error {
+	if err := checkSnapshot(s, &snapshot); err != nil {
+		return err
+	}
+	s.stopPointer = snapshot.stopPointer
+	return nil
+}
+
+// CheckSquashed checks the final state of the segment arena, its last instance: all
+// the allocated dictionaries must have been squashed, each with a (start, end,
+// squashed index) triple in the info segment and a distinct squashed index
+func (s *SegmentArena) CheckSquashed(mem *memory.Memory, segment *memory.Segment) error {
+	used := segment.Len()
+	if used == 0 {
+		return nil
+	}
+	if used%cellsPerSegmentArena != 0 {
+		return fmt.Errorf("%d cells used, which is not a multiple of the %d cells of an instance", used, cellsPerSegmentArena)
+	}
+
+	last := used - cellsPerSegmentArena
+	infoMv := segment.Peek(last)
+	infoPtr, err := infoMv.MemoryAddress()
+	if err != nil {
+		return fmt.Errorf("info segment pointer: %w", err)
+	}
+	dictsMv := segment.Peek(last + 1)
+	dicts, err := dictsMv.Uint64()
+	if err != nil {
+		return fmt.Errorf("number of dictionaries: %w", err)
+	}
+	squashedMv := segment.Peek(last + 2)
+	squashed, err := squashedMv.Uint64()
+	if err != nil {
+		return fmt.Errorf("number of squashed dictionaries: %w", err)
+	}
+	if squashed != dicts {
+		return fmt.Errorf("%d dictionaries allocated but %d squashed", dicts, squashed)
+	}
+
+	squashedIndexes := make(map[uint64]struct{}, dicts)
+	for dict := uint64(0); dict < dicts; dict++ {
+		base := infoPtr.Offset + dict*cellsPerSegmentInfo
+		var info [cellsPerSegmentInfo]memory.MemoryValue
+		for i := range info {
+			info[i], err = mem.Peek(infoPtr.SegmentIndex, base+uint64(i))
+			if err != nil {
+				return fmt.Errorf("dictionary %d: %w", dict, err)
+			}
+			if !info[i].Known() {
+				return fmt.Errorf("dictionary %d: info cell %d is not written", dict, i)
+			}
+		}
+		if !info[0].IsAddress() || !info[1].IsAddress() {
+			return fmt.Errorf("dictionary %d: expected the start and end of its segment but got %s and %s", dict, &info[0], &info[1])
+		}
+		index, err := info[2].Uint64()
+		if err != nil {
+			return fmt.Errorf("dictionary %d: squashed index: %w", dict, err)
+		}
+		if _, ok := squashedIndexes[index]; ok || index >= dicts {
+			return fmt.Errorf("dictionary %d: invalid squashed index %d", dict, index)
+		}
+		squashedIndexes[index] = struct{}{}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package builtins
+
+import (
+	"testing"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSegmentArenaCheckWrite(t *testing.T) {
+	segment := memory.EmptySegmentWithLength(6).WithBuiltinRunner(&SegmentArena{})
+
+	felt := memory.MemoryValueFromInt(0)
+	infoPtr := memory.MemoryValueFromSegmentAndOffset(1, 0)
+	require.NoError(t, segment.Write(0, &infoPtr))
+	require.NoError(t, segment.Write(1, &felt))
+	require.ErrorContains(t, segment.Write(3, &felt), "expected the info segment pointer but got a felt")
+	require.ErrorContains(t, segment.Write(4, &infoPtr), "expected a felt but got an address")
+}
+
+// Builds a memory with the segment arena at segment 0 and the info segment at
+// segment 1, the arena instances and the info triples taken as given
+func segmentArenaMemory(t *testing.T, instances [][2]uint64, info [][]memory.MemoryValue) (*memory.Memory, *memory.Segment) {
+	t.Helper()
+	mem := memory.InitializeEmptyMemory()
+	mem.AllocateBuiltinSegment(&SegmentArena{})
+	infoSegment := mem.AllocateEmptySegment()
+
+	for i, instance := range instances {
+		values := []memory.MemoryValue{
+			memory.MemoryValueFromMemoryAddress(&infoSegment),
+			memory.MemoryValueFromUint(instance[0]),
+			memory.MemoryValueFromUint(instance[1]),
+		}
+		for cell := range values {
+			require.NoError(t, mem.Write(0, uint64(i*cellsPerSegmentArena+cell), &values[cell]))
+		}
+	}
+	for dict, triple := range info {
+		for cell := range triple {
+			require.NoError(t, mem.Write(1, uint64(dict*cellsPerSegmentInfo+cell), &triple[cell]))
+		}
+	}
+	return mem, mem.Segments[0]
+}
+
+func dictInfo(segment, start, end int, squashedIndex uint64) []memory.MemoryValue {
+	return []memory.MemoryValue{
+		memory.MemoryValueFromSegmentAndOffset(segment, start),
+		memory.MemoryValueFromSegmentAndOffset(segment, end),
+		memory.MemoryValueFromUint(squashedIndex),
+	}
+}
+
+func TestSegmentArenaCheckSquashed(t *testing.T) {
+	arena := &SegmentArena{}
+
+	mem, segment := segmentArenaMemory(t, nil, nil)
+	require.NoError(t, arena.CheckSquashed(mem, segment))
+
+	mem, segment = segmentArenaMemory(t,
+		[][2]uint64{{0, 0}, {1, 0}, {2, 0}, {2, 1}, {2, 2}},
+		[][]memory.MemoryValue{dictInfo(2, 0, 4, 1), dictInfo(3, 0, 2, 0)},
+	)
+	require.NoError(t, arena.CheckSquashed(mem, segment))
+}
+
+func TestSegmentArenaCheckSquashedErrors(t *testing.T) {
+	arena := &SegmentArena{}
+
+	mem, segment := segmentArenaMemory(t,
+		[][2]uint64{{0, 0}, {1, 0}, {2, 0}, {2, 1}},
+		[][]memory.MemoryValue{dictInfo(2, 0, 4, 0), dictInfo(3, 0, 0, 0)[:1]},
+	)
+	require.EqualError(t, arena.CheckSquashed(mem, segment), "2 dictionaries allocated but 1 squashed")
+
+	mem, segment = segmentArenaMemory(t,
+		[][2]uint64{{0, 0}, {1, 0}, {1, 1}},
+		[][]memory.MemoryValue{dictInfo(2, 0, 4, 0)[:2]},
+	)
+	require.EqualError(t, arena.CheckSquashed(mem, segment), "dictionary 0: info cell 2 is not written")
+
+	mem, segment = segmentArenaMemory(t,
+		[][2]uint64{{0, 0}, {2, 0}, {2, 2}},
+		[][]memory.MemoryValue{dictInfo(2, 0, 4, 0), dictInfo(3, 0, 2, 0)},
+	)
+	require.EqualError(t, arena.CheckSquashed(mem, segment), "dictionary 1: invalid squashed index 0")
+
+	mem, segment = segmentArenaMemory(t, [][2]uint64{{0, 0}}, nil)
+	extra := memory.MemoryValueFromSegmentAndOffset(1, 0)
+	require.NoError(t, mem.Write(0, 3, &extra))
+	require.EqualError(t, arena.CheckSquashed(mem, segment), "4 cells used, which is not a multiple of the 3 cells of an instance")
+}
+
+func TestSegmentArenaAdditionalSegment(t *testing.T) {
+	mem := memory.InitializeEmptyMemory()
+	first := mem.AllocateBuiltinSegment(&SegmentArena{})
+	mem.Segments[first.SegmentIndex].BuiltinRunner.SetStopPointer(3)
+
+	additional, err := AllocateAdditionalSegment(mem, SegmentArenaName)
+	require.NoError(t, err)
+	runner := mem.Segments[additional.SegmentIndex].BuiltinRunner
+	require.Equal(t, &SegmentArena{}, runner)
+	require.NotSame(t, mem.Segments[first.SegmentIndex].BuiltinRunner, runner)
+}
@@ -0,0 +1,33 @@
+package builtins
+
+import "slices"
+
+// verifiedInstances is a bitmap of the instances of a builtin segment that passed the
+// checks of their runner. The cells of an instance cannot change once written, so a
+// valid instance stays valid and its expensive checks, such as the ECDSA signature
+// verification, are not repeated when its cells are rewritten, e.g. by the relocation
+// of the temporary segments or by hints
+type verifiedInstances []uint64
+
+func (v verifiedInstances) has(instance uint64) bool {
+	word := instance / 64
+	return word < uint64(len(v)) && v[word]&(1<<(instance%64)) != 0
+}
+
+func (v *verifiedInstances) add(instance uint64) {
+	word := instance / 64
+	if word >= uint64(len(*v)) {
+		*v = append(*v, make([]uint64, word+1-uint64(len(*v)))...)
+	}
+	(*v)[word] |= 1 << (instance % 64)
+}
+
+func (v verifiedInstances) remove(instance uint64) {
+	if word := instance / 64; word < uint64(len(v)) {
+		v[word] &^= 1 << (instance % 64)
+	}
+}
+
+func (v verifiedInstances) clone() verifiedInstances {
+	return slices.Clone(v)
+}
@@ -0,0 +1,25 @@
+package vm
+
+// CallSubtrees returns, for each call of the function starting at pc, the steps of
+// the trace from its first step to its ret, nested calls included. A step belongs
+// to the call as long as its fp is not below the fp of the call, since callees
+// push their frames above it. Recursive calls are part of the subtree of the
+// outermost call and are not returned on their own. pc has to be given the same
+// way as in the trace, i.e. offset by one for a relocated trace
+func CallSubtrees(trace []Trace, pc uint64) [][]Trace {
+	var calls [][]Trace
+	for i := 0; i < len(trace); i++ {
+		// a jump back to the first instruction of the function keeps its fp
+		if trace[i].Pc != pc || (i > 0 && trace[i].Fp == trace[i-1].Fp) {
+			continue
+		}
+		fp := trace[i].Fp
+		end := i + 1
+		for end < len(trace) && trace[end].Fp >= fp {
+			end++
+		}
+		calls = append(calls, trace[i:end])
+		i = end - 1
+	}
+	return calls
+}
@@ -0,0 +1,84 @@
+package vm
+
+import (
+	"fmt"
+
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Invariant is a predicate on the state of the VM checked during the run. It lets
+// tests assert properties of a whole execution instead of its final state only
+type Invariant struct {
+	// Identifies the invariant in the error reporting its violation
+	Name string
+	// Program counters at which the invariant is checked, once the step reaching
+	// them is executed. When empty, the invariant is checked after every step
+	Pcs []mem.MemoryAddress
+	// Returns an error describing why the invariant doesn't hold
+	Check func(vm *VirtualMachine) error
+}
+
+// InvariantViolation is returned by RunStep when an invariant doesn't hold after
+// the step
+type InvariantViolation struct {
+	Invariant string
+	// Step count and registers when the invariant was checked
+	Step    uint64
+	Context Context
+	Err     error
+}
+
+func (e *InvariantViolation) Error() string {
+	return fmt.Sprintf("invariant %s violated with ap %d and fp %d: %v", e.Invariant, e.Context.Ap, e.Context.Fp, e.Err)
+}
+
+func (e *InvariantViolation) Unwrap() error {
+	return e.Err
+}
+
+func (vm *VirtualMachine) checkInvariants() error {
+	for i := range vm.config.Invariants {
+		invariant := &vm.config.Invariants[i]
+		if len(invariant.Pcs) > 0 && !containsPc(invariant.Pcs, &vm.Context.Pc) {
+			continue
+		}
+		if err := invariant.Check(vm); err != nil {
+			return &InvariantViolation{Invariant: invariant.Name, Step: vm.Step, Context: vm.Context, Err: err}
+		}
+	}
+	return nil
+}
+
+func containsPc(pcs []mem.MemoryAddress, pc *mem.MemoryAddress) bool {
+	for i := range pcs {
+		if pcs[i].Equal(pc) {
+			return true
+		}
+	}
+	return false
+}
+
+// ApAtMost holds while ap doesn't exceed max
+func ApAtMost(max uint64) func(vm *VirtualMachine) error {
+	return func(vm *VirtualMachine) error {
+		if vm.Context.Ap > max {
+			return fmt.Errorf("ap is %d, above %d", vm.Context.Ap, max)
+		}
+		return nil
+	}
+}
+
+// CellEquals holds while the cell at address holds value. An unknown cell doesn't
+// hold any value
+func CellEquals(address mem.MemoryAddress, value mem.MemoryValue) func(vm *VirtualMachine) error {
+	return func(vm *VirtualMachine) error {
+		cell, err := vm.Memory.PeekFromAddress(&address)
+		if err != nil {
+			return err
+		}
+		if !cell.Equal(&value) {
+			return fmt.Errorf("%s holds %s, expected %s", address, cell, value)
+		}
+		return nil
+	}
+}
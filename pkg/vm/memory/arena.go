@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"math/bits"
+	"sync"
+	"unsafe"
+)
+
+// Segment data is taken from pools bucketed by capacity, so that services running
+// many programs reuse the memory of previous runs once it has been released instead
+// of leaving it to the garbage collector. The pool with index i holds slices with a
+// capacity in [2^(i+min), 2^(i+min+1))
+const (
+	minPooledCapacityLog = 6
+	maxPooledCapacityLog = 26
+)
+
+var segmentDataPools [maxPooledCapacityLog - minPooledCapacityLog + 1]sync.Pool
+
+// Returns a slice of unknown values with the given length and capacity
+func allocateSegmentData(length, capacity int) []MemoryValue {
+	capacity = max(length, capacity)
+	// smallest bucket whose slices are all large enough
+	log := max(bits.Len(uint(capacity-1)), minPooledCapacityLog)
+	if capacity > 0 && log <= maxPooledCapacityLog {
+		if data, ok := segmentDataPools[log-minPooledCapacityLog].Get().(*[]MemoryValue); ok {
+			return (*data)[:length:capacity]
+		}
+	}
+	return make([]MemoryValue, length, capacity)
+}
+
+// Gives back segment data to the pools. The data must not be used afterwards
+func releaseSegmentData(data []MemoryValue) {
+	log := bits.Len(uint(cap(data))) - 1
+	if log < minPooledCapacityLog || log > maxPooledCapacityLog {
+		return
+	}
+	capacity := cap(data)
+	data = data[:capacity]
+	clear(data)
+	segmentDataPools[log-minPooledCapacityLog].Put(&data)
+}
+
+// Releases the data of every segment so it can be reused by future allocations.
+// It is meant to be called once all the artifacts of a run have been emitted: the
+// memory, as well as the field elements returned when relocating it, must not be
+// used afterwards. Segments still shared with a fork, or frozen and possibly still
+// read in the background, are left to the garbage collector
+func (memory *Memory) Release() {
+	for _, segments := range [][]*Segment{memory.Segments, memory.TemporarySegments} {
+		for _, segment := range segments {
+			if !segment.shared && !segment.frozen {
+				releaseSegmentData(segment.Data)
+			}
+			segment.Data = nil
+			segment.LastIndex = -1
+		}
+	}
+	memory.Segments = nil
+	memory.TemporarySegments = nil
+}
+
+type SegmentUsage struct {
+	// segment index, negative for temporary segments
+	Index int
+	// name of the builtin using the segment
+	Builtin string
+	// number of cells up to the last written one
+	UsedCells uint64
+	// number of cells the segment data has room for
+	AllocatedCells uint64
+	// size in bytes of the allocated cells
+	Bytes uint64
+}
+
+type Usage struct {
+	Segments       []SegmentUsage
+	UsedCells      uint64
+	AllocatedCells uint64
+	Bytes          uint64
+}
+
+// Reports how much memory each segment uses and allocates
+func (memory *Memory) Usage() Usage {
+	cellSize := uint64(unsafe.Sizeof(MemoryValue{}))
+	var usage Usage
+	add := func(index int, segment *Segment) {
+		segmentUsage := SegmentUsage{
+			Index:          index,
+			Builtin:        segment.BuiltinRunner.String(),
+			UsedCells:      segment.Len(),
+			AllocatedCells: uint64(cap(segment.Data)),
+			Bytes:          uint64(cap(segment.Data)) * cellSize,
+		}
+		usage.Segments = append(usage.Segments, segmentUsage)
+		usage.UsedCells += segmentUsage.UsedCells
+		usage.AllocatedCells += segmentUsage.AllocatedCells
+		usage.Bytes += segmentUsage.Bytes
+	}
+	for i, segment := range memory.Segments {
+		add(i, segment)
+	}
+	// the first temporary segment is a placeholder for indexing
+	for i := 1; i < len(memory.TemporarySegments); i++ {
+		add(-i, memory.TemporarySegments[i])
+	}
+	return usage
+}
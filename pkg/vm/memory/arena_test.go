@@ -0,0 +1,49 @@
+package memory
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryUsage(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+	memory.AllocateEmptyTemporarySegment()
+
+	value := MemoryValueFromInt(1)
+	require.NoError(t, memory.Write(0, 9, &value))
+	require.NoError(t, memory.Write(-1, 0, &value))
+
+	usage := memory.Usage()
+	cellSize := uint64(unsafe.Sizeof(MemoryValue{}))
+	require.Equal(t, []SegmentUsage{
+		{Index: 0, Builtin: "no builtin", UsedCells: 10, AllocatedCells: 100, Bytes: 100 * cellSize},
+		{Index: -1, Builtin: "no builtin", UsedCells: 1, AllocatedCells: 100, Bytes: 100 * cellSize},
+	}, usage.Segments)
+	require.Equal(t, uint64(11), usage.UsedCells)
+	require.Equal(t, uint64(200), usage.AllocatedCells)
+	require.Equal(t, 200*cellSize, usage.Bytes)
+}
+
+func TestReleasedSegmentDataIsCleared(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+	value := MemoryValueFromInt(1)
+	for i := uint64(0); i < 300; i++ {
+		require.NoError(t, memory.Write(0, i, &value))
+	}
+	memory.Release()
+	require.Empty(t, memory.Segments)
+
+	// whether or not the released data is reused, new segments start unknown
+	for i := 0; i < 4; i++ {
+		data := allocateSegmentData(200, 200)
+		require.Len(t, data, 200)
+		require.Equal(t, 200, cap(data))
+		for j := range data {
+			require.Equal(t, UnknownValue, data[j])
+		}
+	}
+}
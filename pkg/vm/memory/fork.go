@@ -0,0 +1,49 @@
+package memory
+
+import "maps"
+
+// Makes the segment own its data, copying it if it is still shared with a fork.
+// It must be called before mutating the segment data in place
+func (segment *Segment) own() {
+	if !segment.shared {
+		return
+	}
+	data := make([]MemoryValue, len(segment.Data), cap(segment.Data))
+	copy(data, segment.Data)
+	segment.Data = data
+	segment.shared = false
+}
+
+func (segment *Segment) fork(cloneRunner func(BuiltinRunner) BuiltinRunner) *Segment {
+	segment.shared = true
+	return &Segment{
+		Data:                segment.Data,
+		LastIndex:           segment.LastIndex,
+		BuiltinRunner:       cloneRunner(segment.BuiltinRunner),
+		BuiltinMode:         segment.BuiltinMode,
+		Failure:             segment.Failure,
+		PublicMemoryOffsets: append([]PublicMemoryOffset(nil), segment.PublicMemoryOffsets...),
+		shared:              true,
+		frozen:              segment.frozen,
+	}
+}
+
+// Returns a copy of the memory sharing its segments data with the original one.
+// The data of a segment is only copied the first time either memory writes to it,
+// which makes forking cheap regardless of the memory size. Builtin runners hold
+// their own state so each forked segment gets the runner returned by cloneRunner.
+// An active checkpoint is not carried over to the fork
+func (memory *Memory) Fork(cloneRunner func(BuiltinRunner) BuiltinRunner) *Memory {
+	forked := &Memory{
+		Segments:          make([]*Segment, len(memory.Segments), cap(memory.Segments)),
+		TemporarySegments: make([]*Segment, len(memory.TemporarySegments)),
+		relocationRules:   maps.Clone(memory.relocationRules),
+	}
+	for i, segment := range memory.Segments {
+		forked.Segments[i] = segment.fork(cloneRunner)
+	}
+	for i, segment := range memory.TemporarySegments {
+		forked.TemporarySegments[i] = segment.fork(cloneRunner)
+	}
+	return forked
+}
@@ -0,0 +1,34 @@
+package memory
+
+import (
+	"fmt"
+
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+)
+
+// Freeze makes the segment read only, for the segments that can't change anymore
+// such as the program segment, so that their data can be read concurrently with the
+// run, e.g. to relocate them in the background. Writing the value a cell already
+// holds is still accepted since it leaves the data untouched, while any other write,
+// growing the segment included, is an error
+func (segment *Segment) Freeze() {
+	segment.frozen = true
+}
+
+func (segment *Segment) Frozen() bool {
+	return segment.frozen
+}
+
+func (segment *Segment) writeFrozen(offset uint64, value *MemoryValue) error {
+	if offset >= segment.RealLen() || !segment.Data[offset].Known() {
+		return fmt.Errorf("cannot write to offset %d of a frozen segment", offset)
+	}
+	if mv := &segment.Data[offset]; !mv.Equal(value) {
+		return utils.WithErrorCode(utils.ErrorCodeInconsistentMemory, fmt.Errorf("rewriting value: old value: %s, new value: %s", mv, value))
+	}
+	return nil
+}
+
+func (segment *Segment) readPastFrozen(offset uint64) error {
+	return fmt.Errorf("%s: cannot read offset %d past the end of a frozen segment", segment.BuiltinRunner, offset)
+}
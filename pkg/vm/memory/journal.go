@@ -0,0 +1,91 @@
+package memory
+
+import (
+	"errors"
+	"maps"
+)
+
+// Keeps track of the cells written to a segment since the last checkpoint
+type segmentJournal struct {
+	// offsets of the cells which were unknown before being written
+	written   []uint64
+	lastIndex int
+	dataLen   int
+}
+
+func (segment *Segment) recordWrite(offset uint64) {
+	segment.journal.written = append(segment.journal.written, offset)
+}
+
+func (segment *Segment) rollback() {
+	segment.own()
+	for _, offset := range segment.journal.written {
+		segment.Data[offset] = UnknownValue
+	}
+	segment.Data = segment.Data[:segment.journal.dataLen]
+	segment.LastIndex = segment.journal.lastIndex
+	segment.journal = nil
+}
+
+// State of the memory layout at the moment a checkpoint was taken
+type memoryJournal struct {
+	segments          int
+	temporarySegments int
+	relocationRules   map[int]MemoryAddress
+}
+
+// Starts recording every write done to memory so they can be reverted with Rollback.
+// Segments allocated after the checkpoint are discarded on rollback. Only one
+// checkpoint can be active at a time and the state held by builtin runners is not
+// covered, it has to be snapshotted separately.
+func (memory *Memory) Checkpoint() error {
+	if memory.journal != nil {
+		return errors.New("a checkpoint is already active")
+	}
+	memory.journal = &memoryJournal{
+		segments:          len(memory.Segments),
+		temporarySegments: len(memory.TemporarySegments),
+		relocationRules:   maps.Clone(memory.relocationRules),
+	}
+	for _, segments := range [][]*Segment{memory.Segments, memory.TemporarySegments} {
+		for _, segment := range segments {
+			segment.journal = &segmentJournal{
+				lastIndex: segment.LastIndex,
+				dataLen:   len(segment.Data),
+			}
+		}
+	}
+	return nil
+}
+
+// Reverts memory to the state it had when the last checkpoint was taken. The cost
+// is proportional to the number of cells written since then
+func (memory *Memory) Rollback() error {
+	if memory.journal == nil {
+		return errors.New("no active checkpoint")
+	}
+	memory.Segments = memory.Segments[:memory.journal.segments]
+	memory.TemporarySegments = memory.TemporarySegments[:memory.journal.temporarySegments]
+	for _, segments := range [][]*Segment{memory.Segments, memory.TemporarySegments} {
+		for _, segment := range segments {
+			segment.rollback()
+		}
+	}
+	memory.relocationRules = memory.journal.relocationRules
+	memory.journal = nil
+	return nil
+}
+
+// Stops recording writes, keeping every change done since the last checkpoint
+func (memory *Memory) Commit() error {
+	if memory.journal == nil {
+		return errors.New("no active checkpoint")
+	}
+	for _, segments := range [][]*Segment{memory.Segments, memory.TemporarySegments} {
+		for _, segment := range segments {
+			segment.journal = nil
+		}
+	}
+	memory.journal = nil
+	return nil
+}
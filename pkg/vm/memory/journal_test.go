@@ -0,0 +1,53 @@
+package memory
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckpointRollback(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+	memory.AllocateEmptySegment()
+
+	one := MemoryValueFromInt(1)
+	two := MemoryValueFromInt(2)
+	require.NoError(t, memory.Write(0, 0, &one))
+
+	require.NoError(t, memory.Checkpoint())
+	require.ErrorContains(t, memory.Checkpoint(), "already active")
+
+	require.NoError(t, memory.Write(0, 0, &one))
+	require.NoError(t, memory.Write(0, 150, &two))
+	require.NoError(t, memory.Write(1, 3, &two))
+	newSegment := memory.AllocateEmptySegment()
+	require.NoError(t, memory.WriteToAddress(&newSegment, &one))
+
+	require.NoError(t, memory.Rollback())
+
+	require.Len(t, memory.Segments, 2)
+	require.Equal(t, uint64(1), memory.Segments[0].Len())
+	require.Equal(t, one, memory.Segments[0].Peek(0))
+	require.Equal(t, UnknownValue, memory.Segments[0].Peek(150))
+	require.Equal(t, uint64(0), memory.Segments[1].Len())
+	require.Equal(t, UnknownValue, memory.Segments[1].Peek(3))
+
+	// rolled back cells can be written again with a different value
+	require.NoError(t, memory.Write(1, 3, &one))
+	require.ErrorContains(t, memory.Rollback(), "no active checkpoint")
+}
+
+func TestCheckpointCommit(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+
+	one := MemoryValueFromInt(1)
+	require.NoError(t, memory.Checkpoint())
+	require.NoError(t, memory.Write(0, 2, &one))
+	require.NoError(t, memory.Commit())
+
+	require.Equal(t, one, memory.Segments[0].Peek(2))
+	require.Nil(t, memory.Segments[0].journal)
+	require.ErrorContains(t, memory.Commit(), "no active checkpoint")
+}
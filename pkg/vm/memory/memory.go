@@ -1,9 +1,11 @@
 package memory
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
 	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
 )
 
@@ -17,6 +19,48 @@ type BuiltinRunner interface {
 	SetStopPointer(stopPointer uint64)
 }
 
+// Selects which of the builtin runner operations are applied to a segment
+type BuiltinMode uint8
+
+const (
+	// Written values are validated and unknown values are deduced when read
+	ValidateAndDeduce BuiltinMode = iota
+	// Written values are validated but unknown values are never deduced
+	ValidateOnly
+	// Unknown values are deduced when read but written values are not validated
+	DeduceOnly
+)
+
+func (mode BuiltinMode) String() string {
+	switch mode {
+	case ValidateAndDeduce:
+		return "validate_and_deduce"
+	case ValidateOnly:
+		return "validate"
+	case DeduceOnly:
+		return "deduce"
+	}
+	return fmt.Sprintf("unknown builtin mode %d", uint8(mode))
+}
+
+func (mode BuiltinMode) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mode.String())
+}
+
+func (mode *BuiltinMode) UnmarshalJSON(data []byte) error {
+	var name string
+	if err := json.Unmarshal(data, &name); err != nil {
+		return fmt.Errorf("unmarshal builtin mode: %w", err)
+	}
+	for _, m := range []BuiltinMode{ValidateAndDeduce, ValidateOnly, DeduceOnly} {
+		if m.String() == name {
+			*mode = m
+			return nil
+		}
+	}
+	return fmt.Errorf("unmarshal unknown builtin mode: %s", name)
+}
+
 type NoBuiltin struct{}
 
 func (b *NoBuiltin) CheckWrite(segment *Segment, offset uint64, value *MemoryValue) error {
@@ -45,12 +89,32 @@ func (b *NoBuiltin) GetStopPointer() uint64 {
 
 func (b *NoBuiltin) SetStopPointer(stopPointer uint64) {}
 
+// InjectedFailure makes the writes and deductions of one instance of a builtin
+// segment fail with a chosen error, the other instances being handled by the
+// builtin runner. It is meant for negative tests, of the error handling of programs
+// as well as of the runner itself
+type InjectedFailure struct {
+	// index of the failing instance in the segment
+	Instance uint64
+	Err      error
+}
+
 type Segment struct {
 	Data []MemoryValue
 	// the max index where a value was written
-	LastIndex           int
-	BuiltinRunner       BuiltinRunner
+	LastIndex     int
+	BuiltinRunner BuiltinRunner
+	// operations of the builtin runner applied to the segment
+	BuiltinMode BuiltinMode
+	// nil unless a failure is injected into one of the instances of the segment
+	Failure             *InjectedFailure
 	PublicMemoryOffsets []PublicMemoryOffset
+	// set while a memory checkpoint is active
+	journal *segmentJournal
+	// true if Data is shared with a forked memory and must be copied before being written
+	shared bool
+	// true once the data of the segment cannot change anymore, see Freeze
+	frozen bool
 }
 
 func (segment *Segment) WithBuiltinRunner(builtinRunner BuiltinRunner) *Segment {
@@ -61,7 +125,7 @@ func (segment *Segment) WithBuiltinRunner(builtinRunner BuiltinRunner) *Segment
 func EmptySegment() *Segment {
 	// empty segments have capacity 100 as a default
 	return &Segment{
-		Data:          make([]MemoryValue, 0, 100),
+		Data:          allocateSegmentData(0, 100),
 		LastIndex:     -1,
 		BuiltinRunner: &NoBuiltin{},
 	}
@@ -69,7 +133,7 @@ func EmptySegment() *Segment {
 
 func EmptySegmentWithCapacity(capacity int) *Segment {
 	return &Segment{
-		Data:          make([]MemoryValue, 0, capacity),
+		Data:          allocateSegmentData(0, capacity),
 		LastIndex:     -1,
 		BuiltinRunner: &NoBuiltin{},
 	}
@@ -77,7 +141,7 @@ func EmptySegmentWithCapacity(capacity int) *Segment {
 
 func EmptySegmentWithLength(length int) *Segment {
 	return &Segment{
-		Data:          make([]MemoryValue, length),
+		Data:          allocateSegmentData(length, length),
 		LastIndex:     length - 1,
 		BuiltinRunner: &NoBuiltin{},
 	}
@@ -94,9 +158,22 @@ func (segment *Segment) RealLen() uint64 {
 	return uint64(len(segment.Data))
 }
 
+// returns the offset past the last known cell of a segment. Unlike Len, it isn't
+// changed by Finalize, which sets the length to the allocated size
+func (segment *Segment) WrittenLen() uint64 {
+	written := uint64(len(segment.Data))
+	for written > 0 && !segment.Data[written-1].Known() {
+		written--
+	}
+	return written
+}
+
 // Writes a new memory value to a specified offset, errors in case of overwriting a
 // different memory value
 func (segment *Segment) Write(offset uint64, value *MemoryValue) error {
+	if segment.frozen {
+		return segment.writeFrozen(offset, value)
+	}
 	if offset >= segment.RealLen() {
 		segment.IncreaseSegmentSize(offset + 1)
 	}
@@ -106,25 +183,67 @@ func (segment *Segment) Write(offset uint64, value *MemoryValue) error {
 
 	mv := &segment.Data[offset]
 	if mv.Known() && !mv.Equal(value) {
-		return fmt.Errorf("rewriting value: old value: %s, new value: %s", mv, value)
+		return utils.WithErrorCode(utils.ErrorCodeInconsistentMemory, fmt.Errorf("rewriting value: old value: %s, new value: %s", mv, value))
+	}
+	if segment.journal != nil && !mv.Known() {
+		segment.recordWrite(offset)
 	}
+	segment.own()
 	segment.Data[offset] = *value
-	if err := segment.BuiltinRunner.CheckWrite(segment, offset, value); err != nil {
-		return fmt.Errorf("%s: %w", segment.BuiltinRunner, err)
+	if segment.BuiltinMode != DeduceOnly {
+		if err := segment.checkWrite(offset, value); err != nil {
+			return fmt.Errorf("%s: %w", segment.BuiltinRunner, err)
+		}
 	}
 
 	return nil
 }
 
+// Returns the injected error when the cell belongs to the failing instance
+func (segment *Segment) injectedFailure(offset uint64) error {
+	if segment.Failure == nil {
+		return nil
+	}
+	cellsPerInstance := segment.BuiltinRunner.GetCellsPerInstance()
+	// builtins without instances, such as the output, fail at the cell of that index
+	if cellsPerInstance == 0 {
+		cellsPerInstance = 1
+	}
+	if offset/cellsPerInstance != segment.Failure.Instance {
+		return nil
+	}
+	return segment.Failure.Err
+}
+
+func (segment *Segment) checkWrite(offset uint64, value *MemoryValue) error {
+	if err := segment.injectedFailure(offset); err != nil {
+		return err
+	}
+	return segment.BuiltinRunner.CheckWrite(segment, offset, value)
+}
+
+func (segment *Segment) inferValue(offset uint64) error {
+	if err := segment.injectedFailure(offset); err != nil {
+		return err
+	}
+	return segment.BuiltinRunner.InferValue(segment, offset)
+}
+
 // Reads a memory value from a specified offset at the segment
 func (segment *Segment) Read(offset uint64) (MemoryValue, error) {
 	if offset >= segment.RealLen() {
+		if segment.frozen {
+			return UnknownValue, segment.readPastFrozen(offset)
+		}
 		segment.IncreaseSegmentSize(offset + 1)
 	}
 
 	mv := &segment.Data[offset]
 	if !mv.Known() {
-		if err := segment.BuiltinRunner.InferValue(segment, offset); err != nil {
+		if segment.BuiltinMode == ValidateOnly {
+			return UnknownValue, fmt.Errorf("%s: deduction is disabled", segment.BuiltinRunner)
+		}
+		if err := segment.inferValue(offset); err != nil {
 			return UnknownValue, fmt.Errorf("%s: %w", segment.BuiltinRunner, err)
 		}
 	}
@@ -142,6 +261,112 @@ func (segment *Segment) Peek(offset uint64) MemoryValue {
 	return segment.Data[offset]
 }
 
+// Reads n consecutive memory values starting at offset, deducing the unknown ones like
+// Read does. The segment is grown at most once for the whole range
+func (segment *Segment) ReadRange(offset uint64, n uint64) ([]MemoryValue, error) {
+	values, failed, err := segment.readRange(offset, n)
+	if err != nil {
+		return nil, fmt.Errorf("offset %d: %w", failed, err)
+	}
+	return values, nil
+}
+
+// Writes consecutive memory values starting at offset. All the cells are checked
+// against overwriting a different value before any of them is written, so that a
+// rewrite leaves the segment untouched. The builtin runner then checks each write
+func (segment *Segment) WriteRange(offset uint64, values []MemoryValue) error {
+	if failed, err := segment.writeRange(offset, values); err != nil {
+		return fmt.Errorf("offset %d: %w", failed, err)
+	}
+	return nil
+}
+
+// returns the offset of the cell that failed along with the error
+func (segment *Segment) readRange(offset uint64, n uint64) ([]MemoryValue, uint64, error) {
+	if n == 0 {
+		return []MemoryValue{}, offset, nil
+	}
+	end := offset + n
+	if end < offset {
+		return nil, offset, fmt.Errorf("range of %d cells overflows the segment", n)
+	}
+	if end > segment.RealLen() {
+		if segment.frozen {
+			return nil, segment.RealLen(), segment.readPastFrozen(segment.RealLen())
+		}
+		segment.IncreaseSegmentSize(end)
+	}
+
+	for i := offset; i < end; i++ {
+		if segment.Data[i].Known() {
+			continue
+		}
+		if segment.BuiltinMode == ValidateOnly {
+			return nil, i, fmt.Errorf("%s: deduction is disabled", segment.BuiltinRunner)
+		}
+		if err := segment.inferValue(i); err != nil {
+			return nil, i, fmt.Errorf("%s: %w", segment.BuiltinRunner, err)
+		}
+	}
+
+	if end-1 > segment.Len() {
+		segment.LastIndex = int(end - 1)
+	}
+	values := make([]MemoryValue, n)
+	copy(values, segment.Data[offset:end])
+	return values, offset, nil
+}
+
+// returns the offset of the cell that failed along with the error
+func (segment *Segment) writeRange(offset uint64, values []MemoryValue) (uint64, error) {
+	if len(values) == 0 {
+		return offset, nil
+	}
+	end := offset + uint64(len(values))
+	if end < offset {
+		return offset, fmt.Errorf("range of %d cells overflows the segment", len(values))
+	}
+	if segment.frozen {
+		for i := range values {
+			if err := segment.writeFrozen(offset+uint64(i), &values[i]); err != nil {
+				return offset + uint64(i), err
+			}
+		}
+		return offset, nil
+	}
+	if end > segment.RealLen() {
+		segment.IncreaseSegmentSize(end)
+	}
+
+	for i := range values {
+		mv := &segment.Data[offset+uint64(i)]
+		if mv.Known() && !mv.Equal(&values[i]) {
+			return offset + uint64(i), utils.WithErrorCode(utils.ErrorCodeInconsistentMemory, fmt.Errorf("rewriting value: old value: %s, new value: %s", mv, &values[i]))
+		}
+	}
+
+	if end > segment.Len() {
+		segment.LastIndex = int(end - 1)
+	}
+	if segment.journal != nil {
+		for i := offset; i < end; i++ {
+			if !segment.Data[i].Known() {
+				segment.recordWrite(i)
+			}
+		}
+	}
+	segment.own()
+	copy(segment.Data[offset:end], values)
+	if segment.BuiltinMode != DeduceOnly {
+		for i := range values {
+			if err := segment.checkWrite(offset+uint64(i), &values[i]); err != nil {
+				return offset + uint64(i), fmt.Errorf("%s: %w", segment.BuiltinRunner, err)
+			}
+		}
+	}
+	return offset, nil
+}
+
 // Increase a segment allocated space. Panics if the new size is smaller
 func (segment *Segment) IncreaseSegmentSize(newSize uint64) {
 	segmentData := segment.Data
@@ -157,7 +382,8 @@ func (segment *Segment) IncreaseSegmentSize(newSize uint64) {
 	if cap(segmentData) > int(newSize) {
 		newSegmentData = segmentData[:cap(segmentData)]
 	} else {
-		newSegmentData = make([]MemoryValue, max(newSize, uint64(len(segmentData)*2)))
+		newSize = max(newSize, uint64(len(segmentData)*2))
+		newSegmentData = allocateSegmentData(int(newSize), int(newSize))
 		copy(newSegmentData, segmentData)
 	}
 	segment.Data = newSegmentData
@@ -215,6 +441,10 @@ type Memory struct {
 	// TemporarySegments is a map of temporary segments, key is the segment index, value is the segment
 	TemporarySegments []*Segment
 	relocationRules   map[int]MemoryAddress
+	// set while a checkpoint is active
+	journal *memoryJournal
+	// called after every successful write while set
+	writeObserver func(address MemoryAddress, value *MemoryValue)
 }
 
 // todo(rodro): can the amount of segments be known before hand?
@@ -245,6 +475,25 @@ func (memory *Memory) AllocateSegment(data []*f.Element) (MemoryAddress, error)
 	}, nil
 }
 
+// Allocates a segment holding trusted data, e.g. a large input preloaded by an
+// embedder, and returns its index. The values are not written one by one, so they
+// skip the write validations and loading them is cheap. Writing to the segment
+// afterwards is validated as usual, the segment is never frozen like the program
+// segment, even when the memory is relocated eagerly. The data is shared with the caller until the
+// segment is first written to, and Release never gives it back to the pools
+func (memory *Memory) AllocateTrustedSegment(data []MemoryValue) MemoryAddress {
+	memory.Segments = append(memory.Segments, &Segment{
+		Data:          data,
+		LastIndex:     len(data) - 1,
+		BuiltinRunner: &NoBuiltin{},
+		shared:        true,
+	})
+	return MemoryAddress{
+		SegmentIndex: len(memory.Segments) - 1,
+		Offset:       0,
+	}
+}
+
 // Allocates an empty segment and returns its index
 func (memory *Memory) AllocateEmptySegment() MemoryAddress {
 	memory.Segments = append(memory.Segments, EmptySegment())
@@ -283,17 +532,85 @@ func (memory *Memory) Write(segmentIndex int, offset uint64, value *MemoryValue)
 		if err := memory.Segments[segmentIndex].Write(offset, value); err != nil {
 			return fmt.Errorf("segment %d, offset %d: %w", segmentIndex, offset, err)
 		}
-		return nil
 	} else {
-		segmentIndex = -segmentIndex
-		if segmentIndex >= len(memory.TemporarySegments) {
-			return fmt.Errorf("temporary segment %d: unallocated", segmentIndex)
+		temporaryIndex := -segmentIndex
+		if temporaryIndex >= len(memory.TemporarySegments) {
+			return fmt.Errorf("temporary segment %d: unallocated", temporaryIndex)
 		}
-		if err := memory.TemporarySegments[segmentIndex].Write(offset, value); err != nil {
-			return fmt.Errorf("temporary segment %d, offset %d: %w", segmentIndex, offset, err)
+		if err := memory.TemporarySegments[temporaryIndex].Write(offset, value); err != nil {
+			return fmt.Errorf("temporary segment %d, offset %d: %w", temporaryIndex, offset, err)
 		}
-		return nil
 	}
+	if memory.writeObserver != nil {
+		memory.writeObserver(MemoryAddress{SegmentIndex: segmentIndex, Offset: offset}, value)
+	}
+	return nil
+}
+
+// Writes consecutive memory values starting at address, with the checks of the
+// segment WriteRange. Errors if writing to an unallocated segment or if overwriting a
+// different memory value
+func (memory *Memory) WriteRange(address *MemoryAddress, values []MemoryValue) error {
+	segment, err := memory.lookupSegment(address.SegmentIndex)
+	if err != nil {
+		return err
+	}
+	if failed, err := segment.writeRange(address.Offset, values); err != nil {
+		return fmt.Errorf("%s, offset %d: %w", segmentName(address.SegmentIndex), failed, err)
+	}
+	if memory.writeObserver != nil {
+		for i := range values {
+			memory.writeObserver(MemoryAddress{SegmentIndex: address.SegmentIndex, Offset: address.Offset + uint64(i)}, &values[i])
+		}
+	}
+	return nil
+}
+
+// Reads n consecutive memory values starting at address. Errors if reading from an
+// unallocated segment or if one of the values is unknown and can't be deduced
+func (memory *Memory) ReadRange(address *MemoryAddress, n uint64) ([]MemoryValue, error) {
+	segment, err := memory.lookupSegment(address.SegmentIndex)
+	if err != nil {
+		return nil, err
+	}
+	values, failed, err := segment.readRange(address.Offset, n)
+	if err != nil {
+		return nil, fmt.Errorf("%s, offset %d: %w", segmentName(address.SegmentIndex), failed, err)
+	}
+	return values, nil
+}
+
+func (memory *Memory) lookupSegment(segmentIndex int) (*Segment, error) {
+	if segmentIndex >= 0 {
+		if segmentIndex >= len(memory.Segments) {
+			return nil, fmt.Errorf("segment %d: unallocated", segmentIndex)
+		}
+		return memory.Segments[segmentIndex], nil
+	}
+	if -segmentIndex >= len(memory.TemporarySegments) {
+		return nil, fmt.Errorf("temporary segment %d: unallocated", -segmentIndex)
+	}
+	return memory.TemporarySegments[-segmentIndex], nil
+}
+
+func segmentName(segmentIndex int) string {
+	if segmentIndex < 0 {
+		return fmt.Sprintf("temporary segment %d", -segmentIndex)
+	}
+	return fmt.Sprintf("segment %d", segmentIndex)
+}
+
+// SetWriteObserver registers a function called after every successful write to
+// memory, until it is replaced or removed by passing nil. Values deduced by builtin
+// runners are not writes and are not observed
+func (memory *Memory) SetWriteObserver(observer func(address MemoryAddress, value *MemoryValue)) {
+	memory.writeObserver = observer
+}
+
+// WriteObserver returns the function registered with SetWriteObserver, so that an
+// observer can be chained to it rather than replace it
+func (memory *Memory) WriteObserver() func(address MemoryAddress, value *MemoryValue) {
+	return memory.writeObserver
 }
 
 // Writes to a memory address a new memory value. Errors if writing to an unallocated
@@ -444,6 +761,17 @@ func (memory *Memory) FindSegmentWithBuiltin(builtinName string) (*Segment, bool
 	return nil, false
 }
 
+// It finds all the segments with a given builtin name, in allocation order
+func (memory *Memory) FindSegmentsWithBuiltin(builtinName string) []*Segment {
+	var segments []*Segment
+	for i := range memory.Segments {
+		if memory.Segments[i].BuiltinRunner.String() == builtinName {
+			segments = append(segments, memory.Segments[i])
+		}
+	}
+	return segments
+}
+
 func (memory *Memory) WriteUint256ToAddress(addr MemoryAddress, low, high *f.Element) error {
 	lowMemoryValue := MemoryValueFromFieldElement(low)
 	err := memory.WriteToAddress(&addr, &lowMemoryValue)
@@ -465,6 +793,10 @@ func (memory *Memory) AddRelocationRule(segmentIndex int, addr MemoryAddress) {
 	memory.relocationRules[segmentIndex] = addr
 }
 
+// Moves the temporary segments to the addresses given by their relocation rules. The
+// known cells of a temporary segment are written one after the other from its base
+// address, and the addresses stored in the real segments that point to a relocated
+// temporary segment are rewritten to its base address plus their offset
 func (memory *Memory) RelocateTemporarySegments() error {
 	// We check if the length of the temporary segments is 1 because the first temporary is added during initialization
 	// for proper indexing, and is always empty
@@ -473,18 +805,9 @@ func (memory *Memory) RelocateTemporarySegments() error {
 	}
 	for i, segment := range memory.Segments {
 		for j := uint64(0); j < segment.RealLen(); j++ {
-			if !segment.Data[j].Known() {
-				continue
-			}
-
-			if segment.Data[j].IsAddress() {
-				addr, _ := segment.Data[j].MemoryAddress()
-				if addr.SegmentIndex < 0 {
-					if rule, ok := memory.relocationRules[-addr.SegmentIndex]; ok {
-						newAddr := MemoryAddress{SegmentIndex: rule.SegmentIndex, Offset: rule.Offset + addr.Offset}
-						memory.Segments[i].Data[j] = MemoryValueFromMemoryAddress(&newAddr)
-					}
-				}
+			if relocated, ok := memory.relocateTemporaryValue(&segment.Data[j]); ok {
+				memory.Segments[i].own()
+				memory.Segments[i].Data[j] = relocated
 			}
 		}
 	}
@@ -508,3 +831,28 @@ func (memory *Memory) RelocateTemporarySegments() error {
 	}
 	return nil
 }
+
+// RelocationRule returns the address the temporary segment of the given index,
+// positive, is relocated to, and false if it has no relocation rule
+func (memory *Memory) RelocationRule(temporaryIndex int) (MemoryAddress, bool) {
+	addr, ok := memory.relocationRules[temporaryIndex]
+	return addr, ok
+}
+
+// Returns the value an address into a temporary segment with a relocation rule is
+// relocated to, or false if the value is not such an address
+func (memory *Memory) relocateTemporaryValue(value *MemoryValue) (MemoryValue, bool) {
+	if !value.IsAddress() {
+		return UnknownValue, false
+	}
+	addr, _ := value.MemoryAddress()
+	if addr.SegmentIndex >= 0 {
+		return UnknownValue, false
+	}
+	rule, ok := memory.relocationRules[-addr.SegmentIndex]
+	if !ok {
+		return UnknownValue, false
+	}
+	newAddr := MemoryAddress{SegmentIndex: rule.SegmentIndex, Offset: rule.Offset + addr.Offset}
+	return MemoryValueFromMemoryAddress(&newAddr), true
+}
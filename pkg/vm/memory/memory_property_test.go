@@ -0,0 +1,329 @@
+package memory
+
+import (
+	"math/rand"
+	"reflect"
+	"slices"
+	"testing"
+	"testing/quick"
+
+	"github.com/stretchr/testify/require"
+)
+
+// Property tests of the memory model documented in docs/docs/vm-fundamentals/memory.md.
+// Each one runs the memory against a plain map from address to value, which is the
+// model every redesign of the memory has to keep agreeing with
+
+const (
+	propertySegments = 4
+	propertyOffsets  = 64
+)
+
+// a write of a felt, or of an address when isAddress is set, to segment:offset
+type memoryWrite struct {
+	address   MemoryAddress
+	isAddress bool
+	target    MemoryAddress
+	felt      uint64
+}
+
+func (write *memoryWrite) value() MemoryValue {
+	if write.isAddress {
+		return MemoryValueFromMemoryAddress(&write.target)
+	}
+	return MemoryValueFromUint(write.felt)
+}
+
+type memoryWrites []memoryWrite
+
+// Generates writes over a few small segments so that many of them hit the same cells
+func (memoryWrites) Generate(r *rand.Rand, size int) reflect.Value {
+	randomAddress := func() MemoryAddress {
+		return MemoryAddress{
+			SegmentIndex: r.Intn(propertySegments),
+			Offset:       uint64(r.Intn(propertyOffsets)),
+		}
+	}
+	writes := make(memoryWrites, r.Intn(size+1))
+	for i := range writes {
+		writes[i] = memoryWrite{
+			address:   randomAddress(),
+			isAddress: r.Intn(4) == 0,
+			target:    randomAddress(),
+			felt:      uint64(r.Intn(8)),
+		}
+	}
+	return reflect.ValueOf(writes)
+}
+
+func newPropertyMemory() *Memory {
+	memory := InitializeEmptyMemory()
+	for i := 0; i < propertySegments; i++ {
+		memory.AllocateEmptySegment()
+	}
+	return memory
+}
+
+// Applies the writes to the memory and to the model. A write succeeds if and only if
+// the cell is unknown or already holds the same value
+func applyWrites(t *testing.T, memory *Memory, model map[MemoryAddress]MemoryValue, writes memoryWrites) bool {
+	for i := range writes {
+		write := &writes[i]
+		value := write.value()
+		err := memory.WriteToAddress(&write.address, &value)
+		previous, known := model[write.address]
+		switch {
+		case !known:
+			if err != nil {
+				t.Logf("first write to %s failed: %v", write.address, err)
+				return false
+			}
+			model[write.address] = value
+		case previous.Equal(&value):
+			if err != nil {
+				t.Logf("writing the same value to %s failed: %v", write.address, err)
+				return false
+			}
+		default:
+			if err == nil {
+				t.Logf("overwriting %s with %s did not fail", previous, value)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Checks the memory holds exactly the model values and that every segment length
+// is the highest written offset + 1
+func matchesModel(t *testing.T, memory *Memory, model map[MemoryAddress]MemoryValue) bool {
+	lengths := make([]uint64, len(memory.Segments))
+	for address := range model {
+		lengths[address.SegmentIndex] = max(lengths[address.SegmentIndex], address.Offset+1)
+	}
+	for i, segment := range memory.Segments {
+		if segment.Len() != lengths[i] {
+			t.Logf("segment %d has length %d, expected %d", i, segment.Len(), lengths[i])
+			return false
+		}
+		for offset := uint64(0); offset < propertyOffsets; offset++ {
+			address := MemoryAddress{SegmentIndex: i, Offset: offset}
+			expected, known := model[address]
+			if !known {
+				expected = UnknownValue
+			}
+			value, err := memory.PeekFromAddress(&address)
+			if err != nil || !sameValue(&expected, &value) {
+				t.Logf("%s holds %s, expected %s", address, value, expected)
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Like MemoryValue.Equal, except that unknown values are equal
+func sameValue(a, b *MemoryValue) bool {
+	return a.Equal(b) || (!a.Known() && !b.Known())
+}
+
+func checkProperty(t *testing.T, property any) {
+	t.Helper()
+	require.NoError(t, quick.Check(property, &quick.Config{MaxCount: 500}))
+}
+
+// Cells are written once: a written value can be written again but never replaced,
+// and failed writes leave the memory untouched
+func TestPropertyWriteOnce(t *testing.T) {
+	checkProperty(t, func(writes memoryWrites) bool {
+		memory := newPropertyMemory()
+		model := make(map[MemoryAddress]MemoryValue)
+		return applyWrites(t, memory, model, writes) && matchesModel(t, memory, model)
+	})
+}
+
+// Writing to a segment never changes the cells of another segment
+func TestPropertySegmentIsolation(t *testing.T) {
+	checkProperty(t, func(writes memoryWrites, segment uint8) bool {
+		isolated := int(segment) % propertySegments
+		memory := newPropertyMemory()
+		model := make(map[MemoryAddress]MemoryValue)
+		var others memoryWrites
+		for _, write := range writes {
+			if write.address.SegmentIndex == isolated {
+				value := write.value()
+				if _, known := model[write.address]; !known {
+					model[write.address] = value
+				}
+				// errors are checked by TestPropertyWriteOnce
+				_ = memory.WriteToAddress(&write.address, &value)
+			} else {
+				others = append(others, write)
+			}
+		}
+		before := append([]MemoryValue(nil), memory.Segments[isolated].Data...)
+		if !applyWrites(t, memory, model, others) {
+			return false
+		}
+		after := memory.Segments[isolated].Data
+		for offset := range before {
+			if !sameValue(&before[offset], &after[offset]) {
+				t.Logf("%d:%d changed from %s to %s", isolated, offset, &before[offset], &after[offset])
+				return false
+			}
+		}
+		return matchesModel(t, memory, model)
+	})
+}
+
+// Relocation lays the segments one after the other starting at 1: every cell of a
+// segment is moved to its segment offset + its offset, and address values are
+// replaced by the relocated address they point to
+func TestPropertyRelocation(t *testing.T) {
+	checkProperty(t, func(writes memoryWrites) bool {
+		memory := newPropertyMemory()
+		model := make(map[MemoryAddress]MemoryValue)
+		if !applyWrites(t, memory, model, writes) {
+			return false
+		}
+
+		segmentsOffsets, maxMemoryUsed := memory.RelocationOffsets()
+		if segmentsOffsets[0] != 1 || maxMemoryUsed != segmentsOffsets[len(memory.Segments)] {
+			t.Logf("invalid bounds: offsets %v, memory used %d", segmentsOffsets, maxMemoryUsed)
+			return false
+		}
+		relocated := make(map[uint64]MemoryValue)
+		for i, segment := range memory.Segments {
+			// segments are contiguous and don't overlap
+			if segmentsOffsets[i+1]-segmentsOffsets[i] != segment.Len() {
+				t.Logf("segment %d is relocated to [%d, %d) but has length %d", i, segmentsOffsets[i], segmentsOffsets[i+1], segment.Len())
+				return false
+			}
+			for offset := uint64(0); offset < segment.Len(); offset++ {
+				address := MemoryAddress{SegmentIndex: i, Offset: offset}
+				relocated[address.Relocate(segmentsOffsets).Uint64()] = segment.Data[offset]
+			}
+		}
+		for address, value := range model {
+			index := address.Relocate(segmentsOffsets).Uint64()
+			if cell := relocated[index]; !cell.Equal(&value) {
+				t.Logf("%s was relocated to %d but it holds %s", address, index, relocated[index])
+				return false
+			}
+			if target, err := value.MemoryAddress(); err == nil {
+				expected := segmentsOffsets[target.SegmentIndex] + target.Offset
+				if target.Relocate(segmentsOffsets).Uint64() != expected {
+					t.Logf("address %s was not relocated to %d", target, expected)
+					return false
+				}
+			}
+		}
+		return true
+	})
+}
+
+// Relocating a temporary segment writes its known cells one after the other from the
+// base address, and turns every address pointing into it from a real segment into the
+// base address plus its offset
+func TestPropertyTemporarySegmentRelocation(t *testing.T) {
+	checkProperty(t, func(writes memoryWrites, baseOffset uint8) bool {
+		memory := newPropertyMemory()
+		temporary := memory.AllocateEmptyTemporarySegment()
+		// the real segment the temporary one is moved to is left empty
+		base := MemoryAddress{SegmentIndex: memory.AllocateEmptySegment().SegmentIndex, Offset: uint64(baseOffset)}
+
+		// the last segment is replaced by the temporary one
+		model := make(map[MemoryAddress]MemoryValue)
+		temporaryModel := make(map[uint64]MemoryValue)
+		for i := range writes {
+			write := &writes[i]
+			if write.isAddress && write.target.SegmentIndex == propertySegments-1 {
+				write.target.SegmentIndex = temporary.SegmentIndex
+			}
+			value := write.value()
+			address := write.address
+			if address.SegmentIndex == propertySegments-1 {
+				address.SegmentIndex = temporary.SegmentIndex
+			}
+			if err := memory.WriteToAddress(&address, &value); err != nil {
+				continue
+			}
+			if address.SegmentIndex == temporary.SegmentIndex {
+				temporaryModel[address.Offset] = value
+				continue
+			}
+			if write.isAddress && write.target.SegmentIndex == temporary.SegmentIndex {
+				target := MemoryAddress{SegmentIndex: base.SegmentIndex, Offset: base.Offset + write.target.Offset}
+				value = MemoryValueFromMemoryAddress(&target)
+			}
+			model[address] = value
+		}
+		offsets := make([]uint64, 0, len(temporaryModel))
+		for offset := range temporaryModel {
+			offsets = append(offsets, offset)
+		}
+		slices.Sort(offsets)
+		for i, offset := range offsets {
+			model[MemoryAddress{SegmentIndex: base.SegmentIndex, Offset: base.Offset + uint64(i)}] = temporaryModel[offset]
+		}
+
+		memory.AddRelocationRule(-temporary.SegmentIndex, base)
+		if err := memory.RelocateTemporarySegments(); err != nil {
+			t.Logf("relocation failed: %v", err)
+			return false
+		}
+		for address, expected := range model {
+			value, err := memory.PeekFromAddress(&address)
+			if err != nil || !expected.Equal(&value) {
+				t.Logf("%s holds %s, expected %s", address, value, expected)
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// A fork and its original memory never see each other writes
+func TestPropertyForkIsolation(t *testing.T) {
+	cloneRunner := func(runner BuiltinRunner) BuiltinRunner { return runner }
+	checkProperty(t, func(shared, original, forked memoryWrites) bool {
+		memory := newPropertyMemory()
+		model := make(map[MemoryAddress]MemoryValue)
+		if !applyWrites(t, memory, model, shared) {
+			return false
+		}
+		fork := memory.Fork(cloneRunner)
+		forkModel := make(map[MemoryAddress]MemoryValue, len(model))
+		for address, value := range model {
+			forkModel[address] = value
+		}
+		return applyWrites(t, memory, model, original) &&
+			applyWrites(t, fork, forkModel, forked) &&
+			matchesModel(t, memory, model) &&
+			matchesModel(t, fork, forkModel)
+	})
+}
+
+// Rolling back a checkpoint restores the memory as it was when it was taken
+func TestPropertyRollback(t *testing.T) {
+	checkProperty(t, func(before, after memoryWrites) bool {
+		memory := newPropertyMemory()
+		model := make(map[MemoryAddress]MemoryValue)
+		if !applyWrites(t, memory, model, before) {
+			return false
+		}
+		if err := memory.Checkpoint(); err != nil {
+			t.Logf("checkpoint failed: %v", err)
+			return false
+		}
+		for i := range after {
+			value := after[i].value()
+			_ = memory.WriteToAddress(&after[i].address, &value)
+		}
+		if err := memory.Rollback(); err != nil {
+			t.Logf("rollback failed: %v", err)
+			return false
+		}
+		return matchesModel(t, memory, model)
+	})
+}
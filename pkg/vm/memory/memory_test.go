@@ -72,6 +72,91 @@ func TestSegmentReadAndWrite(t *testing.T) {
 	assert.True(t, segment.Data[0].Known())
 }
 
+func TestSegmentReadRange(t *testing.T) {
+	segment := defaultSegment(3, 5, nil, 7)
+
+	values, err := segment.ReadRange(0, 2)
+	require.NoError(t, err)
+	assert.Equal(t, []MemoryValue{MemoryValueFromInt(3), MemoryValueFromInt(5)}, values)
+
+	values, err = segment.ReadRange(1, 0)
+	require.NoError(t, err)
+	assert.Empty(t, values)
+
+	_, err = segment.ReadRange(1, 3)
+	assert.ErrorContains(t, err, "offset 2: ")
+	assert.ErrorContains(t, err, "reading unknown value")
+}
+
+func TestSegmentWriteRange(t *testing.T) {
+	segment := defaultSegment(nil, 5)
+
+	values := []MemoryValue{MemoryValueFromInt(4), MemoryValueFromInt(5), MemoryValueFromInt(6)}
+	require.NoError(t, segment.WriteRange(0, values))
+	assert.Equal(t, values, segment.Data[:3])
+	assert.Equal(t, uint64(3), segment.Len())
+
+	// a rewrite in the range leaves the other cells unwritten
+	err := segment.WriteRange(2, []MemoryValue{MemoryValueFromInt(7), MemoryValueFromInt(8)})
+	assert.ErrorContains(t, err, "offset 2: rewriting value")
+	assert.False(t, segment.Data[3].Known())
+	assert.Equal(t, uint64(3), segment.Len())
+
+	require.NoError(t, segment.WriteRange(2, []MemoryValue{MemoryValueFromInt(6), MemoryValueFromInt(8)}))
+	noErrorAndEqualSegmentRead(t, &segment, 3, MemoryValueFromInt(8))
+}
+
+func TestFrozenSegment(t *testing.T) {
+	segment := defaultSegment(3, nil, 5)
+	segment.Freeze()
+
+	// writing the value a cell holds leaves the segment untouched
+	three := MemoryValueFromInt(3)
+	require.NoError(t, segment.Write(0, &three))
+	require.NoError(t, segment.WriteRange(0, []MemoryValue{three}))
+
+	four := MemoryValueFromInt(4)
+	require.ErrorContains(t, segment.Write(0, &four), "rewriting value: old value: 3, new value: 4")
+	require.EqualError(t, segment.Write(1, &four), "cannot write to offset 1 of a frozen segment")
+	require.EqualError(t, segment.Write(3, &four), "cannot write to offset 3 of a frozen segment")
+	require.EqualError(t, segment.WriteRange(2, []MemoryValue{MemoryValueFromInt(5), four}), "offset 3: cannot write to offset 3 of a frozen segment")
+
+	_, err := segment.Read(3)
+	require.EqualError(t, err, "no builtin: cannot read offset 3 past the end of a frozen segment")
+	_, err = segment.ReadRange(2, 2)
+	require.EqualError(t, err, "offset 3: no builtin: cannot read offset 3 past the end of a frozen segment")
+	require.Equal(t, uint64(3), segment.RealLen())
+	require.Equal(t, uint64(3), segment.Len())
+	noErrorAndEqualSegmentRead(t, &segment, 2, MemoryValueFromInt(5))
+}
+
+func TestMemoryRange(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+	temporary := memory.AllocateEmptyTemporarySegment()
+
+	var written []MemoryAddress
+	memory.SetWriteObserver(func(address MemoryAddress, value *MemoryValue) {
+		written = append(written, address)
+	})
+	values := []MemoryValue{MemoryValueFromInt(1), MemoryValueFromSegmentAndOffset(0, 4)}
+	require.NoError(t, memory.WriteRange(&temporary, values))
+	assert.Equal(t, []MemoryAddress{temporary, {SegmentIndex: temporary.SegmentIndex, Offset: 1}}, written)
+
+	read, err := memory.ReadRange(&temporary, 2)
+	require.NoError(t, err)
+	assert.Equal(t, values, read)
+
+	_, err = memory.ReadRange(&temporary, 3)
+	assert.ErrorContains(t, err, fmt.Sprintf("temporary segment %d, offset 2: ", -temporary.SegmentIndex))
+	err = memory.WriteRange(&MemoryAddress{SegmentIndex: 0, Offset: 0}, values)
+	require.NoError(t, err)
+	err = memory.WriteRange(&MemoryAddress{SegmentIndex: 0, Offset: 1}, values)
+	assert.ErrorContains(t, err, "segment 0, offset 1: rewriting value")
+	_, err = memory.ReadRange(&MemoryAddress{SegmentIndex: 3}, 1)
+	assert.EqualError(t, err, "segment 3: unallocated")
+}
+
 func TestIncreaseSegmentSizeSmallerSize(t *testing.T) {
 	segment := defaultSegment(1, 2)
 	// Panic if we decrase the size
@@ -138,6 +223,24 @@ func TestMemoryWriteAndRead(t *testing.T) {
 	assert.Equal(t, val, MemoryValueFromInt(31))
 }
 
+func TestAllocateTrustedSegment(t *testing.T) {
+	memory := InitializeEmptyMemory()
+	memory.AllocateEmptySegment()
+	data := []MemoryValue{MemoryValueFromInt(1), UnknownValue, MemoryValueFromInt(3)}
+	address := memory.AllocateTrustedSegment(data)
+	assert.Equal(t, MemoryAddress{SegmentIndex: 1, Offset: 0}, address)
+	assert.Equal(t, uint64(3), memory.Segments[1].Len())
+
+	val, err := memory.Read(1, 2)
+	require.NoError(t, err)
+	assert.Equal(t, MemoryValueFromInt(3), val)
+
+	// later writes are validated and don't modify the data of the caller
+	require.ErrorContains(t, memory.Write(1, 0, memoryValuePointerFromInt(2)), "rewriting value")
+	require.NoError(t, memory.Write(1, 1, memoryValuePointerFromInt(2)))
+	assert.Equal(t, UnknownValue, data[1])
+}
+
 func TestMemoryReadUnallocated(t *testing.T) {
 	memory := InitializeEmptyMemory()
 	memory.AllocateEmptySegment()
@@ -1,8 +1,12 @@
 package memory
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"sync"
 	"unsafe"
 
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
@@ -67,7 +71,7 @@ func (address *MemoryAddress) Add(lhs *MemoryAddress, rhs *f.Element) error {
 	lhsOffset := new(f.Element).SetUint64(lhs.Offset)
 	newOffset := new(f.Element).Add(lhsOffset, rhs)
 	if !newOffset.IsUint64() {
-		return fmt.Errorf("new offset bigger than uint64: %s", rhs.Text(10))
+		return fmt.Errorf("new offset bigger than uint64: %s", utils.FeltString(rhs))
 	}
 	address.SegmentIndex = lhs.SegmentIndex
 	address.Offset = newOffset.Uint64()
@@ -82,7 +86,7 @@ func (address *MemoryAddress) Sub(lhs *MemoryAddress, rhs *f.Element) error {
 	}
 	newOffset := new(f.Element).Sub(lhsOffset, rhs)
 	if !newOffset.IsUint64() {
-		return fmt.Errorf("new offset bigger than uint64: %s", rhs.Text(10))
+		return fmt.Errorf("new offset bigger than uint64: %s", utils.FeltString(rhs))
 	}
 	address.SegmentIndex = lhs.SegmentIndex
 	address.Offset = newOffset.Uint64()
@@ -104,6 +108,39 @@ func (address MemoryAddress) String() string {
 	)
 }
 
+// Addresses are written as "segment:offset", e.g. "2:15", the segment being negative
+// for temporary segments. It is the representation used by every artifact and
+// service exposing addresses to external tools
+func (address MemoryAddress) MarshalText() ([]byte, error) {
+	return []byte(address.String()), nil
+}
+
+func (address *MemoryAddress) UnmarshalText(text []byte) error {
+	parsed, err := ParseMemoryAddress(string(text))
+	if err != nil {
+		return err
+	}
+	*address = parsed
+	return nil
+}
+
+// Parses an address written as "segment:offset"
+func ParseMemoryAddress(text string) (MemoryAddress, error) {
+	segment, offset, ok := strings.Cut(text, ":")
+	if !ok {
+		return UnknownAddress, fmt.Errorf("invalid address %q: expected segment:offset", text)
+	}
+	segmentIndex, err := strconv.Atoi(segment)
+	if err != nil {
+		return UnknownAddress, fmt.Errorf("invalid address %q: invalid segment: %w", text, err)
+	}
+	address := MemoryAddress{SegmentIndex: segmentIndex}
+	if address.Offset, err = strconv.ParseUint(offset, 10, 64); err != nil {
+		return UnknownAddress, fmt.Errorf("invalid address %q: invalid offset: %w", text, err)
+	}
+	return address, nil
+}
+
 // Stores all posible types that can be stored in a Memory cell,
 //
 //   - either a Felt value (an `f.Element`),
@@ -124,6 +161,23 @@ const (
 
 var UnknownValue = MemoryValue{}
 
+var memoryValuePool = sync.Pool{
+	New: func() any { return new(MemoryValue) },
+}
+
+// Returns an unknown memory value taken from a pool of temporaries. It is meant for
+// hot paths where a value passed by pointer would otherwise escape to the heap, and
+// must be given back with ReleaseMemoryValue once it is no longer referenced. Memory
+// writes copy the value, so it can be released right after being written
+func AcquireMemoryValue() *MemoryValue {
+	return memoryValuePool.Get().(*MemoryValue)
+}
+
+func ReleaseMemoryValue(mv *MemoryValue) {
+	*mv = UnknownValue
+	memoryValuePool.Put(mv)
+}
+
 func MemoryValueFromMemoryAddress(address *MemoryAddress) MemoryValue {
 	v := MemoryValue{
 		Kind: addrMemoryValue,
@@ -351,7 +405,72 @@ func (mv MemoryValue) String() string {
 	if mv.IsAddress() {
 		return mv.addrUnsafe().String()
 	}
-	return mv.Felt.String()
+	return utils.FeltString(&mv.Felt)
+}
+
+// Memory values are written as "segment:offset" when they are addresses and as 0x
+// prefixed hex when they are felts, whatever the felt format of the output. Unknown
+// values are written as an empty text
+func (mv MemoryValue) MarshalText() ([]byte, error) {
+	switch mv.Kind {
+	case addrMemoryValue:
+		return mv.addrUnsafe().MarshalText()
+	case feltMemoryValue:
+		return []byte("0x" + mv.Felt.Text(16)), nil
+	default:
+		return []byte{}, nil
+	}
+}
+
+// Parses a memory value written by MarshalText. Felts may also be decimal
+func (mv *MemoryValue) UnmarshalText(text []byte) error {
+	value, err := ParseMemoryValue(string(text))
+	if err != nil {
+		return err
+	}
+	*mv = value
+	return nil
+}
+
+// Unknown values are written as null in json
+func (mv MemoryValue) MarshalJSON() ([]byte, error) {
+	if !mv.Known() {
+		return []byte("null"), nil
+	}
+	text, _ := mv.MarshalText()
+	return json.Marshal(string(text))
+}
+
+func (mv *MemoryValue) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		*mv = UnknownValue
+		return nil
+	}
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		return fmt.Errorf("memory value: %w", err)
+	}
+	return mv.UnmarshalText([]byte(text))
+}
+
+// Parses a memory value, an address written as "segment:offset" or a felt written in
+// decimal or 0x prefixed hex. An empty text is an unknown value
+func ParseMemoryValue(text string) (MemoryValue, error) {
+	if text == "" {
+		return UnknownValue, nil
+	}
+	if strings.Contains(text, ":") {
+		address, err := ParseMemoryAddress(text)
+		if err != nil {
+			return UnknownValue, err
+		}
+		return MemoryValueFromMemoryAddress(&address), nil
+	}
+	felt, err := new(f.Element).SetString(text)
+	if err != nil {
+		return UnknownValue, fmt.Errorf("invalid memory value %q: %w", text, err)
+	}
+	return MemoryValueFromFieldElement(felt), nil
 }
 
 // Returns a MemoryValue holding a felt as uint if it fits
@@ -371,24 +490,7 @@ func (mv *MemoryValue) addrUnsafe() *MemoryAddress {
 }
 
 func (memory *Memory) GetConsecutiveMemoryValues(addr MemoryAddress, size uint64) ([]MemoryValue, error) {
-	values := make([]MemoryValue, size)
-
-	for i := uint64(0); i < size; i++ {
-		{
-			v, err := memory.ReadFromAddress(&addr)
-			if err != nil {
-				return nil, err
-			}
-			values[i] = v
-		}
-
-		var err error
-		addr, err = addr.AddOffset(int16(1))
-		if err != nil {
-			return nil, err
-		}
-	}
-	return values, nil
+	return memory.ReadRange(&addr, size)
 }
 
 func (memory *Memory) ResolveAsBigInt3(valAddr MemoryAddress) ([3]*f.Element, error) {
@@ -1,6 +1,7 @@
 package memory
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/require"
@@ -150,3 +151,39 @@ func memoryValuePointerFromInt[T constraints.Integer](v T) *MemoryValue {
 	mv := MemoryValueFromInt(v)
 	return &mv
 }
+
+func TestMemoryValueMarshalJSON(t *testing.T) {
+	values := []MemoryValue{
+		MemoryValueFromSegmentAndOffset(2, 15),
+		MemoryValueFromSegmentAndOffset(-1, 3),
+		MemoryValueFromInt(255),
+		MemoryValueFromInt(-1),
+		UnknownValue,
+	}
+	content, err := json.Marshal(values)
+	require.NoError(t, err)
+	require.JSONEq(t, `["2:15", "-1:3", "0xff", "0x800000000000011000000000000000000000000000000000000000000000000", null]`, string(content))
+
+	var decoded []MemoryValue
+	require.NoError(t, json.Unmarshal(content, &decoded))
+	require.Equal(t, values, decoded)
+
+	require.NoError(t, json.Unmarshal([]byte(`["255"]`), &decoded))
+	require.Equal(t, []MemoryValue{MemoryValueFromInt(255)}, decoded)
+	require.ErrorContains(t, json.Unmarshal([]byte(`["2:x"]`), &decoded), "invalid offset")
+	require.ErrorContains(t, json.Unmarshal([]byte(`["seven"]`), &decoded), "invalid memory value")
+}
+
+func TestMemoryAddressMarshalText(t *testing.T) {
+	content, err := json.Marshal(map[string]MemoryAddress{"pc": {SegmentIndex: 0, Offset: 7}})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"pc": "0:7"}`, string(content))
+
+	address, err := ParseMemoryAddress("-2:10")
+	require.NoError(t, err)
+	require.Equal(t, MemoryAddress{SegmentIndex: -2, Offset: 10}, address)
+	_, err = ParseMemoryAddress("10")
+	require.ErrorContains(t, err, "expected segment:offset")
+	_, err = ParseMemoryAddress("a:10")
+	require.ErrorContains(t, err, "invalid segment")
+}
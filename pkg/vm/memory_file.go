@@ -0,0 +1,118 @@
+package vm
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// MemoryFileRecord is an (address, value) pair of a memory file, the format written
+// by --memoryfile and by the Python VM
+type MemoryFileRecord struct {
+	Address uint64
+	Value   f.Element
+}
+
+// DecodeMemoryFile decodes the records of a memory file in the order they are
+// written, duplicates included, so that EncodeMemoryFile gives back the exact same
+// bytes. The reference VM writes some memory holes as a record of NUL bytes, e.g. the
+// unused cells of the builtin segments, which decode as zero while this VM leaves the
+// holes out of the file
+func DecodeMemoryFile(content []byte) ([]MemoryFileRecord, error) {
+	const recordSize = addrSize + feltSize
+	if len(content)%recordSize != 0 {
+		return nil, fmt.Errorf("memory file of %d bytes, which is not a multiple of the %d bytes of a record", len(content), recordSize)
+	}
+	records := make([]MemoryFileRecord, len(content)/recordSize)
+	for i := range records {
+		record := content[i*recordSize : (i+1)*recordSize]
+		records[i].Address = binary.LittleEndian.Uint64(record[:addrSize])
+		value, err := f.LittleEndian.Element((*[feltSize]byte)(record[addrSize:]))
+		if err != nil {
+			return nil, fmt.Errorf("record %d, address %d: %w", i, records[i].Address, err)
+		}
+		records[i].Value = value
+	}
+	return records, nil
+}
+
+// EncodeMemoryFile encodes the records in the order given
+func EncodeMemoryFile(records []MemoryFileRecord) []byte {
+	const recordSize = addrSize + feltSize
+	content := make([]byte, len(records)*recordSize)
+	for i := range records {
+		record := content[i*recordSize : (i+1)*recordSize]
+		binary.LittleEndian.PutUint64(record[:addrSize], records[i].Address)
+		f.LittleEndian.PutElement((*[feltSize]byte)(record[addrSize:]), records[i].Value)
+	}
+	return content
+}
+
+// MemoryFileCells returns the relocated memory of the records, in the form of
+// DecodeMemory. An address written twice with different values is an error
+func MemoryFileCells(records []MemoryFileRecord) ([]*f.Element, error) {
+	var size uint64
+	for i := range records {
+		size = max(size, records[i].Address+1)
+	}
+	memory := make([]*f.Element, size)
+	for i := range records {
+		address := records[i].Address
+		if memory[address] != nil && !memory[address].Equal(&records[i].Value) {
+			return nil, fmt.Errorf("address %d is written twice, with %s then %s", address, memory[address], &records[i].Value)
+		}
+		value := records[i].Value
+		memory[address] = &value
+	}
+	return memory, nil
+}
+
+// MemoryDiff is a cell whose value differs between two memories, a nil value being
+// a hole
+type MemoryDiff struct {
+	Address  uint64
+	Expected *f.Element
+	Actual   *f.Element
+}
+
+func (diff *MemoryDiff) String() string {
+	cell := func(value *f.Element) string {
+		if value == nil {
+			return "hole"
+		}
+		return value.Text(10)
+	}
+	return fmt.Sprintf("address %d: expected %s, got %s", diff.Address, cell(diff.Expected), cell(diff.Actual))
+}
+
+// CompareMemory returns the cells of two relocated memories that differ. Unless
+// strictHoles is set, a hole on one side and a zero on the other are taken as the
+// same cell, the NUL encoding of the hole by the reference VM
+func CompareMemory(expected, actual []*f.Element, strictHoles bool) []MemoryDiff {
+	cell := func(memory []*f.Element, address int) *f.Element {
+		if address < len(memory) {
+			return memory[address]
+		}
+		return nil
+	}
+
+	var diffs []MemoryDiff
+	for address := 0; address < max(len(expected), len(actual)); address++ {
+		e, a := cell(expected, address), cell(actual, address)
+		switch {
+		case e == nil && a == nil:
+			continue
+		case e != nil && a != nil:
+			if e.Equal(a) {
+				continue
+			}
+		case !strictHoles:
+			if (e == nil && a.IsZero()) || (a == nil && e.IsZero()) {
+				continue
+			}
+		}
+		diffs = append(diffs, MemoryDiff{Address: uint64(address), Expected: e, Actual: a})
+	}
+	return diffs
+}
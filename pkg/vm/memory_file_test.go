@@ -0,0 +1,69 @@
+package vm
+
+import (
+	"testing"
+
+	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryFileRoundTrip(t *testing.T) {
+	// unsorted, with a duplicate and a hole written as NUL bytes
+	records := []MemoryFileRecord{
+		{Address: 3, Value: *new(f.Element).SetUint64(7)},
+		{Address: 1, Value: *new(f.Element).SetUint64(5)},
+		{Address: 2, Value: f.Element{}},
+		{Address: 3, Value: *new(f.Element).SetUint64(7)},
+	}
+	content := EncodeMemoryFile(records)
+	require.Len(t, content, 4*(addrSize+feltSize))
+	require.Equal(t, make([]byte, feltSize), content[2*(addrSize+feltSize)+addrSize:3*(addrSize+feltSize)])
+
+	decoded, err := DecodeMemoryFile(content)
+	require.NoError(t, err)
+	require.Equal(t, records, decoded)
+	require.Equal(t, content, EncodeMemoryFile(decoded))
+
+	memory, err := MemoryFileCells(decoded)
+	require.NoError(t, err)
+	require.Equal(t, []*f.Element{nil, new(f.Element).SetUint64(5), {}, new(f.Element).SetUint64(7)}, memory)
+	require.Equal(t, memory, DecodeMemory(content))
+}
+
+func TestMemoryFileErrors(t *testing.T) {
+	_, err := DecodeMemoryFile(make([]byte, addrSize+feltSize+1))
+	require.EqualError(t, err, "memory file of 41 bytes, which is not a multiple of the 40 bytes of a record")
+
+	content := make([]byte, addrSize+feltSize)
+	for i := addrSize; i < len(content); i++ {
+		content[i] = 0xff
+	}
+	_, err = DecodeMemoryFile(content)
+	require.ErrorContains(t, err, "record 0, address 0")
+
+	_, err = MemoryFileCells([]MemoryFileRecord{
+		{Address: 1, Value: *new(f.Element).SetUint64(5)},
+		{Address: 1, Value: *new(f.Element).SetUint64(6)},
+	})
+	require.EqualError(t, err, "address 1 is written twice, with 5 then 6")
+}
+
+func TestCompareMemory(t *testing.T) {
+	felt := func(v uint64) *f.Element {
+		return new(f.Element).SetUint64(v)
+	}
+	expected := []*f.Element{nil, felt(1), felt(0), felt(3), nil, felt(0)}
+	actual := []*f.Element{nil, felt(1), nil, felt(4), felt(5)}
+
+	require.Equal(t, []MemoryDiff{
+		{Address: 3, Expected: felt(3), Actual: felt(4)},
+		{Address: 4, Expected: nil, Actual: felt(5)},
+	}, CompareMemory(expected, actual, false))
+
+	diffs := CompareMemory(expected, actual, true)
+	require.Len(t, diffs, 4)
+	require.Equal(t, "address 2: expected 0, got hole", diffs[0].String())
+	require.Equal(t, "address 5: expected 0, got hole", diffs[3].String())
+
+	require.Empty(t, CompareMemory(expected, expected, true))
+}
@@ -0,0 +1,154 @@
+package vm
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	asmb "github.com/NethermindEth/cairo-vm-go/pkg/assembler"
+	"github.com/NethermindEth/cairo-vm-go/pkg/field"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Words of the state and of the message of a blake2s compression
+const (
+	blakeStateWords   = 8
+	blakeMessageWords = 16
+)
+
+// qm31Operands unpacks the felts of the operands of a qm31 instruction
+func qm31Operands(lhs, rhs *mem.MemoryValue) (field.QM31, field.QM31, error) {
+	if lhs.IsAddress() || rhs.IsAddress() {
+		return field.QM31{}, field.QM31{}, errors.New("qm31 operands cannot be memory addresses")
+	}
+	x, err := field.QM31FromFelt(&lhs.Felt)
+	if err != nil {
+		return field.QM31{}, field.QM31{}, err
+	}
+	y, err := field.QM31FromFelt(&rhs.Felt)
+	if err != nil {
+		return field.QM31{}, field.QM31{}, err
+	}
+	return x, y, nil
+}
+
+// qm31Res computes the res of a qm31 instruction, the sum or the product of its
+// operands over QM31
+func qm31Res(instruction *asmb.Instruction, op0, op1 *mem.MemoryValue) (mem.MemoryValue, error) {
+	x, y, err := qm31Operands(op0, op1)
+	if err != nil {
+		return mem.MemoryValue{}, err
+	}
+	var res field.QM31
+	if instruction.Res == asmb.AddOperands {
+		res.Add(&x, &y)
+	} else {
+		res.Mul(&x, &y)
+	}
+	felt := res.Felt()
+	return mem.MemoryValueFromFieldElement(&felt), nil
+}
+
+// qm31MissingOperand deduces the unknown operand of a qm31 assertion out of dst and
+// the known operand, by subtracting or dividing over QM31
+func qm31MissingOperand(instruction *asmb.Instruction, dst, known *mem.MemoryValue) (mem.MemoryValue, error) {
+	x, y, err := qm31Operands(dst, known)
+	if err != nil {
+		return mem.MemoryValue{}, err
+	}
+	var missing field.QM31
+	if instruction.Res == asmb.AddOperands {
+		missing.Sub(&x, &y)
+	} else {
+		if y.IsZero() {
+			return mem.MemoryValue{}, errors.New("qm31 division by zero")
+		}
+		field.Div(&missing, &x, &y)
+	}
+	felt := missing.Felt()
+	return mem.MemoryValueFromFieldElement(&felt), nil
+}
+
+// runBlake runs the compression of a blake or blake_finalize instruction: dst is
+// the counter of the bytes compressed so far, op0 points to the 8 words of the
+// state, op1 to the 16 words of the message and [ap] to where the new state is
+// written. The finalize variant compresses the last block of the message
+func (vm *VirtualMachine) runBlake(instruction *asmb.Instruction, dstAddr, op0Addr *mem.MemoryAddress, res *mem.MemoryValue) error {
+	counter, err := vm.Memory.ReadFromAddress(dstAddr)
+	if err != nil {
+		return fmt.Errorf("cannot read the counter: %w", err)
+	}
+	t0, err := blakeWord(&counter)
+	if err != nil {
+		return fmt.Errorf("counter: %w", err)
+	}
+
+	statePtr, err := vm.Memory.ReadFromAddress(op0Addr)
+	if err != nil {
+		return fmt.Errorf("cannot read the state pointer: %w", err)
+	}
+	var state [blakeStateWords]uint32
+	if err := vm.readBlakeWords(&statePtr, state[:]); err != nil {
+		return fmt.Errorf("state: %w", err)
+	}
+
+	// res is op1, the message pointer
+	var message [blakeMessageWords]uint32
+	if err := vm.readBlakeWords(res, message[:]); err != nil {
+		return fmt.Errorf("message: %w", err)
+	}
+
+	apAddr := vm.Context.AddressAp()
+	outputPtr, err := vm.Memory.ReadFromAddress(&apAddr)
+	if err != nil {
+		return fmt.Errorf("cannot read the output pointer: %w", err)
+	}
+	output, err := outputPtr.MemoryAddress()
+	if err != nil {
+		return fmt.Errorf("output pointer: %w", err)
+	}
+
+	var f0 uint32
+	if instruction.OpcodeExtension == asmb.OpcodeExtensionBlakeFinalize {
+		f0 = math.MaxUint32
+	}
+	newState := utils.Blake2sCompress(message[:], state, t0, 0, f0, 0)
+	for i := range newState {
+		word := mem.MemoryValueFromUint(newState[i])
+		if err := vm.Memory.Write(output.SegmentIndex, output.Offset+uint64(i), &word); err != nil {
+			return fmt.Errorf("new state: %w", err)
+		}
+	}
+	return nil
+}
+
+// readBlakeWords reads consecutive 32 bits words starting at ptr
+func (vm *VirtualMachine) readBlakeWords(ptr *mem.MemoryValue, words []uint32) error {
+	address, err := ptr.MemoryAddress()
+	if err != nil {
+		return err
+	}
+	for i := range words {
+		value, err := vm.Memory.Read(address.SegmentIndex, address.Offset+uint64(i))
+		if err != nil {
+			return fmt.Errorf("word %d: %w", i, err)
+		}
+		words[i], err = blakeWord(&value)
+		if err != nil {
+			return fmt.Errorf("word %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func blakeWord(value *mem.MemoryValue) (uint32, error) {
+	word, err := value.Uint64()
+	if err != nil {
+		return 0, err
+	}
+	if word > math.MaxUint32 {
+		return 0, fmt.Errorf("%d does not fit in 32 bits", word)
+	}
+	return uint32(word), nil
+}
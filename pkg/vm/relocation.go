@@ -0,0 +1,92 @@
+package vm
+
+import (
+	"fmt"
+	"strconv"
+
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
+)
+
+// The relocation of a frozen segment done in the background while the run goes on.
+// The felts of the segment are ready once the relocation is done, while its
+// addresses wait for the final offsets of the segments to be relocated
+type backgroundRelocation struct {
+	done  chan struct{}
+	data  []mem.MemoryValue
+	felts []*f.Element
+	// offsets of the cells holding an address
+	addresses []uint64
+}
+
+// RelocateInBackground freezes a segment that can't change anymore, such as the
+// program segment, and starts relocating it in the background, so that
+// RelocateMemory only has to place its cells and relocate its addresses once the
+// run is over. When the schedule of the config replays a log, the relocation
+// is only done once RelocateMemory starts
+func (vm *VirtualMachine) RelocateInBackground(segmentIndex int) error {
+	if segmentIndex < 0 || segmentIndex >= len(vm.Memory.Segments) {
+		return fmt.Errorf("cannot relocate segment %d in the background: unallocated", segmentIndex)
+	}
+	if _, ok := vm.backgroundRelocations[segmentIndex]; ok {
+		return nil
+	}
+	segment := vm.Memory.Segments[segmentIndex]
+	segment.Freeze()
+
+	relocation := &backgroundRelocation{
+		done: make(chan struct{}),
+		data: segment.Data[:segment.RealLen()],
+	}
+	if vm.backgroundRelocations == nil {
+		vm.backgroundRelocations = make(map[int]*backgroundRelocation)
+	}
+	vm.backgroundRelocations[segmentIndex] = relocation
+
+	schedule := vm.config.Schedule
+	key := strconv.Itoa(segmentIndex)
+	if schedule.Replaying() {
+		schedule.Queue(vm.relocationDomain, key, func() {
+			relocation.run()
+			close(relocation.done)
+		})
+		return nil
+	}
+	go func() {
+		relocation.run()
+		// logged before RelocateMemory can go on, so the log is complete once it returns
+		schedule.Done(vm.relocationDomain, key)
+		close(relocation.done)
+	}()
+	return nil
+}
+
+func (relocation *backgroundRelocation) run() {
+	relocation.felts = make([]*f.Element, len(relocation.data))
+	for j := range relocation.data {
+		cell := &relocation.data[j]
+		if !cell.Known() {
+			continue
+		}
+		if cell.IsAddress() {
+			relocation.addresses = append(relocation.addresses, uint64(j))
+			continue
+		}
+		relocation.felts[j], _ = cell.FieldElement()
+	}
+}
+
+// Waits for the background relocation and places the cells of the segment in the
+// relocated memory, starting at base
+func (relocation *backgroundRelocation) place(relocatedMemory []*f.Element, base uint64, segmentsOffsets []uint64) {
+	<-relocation.done
+	for j, felt := range relocation.felts {
+		if felt != nil {
+			relocatedMemory[base+uint64(j)] = felt
+		}
+	}
+	for _, j := range relocation.addresses {
+		addr, _ := relocation.data[j].MemoryAddress()
+		relocatedMemory[base+j] = addr.Relocate(segmentsOffsets)
+	}
+}
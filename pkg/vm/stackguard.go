@@ -0,0 +1,226 @@
+package vm
+
+import (
+	"fmt"
+
+	asmb "github.com/NethermindEth/cairo-vm-go/pkg/assembler"
+	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
+)
+
+// Kinds of StackCorruption
+const (
+	ReturnPcOverwritten   = "return pc overwritten"
+	FpChainBroken         = "fp chain broken"
+	BuiltinPtrNotReturned = "builtin pointer not returned"
+)
+
+// ImplicitArg is an implicit argument of a function, such as a builtin pointer,
+// which the function returns before its return values
+type ImplicitArg struct {
+	Name string
+	// the argument is at fp - ArgOffset in the frame of the function
+	ArgOffset uint64
+	// the function returns it at ap - ReturnOffset, ap being the one of its ret
+	ReturnOffset uint64
+}
+
+// Frame is a function call tracked by the stack guard
+type Frame struct {
+	// pc of the called function
+	Function mem.MemoryAddress
+	Fp       uint64
+	// values saved by the call at fp - 2 and fp - 1
+	ReturnFp mem.MemoryValue
+	ReturnPc mem.MemoryValue
+	// values of the implicit arguments received by the call, in the order of
+	// VirtualMachineConfig.ImplicitArgs
+	ImplicitArgs []mem.MemoryValue
+}
+
+// StackCorruption is returned instead of the error of a step which writes over the
+// fp or the return pc saved by a call, or of a ret which doesn't find them. Without
+// the stack guard such a corruption only surfaces once ret jumps to an invalid pc
+type StackCorruption struct {
+	// either ReturnPcOverwritten, FpChainBroken or BuiltinPtrNotReturned
+	Kind  string
+	Frame Frame
+	// given by VirtualMachineConfig.FunctionName, the pc of the function is printed
+	// when it is empty
+	FunctionName string
+	Err          error
+}
+
+func (e *StackCorruption) Error() string {
+	function := e.FunctionName
+	if function == "" {
+		function = "function at pc " + e.Frame.Function.String()
+	}
+	return fmt.Sprintf("%s in the frame of %s with fp %d: %v", e.Kind, function, e.Frame.Fp, e.Err)
+}
+
+func (e *StackCorruption) Unwrap() error {
+	return e.Err
+}
+
+func (vm *VirtualMachine) stackCorruption(kind string, frame *Frame, err error) *StackCorruption {
+	corruption := &StackCorruption{Kind: kind, Frame: *frame, Err: err}
+	if vm.config.FunctionName != nil {
+		corruption.FunctionName = vm.config.FunctionName(frame.Function)
+	}
+	return corruption
+}
+
+// The frame of the entrypoint is set up by the runner rather than by a call
+func (vm *VirtualMachine) initialFrame() {
+	fp := vm.Context.Fp
+	if fp < 2 {
+		return
+	}
+	frame := Frame{Function: vm.Context.Pc, Fp: fp}
+	frame.ReturnFp, _ = vm.Memory.Peek(ExecutionSegment, fp-2)
+	frame.ReturnPc, _ = vm.Memory.Peek(ExecutionSegment, fp-1)
+	if frame.ReturnFp.Known() && frame.ReturnPc.Known() {
+		vm.receiveImplicitArgs(&frame)
+		vm.Frames = append(vm.Frames, frame)
+	}
+}
+
+// Checks the frame a ret is about to leave against the values saved by its call
+func (vm *VirtualMachine) checkReturn() error {
+	if len(vm.Frames) == 0 {
+		return nil
+	}
+	frame := &vm.Frames[len(vm.Frames)-1]
+	if frame.Fp != vm.Context.Fp {
+		return vm.stackCorruption(FpChainBroken, frame, fmt.Errorf("ret with fp %d", vm.Context.Fp))
+	}
+	returnFp, _ := vm.Memory.Peek(ExecutionSegment, frame.Fp-2)
+	if !returnFp.Equal(&frame.ReturnFp) {
+		return vm.stackCorruption(FpChainBroken, frame, fmt.Errorf("saved fp is %s, expected %s", returnFp, frame.ReturnFp))
+	}
+	returnPc, _ := vm.Memory.Peek(ExecutionSegment, frame.Fp-1)
+	if !returnPc.Equal(&frame.ReturnPc) {
+		return vm.stackCorruption(ReturnPcOverwritten, frame, fmt.Errorf("saved return pc is %s, expected %s", returnPc, frame.ReturnPc))
+	}
+	return vm.checkImplicitArgs(frame)
+}
+
+func (vm *VirtualMachine) implicitArgsOf(function *mem.MemoryAddress) []ImplicitArg {
+	if function.SegmentIndex != ProgramSegment {
+		return nil
+	}
+	return vm.config.ImplicitArgs[function.Offset]
+}
+
+func (vm *VirtualMachine) receiveImplicitArgs(frame *Frame) {
+	args := vm.implicitArgsOf(&frame.Function)
+	if len(args) == 0 {
+		return
+	}
+	frame.ImplicitArgs = make([]mem.MemoryValue, len(args))
+	for i := range args {
+		if args[i].ArgOffset <= frame.Fp {
+			frame.ImplicitArgs[i], _ = vm.Memory.Peek(ExecutionSegment, frame.Fp-args[i].ArgOffset)
+		}
+	}
+}
+
+// Checks that the builtin pointers received by the frame are returned moved by
+// whole instances. Implicit arguments which are not builtin pointers are ignored
+func (vm *VirtualMachine) checkImplicitArgs(frame *Frame) error {
+	args := vm.implicitArgsOf(&frame.Function)
+	for i := range frame.ImplicitArgs {
+		received := &frame.ImplicitArgs[i]
+		start, err := received.MemoryAddress()
+		if err != nil || int(start.SegmentIndex) >= len(vm.Memory.Segments) {
+			continue
+		}
+		runner := vm.Memory.Segments[start.SegmentIndex].BuiltinRunner
+		if _, ok := runner.(*mem.NoBuiltin); ok || runner == nil {
+			continue
+		}
+
+		arg := &args[i]
+		var returned mem.MemoryValue
+		if arg.ReturnOffset <= vm.Context.Ap {
+			returned, _ = vm.Memory.Peek(ExecutionSegment, vm.Context.Ap-arg.ReturnOffset)
+		}
+		end, err := returned.MemoryAddress()
+		if err != nil || end.SegmentIndex != start.SegmentIndex || end.Offset < start.Offset {
+			return vm.stackCorruption(BuiltinPtrNotReturned, frame, fmt.Errorf("%s returned as %s, received %s", arg.Name, returned, received))
+		}
+		if cells := runner.GetCellsPerInstance(); cells != 0 && (end.Offset-start.Offset)%cells != 0 {
+			return vm.stackCorruption(BuiltinPtrNotReturned, frame, fmt.Errorf(
+				"%s returned as %s, received %s, which is not a whole number of %s instances", arg.Name, returned, received, runner,
+			))
+		}
+	}
+	return nil
+}
+
+// Tracks the frames once call and ret are executed
+func (vm *VirtualMachine) updateFrames(instruction *asmb.Instruction) {
+	switch instruction.Opcode {
+	case asmb.OpCodeCall:
+		frame := Frame{Function: vm.Context.Pc, Fp: vm.Context.Fp}
+		frame.ReturnFp, _ = vm.Memory.Peek(ExecutionSegment, vm.Context.Fp-2)
+		frame.ReturnPc, _ = vm.Memory.Peek(ExecutionSegment, vm.Context.Fp-1)
+		vm.receiveImplicitArgs(&frame)
+		vm.Frames = append(vm.Frames, frame)
+	case asmb.OpCodeRet:
+		if len(vm.Frames) > 0 {
+			vm.Frames = vm.Frames[:len(vm.Frames)-1]
+		}
+	}
+}
+
+// Turns the error of an instruction writing to a saved fp or return pc into a
+// StackCorruption. A failing call is reported in the frame it would have created
+func (vm *VirtualMachine) diagnoseStackCorruption(instruction *asmb.Instruction, err error) error {
+	dstAddr, dstErr := vm.getDstAddr(instruction)
+	if dstErr != nil {
+		return err
+	}
+
+	if instruction.Opcode == asmb.OpCodeCall {
+		op0Addr, opErr := vm.getOp0Addr(instruction)
+		if opErr != nil {
+			return err
+		}
+		frame := Frame{Fp: vm.Context.Ap + 2}
+		if op1Addr, opErr := vm.getOp1Addr(instruction, &op0Addr); opErr == nil {
+			if res, resErr := vm.computeRes(instruction, &op0Addr, &op1Addr); resErr == nil {
+				frame.Function, _ = vm.updatePc(instruction, &dstAddr, &op1Addr, &res)
+			}
+		}
+		// call saves fp at [ap] then the return pc at [ap + 1]
+		fpAddr := vm.Context.AddressFp()
+		expectedFp := mem.MemoryValueFromMemoryAddress(&fpAddr)
+		if returnFp, _ := vm.Memory.PeekFromAddress(&dstAddr); returnFp.Known() && !returnFp.Equal(&expectedFp) {
+			return vm.stackCorruption(FpChainBroken, &frame, err)
+		}
+		returnAddr := mem.MemoryAddress{
+			SegmentIndex: vm.Context.Pc.SegmentIndex,
+			Offset:       vm.Context.Pc.Offset + uint64(instruction.Size()),
+		}
+		expectedPc := mem.MemoryValueFromMemoryAddress(&returnAddr)
+		if returnPc, _ := vm.Memory.PeekFromAddress(&op0Addr); returnPc.Known() && !returnPc.Equal(&expectedPc) {
+			return vm.stackCorruption(ReturnPcOverwritten, &frame, err)
+		}
+		return err
+	}
+
+	if instruction.Opcode != asmb.OpCodeAssertEq || dstAddr.SegmentIndex != ExecutionSegment {
+		return err
+	}
+	for i := len(vm.Frames) - 1; i >= 0; i-- {
+		frame := &vm.Frames[i]
+		switch dstAddr.Offset {
+		case frame.Fp - 2:
+			return vm.stackCorruption(FpChainBroken, frame, err)
+		case frame.Fp - 1:
+			return vm.stackCorruption(ReturnPcOverwritten, frame, err)
+		}
+	}
+	return err
+}
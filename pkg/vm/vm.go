@@ -3,7 +3,9 @@ package vm
 import (
 	"encoding/binary"
 	"fmt"
+	"maps"
 	"math"
+	"slices"
 
 	asmb "github.com/NethermindEth/cairo-vm-go/pkg/assembler"
 	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
@@ -70,6 +72,13 @@ type Trace struct {
 	Ap uint64
 }
 
+// State of the vm before executing a sampled step
+type Sample struct {
+	Step uint64
+	Pc   mem.MemoryAddress
+	Ap   uint64
+}
+
 // This type represents the current execution context of the vm
 type VirtualMachineConfig struct {
 	// If true, the vm outputs the trace and the relocated memory at the end of execution and finalize segments
@@ -77,6 +86,27 @@ type VirtualMachineConfig struct {
 	ProofMode bool
 	// If true, the vm collects the relocated trace at the end of execution, without finalizing segments
 	CollectTrace bool
+	// If positive, the vm records pc and ap every SampleInterval steps. This is cheap
+	// enough to profile runs far too long to be traced
+	SampleInterval uint64
+	// Opcode extensions allowed by the layout. Instructions using any other extension
+	// fail to decode
+	OpcodeExtensions []asmb.OpcodeExtension
+	// Checked after each step, see Invariant
+	Invariants []Invariant
+	// If true, the vm tracks the frames of the calls and reports writes over their
+	// saved fp and return pc, see StackCorruption
+	StackGuard bool
+	// Names the function starting at a pc in the errors of the stack guard, or
+	// returns an empty string. Optional
+	FunctionName func(pc mem.MemoryAddress) string
+	// Implicit arguments of the functions by offset of their first instruction in
+	// the program. With the stack guard, the ret of one of these functions fails
+	// with a BuiltinPtrNotReturned StackCorruption when the builtin pointers it
+	// received are not returned moved by whole instances. Optional
+	ImplicitArgs map[uint64][]ImplicitArg
+	// Logs or replays the order the background relocations complete in. Optional
+	Schedule *utils.Schedule
 }
 
 type VirtualMachine struct {
@@ -84,12 +114,21 @@ type VirtualMachine struct {
 	Memory  *mem.Memory
 	Step    uint64
 	Trace   []Context
-	config  VirtualMachineConfig
+	Samples []Sample
+	// call stack, only tracked with the stack guard
+	Frames []Frame
+	config VirtualMachineConfig
 	// instructions cache
 	instructions map[uint64]*asmb.Instruction
+	// code segments registered besides the program segment, with their instructions cache
+	codeSegments map[int]map[uint64]*asmb.Instruction
 	// RcLimitsMin and RcLimitsMax define the range of values of instructions offsets, used for checking the number of potential range checks holes
 	RcLimitsMin uint16
 	RcLimitsMax uint16
+	// frozen segments being relocated in the background, by segment index
+	backgroundRelocations map[int]*backgroundRelocation
+	// domain of the background relocations in the schedule of the config
+	relocationDomain string
 }
 
 func (vm *VirtualMachine) PrintMemory(skipBytecode bool) {
@@ -119,7 +158,7 @@ func NewVirtualMachine(
 		trace = make([]Context, 0, 10000000)
 	}
 
-	return &VirtualMachine{
+	vm := &VirtualMachine{
 		Context:      initialContext,
 		Memory:       memory,
 		Trace:        trace,
@@ -127,18 +166,97 @@ func NewVirtualMachine(
 		instructions: make(map[uint64]*asmb.Instruction),
 		RcLimitsMin:  math.MaxUint16,
 		RcLimitsMax:  0,
-	}, nil
+	}
+	vm.relocationDomain = config.Schedule.Domain("relocation")
+	if config.StackGuard {
+		vm.initialFrame()
+	}
+	return vm, nil
+}
+
+// Returns a copy of the VM which can keep running independently from the original one.
+// Memory segments are shared until one of the two VMs writes to them.
+func (vm *VirtualMachine) Fork() *VirtualMachine {
+	var trace []Context
+	if vm.Trace != nil {
+		trace = append(make([]Context, 0, len(vm.Trace)), vm.Trace...)
+	}
+
+	return &VirtualMachine{
+		Context:      vm.Context,
+		Memory:       vm.Memory.Fork(builtins.CloneRunner),
+		Step:         vm.Step,
+		Trace:        trace,
+		Samples:      slices.Clone(vm.Samples),
+		Frames:       slices.Clone(vm.Frames),
+		config:       vm.config,
+		instructions: maps.Clone(vm.instructions),
+		codeSegments: cloneCodeSegments(vm.codeSegments),
+		RcLimitsMin:  vm.RcLimitsMin,
+		RcLimitsMax:  vm.RcLimitsMax,
+		// the copy relocates in the background on its own
+		relocationDomain: vm.config.Schedule.Domain("relocation"),
+	}
+}
+
+func cloneCodeSegments(codeSegments map[int]map[uint64]*asmb.Instruction) map[int]map[uint64]*asmb.Instruction {
+	if codeSegments == nil {
+		return nil
+	}
+	clone := make(map[int]map[uint64]*asmb.Instruction, len(codeSegments))
+	for index, instructions := range codeSegments {
+		clone[index] = maps.Clone(instructions)
+	}
+	return clone
+}
+
+// RegisterCodeSegment allows pc to run through a segment other than the program
+// segment, such as a program loaded while the vm runs
+func (vm *VirtualMachine) RegisterCodeSegment(segmentIndex int) error {
+	if segmentIndex <= ProgramSegment || segmentIndex >= len(vm.Memory.Segments) {
+		return fmt.Errorf("cannot register segment %d as a code segment", segmentIndex)
+	}
+	if vm.codeSegments == nil {
+		vm.codeSegments = make(map[int]map[uint64]*asmb.Instruction)
+	}
+	if _, ok := vm.codeSegments[segmentIndex]; !ok {
+		vm.codeSegments[segmentIndex] = make(map[uint64]*asmb.Instruction)
+	}
+	return nil
+}
+
+// Returns the instructions cache of the code segment pc is in, or an error when pc
+// is out of the bounds of the program and of the registered code segments
+func (vm *VirtualMachine) pcInstructions() (map[uint64]*asmb.Instruction, error) {
+	pc := &vm.Context.Pc
+	instructions := vm.instructions
+	if pc.SegmentIndex != ProgramSegment {
+		var ok bool
+		instructions, ok = vm.codeSegments[pc.SegmentIndex]
+		if !ok {
+			return nil, utils.WithErrorCode(utils.ErrorCodeInvalidPc, fmt.Errorf("pc %s is out of the program segment", pc))
+		}
+	}
+	if size := vm.Memory.Segments[pc.SegmentIndex].Len(); pc.Offset >= size {
+		return nil, utils.WithErrorCode(utils.ErrorCodeInvalidPc, fmt.Errorf("pc %s is past the end of its code segment of size %d", pc, size))
+	}
+	return instructions, nil
 }
 
 func (vm *VirtualMachine) RunStep(hintRunner HintRunner) error {
+	instructions, err := vm.pcInstructions()
+	if err != nil {
+		return err
+	}
+
 	// first run the hint
-	err := hintRunner.RunHint(vm)
+	err = hintRunner.RunHint(vm)
 	if err != nil {
 		return err
 	}
 
 	// if instruction is not in cache, redecode and store it
-	instruction, ok := vm.instructions[vm.Context.Pc.Offset]
+	instruction, ok := instructions[vm.Context.Pc.Offset]
 	if !ok {
 		memoryValue, err := vm.Memory.ReadFromAddress(&vm.Context.Pc)
 		if err != nil {
@@ -154,27 +272,75 @@ func (vm *VirtualMachine) RunStep(hintRunner HintRunner) error {
 		if err != nil {
 			return fmt.Errorf("decoding instruction: %w", err)
 		}
-		vm.instructions[vm.Context.Pc.Offset] = instruction
+		if instruction.OpcodeExtension != asmb.OpcodeExtensionStone &&
+			!slices.Contains(vm.config.OpcodeExtensions, instruction.OpcodeExtension) {
+			return fmt.Errorf("decoding instruction: opcode extension %s is not supported by the layout", instruction.OpcodeExtension)
+		}
+		instructions[vm.Context.Pc.Offset] = instruction
 	}
 
 	// store the trace before state change
 	if vm.config.ProofMode || vm.config.CollectTrace {
 		vm.Trace = append(vm.Trace, vm.Context)
 	}
+	if vm.config.SampleInterval != 0 && vm.Step%vm.config.SampleInterval == 0 {
+		vm.Samples = append(vm.Samples, Sample{Step: vm.Step, Pc: vm.Context.Pc, Ap: vm.Context.Ap})
+	}
+
+	if vm.config.StackGuard && instruction.Opcode == asmb.OpCodeRet {
+		if err := vm.checkReturn(); err != nil {
+			return fmt.Errorf("running instruction: %w", err)
+		}
+	}
 
 	err = vm.RunInstruction(instruction)
 	if err != nil {
+		if vm.config.StackGuard {
+			err = vm.diagnoseStackCorruption(instruction, err)
+		}
 		return fmt.Errorf("running instruction: %w", err)
 	}
+	if vm.config.StackGuard {
+		vm.updateFrames(instruction)
+	}
 
 	vm.Step++
+	if len(vm.config.Invariants) > 0 {
+		return vm.checkInvariants()
+	}
+	return nil
+}
+
+// RunUntil executes steps until the program counter equals `pc`. If `pc` is
+// already the current program counter no step is executed. Up to `maxSteps`
+// steps are run before failing, which protects callers from programs that never
+// reach the given pc.
+func (vm *VirtualMachine) RunUntil(hintRunner HintRunner, pc *mem.MemoryAddress, maxSteps uint64) error {
+	for executed := uint64(0); !vm.Context.Pc.Equal(pc); executed++ {
+		if executed >= maxSteps {
+			return fmt.Errorf("pc %s step %d: pc %s not reached after %d steps", vm.Context.Pc, vm.Step, pc, maxSteps)
+		}
+		if err := vm.RunStep(hintRunner); err != nil {
+			return fmt.Errorf("pc %s step %d: %w", vm.Context.Pc, vm.Step, err)
+		}
+	}
+	return nil
+}
+
+// RunSteps executes exactly `n` steps starting from the current context, unless
+// an error happens first
+func (vm *VirtualMachine) RunSteps(hintRunner HintRunner, n uint64) error {
+	for i := uint64(0); i < n; i++ {
+		if err := vm.RunStep(hintRunner); err != nil {
+			return fmt.Errorf("pc %s step %d: %w", vm.Context.Pc, vm.Step, err)
+		}
+	}
 	return nil
 }
 
 const RC_OFFSET_BITS = 16
 
 func (vm *VirtualMachine) RunInstruction(instruction *asmb.Instruction) error {
-
 	var off0 int = int(instruction.OffDest) + (1 << (RC_OFFSET_BITS - 1))
 	var off1 int = int(instruction.OffOp0) + (1 << (RC_OFFSET_BITS - 1))
 	var off2 int = int(instruction.OffOp1) + (1 << (RC_OFFSET_BITS - 1))
@@ -198,28 +364,39 @@ func (vm *VirtualMachine) RunInstruction(instruction *asmb.Instruction) error {
 		return fmt.Errorf("op1 cell: %w", err)
 	}
 
-	res, err := vm.inferOperand(instruction, &dstAddr, &op0Addr, &op1Addr)
+	// res is needed by pointer and would be heap allocated at every step
+	res := mem.AcquireMemoryValue()
+	defer mem.ReleaseMemoryValue(res)
+
+	*res, err = vm.inferOperand(instruction, &dstAddr, &op0Addr, &op1Addr)
 	if err != nil {
 		return fmt.Errorf("infer res: %w", err)
 	}
 	if !res.Known() {
-		res, err = vm.computeRes(instruction, &op0Addr, &op1Addr)
+		*res, err = vm.computeRes(instruction, &op0Addr, &op1Addr)
 		if err != nil {
 			return fmt.Errorf("compute res: %w", err)
 		}
 	}
 
-	err = vm.opcodeAssertions(instruction, &dstAddr, &op0Addr, &res)
+	err = vm.opcodeAssertions(instruction, &dstAddr, &op0Addr, res)
 	if err != nil {
 		return fmt.Errorf("opcode assertions: %w", err)
 	}
 
-	nextPc, err := vm.updatePc(instruction, &dstAddr, &op1Addr, &res)
+	switch instruction.OpcodeExtension {
+	case asmb.OpcodeExtensionBlake, asmb.OpcodeExtensionBlakeFinalize:
+		if err := vm.runBlake(instruction, &dstAddr, &op0Addr, res); err != nil {
+			return fmt.Errorf("%s: %w", instruction.OpcodeExtension, err)
+		}
+	}
+
+	nextPc, err := vm.updatePc(instruction, &dstAddr, &op1Addr, res)
 	if err != nil {
 		return fmt.Errorf("pc update: %w", err)
 	}
 
-	nextAp, err := vm.updateAp(instruction, &res)
+	nextAp, err := vm.updateAp(instruction, res)
 	if err != nil {
 		return fmt.Errorf("ap update: %w", err)
 	}
@@ -311,8 +488,12 @@ func (vm *VirtualMachine) inferOperand(
 		return mem.MemoryValue{}, nil
 	}
 
+	// values passed by pointer below are taken from the pool, since deductions
+	// happen often enough for their allocations to matter
+	dstValue := mem.AcquireMemoryValue()
+	defer mem.ReleaseMemoryValue(dstValue)
 	// we known dst value is known due to previous check
-	dstValue, _ := vm.Memory.PeekFromAddress(dstAddr)
+	*dstValue, _ = vm.Memory.PeekFromAddress(dstAddr)
 
 	op0Value, err := vm.Memory.PeekFromAddress(op0Addr)
 	if err != nil {
@@ -328,38 +509,42 @@ func (vm *VirtualMachine) inferOperand(
 	}
 
 	if instruction.Res == asmb.Op1 && !op1Value.Known() {
-		if err = vm.Memory.WriteToAddress(op1Addr, &dstValue); err != nil {
+		if err = vm.Memory.WriteToAddress(op1Addr, dstValue); err != nil {
 			return mem.MemoryValue{}, err
 		}
-		return dstValue, nil
+		return *dstValue, nil
 	}
 
-	var knownOpValue mem.MemoryValue
+	knownOpValue := mem.AcquireMemoryValue()
+	defer mem.ReleaseMemoryValue(knownOpValue)
 	var unknownOpAddr *mem.MemoryAddress
 	if op0Value.Known() {
-		knownOpValue = op0Value
+		*knownOpValue = op0Value
 		unknownOpAddr = op1Addr
 	} else {
-		knownOpValue = op1Value
+		*knownOpValue = op1Value
 		unknownOpAddr = op0Addr
 	}
 
-	var missingVal mem.MemoryValue
-	if instruction.Res == asmb.AddOperands {
-		missingVal = mem.EmptyMemoryValueAs(dstValue.IsAddress())
-		err = missingVal.Sub(&dstValue, &knownOpValue)
+	missingVal := mem.AcquireMemoryValue()
+	defer mem.ReleaseMemoryValue(missingVal)
+	if instruction.OpcodeExtension == asmb.OpcodeExtensionQM31 {
+		*missingVal, err = qm31MissingOperand(instruction, dstValue, knownOpValue)
+	} else if instruction.Res == asmb.AddOperands {
+		*missingVal = mem.EmptyMemoryValueAs(dstValue.IsAddress())
+		err = missingVal.Sub(dstValue, knownOpValue)
 	} else {
-		missingVal = mem.EmptyMemoryValueAsFelt()
-		err = missingVal.Div(&dstValue, &knownOpValue)
+		*missingVal = mem.EmptyMemoryValueAsFelt()
+		err = missingVal.Div(dstValue, knownOpValue)
 	}
 	if err != nil {
 		return mem.MemoryValue{}, err
 	}
 
-	if err = vm.Memory.WriteToAddress(unknownOpAddr, &missingVal); err != nil {
+	if err = vm.Memory.WriteToAddress(unknownOpAddr, missingVal); err != nil {
 		return mem.MemoryValue{}, err
 	}
-	return dstValue, nil
+	return *dstValue, nil
 }
 
 func (vm *VirtualMachine) computeRes(
@@ -386,6 +571,10 @@ func (vm *VirtualMachine) computeRes(
 			return mem.MemoryValue{}, fmt.Errorf("cannot read op1: %w", err)
 		}
 
+		if instruction.OpcodeExtension == asmb.OpcodeExtensionQM31 {
+			return qm31Res(instruction, &op0, &op1)
+		}
+
 		res := mem.EmptyMemoryValueAs(op0.IsAddress() || op1.IsAddress())
 		if instruction.Res == asmb.AddOperands {
 			err = res.Add(&op0, &op1)
@@ -406,25 +595,28 @@ func (vm *VirtualMachine) opcodeAssertions(
 ) error {
 	switch instruction.Opcode {
 	case asmb.OpCodeCall:
+		mv := mem.AcquireMemoryValue()
+		defer mem.ReleaseMemoryValue(mv)
+
 		fpAddr := vm.Context.AddressFp()
-		fpMv := mem.MemoryValueFromMemoryAddress(&fpAddr)
+		*mv = mem.MemoryValueFromMemoryAddress(&fpAddr)
 		// Store at [ap] the current fp
-		if err := vm.Memory.WriteToAddress(dstAddr, &fpMv); err != nil {
+		if err := vm.Memory.WriteToAddress(dstAddr, mv); err != nil {
 			return err
 		}
 
-		apMv := mem.MemoryValueFromSegmentAndOffset(
+		*mv = mem.MemoryValueFromSegmentAndOffset(
 			vm.Context.Pc.SegmentIndex,
 			int(vm.Context.Pc.Offset+uint64(instruction.Size())),
 		)
 		// Write in [ap + 1] the next instruction to execute
-		if err := vm.Memory.WriteToAddress(op0Addr, &apMv); err != nil {
+		if err := vm.Memory.WriteToAddress(op0Addr, mv); err != nil {
 			return err
 		}
 	case asmb.OpCodeAssertEq:
 		// assert that the calculated res is stored in dst
 		if err := vm.Memory.WriteToAddress(dstAddr, res); err != nil {
-			return err
+			return utils.WithErrorCode(utils.ErrorCodeUnsatisfiedAssert, err)
 		}
 	}
 	return nil
@@ -563,7 +755,12 @@ func (vm *VirtualMachine) RelocateMemory() ([]*f.Element, []uint64) {
 	// this way we fill relocatedMemory starting from zero, but the actual value
 	// returned has nil as its first element.
 	relocatedMemory := make([]*f.Element, maxMemoryUsed)
+	vm.config.Schedule.Settle(vm.relocationDomain)
 	for i, segment := range vm.Memory.Segments {
+		if relocation, ok := vm.backgroundRelocations[i]; ok {
+			relocation.place(relocatedMemory, segmentsOffsets[i], segmentsOffsets)
+			continue
+		}
 		for j := uint64(0); j < segment.RealLen(); j++ {
 			if !segment.Data[j].Known() {
 				continue
@@ -680,6 +877,10 @@ func DecodeMemory(content []byte) []*f.Element {
 	return memory
 }
 
+// BuiltinsFinalStackFromStackPointerDict reads the final pointer of each builtin,
+// stored in the execution segment right before its stack pointer, and sets it as
+// the builtin stop pointer. The final pointer has to point right after the cells
+// used by the builtin: any other value means the program corrupted it.
 func (vm *VirtualMachine) BuiltinsFinalStackFromStackPointerDict(builtinNameToStackPointer map[builtins.BuiltinType]uint64) error {
 
 	for segmentIndex, segment := range vm.Memory.Segments {
@@ -692,20 +893,20 @@ func (vm *VirtualMachine) BuiltinsFinalStackFromStackPointerDict(builtinNameToSt
 		if !ok {
 			continue
 		}
+		if stackPointer == 0 {
+			return fmt.Errorf("no final pointer for %s on the stack", builtinRunner)
+		}
 		stop_pointer_addr := stackPointer - 1
 		stop_pointer_mv, err := vm.Memory.ReadFromAddress(&mem.MemoryAddress{
-			SegmentIndex: 1,
+			SegmentIndex: ExecutionSegment,
 			Offset:       stop_pointer_addr,
 		})
 		if err != nil {
-			return err
+			return fmt.Errorf("cannot read final pointer of %s: %w", builtinRunner, err)
 		}
 		stop_pointer, err := stop_pointer_mv.MemoryAddress()
 		if err != nil {
-			return err
-		}
-		if stop_pointer.SegmentIndex != segmentIndex {
-			return fmt.Errorf("stop pointer segment index mismatch")
+			return fmt.Errorf("invalid stop pointer for %s: %w", builtinRunner, err)
 		}
 		stopPointerOffset := stop_pointer.Offset
 		var used uint64
@@ -718,8 +919,9 @@ func (vm *VirtualMachine) BuiltinsFinalStackFromStackPointerDict(builtinNameToSt
 			}
 			used = numInstances * builtinRunner.GetCellsPerInstance()
 		}
-		if stopPointerOffset != used {
-			return fmt.Errorf("stop pointer offset mismatch")
+		if stop_pointer.SegmentIndex != segmentIndex || stopPointerOffset != used {
+			expected := mem.MemoryAddress{SegmentIndex: segmentIndex, Offset: used}
+			return fmt.Errorf("invalid stop pointer for %s: expected %s, found %s", builtinRunner, expected, stop_pointer)
 		}
 		builtinRunner.SetStopPointer(stopPointerOffset)
 	}
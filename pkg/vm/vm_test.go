@@ -2,6 +2,7 @@ package vm
 
 import (
 	"encoding/binary"
+	"math/big"
 	"testing"
 
 	f "github.com/consensys/gnark-crypto/ecc/stark-curve/fp"
@@ -9,6 +10,9 @@ import (
 	"github.com/stretchr/testify/require"
 
 	a "github.com/NethermindEth/cairo-vm-go/pkg/assembler"
+	"github.com/NethermindEth/cairo-vm-go/pkg/field"
+	"github.com/NethermindEth/cairo-vm-go/pkg/utils"
+	"github.com/NethermindEth/cairo-vm-go/pkg/vm/builtins"
 	mem "github.com/NethermindEth/cairo-vm-go/pkg/vm/memory"
 )
 
@@ -652,6 +656,22 @@ func TestOpcodeAssertionAssertEq(t *testing.T) {
 	assert.Equal(t, res, op0Value)
 }
 
+func TestOpcodeAssertionAssertEqFails(t *testing.T) {
+	vm := DefaultVirtualMachine()
+	dstAddr := writeToDataSegment(vm, 0, 3)
+
+	instruction := a.Instruction{
+		Opcode: a.OpCodeAssertEq,
+	}
+
+	res := mem.MemoryValueFromInt(4)
+	err := vm.opcodeAssertions(&instruction, &dstAddr, nil, &res)
+	require.ErrorContains(t, err, "rewriting value: old value: 3, new value: 4")
+	code, ok := utils.ErrorCodeOf(err)
+	require.True(t, ok)
+	assert.Equal(t, utils.ErrorCodeUnsatisfiedAssert, code)
+}
+
 func TestUpdatePcNextInstr(t *testing.T) {
 	vm := DefaultVirtualMachine()
 
@@ -1129,6 +1149,125 @@ func TestRunStepInstructions(t *testing.T) {
 	})
 }
 
+func TestRunSteps(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+		[ap] = 2, ap++;
+		[ap] = 3, ap++;
+	`)
+	vm.Context.Ap = 1
+	vm.Context.Fp = 1
+
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 2))
+	assert.Equal(t, uint64(2), vm.Step)
+	assert.Equal(t, uint64(3), vm.Context.Ap)
+	assert.Equal(t, mem.MemoryAddress{SegmentIndex: ProgramSegment, Offset: 4}, vm.Context.Pc)
+
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 1))
+	assert.Equal(t, uint64(3), vm.Step)
+}
+
+func TestPcBounds(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+	`)
+	vm.Context.Ap = 1
+	vm.Context.Fp = 1
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 1))
+	err := vm.RunStep(&noHintRunner{})
+	require.ErrorContains(t, err, "pc 0:2 is past the end of its code segment of size 2")
+	code, ok := utils.ErrorCodeOf(err)
+	require.True(t, ok)
+	require.Equal(t, utils.ErrorCodeInvalidPc, code)
+
+	// the instruction cache of the program doesn't apply to other segments
+	bytecode, _, err := a.CasmToBytecode("[ap] = 1, ap++;")
+	require.NoError(t, err)
+	loaded, err := vm.Memory.AllocateSegment(bytecode)
+	require.NoError(t, err)
+	vm.Context.Pc = mem.MemoryAddress{SegmentIndex: loaded.SegmentIndex, Offset: 0}
+	require.ErrorContains(t, vm.RunStep(&noHintRunner{}), "pc 2:0 is out of the program segment")
+
+	require.ErrorContains(t, vm.RegisterCodeSegment(ProgramSegment), "cannot register segment 0")
+	require.ErrorContains(t, vm.RegisterCodeSegment(3), "cannot register segment 3")
+	require.NoError(t, vm.RegisterCodeSegment(loaded.SegmentIndex))
+	require.NoError(t, vm.RunStep(&noHintRunner{}))
+	assert.Equal(t, mem.MemoryAddress{SegmentIndex: loaded.SegmentIndex, Offset: 2}, vm.Context.Pc)
+	assert.Equal(t, mem.MemoryValueFromInt(1), vm.Memory.Segments[ExecutionSegment].Peek(2))
+}
+
+func TestFork(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+		[ap] = 2, ap++;
+	`)
+	vm.Context.Ap = 1
+	vm.Context.Fp = 1
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 1))
+
+	fork := vm.Fork()
+	value := mem.MemoryValueFromInt(7)
+	require.NoError(t, fork.Memory.Write(ExecutionSegment, 5, &value))
+	require.NoError(t, fork.RunSteps(&noHintRunner{}, 1))
+
+	// the original vm is unaffected by the fork execution
+	assert.Equal(t, uint64(1), vm.Step)
+	assert.Equal(t, mem.UnknownValue, vm.Memory.Segments[ExecutionSegment].Peek(2))
+	assert.Equal(t, mem.UnknownValue, vm.Memory.Segments[ExecutionSegment].Peek(5))
+
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 1))
+	assert.Equal(t, uint64(2), fork.Step)
+	assert.Equal(t, mem.MemoryValueFromInt(2), fork.Memory.Segments[ExecutionSegment].Peek(2))
+	assert.Equal(t, mem.MemoryValueFromInt(2), vm.Memory.Segments[ExecutionSegment].Peek(2))
+	assert.Equal(t, mem.MemoryValueFromInt(1), vm.Memory.Segments[ExecutionSegment].Peek(1))
+}
+
+func TestForkSegmentArena(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+	`)
+	arena := vm.Memory.AllocateBuiltinSegment(&builtins.SegmentArena{})
+	info := vm.Memory.AllocateEmptySegment()
+	infoPtr := mem.MemoryValueFromMemoryAddress(&info)
+	require.NoError(t, vm.Memory.WriteToAddress(&arena, &infoPtr))
+
+	fork := vm.Fork()
+	forkRunner := fork.Memory.Segments[arena.SegmentIndex].BuiltinRunner
+	require.IsType(t, &builtins.SegmentArena{}, forkRunner)
+	assert.NotSame(t, vm.Memory.Segments[arena.SegmentIndex].BuiltinRunner, forkRunner)
+
+	// the fork keeps checking the writes to the arena, without affecting the original
+	dicts := mem.MemoryValueFromInt(1)
+	require.NoError(t, fork.Memory.Write(arena.SegmentIndex, 1, &dicts))
+	require.ErrorContains(t, fork.Memory.Write(arena.SegmentIndex, 3, &dicts), "expected the info segment pointer")
+	assert.Equal(t, mem.UnknownValue, vm.Memory.Segments[arena.SegmentIndex].Peek(1))
+}
+
+func TestRunUntil(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+		[ap] = 2, ap++;
+		jmp rel 0;
+	`)
+	vm.Context.Ap = 1
+	vm.Context.Fp = 1
+
+	// the target pc is the current one, nothing is executed
+	start := vm.Context.Pc
+	require.NoError(t, vm.RunUntil(&noHintRunner{}, &start, 10))
+	assert.Equal(t, uint64(0), vm.Step)
+
+	target := mem.MemoryAddress{SegmentIndex: ProgramSegment, Offset: 4}
+	require.NoError(t, vm.RunUntil(&noHintRunner{}, &target, 10))
+	assert.Equal(t, uint64(2), vm.Step)
+	assert.Equal(t, target, vm.Context.Pc)
+
+	// the infinite loop never reaches the start of the program again
+	err := vm.RunUntil(&noHintRunner{}, &start, 5)
+	require.ErrorContains(t, err, "not reached after 5 steps")
+	assert.Equal(t, uint64(7), vm.Step)
+}
+
 // ======================
 // Test Memory Relocation
 // ======================
@@ -1245,6 +1384,40 @@ func TestMemoryRelocationWithAddress(t *testing.T) {
 	require.Equal(t, expected, res)
 }
 
+func TestMemoryRelocationInBackground(t *testing.T) {
+	writes := []memoryWrite{
+		{0, 1, uint64(1)},
+		{0, 3, &mem.MemoryAddress{SegmentIndex: 1, Offset: 5}},
+		{1, 0, uint64(1)},
+		{1, 1, &mem.MemoryAddress{SegmentIndex: 3, Offset: 3}},
+		{2, 0, &mem.MemoryAddress{SegmentIndex: 0, Offset: 1}},
+		{3, 0, &mem.MemoryAddress{SegmentIndex: 2, Offset: 0}},
+		{3, 1, uint64(15)},
+	}
+	// segment 1 grows after the other segments are frozen, which moves the
+	// relocated addresses of the frozen segments
+	later := []memoryWrite{{1, 7, uint64(13)}}
+
+	vm := DefaultVirtualMachine()
+	updateMemoryWithValues(vm.Memory, writes)
+	updateMemoryWithValues(vm.Memory, later)
+	expected, expectedOffsets := vm.RelocateMemory()
+
+	vm = DefaultVirtualMachine()
+	updateMemoryWithValues(vm.Memory, writes)
+	for _, segment := range []int{0, 2, 3} {
+		require.NoError(t, vm.RelocateInBackground(segment))
+	}
+	require.EqualError(t, vm.RelocateInBackground(100), "cannot relocate segment 100 in the background: unallocated")
+	updateMemoryWithValues(vm.Memory, later)
+	value := mem.MemoryValueFromUint(uint64(2))
+	require.ErrorContains(t, vm.Memory.Write(0, 1, &value), "rewriting value")
+
+	relocated, offsets := vm.RelocateMemory()
+	require.Equal(t, expectedOffsets, offsets)
+	require.Equal(t, expected, relocated)
+}
+
 // ==============================
 // Test Trace and Memory Encoding
 // ==============================
@@ -1289,6 +1462,30 @@ func TestTraceEncodingDecoding(t *testing.T) {
 
 }
 
+func TestCallSubtrees(t *testing.T) {
+	// main (fp 10) calls f twice, f recursing once in its first call. f starts at
+	// pc 20 and loops back to it with a jump
+	trace := []Trace{
+		{Pc: 1, Fp: 10, Ap: 10},
+		{Pc: 20, Fp: 13, Ap: 13},
+		{Pc: 20, Fp: 13, Ap: 14},
+		{Pc: 20, Fp: 16, Ap: 16},
+		{Pc: 21, Fp: 16, Ap: 16},
+		{Pc: 22, Fp: 13, Ap: 17},
+		{Pc: 2, Fp: 10, Ap: 18},
+		{Pc: 20, Fp: 20, Ap: 20},
+		{Pc: 21, Fp: 20, Ap: 20},
+		{Pc: 3, Fp: 10, Ap: 21},
+	}
+
+	calls := CallSubtrees(trace, 20)
+	require.Equal(t, [][]Trace{trace[1:6], trace[7:9]}, calls)
+
+	// the entrypoint spans the whole trace
+	require.Equal(t, [][]Trace{trace}, CallSubtrees(trace, 1))
+	require.Empty(t, CallSubtrees(trace, 30))
+}
+
 func TestMemoryEncodingDecoding(t *testing.T) {
 	memory := []*f.Element{
 		new(f.Element).SetUint64(4),
@@ -1339,6 +1536,193 @@ func TestMemoryEncodingDecoding(t *testing.T) {
 	)
 }
 
+func TestOpcodeExtensionSupport(t *testing.T) {
+	bytecode, _, err := a.CasmToBytecode("[ap] = [fp + 1] + [fp + 2];")
+	require.NoError(t, err)
+	instruction := new(f.Element).SetBigInt(new(big.Int).Lsh(big.NewInt(int64(a.OpcodeExtensionQM31)), 63))
+	instruction.Add(instruction, bytecode[0])
+
+	vm := defaultVirtualMachineWithBytecode([]*f.Element{instruction})
+	err = vm.RunStep(&noHintRunner{})
+	require.ErrorContains(t, err, "opcode extension qm31_operation is not supported by the layout")
+
+	vm = defaultVirtualMachineWithBytecode([]*f.Element{instruction})
+	vm.config.OpcodeExtensions = []a.OpcodeExtension{a.OpcodeExtensionQM31}
+	vm.Context.Ap = 3
+	x := field.QM31{field.M31Modulus - 1, 2, 3, 4}
+	y := field.QM31{5, 6, 7, 8}
+	writeToDataSegment(vm, 1, qm31Felt(x))
+	writeToDataSegment(vm, 2, qm31Felt(y))
+	require.NoError(t, vm.RunStep(&noHintRunner{}))
+	require.Equal(t, a.OpcodeExtensionQM31, vm.instructions[0].OpcodeExtension)
+	// the sum is over QM31, the first coordinate wraps around
+	sum, err := vm.Memory.ReadAsElement(ExecutionSegment, 3)
+	require.NoError(t, err)
+	expected := field.QM31{4, 8, 10, 12}
+	require.Equal(t, expected.Felt(), sum)
+}
+
+func qm31Felt(z field.QM31) *f.Element {
+	felt := z.Felt()
+	return &felt
+}
+
+// Builds the instruction of the given casm with an opcode extension
+func extendedInstruction(t *testing.T, code string, extension a.OpcodeExtension) *f.Element {
+	t.Helper()
+	bytecode, _, err := a.CasmToBytecode(code)
+	require.NoError(t, err)
+	instruction := new(f.Element).SetBigInt(new(big.Int).Lsh(big.NewInt(int64(extension)), 63))
+	return instruction.Add(instruction, bytecode[0])
+}
+
+func TestQM31Opcode(t *testing.T) {
+	x := field.QM31{1, 2, 3, field.M31Modulus - 1}
+	y := field.QM31{5, 0, 7, 8}
+	var sum, product field.QM31
+	sum.Add(&x, &y)
+	product.Mul(&x, &y)
+
+	testCases := []struct {
+		name string
+		code string
+		// values of [fp], [fp + 1] and [fp + 2], nil when unknown
+		cells [3]*field.QM31
+		// the value of [fp], [fp + 1] and [fp + 2] after the step
+		expected [3]field.QM31
+	}{
+		{name: "add", code: "[fp] = [fp + 1] + [fp + 2];", cells: [3]*field.QM31{nil, &x, &y}, expected: [3]field.QM31{sum, x, y}},
+		{name: "mul", code: "[fp] = [fp + 1] * [fp + 2];", cells: [3]*field.QM31{nil, &x, &y}, expected: [3]field.QM31{product, x, y}},
+		{name: "sub", code: "[fp] = [fp + 1] + [fp + 2];", cells: [3]*field.QM31{&sum, nil, &y}, expected: [3]field.QM31{sum, x, y}},
+		{name: "div", code: "[fp] = [fp + 1] * [fp + 2];", cells: [3]*field.QM31{&product, &x, nil}, expected: [3]field.QM31{product, x, y}},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			vm := defaultVirtualMachineWithBytecode([]*f.Element{extendedInstruction(t, tc.code, a.OpcodeExtensionQM31)})
+			vm.config.OpcodeExtensions = []a.OpcodeExtension{a.OpcodeExtensionQM31}
+			for i, cell := range tc.cells {
+				if cell != nil {
+					writeToDataSegment(vm, uint64(i), qm31Felt(*cell))
+				}
+			}
+			require.NoError(t, vm.RunStep(&noHintRunner{}))
+			for i := range tc.expected {
+				value, err := vm.Memory.ReadAsElement(ExecutionSegment, uint64(i))
+				require.NoError(t, err)
+				require.Equal(t, tc.expected[i].Felt(), value)
+			}
+		})
+	}
+
+	// operands must be packed with reduced coordinates
+	vm := defaultVirtualMachineWithBytecode([]*f.Element{extendedInstruction(t, "[fp] = [fp + 1] + [fp + 2];", a.OpcodeExtensionQM31)})
+	vm.config.OpcodeExtensions = []a.OpcodeExtension{a.OpcodeExtensionQM31}
+	writeToDataSegment(vm, 1, qm31Felt(x))
+	writeToDataSegment(vm, 2, uint64(field.M31Modulus))
+	require.ErrorContains(t, vm.RunStep(&noHintRunner{}), "is not a packed qm31: coordinate 0 is 2147483647, not reduced modulo 2**31 - 1")
+
+	vm = defaultVirtualMachineWithBytecode([]*f.Element{extendedInstruction(t, "[fp] = [fp + 1] * [fp + 2];", a.OpcodeExtensionQM31)})
+	vm.config.OpcodeExtensions = []a.OpcodeExtension{a.OpcodeExtensionQM31}
+	writeToDataSegment(vm, 0, qm31Felt(x))
+	writeToDataSegment(vm, 1, uint64(0))
+	require.ErrorContains(t, vm.RunStep(&noHintRunner{}), "qm31 division by zero")
+}
+
+func TestBlakeOpcode(t *testing.T) {
+	// dst at fp is the counter, op0 at fp + 1 the state pointer and op1 at fp + 2 the
+	// message pointer, with a regular pc update and ap++
+	encoding := uint64(0x8000) | uint64(0x8001)<<16 | uint64(0x8002)<<32 | uint64(0x80B)<<48
+	blake2sOfAbc := []uint32{0x8C5E8C50, 0xE2147C32, 0xA32BA7E1, 0x2F45EB4E, 0x208B4537, 0x293AD69E, 0x4C9B994D, 0x82596786}
+
+	run := func(t *testing.T, extension a.OpcodeExtension, message []uint32) (*VirtualMachine, []uint32, error) {
+		instruction := new(f.Element).SetBigInt(new(big.Int).Lsh(big.NewInt(int64(extension)), 63))
+		instruction.Add(instruction, new(f.Element).SetUint64(encoding))
+		vm := defaultVirtualMachineWithBytecode([]*f.Element{instruction})
+		vm.config.OpcodeExtensions = []a.OpcodeExtension{extension}
+		vm.Context.Ap = 10
+
+		// the initial state of blake2s-256 without a key
+		iv := utils.IV()
+		iv[0] ^= 0x01010020
+		var state, data []*f.Element
+		for _, word := range iv {
+			state = append(state, new(f.Element).SetUint64(uint64(word)))
+		}
+		for _, word := range message {
+			data = append(data, new(f.Element).SetUint64(uint64(word)))
+		}
+		statePtr, err := vm.Memory.AllocateSegment(state)
+		require.NoError(t, err)
+		messagePtr, err := vm.Memory.AllocateSegment(data)
+		require.NoError(t, err)
+		outputPtr := vm.Memory.AllocateEmptySegment()
+		// "abc" is 3 bytes long
+		writeToDataSegment(vm, 0, uint64(3))
+		writeToDataSegment(vm, 1, &statePtr)
+		writeToDataSegment(vm, 2, &messagePtr)
+		writeToDataSegment(vm, 10, &outputPtr)
+
+		if err := vm.RunStep(&noHintRunner{}); err != nil {
+			return vm, nil, err
+		}
+		var output []uint32
+		for i := uint64(0); i < 8; i++ {
+			word, err := vm.Memory.ReadAsElement(outputPtr.SegmentIndex, outputPtr.Offset+i)
+			require.NoError(t, err)
+			output = append(output, uint32(word.Uint64()))
+		}
+		return vm, output, nil
+	}
+
+	abc := make([]uint32, 16)
+	abc[0] = 0x00636261
+	vm, output, err := run(t, a.OpcodeExtensionBlakeFinalize, abc)
+	require.NoError(t, err)
+	require.Equal(t, blake2sOfAbc, output)
+	require.Equal(t, uint64(1), vm.Context.Pc.Offset)
+	require.Equal(t, uint64(11), vm.Context.Ap)
+
+	// the same block is not the last one of the message
+	_, output, err = run(t, a.OpcodeExtensionBlake, abc)
+	require.NoError(t, err)
+	iv := utils.IV()
+	iv[0] ^= 0x01010020
+	require.Equal(t, utils.Blake2sCompress(abc, iv, 3, 0, 0, 0), output)
+	require.NotEqual(t, blake2sOfAbc, output)
+
+	// the message misses its last word
+	_, _, err = run(t, a.OpcodeExtensionBlake, abc[:15])
+	require.ErrorContains(t, err, "blake: message: word 15")
+}
+
+func TestBuiltinsFinalStack(t *testing.T) {
+	newVm := func(finalPointer any) *VirtualMachine {
+		vm := DefaultVirtualMachine()
+		rangeCheck := vm.Memory.AllocateBuiltinSegment(&builtins.RangeCheck{RangeCheckNParts: 8})
+		for i := uint64(0); i < 3; i++ {
+			value := mem.MemoryValueFromUint(i)
+			require.NoError(t, vm.Memory.Write(rangeCheck.SegmentIndex, i, &value))
+		}
+		writeToDataSegment(vm, 4, finalPointer)
+		return vm
+	}
+	stackPointers := map[builtins.BuiltinType]uint64{builtins.RangeCheckType: 5}
+
+	vm := newVm(&mem.MemoryAddress{SegmentIndex: 2, Offset: 3})
+	require.NoError(t, vm.BuiltinsFinalStackFromStackPointerDict(stackPointers))
+	require.Equal(t, uint64(3), vm.Memory.Segments[2].BuiltinRunner.GetStopPointer())
+
+	vm = newVm(&mem.MemoryAddress{SegmentIndex: 2, Offset: 2})
+	require.EqualError(t, vm.BuiltinsFinalStackFromStackPointerDict(stackPointers), "invalid stop pointer for range_check: expected 2:3, found 2:2")
+
+	vm = newVm(&mem.MemoryAddress{SegmentIndex: ExecutionSegment, Offset: 3})
+	require.EqualError(t, vm.BuiltinsFinalStackFromStackPointerDict(stackPointers), "invalid stop pointer for range_check: expected 2:3, found 1:3")
+
+	vm = newVm(3)
+	require.ErrorContains(t, vm.BuiltinsFinalStackFromStackPointerDict(stackPointers), "invalid stop pointer for range_check")
+}
+
 // ==============
 // Util Functions
 // ==============
@@ -1394,3 +1778,22 @@ func updateMemoryWithValues(memory *mem.Memory, valuesToWrite []memoryWrite) {
 
 	}
 }
+
+func TestSampling(t *testing.T) {
+	vm := defaultVirtualMachineWithCode(`
+		[ap] = 1, ap++;
+		jmp rel 0;
+	`)
+	vm.Context.Ap = 1
+	vm.Context.Fp = 1
+	vm.config.SampleInterval = 3
+
+	require.NoError(t, vm.RunSteps(&noHintRunner{}, 7))
+	loop := mem.MemoryAddress{SegmentIndex: ProgramSegment, Offset: 2}
+	assert.Equal(t, []Sample{
+		{Step: 0, Pc: mem.MemoryAddress{SegmentIndex: ProgramSegment, Offset: 0}, Ap: 1},
+		{Step: 3, Pc: loop, Ap: 2},
+		{Step: 6, Pc: loop, Ap: 2},
+	}, vm.Samples)
+	assert.Nil(t, vm.Trace)
+}